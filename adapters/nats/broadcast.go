@@ -0,0 +1,166 @@
+package nats
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/messaging"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+)
+
+// broadcastBuffer is the channel buffer of a broadcast subscription.
+const broadcastBuffer = 16
+
+// Broadcast publishes msg on core NATS, outside the stream, to every
+// broadcast subscriber of its name in the group (messaging.Broadcaster).
+// Nothing is stored: a broadcast nobody listens to is gone. The MessageID
+// travels along for the receiver to deduplicate — core NATS doesn't.
+func (b *Broker) Broadcast(ctx context.Context, msg messaging.Envelope) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if msg.Name == "" {
+		return errs.New(
+			errs.M("Broadcast: an empty message name isn't allowed"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	body, err := json.Marshal(msg.Payload)
+	if err != nil {
+		return errs.New(
+			errs.M("Broadcast: the payload isn't JSON-encodable"),
+			errs.C(errorClass, errs.InvalidParameter),
+			errs.D(observability.AttrMessageName, msg.Name),
+			errs.E(err))
+	}
+
+	m := nats.NewMsg(b.broadcastSubject(msg.Name))
+	m.Data = body
+	m.Header.Set(HeaderMessageName, msg.Name)
+
+	if msg.MessageID != "" {
+		m.Header.Set(jetstream.MsgIDHeader, msg.MessageID)
+	}
+
+	if err := b.js.Conn().PublishMsg(m); err != nil {
+		return errs.New(
+			errs.M("Broadcast: the message couldn't be published"),
+			errs.C(errorClass, errs.OperationFailed),
+			errs.D(observability.AttrMessageName, msg.Name),
+			errs.E(err))
+	}
+
+	b.logger.Debug("nats: broadcast", observability.AttrMessageName, msg.Name)
+
+	return nil
+}
+
+// SubscribeBroadcast subscribes to the group's broadcasts named name
+// (messaging.Broadcaster). A broadcast arriving while the channel is full
+// is dropped with a warning; AddKey is rejected.
+func (b *Broker) SubscribeBroadcast(
+	ctx context.Context, name string,
+) (messaging.Subscription, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if name == "" {
+		return nil, errs.New(
+			errs.M("SubscribeBroadcast: an empty message name isn't allowed"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	s := &broadcastSub{
+		ch:   make(chan messaging.Envelope, broadcastBuffer),
+		name: name,
+	}
+
+	sub, err := b.js.Conn().Subscribe(b.broadcastSubject(name), func(m *nats.Msg) {
+		env, err := decodeParts(m.Subject, m.Header, m.Data)
+		if err != nil {
+			b.logger.Warn("nats: undecodable broadcast dropped",
+				"subject", m.Subject, observability.AttrError, err.Error())
+
+			return
+		}
+
+		select {
+		case s.ch <- env:
+		default:
+			b.logger.Warn("nats: broadcast subscriber busy; broadcast missed",
+				observability.AttrMessageName, env.Name, observability.AttrMessageID, env.MessageID)
+		}
+	})
+	if err != nil {
+		return nil, errs.New(
+			errs.M("SubscribeBroadcast: the subject couldn't be subscribed"),
+			errs.C(errorClass, errs.OperationFailed),
+			errs.D(observability.AttrMessageName, name),
+			errs.E(err))
+	}
+
+	s.sub = sub
+
+	// the round trip makes the server hold the interest before we return,
+	// so a broadcast made after the call reaches this subscriber.
+	if err := b.js.Conn().Flush(); err != nil {
+		_ = sub.Unsubscribe()
+
+		return nil, errs.New(
+			errs.M("SubscribeBroadcast: the server didn't confirm the subscription"),
+			errs.C(errorClass, errs.OperationFailed),
+			errs.D(observability.AttrMessageName, name),
+			errs.E(err))
+	}
+
+	b.logger.Debug("nats: broadcast subscribed", observability.AttrMessageName, name)
+
+	return s, nil
+}
+
+// broadcastSubject is the core NATS subject of the group's broadcasts
+// named name. Its first tokens differ from the stream's, so the stream
+// never captures a broadcast.
+func (b *Broker) broadcastSubject(name string) string {
+	return b.prefix + "_bcast." + token(b.group) + "." + token(name)
+}
+
+// broadcastSub is a live core NATS subscription to broadcasts.
+type broadcastSub struct {
+	sub  *nats.Subscription
+	ch   chan messaging.Envelope
+	name string
+	once sync.Once
+}
+
+// C returns the channel of the broadcasts.
+func (s *broadcastSub) C() <-chan messaging.Envelope { return s.ch }
+
+// AddKey is rejected: a broadcast has no conversation.
+func (s *broadcastSub) AddKey(string) error {
+	return errs.New(
+		errs.M("nats.AddKey: a broadcast subscription takes no keys"),
+		errs.C(errorClass, errs.InvalidParameter),
+		errs.D(observability.AttrMessageName, s.name))
+}
+
+// Unsubscribe ends the subscription. Idempotent.
+func (s *broadcastSub) Unsubscribe() error {
+	var err error
+
+	s.once.Do(func() { err = s.sub.Unsubscribe() })
+
+	return err
+}
+
+var (
+	_ messaging.Broadcaster  = (*Broker)(nil)
+	_ messaging.Subscription = (*broadcastSub)(nil)
+)
@@ -0,0 +1,222 @@
+package nats
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/messaging"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+)
+
+// noKeyToken is the subject token of a message without a correlation key.
+// base64url never encodes a non-empty string to a single character, so it
+// can't collide with a real key.
+const noKeyToken = "_"
+
+// minTTL is the shortest per-message TTL JetStream accepts; a shorter
+// Envelope.TTL is rounded up to it.
+const minTTL = time.Second
+
+// Publish stores msg in the stream under its name's subject. A MessageID
+// the stream already took inside its duplicate window is dropped by the
+// server and reported as published — the first copy is the one delivered.
+func (b *Broker) Publish(ctx context.Context, msg messaging.Envelope) error {
+	if msg.Name == "" {
+		return errs.New(
+			errs.M("Publish: an empty message name isn't allowed"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	body, err := json.Marshal(msg.Payload)
+	if err != nil {
+		return errs.New(
+			errs.M("Publish: the payload isn't JSON-encodable"),
+			errs.C(errorClass, errs.InvalidParameter),
+			errs.D(observability.AttrMessageName, msg.Name),
+			errs.E(err))
+	}
+
+	m := nats.NewMsg(b.subject(msg.Name, msg.CorrelationKey))
+	m.Data = body
+	m.Header.Set(HeaderMessageName, msg.Name)
+
+	if msg.CorrelationKey != "" {
+		m.Header.Set(HeaderCorrelationKey, msg.CorrelationKey)
+	}
+
+	var popts []jetstream.PublishOpt
+	if msg.MessageID != "" {
+		popts = append(popts, jetstream.WithMsgID(msg.MessageID))
+	}
+
+	if msg.TTL > 0 {
+		popts = append(popts, jetstream.WithMsgTTL(max(msg.TTL, minTTL)))
+	}
+
+	ack, err := b.js.PublishMsg(ctx, m, popts...)
+	if err != nil {
+		return errs.New(
+			errs.M("Publish: the stream didn't take the message"),
+			errs.C(errorClass, errs.OperationFailed),
+			errs.D(observability.AttrMessageName, msg.Name),
+			errs.E(err))
+	}
+
+	if ack.Duplicate {
+		b.logger.Debug("nats: duplicate dropped",
+			observability.AttrMessageName, msg.Name, observability.AttrMessageID, msg.MessageID)
+
+		return nil
+	}
+
+	b.logger.Debug("nats: published",
+		observability.AttrMessageName, msg.Name,
+		observability.AttrCorrelationValue, msg.CorrelationKey)
+
+	return nil
+}
+
+// Subscribe registers interest in messages named name. With no keys (or
+// only empty keys) the subscription is a wildcard served by the group's
+// shared consumer for name; otherwise every key is served by its
+// conversation consumer. Messages buffered in a consumer before the call
+// are delivered to it.
+func (b *Broker) Subscribe(
+	ctx context.Context, name string, keys ...string,
+) (messaging.Subscription, error) {
+	if name == "" {
+		return nil, errs.New(
+			errs.M("Subscribe: an empty message name isn't allowed"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	s := &subscription{
+		b:    b,
+		ch:   make(chan messaging.Envelope),
+		stop: make(chan struct{}),
+		keys: map[string]struct{}{},
+		name: name,
+	}
+
+	var err error
+
+	for _, k := range keys {
+		if k != "" {
+			if err = s.addKey(ctx, k); err != nil {
+				break
+			}
+		}
+	}
+
+	if err == nil && len(s.keys) == 0 {
+		err = s.consume(ctx, b.wildcardConsumer(name),
+			b.subjectPrefix(name)+".*", true)
+	}
+
+	if err != nil {
+		_ = s.Unsubscribe()
+
+		return nil, err
+	}
+
+	b.logger.Debug("nats: subscribed",
+		observability.AttrMessageName, name, "keys", len(s.keys))
+
+	return s, nil
+}
+
+// subject is the subject a message named name with correlation key key is
+// published on.
+func (b *Broker) subject(name, key string) string {
+	if key == "" {
+		return b.subjectPrefix(name) + "." + noKeyToken
+	}
+
+	return b.subjectPrefix(name) + "." + token(key)
+}
+
+// subjectPrefix is the subject prefix of every message named name.
+func (b *Broker) subjectPrefix(name string) string {
+	return b.prefix + "." + token(name)
+}
+
+// wildcardConsumer names the group's shared consumer of name.
+func (b *Broker) wildcardConsumer(name string) string {
+	return "gobpm_w_" + digest(b.group, name)
+}
+
+// keyedConsumer names the group's consumer of the conversation key of name.
+func (b *Broker) keyedConsumer(name, key string) string {
+	return "gobpm_k_" + digest(b.group, name, key)
+}
+
+// conversationConsumer reports whether the group has a consumer for the
+// conversation key of name — then a keyed message belongs to it. On a
+// failed lookup the caller returns the message to the wildcard consumer
+// rather than risk a wrong delivery.
+func (b *Broker) conversationConsumer(ctx context.Context, name, key string) (bool, error) {
+	_, err := b.js.Consumer(ctx, b.stream, b.keyedConsumer(name, key))
+	if errors.Is(err, jetstream.ErrConsumerNotFound) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// token encodes s as one subject token.
+func token(s string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(s))
+}
+
+// digest hashes parts into a consumer-name-safe string.
+func digest(parts ...string) string {
+	h := sha256.New()
+
+	for _, p := range parts {
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// decode rebuilds the envelope a stream message carries.
+func decode(m jetstream.Msg) (messaging.Envelope, error) {
+	return decodeParts(m.Subject(), m.Headers(), m.Data())
+}
+
+// decodeParts rebuilds the envelope of a message's subject, headers and
+// body.
+func decodeParts(subject string, h nats.Header, data []byte) (messaging.Envelope, error) {
+	env := messaging.Envelope{
+		Name:           h.Get(HeaderMessageName),
+		CorrelationKey: h.Get(HeaderCorrelationKey),
+		MessageID:      h.Get(jetstream.MsgIDHeader),
+	}
+
+	if env.Name == "" {
+		return env, errs.New(
+			errs.M("the message carries no %s header", HeaderMessageName),
+			errs.C(errorClass, errs.InvalidObject),
+			errs.D("subject", subject))
+	}
+
+	if err := json.Unmarshal(data, &env.Payload); err != nil {
+		return env, errs.New(
+			errs.M("the message body isn't JSON"),
+			errs.C(errorClass, errs.InvalidObject),
+			errs.D(observability.AttrMessageName, env.Name),
+			errs.E(err))
+	}
+
+	return env, nil
+}
@@ -0,0 +1,249 @@
+// Package nats provides a messaging.MessageBroker over NATS JetStream, for
+// engines that run next to a NATS deployment. Messages live in one stream;
+// delivery state lives in durable consumers the server keeps, so every
+// engine of a group sees the same buffered messages and takes each one
+// once.
+//
+// The mapping:
+//
+//   - the message name is the subject: <prefix>.<name>.<key>, each token
+//     base64url-encoded so any name or key is a valid token (an absent key
+//     is the token "_"); the name and key also ride the Gobpm-Message-Name
+//     and Gobpm-Correlation-Key headers, which are authoritative on receipt;
+//   - the correlation key is the Gobpm-Correlation-Key header;
+//   - Envelope.MessageID is the Nats-Msg-Id header, so the stream drops a
+//     duplicate inside its duplicate window; Envelope.TTL is the per-message
+//     Nats-TTL;
+//   - the payload is the JSON-encoded body, so it must be JSON-encodable and
+//     arrives as its JSON decoding (a number as float64).
+//
+// Consumers are durable and scoped to the engine group. A wildcard
+// subscription — an instance-starter, an uncorrelated catch — shares one
+// consumer per message name across the group, so one engine takes each
+// message. A keyed subscription, and each key AddKey adds, is backed by one
+// consumer per (name, key): the conversation's messages wait in it until the
+// instance's engine takes them, and a buffered message reaches a consumer
+// created later. Delivery stays most-specific: a keyed message whose
+// conversation consumer exists is left to it, never handed to a wildcard
+// subscriber. The server reaps a consumer idle past WithConsumerIdle.
+//
+// A message is acknowledged only once the subscriber took it from C — the
+// channel is unbuffered — so a message in flight when an engine stops is
+// redelivered to another. A delivered message stays in the stream, so a
+// conversation consumer created after a wildcard subscriber took one of its
+// messages sees it again; set Envelope.MessageID and the engine drops the
+// repeat, and bound the stream with WithMaxAge. The broker declares itself
+// cluster-compatible (renv.ClusterAware).
+//
+// The broker is also a messaging.Broadcaster: a broadcast is a core NATS
+// message on <prefix>_bcast.<group>.<name>, outside the stream, and reaches
+// every engine of the group subscribed at the time — the engines' signals
+// travel this way. Nothing of it is stored.
+package nats
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/messaging"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+	"github.com/dr-dobermann/gobpm/pkg/renv"
+)
+
+const errorClass = "NATS_BROKER"
+
+const (
+	// DefaultStream is the JetStream stream the messages live in unless
+	// WithStream overrides it.
+	DefaultStream = "GOBPM_MESSAGES"
+	// DefaultSubjectPrefix is the subject prefix of every message unless
+	// WithSubjectPrefix overrides it.
+	DefaultSubjectPrefix = "gobpm.msg"
+	// DefaultConsumerIdle is how long the server keeps a consumer nobody
+	// pulls from.
+	DefaultConsumerIdle = time.Hour
+	// DefaultAckWait is how long a delivered message may stay unacknowledged
+	// before the server redelivers it; a message waiting for its subscriber
+	// is kept in progress meanwhile.
+	DefaultAckWait = 30 * time.Second
+	// DefaultDuplicateWindow is how long the stream remembers a MessageID.
+	DefaultDuplicateWindow = 10 * time.Minute
+
+	// HeaderMessageName carries the message name.
+	HeaderMessageName = "Gobpm-Message-Name"
+	// HeaderCorrelationKey carries the correlation key.
+	HeaderCorrelationKey = "Gobpm-Correlation-Key"
+)
+
+// Broker is the JetStream messaging.MessageBroker. Build it with New.
+type Broker struct {
+	js           jetstream.JetStream
+	logger       observability.Logger
+	stream       string
+	prefix       string
+	group        string
+	consumerIdle time.Duration
+	ackWait      time.Duration
+	duplicates   time.Duration
+	maxAge       time.Duration
+}
+
+// Option configures a Broker at New.
+type Option func(*Broker) error
+
+// WithStream overrides the stream name (default: DefaultStream).
+func WithStream(name string) Option {
+	return func(b *Broker) error {
+		if name == "" || strings.ContainsAny(name, " .*>/\\") {
+			return errs.New(
+				errs.M("WithStream: %q isn't a valid stream name", name),
+				errs.C(errorClass, errs.InvalidParameter))
+		}
+
+		b.stream = name
+
+		return nil
+	}
+}
+
+// WithSubjectPrefix overrides the subject prefix (default:
+// DefaultSubjectPrefix). The prefix must be one or more literal tokens.
+func WithSubjectPrefix(prefix string) Option {
+	return func(b *Broker) error {
+		if prefix == "" || strings.ContainsAny(prefix, " *>") ||
+			strings.HasPrefix(prefix, ".") || strings.HasSuffix(prefix, ".") ||
+			strings.Contains(prefix, "..") {
+			return errs.New(
+				errs.M("WithSubjectPrefix: %q isn't a literal subject", prefix),
+				errs.C(errorClass, errs.InvalidParameter))
+		}
+
+		b.prefix = prefix
+
+		return nil
+	}
+}
+
+// WithConsumerIdle sets how long the server keeps a consumer nobody pulls
+// from (default: DefaultConsumerIdle).
+func WithConsumerIdle(d time.Duration) Option {
+	return positive("WithConsumerIdle", d, func(b *Broker) { b.consumerIdle = d })
+}
+
+// WithAckWait sets the acknowledgement deadline of a delivered message
+// (default: DefaultAckWait).
+func WithAckWait(d time.Duration) Option {
+	return positive("WithAckWait", d, func(b *Broker) { b.ackWait = d })
+}
+
+// WithDuplicateWindow sets how long the stream remembers a MessageID
+// (default: DefaultDuplicateWindow).
+func WithDuplicateWindow(d time.Duration) Option {
+	return positive("WithDuplicateWindow", d, func(b *Broker) { b.duplicates = d })
+}
+
+// WithMaxAge bounds how long the stream keeps any message, delivered or
+// not (default: unbounded).
+func WithMaxAge(d time.Duration) Option {
+	return positive("WithMaxAge", d, func(b *Broker) { b.maxAge = d })
+}
+
+// WithLogger sets the structured logger (default: slog.Default()).
+func WithLogger(l observability.Logger) Option {
+	return func(b *Broker) error {
+		if l == nil {
+			return errs.New(
+				errs.M("WithLogger: a nil Logger isn't allowed"),
+				errs.C(errorClass, errs.EmptyNotAllowed))
+		}
+
+		b.logger = l
+
+		return nil
+	}
+}
+
+// positive builds an Option setting a duration that must be positive.
+func positive(name string, d time.Duration, set func(*Broker)) Option {
+	return func(b *Broker) error {
+		if d <= 0 {
+			return errs.New(
+				errs.M("%s: the duration must be positive, got %s", name, d),
+				errs.C(errorClass, errs.InvalidParameter))
+		}
+
+		set(b)
+
+		return nil
+	}
+}
+
+// New builds a Broker over the user-owned JetStream context (the
+// connection, its credentials and reconnect policy belong to the
+// embedder) for the engine group group — the same name the engines pass
+// to thresher.WithEngineGroup. It creates the stream, or updates it to the
+// configured limits.
+func New(
+	ctx context.Context, js jetstream.JetStream, group string, opts ...Option,
+) (*Broker, error) {
+	if js == nil {
+		return nil, errs.New(
+			errs.M("New: a nil JetStream isn't allowed"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	if group == "" {
+		return nil, errs.New(
+			errs.M("New: an empty engine group isn't allowed"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	b := &Broker{
+		js:           js,
+		logger:       slog.Default(),
+		stream:       DefaultStream,
+		prefix:       DefaultSubjectPrefix,
+		group:        group,
+		consumerIdle: DefaultConsumerIdle,
+		ackWait:      DefaultAckWait,
+		duplicates:   DefaultDuplicateWindow,
+	}
+
+	for _, o := range opts {
+		if err := o(b); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:        b.stream,
+		Subjects:    []string{b.prefix + ".>"},
+		Duplicates:  b.duplicates,
+		MaxAge:      b.maxAge,
+		AllowMsgTTL: true,
+	}); err != nil {
+		return nil, errs.New(
+			errs.M("New: the stream couldn't be created"),
+			errs.C(errorClass, errs.OperationFailed),
+			errs.D("stream", b.stream),
+			errs.E(err))
+	}
+
+	return b, nil
+}
+
+// ClusterCompatibility declares the broker safe to share between engines
+// (renv.ClusterAware, SRD-078 FR-3).
+func (b *Broker) ClusterCompatibility() (bool, string) {
+	return true, "shared JetStream stream; durable consumers per engine group"
+}
+
+var (
+	_ messaging.MessageBroker = (*Broker)(nil)
+	_ renv.ClusterAware       = (*Broker)(nil)
+)
@@ -0,0 +1,369 @@
+package nats_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	natsgo "github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gobpm/adapters/nats"
+	"github.com/dr-dobermann/gobpm/pkg/messaging"
+	"github.com/dr-dobermann/gobpm/pkg/renv"
+)
+
+// runServer starts an in-process JetStream server for the test.
+func runServer(t *testing.T) *server.Server {
+	t.Helper()
+
+	srv, err := server.NewServer(&server.Options{
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		Port:      -1,
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	require.NoError(t, err)
+
+	go srv.Start()
+	t.Cleanup(srv.Shutdown)
+
+	require.True(t, srv.ReadyForConnections(5*time.Second),
+		"the embedded server must accept connections")
+
+	return srv
+}
+
+// jetStream opens a connection of its own to srv — one per engine, as
+// separate processes would have.
+func jetStream(t *testing.T, srv *server.Server) jetstream.JetStream {
+	t.Helper()
+
+	nc, err := natsgo.Connect(srv.ClientURL())
+	require.NoError(t, err)
+	t.Cleanup(nc.Close)
+
+	js, err := jetstream.New(nc)
+	require.NoError(t, err)
+
+	return js
+}
+
+// newBroker builds a broker of group over its own connection to srv.
+func newBroker(
+	t *testing.T, srv *server.Server, group string, opts ...nats.Option,
+) *nats.Broker {
+	t.Helper()
+
+	b, err := nats.New(context.Background(), jetStream(t, srv), group, opts...)
+	require.NoError(t, err)
+
+	return b
+}
+
+// subscribe subscribes and unsubscribes at cleanup.
+func subscribe(
+	t *testing.T, b messaging.MessageBroker, name string, keys ...string,
+) messaging.Subscription {
+	t.Helper()
+
+	sub, err := b.Subscribe(context.Background(), name, keys...)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = sub.Unsubscribe() })
+
+	return sub
+}
+
+// receive takes the next envelope of sub or fails.
+func receive(t *testing.T, sub messaging.Subscription) messaging.Envelope {
+	t.Helper()
+
+	select {
+	case env := <-sub.C():
+		return env
+	case <-time.After(3 * time.Second):
+		t.Fatal("no message delivered")
+	}
+
+	return messaging.Envelope{}
+}
+
+// silent asserts sub gets nothing for a while.
+func silent(t *testing.T, sub messaging.Subscription) {
+	t.Helper()
+
+	select {
+	case env := <-sub.C():
+		t.Fatalf("unexpected delivery: %+v", env)
+	case <-time.After(300 * time.Millisecond):
+	}
+}
+
+func TestNew(t *testing.T) {
+	srv := runServer(t)
+	ctx := context.Background()
+
+	t.Run("a nil JetStream is rejected", func(t *testing.T) {
+		_, err := nats.New(ctx, nil, "g")
+		require.Error(t, err)
+	})
+
+	t.Run("an empty group is rejected", func(t *testing.T) {
+		_, err := nats.New(ctx, jetStream(t, srv), "")
+		require.Error(t, err)
+	})
+
+	t.Run("invalid options are rejected", func(t *testing.T) {
+		js := jetStream(t, srv)
+
+		for _, o := range []nats.Option{
+			nats.WithStream("a.b"),
+			nats.WithSubjectPrefix("gobpm.*"),
+			nats.WithSubjectPrefix("gobpm."),
+			nats.WithAckWait(0),
+			nats.WithConsumerIdle(-time.Second),
+			nats.WithLogger(nil),
+		} {
+			_, err := nats.New(ctx, js, "g", o)
+			require.Error(t, err)
+		}
+	})
+
+	t.Run("the broker is cluster-compatible", func(t *testing.T) {
+		var ca renv.ClusterAware = newBroker(t, srv, "g")
+
+		ok, reason := ca.ClusterCompatibility()
+		require.True(t, ok)
+		require.NotEmpty(t, reason)
+	})
+}
+
+func TestPublishSubscribe(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("a message published before Subscribe is delivered", func(t *testing.T) {
+		b := newBroker(t, runServer(t), "g")
+
+		require.NoError(t, b.Publish(ctx, messaging.Envelope{
+			Name: "order placed", CorrelationKey: "ORD-1",
+			MessageID: "m-1", Payload: "ORD-1"}))
+
+		env := receive(t, subscribe(t, b, "order placed"))
+		require.Equal(t, "order placed", env.Name)
+		require.Equal(t, "ORD-1", env.CorrelationKey)
+		require.Equal(t, "m-1", env.MessageID)
+		require.Equal(t, "ORD-1", env.Payload)
+	})
+
+	t.Run("names and keys outside the subject alphabet", func(t *testing.T) {
+		b := newBroker(t, runServer(t), "g")
+		sub := subscribe(t, b, "a.b *>", "k.1 >")
+
+		require.NoError(t, b.Publish(ctx, messaging.Envelope{
+			Name: "a.b *>", CorrelationKey: "k.1 >",
+			Payload: map[string]any{"n": 1}}))
+
+		env := receive(t, sub)
+		require.Equal(t, "k.1 >", env.CorrelationKey)
+		require.Equal(t, map[string]any{"n": float64(1)}, env.Payload,
+			"the payload arrives as its JSON decoding")
+	})
+
+	t.Run("invalid messages are rejected", func(t *testing.T) {
+		b := newBroker(t, runServer(t), "g")
+
+		require.Error(t, b.Publish(ctx, messaging.Envelope{}))
+		require.Error(t, b.Publish(ctx, messaging.Envelope{
+			Name: "m", Payload: func() {}}))
+
+		_, err := b.Subscribe(ctx, "")
+		require.Error(t, err)
+	})
+}
+
+// TestMostSpecificDelivery: a keyed message goes to its conversation's
+// subscriber, never to a wildcard one; a message of an unclaimed key goes
+// to the wildcard.
+func TestMostSpecificDelivery(t *testing.T) {
+	ctx := context.Background()
+	b := newBroker(t, runServer(t), "g")
+
+	wild := subscribe(t, b, "payment received")
+	keyed := subscribe(t, b, "payment received", "ORD-1")
+
+	require.NoError(t, b.Publish(ctx, messaging.Envelope{
+		Name: "payment received", CorrelationKey: "ORD-1", Payload: "p-1"}))
+
+	require.Equal(t, "p-1", receive(t, keyed).Payload)
+	silent(t, wild)
+
+	require.NoError(t, b.Publish(ctx, messaging.Envelope{
+		Name: "payment received", CorrelationKey: "ORD-2", Payload: "p-2"}))
+
+	require.Equal(t, "p-2", receive(t, wild).Payload)
+	silent(t, keyed)
+}
+
+// TestAddKeyDrainsBuffered: a key added later receives the conversation's
+// messages published before it — the durable consumer created for it
+// starts at the stream's beginning.
+func TestAddKeyDrainsBuffered(t *testing.T) {
+	ctx := context.Background()
+	b := newBroker(t, runServer(t), "g")
+
+	sub := subscribe(t, b, "payment received", "ORD-1")
+
+	require.NoError(t, b.Publish(ctx, messaging.Envelope{
+		Name: "payment received", CorrelationKey: "ORD-2", Payload: "p-2"}))
+	silent(t, sub)
+
+	require.Error(t, sub.AddKey(""))
+	require.NoError(t, sub.AddKey("ORD-2"))
+	require.NoError(t, sub.AddKey("ORD-2"), "a known key is a no-op")
+
+	require.Equal(t, "p-2", receive(t, sub).Payload)
+}
+
+func TestDuplicateMessageIDDropped(t *testing.T) {
+	ctx := context.Background()
+	b := newBroker(t, runServer(t), "g")
+
+	for range 2 {
+		require.NoError(t, b.Publish(ctx, messaging.Envelope{
+			Name: "m", MessageID: "once", Payload: "x"}))
+	}
+
+	sub := subscribe(t, b, "m")
+
+	receive(t, sub)
+	silent(t, sub)
+}
+
+func TestMessageTTL(t *testing.T) {
+	ctx := context.Background()
+	b := newBroker(t, runServer(t), "g")
+
+	require.NoError(t, b.Publish(ctx, messaging.Envelope{
+		Name: "m", Payload: "stale", TTL: time.Second}))
+	require.NoError(t, b.Publish(ctx, messaging.Envelope{
+		Name: "m", Payload: "fresh"}))
+
+	time.Sleep(1500 * time.Millisecond)
+
+	sub := subscribe(t, b, "m")
+
+	require.Equal(t, "fresh", receive(t, sub).Payload,
+		"an expired message must not be delivered")
+	silent(t, sub)
+}
+
+// TestGroupSharesWildcard: the engines of one group share a wildcard
+// consumer — each message reaches exactly one of them — while another
+// group gets its own copy.
+func TestGroupSharesWildcard(t *testing.T) {
+	const n = 10
+
+	ctx := context.Background()
+	srv := runServer(t)
+
+	subs := []messaging.Subscription{
+		subscribe(t, newBroker(t, srv, "g"), "order placed"),
+		subscribe(t, newBroker(t, srv, "g"), "order placed"),
+	}
+	other := subscribe(t, newBroker(t, srv, "other"), "order placed")
+
+	pub := newBroker(t, srv, "g")
+	for i := range n {
+		require.NoError(t, pub.Publish(ctx, messaging.Envelope{
+			Name: "order placed", Payload: fmt.Sprint(i)}))
+	}
+
+	seen := map[any]int{}
+
+	for range n {
+		select {
+		case env := <-subs[0].C():
+			seen[env.Payload]++
+		case env := <-subs[1].C():
+			seen[env.Payload]++
+		case <-time.After(3 * time.Second):
+			t.Fatalf("only %d of %d messages delivered", len(seen), n)
+		}
+	}
+
+	require.Len(t, seen, n)
+
+	for p, c := range seen {
+		require.Equal(t, 1, c, "message %v delivered more than once", p)
+	}
+
+	silent(t, subs[0])
+	silent(t, subs[1])
+
+	for range n {
+		receive(t, other)
+	}
+}
+
+// TestUnsubscribeRedelivers: a message nobody took from C when the
+// subscription ends is redelivered to the next subscriber.
+func TestUnsubscribeRedelivers(t *testing.T) {
+	ctx := context.Background()
+	b := newBroker(t, runServer(t), "g")
+
+	first, err := b.Subscribe(ctx, "m")
+	require.NoError(t, err)
+
+	require.NoError(t, b.Publish(ctx, messaging.Envelope{Name: "m", Payload: "x"}))
+
+	time.Sleep(200 * time.Millisecond) // the message waits on first's C
+	require.NoError(t, first.Unsubscribe())
+	require.NoError(t, first.Unsubscribe(), "Unsubscribe is idempotent")
+	require.NoError(t, first.AddKey("k"), "AddKey after Unsubscribe is a no-op")
+
+	require.Equal(t, "x", receive(t, subscribe(t, b, "m")).Payload)
+}
+
+// TestBroadcast: a broadcast reaches every broadcast subscriber of its
+// group, is never stored for a stream consumer, and stays inside its group.
+func TestBroadcast(t *testing.T) {
+	ctx := context.Background()
+	srv := runServer(t)
+	b := newBroker(t, srv, "g")
+
+	subscribeBroadcast := func(b *nats.Broker) messaging.Subscription {
+		sub, err := b.SubscribeBroadcast(ctx, "sig")
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = sub.Unsubscribe() })
+
+		return sub
+	}
+
+	subs := []messaging.Subscription{
+		subscribeBroadcast(b), subscribeBroadcast(newBroker(t, srv, "g")),
+	}
+	other := subscribeBroadcast(newBroker(t, srv, "other"))
+	stream := subscribe(t, b, "sig")
+
+	require.NoError(t, b.Broadcast(ctx, messaging.Envelope{
+		Name: "sig", MessageID: "s-1", Payload: map[string]any{"n": 1}}))
+
+	for _, sub := range subs {
+		env := receive(t, sub)
+		require.Equal(t, "s-1", env.MessageID)
+		require.Equal(t, map[string]any{"n": float64(1)}, env.Payload)
+	}
+
+	silent(t, other)
+	silent(t, stream)
+
+	require.Error(t, subs[0].AddKey("k"))
+	require.NoError(t, subs[0].Unsubscribe())
+	require.NoError(t, subs[0].Unsubscribe(), "Unsubscribe is idempotent")
+
+	require.Error(t, b.Broadcast(ctx, messaging.Envelope{}))
+}
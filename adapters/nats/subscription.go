@@ -0,0 +1,219 @@
+package nats
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/messaging"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+)
+
+// subscription is a live registration: one consumption per backing
+// consumer — the wildcard one, or one per key — all feeding ch.
+type subscription struct {
+	b      *Broker
+	ch     chan messaging.Envelope
+	stop   chan struct{}
+	keys   map[string]struct{}
+	active []jetstream.ConsumeContext
+	name   string
+	mu     sync.Mutex
+	closed bool
+}
+
+// C returns the channel of envelopes matching the subscription. It is
+// unbuffered: a message is acknowledged once the receiver takes it.
+func (s *subscription) C() <-chan messaging.Envelope { return s.ch }
+
+// AddKey backs key with its conversation consumer, so the key's buffered
+// and future messages are delivered here (lazy secondary-key association,
+// SRD-017). An empty key is rejected; a known key, or any key after
+// Unsubscribe, is a no-op.
+func (s *subscription) AddKey(key string) error {
+	if key == "" {
+		return errs.New(
+			errs.M("nats.AddKey: an empty correlation key isn't allowed"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	return s.addKey(context.Background(), key)
+}
+
+// addKey starts consuming key's conversation consumer, creating it at need.
+func (s *subscription) addKey(ctx context.Context, key string) error {
+	s.mu.Lock()
+	_, known := s.keys[key]
+	closed := s.closed
+	s.mu.Unlock()
+
+	if known || closed {
+		return nil
+	}
+
+	if err := s.consume(ctx, s.b.keyedConsumer(s.name, key),
+		s.b.subject(s.name, key), false); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.keys[key] = struct{}{}
+	s.mu.Unlock()
+
+	s.b.logger.Debug("nats: key added",
+		observability.AttrMessageName, s.name, observability.AttrCorrelationValue, key)
+
+	return nil
+}
+
+// Unsubscribe stops every consumption; a message waiting for the receiver
+// is returned to its consumer for redelivery. The durable consumers stay,
+// holding the conversation's messages, until the server reaps them idle.
+// Idempotent.
+func (s *subscription) Unsubscribe() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+
+	s.closed = true
+	close(s.stop)
+
+	for _, cc := range s.active {
+		cc.Stop()
+	}
+
+	s.active = nil
+
+	s.b.logger.Debug("nats: unsubscribed", observability.AttrMessageName, s.name)
+
+	return nil
+}
+
+// consume creates or binds the durable consumer and feeds its messages to
+// the subscription. A wildcard consumption leaves a keyed message to its
+// conversation consumer when one exists.
+func (s *subscription) consume(
+	ctx context.Context, durable, filter string, wildcard bool,
+) error {
+	cons, err := s.b.js.CreateOrUpdateConsumer(ctx, s.b.stream,
+		jetstream.ConsumerConfig{
+			Durable:           durable,
+			FilterSubject:     filter,
+			AckPolicy:         jetstream.AckExplicitPolicy,
+			DeliverPolicy:     jetstream.DeliverAllPolicy,
+			AckWait:           s.b.ackWait,
+			InactiveThreshold: s.b.consumerIdle,
+		})
+	if err != nil {
+		return errs.New(
+			errs.M("the consumer couldn't be created"),
+			errs.C(errorClass, errs.OperationFailed),
+			errs.D(observability.AttrMessageName, s.name),
+			errs.E(err))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+
+	// one message at a time: a prefetched message would age past AckWait
+	// while the one before it waits for the receiver.
+	cc, err := cons.Consume(func(m jetstream.Msg) { s.handle(m, wildcard) },
+		jetstream.PullMaxMessages(1))
+	if err != nil {
+		return errs.New(
+			errs.M("the consumer couldn't be consumed"),
+			errs.C(errorClass, errs.OperationFailed),
+			errs.D(observability.AttrMessageName, s.name),
+			errs.E(err))
+	}
+
+	s.active = append(s.active, cc)
+
+	return nil
+}
+
+// handle delivers one stream message. An undecodable message is
+// terminated — no redelivery can fix it.
+func (s *subscription) handle(m jetstream.Msg, wildcard bool) {
+	env, err := decode(m)
+	if err != nil {
+		s.b.logger.Warn("nats: undecodable message terminated",
+			"subject", m.Subject(), observability.AttrError, err.Error())
+		s.settle(m.Term, env)
+
+		return
+	}
+
+	if wildcard && env.CorrelationKey != "" {
+		owned, err := s.b.conversationConsumer(context.Background(),
+			env.Name, env.CorrelationKey)
+		if err != nil {
+			s.b.logger.Warn("nats: conversation lookup failed; message returned",
+				observability.AttrMessageName, env.Name, observability.AttrError, err.Error())
+			s.settle(m.Nak, env)
+
+			return
+		}
+
+		if owned {
+			s.b.logger.Debug("nats: left to its conversation",
+				observability.AttrMessageName, env.Name,
+				observability.AttrCorrelationValue, env.CorrelationKey)
+			s.settle(m.Ack, env)
+
+			return
+		}
+	}
+
+	s.deliver(m, env)
+}
+
+// deliver hands env to the receiver and acknowledges it, keeping the
+// message in progress while the receiver is busy; a stop returns it to the
+// consumer.
+func (s *subscription) deliver(m jetstream.Msg, env messaging.Envelope) {
+	tick := time.NewTicker(s.b.ackWait / 2)
+	defer tick.Stop()
+
+	for {
+		select {
+		case s.ch <- env:
+			s.settle(m.Ack, env)
+
+			s.b.logger.Debug("nats: delivered",
+				observability.AttrMessageName, env.Name,
+				observability.AttrCorrelationValue, env.CorrelationKey)
+
+			return
+
+		case <-tick.C:
+			s.settle(m.InProgress, env)
+
+		case <-s.stop:
+			s.settle(m.Nak, env)
+
+			return
+		}
+	}
+}
+
+// settle runs an acknowledgement call, logging a failure: the server then
+// redelivers, which the engine's own dedup absorbs.
+func (s *subscription) settle(call func() error, env messaging.Envelope) {
+	if err := call(); err != nil {
+		s.b.logger.Warn("nats: acknowledgement failed",
+			observability.AttrMessageName, env.Name, observability.AttrError, err.Error())
+	}
+}
+
+var _ messaging.Subscription = (*subscription)(nil)
@@ -0,0 +1,62 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestDataStoreQueriesShape checks the DataStore statements without a
+// database: each reads and writes the schema's data_store_items under
+// the (store, name) key, binding the store and the datum first and
+// taking exactly the arguments its caller passes.
+func TestDataStoreQueriesShape(t *testing.T) {
+	q := buildDataStoreQueries("gobpm_x")
+
+	for name, c := range map[string]struct {
+		query string
+		args  int
+	}{
+		"get":           {q.get, 2},
+		"put":           {q.put, 3},
+		"putBounded":    {q.putBounded, 4},
+		"create":        {q.create, 3},
+		"createBounded": {q.createBounded, 4},
+		"swap":          {q.swap, 4},
+		"exists":        {q.exists, 2},
+		"del":           {q.del, 2},
+	} {
+		require.Contains(t, c.query, "gobpm_x.data_store_items", name)
+		require.Equal(t, c.args, params(c.query), name)
+	}
+
+	t.Run("a plain write upserts and bumps the version", func(t *testing.T) {
+		for _, s := range []string{q.put, q.putBounded} {
+			require.Contains(t, s, "ON CONFLICT (store, name) DO UPDATE")
+			require.Contains(t, s, "version = i.version + 1")
+		}
+
+		cols, vals := insertArity(t, q.put)
+		require.Equal(t, cols, vals)
+	})
+
+	t.Run("a create never overwrites", func(t *testing.T) {
+		for _, s := range []string{q.create, q.createBounded} {
+			require.Contains(t, s,
+				"ON CONFLICT (store, name) DO NOTHING RETURNING version")
+		}
+	})
+
+	t.Run("the bounded writes count against the capacity", func(t *testing.T) {
+		require.Contains(t, q.putBounded,
+			"WHERE store = $1 AND name <> $2) < $4",
+			"a replace must not count its own row")
+		require.Contains(t, q.createBounded, "WHERE store = $1) < $4")
+	})
+
+	t.Run("a swap holds to the expected version", func(t *testing.T) {
+		require.Contains(t, q.swap,
+			"WHERE store = $1 AND name = $2 AND version = $4")
+		require.Contains(t, q.swap, "RETURNING version")
+	})
+}
@@ -0,0 +1,191 @@
+package postgres_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gobpm/adapters/postgres"
+	"github.com/dr-dobermann/gobpm/pkg/datastore"
+	"github.com/dr-dobermann/gobpm/pkg/datastore/datastoretest"
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/model/data/values"
+)
+
+// TestDataStoreConformance proves the postgres Data Store against the
+// published DataStore contract suite, one fresh schema per subtest.
+func TestDataStoreConformance(t *testing.T) {
+	datastoretest.Conformance(t, func(t *testing.T) datastore.DataStore {
+		s, err := newRepo(t).DataStore("orders")
+		require.NoError(t, err)
+
+		return s
+	})
+}
+
+func TestDataStore(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("the data survive a restart", func(t *testing.T) {
+		repo := newRepo(t)
+
+		s, err := repo.DataStore("orders")
+		require.NoError(t, err)
+		require.NoError(t, s.Put(ctx, "total",
+			datastoretest.Datum(t, "total", values.NewVariable(42))))
+
+		// a second engine's Repo over the same schema.
+		again, err := postgres.New(openDB(t),
+			postgres.WithSchema(repo.Schema()))
+		require.NoError(t, err)
+
+		s2, err := again.DataStore("orders")
+		require.NoError(t, err)
+
+		d, ok, err := s2.Get(ctx, "total")
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, 42, d.Value().Get(ctx))
+
+		other, err := again.DataStore("stock")
+		require.NoError(t, err)
+
+		_, ok, err = other.Get(ctx, "total")
+		require.NoError(t, err)
+		require.False(t, ok, "stores of distinct names never share data")
+	})
+
+	t.Run("the capacity is enforced", func(t *testing.T) {
+		s, err := newRepo(t).DataStore("orders", postgres.WithCapacity(2))
+		require.NoError(t, err)
+		require.Equal(t, 2, s.Capacity())
+		require.False(t, s.IsUnlimited())
+
+		for _, n := range []string{"a", "b"} {
+			require.NoError(t, s.Put(ctx, n,
+				datastoretest.Datum(t, n, values.NewVariable(1))))
+		}
+
+		err = s.Put(ctx, "c", datastoretest.Datum(t, "c", values.NewVariable(1)))
+		require.True(t, hasClass(err, errs.OutOfRangeError), "got %v", err)
+
+		require.NoError(t, s.Put(ctx, "a",
+			datastoretest.Datum(t, "a", values.NewVariable(2))),
+			"a replace never grows the store")
+	})
+
+	t.Run("a bounded PutIf tells a conflict from a full store", func(t *testing.T) {
+		s, err := newRepo(t).DataStore("orders", postgres.WithCapacity(1))
+		require.NoError(t, err)
+
+		v, err := s.PutIf(ctx, "a", datastoretest.Datum(t, "a", values.NewVariable(1)), 0)
+		require.NoError(t, err)
+
+		_, err = s.PutIf(ctx, "a", datastoretest.Datum(t, "a", values.NewVariable(2)), 0)
+		require.True(t, hasClass(err, errs.ConcurrentUpdate), "got %v", err)
+
+		_, err = s.PutIf(ctx, "b", datastoretest.Datum(t, "b", values.NewVariable(1)), 0)
+		require.True(t, hasClass(err, errs.OutOfRangeError), "got %v", err)
+
+		next, err := s.PutIf(ctx, "a", datastoretest.Datum(t, "a", values.NewVariable(2)), v)
+		require.NoError(t, err)
+		require.Greater(t, next, v)
+
+		require.NoError(t, s.Delete(ctx, "a"))
+		_, err = s.PutIf(ctx, "b", datastoretest.Datum(t, "b", values.NewVariable(1)), 0)
+		require.NoError(t, err, "a delete frees the capacity")
+	})
+
+	t.Run("concurrent puts never overfill", func(t *testing.T) {
+		repo := newRepo(t)
+
+		var (
+			wg   sync.WaitGroup
+			mu   sync.Mutex
+			oks  int
+			full int
+		)
+
+		for i := range 8 {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				s, err := repo.DataStore("orders", postgres.WithCapacity(3))
+				require.NoError(t, err)
+
+				n := fmt.Sprintf("k%d", i)
+				err = s.Put(ctx, n, datastoretest.Datum(t, n, values.NewVariable(i)))
+
+				mu.Lock()
+				defer mu.Unlock()
+
+				if err == nil {
+					oks++
+				} else if hasClass(err, errs.OutOfRangeError) {
+					full++
+				}
+			}()
+		}
+
+		wg.Wait()
+		require.Equal(t, 3, oks)
+		require.Equal(t, 5, full)
+	})
+}
+
+// TestDataStoreValidation covers the DSN-free parameter checks.
+func TestDataStoreValidation(t *testing.T) {
+	repo, err := postgres.New(&sql.DB{})
+	require.NoError(t, err)
+
+	_, err = repo.DataStore("")
+	require.True(t, hasClass(err, errs.EmptyNotAllowed))
+
+	_, err = repo.DataStore("orders", postgres.WithCapacity(0))
+	require.True(t, hasClass(err, errs.InvalidParameter))
+
+	s, err := repo.DataStore("orders")
+	require.NoError(t, err)
+	require.True(t, s.IsUnlimited())
+	require.Contains(t, s.String(), "orders")
+
+	_, err = s.PutIf(context.Background(), "total",
+		datastoretest.Datum(t, "total", values.NewVariable(1)), -1)
+	require.True(t, hasClass(err, errs.InvalidParameter))
+	require.True(t, hasClass(s.Delete(context.Background(), ""),
+		errs.EmptyNotAllowed))
+
+	ok, _ := s.ClusterCompatibility()
+	require.True(t, ok)
+}
+
+// TestDataStoreFailuresAreLoud drives the Data Store over a dead pool.
+func TestDataStoreFailuresAreLoud(t *testing.T) {
+	ctx := context.Background()
+	d := datastoretest.Datum(t, "total", values.NewVariable(1))
+
+	s, err := closedRepo(t).DataStore("orders")
+	require.NoError(t, err)
+
+	_, _, err = s.Get(ctx, "total")
+	require.Error(t, err)
+	require.Error(t, s.Put(ctx, "total", d))
+	require.Error(t, s.Migrate(ctx))
+	require.Error(t, s.Delete(ctx, "total"))
+
+	_, err = s.PutIf(ctx, "total", d, 1)
+	require.Error(t, err)
+
+	bounded, err := closedRepo(t).DataStore("orders", postgres.WithCapacity(1))
+	require.NoError(t, err)
+	require.Error(t, bounded.Put(ctx, "total", d))
+
+	_, err = bounded.PutIf(ctx, "total", d, 0)
+	require.Error(t, err)
+}
@@ -0,0 +1,333 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/history"
+	"github.com/dr-dobermann/gobpm/pkg/renv"
+)
+
+// History is the PostgreSQL history.Store: the audit trail of one
+// engine group's tenant, in the Repo's schema. Every event lands in
+// historic_events — the log Query pages through — and, by type, in the
+// reporting projections beside it: historic_instances,
+// historic_activities, historic_tasks and historic_incidents. Build it
+// with Repo.History and feed it the engine's fact stream through
+// thresher.WithHistory, or through Thresher.Observe with a
+// history.Recorder over it.
+type History struct {
+	repo   *Repo
+	group  string
+	tenant string
+	q      historyQueries
+}
+
+// HistoryOption configures a History at Repo.History.
+type HistoryOption func(*History) error
+
+// WithTenant sets the tenant the History records and reads (default:
+// the id the Repo mints for a group's default tenant).
+func WithTenant(id string) HistoryOption {
+	return func(h *History) error {
+		if id == "" {
+			return errs.New(
+				errs.M("WithTenant: an empty tenant id isn't allowed"),
+				errs.C(errorClass, errs.EmptyNotAllowed))
+		}
+
+		h.tenant = id
+
+		return nil
+	}
+}
+
+// History builds the history store of the engine group over the Repo's
+// database and schema. The tables come with the Repo's migrations: Run
+// migrates them along with the repository, and History.Migrate does
+// the same for a store used without one.
+func (r *Repo) History(group string, opts ...HistoryOption) (*History, error) {
+	if group == "" {
+		return nil, errs.New(
+			errs.M("History: an engine group is required"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	h := &History{
+		repo:   r,
+		group:  group,
+		tenant: defaultTenantID,
+	}
+
+	for _, o := range opts {
+		if err := o(h); err != nil {
+			return nil, err
+		}
+	}
+
+	h.q = buildHistoryQueries(r.schema)
+
+	return h, nil
+}
+
+var (
+	_ history.Store     = (*History)(nil)
+	_ renv.ClusterAware = (*History)(nil)
+	_ renv.Migrator     = (*History)(nil)
+)
+
+// ClusterCompatibility declares the store safe to share between
+// engines (renv.ClusterAware): every engine appends to the same log.
+func (h *History) ClusterCompatibility() (bool, string) {
+	return true, "shared PostgreSQL store; Seq is a database sequence"
+}
+
+// Migrate creates or upgrades the adapter's objects, the history
+// tables among them (renv.Migrator) — the Repo's Migrate.
+func (h *History) Migrate(ctx context.Context) error {
+	return h.repo.Migrate(ctx)
+}
+
+// String identifies the store, its schema and its partition in logs.
+func (h *History) String() string {
+	return "postgres history (schema " + h.repo.schema +
+		", group " + h.group + ", tenant " + h.tenant + ")"
+}
+
+// Append stores the events and their projections in one transaction,
+// in order; the database sequence assigns each its Seq.
+func (h *History) Append(ctx context.Context, ee ...history.Event) error {
+	for i := range ee {
+		if err := ee[i].Check(); err != nil {
+			return err
+		}
+	}
+
+	if len(ee) == 0 {
+		return nil
+	}
+
+	tx, err := h.repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return opErr("history Append (begin)", "", err)
+	}
+	defer func() {
+		if rbErr := tx.Rollback(); rbErr != nil &&
+			!errors.Is(rbErr, sql.ErrTxDone) {
+			h.repo.logger.Warn("history Append: rollback failed",
+				"error", rbErr.Error())
+		}
+	}()
+
+	for i := range ee {
+		if err := h.append(ctx, tx, &ee[i]); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return opErr("history Append (commit)", "", err)
+	}
+
+	return nil
+}
+
+// append stores one event in the log, then in the projection its type
+// feeds (VariableUpdated and ActivityStarted feed none).
+func (h *History) append(
+	ctx context.Context, tx *sql.Tx, e *history.Event,
+) error {
+	var seq int64
+
+	if err := tx.QueryRowContext(ctx, h.q.insertEvent,
+		h.group, h.tenant, e.At, string(e.Type), e.InstanceID,
+		e.ProcessID, e.Version, e.ParentInstanceID, e.TrackID, e.NodeID,
+		e.NodeName, e.Outcome, e.TaskID, e.UserID, e.PrevUserID,
+		e.IncidentID, e.Variable, e.Error, int64(e.Duration)).
+		Scan(&seq); err != nil {
+		return opErr("history Append", e.InstanceID, err)
+	}
+
+	var (
+		query string
+		args  []any
+	)
+
+	switch e.Type {
+	case history.InstanceStarted:
+		query, args = h.q.startInstance, []any{
+			e.InstanceID, e.ProcessID, e.Version, e.ParentInstanceID, e.At,
+		}
+
+	case history.InstanceEnded:
+		query, args = h.q.endInstance, []any{
+			e.InstanceID, e.ProcessID, e.Version, e.ParentInstanceID, e.At,
+			e.Outcome, e.Error, e.Duration.Milliseconds(),
+		}
+
+	case history.ActivityEnded:
+		query, args = h.q.insertActivity, []any{
+			seq, e.InstanceID, e.ProcessID, e.Version, e.TrackID, e.NodeID,
+			e.NodeName, e.At.Add(-e.Duration), e.At, e.Outcome,
+			e.Duration.Milliseconds(),
+		}
+
+	case history.TaskOwnerChanged:
+		query, args = h.q.insertTask, []any{
+			seq, e.InstanceID, e.ProcessID, e.TaskID, e.NodeID, e.Outcome,
+			e.UserID, e.PrevUserID, e.At,
+		}
+
+	case history.IncidentChanged:
+		query, args = h.q.insertIncident, []any{
+			seq, e.InstanceID, e.ProcessID, e.IncidentID, e.NodeID,
+			e.Outcome, e.Error, e.At,
+		}
+
+	default:
+		return nil
+	}
+
+	if _, err := tx.ExecContext(ctx, query,
+		append([]any{h.group, h.tenant}, args...)...); err != nil {
+		return opErr("history Append ("+string(e.Type)+")", e.InstanceID, err)
+	}
+
+	return nil
+}
+
+// Query returns the page of the partition's events q selects, in Seq
+// order, every filter pushed down to the log's indexes.
+func (h *History) Query(
+	ctx context.Context, q history.Query,
+) (history.Page, error) {
+	limit, err := q.PageSize()
+	if err != nil {
+		return history.Page{}, err
+	}
+
+	query, args := h.eventQuery(q, limit)
+
+	ee, err := h.scanEvents(ctx, query, args)
+	if err != nil {
+		return history.Page{}, err
+	}
+
+	var p history.Page
+
+	if len(ee) > limit {
+		ee = ee[:limit]
+		p.Next = ee[limit-1].Seq
+	}
+
+	p.Events = ee
+
+	return p, nil
+}
+
+// eventQuery renders the event select of q and its arguments: the
+// partition and the cursor, then each filter q sets, then the page
+// size plus the one row past it that tells whether another page
+// follows.
+func (h *History) eventQuery(q history.Query, limit int) (string, []any) {
+	var (
+		sb   strings.Builder
+		args = []any{h.group, h.tenant, q.After}
+	)
+
+	sb.WriteString(h.q.selectEvents)
+
+	// arg binds v as the next parameter and returns its placeholder.
+	arg := func(v any) string {
+		args = append(args, v)
+
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	// a fixed filter order keeps the statement text stable for the
+	// driver's statement cache.
+	for _, f := range []struct{ col, v string }{
+		{"instance_id", q.InstanceID},
+		{"process_id", q.ProcessID},
+		{"node_id", q.NodeID},
+		{"task_id", q.TaskID},
+	} {
+		if f.v != "" {
+			sb.WriteString(" AND " + f.col + " = " + arg(f.v))
+		}
+	}
+
+	if q.UserID != "" {
+		p := arg(q.UserID)
+		sb.WriteString(" AND (user_id = " + p + " OR prev_user_id = " + p + ")")
+	}
+
+	if len(q.Types) > 0 {
+		pp := make([]string, len(q.Types))
+		for i, typ := range q.Types {
+			pp[i] = arg(string(typ))
+		}
+
+		sb.WriteString(" AND type IN (" + strings.Join(pp, ", ") + ")")
+	}
+
+	if !q.From.IsZero() {
+		sb.WriteString(" AND at >= " + arg(q.From))
+	}
+
+	if !q.To.IsZero() {
+		sb.WriteString(" AND at < " + arg(q.To))
+	}
+
+	sb.WriteString(" ORDER BY seq LIMIT " + arg(limit+1))
+
+	return sb.String(), args
+}
+
+// scanEvents runs an event select and scans its rows.
+func (h *History) scanEvents(
+	ctx context.Context, query string, args []any,
+) ([]history.Event, error) {
+	rows, err := h.repo.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, opErr("history Query", "", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			h.repo.logger.Warn("history Query: rows close failed",
+				"error", cerr.Error())
+		}
+	}()
+
+	var ee []history.Event
+
+	for rows.Next() {
+		var (
+			e   history.Event
+			typ string
+			dur int64
+		)
+
+		if err := rows.Scan(&e.Seq, &e.At, &typ, &e.InstanceID,
+			&e.ProcessID, &e.Version, &e.ParentInstanceID, &e.TrackID,
+			&e.NodeID, &e.NodeName, &e.Outcome, &e.TaskID, &e.UserID,
+			&e.PrevUserID, &e.IncidentID, &e.Variable, &e.Error,
+			&dur); err != nil {
+			return nil, opErr("history Query (scan)", "", err)
+		}
+
+		e.Type, e.Duration = history.Type(typ), time.Duration(dur)
+		ee = append(ee, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, opErr("history Query (rows)", "", err)
+	}
+
+	return ee, nil
+}
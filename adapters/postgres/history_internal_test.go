@@ -0,0 +1,185 @@
+package postgres
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gobpm/pkg/history"
+)
+
+// The History's statements, checked for shape without a database: each
+// is schema-qualified, binds the partition first and takes exactly the
+// arguments its caller passes. The DSN-gated tests run them for real.
+
+var paramRx = regexp.MustCompile(`\$(\d+)`)
+
+// params returns the highest $N placeholder of query.
+func params(query string) int {
+	n := 0
+
+	for _, m := range paramRx.FindAllStringSubmatch(query, -1) {
+		if v, _ := strconv.Atoi(m[1]); v > n {
+			n = v
+		}
+	}
+
+	return n
+}
+
+var insertRx = regexp.MustCompile(`\(([^()]*)\)\s+VALUES\s+\(([^()]*)\)`)
+
+// insertArity returns how many columns an INSERT names and how many
+// values it binds.
+func insertArity(t *testing.T, query string) (int, int) {
+	t.Helper()
+
+	m := insertRx.FindStringSubmatch(query)
+	require.NotNil(t, m, "not an INSERT ... VALUES: %s", query)
+
+	return len(strings.Split(m[1], ",")), len(strings.Split(m[2], ","))
+}
+
+func TestHistoryQueriesShape(t *testing.T) {
+	q := buildHistoryQueries("gobpm_x")
+
+	t.Run("inserts bind a value per column, the partition first", func(t *testing.T) {
+		for name, c := range map[string]struct {
+			query string
+			args  int
+		}{
+			"insertEvent":    {q.insertEvent, 19},
+			"startInstance":  {q.startInstance, 7},
+			"endInstance":    {q.endInstance, 10},
+			"insertActivity": {q.insertActivity, 13},
+			"insertTask":     {q.insertTask, 11},
+			"insertIncident": {q.insertIncident, 10},
+		} {
+			cols, vals := insertArity(t, c.query)
+			require.Equal(t, cols, vals, name)
+			require.Equal(t, c.args, params(c.query), name)
+			require.Contains(t, c.query, "(engine_group, tenant_id, ", name)
+			require.Contains(t, c.query, "INSERT INTO gobpm_x.historic_", name)
+		}
+	})
+
+	t.Run("the projections upsert on their key", func(t *testing.T) {
+		for _, s := range []string{q.startInstance, q.endInstance} {
+			require.Contains(t, s,
+				"ON CONFLICT (engine_group, tenant_id, instance_id) DO UPDATE")
+		}
+	})
+
+	t.Run("reads and purges are scoped to the partition", func(t *testing.T) {
+		for name, c := range map[string]struct {
+			query string
+			args  int
+		}{
+			"selectEvents":   {q.selectEvents, 3},
+			"finished":       {q.finished, 5},
+			"nodeDurations":  {q.nodeDurations, 5},
+			"purgeInstances": {q.purgeInstances, 3},
+		} {
+			require.Contains(t, c.query,
+				"WHERE engine_group = $1 AND tenant_id = $2", name)
+			require.Equal(t, c.args, params(c.query), name)
+		}
+
+		require.Len(t, q.purge, 4)
+
+		for _, s := range q.purge {
+			require.Contains(t, s,
+				"WHERE c.engine_group = $1 AND c.tenant_id = $2")
+			require.Equal(t, 3, params(s))
+			require.Contains(t, s, "gobpm_x.historic_instances i")
+		}
+	})
+
+	t.Run("the reports keep to their window and order", func(t *testing.T) {
+		for _, s := range []string{q.finished, q.nodeDurations} {
+			require.Contains(t, s,
+				"process_id = $3 AND ended_at >= $4 AND ended_at < $5")
+		}
+
+		require.True(t, strings.HasSuffix(q.finished,
+			"ORDER BY ended_at, instance_id"))
+		require.True(t, strings.HasSuffix(q.nodeDurations,
+			"GROUP BY node_id ORDER BY node_id"))
+	})
+
+	t.Run("events are read in the columns they are written", func(t *testing.T) {
+		require.Contains(t, q.selectEvents, "SELECT seq, "+historyColumns+" FROM")
+		require.Contains(t, q.insertEvent,
+			"(engine_group, tenant_id, "+historyColumns+")")
+	})
+}
+
+func TestHistoryEventQuery(t *testing.T) {
+	h := &History{
+		group:  "g",
+		tenant: "t",
+		q:      buildHistoryQueries("gobpm_x"),
+	}
+
+	t.Run("no filter pages by the cursor alone", func(t *testing.T) {
+		query, args := h.eventQuery(history.Query{After: 7}, 50)
+
+		require.Equal(t,
+			h.q.selectEvents+" ORDER BY seq LIMIT $4", query)
+		require.Equal(t, []any{"g", "t", int64(7), 51}, args)
+	})
+
+	t.Run("every filter binds in a fixed order", func(t *testing.T) {
+		from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		to := from.Add(time.Hour)
+
+		query, args := h.eventQuery(history.Query{
+			InstanceID: "i", ProcessID: "p", NodeID: "n", TaskID: "k",
+			UserID: "u",
+			Types:  []history.Type{history.InstanceStarted, history.InstanceEnded},
+			From:   from, To: to,
+		}, 10)
+
+		require.Equal(t, h.q.selectEvents+
+			" AND instance_id = $4 AND process_id = $5 AND node_id = $6"+
+			" AND task_id = $7 AND (user_id = $8 OR prev_user_id = $8)"+
+			" AND type IN ($9, $10) AND at >= $11 AND at < $12"+
+			" ORDER BY seq LIMIT $13", query)
+		require.Equal(t, []any{
+			"g", "t", int64(0), "i", "p", "n", "k", "u",
+			string(history.InstanceStarted), string(history.InstanceEnded),
+			from, to, 11,
+		}, args)
+		require.Equal(t, len(args), params(query))
+	})
+}
+
+var createTableRx = regexp.MustCompile(
+	`(?s)CREATE TABLE (historic_\w+) \((.*?)\n\)([^;]*);`)
+
+// TestHistoryMigrationPartitions pins the history tables' declarative
+// partitioning: each is hashed on the engine group and the tenant, its
+// key leads with both, and the migration creates its partitions.
+func TestHistoryMigrationPartitions(t *testing.T) {
+	body, err := migrationsFS.ReadFile("migrations/0003_history.sql")
+	require.NoError(t, err)
+
+	sql := string(body)
+	tables := createTableRx.FindAllStringSubmatch(sql, -1)
+	require.Len(t, tables, 5)
+
+	for _, m := range tables {
+		name, cols, tail := m[1], m[2], m[3]
+
+		require.Equal(t, " PARTITION BY HASH (engine_group, tenant_id)", tail, name)
+		require.Contains(t, cols, "PRIMARY KEY (engine_group, tenant_id, ", name)
+		require.Contains(t, sql, "'"+name+"'", "%s gets its partitions", name)
+	}
+
+	require.Contains(t, sql, "FOR i IN 0..7 LOOP")
+	require.Contains(t, sql, "WITH (MODULUS 8, REMAINDER %s)")
+}
@@ -0,0 +1,147 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+)
+
+// FinishedInstance is one row of FinishedInstances: an ended instance
+// as historic_instances holds it.
+type FinishedInstance struct {
+	InstanceID       string
+	ProcessID        string
+	ParentInstanceID string
+	// Outcome is Completed or Terminated; Error carries the fault of a
+	// failed one.
+	Outcome string
+	Error   string
+	Version int
+	// StartedAt is zero when history never saw the instance start.
+	StartedAt time.Time
+	EndedAt   time.Time
+	Duration  time.Duration
+}
+
+// NodeDuration is one row of NodeDurations: how many activities of a
+// node ended in the window, and how long they took on average.
+type NodeDuration struct {
+	NodeID   string
+	NodeName string
+	Count    int64
+	Average  time.Duration
+}
+
+// FinishedInstances returns the instances of processID that ended in
+// [from, to), by end time — the historic_instances_finished index's
+// path.
+func (h *History) FinishedInstances(
+	ctx context.Context, processID string, from, to time.Time,
+) ([]FinishedInstance, error) {
+	if err := checkReport("FinishedInstances", processID, from, to); err != nil {
+		return nil, err
+	}
+
+	rows, err := h.repo.db.QueryContext(ctx, h.q.finished,
+		h.group, h.tenant, processID, from, to)
+	if err != nil {
+		return nil, opErr("FinishedInstances", "", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			h.repo.logger.Warn("FinishedInstances: rows close failed",
+				"error", cerr.Error())
+		}
+	}()
+
+	var ii []FinishedInstance
+
+	for rows.Next() {
+		var (
+			fi      FinishedInstance
+			started sql.NullTime
+			ms      int64
+		)
+
+		if err := rows.Scan(&fi.InstanceID, &fi.ProcessID, &fi.Version,
+			&fi.ParentInstanceID, &started, &fi.EndedAt, &fi.Outcome,
+			&fi.Error, &ms); err != nil {
+			return nil, opErr("FinishedInstances (scan)", "", err)
+		}
+
+		fi.StartedAt = started.Time
+		fi.Duration = time.Duration(ms) * time.Millisecond
+		ii = append(ii, fi)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, opErr("FinishedInstances (rows)", "", err)
+	}
+
+	return ii, nil
+}
+
+// NodeDurations returns, per node of processID, the count and the
+// average duration of the activities that ended in [from, to), by node
+// id — the historic_activities_node index's path.
+func (h *History) NodeDurations(
+	ctx context.Context, processID string, from, to time.Time,
+) ([]NodeDuration, error) {
+	if err := checkReport("NodeDurations", processID, from, to); err != nil {
+		return nil, err
+	}
+
+	rows, err := h.repo.db.QueryContext(ctx, h.q.nodeDurations,
+		h.group, h.tenant, processID, from, to)
+	if err != nil {
+		return nil, opErr("NodeDurations", "", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			h.repo.logger.Warn("NodeDurations: rows close failed",
+				"error", cerr.Error())
+		}
+	}()
+
+	var nn []NodeDuration
+
+	for rows.Next() {
+		var (
+			nd NodeDuration
+			ms float64
+		)
+
+		if err := rows.Scan(&nd.NodeID, &nd.NodeName, &nd.Count,
+			&ms); err != nil {
+			return nil, opErr("NodeDurations (scan)", "", err)
+		}
+
+		nd.Average = time.Duration(ms * float64(time.Millisecond))
+		nn = append(nn, nd)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, opErr("NodeDurations (rows)", "", err)
+	}
+
+	return nn, nil
+}
+
+// checkReport validates a report's process and its [from, to) window.
+func checkReport(op, processID string, from, to time.Time) error {
+	if processID == "" || from.IsZero() || to.IsZero() {
+		return errs.New(
+			errs.M("%s: a process and both window bounds are required", op),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	if !from.Before(to) {
+		return errs.New(
+			errs.M("%s: the window [%v, %v) is empty", op, from, to),
+			errs.C(errorClass, errs.InvalidParameter))
+	}
+
+	return nil
+}
@@ -0,0 +1,192 @@
+package postgres_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gobpm/adapters/postgres"
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/history"
+	"github.com/dr-dobermann/gobpm/pkg/history/historytest"
+)
+
+// TestHistoryConformance proves the postgres history against the
+// published Store contract suite, one fresh schema per subtest.
+func TestHistoryConformance(t *testing.T) {
+	historytest.Conformance(t, func(t *testing.T) history.Store {
+		h, err := newRepo(t).History("g")
+		require.NoError(t, err)
+
+		return h
+	})
+}
+
+var histEpoch = time.Date(2026, time.August, 4, 12, 0, 0, 0, time.UTC)
+
+// at is the sec-th second after histEpoch.
+func at(sec int) time.Time {
+	return histEpoch.Add(time.Duration(sec) * time.Second)
+}
+
+// run returns a recorded instance of order: its start, one activity
+// per node with the given seconds, and its end at the last one.
+func run(id string, start int, nodes map[string]int) []history.Event {
+	ee := []history.Event{{
+		At: at(start), Type: history.InstanceStarted, InstanceID: id,
+		ProcessID: "order", Version: 1,
+	}}
+
+	end := start
+	for node, secs := range nodes {
+		ee = append(ee, history.Event{
+			At: at(start + secs), Type: history.ActivityEnded,
+			InstanceID: id, ProcessID: "order", Version: 1,
+			NodeID: node, NodeName: "Node " + node, Outcome: "Completed",
+			Duration: time.Duration(secs) * time.Second,
+		})
+
+		end = max(end, start+secs)
+	}
+
+	return append(ee, history.Event{
+		At: at(end), Type: history.InstanceEnded, InstanceID: id,
+		ProcessID: "order", Version: 1, Outcome: "Completed",
+		Duration: time.Duration(end-start) * time.Second,
+	})
+}
+
+func TestHistoryReports(t *testing.T) {
+	ctx := context.Background()
+	repo := newRepo(t)
+
+	h, err := repo.History("g")
+	require.NoError(t, err)
+
+	require.NoError(t, h.Append(ctx, run("i-1", 0, map[string]int{"a": 2, "b": 4})...))
+	require.NoError(t, h.Append(ctx, run("i-2", 10, map[string]int{"a": 4})...))
+	require.NoError(t, h.Append(ctx, history.Event{
+		At: at(20), Type: history.InstanceStarted, InstanceID: "i-running",
+		ProcessID: "order", Version: 1,
+	}))
+
+	// another tenant's history stays out of this one's reports.
+	other, err := repo.History("g", postgres.WithTenant("acme"))
+	require.NoError(t, err)
+	require.NoError(t, other.Append(ctx, run("i-acme", 0, map[string]int{"a": 60})...))
+
+	t.Run("finished instances in a window", func(t *testing.T) {
+		ii, err := h.FinishedInstances(ctx, "order", at(0), at(30))
+		require.NoError(t, err)
+		require.Len(t, ii, 2)
+		require.Equal(t, "i-1", ii[0].InstanceID)
+		require.Equal(t, 4*time.Second, ii[0].Duration)
+		require.True(t, ii[0].StartedAt.Equal(at(0)))
+		require.Equal(t, "i-2", ii[1].InstanceID)
+
+		ii, err = h.FinishedInstances(ctx, "order", at(5), at(30))
+		require.NoError(t, err)
+		require.Len(t, ii, 1, "i-1 ended before the window")
+	})
+
+	t.Run("average duration per node", func(t *testing.T) {
+		nn, err := h.NodeDurations(ctx, "order", at(0), at(30))
+		require.NoError(t, err)
+		require.Equal(t, []postgres.NodeDuration{
+			{NodeID: "a", NodeName: "Node a", Count: 2, Average: 3 * time.Second},
+			{NodeID: "b", NodeName: "Node b", Count: 1, Average: 4 * time.Second},
+		}, nn)
+	})
+
+	t.Run("cleanup purges the ended instances before the cutoff",
+		func(t *testing.T) {
+			n, err := h.Cleanup(ctx, at(10))
+			require.NoError(t, err)
+			require.Equal(t, int64(1), n, "only i-1 ended before the cutoff")
+
+			p, err := h.Query(ctx, history.Query{InstanceID: "i-1"})
+			require.NoError(t, err)
+			require.Empty(t, p.Events, "the purged instance's log goes too")
+
+			p, err = h.Query(ctx, history.Query{InstanceID: "i-running"})
+			require.NoError(t, err)
+			require.Len(t, p.Events, 1, "a running instance's history stays")
+
+			nn, err := h.NodeDurations(ctx, "order", at(0), at(30))
+			require.NoError(t, err)
+			require.Len(t, nn, 1)
+			require.Equal(t, int64(1), nn[0].Count)
+
+			ii, err := other.FinishedInstances(ctx, "order", at(0), at(90))
+			require.NoError(t, err)
+			require.Len(t, ii, 1, "the cleanup is tenant-scoped")
+		})
+}
+
+// TestHistoryValidation covers the DSN-free parameter checks.
+func TestHistoryValidation(t *testing.T) {
+	repo, err := postgres.New(&sql.DB{})
+	require.NoError(t, err)
+
+	_, err = repo.History("")
+	require.Error(t, err)
+
+	_, err = repo.History("g", postgres.WithTenant(""))
+	require.Error(t, err)
+
+	h, err := repo.History("g")
+	require.NoError(t, err)
+	require.Contains(t, h.String(), "group g")
+
+	ok, _ := h.ClusterCompatibility()
+	require.True(t, ok)
+
+	ctx := context.Background()
+
+	_, err = h.FinishedInstances(ctx, "", at(0), at(1))
+	require.True(t, hasClass(err, errs.EmptyNotAllowed))
+
+	_, err = h.NodeDurations(ctx, "order", at(1), at(1))
+	require.True(t, hasClass(err, errs.InvalidParameter))
+
+	_, err = h.Cleanup(ctx, time.Time{})
+	require.True(t, hasClass(err, errs.EmptyNotAllowed))
+
+	require.True(t, hasClass(h.RunRetention(ctx, 0, time.Hour),
+		errs.InvalidParameter))
+}
+
+// TestHistoryFailuresAreLoud drives the history over a dead pool.
+func TestHistoryFailuresAreLoud(t *testing.T) {
+	h, err := closedRepo(t).History("g")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	require.Error(t, h.Append(ctx, run("i-1", 0, nil)...))
+
+	_, err = h.Query(ctx, history.Query{})
+	require.Error(t, err)
+
+	_, err = h.FinishedInstances(ctx, "order", at(0), at(1))
+	require.Error(t, err)
+
+	_, err = h.NodeDurations(ctx, "order", at(0), at(1))
+	require.Error(t, err)
+
+	_, err = h.Cleanup(ctx, at(0))
+	require.Error(t, err)
+
+	require.Error(t, h.Migrate(ctx))
+}
+
+// hasClass reports whether err carries the errs class.
+func hasClass(err error, class string) bool {
+	var ae *errs.ApplicationError
+
+	return errors.As(err, &ae) && ae.HasClass(class)
+}
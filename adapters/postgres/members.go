@@ -0,0 +1,128 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/repository"
+)
+
+// PutMember records the member, replacing the record under the same
+// group and engine id (repository.MembershipRegistry). The refresh
+// interval is kept in whole milliseconds.
+func (r *Repo) PutMember(ctx context.Context, member repository.GroupMember) error {
+	if member.Group == "" || member.EngineID == "" {
+		return errs.New(
+			errs.M("PutMember: a group and an engine id are required"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	if _, err := r.db.ExecContext(ctx, r.q.memberPut,
+		member.Group, member.EngineID, member.Version, member.Revision,
+		member.Started, member.Heartbeat,
+		member.Refresh.Milliseconds()); err != nil {
+		return opErr("PutMember", member.EngineID, err)
+	}
+
+	return nil
+}
+
+// RemoveMember removes the engine's record from the group
+// (repository.MembershipRegistry).
+func (r *Repo) RemoveMember(ctx context.Context, group, engineID string) error {
+	if _, err := r.db.ExecContext(ctx, r.q.memberRemove, group, engineID); err != nil {
+		return opErr("RemoveMember", engineID, err)
+	}
+
+	return nil
+}
+
+// Members returns the group's records ordered by engine id
+// (repository.MembershipRegistry).
+func (r *Repo) Members(
+	ctx context.Context, group string,
+) ([]repository.GroupMember, error) {
+	if group == "" {
+		return nil, errs.New(
+			errs.M("Members: an engine group is required"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	rows, err := r.db.QueryContext(ctx, r.q.members, group)
+	if err != nil {
+		return nil, opErr("Members", "", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			r.logger.Warn("Members: rows close failed", "error", cerr.Error())
+		}
+	}()
+
+	var members []repository.GroupMember
+
+	for rows.Next() {
+		var (
+			m         = repository.GroupMember{Group: group}
+			refreshMS int64
+		)
+
+		if err := rows.Scan(&m.EngineID, &m.Version, &m.Revision,
+			&m.Started, &m.Heartbeat, &refreshMS); err != nil {
+			return nil, opErr("Members (scan)", "", err)
+		}
+
+		m.Refresh = time.Duration(refreshMS) * time.Millisecond
+
+		members = append(members, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, opErr("Members (rows)", "", err)
+	}
+
+	return members, nil
+}
+
+// OwnedCounts counts the group's unfinished records by lease owner
+// (repository.MembershipRegistry).
+func (r *Repo) OwnedCounts(
+	ctx context.Context, group string,
+) (map[string]int, error) {
+	if group == "" {
+		return nil, errs.New(
+			errs.M("OwnedCounts: an engine group is required"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	rows, err := r.db.QueryContext(ctx, r.q.ownedCounts, group)
+	if err != nil {
+		return nil, opErr("OwnedCounts", "", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			r.logger.Warn("OwnedCounts: rows close failed", "error", cerr.Error())
+		}
+	}()
+
+	owned := map[string]int{}
+
+	for rows.Next() {
+		var (
+			owner string
+			n     int
+		)
+
+		if err := rows.Scan(&owner, &n); err != nil {
+			return nil, opErr("OwnedCounts (scan)", "", err)
+		}
+
+		owned[owner] = n
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, opErr("OwnedCounts (rows)", "", err)
+	}
+
+	return owned, nil
+}
@@ -0,0 +1,13 @@
+-- Business keys — executed with search_path set to the adapter's
+-- schema, like 0001. The process id and the business key (the host's
+-- own identifier for an instance) are lifted out of the payload so
+-- the business-key lookup needs no payload decode. Both default to
+-- '': a record written before this migration carries no key.
+ALTER TABLE instances
+    ADD COLUMN process_id   text NOT NULL DEFAULT '',
+    ADD COLUMN business_key text NOT NULL DEFAULT '';
+
+-- The business-key lookup's path; keyless records stay out of it.
+CREATE INDEX instances_business_key
+    ON instances (engine_group, process_id, business_key)
+    WHERE business_key <> '';
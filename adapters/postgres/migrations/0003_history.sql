@@ -0,0 +1,160 @@
+-- History — executed with search_path set to the adapter's schema,
+-- like 0001. historic_events is the audit log the history.Store
+-- contract reads (every event, full fidelity, Seq-ordered); the four
+-- historic_* tables beside it are the reporting projections the same
+-- Append maintains, shaped for plain SQL.
+--
+-- Every table is partitioned by engine group and tenant — declaratively,
+-- PARTITION BY HASH (engine_group, tenant_id) over eight partitions
+-- each — and both also lead each key and each index, so a
+-- group's (and a tenant's) history is a contiguous index range inside
+-- its partition. Every History statement binds both by equality, so
+-- the planner prunes to the one partition. Hashing needs no DDL as
+-- groups and tenants come and go; a deployment outgrowing the modulus
+-- re-splits in a later migration. Deliberately NO foreign keys to
+-- groups or tenants: history outlives the instances, and the retention
+-- job — not a cascade — decides when it goes.
+
+-- The audit log. seq is the history.Event Seq (the paging cursor);
+-- duration_ns keeps the Duration lossless. The sequence alone makes it
+-- unique; the key carries the partition columns, as a partitioned
+-- table's must.
+CREATE TABLE historic_events (
+    seq                bigserial   NOT NULL,
+    engine_group       text        NOT NULL,
+    tenant_id          text        NOT NULL,
+    at                 timestamptz NOT NULL,
+    type               text        NOT NULL,
+    instance_id        text        NOT NULL,
+    process_id         text        NOT NULL DEFAULT '',
+    version            integer     NOT NULL DEFAULT 0,
+    parent_instance_id text        NOT NULL DEFAULT '',
+    track_id           text        NOT NULL DEFAULT '',
+    node_id            text        NOT NULL DEFAULT '',
+    node_name          text        NOT NULL DEFAULT '',
+    outcome            text        NOT NULL DEFAULT '',
+    task_id            text        NOT NULL DEFAULT '',
+    user_id            text        NOT NULL DEFAULT '',
+    prev_user_id       text        NOT NULL DEFAULT '',
+    incident_id        text        NOT NULL DEFAULT '',
+    variable           text        NOT NULL DEFAULT '',
+    error              text        NOT NULL DEFAULT '',
+    duration_ns        bigint      NOT NULL DEFAULT 0,
+    PRIMARY KEY (engine_group, tenant_id, seq)
+) PARTITION BY HASH (engine_group, tenant_id);
+
+-- The key is the paging path; the per-instance trail beside it.
+CREATE INDEX historic_events_instance
+    ON historic_events (engine_group, tenant_id, instance_id, seq);
+
+-- One row per instance: started by InstanceStarted, closed by
+-- InstanceEnded (either may arrive first — both upsert). ended_at is
+-- NULL while the instance runs.
+CREATE TABLE historic_instances (
+    engine_group       text        NOT NULL,
+    tenant_id          text        NOT NULL,
+    instance_id        text        NOT NULL,
+    process_id         text        NOT NULL DEFAULT '',
+    version            integer     NOT NULL DEFAULT 0,
+    parent_instance_id text        NOT NULL DEFAULT '',
+    started_at         timestamptz,
+    ended_at           timestamptz,
+    outcome            text        NOT NULL DEFAULT '',
+    error              text        NOT NULL DEFAULT '',
+    duration_ms        bigint      NOT NULL DEFAULT 0,
+    PRIMARY KEY (engine_group, tenant_id, instance_id)
+) PARTITION BY HASH (engine_group, tenant_id);
+
+-- "Instances of process X finished between T1 and T2", and the
+-- retention job's scan.
+CREATE INDEX historic_instances_finished
+    ON historic_instances (engine_group, tenant_id, process_id, ended_at)
+    WHERE ended_at IS NOT NULL;
+
+-- One row per finished activity (ActivityEnded): a token's stay at a
+-- node, with how it ended and how long it took.
+CREATE TABLE historic_activities (
+    seq          bigint      NOT NULL,
+    engine_group text        NOT NULL,
+    tenant_id    text        NOT NULL,
+    instance_id  text        NOT NULL,
+    process_id   text        NOT NULL DEFAULT '',
+    version      integer     NOT NULL DEFAULT 0,
+    track_id     text        NOT NULL DEFAULT '',
+    node_id      text        NOT NULL,
+    node_name    text        NOT NULL DEFAULT '',
+    started_at   timestamptz NOT NULL,
+    ended_at     timestamptz NOT NULL,
+    outcome      text        NOT NULL DEFAULT '',
+    duration_ms  bigint      NOT NULL DEFAULT 0,
+    PRIMARY KEY (engine_group, tenant_id, seq)
+) PARTITION BY HASH (engine_group, tenant_id);
+
+-- "Average duration per node" of a process.
+CREATE INDEX historic_activities_node
+    ON historic_activities (engine_group, tenant_id, process_id, node_id,
+                            ended_at);
+
+CREATE INDEX historic_activities_instance
+    ON historic_activities (engine_group, tenant_id, instance_id);
+
+-- One row per user-task ownership change.
+CREATE TABLE historic_tasks (
+    seq          bigint      NOT NULL,
+    engine_group text        NOT NULL,
+    tenant_id    text        NOT NULL,
+    instance_id  text        NOT NULL,
+    process_id   text        NOT NULL DEFAULT '',
+    task_id      text        NOT NULL,
+    node_id      text        NOT NULL DEFAULT '',
+    change       text        NOT NULL,
+    user_id      text        NOT NULL DEFAULT '',
+    prev_user_id text        NOT NULL DEFAULT '',
+    at           timestamptz NOT NULL,
+    PRIMARY KEY (engine_group, tenant_id, seq)
+) PARTITION BY HASH (engine_group, tenant_id);
+
+CREATE INDEX historic_tasks_task
+    ON historic_tasks (engine_group, tenant_id, task_id, at);
+
+CREATE INDEX historic_tasks_instance
+    ON historic_tasks (engine_group, tenant_id, instance_id);
+
+-- One row per incident action (raised, retried, resolved, closed).
+CREATE TABLE historic_incidents (
+    seq          bigint      NOT NULL,
+    engine_group text        NOT NULL,
+    tenant_id    text        NOT NULL,
+    instance_id  text        NOT NULL,
+    process_id   text        NOT NULL DEFAULT '',
+    incident_id  text        NOT NULL,
+    node_id      text        NOT NULL DEFAULT '',
+    action       text        NOT NULL,
+    error        text        NOT NULL DEFAULT '',
+    at           timestamptz NOT NULL,
+    PRIMARY KEY (engine_group, tenant_id, seq)
+) PARTITION BY HASH (engine_group, tenant_id);
+
+CREATE INDEX historic_incidents_incident
+    ON historic_incidents (engine_group, tenant_id, incident_id, at);
+
+CREATE INDEX historic_incidents_instance
+    ON historic_incidents (engine_group, tenant_id, instance_id);
+
+-- The partitions: historic_<table>_p0 .. _p7 of every table above.
+DO $$
+DECLARE
+    parent text;
+    i      integer;
+BEGIN
+    FOREACH parent IN ARRAY ARRAY['historic_events', 'historic_instances',
+        'historic_activities', 'historic_tasks', 'historic_incidents']
+    LOOP
+        FOR i IN 0..7 LOOP
+            EXECUTE format('CREATE TABLE %I PARTITION OF %I FOR VALUES'
+                || ' WITH (MODULUS 8, REMAINDER %s)',
+                parent || '_p' || i, parent, i);
+        END LOOP;
+    END LOOP;
+END
+$$;
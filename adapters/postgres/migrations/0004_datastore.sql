@@ -0,0 +1,13 @@
+-- Data Stores — executed with search_path set to the adapter's schema,
+-- like 0001. One row per datum of an engine-global BPMN Data Store:
+-- store is the DataStore's name, name the datum's key within it, and
+-- payload the datastore.EncodeDatum form (the checkpoint value codec).
+-- A store's capacity is the adapter's configuration, not a row: the
+-- bounded Put counts the store's rows under a per-store lock.
+CREATE TABLE data_store_items (
+    store      text        NOT NULL,
+    name       text        NOT NULL,
+    payload    bytea       NOT NULL,
+    updated_at timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (store, name)
+);
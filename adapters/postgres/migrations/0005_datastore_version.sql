@@ -0,0 +1,6 @@
+-- Data Store versions — executed with search_path set to the adapter's
+-- schema, like 0001. Every write of a datum bumps its version; PutIf
+-- compare-and-swaps against it (datastore.Versioned). The rows 0004
+-- stored start at 1, as a first write does.
+ALTER TABLE data_store_items
+    ADD COLUMN version bigint NOT NULL DEFAULT 1;
@@ -0,0 +1,17 @@
+-- The engine group's due-timer index — executed with search_path set
+-- to the adapter's schema, like 0001. One row per deadline a
+-- dehydrated instance holds, keyed by the instance, the track and the
+-- timer's event. No foreign keys: the index outlives neither its
+-- instance's record nor the engine that writes it any longer than a
+-- takeover needs.
+CREATE TABLE timers (
+    engine_group text        NOT NULL,
+    instance_id  text        NOT NULL,
+    track_id     text        NOT NULL,
+    event_id     text        NOT NULL,
+    deadline     timestamptz NOT NULL,
+    PRIMARY KEY (instance_id, track_id, event_id)
+);
+
+-- The takeover poll's path: a group's deadlines, earliest first.
+CREATE INDEX timers_due ON timers (engine_group, deadline);
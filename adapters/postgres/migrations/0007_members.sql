@@ -0,0 +1,16 @@
+-- The engine group's membership registry — executed with search_path
+-- set to the adapter's schema, like 0001. One row per engine that has
+-- joined a group and not left it: its build, its start, its latest
+-- heartbeat and how often it refreshes it (refresh_ms). A crashed
+-- engine's row stays behind with a stale heartbeat; that is how the
+-- health view tells it from a live one.
+CREATE TABLE members (
+    engine_group text        NOT NULL,
+    engine_id    text        NOT NULL,
+    version      text        NOT NULL DEFAULT '',
+    revision     text        NOT NULL DEFAULT '',
+    started_at   timestamptz NOT NULL,
+    heartbeat_at timestamptz NOT NULL,
+    refresh_ms   bigint      NOT NULL DEFAULT 0,
+    PRIMARY KEY (engine_group, engine_id)
+);
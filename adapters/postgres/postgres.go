@@ -134,10 +134,13 @@ func opErr(op, id string, err error) error {
 }
 
 var (
-	_ repository.Repository = (*Repo)(nil)
-	_ renv.ClusterAware     = (*Repo)(nil)
-	_ renv.Migrator         = (*Repo)(nil)
-	_ fmt.Stringer          = (*Repo)(nil)
+	_ repository.Repository         = (*Repo)(nil)
+	_ repository.BusinessKeyFinder  = (*Repo)(nil)
+	_ repository.TimerIndex         = (*Repo)(nil)
+	_ repository.MembershipRegistry = (*Repo)(nil)
+	_ renv.ClusterAware             = (*Repo)(nil)
+	_ renv.Migrator                 = (*Repo)(nil)
+	_ fmt.Stringer                  = (*Repo)(nil)
 )
 
 // String identifies the adapter and its schema in logs.
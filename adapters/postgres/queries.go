@@ -15,11 +15,19 @@ type queries struct {
 	load                string
 	del                 string
 	list                string
+	findByBusinessKey   string
 	registerGroup       string
 	groupExists         string
 	ensureTenant        string
 	mintDefaultTenant   string
 	selectDefaultTenant string
+	timerPut            string
+	timerDelete         string
+	timersDue           string
+	memberPut           string
+	memberRemove        string
+	members             string
+	ownedCounts         string
 }
 
 // claimExcluded renders the statuses the recovery listing excludes —
@@ -31,35 +39,54 @@ var claimExcluded = fmt.Sprintf("(%d, %d, %d)",
 	repository.StatusTerminated,
 	repository.StatusSuspended)
 
+// terminalStatuses renders the statuses the business-key lookup and
+// the owned counts exclude: a finished instance no longer holds its
+// key, nor counts against its engine.
+var terminalStatuses = fmt.Sprintf("(%d, %d)",
+	repository.StatusCompleted,
+	repository.StatusTerminated)
+
+// suspended renders the status whose deadlines no takeover fires.
+var suspended = fmt.Sprint(int(repository.StatusSuspended))
+
 // buildQueries renders the statement set for the schema. The only
 // interpolated fragments are the schemaRx-validated schema name and
-// the constant claimExcluded list; every value travels as a $N
+// the constant status lists; every value travels as a $N
 // parameter.
 func buildQueries(schema string) queries {
 	instances := schema + ".instances"
 	tenants := schema + ".tenants"
 	groups := schema + ".groups"
+	timers := schema + ".timers"
+	members := schema + ".members"
 
 	return queries{
 		insert: "INSERT INTO " + instances +
 			" (id, engine_group, tenant_id, status, payload, rec_version," +
-			" lease_owner, lease_incarnation, lease_expiry)" +
-			" VALUES ($1, $2, $3, $4, $5, 1, $6, $7, $8)" +
+			" lease_owner, lease_incarnation, lease_expiry," +
+			" process_id, business_key)" +
+			" VALUES ($1, $2, $3, $4, $5, 1, $6, $7, $8, $9, $10)" +
 			" ON CONFLICT (id) DO NOTHING",
 		update: "UPDATE " + instances +
 			" SET engine_group = $2, tenant_id = $3, status = $4," +
 			" payload = $5, rec_version = rec_version + 1," +
 			" lease_owner = $6, lease_incarnation = $7, lease_expiry = $8," +
-			" updated_at = now()" +
+			" process_id = $10, business_key = $11, updated_at = now()" +
 			" WHERE id = $1 AND rec_version = $9",
 		load: "SELECT engine_group, tenant_id, status, payload," +
-			" rec_version, lease_owner, lease_incarnation, lease_expiry" +
+			" rec_version, lease_owner, lease_incarnation, lease_expiry," +
+			" process_id, business_key" +
 			" FROM " + instances + " WHERE id = $1",
 		del: "DELETE FROM " + instances + " WHERE id = $1",
 		list: "SELECT id FROM " + instances +
 			" WHERE engine_group = $1 AND status NOT IN " + claimExcluded +
 			" AND (lease_owner = '' OR lease_expiry <= $2)" +
 			" ORDER BY id",
+		findByBusinessKey: "SELECT id FROM " + instances +
+			" WHERE engine_group = $1 AND process_id = $2" +
+			" AND business_key = $3" +
+			" AND status NOT IN " + terminalStatuses +
+			" ORDER BY id",
 		registerGroup: "INSERT INTO " + groups +
 			" (group_name) VALUES ($1) ON CONFLICT DO NOTHING",
 		groupExists: "SELECT EXISTS (SELECT 1 FROM " + groups +
@@ -73,5 +100,214 @@ func buildQueries(schema string) queries {
 			" ON CONFLICT DO NOTHING",
 		selectDefaultTenant: "SELECT tenant_id FROM " + tenants +
 			" WHERE engine_group = $1 AND is_default",
+		timerPut: "INSERT INTO " + timers +
+			" (engine_group, instance_id, track_id, event_id, deadline)" +
+			" VALUES ($1, $2, $3, $4, $5)" +
+			" ON CONFLICT (instance_id, track_id, event_id) DO UPDATE" +
+			" SET engine_group = excluded.engine_group," +
+			" deadline = excluded.deadline",
+		// an empty track or event widens the delete to the instance's or
+		// the track's every deadline
+		timerDelete: "DELETE FROM " + timers + " WHERE instance_id = $1" +
+			" AND ($2 = '' OR track_id = $2) AND ($3 = '' OR event_id = $3)",
+		// the instance record leaves out the deadlines of a suspended
+		// instance and of one leased at $3; one without a record, or a
+		// finished one, stays listed. A NULL limit ($4) is no limit.
+		timersDue: "SELECT t.instance_id, t.track_id, t.event_id, t.deadline" +
+			" FROM " + timers + " t LEFT JOIN " + instances +
+			" i ON i.id = t.instance_id" +
+			" WHERE t.engine_group = $1 AND t.deadline <= $2" +
+			" AND (i.id IS NULL OR i.status IN " + terminalStatuses +
+			" OR (i.status <> " + suspended +
+			" AND (i.lease_owner = '' OR i.lease_expiry <= $3)))" +
+			" ORDER BY t.deadline, t.instance_id COLLATE \"C\"," +
+			" t.track_id COLLATE \"C\", t.event_id COLLATE \"C\" LIMIT $4",
+		memberPut: "INSERT INTO " + members +
+			" (engine_group, engine_id, version, revision," +
+			" started_at, heartbeat_at, refresh_ms)" +
+			" VALUES ($1, $2, $3, $4, $5, $6, $7)" +
+			" ON CONFLICT (engine_group, engine_id) DO UPDATE" +
+			" SET version = excluded.version, revision = excluded.revision," +
+			" started_at = excluded.started_at," +
+			" heartbeat_at = excluded.heartbeat_at," +
+			" refresh_ms = excluded.refresh_ms",
+		memberRemove: "DELETE FROM " + members +
+			" WHERE engine_group = $1 AND engine_id = $2",
+		members: "SELECT engine_id, version, revision, started_at," +
+			" heartbeat_at, refresh_ms" +
+			" FROM " + members + " WHERE engine_group = $1" +
+			" ORDER BY engine_id COLLATE \"C\"",
+		ownedCounts: "SELECT lease_owner, count(*) FROM " + instances +
+			" WHERE engine_group = $1 AND status NOT IN " + terminalStatuses +
+			" AND lease_owner <> ''" +
+			" GROUP BY lease_owner",
+	}
+}
+
+// historyQueries are the History's fixed statements, precomputed at
+// Repo.History like queries; the event Query's WHERE clause is the one
+// statement rendered per call (its filters are optional).
+type historyQueries struct {
+	insertEvent    string
+	startInstance  string
+	endInstance    string
+	insertActivity string
+	insertTask     string
+	insertIncident string
+	selectEvents   string
+	finished       string
+	nodeDurations  string
+	purge          []string
+	purgeInstances string
+}
+
+// historyColumns are historic_events' event columns in the order
+// insertEvent binds and selectEvents scans them (after seq).
+const historyColumns = "at, type, instance_id, process_id, version," +
+	" parent_instance_id, track_id, node_id, node_name, outcome," +
+	" task_id, user_id, prev_user_id, incident_id, variable, error," +
+	" duration_ns"
+
+// buildHistoryQueries renders the History statement set for the
+// schema. $1 and $2 are always the engine group and the tenant.
+func buildHistoryQueries(schema string) historyQueries {
+	events := schema + ".historic_events"
+	instances := schema + ".historic_instances"
+	activities := schema + ".historic_activities"
+	tasks := schema + ".historic_tasks"
+	incidents := schema + ".historic_incidents"
+
+	// purgeOf deletes table's rows of the instances that ended before
+	// the cutoff ($3), and its rows older than the cutoff of the
+	// instances history never saw start or end (their facts were
+	// dropped, so no instance row will ever retire them).
+	purgeOf := func(table, at string) string {
+		same := "i.engine_group = c.engine_group" +
+			" AND i.tenant_id = c.tenant_id" +
+			" AND i.instance_id = c.instance_id"
+
+		return "DELETE FROM " + table + " c" +
+			" WHERE c.engine_group = $1 AND c.tenant_id = $2" +
+			" AND (EXISTS (SELECT 1 FROM " + instances + " i WHERE " + same +
+			" AND i.ended_at < $3)" +
+			" OR (c." + at + " < $3 AND NOT EXISTS (SELECT 1 FROM " +
+			instances + " i WHERE " + same + ")))"
+	}
+
+	return historyQueries{
+		insertEvent: "INSERT INTO " + events +
+			" (engine_group, tenant_id, " + historyColumns + ")" +
+			" VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12," +
+			" $13, $14, $15, $16, $17, $18, $19)" +
+			" RETURNING seq",
+		startInstance: "INSERT INTO " + instances +
+			" (engine_group, tenant_id, instance_id, process_id, version," +
+			" parent_instance_id, started_at)" +
+			" VALUES ($1, $2, $3, $4, $5, $6, $7)" +
+			" ON CONFLICT (engine_group, tenant_id, instance_id) DO UPDATE" +
+			" SET process_id = EXCLUDED.process_id," +
+			" version = EXCLUDED.version," +
+			" parent_instance_id = EXCLUDED.parent_instance_id," +
+			" started_at = EXCLUDED.started_at",
+		endInstance: "INSERT INTO " + instances + " AS h" +
+			" (engine_group, tenant_id, instance_id, process_id, version," +
+			" parent_instance_id, ended_at, outcome, error, duration_ms)" +
+			" VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)" +
+			" ON CONFLICT (engine_group, tenant_id, instance_id) DO UPDATE" +
+			" SET process_id = COALESCE(NULLIF(EXCLUDED.process_id, '')," +
+			" h.process_id)," +
+			" version = COALESCE(NULLIF(EXCLUDED.version, 0), h.version)," +
+			" ended_at = EXCLUDED.ended_at, outcome = EXCLUDED.outcome," +
+			" error = EXCLUDED.error, duration_ms = EXCLUDED.duration_ms",
+		insertActivity: "INSERT INTO " + activities +
+			" (engine_group, tenant_id, seq, instance_id, process_id," +
+			" version, track_id, node_id, node_name, started_at, ended_at," +
+			" outcome, duration_ms)" +
+			" VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)",
+		insertTask: "INSERT INTO " + tasks +
+			" (engine_group, tenant_id, seq, instance_id, process_id," +
+			" task_id, node_id, change, user_id, prev_user_id, at)" +
+			" VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)",
+		insertIncident: "INSERT INTO " + incidents +
+			" (engine_group, tenant_id, seq, instance_id, process_id," +
+			" incident_id, node_id, action, error, at)" +
+			" VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)",
+		selectEvents: "SELECT seq, " + historyColumns + " FROM " + events +
+			" WHERE engine_group = $1 AND tenant_id = $2 AND seq > $3",
+		finished: "SELECT instance_id, process_id, version," +
+			" parent_instance_id, started_at, ended_at, outcome, error," +
+			" duration_ms FROM " + instances +
+			" WHERE engine_group = $1 AND tenant_id = $2" +
+			" AND process_id = $3 AND ended_at >= $4 AND ended_at < $5" +
+			" ORDER BY ended_at, instance_id",
+		nodeDurations: "SELECT node_id, max(node_name), count(*)," +
+			" avg(duration_ms)::double precision FROM " + activities +
+			" WHERE engine_group = $1 AND tenant_id = $2" +
+			" AND process_id = $3 AND ended_at >= $4 AND ended_at < $5" +
+			" GROUP BY node_id ORDER BY node_id",
+		purge: []string{
+			purgeOf(activities, "ended_at"),
+			purgeOf(tasks, "at"),
+			purgeOf(incidents, "at"),
+			purgeOf(events, "at"),
+		},
+		purgeInstances: "DELETE FROM " + instances +
+			" WHERE engine_group = $1 AND tenant_id = $2 AND ended_at < $3",
+	}
+}
+
+// dataStoreQueries are the DataStore's fixed statements, precomputed
+// at Repo.DataStore like the Repo's own. Every write bumps the row's
+// version; a new row starts at the column default, 1.
+type dataStoreQueries struct {
+	get string
+	put string
+	// putBounded stores the datum only while the store holds fewer
+	// than $4 OTHER names — a replace always fits, a new name only
+	// below the capacity. No row affected means the store is full.
+	putBounded string
+	// create and createBounded insert a new name only (PutIf over
+	// version 0); no row returned means it exists — or, bounded, that
+	// the store is full.
+	create        string
+	createBounded string
+	// swap replaces the datum iff it is at version $4.
+	swap   string
+	exists string
+	del    string
+}
+
+// buildDataStoreQueries renders the DataStore statements for the
+// schema.
+func buildDataStoreQueries(schema string) dataStoreQueries {
+	items := schema + ".data_store_items"
+
+	const (
+		upsert = " ON CONFLICT (store, name) DO UPDATE" +
+			" SET payload = EXCLUDED.payload, version = i.version + 1," +
+			" updated_at = now()"
+		insertOnly = " ON CONFLICT (store, name) DO NOTHING RETURNING version"
+	)
+
+	return dataStoreQueries{
+		get: "SELECT payload, version FROM " + items +
+			" WHERE store = $1 AND name = $2",
+		put: "INSERT INTO " + items + " AS i (store, name, payload)" +
+			" VALUES ($1, $2, $3)" + upsert,
+		putBounded: "INSERT INTO " + items + " AS i (store, name, payload)" +
+			" SELECT $1, $2, $3 WHERE (SELECT count(*) FROM " + items +
+			" WHERE store = $1 AND name <> $2) < $4" + upsert,
+		create: "INSERT INTO " + items + " (store, name, payload)" +
+			" VALUES ($1, $2, $3)" + insertOnly,
+		createBounded: "INSERT INTO " + items + " (store, name, payload)" +
+			" SELECT $1, $2, $3 WHERE (SELECT count(*) FROM " + items +
+			" WHERE store = $1) < $4" + insertOnly,
+		swap: "UPDATE " + items +
+			" SET payload = $3, version = version + 1, updated_at = now()" +
+			" WHERE store = $1 AND name = $2 AND version = $4" +
+			" RETURNING version",
+		exists: "SELECT EXISTS (SELECT 1 FROM " + items +
+			" WHERE store = $1 AND name = $2)",
+		del: "DELETE FROM " + items + " WHERE store = $1 AND name = $2",
 	}
 }
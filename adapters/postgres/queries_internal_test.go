@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestTimerQueriesShape checks the due-timer index statements without a
+// database: each is schema-qualified and takes exactly the arguments
+// its caller passes, a put replaces the deadline under its key, and the
+// due poll keeps to its group in a total, collation-stable order.
+func TestTimerQueriesShape(t *testing.T) {
+	q := buildQueries("gobpm_x")
+
+	for name, c := range map[string]struct {
+		query string
+		args  int
+	}{
+		"timerPut":    {q.timerPut, 5},
+		"timerDelete": {q.timerDelete, 3},
+		"timersDue":   {q.timersDue, 4},
+	} {
+		require.Contains(t, c.query, "gobpm_x.timers", name)
+		require.Equal(t, c.args, params(c.query), name)
+	}
+
+	cols, vals := insertArity(t, q.timerPut)
+	require.Equal(t, cols, vals)
+	require.Contains(t, q.timerPut,
+		"ON CONFLICT (instance_id, track_id, event_id) DO UPDATE")
+
+	require.Contains(t, q.timerDelete,
+		"AND ($2 = '' OR track_id = $2) AND ($3 = '' OR event_id = $3)",
+		"an empty track or event widens the delete")
+
+	require.Contains(t, q.timersDue, "WHERE t.engine_group = $1 AND t.deadline <= $2")
+	require.Contains(t, q.timersDue, "LEFT JOIN gobpm_x.instances i",
+		"the instance record filters what no takeover fires")
+	require.Contains(t, q.timersDue, "i.lease_expiry <= $3")
+	require.True(t, strings.HasSuffix(q.timersDue,
+		`ORDER BY t.deadline, t.instance_id COLLATE "C",`+
+			` t.track_id COLLATE "C", t.event_id COLLATE "C" LIMIT $4`))
+}
+
+// TestMemberQueriesShape checks the membership registry statements
+// without a database: the registry is keyed by group and engine, a put
+// refreshes the engine's row in place, and the owned counts take the
+// group's unfinished instances by lease owner, lapsed leases included.
+func TestMemberQueriesShape(t *testing.T) {
+	q := buildQueries("gobpm_x")
+
+	for name, c := range map[string]struct {
+		query string
+		args  int
+	}{
+		"memberPut":    {q.memberPut, 7},
+		"memberRemove": {q.memberRemove, 2},
+		"members":      {q.members, 1},
+	} {
+		require.Contains(t, c.query, "gobpm_x.members", name)
+		require.Equal(t, c.args, params(c.query), name)
+	}
+
+	cols, vals := insertArity(t, q.memberPut)
+	require.Equal(t, cols, vals)
+	require.Contains(t, q.memberPut,
+		"ON CONFLICT (engine_group, engine_id) DO UPDATE")
+	require.Contains(t, q.memberRemove,
+		"WHERE engine_group = $1 AND engine_id = $2")
+	require.True(t, strings.HasSuffix(q.members,
+		`WHERE engine_group = $1 ORDER BY engine_id COLLATE "C"`))
+
+	require.Contains(t, q.ownedCounts, "FROM gobpm_x.instances")
+	require.Equal(t, 1, params(q.ownedCounts))
+	require.Contains(t, q.ownedCounts,
+		"WHERE engine_group = $1 AND status NOT IN "+terminalStatuses)
+	require.Contains(t, q.ownedCounts,
+		"AND lease_owner <> '' GROUP BY lease_owner")
+	require.NotContains(t, q.ownedCounts, "lease_expiry")
+}
@@ -72,7 +72,8 @@ func (r *Repo) insert(
 ) error {
 	res, err := r.db.ExecContext(ctx, r.q.insert,
 		rec.ID, rec.Group, tenant, int(rec.Status), rec.Payload,
-		rec.Lease.Owner, rec.Lease.Incarnation, rec.Lease.Expiry)
+		rec.Lease.Owner, rec.Lease.Incarnation, rec.Lease.Expiry,
+		rec.ProcessID, rec.BusinessKey)
 	if err != nil {
 		return opErr("Save (create)", rec.ID, err)
 	}
@@ -88,7 +89,7 @@ func (r *Repo) update(
 	res, err := r.db.ExecContext(ctx, r.q.update,
 		rec.ID, rec.Group, tenant, int(rec.Status), rec.Payload,
 		rec.Lease.Owner, rec.Lease.Incarnation, rec.Lease.Expiry,
-		rec.RecVersion)
+		rec.RecVersion, rec.ProcessID, rec.BusinessKey)
 	if err != nil {
 		return opErr("Save (update)", rec.ID, err)
 	}
@@ -175,7 +176,7 @@ func (r *Repo) Load(
 	err := r.db.QueryRowContext(ctx, r.q.load,
 		id).Scan(&rec.Group, &rec.Tenant, &status, &rec.Payload,
 		&rec.RecVersion, &rec.Lease.Owner, &rec.Lease.Incarnation,
-		&rec.Lease.Expiry)
+		&rec.Lease.Expiry, &rec.ProcessID, &rec.BusinessKey)
 	if errors.Is(err, sql.ErrNoRows) {
 		return repository.InstanceRecord{}, false, nil
 	}
@@ -210,13 +211,37 @@ func (r *Repo) ListInFlight(
 			errs.C(errorClass, errs.EmptyNotAllowed))
 	}
 
-	rows, err := r.db.QueryContext(ctx, r.q.list, group, now)
+	return r.queryIDs(ctx, "ListInFlight", r.q.list, group, now)
+}
+
+// FindByBusinessKey returns the IDs of the group's non-terminal
+// instances of processID carrying businessKey, ordered by id
+// (repository.BusinessKeyFinder).
+func (r *Repo) FindByBusinessKey(
+	ctx context.Context, group, processID, businessKey string,
+) ([]string, error) {
+	if group == "" || processID == "" || businessKey == "" {
+		return nil, errs.New(
+			errs.M("FindByBusinessKey: a group, a process and a key are required"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	return r.queryIDs(ctx, "FindByBusinessKey", r.q.findByBusinessKey,
+		group, processID, businessKey)
+}
+
+// queryIDs runs a single-column id query — the shape both listings
+// share — naming op in any error.
+func (r *Repo) queryIDs(
+	ctx context.Context, op, query string, args ...any,
+) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, opErr("ListInFlight", "", err)
+		return nil, opErr(op, "", err)
 	}
 	defer func() {
 		if cerr := rows.Close(); cerr != nil {
-			r.logger.Warn("ListInFlight: rows close failed", "error", cerr.Error())
+			r.logger.Warn(op+": rows close failed", "error", cerr.Error())
 		}
 	}()
 
@@ -225,14 +250,14 @@ func (r *Repo) ListInFlight(
 	for rows.Next() {
 		var id string
 		if err := rows.Scan(&id); err != nil {
-			return nil, opErr("ListInFlight (scan)", "", err)
+			return nil, opErr(op+" (scan)", "", err)
 		}
 
 		ids = append(ids, id)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, opErr("ListInFlight (rows)", "", err)
+		return nil, opErr(op+" (rows)", "", err)
 	}
 
 	return ids, nil
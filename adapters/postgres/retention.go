@@ -0,0 +1,104 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+)
+
+// Cleanup purges the history of the instances that ended before the
+// cutoff — the instance row and every event, activity, task and
+// incident row of the instance — in one transaction, and returns how
+// many instances went. The rows of an instance history never saw start
+// or end (its facts were dropped) go once they are older than the
+// cutoff; a running instance's history stays.
+func (h *History) Cleanup(ctx context.Context, before time.Time) (int64, error) {
+	if before.IsZero() {
+		return 0, errs.New(
+			errs.M("Cleanup: a cutoff is required"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	tx, err := h.repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, opErr("history Cleanup (begin)", "", err)
+	}
+	defer func() {
+		if rbErr := tx.Rollback(); rbErr != nil &&
+			!errors.Is(rbErr, sql.ErrTxDone) {
+			h.repo.logger.Warn("history Cleanup: rollback failed",
+				"error", rbErr.Error())
+		}
+	}()
+
+	// the dependent rows first: they find their instances through the
+	// rows purgeInstances removes.
+	for _, q := range h.q.purge {
+		if _, err := tx.ExecContext(ctx, q,
+			h.group, h.tenant, before); err != nil {
+			return 0, opErr("history Cleanup", "", err)
+		}
+	}
+
+	res, err := tx.ExecContext(ctx, h.q.purgeInstances,
+		h.group, h.tenant, before)
+	if err != nil {
+		return 0, opErr("history Cleanup (instances)", "", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, opErr("history Cleanup (outcome)", "", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, opErr("history Cleanup (commit)", "", err)
+	}
+
+	return n, nil
+}
+
+// RunRetention is the retention job: it runs Cleanup at once and then
+// every interval, each time purging the history that ended more than
+// keep ago, until ctx ends. A failed run is logged at Warn and retried
+// at the next tick. Run it on its own goroutine; one engine of the
+// group is enough — concurrent runs only find less to purge.
+func (h *History) RunRetention(
+	ctx context.Context, every, keep time.Duration,
+) error {
+	if every <= 0 || keep <= 0 {
+		return errs.New(
+			errs.M("RunRetention: the interval (%v) and the retention (%v)"+
+				" must be positive", every, keep),
+			errs.C(errorClass, errs.InvalidParameter))
+	}
+
+	tick := time.NewTicker(every)
+	defer tick.Stop()
+
+	for {
+		n, err := h.Cleanup(ctx, time.Now().Add(-keep))
+
+		switch {
+		case ctx.Err() != nil:
+			return nil
+
+		case err != nil:
+			h.repo.logger.Warn("history retention run failed",
+				"group", h.group, "tenant", h.tenant, "error", err.Error())
+
+		case n > 0:
+			h.repo.logger.Info("history retention purged instances",
+				"group", h.group, "tenant", h.tenant, "instances", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-tick.C:
+		}
+	}
+}
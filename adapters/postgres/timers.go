@@ -0,0 +1,87 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/repository"
+)
+
+// PutTimer records the deadline, replacing one under the same
+// instance, track and event (repository.TimerIndex).
+func (r *Repo) PutTimer(ctx context.Context, timer repository.DueTimer) error {
+	if timer.Group == "" || timer.InstanceID == "" || timer.TrackID == "" {
+		return errs.New(
+			errs.M("PutTimer: a group, an instance and a track are required"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	if _, err := r.db.ExecContext(ctx, r.q.timerPut,
+		timer.Group, timer.InstanceID, timer.TrackID, timer.EventID,
+		timer.Deadline); err != nil {
+		return opErr("PutTimer", timer.InstanceID, err)
+	}
+
+	return nil
+}
+
+// DeleteTimers removes the instance's deadlines of one timer, one
+// track or — with an empty trackID — all of them
+// (repository.TimerIndex).
+func (r *Repo) DeleteTimers(
+	ctx context.Context, instanceID, trackID, eventID string,
+) error {
+	if _, err := r.db.ExecContext(ctx, r.q.timerDelete,
+		instanceID, trackID, eventID); err != nil {
+		return opErr("DeleteTimers", instanceID, err)
+	}
+
+	return nil
+}
+
+// DueTimers returns the group's deadlines at or before `before`,
+// earliest first, at most limit of them, leaving out those of a
+// suspended instance and of one leased at now (repository.TimerIndex).
+func (r *Repo) DueTimers(
+	ctx context.Context, group string, before, now time.Time, limit int,
+) ([]repository.DueTimer, error) {
+	if group == "" {
+		return nil, errs.New(
+			errs.M("DueTimers: an engine group is required"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	var lim any
+	if limit > 0 {
+		lim = limit
+	}
+
+	rows, err := r.db.QueryContext(ctx, r.q.timersDue, group, before, now, lim)
+	if err != nil {
+		return nil, opErr("DueTimers", "", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			r.logger.Warn("DueTimers: rows close failed", "error", cerr.Error())
+		}
+	}()
+
+	var due []repository.DueTimer
+
+	for rows.Next() {
+		d := repository.DueTimer{Group: group}
+
+		if err := rows.Scan(&d.InstanceID, &d.TrackID, &d.EventID, &d.Deadline); err != nil {
+			return nil, opErr("DueTimers (scan)", "", err)
+		}
+
+		due = append(due, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, opErr("DueTimers (rows)", "", err)
+	}
+
+	return due, nil
+}
@@ -0,0 +1,180 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/messaging"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+)
+
+// broadcastBuffer is the channel buffer of a broadcast subscription.
+const broadcastBuffer = 16
+
+// broadcast is the body of a broadcast on its pub/sub channel.
+type broadcast struct {
+	Name    string          `json:"name"`
+	ID      string          `json:"id,omitempty"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// Broadcast publishes msg on the group's pub/sub channel for its name, to
+// every broadcast subscriber (messaging.Broadcaster). Nothing is stored: a
+// broadcast nobody listens to is gone. The MessageID travels along for the
+// receiver to deduplicate — pub/sub doesn't.
+func (b *Broker) Broadcast(ctx context.Context, msg messaging.Envelope) error {
+	if msg.Name == "" {
+		return errs.New(
+			errs.M("Broadcast: an empty message name isn't allowed"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	payload, err := json.Marshal(msg.Payload)
+	if err != nil {
+		return errs.New(
+			errs.M("Broadcast: the payload isn't JSON-encodable"),
+			errs.C(errorClass, errs.InvalidParameter),
+			errs.D(observability.AttrMessageName, msg.Name),
+			errs.E(err))
+	}
+
+	body, err := json.Marshal(broadcast{
+		Name: msg.Name, ID: msg.MessageID, Payload: payload,
+	})
+	if err != nil {
+		return errs.New(
+			errs.M("Broadcast: the message couldn't be encoded"),
+			errs.C(errorClass, errs.InvalidParameter),
+			errs.D(observability.AttrMessageName, msg.Name),
+			errs.E(err))
+	}
+
+	if err := b.rdb.Publish(ctx, b.broadcastChannel(msg.Name), body).Err(); err != nil {
+		return errs.New(
+			errs.M("Broadcast: the message couldn't be published"),
+			errs.C(errorClass, errs.OperationFailed),
+			errs.D(observability.AttrMessageName, msg.Name),
+			errs.E(err))
+	}
+
+	b.logger.Debug("redis: broadcast", observability.AttrMessageName, msg.Name)
+
+	return nil
+}
+
+// SubscribeBroadcast subscribes to the group's broadcasts named name
+// (messaging.Broadcaster). A broadcast arriving while the channel is full
+// is dropped with a warning; AddKey is rejected.
+func (b *Broker) SubscribeBroadcast(
+	ctx context.Context, name string,
+) (messaging.Subscription, error) {
+	if name == "" {
+		return nil, errs.New(
+			errs.M("SubscribeBroadcast: an empty message name isn't allowed"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	ps := b.rdb.Subscribe(ctx, b.broadcastChannel(name))
+
+	// the confirmation makes the server hold the interest before we
+	// return, so a broadcast made after the call reaches this subscriber.
+	if _, err := ps.Receive(ctx); err != nil {
+		_ = ps.Close()
+
+		return nil, errs.New(
+			errs.M("SubscribeBroadcast: the channel couldn't be subscribed"),
+			errs.C(errorClass, errs.OperationFailed),
+			errs.D(observability.AttrMessageName, name),
+			errs.E(err))
+	}
+
+	s := &broadcastSub{
+		ps:   ps,
+		ch:   make(chan messaging.Envelope, broadcastBuffer),
+		name: name,
+	}
+
+	go s.relay(b.logger)
+
+	b.logger.Debug("redis: broadcast subscribed", observability.AttrMessageName, name)
+
+	return s, nil
+}
+
+// broadcastChannel is the pub/sub channel of the group's broadcasts named
+// name.
+func (b *Broker) broadcastChannel(name string) string {
+	return b.prefix + ":bcast:" + token(b.group) + ":" + token(name)
+}
+
+// broadcastSub is a live pub/sub subscription to broadcasts.
+type broadcastSub struct {
+	ps   *redis.PubSub
+	ch   chan messaging.Envelope
+	name string
+	once sync.Once
+}
+
+// C returns the channel of the broadcasts.
+func (s *broadcastSub) C() <-chan messaging.Envelope { return s.ch }
+
+// AddKey is rejected: a broadcast has no conversation.
+func (s *broadcastSub) AddKey(string) error {
+	return errs.New(
+		errs.M("redis.AddKey: a broadcast subscription takes no keys"),
+		errs.C(errorClass, errs.InvalidParameter),
+		errs.D(observability.AttrMessageName, s.name))
+}
+
+// Unsubscribe ends the subscription. Idempotent.
+func (s *broadcastSub) Unsubscribe() error {
+	var err error
+
+	s.once.Do(func() { err = s.ps.Close() })
+
+	return err
+}
+
+// relay decodes the channel's messages until the subscription ends.
+func (s *broadcastSub) relay(logger observability.Logger) {
+	for m := range s.ps.Channel() {
+		var bc broadcast
+
+		env := messaging.Envelope{}
+
+		err := json.Unmarshal([]byte(m.Payload), &bc)
+		if err == nil {
+			env.Name, env.MessageID = bc.Name, bc.ID
+			err = json.Unmarshal(bc.Payload, &env.Payload)
+		}
+
+		if err == nil && env.Name == "" {
+			err = errs.New(
+				errs.M("the broadcast carries no name"),
+				errs.C(errorClass, errs.InvalidObject))
+		}
+
+		if err != nil {
+			logger.Warn("redis: undecodable broadcast dropped",
+				"channel", m.Channel, observability.AttrError, err.Error())
+
+			continue
+		}
+
+		select {
+		case s.ch <- env:
+		default:
+			logger.Warn("redis: broadcast subscriber busy; broadcast missed",
+				observability.AttrMessageName, env.Name, observability.AttrMessageID, env.MessageID)
+		}
+	}
+}
+
+var (
+	_ messaging.Broadcaster  = (*Broker)(nil)
+	_ messaging.Subscription = (*broadcastSub)(nil)
+)
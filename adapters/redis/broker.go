@@ -0,0 +1,282 @@
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/messaging"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+)
+
+// The fields of a stream entry.
+const (
+	fieldName    = "name"
+	fieldKey     = "key"
+	fieldID      = "id"
+	fieldPayload = "payload"
+	fieldExpires = "expires"
+)
+
+// Publish appends msg to its name's stream and, when keyed, to its
+// conversation's stream, in one transaction. A MessageID published inside
+// the duplicate window is dropped and reported as published — the first
+// copy is the one delivered. A keyed message without a MessageID is given
+// one: both of its copies carry it, so the engine drops the second.
+func (b *Broker) Publish(ctx context.Context, msg messaging.Envelope) error {
+	if msg.Name == "" {
+		return errs.New(
+			errs.M("Publish: an empty message name isn't allowed"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	body, err := json.Marshal(msg.Payload)
+	if err != nil {
+		return errs.New(
+			errs.M("Publish: the payload isn't JSON-encodable"),
+			errs.C(errorClass, errs.InvalidParameter),
+			errs.D(observability.AttrMessageName, msg.Name),
+			errs.E(err))
+	}
+
+	values := map[string]any{fieldName: msg.Name, fieldPayload: string(body)}
+
+	if msg.CorrelationKey != "" {
+		values[fieldKey] = msg.CorrelationKey
+
+		if msg.MessageID == "" {
+			values[fieldID] = generatedID()
+		}
+	}
+
+	if msg.MessageID != "" {
+		values[fieldID] = msg.MessageID
+	}
+
+	if msg.TTL > 0 {
+		values[fieldExpires] = time.Now().Add(msg.TTL).UnixMilli()
+	}
+
+	if msg.MessageID != "" {
+		fresh, err := b.rdb.SetNX(ctx, b.idKey(msg.MessageID), 1, b.duplicates).Result()
+		if err != nil {
+			return errs.New(
+				errs.M("Publish: the MessageID couldn't be recorded"),
+				errs.C(errorClass, errs.OperationFailed),
+				errs.D(observability.AttrMessageName, msg.Name),
+				errs.E(err))
+		}
+
+		if !fresh {
+			b.logger.Debug("redis: duplicate dropped",
+				observability.AttrMessageName, msg.Name, observability.AttrMessageID, msg.MessageID)
+
+			return nil
+		}
+	}
+
+	if _, err := b.rdb.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.XAdd(ctx, &redis.XAddArgs{
+			Stream: b.nameStream(msg.Name),
+			MaxLen: b.maxLen,
+			Approx: b.maxLen > 0,
+			Values: values,
+		})
+
+		if msg.CorrelationKey != "" {
+			conv := b.keyStream(msg.Name, msg.CorrelationKey)
+
+			p.XAdd(ctx, &redis.XAddArgs{Stream: conv, Values: values})
+			p.Expire(ctx, conv, b.keyIdle)
+		}
+
+		return nil
+	}); err != nil {
+		if msg.MessageID != "" {
+			// the message wasn't taken: let a retry through the dedup.
+			_ = b.rdb.Del(ctx, b.idKey(msg.MessageID)).Err()
+		}
+
+		return errs.New(
+			errs.M("Publish: the stream didn't take the message"),
+			errs.C(errorClass, errs.OperationFailed),
+			errs.D(observability.AttrMessageName, msg.Name),
+			errs.E(err))
+	}
+
+	b.logger.Debug("redis: published",
+		observability.AttrMessageName, msg.Name,
+		observability.AttrCorrelationValue, msg.CorrelationKey)
+
+	return nil
+}
+
+// Subscribe registers interest in messages named name. With no keys (or
+// only empty keys) the subscription is a wildcard reading the name's
+// stream; otherwise every key reads its conversation's stream. Entries
+// buffered in a stream before the call are delivered to it.
+func (b *Broker) Subscribe(
+	ctx context.Context, name string, keys ...string,
+) (messaging.Subscription, error) {
+	if name == "" {
+		return nil, errs.New(
+			errs.M("Subscribe: an empty message name isn't allowed"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	s := &subscription{
+		b:       b,
+		ch:      make(chan messaging.Envelope),
+		settled: make(chan error),
+		stop:    make(chan struct{}),
+		keys:    map[string]struct{}{},
+		name:    name,
+	}
+
+	var err error
+
+	for _, k := range keys {
+		if k != "" {
+			if err = s.addKey(ctx, k); err != nil {
+				break
+			}
+		}
+	}
+
+	if err == nil && len(s.keys) == 0 {
+		err = s.read(ctx, b.nameStream(name), true)
+	}
+
+	if err != nil {
+		_ = s.Unsubscribe()
+
+		return nil, err
+	}
+
+	b.logger.Debug("redis: subscribed",
+		observability.AttrMessageName, name, "keys", len(s.keys))
+
+	return s, nil
+}
+
+// generatedID mints the MessageID of a keyed message published without
+// one. It is unique, so it skips the duplicate window.
+func generatedID() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+
+	return "redis-" + hex.EncodeToString(id)
+}
+
+// nameStream is the stream of every message named name.
+func (b *Broker) nameStream(name string) string {
+	return b.prefix + ":{" + token(name) + "}"
+}
+
+// keyStream is the stream of the conversation key of name.
+func (b *Broker) keyStream(name, key string) string {
+	return b.nameStream(name) + ":" + token(key)
+}
+
+// idKey remembers a published MessageID.
+func (b *Broker) idKey(id string) string {
+	return b.prefix + ":id:" + token(id)
+}
+
+// joinGroup makes the broker's consumer group read stream from its first
+// entry; a conversation's stream starts its idle expiry.
+func (b *Broker) joinGroup(ctx context.Context, stream string, conversation bool) error {
+	err := b.rdb.XGroupCreateMkStream(ctx, stream, b.group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return err
+	}
+
+	if conversation {
+		return b.rdb.Expire(ctx, stream, b.keyIdle).Err()
+	}
+
+	return nil
+}
+
+// conversation reports whether the group reads the conversation key of
+// name — then a keyed message belongs to it.
+func (b *Broker) conversation(ctx context.Context, name, key string) (bool, error) {
+	groups, err := b.rdb.XInfoGroups(ctx, b.keyStream(name, key)).Result()
+	if err != nil {
+		if strings.Contains(err.Error(), "no such key") {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	for _, g := range groups {
+		if g.Name == b.group {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// token encodes s for a Redis key, keeping it free of the hash-tag braces
+// and the ':' separator.
+func token(s string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(s))
+}
+
+// decode rebuilds the envelope a stream entry carries, with its expiry
+// (zero when it has none).
+func decode(m redis.XMessage) (messaging.Envelope, time.Time, error) {
+	field := func(k string) string {
+		v, _ := m.Values[k].(string)
+
+		return v
+	}
+
+	env := messaging.Envelope{
+		Name:           field(fieldName),
+		CorrelationKey: field(fieldKey),
+		MessageID:      field(fieldID),
+	}
+
+	if env.Name == "" {
+		return env, time.Time{}, errs.New(
+			errs.M("the entry carries no %q field", fieldName),
+			errs.C(errorClass, errs.InvalidObject),
+			errs.D("entry", m.ID))
+	}
+
+	if err := json.Unmarshal([]byte(field(fieldPayload)), &env.Payload); err != nil {
+		return env, time.Time{}, errs.New(
+			errs.M("the entry payload isn't JSON"),
+			errs.C(errorClass, errs.InvalidObject),
+			errs.D(observability.AttrMessageName, env.Name),
+			errs.E(err))
+	}
+
+	var expires time.Time
+
+	if v := field(fieldExpires); v != "" {
+		ms, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return env, time.Time{}, errs.New(
+				errs.M("the entry expiry isn't a timestamp"),
+				errs.C(errorClass, errs.InvalidObject),
+				errs.D(observability.AttrMessageName, env.Name),
+				errs.E(err))
+		}
+
+		expires = time.UnixMilli(ms)
+	}
+
+	return env, expires, nil
+}
@@ -0,0 +1,315 @@
+package redis_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gobpm/pkg/messaging"
+	"github.com/dr-dobermann/gobpm/pkg/model/activities"
+	"github.com/dr-dobermann/gobpm/pkg/model/bpmncommon"
+	"github.com/dr-dobermann/gobpm/pkg/model/data"
+	"github.com/dr-dobermann/gobpm/pkg/model/data/goexpr"
+	"github.com/dr-dobermann/gobpm/pkg/model/data/values"
+	"github.com/dr-dobermann/gobpm/pkg/model/events"
+	"github.com/dr-dobermann/gobpm/pkg/model/flow"
+	"github.com/dr-dobermann/gobpm/pkg/model/foundation"
+	"github.com/dr-dobermann/gobpm/pkg/model/process"
+	"github.com/dr-dobermann/gobpm/pkg/model/service"
+	"github.com/dr-dobermann/gobpm/pkg/model/service/gooper"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+	"github.com/dr-dobermann/gobpm/pkg/repository"
+	"github.com/dr-dobermann/gobpm/pkg/repository/memrepo"
+	"github.com/dr-dobermann/gobpm/pkg/thresher"
+)
+
+// The conversation proof across an engine group — two engines, each over
+// its own client to one Redis server: a keyed message start
+// instantiates ONE handler, and the conversation's follow-up reaches
+// that handler whichever engine runs it.
+
+// factWatch collects engine facts for the group assertions.
+type factWatch struct {
+	mu    sync.Mutex
+	facts []observability.Fact
+}
+
+func (fw *factWatch) OnFact(f observability.Fact) {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	fw.facts = append(fw.facts, f)
+}
+
+// instances adds to ids the instances that reached phase p — by id, as a
+// hydration re-announces its instance.
+func (fw *factWatch) instances(p observability.Phase, ids map[string]struct{}) {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	for _, f := range fw.facts {
+		if f.Kind == observability.KindInstanceState && f.Phase == p {
+			ids[f.Details[observability.AttrInstanceID]] = struct{}{}
+		}
+	}
+}
+
+// orderKey builds the handler's CorrelationKey: the order id read from
+// the "order placed" payload bound under item id "order_in".
+func orderKey(t *testing.T) *bpmncommon.CorrelationKey {
+	t.Helper()
+
+	mp, err := goexpr.New(nil, data.MustItemDefinition(values.NewVariable("")),
+		func(ctx context.Context, ds data.Source) (data.Value, error) {
+			d, err := ds.Find(ctx, "order_in")
+			if err != nil {
+				return nil, err
+			}
+
+			return values.NewVariable(fmt.Sprint(d.Value().Get(ctx))), nil
+		})
+	require.NoError(t, err)
+
+	re, err := bpmncommon.NewCorrelationPropertyRetrievalExpression(mp,
+		bpmncommon.MustMessage("order placed", data.MustItemDefinition(
+			values.NewVariable(""), foundation.WithID("order_in"))))
+	require.NoError(t, err)
+
+	prop, err := bpmncommon.NewCorrelationProperty("orderId", "string",
+		[]bpmncommon.CorrelationPropertyRetrievalExpression{*re})
+	require.NoError(t, err)
+
+	key, err := bpmncommon.NewCorrelationKey("orderKey",
+		[]bpmncommon.CorrelationProperty{*prop})
+	require.NoError(t, err)
+
+	return key
+}
+
+// orderProcess builds the conversation handler with PINNED node ids
+// (deployment parity across the group, ADR-033 §2.8):
+//
+//	start("order placed", keyed by orderId) -> catch("payment received")
+//	  -> report(pay_in) -> end
+//
+// report pushes the bound payment payload to got.
+func orderProcess(t *testing.T, key string, got chan<- string) *process.Process {
+	t.Helper()
+
+	require.NoError(t, data.CreateDefaultStates())
+
+	p, err := process.New(key, foundation.WithID(key))
+	require.NoError(t, err)
+
+	start, err := events.NewStartEvent("start",
+		events.WithMessageTrigger(events.MustMessageEventDefinition(
+			bpmncommon.MustMessage("order placed", data.MustItemDefinition(
+				values.NewVariable(""), foundation.WithID("order_in"))), nil)),
+		events.WithCorrelationKey(orderKey(t)),
+		foundation.WithID(key+"-start"))
+	require.NoError(t, err)
+
+	catch, err := events.NewIntermediateCatchEvent("await-payment",
+		events.MustMessageEventDefinition(
+			bpmncommon.MustMessage("payment received", data.MustItemDefinition(
+				values.NewVariable(""), foundation.WithID("pay_in"))), nil),
+		foundation.WithID(key+"-catch"))
+	require.NoError(t, err)
+
+	op, err := gooper.New(key+"-report",
+		func(ctx context.Context, r service.DataReader,
+			_ *data.ItemDefinition) (*data.ItemDefinition, error) {
+			pay, err := r.GetDataByID("pay_in")
+			if err != nil {
+				return nil, fmt.Errorf("read pay_in: %w", err)
+			}
+
+			got <- fmt.Sprint(pay.Value().Get(ctx))
+
+			return nil, nil
+		})
+	require.NoError(t, err)
+
+	report, err := activities.NewServiceTask(key+"-report", op,
+		activities.WithoutParams(), foundation.WithID(key+"-report"))
+	require.NoError(t, err)
+
+	end, err := events.NewEndEvent("end", foundation.WithID(key+"-end"))
+	require.NoError(t, err)
+
+	for _, e := range []flow.Element{start, catch, report, end} {
+		require.NoError(t, p.Add(e))
+	}
+
+	for _, pair := range [][2]flow.Element{
+		{start, catch}, {catch, report}, {report, end},
+	} {
+		_, err := flow.Link(pair[0].(flow.SequenceSource),
+			pair[1].(flow.SequenceTarget))
+		require.NoError(t, err)
+	}
+
+	return p
+}
+
+// bootEngine runs an engine of group over the shared store and its own
+// broker, with the process registered BEFORE Run (deployment parity).
+func bootEngine(
+	t *testing.T, name, group string, repo repository.Repository,
+	broker messaging.MessageBroker, p *process.Process,
+) (*thresher.Thresher, *factWatch) {
+	t.Helper()
+
+	th, err := thresher.New(name,
+		thresher.WithoutBanner(), thresher.WithoutStartupConfig(),
+		thresher.WithRepository(repo),
+		thresher.WithMessageBroker(broker),
+		thresher.WithEngineGroup(group),
+		thresher.WithLeaseTTL(time.Minute))
+	require.NoError(t, err)
+
+	fw := &factWatch{}
+	sub := th.Observe(fw)
+	t.Cleanup(sub.Cancel)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	_, err = th.RegisterProcess(p)
+	require.NoError(t, err)
+	require.NoError(t, th.Run(ctx))
+
+	return th, fw
+}
+
+// TestGroupConversation: the group's two engines share the starter's
+// consumer group, so the keyed start creates exactly one instance; the
+// follow-up published through the OTHER engine's broker reaches it by
+// its conversation stream and completes it.
+func TestGroupConversation(t *testing.T) {
+	ctx := context.Background()
+	srv := miniredis.RunT(t)
+	repo := memrepo.New()
+	got := make(chan string, 2)
+
+	brokerA := newBroker(t, srv, "g")
+	brokerB := newBroker(t, srv, "g")
+
+	_, fwA := bootEngine(t, "engine-a", "g", repo, brokerA,
+		orderProcess(t, "redis-e2e", got))
+	_, fwB := bootEngine(t, "engine-b", "g", repo, brokerB,
+		orderProcess(t, "redis-e2e", got))
+
+	instances := func(p observability.Phase) int {
+		ids := map[string]struct{}{}
+		fwA.instances(p, ids)
+		fwB.instances(p, ids)
+
+		return len(ids)
+	}
+
+	require.NoError(t, brokerA.Publish(ctx, messaging.Envelope{
+		Name: "order placed", CorrelationKey: "ORD-1",
+		MessageID: "order-1", Payload: "ORD-1"}))
+
+	require.Eventually(t, func() bool {
+		return instances(observability.PhaseCreated) == 1
+	}, 3*time.Second, 10*time.Millisecond,
+		"the keyed start must instantiate a handler")
+
+	require.NoError(t, brokerB.Publish(ctx, messaging.Envelope{
+		Name: "payment received", CorrelationKey: "ORD-1",
+		MessageID: "payment-1", Payload: "PAY-1"}))
+
+	select {
+	case pay := <-got:
+		require.Equal(t, "PAY-1", pay,
+			"the handler must bind its conversation's payment")
+	case <-time.After(5 * time.Second):
+		t.Fatal("the follow-up did not reach the handler")
+	}
+
+	require.Eventually(t, func() bool {
+		return instances(observability.PhaseCompleted) == 1
+	}, 3*time.Second, 10*time.Millisecond,
+		"the handler must complete")
+
+	require.Equal(t, 1, instances(observability.PhaseCreated),
+		"the group must start ONE handler per order")
+}
+
+// signalProcess builds start -> catch(signal "go-live") -> end with PINNED
+// node ids.
+func signalProcess(t *testing.T, key string) *process.Process {
+	t.Helper()
+
+	p, err := process.New(key, foundation.WithID(key))
+	require.NoError(t, err)
+
+	start, err := events.NewStartEvent("start", foundation.WithID(key+"-start"))
+	require.NoError(t, err)
+
+	catch, err := events.NewIntermediateCatchEvent("await-signal",
+		signalDefinition(t), foundation.WithID(key+"-catch"))
+	require.NoError(t, err)
+
+	end, err := events.NewEndEvent("end", foundation.WithID(key+"-end"))
+	require.NoError(t, err)
+
+	for _, e := range []flow.Element{start, catch, end} {
+		require.NoError(t, p.Add(e))
+	}
+
+	for _, pair := range [][2]flow.Element{{start, catch}, {catch, end}} {
+		_, err := flow.Link(pair[0].(flow.SequenceSource),
+			pair[1].(flow.SequenceTarget))
+		require.NoError(t, err)
+	}
+
+	return p
+}
+
+// signalDefinition builds the "go-live" signal definition.
+func signalDefinition(t *testing.T) *events.SignalEventDefinition {
+	t.Helper()
+
+	sig, err := events.NewSignal("go-live", nil)
+	require.NoError(t, err)
+
+	def, err := events.NewSignalEventDefinition(sig)
+	require.NoError(t, err)
+
+	return def
+}
+
+// TestGroupSignal: a signal thrown on one engine completes the catch an
+// instance of the other engine waits on — the default signal fan-out rides
+// the broker's broadcast.
+func TestGroupSignal(t *testing.T) {
+	ctx := context.Background()
+	srv := miniredis.RunT(t)
+	repo := memrepo.New()
+
+	thA, _ := bootEngine(t, "engine-a", "g", repo, newBroker(t, srv, "g"),
+		signalProcess(t, "redis-signal"))
+	thB, _ := bootEngine(t, "engine-b", "g", repo, newBroker(t, srv, "g"),
+		signalProcess(t, "redis-signal"))
+
+	h, err := thB.StartLatest("redis-signal")
+	require.NoError(t, err)
+
+	// re-thrown until it lands: the catch arms asynchronously.
+	require.Eventually(t, func() bool {
+		require.NoError(t, thA.PropagateEvent(ctx, signalDefinition(t)))
+
+		rec, ok, _ := repo.Load(ctx, h.ID())
+
+		return ok && rec.Status == repository.StatusCompleted
+	}, 5*time.Second, 100*time.Millisecond,
+		"the signal thrown on A must complete the instance of B")
+}
@@ -0,0 +1,24 @@
+module github.com/dr-dobermann/gobpm/adapters/redis
+
+go 1.25
+
+toolchain go1.25.12
+
+replace github.com/dr-dobermann/gobpm => ../..
+
+require (
+	github.com/alicebob/miniredis/v2 v2.39.0
+	github.com/dr-dobermann/gobpm v0.9.0
+	github.com/redis/go-redis/v9 v9.22.0
+	github.com/stretchr/testify v1.11.1
+)
+
+require (
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/yuin/gopher-lua v1.1.1 // indirect
+	go.uber.org/atomic v1.11.0 // indirect
+	golang.org/x/sys v0.30.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
@@ -0,0 +1,247 @@
+// Package redis provides a messaging.MessageBroker over Redis Streams, for
+// engines whose deployment has Redis but no message bus. Messages live in
+// streams; delivery state lives in consumer groups Redis keeps, so every
+// engine of a group sees the same buffered messages and takes each one
+// once.
+//
+// The mapping:
+//
+//   - every message is an entry of its name's stream, <prefix>:{<name>};
+//     a keyed message is also an entry of its conversation's stream,
+//     <prefix>:{<name>}:<key> — names and keys base64url-encoded, the name
+//     a hash tag so both streams share a cluster slot;
+//   - the entry's fields are the name, the correlation key ("key"), the
+//     MessageID, the JSON-encoded payload and, for an Envelope.TTL, the
+//     expiry — an expired entry is acknowledged and dropped on receipt;
+//   - a MessageID is remembered for WithDuplicateWindow, and a duplicate
+//     published inside it is dropped.
+//
+// Each engine group is one consumer group. A wildcard subscription — an
+// instance-starter, an uncorrelated catch — reads the name's stream, so one
+// engine of the group takes each message. A keyed subscription, and each
+// key AddKey adds, reads the conversation's stream: its messages wait there
+// until the instance's engine takes them, including those published before
+// the key was added. Delivery stays most-specific: a keyed message whose
+// conversation the group reads is left to it, never handed to a wildcard
+// subscriber. A conversation's stream expires after WithKeyIdle without
+// publishes or readers.
+//
+// An entry is acknowledged only once the subscriber took it from C — the
+// channel is unbuffered. The subscription is a messaging.Settler: the
+// engine's message waiters settle each entry themselves, so it is
+// acknowledged once the engine took the message into an instance, and one
+// whose delivery failed stays pending. An entry a stopped engine never
+// settled stays pending and is claimed by another reader of the group once
+// it has been idle for WithClaimIdle. A delivered entry stays in its stream,
+// so a conversation read after a wildcard subscriber took one of its
+// messages sees it again; every keyed entry carries a MessageID — the
+// publisher's, or one Publish mints — and the engine drops the repeat.
+// The broker declares itself cluster-compatible (renv.ClusterAware).
+//
+// The broker is also a messaging.Broadcaster: a broadcast is published on
+// the pub/sub channel <prefix>:bcast:<group>:<name> and reaches every
+// engine of the group listening at the time — the engines' signals travel
+// this way. Nothing of it is stored.
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/messaging"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+	"github.com/dr-dobermann/gobpm/pkg/renv"
+)
+
+const errorClass = "REDIS_BROKER"
+
+const (
+	// DefaultKeyPrefix prefixes every Redis key the broker writes unless
+	// WithKeyPrefix overrides it.
+	DefaultKeyPrefix = "gobpm:msg"
+	// DefaultClaimIdle is how long an entry may stay pending with a reader
+	// before another reader of the group claims it; an entry waiting for
+	// its subscriber is kept fresh meanwhile.
+	DefaultClaimIdle = 30 * time.Second
+	// DefaultPollInterval bounds one blocking read, and so how long a
+	// stopped reader lingers.
+	DefaultPollInterval = 500 * time.Millisecond
+	// DefaultKeyIdle is how long a conversation's stream outlives its last
+	// publish or reader.
+	DefaultKeyIdle = time.Hour
+	// DefaultDuplicateWindow is how long a MessageID is remembered.
+	DefaultDuplicateWindow = 10 * time.Minute
+)
+
+// Broker is the Redis Streams messaging.MessageBroker. Build it with New.
+type Broker struct {
+	rdb          redis.UniversalClient
+	logger       observability.Logger
+	prefix       string
+	group        string
+	consumer     string
+	claimIdle    time.Duration
+	poll         time.Duration
+	keyIdle      time.Duration
+	duplicates   time.Duration
+	maxLen       int64
+	subscription atomic.Uint64
+}
+
+// Option configures a Broker at New.
+type Option func(*Broker) error
+
+// WithKeyPrefix overrides the prefix of the broker's Redis keys (default:
+// DefaultKeyPrefix).
+func WithKeyPrefix(prefix string) Option {
+	return func(b *Broker) error {
+		if prefix == "" || strings.ContainsAny(prefix, "{} ") {
+			return errs.New(
+				errs.M("WithKeyPrefix: %q isn't a valid key prefix", prefix),
+				errs.C(errorClass, errs.InvalidParameter))
+		}
+
+		b.prefix = prefix
+
+		return nil
+	}
+}
+
+// WithClaimIdle sets how long an entry stays pending with a reader before
+// another reader claims it (default: DefaultClaimIdle).
+func WithClaimIdle(d time.Duration) Option {
+	return positive("WithClaimIdle", d, func(b *Broker) { b.claimIdle = d })
+}
+
+// WithPollInterval sets the bound of one blocking read (default:
+// DefaultPollInterval).
+func WithPollInterval(d time.Duration) Option {
+	return positive("WithPollInterval", d, func(b *Broker) { b.poll = d })
+}
+
+// WithKeyIdle sets how long a conversation's stream outlives its last
+// publish or reader (default: DefaultKeyIdle).
+func WithKeyIdle(d time.Duration) Option {
+	return positive("WithKeyIdle", d, func(b *Broker) { b.keyIdle = d })
+}
+
+// WithDuplicateWindow sets how long a MessageID is remembered (default:
+// DefaultDuplicateWindow).
+func WithDuplicateWindow(d time.Duration) Option {
+	return positive("WithDuplicateWindow", d, func(b *Broker) { b.duplicates = d })
+}
+
+// WithMaxLen caps each name's stream at about n entries, the oldest
+// trimmed first (default: uncapped).
+func WithMaxLen(n int64) Option {
+	return func(b *Broker) error {
+		if n <= 0 {
+			return errs.New(
+				errs.M("WithMaxLen: the cap must be positive, got %d", n),
+				errs.C(errorClass, errs.InvalidParameter))
+		}
+
+		b.maxLen = n
+
+		return nil
+	}
+}
+
+// WithLogger sets the structured logger (default: slog.Default()).
+func WithLogger(l observability.Logger) Option {
+	return func(b *Broker) error {
+		if l == nil {
+			return errs.New(
+				errs.M("WithLogger: a nil Logger isn't allowed"),
+				errs.C(errorClass, errs.EmptyNotAllowed))
+		}
+
+		b.logger = l
+
+		return nil
+	}
+}
+
+// positive builds an Option setting a duration that must be positive.
+func positive(name string, d time.Duration, set func(*Broker)) Option {
+	return func(b *Broker) error {
+		if d <= 0 {
+			return errs.New(
+				errs.M("%s: the duration must be positive, got %s", name, d),
+				errs.C(errorClass, errs.InvalidParameter))
+		}
+
+		set(b)
+
+		return nil
+	}
+}
+
+// New builds a Broker over the user-owned client (its addresses,
+// credentials and pool belong to the embedder) for the engine group group
+// — the same name the engines pass to thresher.WithEngineGroup, and the
+// name of the broker's consumer groups. It checks the server is reachable.
+func New(
+	ctx context.Context, rdb redis.UniversalClient, group string, opts ...Option,
+) (*Broker, error) {
+	if rdb == nil {
+		return nil, errs.New(
+			errs.M("New: a nil Redis client isn't allowed"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	if group == "" {
+		return nil, errs.New(
+			errs.M("New: an empty engine group isn't allowed"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	id := make([]byte, 8)
+	_, _ = rand.Read(id)
+
+	b := &Broker{
+		rdb:        rdb,
+		logger:     slog.Default(),
+		prefix:     DefaultKeyPrefix,
+		group:      group,
+		consumer:   hex.EncodeToString(id),
+		claimIdle:  DefaultClaimIdle,
+		poll:       DefaultPollInterval,
+		keyIdle:    DefaultKeyIdle,
+		duplicates: DefaultDuplicateWindow,
+	}
+
+	for _, o := range opts {
+		if err := o(b); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		return nil, errs.New(
+			errs.M("New: the Redis server isn't reachable"),
+			errs.C(errorClass, errs.OperationFailed),
+			errs.E(err))
+	}
+
+	return b, nil
+}
+
+// ClusterCompatibility declares the broker safe to share between engines
+// (renv.ClusterAware, SRD-078 FR-3).
+func (b *Broker) ClusterCompatibility() (bool, string) {
+	return true, "shared Redis streams; one consumer group per engine group"
+}
+
+var (
+	_ messaging.MessageBroker = (*Broker)(nil)
+	_ renv.ClusterAware       = (*Broker)(nil)
+)
@@ -0,0 +1,483 @@
+package redis_test
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gobpm/adapters/redis"
+	"github.com/dr-dobermann/gobpm/pkg/messaging"
+	"github.com/dr-dobermann/gobpm/pkg/renv"
+)
+
+// client opens a connection pool of its own to srv — one per engine, as
+// separate processes would have.
+func client(t *testing.T, srv *miniredis.Miniredis) goredis.UniversalClient {
+	t.Helper()
+
+	rdb := goredis.NewClient(&goredis.Options{Addr: srv.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+
+	return rdb
+}
+
+// newBroker builds a broker of group over its own client to srv, polling
+// briefly so a stopped reader goes quickly.
+func newBroker(
+	t *testing.T, srv *miniredis.Miniredis, group string, opts ...redis.Option,
+) *redis.Broker {
+	t.Helper()
+
+	b, err := redis.New(context.Background(), client(t, srv), group,
+		append([]redis.Option{redis.WithPollInterval(50 * time.Millisecond)},
+			opts...)...)
+	require.NoError(t, err)
+
+	return b
+}
+
+// subscribe subscribes and unsubscribes at cleanup.
+func subscribe(
+	t *testing.T, b messaging.MessageBroker, name string, keys ...string,
+) messaging.Subscription {
+	t.Helper()
+
+	sub, err := b.Subscribe(context.Background(), name, keys...)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = sub.Unsubscribe() })
+
+	return sub
+}
+
+// receive takes the next envelope of sub or fails.
+func receive(t *testing.T, sub messaging.Subscription) messaging.Envelope {
+	t.Helper()
+
+	select {
+	case env := <-sub.C():
+		return env
+	case <-time.After(3 * time.Second):
+		t.Fatal("no message delivered")
+	}
+
+	return messaging.Envelope{}
+}
+
+// silent asserts sub gets nothing for a while.
+func silent(t *testing.T, sub messaging.Subscription) {
+	t.Helper()
+
+	select {
+	case env := <-sub.C():
+		t.Fatalf("unexpected delivery: %+v", env)
+	case <-time.After(300 * time.Millisecond):
+	}
+}
+
+// nameStream is the stream the broker appends messages named name to
+// under the default key prefix.
+func nameStream(name string) string {
+	return redis.DefaultKeyPrefix + ":{" +
+		base64.RawURLEncoding.EncodeToString([]byte(name)) + "}"
+}
+
+func TestNew(t *testing.T) {
+	srv := miniredis.RunT(t)
+	ctx := context.Background()
+
+	t.Run("a nil client is rejected", func(t *testing.T) {
+		_, err := redis.New(ctx, nil, "g")
+		require.Error(t, err)
+	})
+
+	t.Run("an empty group is rejected", func(t *testing.T) {
+		_, err := redis.New(ctx, client(t, srv), "")
+		require.Error(t, err)
+	})
+
+	t.Run("invalid options are rejected", func(t *testing.T) {
+		rdb := client(t, srv)
+
+		for _, o := range []redis.Option{
+			redis.WithKeyPrefix(""),
+			redis.WithKeyPrefix("a{b}"),
+			redis.WithClaimIdle(0),
+			redis.WithPollInterval(-time.Second),
+			redis.WithMaxLen(0),
+			redis.WithLogger(nil),
+		} {
+			_, err := redis.New(ctx, rdb, "g", o)
+			require.Error(t, err)
+		}
+	})
+
+	t.Run("an unreachable server is reported", func(t *testing.T) {
+		down := miniredis.RunT(t)
+		rdb := client(t, down)
+		down.Close()
+
+		_, err := redis.New(ctx, rdb, "g")
+		require.Error(t, err)
+	})
+
+	t.Run("the broker is cluster-compatible", func(t *testing.T) {
+		var ca renv.ClusterAware = newBroker(t, srv, "g")
+
+		ok, reason := ca.ClusterCompatibility()
+		require.True(t, ok)
+		require.NotEmpty(t, reason)
+	})
+}
+
+func TestPublishSubscribe(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("a message published before Subscribe is delivered", func(t *testing.T) {
+		b := newBroker(t, miniredis.RunT(t), "g")
+
+		require.NoError(t, b.Publish(ctx, messaging.Envelope{
+			Name: "order placed", CorrelationKey: "ORD-1",
+			MessageID: "m-1", Payload: "ORD-1"}))
+
+		env := receive(t, subscribe(t, b, "order placed"))
+		require.Equal(t, "order placed", env.Name)
+		require.Equal(t, "ORD-1", env.CorrelationKey)
+		require.Equal(t, "m-1", env.MessageID)
+		require.Equal(t, "ORD-1", env.Payload)
+	})
+
+	t.Run("the correlation key is an entry field", func(t *testing.T) {
+		srv := miniredis.RunT(t)
+		b := newBroker(t, srv, "g")
+
+		require.NoError(t, b.Publish(ctx, messaging.Envelope{
+			Name: "order placed", CorrelationKey: "ORD-1", Payload: "x"}))
+
+		entries, err := client(t, srv).XRange(ctx,
+			nameStream("order placed"), "-", "+").Result()
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		require.Equal(t, "ORD-1", entries[0].Values["key"])
+	})
+
+	t.Run("names and keys outside the key alphabet", func(t *testing.T) {
+		b := newBroker(t, miniredis.RunT(t), "g")
+		sub := subscribe(t, b, "a:{b}", "k:{1}")
+
+		require.NoError(t, b.Publish(ctx, messaging.Envelope{
+			Name: "a:{b}", CorrelationKey: "k:{1}",
+			Payload: map[string]any{"n": 1}}))
+
+		env := receive(t, sub)
+		require.Equal(t, "k:{1}", env.CorrelationKey)
+		require.Equal(t, map[string]any{"n": float64(1)}, env.Payload,
+			"the payload arrives as its JSON decoding")
+	})
+
+	t.Run("invalid messages are rejected", func(t *testing.T) {
+		b := newBroker(t, miniredis.RunT(t), "g")
+
+		require.Error(t, b.Publish(ctx, messaging.Envelope{}))
+		require.Error(t, b.Publish(ctx, messaging.Envelope{
+			Name: "m", Payload: func() {}}))
+
+		_, err := b.Subscribe(ctx, "")
+		require.Error(t, err)
+	})
+}
+
+// TestMostSpecificDelivery: a keyed message goes to its conversation's
+// subscriber, never to a wildcard one; a message of an unclaimed key goes
+// to the wildcard.
+func TestMostSpecificDelivery(t *testing.T) {
+	ctx := context.Background()
+	b := newBroker(t, miniredis.RunT(t), "g")
+
+	wild := subscribe(t, b, "payment received")
+	keyed := subscribe(t, b, "payment received", "ORD-1")
+
+	require.NoError(t, b.Publish(ctx, messaging.Envelope{
+		Name: "payment received", CorrelationKey: "ORD-1", Payload: "p-1"}))
+
+	require.Equal(t, "p-1", receive(t, keyed).Payload)
+	silent(t, wild)
+
+	require.NoError(t, b.Publish(ctx, messaging.Envelope{
+		Name: "payment received", CorrelationKey: "ORD-2", Payload: "p-2"}))
+
+	require.Equal(t, "p-2", receive(t, wild).Payload)
+	silent(t, keyed)
+}
+
+// TestAddKeyDrainsBuffered: a key added later receives the conversation's
+// messages published before it — the group joins the conversation's
+// stream at its first entry.
+func TestAddKeyDrainsBuffered(t *testing.T) {
+	ctx := context.Background()
+	b := newBroker(t, miniredis.RunT(t), "g")
+
+	sub := subscribe(t, b, "payment received", "ORD-1")
+
+	require.NoError(t, b.Publish(ctx, messaging.Envelope{
+		Name: "payment received", CorrelationKey: "ORD-2", Payload: "p-2"}))
+	silent(t, sub)
+
+	require.Error(t, sub.AddKey(""))
+	require.NoError(t, sub.AddKey("ORD-2"))
+	require.NoError(t, sub.AddKey("ORD-2"), "a known key is a no-op")
+
+	require.Equal(t, "p-2", receive(t, sub).Payload)
+}
+
+func TestDuplicateMessageIDDropped(t *testing.T) {
+	ctx := context.Background()
+	b := newBroker(t, miniredis.RunT(t), "g")
+
+	for range 2 {
+		require.NoError(t, b.Publish(ctx, messaging.Envelope{
+			Name: "m", MessageID: "once", Payload: "x"}))
+	}
+
+	sub := subscribe(t, b, "m")
+
+	receive(t, sub)
+	silent(t, sub)
+}
+
+func TestMessageTTL(t *testing.T) {
+	ctx := context.Background()
+	b := newBroker(t, miniredis.RunT(t), "g")
+
+	require.NoError(t, b.Publish(ctx, messaging.Envelope{
+		Name: "m", Payload: "stale", TTL: 50 * time.Millisecond}))
+	require.NoError(t, b.Publish(ctx, messaging.Envelope{
+		Name: "m", Payload: "fresh"}))
+
+	time.Sleep(100 * time.Millisecond)
+
+	sub := subscribe(t, b, "m")
+
+	require.Equal(t, "fresh", receive(t, sub).Payload,
+		"an expired message must not be delivered")
+	silent(t, sub)
+}
+
+// TestAckAfterAccept: an entry stays pending while it waits on C and is
+// acknowledged once the receiver took it.
+func TestAckAfterAccept(t *testing.T) {
+	ctx := context.Background()
+	srv := miniredis.RunT(t)
+	rdb := client(t, srv)
+	b := newBroker(t, srv, "g")
+
+	sub := subscribe(t, b, "m")
+
+	require.NoError(t, b.Publish(ctx, messaging.Envelope{Name: "m", Payload: "x"}))
+
+	pending := func() int64 {
+		p, err := rdb.XPending(ctx, nameStream("m"), "g").Result()
+		require.NoError(t, err)
+
+		return p.Count
+	}
+
+	require.Eventually(t, func() bool { return pending() == 1 },
+		time.Second, 10*time.Millisecond,
+		"the entry must be read and wait for the receiver")
+
+	receive(t, sub)
+
+	require.Eventually(t, func() bool { return pending() == 0 },
+		time.Second, 10*time.Millisecond,
+		"the entry must be acknowledged once taken")
+}
+
+// TestSettleManually: in manual settlement an entry the receiver took stays
+// pending until it is settled — an error leaves it to be delivered again,
+// nil acknowledges it.
+func TestSettleManually(t *testing.T) {
+	ctx := context.Background()
+	srv := miniredis.RunT(t)
+	rdb := client(t, srv)
+	b := newBroker(t, srv, "g", redis.WithClaimIdle(200*time.Millisecond))
+
+	sub := subscribe(t, b, "m")
+	st, ok := sub.(messaging.Settler)
+	require.True(t, ok)
+	st.SettleManually()
+
+	require.NoError(t, b.Publish(ctx, messaging.Envelope{Name: "m", Payload: "x"}))
+
+	pending := func() int64 {
+		p, err := rdb.XPending(ctx, nameStream("m"), "g").Result()
+		require.NoError(t, err)
+
+		return p.Count
+	}
+
+	require.Equal(t, "x", receive(t, sub).Payload)
+	require.Never(t, func() bool { return pending() == 0 },
+		300*time.Millisecond, 20*time.Millisecond,
+		"a taken entry stays pending until settled")
+
+	st.Settle(errors.New("the instance didn't take it"))
+
+	require.Equal(t, "x", receive(t, sub).Payload,
+		"an entry settled with an error is delivered again")
+
+	st.Settle(nil)
+
+	require.Eventually(t, func() bool { return pending() == 0 },
+		time.Second, 10*time.Millisecond,
+		"the entry must be acknowledged once settled")
+}
+
+// TestKeyedMessageIDMinted: both copies of a keyed message published
+// without a MessageID carry the same minted one, so the engine drops the
+// copy a second subscriber takes.
+func TestKeyedMessageIDMinted(t *testing.T) {
+	ctx := context.Background()
+	srv := miniredis.RunT(t)
+	rdb := client(t, srv)
+	b := newBroker(t, srv, "g")
+
+	require.NoError(t, b.Publish(ctx, messaging.Envelope{
+		Name: "m", CorrelationKey: "k", Payload: "x"}))
+	require.NoError(t, b.Publish(ctx, messaging.Envelope{
+		Name: "m", Payload: "anonymous"}))
+
+	byName, err := rdb.XRange(ctx, nameStream("m"), "-", "+").Result()
+	require.NoError(t, err)
+	require.Len(t, byName, 2)
+
+	byKey, err := rdb.XRange(ctx, nameStream("m")+":"+
+		base64.RawURLEncoding.EncodeToString([]byte("k")), "-", "+").Result()
+	require.NoError(t, err)
+	require.Len(t, byKey, 1)
+
+	require.NotEmpty(t, byName[0].Values["id"])
+	require.Equal(t, byName[0].Values["id"], byKey[0].Values["id"])
+	require.NotContains(t, byName[1].Values, "id",
+		"an unkeyed message stays anonymous")
+
+	require.Equal(t, byName[0].Values["id"],
+		receive(t, subscribe(t, b, "m")).MessageID)
+}
+
+// TestGroupSharesWildcard: the engines of one group share the consumer
+// group — each message reaches exactly one of them — while another group
+// gets its own copy.
+func TestGroupSharesWildcard(t *testing.T) {
+	const n = 10
+
+	ctx := context.Background()
+	srv := miniredis.RunT(t)
+
+	subs := []messaging.Subscription{
+		subscribe(t, newBroker(t, srv, "g"), "order placed"),
+		subscribe(t, newBroker(t, srv, "g"), "order placed"),
+	}
+	other := subscribe(t, newBroker(t, srv, "other"), "order placed")
+
+	pub := newBroker(t, srv, "g")
+	for i := range n {
+		require.NoError(t, pub.Publish(ctx, messaging.Envelope{
+			Name: "order placed", Payload: fmt.Sprint(i)}))
+	}
+
+	seen := map[any]int{}
+
+	for range n {
+		select {
+		case env := <-subs[0].C():
+			seen[env.Payload]++
+		case env := <-subs[1].C():
+			seen[env.Payload]++
+		case <-time.After(3 * time.Second):
+			t.Fatalf("only %d of %d messages delivered", len(seen), n)
+		}
+	}
+
+	require.Len(t, seen, n)
+
+	for p, c := range seen {
+		require.Equal(t, 1, c, "message %v delivered more than once", p)
+	}
+
+	silent(t, subs[0])
+	silent(t, subs[1])
+
+	for range n {
+		receive(t, other)
+	}
+}
+
+// TestAbandonedEntryClaimed: an entry a stopped engine never handed over
+// is claimed by another engine of the group once idle past the claim
+// idle.
+func TestAbandonedEntryClaimed(t *testing.T) {
+	ctx := context.Background()
+	srv := miniredis.RunT(t)
+	claim := redis.WithClaimIdle(200 * time.Millisecond)
+
+	first, err := newBroker(t, srv, "g", claim).Subscribe(ctx, "m")
+	require.NoError(t, err)
+
+	b := newBroker(t, srv, "g", claim)
+	require.NoError(t, b.Publish(ctx, messaging.Envelope{Name: "m", Payload: "x"}))
+
+	time.Sleep(100 * time.Millisecond) // the entry waits on first's C
+	require.NoError(t, first.Unsubscribe())
+	require.NoError(t, first.Unsubscribe(), "Unsubscribe is idempotent")
+	require.NoError(t, first.AddKey("k"), "AddKey after Unsubscribe is a no-op")
+
+	require.Equal(t, "x", receive(t, subscribe(t, b, "m")).Payload)
+}
+
+// TestBroadcast: a broadcast reaches every broadcast subscriber of its
+// group, is never stored for a stream reader, and stays inside its group.
+func TestBroadcast(t *testing.T) {
+	ctx := context.Background()
+	srv := miniredis.RunT(t)
+	b := newBroker(t, srv, "g")
+
+	subscribeBroadcast := func(b *redis.Broker) messaging.Subscription {
+		sub, err := b.SubscribeBroadcast(ctx, "sig")
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = sub.Unsubscribe() })
+
+		return sub
+	}
+
+	subs := []messaging.Subscription{
+		subscribeBroadcast(b), subscribeBroadcast(newBroker(t, srv, "g")),
+	}
+	other := subscribeBroadcast(newBroker(t, srv, "other"))
+	stream := subscribe(t, b, "sig")
+
+	require.NoError(t, b.Broadcast(ctx, messaging.Envelope{
+		Name: "sig", MessageID: "s-1", Payload: map[string]any{"n": 1}}))
+
+	for _, sub := range subs {
+		env := receive(t, sub)
+		require.Equal(t, "sig", env.Name)
+		require.Equal(t, "s-1", env.MessageID)
+		require.Equal(t, map[string]any{"n": float64(1)}, env.Payload)
+	}
+
+	silent(t, other)
+	silent(t, stream)
+
+	require.Error(t, subs[0].AddKey("k"))
+	require.NoError(t, subs[0].Unsubscribe())
+	require.NoError(t, subs[0].Unsubscribe(), "Unsubscribe is idempotent")
+
+	require.Error(t, b.Broadcast(ctx, messaging.Envelope{}))
+}
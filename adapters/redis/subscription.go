@@ -0,0 +1,411 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/messaging"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+)
+
+// subscription is a live registration: one reader per stream — the name's
+// stream, or one per key — all feeding ch. Every reader is a consumer of
+// its own in the group. In manual settlement the reader whose envelope the
+// receiver took waits on settled for its verdict; the receiver takes the
+// next envelope only after settling, so one reader waits at a time.
+type subscription struct {
+	b       *Broker
+	ch      chan messaging.Envelope
+	settled chan error
+	stop    chan struct{}
+	keys    map[string]struct{}
+	name    string
+	mu      sync.Mutex
+	manual  atomic.Bool
+	closed  bool
+}
+
+// C returns the channel of envelopes matching the subscription. It is
+// unbuffered: an entry is acknowledged once the receiver takes it, or, after
+// SettleManually, once the receiver settles it.
+func (s *subscription) C() <-chan messaging.Envelope { return s.ch }
+
+// SettleManually keeps every entry taken from C pending until Settle
+// reports on it (messaging.Settler).
+func (s *subscription) SettleManually() { s.manual.Store(true) }
+
+// Settle settles the entry last taken from C: a nil err acknowledges it;
+// an error leaves it pending, to be claimed again after WithClaimIdle. A
+// call outside manual settlement, or after Unsubscribe, is a no-op.
+func (s *subscription) Settle(err error) {
+	if !s.manual.Load() {
+		return
+	}
+
+	select {
+	case s.settled <- err:
+	case <-s.stop:
+	}
+}
+
+// AddKey starts reading key's conversation stream, so the key's buffered
+// and future messages are delivered here (lazy secondary-key association,
+// SRD-017). An empty key is rejected; a known key, or any key after
+// Unsubscribe, is a no-op.
+func (s *subscription) AddKey(key string) error {
+	if key == "" {
+		return errs.New(
+			errs.M("redis.AddKey: an empty correlation key isn't allowed"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	return s.addKey(context.Background(), key)
+}
+
+// addKey starts reading key's conversation stream, joining it at need.
+func (s *subscription) addKey(ctx context.Context, key string) error {
+	s.mu.Lock()
+	_, known := s.keys[key]
+	closed := s.closed
+	s.mu.Unlock()
+
+	if known || closed {
+		return nil
+	}
+
+	if err := s.read(ctx, s.b.keyStream(s.name, key), false); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.keys[key] = struct{}{}
+	s.mu.Unlock()
+
+	s.b.logger.Debug("redis: key added",
+		observability.AttrMessageName, s.name, observability.AttrCorrelationValue, key)
+
+	return nil
+}
+
+// Unsubscribe stops every reader. An entry waiting for the receiver stays
+// pending until another reader of the group claims it (WithClaimIdle).
+// Idempotent.
+func (s *subscription) Unsubscribe() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+
+	s.closed = true
+	close(s.stop)
+
+	s.b.logger.Debug("redis: unsubscribed", observability.AttrMessageName, s.name)
+
+	return nil
+}
+
+// read joins the group to stream and starts a reader on it. A wildcard
+// reader leaves a keyed message to its conversation when the group reads
+// one.
+func (s *subscription) read(ctx context.Context, stream string, wildcard bool) error {
+	if err := s.b.joinGroup(ctx, stream, !wildcard); err != nil {
+		return errs.New(
+			errs.M("the consumer group couldn't be joined"),
+			errs.C(errorClass, errs.OperationFailed),
+			errs.D(observability.AttrMessageName, s.name),
+			errs.E(err))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+
+	consumer := s.b.consumer + "-" + strconv.FormatUint(s.b.subscription.Add(1), 10)
+
+	go s.loop(stream, consumer, wildcard)
+
+	return nil
+}
+
+// loop reads stream as consumer until the subscription stops, claiming
+// entries other readers abandoned on the way.
+func (s *subscription) loop(stream, consumer string, wildcard bool) {
+	ctx := context.Background()
+	defer s.leave(ctx, stream, consumer)
+
+	if !s.claim(ctx, stream, consumer, wildcard) {
+		return
+	}
+
+	tick := time.NewTicker(s.b.claimIdle / 2)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+
+		case <-tick.C:
+			if !s.claim(ctx, stream, consumer, wildcard) {
+				return
+			}
+
+			continue
+
+		default:
+		}
+
+		res, err := s.b.rdb.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    s.b.group,
+			Consumer: consumer,
+			Streams:  []string{stream, ">"},
+			Count:    1,
+			Block:    s.b.poll,
+		}).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+
+		if err != nil {
+			s.b.logger.Warn("redis: read failed",
+				observability.AttrMessageName, s.name, observability.AttrError, err.Error())
+
+			select {
+			case <-s.stop:
+				return
+			case <-time.After(s.b.poll):
+			}
+
+			continue
+		}
+
+		for _, st := range res {
+			for _, m := range st.Messages {
+				if !s.handle(ctx, stream, consumer, m, wildcard) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// claim takes over the entries of stream that stayed pending with another
+// reader past the claim idle, and keeps a conversation's stream from
+// expiring while it is read. It returns false once the subscription
+// stopped.
+func (s *subscription) claim(
+	ctx context.Context, stream, consumer string, wildcard bool,
+) bool {
+	if !wildcard {
+		if err := s.b.rdb.Expire(ctx, stream, s.b.keyIdle).Err(); err != nil {
+			s.b.logger.Warn("redis: conversation expiry not refreshed",
+				observability.AttrMessageName, s.name, observability.AttrError, err.Error())
+		}
+	}
+
+	start := "0-0"
+
+	for {
+		msgs, next, err := s.b.rdb.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+			Stream:   stream,
+			Group:    s.b.group,
+			Consumer: consumer,
+			MinIdle:  s.b.claimIdle,
+			Start:    start,
+			Count:    16,
+		}).Result()
+		if err != nil {
+			s.b.logger.Warn("redis: claim failed",
+				observability.AttrMessageName, s.name, observability.AttrError, err.Error())
+
+			return true
+		}
+
+		for _, m := range msgs {
+			s.b.logger.Debug("redis: abandoned entry claimed",
+				observability.AttrMessageName, s.name, "entry", m.ID)
+
+			if !s.handle(ctx, stream, consumer, m, wildcard) {
+				return false
+			}
+		}
+
+		if next == "0-0" || next == "" {
+			return true
+		}
+
+		start = next
+	}
+}
+
+// handle delivers one entry. An undecodable or expired entry is
+// acknowledged and dropped — no redelivery can fix it. It returns false
+// once the subscription stopped.
+func (s *subscription) handle(
+	ctx context.Context, stream, consumer string, m redis.XMessage, wildcard bool,
+) bool {
+	env, expires, err := decode(m)
+	if err != nil {
+		s.b.logger.Warn("redis: undecodable entry dropped",
+			"entry", m.ID, observability.AttrError, err.Error())
+		s.ack(ctx, stream, m.ID, env)
+
+		return true
+	}
+
+	if !expires.IsZero() && time.Now().After(expires) {
+		s.b.logger.Debug("redis: expired entry dropped",
+			observability.AttrMessageName, env.Name, observability.AttrMessageID, env.MessageID)
+		s.ack(ctx, stream, m.ID, env)
+
+		return true
+	}
+
+	if wildcard && env.CorrelationKey != "" {
+		owned, err := s.b.conversation(ctx, env.Name, env.CorrelationKey)
+		if err != nil {
+			s.b.logger.Warn("redis: conversation lookup failed; entry left pending",
+				observability.AttrMessageName, env.Name, observability.AttrError, err.Error())
+
+			return true
+		}
+
+		if owned {
+			s.b.logger.Debug("redis: left to its conversation",
+				observability.AttrMessageName, env.Name,
+				observability.AttrCorrelationValue, env.CorrelationKey)
+			s.ack(ctx, stream, m.ID, env)
+
+			return true
+		}
+	}
+
+	return s.deliver(ctx, stream, consumer, m.ID, env)
+}
+
+// deliver hands env to the receiver and acknowledges its entry — at once,
+// or in manual settlement once the receiver settles it — keeping the entry
+// from being claimed while the receiver is busy. It returns false when the
+// subscription stopped first, leaving the entry pending.
+func (s *subscription) deliver(
+	ctx context.Context, stream, consumer, id string, env messaging.Envelope,
+) bool {
+	tick := time.NewTicker(s.b.claimIdle / 2)
+	defer tick.Stop()
+
+	for taken := false; ; {
+		var (
+			out     chan messaging.Envelope
+			settled chan error
+		)
+
+		if taken {
+			settled = s.settled
+		} else {
+			out = s.ch
+		}
+
+		select {
+		case out <- env:
+			if s.manual.Load() {
+				taken = true
+
+				continue
+			}
+
+			s.delivered(ctx, stream, id, env, nil)
+
+			return true
+
+		case err := <-settled:
+			s.delivered(ctx, stream, id, env, err)
+
+			return true
+
+		case <-tick.C:
+			s.keep(ctx, stream, consumer, id, env)
+
+		case <-s.stop:
+			return false
+		}
+	}
+}
+
+// delivered acknowledges an entry the receiver took, or leaves it pending
+// when the receiver settled it with an error.
+func (s *subscription) delivered(
+	ctx context.Context, stream, id string, env messaging.Envelope, err error,
+) {
+	if err != nil {
+		s.b.logger.Warn("redis: delivery failed; entry left pending",
+			observability.AttrMessageName, env.Name, observability.AttrError, err.Error())
+
+		return
+	}
+
+	s.ack(ctx, stream, id, env)
+
+	s.b.logger.Debug("redis: delivered",
+		observability.AttrMessageName, env.Name,
+		observability.AttrCorrelationValue, env.CorrelationKey)
+}
+
+// keep re-claims an entry waiting for its receiver, which resets its idle
+// time so no other reader claims it meanwhile.
+func (s *subscription) keep(
+	ctx context.Context, stream, consumer, id string, env messaging.Envelope,
+) {
+	if err := s.b.rdb.XClaimJustID(ctx, &redis.XClaimArgs{
+		Stream:   stream,
+		Group:    s.b.group,
+		Consumer: consumer,
+		Messages: []string{id},
+	}).Err(); err != nil {
+		s.b.logger.Warn("redis: entry not kept in progress",
+			observability.AttrMessageName, env.Name, observability.AttrError, err.Error())
+	}
+}
+
+// ack acknowledges an entry, logging a failure: the entry then stays
+// pending and is claimed again, which the engine's own dedup absorbs.
+func (s *subscription) ack(ctx context.Context, stream, id string, env messaging.Envelope) {
+	if err := s.b.rdb.XAck(ctx, stream, s.b.group, id).Err(); err != nil {
+		s.b.logger.Warn("redis: acknowledgement failed",
+			observability.AttrMessageName, env.Name, observability.AttrError, err.Error())
+	}
+}
+
+// leave removes a stopped reader's consumer from the group unless it still
+// holds a pending entry another reader has yet to claim.
+func (s *subscription) leave(ctx context.Context, stream, consumer string) {
+	pending, err := s.b.rdb.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream:   stream,
+		Group:    s.b.group,
+		Start:    "-",
+		End:      "+",
+		Count:    1,
+		Consumer: consumer,
+	}).Result()
+	if err != nil || len(pending) > 0 {
+		return
+	}
+
+	_ = s.b.rdb.XGroupDelConsumer(ctx, stream, s.b.group, consumer).Err()
+}
+
+var (
+	_ messaging.Subscription = (*subscription)(nil)
+	_ messaging.Settler      = (*subscription)(nil)
+)
@@ -0,0 +1,17 @@
+package sqlite_test
+
+import (
+	"testing"
+
+	"github.com/dr-dobermann/gobpm/pkg/repository"
+	"github.com/dr-dobermann/gobpm/pkg/repository/repositorytest"
+)
+
+// TestConformance proves the adapter against the published Repository
+// contract suite — the same suite memrepo and postgres pass. Every
+// factory call gets its own database file.
+func TestConformance(t *testing.T) {
+	repositorytest.Conformance(t, func(t *testing.T) repository.Repository {
+		return newRepo(t)
+	})
+}
@@ -0,0 +1,362 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/dr-dobermann/gobpm/pkg/datastore"
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/model/data"
+	"github.com/dr-dobermann/gobpm/pkg/renv"
+)
+
+// DataStore is the durable datastore.DataStore over the Repo's file:
+// one engine-global BPMN Data Store's data, a row of data_store_items
+// per name holding the datum in the datastore.EncodeDatum form and its
+// version. Build it with Repo.DataStore and register it through
+// thresher.WithDataStore; Run migrates it. The store is
+// datastore.Versioned. The capacity, when set, is enforced: a write of
+// a new name into a full store fails, a replace always succeeds.
+type DataStore struct {
+	repo     *Repo
+	name     string
+	capacity int
+}
+
+// DataStoreOption configures a DataStore at Repo.DataStore.
+type DataStoreOption func(*DataStore) error
+
+// WithCapacity bounds the store to n names (default: unlimited).
+func WithCapacity(n int) DataStoreOption {
+	return func(s *DataStore) error {
+		if n <= 0 {
+			return errs.New(
+				errs.M("WithCapacity: the capacity must be positive, got %d", n),
+				errs.C(errorClass, errs.InvalidParameter))
+		}
+
+		s.capacity = n
+
+		return nil
+	}
+}
+
+// DataStore builds the Data Store named name over the Repo's database.
+// Stores of distinct names share the table and never each other's
+// data.
+func (r *Repo) DataStore(name string, opts ...DataStoreOption) (*DataStore, error) {
+	if name == "" {
+		return nil, errs.New(
+			errs.M("DataStore: a store name is required"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	s := &DataStore{
+		repo: r,
+		name: name,
+	}
+
+	for _, o := range opts {
+		if err := o(s); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+var (
+	_ datastore.DataStore = (*DataStore)(nil)
+	_ datastore.Versioned = (*DataStore)(nil)
+	_ renv.ClusterAware   = (*DataStore)(nil)
+	_ renv.Migrator       = (*DataStore)(nil)
+)
+
+// ClusterCompatibility declares the store unfit to share between
+// engines (renv.ClusterAware), as the Repo is.
+func (s *DataStore) ClusterCompatibility() (bool, string) {
+	return s.repo.ClusterCompatibility()
+}
+
+// Migrate creates or upgrades the adapter's objects, the Data Store
+// table among them (renv.Migrator) — the Repo's Migrate.
+func (s *DataStore) Migrate(ctx context.Context) error {
+	return s.repo.Migrate(ctx)
+}
+
+// String identifies the store and its file in logs.
+func (s *DataStore) String() string {
+	return "sqlite data store " + s.name + " (" + s.repo.path + ")"
+}
+
+// Capacity reports the store's capacity; 0 means unlimited.
+func (s *DataStore) Capacity() int { return s.capacity }
+
+// IsUnlimited reports whether the store has no capacity bound.
+func (s *DataStore) IsUnlimited() bool { return s.capacity == 0 }
+
+// Get returns the datum stored under name, decoded; the bool is false
+// when none exists.
+func (s *DataStore) Get(ctx context.Context, name string) (data.Data, bool, error) {
+	d, _, ok, err := s.GetVersioned(ctx, name)
+
+	return d, ok, err
+}
+
+// GetVersioned returns the datum stored under name, decoded, and its
+// version; the bool is false (and the version 0) when none exists.
+func (s *DataStore) GetVersioned(
+	ctx context.Context, name string,
+) (data.Data, int64, bool, error) {
+	if name == "" {
+		return nil, 0, false, errs.New(
+			errs.M("DataStore.Get: an empty name isn't allowed"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	var (
+		payload []byte
+		version int64
+	)
+
+	err := s.repo.db.QueryRowContext(ctx, qDataGet, s.name, name).
+		Scan(&payload, &version)
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, 0, false, nil
+
+	case err != nil:
+		return nil, 0, false, opErr("DataStore.Get", name, err)
+	}
+
+	d, err := datastore.DecodeDatum(ctx, payload)
+	if err != nil {
+		return nil, 0, false, errs.New(
+			errs.M("DataStore.Get: the stored %q of %q doesn't decode",
+				name, s.name),
+			errs.C(errorClass, errs.InvalidObject),
+			errs.E(err))
+	}
+
+	return d, version, true, nil
+}
+
+// Put stores (or replaces) d under name. A bounded store checks and
+// writes in one IMMEDIATE transaction, so concurrent Puts — from any
+// handle on the file — never overfill it; a new name past the capacity
+// fails with OutOfRangeError.
+func (s *DataStore) Put(ctx context.Context, name string, d data.Data) error {
+	payload, err := s.encode(ctx, "Put", name, d)
+	if err != nil {
+		return err
+	}
+
+	if s.IsUnlimited() {
+		if _, err := s.repo.db.ExecContext(ctx, qDataPut,
+			s.name, name, payload); err != nil {
+			return opErr("DataStore.Put", name, err)
+		}
+
+		return nil
+	}
+
+	return s.immediate(ctx, "Put", name, func(conn *sql.Conn) error {
+		return s.putLocked(ctx, conn, name, payload)
+	})
+}
+
+// putLocked runs the bounded insert inside the open transaction.
+func (s *DataStore) putLocked(
+	ctx context.Context, conn *sql.Conn, name string, payload []byte,
+) error {
+	res, err := conn.ExecContext(ctx, qDataPutBounded,
+		s.name, name, payload, s.capacity)
+	if err != nil {
+		return opErr("DataStore.Put", name, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return opErr("DataStore.Put (outcome)", name, err)
+	}
+
+	if n == 0 {
+		return s.fullErr(name)
+	}
+
+	return nil
+}
+
+// PutIf stores d under name iff the stored version equals version (0:
+// iff none exists) and returns the new version; a mismatch fails with
+// errs.ConcurrentUpdate. A new name past the capacity fails with
+// OutOfRangeError.
+func (s *DataStore) PutIf(
+	ctx context.Context, name string, d data.Data, version int64,
+) (int64, error) {
+	if version < 0 {
+		return 0, errs.New(
+			errs.M("DataStore.PutIf: a negative version (%d) isn't allowed",
+				version),
+			errs.C(errorClass, errs.InvalidParameter))
+	}
+
+	payload, err := s.encode(ctx, "PutIf", name, d)
+	if err != nil {
+		return 0, err
+	}
+
+	var next int64
+
+	switch {
+	case version > 0:
+		err = s.repo.db.QueryRowContext(ctx, qDataSwap,
+			s.name, name, payload, version).Scan(&next)
+
+	case s.IsUnlimited():
+		err = s.repo.db.QueryRowContext(ctx, qDataCreate,
+			s.name, name, payload).Scan(&next)
+
+	default:
+		err = s.immediate(ctx, "PutIf", name, func(conn *sql.Conn) error {
+			var cerr error
+			next, cerr = s.createLocked(ctx, conn, name, payload)
+
+			return cerr
+		})
+		if err != nil {
+			return 0, err
+		}
+
+		return next, nil
+	}
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return 0, s.conflictErr(name, version)
+
+	case err != nil:
+		return 0, opErr("DataStore.PutIf", name, err)
+	}
+
+	return next, nil
+}
+
+// createLocked inserts a new name into a bounded store inside the open
+// transaction, telling an existing name from a full store.
+func (s *DataStore) createLocked(
+	ctx context.Context, conn *sql.Conn, name string, payload []byte,
+) (int64, error) {
+	var next int64
+
+	err := conn.QueryRowContext(ctx, qDataCreateBounded,
+		s.name, name, payload, s.capacity).Scan(&next)
+	if err == nil {
+		return next, nil
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, opErr("DataStore.PutIf", name, err)
+	}
+
+	var exists bool
+	if err := conn.QueryRowContext(ctx, qDataExists, s.name, name).
+		Scan(&exists); err != nil {
+		return 0, opErr("DataStore.PutIf (exists)", name, err)
+	}
+
+	if exists {
+		return 0, s.conflictErr(name, 0)
+	}
+
+	return 0, s.fullErr(name)
+}
+
+// Delete removes the datum under name (a no-op if it is absent).
+func (s *DataStore) Delete(ctx context.Context, name string) error {
+	if name == "" {
+		return errs.New(
+			errs.M("DataStore.Delete: an empty name isn't allowed"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	if _, err := s.repo.db.ExecContext(ctx, qDataDelete,
+		s.name, name); err != nil {
+		return opErr("DataStore.Delete", name, err)
+	}
+
+	return nil
+}
+
+// encode checks a write's name and datum and encodes the datum.
+func (s *DataStore) encode(
+	ctx context.Context, op, name string, d data.Data,
+) ([]byte, error) {
+	if name == "" || d == nil {
+		return nil, errs.New(
+			errs.M("DataStore.%s: a name and a datum are required", op),
+			errs.C(errorClass, errs.EmptyNotAllowed),
+			errs.D("name", name))
+	}
+
+	return datastore.EncodeDatum(ctx, s.name, d)
+}
+
+// immediate runs fn on a dedicated connection in one IMMEDIATE
+// transaction — BEGIN IMMEDIATE takes the file's write lock before the
+// capacity count, so concurrent writers never overfill the store. An
+// fn error rolls the transaction back.
+func (s *DataStore) immediate(
+	ctx context.Context, op, name string, fn func(*sql.Conn) error,
+) error {
+	conn, err := s.repo.db.Conn(ctx)
+	if err != nil {
+		return opErr("DataStore."+op+" (connection)", name, err)
+	}
+	defer func() {
+		if cerr := conn.Close(); cerr != nil {
+			s.repo.logger.Warn("DataStore."+op+": connection close failed",
+				"error", cerr.Error())
+		}
+	}()
+
+	if _, err := conn.ExecContext(ctx, "BEGIN IMMEDIATE"); err != nil {
+		return opErr("DataStore."+op+" (begin)", name, err)
+	}
+
+	if err := fn(conn); err != nil {
+		if _, rbErr := conn.ExecContext(context.WithoutCancel(ctx),
+			"ROLLBACK"); rbErr != nil {
+			s.repo.logger.Warn("DataStore."+op+": rollback failed",
+				"error", rbErr.Error())
+		}
+
+		return err
+	}
+
+	if _, err := conn.ExecContext(ctx, "COMMIT"); err != nil {
+		return opErr("DataStore."+op+" (commit)", name, err)
+	}
+
+	return nil
+}
+
+// fullErr is the error of a new name written into a full store.
+func (s *DataStore) fullErr(name string) error {
+	return errs.New(
+		errs.M("DataStore: store %q is full (capacity %d)",
+			s.name, s.capacity),
+		errs.C(errorClass, errs.OutOfRangeError),
+		errs.D("name", name))
+}
+
+// conflictErr is the error of a PutIf over a version the datum isn't
+// at.
+func (s *DataStore) conflictErr(name string, version int64) error {
+	return errs.New(
+		errs.M("DataStore.PutIf: %q of %q isn't at version %d",
+			name, s.name, version),
+		errs.C(errorClass, errs.ConcurrentUpdate))
+}
@@ -0,0 +1,252 @@
+package sqlite_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gobpm/adapters/sqlite"
+	"github.com/dr-dobermann/gobpm/pkg/datastore"
+	"github.com/dr-dobermann/gobpm/pkg/datastore/datastoretest"
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/model/data/values"
+	"github.com/dr-dobermann/gobpm/pkg/thresher"
+)
+
+// TestDataStoreConformance proves the sqlite Data Store against the
+// published DataStore contract suite, one fresh file per subtest.
+func TestDataStoreConformance(t *testing.T) {
+	datastoretest.Conformance(t, func(t *testing.T) datastore.DataStore {
+		s, err := newRepo(t).DataStore("orders")
+		require.NoError(t, err)
+
+		return s
+	})
+}
+
+func TestDataStore(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("the data survive a restart", func(t *testing.T) {
+		path := dbPath(t)
+
+		repo, err := sqlite.Open(path)
+		require.NoError(t, err)
+		require.NoError(t, repo.Migrate(ctx))
+
+		s, err := repo.DataStore("orders")
+		require.NoError(t, err)
+		require.NoError(t, s.Put(ctx, "total",
+			datastoretest.Datum(t, "total", values.NewVariable(42))))
+		require.NoError(t, repo.Close())
+
+		s2, err := openRepo(t, path).DataStore("orders")
+		require.NoError(t, err)
+
+		d, ok, err := s2.Get(ctx, "total")
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, 42, d.Value().Get(ctx))
+	})
+
+	t.Run("stores of distinct names never share data", func(t *testing.T) {
+		repo := newRepo(t)
+
+		orders, err := repo.DataStore("orders")
+		require.NoError(t, err)
+		require.NoError(t, orders.Put(ctx, "total",
+			datastoretest.Datum(t, "total", values.NewVariable(1))))
+
+		stock, err := repo.DataStore("stock")
+		require.NoError(t, err)
+
+		_, ok, err := stock.Get(ctx, "total")
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+
+	t.Run("the capacity is enforced", func(t *testing.T) {
+		s, err := newRepo(t).DataStore("orders", sqlite.WithCapacity(2))
+		require.NoError(t, err)
+		require.Equal(t, 2, s.Capacity())
+		require.False(t, s.IsUnlimited())
+
+		for _, n := range []string{"a", "b"} {
+			require.NoError(t, s.Put(ctx, n,
+				datastoretest.Datum(t, n, values.NewVariable(1))))
+		}
+
+		err = s.Put(ctx, "c", datastoretest.Datum(t, "c", values.NewVariable(1)))
+		require.True(t, hasClass(err, errs.OutOfRangeError), "got %v", err)
+
+		require.NoError(t, s.Put(ctx, "a",
+			datastoretest.Datum(t, "a", values.NewVariable(2))),
+			"a replace never grows the store")
+
+		d, _, err := s.Get(ctx, "a")
+		require.NoError(t, err)
+		require.Equal(t, 2, d.Value().Get(ctx))
+	})
+
+	t.Run("a bounded PutIf tells a conflict from a full store", func(t *testing.T) {
+		s, err := newRepo(t).DataStore("orders", sqlite.WithCapacity(1))
+		require.NoError(t, err)
+
+		v, err := s.PutIf(ctx, "a", datastoretest.Datum(t, "a", values.NewVariable(1)), 0)
+		require.NoError(t, err)
+
+		_, err = s.PutIf(ctx, "a", datastoretest.Datum(t, "a", values.NewVariable(2)), 0)
+		require.True(t, hasClass(err, errs.ConcurrentUpdate), "got %v", err)
+
+		_, err = s.PutIf(ctx, "b", datastoretest.Datum(t, "b", values.NewVariable(1)), 0)
+		require.True(t, hasClass(err, errs.OutOfRangeError), "got %v", err)
+
+		next, err := s.PutIf(ctx, "a", datastoretest.Datum(t, "a", values.NewVariable(2)), v)
+		require.NoError(t, err)
+		require.Greater(t, next, v)
+
+		require.NoError(t, s.Delete(ctx, "a"))
+		_, err = s.PutIf(ctx, "b", datastoretest.Datum(t, "b", values.NewVariable(1)), 0)
+		require.NoError(t, err, "a delete frees the capacity")
+	})
+
+	t.Run("concurrent handles never overfill", func(t *testing.T) {
+		path := dbPath(t)
+		openRepo(t, path)
+
+		var (
+			wg   sync.WaitGroup
+			mu   sync.Mutex
+			oks  int
+			full int
+		)
+
+		for i := range 6 {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				s, err := openRepo(t, path).DataStore("orders",
+					sqlite.WithCapacity(2))
+				require.NoError(t, err)
+
+				n := fmt.Sprintf("k%d", i)
+				err = s.Put(ctx, n, datastoretest.Datum(t, n, values.NewVariable(i)))
+
+				mu.Lock()
+				defer mu.Unlock()
+
+				if err == nil {
+					oks++
+				} else if hasClass(err, errs.OutOfRangeError) {
+					full++
+				}
+			}()
+		}
+
+		wg.Wait()
+		require.Equal(t, 2, oks)
+		require.Equal(t, 4, full)
+	})
+}
+
+// TestDataStoreMigratesAtRun: a store registered through
+// thresher.WithDataStore over an unmigrated file is ready once Run
+// returns — no Repository involved.
+func TestDataStoreMigratesAtRun(t *testing.T) {
+	repo, err := sqlite.Open(dbPath(t))
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, repo.Close()) })
+
+	s, err := repo.DataStore("orders")
+	require.NoError(t, err)
+
+	th, err := thresher.New("ds-mig",
+		thresher.WithoutBanner(), thresher.WithoutStartupConfig(),
+		thresher.WithDataStore("orders", s))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	require.NoError(t, th.Run(ctx))
+	require.NoError(t, s.Put(ctx, "total",
+		datastoretest.Datum(t, "total", values.NewVariable(1))))
+}
+
+func TestDataStoreValidation(t *testing.T) {
+	repo := newRepo(t)
+
+	_, err := repo.DataStore("")
+	require.True(t, hasClass(err, errs.EmptyNotAllowed))
+
+	_, err = repo.DataStore("orders", sqlite.WithCapacity(-1))
+	require.True(t, hasClass(err, errs.InvalidParameter))
+
+	s, err := repo.DataStore("orders")
+	require.NoError(t, err)
+	require.True(t, s.IsUnlimited())
+	require.Contains(t, s.String(), "orders")
+
+	_, err = s.PutIf(context.Background(), "total",
+		datastoretest.Datum(t, "total", values.NewVariable(1)), -1)
+	require.True(t, hasClass(err, errs.InvalidParameter))
+	require.True(t, hasClass(s.Delete(context.Background(), ""),
+		errs.EmptyNotAllowed))
+
+	ok, _ := s.ClusterCompatibility()
+	require.False(t, ok)
+}
+
+// TestDataStoreFailuresAreLoud drives the Data Store over a closed
+// handle.
+func TestDataStoreFailuresAreLoud(t *testing.T) {
+	ctx := context.Background()
+	d := datastoretest.Datum(t, "total", values.NewVariable(1))
+
+	repo, err := sqlite.Open(dbPath(t))
+	require.NoError(t, err)
+	require.NoError(t, repo.Close())
+
+	s, err := repo.DataStore("orders")
+	require.NoError(t, err)
+
+	_, _, err = s.Get(ctx, "total")
+	require.Error(t, err)
+	require.Error(t, s.Put(ctx, "total", d))
+	require.Error(t, s.Migrate(ctx))
+	require.Error(t, s.Delete(ctx, "total"))
+
+	_, err = s.PutIf(ctx, "total", d, 1)
+	require.Error(t, err)
+
+	bounded, err := repo.DataStore("orders", sqlite.WithCapacity(1))
+	require.NoError(t, err)
+	require.Error(t, bounded.Put(ctx, "total", d))
+
+	_, err = bounded.PutIf(ctx, "total", d, 0)
+	require.Error(t, err)
+
+	// an unmigrated file has no table to read.
+	raw, err := sqlite.Open(dbPath(t))
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, raw.Close()) })
+
+	s, err = raw.DataStore("orders")
+	require.NoError(t, err)
+
+	_, _, err = s.Get(ctx, "total")
+	require.Error(t, err)
+}
+
+// hasClass reports whether err carries the errs class.
+func hasClass(err error, class string) bool {
+	var ae *errs.ApplicationError
+
+	return errors.As(err, &ae) && ae.HasClass(class)
+}
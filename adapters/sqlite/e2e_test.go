@@ -0,0 +1,208 @@
+package sqlite_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gobpm/adapters/sqlite"
+	"github.com/dr-dobermann/gobpm/pkg/model/activities"
+	"github.com/dr-dobermann/gobpm/pkg/model/data"
+	"github.com/dr-dobermann/gobpm/pkg/model/data/goexpr"
+	"github.com/dr-dobermann/gobpm/pkg/model/data/values"
+	"github.com/dr-dobermann/gobpm/pkg/model/events"
+	"github.com/dr-dobermann/gobpm/pkg/model/flow"
+	"github.com/dr-dobermann/gobpm/pkg/model/foundation"
+	"github.com/dr-dobermann/gobpm/pkg/model/process"
+	"github.com/dr-dobermann/gobpm/pkg/model/service"
+	"github.com/dr-dobermann/gobpm/pkg/model/service/gooper"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+	"github.com/dr-dobermann/gobpm/pkg/repository"
+	"github.com/dr-dobermann/gobpm/pkg/thresher"
+)
+
+// The restart-recovery proof over a real file — examples/restart-recovery
+// with the in-memory store swapped for SQLite and the second engine on a
+// freshly opened handle, as a restarted process would be.
+
+// factWatch collects engine facts for the recovery assertions.
+type factWatch struct {
+	mu    sync.Mutex
+	facts []observability.Fact
+}
+
+func (fw *factWatch) OnFact(f observability.Fact) {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	fw.facts = append(fw.facts, f)
+}
+
+func (fw *factWatch) saw(k observability.Kind, p observability.Phase) bool {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	for _, f := range fw.facts {
+		if f.Kind == k && f.Phase == p {
+			return true
+		}
+	}
+
+	return false
+}
+
+// timerProc builds start → timer(when) → hit-lane → end with PINNED
+// node ids (cross-engine recovery demands stable element identity —
+// the deployment-parity contract, ADR-033 §2.8).
+func timerProc(
+	t *testing.T, key string, when time.Time, hit *atomic.Bool,
+) *process.Process {
+	t.Helper()
+
+	require.NoError(t, data.CreateDefaultStates())
+
+	p, err := process.New(key, foundation.WithID(key))
+	require.NoError(t, err)
+
+	start, err := events.NewStartEvent("start",
+		foundation.WithID(key+"-start"))
+	require.NoError(t, err)
+
+	texpr, err := goexpr.New(nil,
+		data.MustItemDefinition(values.NewVariable(time.Time{})),
+		func(_ context.Context, _ data.Source) (data.Value, error) {
+			return values.NewVariable(when), nil
+		})
+	require.NoError(t, err)
+
+	def, err := events.NewTimerEventDefinition(texpr, nil, nil)
+	require.NoError(t, err)
+
+	wait, err := events.NewIntermediateCatchEvent("wait", def,
+		foundation.WithID(key+"-wait"))
+	require.NoError(t, err)
+
+	op, err := gooper.New(key+"-lane",
+		func(_ context.Context, _ service.DataReader,
+			_ *data.ItemDefinition) (*data.ItemDefinition, error) {
+			hit.Store(true)
+
+			return nil, nil
+		})
+	require.NoError(t, err)
+
+	lane, err := activities.NewServiceTask(key+"-lane", op,
+		activities.WithoutParams(), foundation.WithID(key+"-lane"))
+	require.NoError(t, err)
+
+	end, err := events.NewEndEvent("end", foundation.WithID(key+"-end"))
+	require.NoError(t, err)
+
+	for _, e := range []flow.Element{start, wait, lane, end} {
+		require.NoError(t, p.Add(e))
+	}
+
+	for _, pair := range [][2]flow.Element{
+		{start, wait}, {wait, lane}, {lane, end},
+	} {
+		_, err := flow.Link(pair[0].(flow.SequenceSource),
+			pair[1].(flow.SequenceTarget))
+		require.NoError(t, err)
+	}
+
+	return p
+}
+
+// bootEngine runs an engine over the shared store in the given group,
+// with the process registered BEFORE Run (deployment parity).
+func bootEngine(
+	t *testing.T, name, group string, repo *sqlite.Repo,
+	ttl time.Duration, p *process.Process,
+) (*thresher.Thresher, *factWatch, context.CancelFunc) {
+	t.Helper()
+
+	th, err := thresher.New(name,
+		thresher.WithoutBanner(), thresher.WithoutStartupConfig(),
+		thresher.WithRepository(repo),
+		thresher.WithEngineGroup(group),
+		thresher.WithLeaseTTL(ttl))
+	require.NoError(t, err)
+
+	fw := &factWatch{}
+	sub := th.Observe(fw)
+	t.Cleanup(sub.Cancel)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	_, err = th.RegisterProcess(p)
+	require.NoError(t, err)
+	require.NoError(t, th.Run(ctx))
+
+	return th, fw, cancel
+}
+
+// TestRestartRecovery: engine A parks an instance on a timer and is
+// ABANDONED (the Active record and its expiring lease stay in the file);
+// a "restarted" engine B opens the same file anew, recovers the instance,
+// the timer fires at the RECORDED deadline and the instance completes
+// under B's lease.
+func TestRestartRecovery(t *testing.T) {
+	path := dbPath(t)
+	ctx := context.Background()
+
+	deadline := time.Now().Add(1200 * time.Millisecond)
+
+	var hitA, hitB atomic.Bool
+
+	repoA := openRepo(t, path)
+	p1 := timerProc(t, "sqlite-e2e", deadline, &hitA)
+
+	thA, _, cancelA := bootEngine(t, "engine-a", "g", repoA,
+		80*time.Millisecond, p1)
+	defer cancelA() // teardown only; the "crash" is abandonment
+
+	h, err := thA.StartLatest(p1.ID())
+	require.NoError(t, err)
+
+	instID := h.ID()
+
+	// the park checkpoint lands in the file.
+	require.Eventually(t, func() bool {
+		rec, ok, _ := repoA.Load(ctx, instID)
+
+		return ok && rec.Status == repository.StatusActive &&
+			rec.Lease.Owner == "engine-a"
+	}, 3*time.Second, 10*time.Millisecond,
+		"engine A must checkpoint the parked instance")
+
+	time.Sleep(120 * time.Millisecond) // A's lease lapses
+
+	repoB := openRepo(t, path) // the restarted process' handle
+	p2 := timerProc(t, "sqlite-e2e", deadline, &hitB)
+
+	_, fwB, cancelB := bootEngine(t, "engine-b", "g", repoB,
+		time.Minute, p2)
+	defer cancelB()
+
+	require.Eventually(t, func() bool {
+		return fwB.saw(observability.KindInstanceState,
+			observability.PhaseRecovered)
+	}, 3*time.Second, 10*time.Millisecond,
+		"engine B must claim and recover the abandoned instance")
+
+	require.Eventually(t, func() bool { return hitB.Load() },
+		3*time.Second, 10*time.Millisecond,
+		"the restored timer must fire at the recorded deadline on B")
+
+	require.Eventually(t, func() bool {
+		rec, ok, _ := repoB.Load(ctx, instID)
+
+		return ok && rec.Status == repository.StatusCompleted &&
+			rec.Lease.Owner == "engine-b"
+	}, 3*time.Second, 10*time.Millisecond,
+		"the completed record must belong to the recovering engine")
+}
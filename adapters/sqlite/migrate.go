@@ -0,0 +1,190 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+)
+
+// migrationsFS embeds the versioned SQL migrations: NNNN_*.sql,
+// applied in order, each in its own transaction, recorded in
+// schema_version by number.
+//
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// migration is one embedded migration file.
+type migration struct {
+	name    string
+	version int
+}
+
+// loadMigrations lists the embedded migrations sorted by version.
+func loadMigrations() ([]migration, error) {
+	entries, err := migrationsFS.ReadDir("migrations")
+	if err != nil {
+		return nil, opErr("listing the embedded migrations", "", err)
+	}
+
+	names := make([]string, len(entries))
+	for i, e := range entries {
+		names[i] = e.Name()
+	}
+
+	return parseMigrations(names)
+}
+
+// migRx pins the migration naming convention: NNNN_*.sql.
+var migRx = regexp.MustCompile(`^(\d{4})_.+\.sql$`)
+
+// parseMigrations validates the NNNN_*.sql naming and sorts by the
+// version prefix.
+func parseMigrations(names []string) ([]migration, error) {
+	mm := make([]migration, 0, len(names))
+
+	for _, name := range names {
+		m := migRx.FindStringSubmatch(name)
+		if m == nil {
+			return nil, errs.New(
+				errs.M("migration %q isn't named NNNN_*.sql", name),
+				errs.C(errorClass, errs.InvalidObject))
+		}
+
+		v, err := strconv.Atoi(m[1])
+		if err != nil { // the regex guarantees digits
+			return nil, errs.Invariant("migration %q: version prefix: %v", name, err)
+		}
+
+		mm = append(mm, migration{name: name, version: v})
+	}
+
+	sort.Slice(mm, func(i, j int) bool {
+		return mm[i].version < mm[j].version
+	})
+
+	return mm, nil
+}
+
+// Migrate creates or upgrades the adapter's tables (renv.Migrator):
+// the schema_version ledger, then every pending migration — each in
+// one IMMEDIATE transaction that re-checks the current version, so two
+// processes opening the same file serialize on SQLite's write lock
+// instead of colliding on DDL. Re-running over an up-to-date file is a
+// no-op.
+func (r *Repo) Migrate(ctx context.Context) error {
+	if _, err := r.db.ExecContext(ctx,
+		"CREATE TABLE IF NOT EXISTS schema_version"+
+			" (version integer PRIMARY KEY,"+
+			" applied_at integer NOT NULL)"); err != nil {
+		return opErr("creating the schema_version ledger", "", err)
+	}
+
+	mm, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+
+	for {
+		applied, err := r.applyNext(ctx, mm)
+		if err != nil {
+			return err
+		}
+
+		if !applied {
+			return nil
+		}
+	}
+}
+
+// applyNext applies the single next pending migration under the write
+// lock, reporting whether one was applied (false: up to date).
+// database/sql can't begin an IMMEDIATE transaction, so it runs on a
+// dedicated connection with the transaction statements spelled out.
+func (r *Repo) applyNext(ctx context.Context, mm []migration) (bool, error) {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return false, opErr("taking a migration connection", "", err)
+	}
+	defer func() {
+		if cerr := conn.Close(); cerr != nil {
+			r.logger.Warn("migration connection close failed",
+				"error", cerr.Error())
+		}
+	}()
+
+	if _, err := conn.ExecContext(ctx, "BEGIN IMMEDIATE"); err != nil {
+		return false, opErr("beginning a migration transaction", "", err)
+	}
+
+	next, applied, err := r.applyLocked(ctx, conn, mm)
+	if err != nil {
+		if _, rbErr := conn.ExecContext(context.WithoutCancel(ctx),
+			"ROLLBACK"); rbErr != nil {
+			r.logger.Warn("migration rollback failed", "error", rbErr.Error())
+		}
+
+		return false, err
+	}
+
+	if _, err := conn.ExecContext(ctx, "COMMIT"); err != nil {
+		return false, opErr("committing a migration transaction", "", err)
+	}
+
+	if applied {
+		r.logger.Info("sqlite repository migration applied",
+			"path", r.path, "migration", next.name)
+	}
+
+	return applied, nil
+}
+
+// applyLocked runs inside applyNext's transaction: it reads the current
+// version and applies the next pending migration, if any.
+func (r *Repo) applyLocked(
+	ctx context.Context, conn *sql.Conn, mm []migration,
+) (migration, bool, error) {
+	var current int
+	if err := conn.QueryRowContext(ctx,
+		"SELECT COALESCE(MAX(version), 0) FROM schema_version").
+		Scan(&current); err != nil {
+		return migration{}, false, opErr("reading the current schema version", "", err)
+	}
+
+	next, ok := nextPending(mm, current)
+	if !ok {
+		return migration{}, false, nil
+	}
+
+	body, err := migrationsFS.ReadFile("migrations/" + next.name)
+	if err != nil {
+		return next, false, opErr("reading migration "+next.name, "", err)
+	}
+
+	if _, err := conn.ExecContext(ctx, string(body)); err != nil {
+		return next, false, opErr("applying migration "+next.name, "", err)
+	}
+
+	if _, err := conn.ExecContext(ctx,
+		"INSERT INTO schema_version (version, applied_at)"+
+			" VALUES (?, unixepoch())", next.version); err != nil {
+		return next, false, opErr("recording migration "+next.name, "", err)
+	}
+
+	return next, true, nil
+}
+
+// nextPending returns the first migration above the current version.
+func nextPending(mm []migration, current int) (migration, bool) {
+	for _, m := range mm {
+		if m.version > current {
+			return m, true
+		}
+	}
+
+	return migration{}, false
+}
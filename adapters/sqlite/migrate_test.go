@@ -0,0 +1,95 @@
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gobpm/pkg/repository"
+)
+
+// Migrations: fresh-file create, idempotent re-run over the same file
+// from a second handle, the recorded version, and the database-enforced
+// single default tenant.
+
+func TestMigrate(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("a fresh file serves the contract", func(t *testing.T) {
+		repo := newRepo(t)
+
+		require.NoError(t, repo.RegisterGroup(ctx, "g"))
+		require.NoError(t, repo.Save(ctx, repository.InstanceRecord{
+			ID: "i-1", Group: "g", Status: repository.StatusActive,
+		}))
+
+		ids, err := repo.ListInFlight(ctx, "g", time.Now())
+		require.NoError(t, err)
+		require.Equal(t, []string{"i-1"}, ids)
+	})
+
+	t.Run("reopening an up-to-date file is a no-op", func(t *testing.T) {
+		path := dbPath(t)
+		first := openRepo(t, path)
+
+		require.NoError(t, first.RegisterGroup(ctx, "g"))
+		require.NoError(t, first.Save(ctx, repository.InstanceRecord{
+			ID: "keep", Group: "g", Status: repository.StatusActive,
+		}))
+
+		second := openRepo(t, path) // migrates again
+
+		_, ok, err := second.Load(ctx, "keep")
+		require.NoError(t, err)
+		require.True(t, ok, "a re-run must never touch existing data")
+
+		var version, rows int
+		require.NoError(t, rawDB(t, path).QueryRowContext(ctx,
+			"SELECT COALESCE(MAX(version), 0), count(*)"+
+				" FROM schema_version").Scan(&version, &rows))
+		require.Equal(t, 4, version, "migration 0004 must be recorded")
+		require.Equal(t, 4, rows, "a re-run must record nothing new")
+	})
+
+	t.Run("the database rejects a second default tenant per group",
+		func(t *testing.T) {
+			path := dbPath(t)
+			repo := openRepo(t, path)
+
+			require.NoError(t, repo.RegisterGroup(ctx, "g"))
+			require.NoError(t, repo.Save(ctx, repository.InstanceRecord{
+				ID: "i-1", Group: "g", Status: repository.StatusActive,
+			}))
+
+			_, err := rawDB(t, path).ExecContext(ctx,
+				"INSERT INTO tenants (tenant_id, engine_group, name,"+
+					" is_default) VALUES ('rogue', 'g', 'Rogue', 1)")
+			require.Error(t, err,
+				"the partial unique index must reject a second default")
+		})
+
+	t.Run("a tenant of an unregistered group is refused by the database",
+		func(t *testing.T) {
+			path := dbPath(t)
+			openRepo(t, path)
+
+			_, err := rawDB(t, path).ExecContext(ctx,
+				"INSERT INTO tenants (tenant_id, engine_group)"+
+					" VALUES ('t', 'ghost')")
+			require.Error(t, err, "foreign keys must be on")
+		})
+}
+
+// rawDB opens a second plain handle on path, closed at cleanup.
+func rawDB(t *testing.T, path string) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=foreign_keys(1)")
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, db.Close()) })
+
+	return db
+}
@@ -0,0 +1,54 @@
+-- The adapter's initial objects — the postgres adapter's tables in
+-- SQLite's dialect. SQLite has no timestamp type: the lease expiry is
+-- INTEGER Unix nanoseconds (0 for none), which compares exactly; the
+-- bookkeeping times are Unix seconds.
+
+-- The engine-group registry: records may reference established
+-- groups only (foreign keys are on for every connection Open makes).
+CREATE TABLE groups (
+    group_name text    PRIMARY KEY,
+    created_at integer NOT NULL
+);
+
+-- Tenants, keyed per engine group. The default tenant is
+-- FLAG-designated, never a reserved id; the partial unique index makes
+-- "one default per group" a database guarantee.
+CREATE TABLE tenants (
+    tenant_id    text    NOT NULL,
+    engine_group text    NOT NULL REFERENCES groups (group_name),
+    name         text    NOT NULL DEFAULT '',
+    is_default   integer NOT NULL DEFAULT 0,
+    PRIMARY KEY (engine_group, tenant_id)
+);
+
+CREATE UNIQUE INDEX tenants_one_default_per_group
+    ON tenants (engine_group) WHERE is_default;
+
+-- Instance checkpoints. Deliberately NO CHECK constraint on status:
+-- the vocabulary is append-only, and DDL must not reject a status a
+-- newer engine writes.
+CREATE TABLE instances (
+    id                text    PRIMARY KEY,
+    engine_group      text    NOT NULL REFERENCES groups (group_name),
+    tenant_id         text    NOT NULL,
+    status            integer NOT NULL,
+    payload           blob    NOT NULL DEFAULT x'',
+    rec_version       integer NOT NULL,
+    lease_owner       text    NOT NULL DEFAULT '',
+    lease_incarnation integer NOT NULL DEFAULT 0,
+    lease_expiry      integer NOT NULL DEFAULT 0,
+    process_id        text    NOT NULL DEFAULT '',
+    business_key      text    NOT NULL DEFAULT '',
+    updated_at        integer NOT NULL,
+    FOREIGN KEY (engine_group, tenant_id)
+        REFERENCES tenants (engine_group, tenant_id)
+);
+
+-- The recovery listing's path (group-scoped claimable scan).
+CREATE INDEX instances_recovery_listing
+    ON instances (engine_group, status, lease_expiry);
+
+-- The business-key lookup's path; keyless records stay out of it.
+CREATE INDEX instances_business_key
+    ON instances (engine_group, process_id, business_key)
+    WHERE business_key <> '';
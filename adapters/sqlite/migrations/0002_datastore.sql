@@ -0,0 +1,12 @@
+-- Data Stores — the postgres adapter's data_store_items in SQLite's
+-- dialect. One row per datum of an engine-global BPMN Data Store:
+-- store is the DataStore's name, name the datum's key within it, and
+-- payload the datastore.EncodeDatum form (the checkpoint value codec).
+-- A store's capacity is the adapter's configuration, not a row.
+CREATE TABLE data_store_items (
+    store      text    NOT NULL,
+    name       text    NOT NULL,
+    payload    blob    NOT NULL,
+    updated_at integer NOT NULL,
+    PRIMARY KEY (store, name)
+);
@@ -0,0 +1,6 @@
+-- Data Store versions — the postgres adapter's 0005 in SQLite's
+-- dialect. Every write of a datum bumps its version; PutIf
+-- compare-and-swaps against it (datastore.Versioned). The rows 0002
+-- stored start at 1, as a first write does.
+ALTER TABLE data_store_items
+    ADD COLUMN version integer NOT NULL DEFAULT 1;
@@ -0,0 +1,129 @@
+package sqlite
+
+import (
+	"fmt"
+
+	"github.com/dr-dobermann/gobpm/pkg/repository"
+)
+
+// claimExcluded renders the statuses the recovery listing excludes:
+// claimable is non-terminal and not suspended, so a growing status
+// vocabulary lists automatically.
+var claimExcluded = fmt.Sprintf("(%d, %d, %d)",
+	repository.StatusCompleted,
+	repository.StatusTerminated,
+	repository.StatusSuspended)
+
+// terminalStatuses renders the statuses the business-key lookup
+// excludes: a finished instance no longer holds its key.
+var terminalStatuses = fmt.Sprintf("(%d, %d)",
+	repository.StatusCompleted,
+	repository.StatusTerminated)
+
+// suspended renders the status whose deadlines no takeover fires.
+var suspended = fmt.Sprint(int(repository.StatusSuspended))
+
+// The adapter's statements. The only interpolated fragments are the
+// constant status lists; every value travels as a ? parameter.
+var (
+	qInsert = "INSERT INTO instances" +
+		" (id, engine_group, tenant_id, status, payload, rec_version," +
+		" lease_owner, lease_incarnation, lease_expiry," +
+		" process_id, business_key, updated_at)" +
+		" VALUES (?, ?, ?, ?, ?, 1, ?, ?, ?, ?, ?, unixepoch())" +
+		" ON CONFLICT (id) DO NOTHING"
+	qUpdate = "UPDATE instances" +
+		" SET engine_group = ?, tenant_id = ?, status = ?," +
+		" payload = ?, rec_version = rec_version + 1," +
+		" lease_owner = ?, lease_incarnation = ?, lease_expiry = ?," +
+		" process_id = ?, business_key = ?, updated_at = unixepoch()" +
+		" WHERE id = ? AND rec_version = ?"
+	qLoad = "SELECT engine_group, tenant_id, status, payload," +
+		" rec_version, lease_owner, lease_incarnation, lease_expiry," +
+		" process_id, business_key" +
+		" FROM instances WHERE id = ?"
+	qDelete = "DELETE FROM instances WHERE id = ?"
+	qList   = "SELECT id FROM instances" +
+		" WHERE engine_group = ? AND status NOT IN " + claimExcluded +
+		" AND (lease_owner = '' OR lease_expiry <= ?)" +
+		" ORDER BY id"
+	qFindByBusinessKey = "SELECT id FROM instances" +
+		" WHERE engine_group = ? AND process_id = ?" +
+		" AND business_key = ?" +
+		" AND status NOT IN " + terminalStatuses +
+		" ORDER BY id"
+	qRegisterGroup = "INSERT INTO groups (group_name, created_at)" +
+		" VALUES (?, unixepoch()) ON CONFLICT DO NOTHING"
+	qGroupExists = "SELECT EXISTS (SELECT 1 FROM groups" +
+		" WHERE group_name = ?)"
+	qEnsureTenant = "INSERT INTO tenants (tenant_id, engine_group, name)" +
+		" VALUES (?1, ?2, ?1) ON CONFLICT DO NOTHING"
+	qMintDefaultTenant = "INSERT INTO tenants" +
+		" (tenant_id, engine_group, name, is_default)" +
+		" VALUES (?, ?, 'Default', 1) ON CONFLICT DO NOTHING"
+	qSelectDefaultTenant = "SELECT tenant_id FROM tenants" +
+		" WHERE engine_group = ? AND is_default"
+)
+
+// The DataStore's statements. qDataPutBounded stores the datum only
+// while the store holds fewer than ?4 OTHER names — a replace always
+// fits, a new name only below the capacity; no row affected means the
+// store is full. Every write bumps the datum's version; the
+// compare-and-swap writes (qDataCreate, qDataCreateBounded, qDataSwap)
+// return the new version and no row when the datum isn't at the
+// expected one.
+var (
+	qDataGet = "SELECT payload, version FROM data_store_items" +
+		" WHERE store = ? AND name = ?"
+	qDataPut = "INSERT INTO data_store_items" +
+		" (store, name, payload, updated_at)" +
+		" VALUES (?, ?, ?, unixepoch())" + qDataUpsert
+	qDataPutBounded = "INSERT INTO data_store_items" +
+		" (store, name, payload, updated_at)" +
+		" SELECT ?1, ?2, ?3, unixepoch() WHERE (SELECT count(*)" +
+		" FROM data_store_items WHERE store = ?1 AND name <> ?2) < ?4" +
+		qDataUpsert
+	qDataCreate = "INSERT INTO data_store_items" +
+		" (store, name, payload, updated_at)" +
+		" VALUES (?, ?, ?, unixepoch())" +
+		" ON CONFLICT (store, name) DO NOTHING RETURNING version"
+	qDataCreateBounded = "INSERT INTO data_store_items" +
+		" (store, name, payload, updated_at)" +
+		" SELECT ?1, ?2, ?3, unixepoch() WHERE (SELECT count(*)" +
+		" FROM data_store_items WHERE store = ?1) < ?4" +
+		" ON CONFLICT (store, name) DO NOTHING RETURNING version"
+	qDataSwap = "UPDATE data_store_items" +
+		" SET payload = ?3, updated_at = unixepoch(), version = version + 1" +
+		" WHERE store = ?1 AND name = ?2 AND version = ?4 RETURNING version"
+	qDataExists = "SELECT EXISTS (SELECT 1 FROM data_store_items" +
+		" WHERE store = ? AND name = ?)"
+	qDataDelete = "DELETE FROM data_store_items WHERE store = ? AND name = ?"
+)
+
+const qDataUpsert = " ON CONFLICT (store, name) DO UPDATE" +
+	" SET payload = excluded.payload, updated_at = excluded.updated_at," +
+	" version = data_store_items.version + 1"
+
+// The due-timer index's statements. An empty track or event widens
+// qTimerDelete to the instance's or the track's every deadline; a
+// negative limit is SQLite's "no limit". qTimersDue joins the instance
+// record to leave out the deadlines of a suspended instance and of one
+// whose lease is live at ?3; an instance without a record, or a finished
+// one, stays listed.
+var (
+	qTimerPut = "INSERT INTO timers" +
+		" (engine_group, instance_id, track_id, event_id, deadline)" +
+		" VALUES (?, ?, ?, ?, ?)" +
+		" ON CONFLICT (instance_id, track_id, event_id) DO UPDATE" +
+		" SET engine_group = excluded.engine_group," +
+		" deadline = excluded.deadline"
+	qTimerDelete = "DELETE FROM timers WHERE instance_id = ?1" +
+		" AND (?2 = '' OR track_id = ?2) AND (?3 = '' OR event_id = ?3)"
+	qTimersDue = "SELECT t.instance_id, t.track_id, t.event_id, t.deadline" +
+		" FROM timers t LEFT JOIN instances i ON i.id = t.instance_id" +
+		" WHERE t.engine_group = ?1 AND t.deadline <= ?2" +
+		" AND (i.id IS NULL OR i.status IN " + terminalStatuses +
+		" OR (i.status <> " + suspended +
+		" AND (i.lease_owner = '' OR i.lease_expiry <= ?3)))" +
+		" ORDER BY t.deadline, t.instance_id, t.track_id, t.event_id LIMIT ?4"
+)
@@ -0,0 +1,319 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/repository"
+)
+
+// defaultTenantID is the id the adapter mints for a group's
+// flag-designated default tenant row on first use. The FLAG is the
+// designation (the partial unique index enforces one per group); the
+// id is only a convenience name, never reserved.
+const defaultTenantID = "default"
+
+// Save stores the record with compare-and-set semantics: rec.RecVersion
+// must equal the stored version (0 creates); the stored version
+// increments on success; a mismatch fails with errs.ConcurrentUpdate.
+// The record must carry its creator's engine group; an empty Tenant
+// resolves to the group's flag-designated default tenant row, created
+// idempotently on first use.
+func (r *Repo) Save(ctx context.Context, rec repository.InstanceRecord) error {
+	if rec.ID == "" {
+		return errs.New(
+			errs.M("Save: a record needs an ID"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	if rec.Group == "" {
+		return errs.New(
+			errs.M("Save: a record needs an engine group"),
+			errs.C(errorClass, errs.EmptyNotAllowed),
+			errs.D("id", rec.ID))
+	}
+
+	registered, err := r.GroupExists(ctx, rec.Group)
+	if err != nil {
+		return err
+	}
+
+	if !registered {
+		return errs.New(
+			errs.M("Save: engine group %q isn't registered", rec.Group),
+			errs.C(errorClass, errs.ObjectNotFound),
+			errs.D("id", rec.ID))
+	}
+
+	tenant, err := r.resolveTenant(ctx, rec.Group, rec.Tenant)
+	if err != nil {
+		return err
+	}
+
+	if rec.Payload == nil {
+		rec.Payload = []byte{} // a nil slice would land as NULL
+	}
+
+	if rec.RecVersion == 0 {
+		return r.insert(ctx, rec, tenant)
+	}
+
+	return r.update(ctx, rec, tenant)
+}
+
+// insert creates the record at stored version 1; an existing id means
+// the writer lost the CAS race.
+func (r *Repo) insert(
+	ctx context.Context, rec repository.InstanceRecord, tenant string,
+) error {
+	res, err := r.db.ExecContext(ctx, qInsert,
+		rec.ID, rec.Group, tenant, int(rec.Status), rec.Payload,
+		rec.Lease.Owner, rec.Lease.Incarnation, toNanos(rec.Lease.Expiry),
+		rec.ProcessID, rec.BusinessKey)
+	if err != nil {
+		return opErr("Save (create)", rec.ID, err)
+	}
+
+	return casOutcome(res, rec.ID, "create")
+}
+
+// update advances the record iff the stored version matches; zero rows
+// means the record moved (or vanished) under the writer.
+func (r *Repo) update(
+	ctx context.Context, rec repository.InstanceRecord, tenant string,
+) error {
+	res, err := r.db.ExecContext(ctx, qUpdate,
+		rec.Group, tenant, int(rec.Status), rec.Payload,
+		rec.Lease.Owner, rec.Lease.Incarnation, toNanos(rec.Lease.Expiry),
+		rec.ProcessID, rec.BusinessKey, rec.ID, rec.RecVersion)
+	if err != nil {
+		return opErr("Save (update)", rec.ID, err)
+	}
+
+	return casOutcome(res, rec.ID, "update")
+}
+
+// casOutcome maps "zero rows touched" to the ConcurrentUpdate fencing
+// error every adapter reports identically.
+func casOutcome(res sql.Result, id, op string) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return opErr("Save ("+op+" outcome)", id, err)
+	}
+
+	if n == 0 {
+		return errs.New(
+			errs.M("Save: the record changed under the writer"),
+			errs.C(errorClass, errs.ConcurrentUpdate),
+			errs.D("id", id))
+	}
+
+	return nil
+}
+
+// toNanos renders a lease time as the stored Unix nanoseconds; the zero
+// time (an unowned lease) is 0.
+func toNanos(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+
+	return t.UnixNano()
+}
+
+// fromNanos is toNanos' inverse.
+func fromNanos(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(0, n).UTC()
+}
+
+// resolveTenant returns the tenant row the record lands under,
+// creating it idempotently: "" resolves to the group's flag-designated
+// default; an explicit id is ensured as a regular (non-default) row.
+func (r *Repo) resolveTenant(
+	ctx context.Context, group, tenant string,
+) (string, error) {
+	if tenant == "" {
+		return r.ensureDefaultTenant(ctx, group)
+	}
+
+	if _, err := r.db.ExecContext(ctx, qEnsureTenant,
+		tenant, group); err != nil {
+		return "", opErr("ensuring tenant "+tenant, "", err)
+	}
+
+	return tenant, nil
+}
+
+// ensureDefaultTenant returns the group's flag-designated default
+// tenant id, minting the row on first use.
+func (r *Repo) ensureDefaultTenant(
+	ctx context.Context, group string,
+) (string, error) {
+	if _, err := r.db.ExecContext(ctx, qMintDefaultTenant,
+		defaultTenantID, group); err != nil {
+		return "", opErr("ensuring the default tenant", "", err)
+	}
+
+	var id string
+
+	err := r.db.QueryRowContext(ctx, qSelectDefaultTenant,
+		group).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", errs.New(
+			errs.M("no default tenant for group %q and the %q id is taken"+
+				" by a non-default tenant", group, defaultTenantID),
+			errs.C(errorClass, errs.OperationFailed))
+	}
+
+	if err != nil {
+		return "", opErr("resolving the default tenant", "", err)
+	}
+
+	return id, nil
+}
+
+// Load returns the record for id; the bool is false when none exists.
+func (r *Repo) Load(
+	ctx context.Context, id string,
+) (repository.InstanceRecord, bool, error) {
+	var (
+		rec    repository.InstanceRecord
+		status int
+		expiry int64
+	)
+
+	rec.ID = id
+
+	err := r.db.QueryRowContext(ctx, qLoad,
+		id).Scan(&rec.Group, &rec.Tenant, &status, &rec.Payload,
+		&rec.RecVersion, &rec.Lease.Owner, &rec.Lease.Incarnation,
+		&expiry, &rec.ProcessID, &rec.BusinessKey)
+	if errors.Is(err, sql.ErrNoRows) {
+		return repository.InstanceRecord{}, false, nil
+	}
+
+	if err != nil {
+		return repository.InstanceRecord{}, false, opErr("Load", id, err)
+	}
+
+	rec.Status = repository.Status(status)
+	rec.Lease.Expiry = fromNanos(expiry)
+
+	return rec, true, nil
+}
+
+// Delete removes the record for id (a no-op if it is absent).
+func (r *Repo) Delete(ctx context.Context, id string) error {
+	if _, err := r.db.ExecContext(ctx, qDelete, id); err != nil {
+		return opErr("Delete", id, err)
+	}
+
+	return nil
+}
+
+// ListInFlight returns the IDs of the CLAIMABLE in-flight instances of
+// the given engine group — not terminal, not suspended, with no live
+// lease at now — ordered by id for determinism.
+func (r *Repo) ListInFlight(
+	ctx context.Context, group string, now time.Time,
+) ([]string, error) {
+	if group == "" {
+		return nil, errs.New(
+			errs.M("ListInFlight: an engine group is required"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	return r.queryIDs(ctx, "ListInFlight", qList, group, now.UnixNano())
+}
+
+// FindByBusinessKey returns the IDs of the group's non-terminal
+// instances of processID carrying businessKey, ordered by id
+// (repository.BusinessKeyFinder).
+func (r *Repo) FindByBusinessKey(
+	ctx context.Context, group, processID, businessKey string,
+) ([]string, error) {
+	if group == "" || processID == "" || businessKey == "" {
+		return nil, errs.New(
+			errs.M("FindByBusinessKey: a group, a process and a key are required"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	return r.queryIDs(ctx, "FindByBusinessKey", qFindByBusinessKey,
+		group, processID, businessKey)
+}
+
+// queryIDs runs a single-column id query — the shape both listings
+// share — naming op in any error.
+func (r *Repo) queryIDs(
+	ctx context.Context, op, query string, args ...any,
+) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, opErr(op, "", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			r.logger.Warn(op+": rows close failed", "error", cerr.Error())
+		}
+	}()
+
+	var ids []string
+
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, opErr(op+" (scan)", "", err)
+		}
+
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, opErr(op+" (rows)", "", err)
+	}
+
+	return ids, nil
+}
+
+// RegisterGroup establishes the engine group in the registry,
+// idempotently.
+func (r *Repo) RegisterGroup(ctx context.Context, group string) error {
+	if group == "" {
+		return errs.New(
+			errs.M("RegisterGroup: an engine group is required"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	if _, err := r.db.ExecContext(ctx, qRegisterGroup, group); err != nil {
+		return opErr("RegisterGroup "+group, "", err)
+	}
+
+	return nil
+}
+
+// GroupExists reports whether the group is established in the registry.
+func (r *Repo) GroupExists(
+	ctx context.Context, group string,
+) (bool, error) {
+	if group == "" {
+		return false, errs.New(
+			errs.M("GroupExists: an engine group is required"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	var ok bool
+
+	if err := r.db.QueryRowContext(ctx, qGroupExists,
+		group).Scan(&ok); err != nil {
+		return false, opErr("GroupExists "+group, "", err)
+	}
+
+	return ok, nil
+}
@@ -0,0 +1,162 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	_ "modernc.org/sqlite" // the pure-Go driver, registered as "sqlite"
+
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+	"github.com/dr-dobermann/gobpm/pkg/renv"
+	"github.com/dr-dobermann/gobpm/pkg/repository"
+)
+
+const errorClass = "SQLITE_REPO"
+
+// pragmas are the connection settings Open applies to every connection
+// of its pool: foreign keys on (the group and tenant references are
+// database guarantees), a busy timeout instead of an instant
+// SQLITE_BUSY, and the WAL journal so readers never block the writer.
+const pragmas = "_pragma=foreign_keys(1)" +
+	"&_pragma=busy_timeout(5000)" +
+	"&_pragma=journal_mode(WAL)"
+
+// Repo is the SQLite repository.Repository. Build it with Open or New.
+type Repo struct {
+	db     *sql.DB
+	logger observability.Logger
+	path   string
+	// owned is set when Open made db: Close closes it then.
+	owned bool
+}
+
+// Option configures a Repo at Open or New.
+type Option func(*Repo) error
+
+// WithLogger sets the structured logger (default: slog.Default()).
+func WithLogger(l observability.Logger) Option {
+	return func(r *Repo) error {
+		if l == nil {
+			return errs.New(
+				errs.M("WithLogger: a nil Logger isn't allowed"),
+				errs.C(errorClass, errs.EmptyNotAllowed))
+		}
+
+		r.logger = l
+
+		return nil
+	}
+}
+
+// Open opens (creating it if absent) the SQLite database file at path
+// and builds a Repo that owns the handle — Close releases it. The pool
+// holds a single connection: SQLite serializes writers anyway, and one
+// connection never meets SQLITE_BUSY from its own pool. Run Migrate (or
+// wire the Repo into thresher, which migrates at Run) before the first
+// Save.
+func Open(path string, opts ...Option) (*Repo, error) {
+	if path == "" {
+		return nil, errs.New(
+			errs.M("Open: a database path is required"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	db, err := sql.Open("sqlite", "file:"+path+"?"+pragmas)
+	if err != nil {
+		return nil, opErr("opening "+path, "", err)
+	}
+
+	db.SetMaxOpenConns(1)
+
+	r, err := New(db, opts...)
+	if err != nil {
+		return nil, errors.Join(err, db.Close())
+	}
+
+	r.path, r.owned = path, true
+
+	return r, nil
+}
+
+// New builds a Repo over a handle the embedder opened over the "sqlite"
+// driver. The handle should enable foreign keys and a busy timeout on
+// every connection, as Open does.
+func New(db *sql.DB, opts ...Option) (*Repo, error) {
+	if db == nil {
+		return nil, errs.New(
+			errs.M("New: a nil *sql.DB isn't allowed"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	r := &Repo{
+		db:     db,
+		logger: slog.Default(),
+	}
+
+	for _, o := range opts {
+		if err := o(r); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// Close releases the database handle Open made; a Repo built by New
+// leaves the embedder's handle open.
+func (r *Repo) Close() error {
+	if !r.owned {
+		return nil
+	}
+
+	if err := r.db.Close(); err != nil {
+		return opErr("Close", "", err)
+	}
+
+	return nil
+}
+
+// ClusterCompatibility declares the adapter unfit to share between
+// engines (renv.ClusterAware).
+func (r *Repo) ClusterCompatibility() (bool, string) {
+	return false, "single-file SQLite store: no cross-host fencing;" +
+		" use the postgres adapter for a cluster"
+}
+
+// opErr wraps a database failure into the classified error idiom; id
+// tags the record when the operation has one ("" omits the detail).
+func opErr(op, id string, err error) error {
+	if id == "" {
+		return errs.New(
+			errs.M("%s failed", op),
+			errs.C(errorClass, errs.OperationFailed),
+			errs.E(err))
+	}
+
+	return errs.New(
+		errs.M("%s failed", op),
+		errs.C(errorClass, errs.OperationFailed),
+		errs.D("id", id),
+		errs.E(err))
+}
+
+var (
+	_ repository.Repository        = (*Repo)(nil)
+	_ repository.BusinessKeyFinder = (*Repo)(nil)
+	_ repository.TimerIndex        = (*Repo)(nil)
+	_ renv.ClusterAware            = (*Repo)(nil)
+	_ renv.Migrator                = (*Repo)(nil)
+	_ fmt.Stringer                 = (*Repo)(nil)
+)
+
+// String identifies the adapter and its file in logs.
+func (r *Repo) String() string {
+	if r.path == "" {
+		return "sqlite repository"
+	}
+
+	return "sqlite repository (" + r.path + ")"
+}
@@ -0,0 +1,90 @@
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gobpm/adapters/sqlite"
+	"github.com/dr-dobermann/gobpm/pkg/renv"
+)
+
+// dbPath returns a fresh database file path under the test's temp dir.
+func dbPath(t *testing.T) string {
+	t.Helper()
+
+	return filepath.Join(t.TempDir(), "gobpm.db")
+}
+
+// openRepo opens a migrated Repo over path, closed at cleanup.
+func openRepo(t *testing.T, path string) *sqlite.Repo {
+	t.Helper()
+
+	repo, err := sqlite.Open(path)
+	require.NoError(t, err)
+	require.NoError(t, repo.Migrate(context.Background()))
+
+	t.Cleanup(func() { require.NoError(t, repo.Close()) })
+
+	return repo
+}
+
+// newRepo builds a migrated Repo over a fresh file.
+func newRepo(t *testing.T) *sqlite.Repo {
+	t.Helper()
+
+	return openRepo(t, dbPath(t))
+}
+
+func TestOpenValidation(t *testing.T) {
+	t.Run("an empty path is rejected", func(t *testing.T) {
+		_, err := sqlite.Open("")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "path")
+	})
+
+	t.Run("a nil db is rejected", func(t *testing.T) {
+		_, err := sqlite.New(nil)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "nil *sql.DB")
+	})
+
+	t.Run("a nil logger is rejected", func(t *testing.T) {
+		_, err := sqlite.Open(dbPath(t), sqlite.WithLogger(nil))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "WithLogger")
+	})
+
+	t.Run("a real logger is accepted", func(t *testing.T) {
+		repo, err := sqlite.Open(dbPath(t),
+			sqlite.WithLogger(slog.Default()))
+		require.NoError(t, err)
+		require.Contains(t, repo.String(), "gobpm.db")
+		require.NoError(t, repo.Close())
+	})
+
+	t.Run("New leaves the embedder's handle open", func(t *testing.T) {
+		db, err := sql.Open("sqlite", dbPath(t))
+		require.NoError(t, err)
+		t.Cleanup(func() { require.NoError(t, db.Close()) })
+
+		repo, err := sqlite.New(db)
+		require.NoError(t, err)
+		require.NoError(t, repo.Close())
+		require.NoError(t, db.Ping())
+	})
+}
+
+// TestClusterDeclaration: a single SQLite file declares itself unfit
+// to share between engines (renv.ClusterAware).
+func TestClusterDeclaration(t *testing.T) {
+	var ca renv.ClusterAware = newRepo(t)
+
+	ok, reason := ca.ClusterCompatibility()
+	require.False(t, ok)
+	require.Contains(t, reason, "postgres")
+}
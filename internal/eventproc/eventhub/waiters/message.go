@@ -230,6 +230,11 @@ func (mw *messageWaiter) Service(ctx context.Context) error {
 			errs.E(err))
 	}
 
+	// a settling broker keeps each message until the processors took it.
+	if st, ok := sub.(messaging.Settler); ok {
+		st.SettleManually()
+	}
+
 	mw.sub = sub
 	mw.state = eventproc.WSRunned
 	mw.stopCh = make(chan struct{})
@@ -287,7 +292,10 @@ func (mw *messageWaiter) runMessageService(
 				return
 			}
 
-			if err := mw.processMessageEvent(ctx, env); err != nil {
+			err := mw.processMessageEvent(ctx, env)
+			settle(sub, err)
+
+			if err != nil {
 				// a fire-definition / processor failure is terminal for this
 				// waiter (it already set WSFailed and reported the fire); log it
 				// at the goroutine top — nothing above can act on it — and stop
@@ -302,6 +310,15 @@ func (mw *messageWaiter) runMessageService(
 	}
 }
 
+// settle reports a delivery's outcome to a settling broker (messaging.Settler):
+// a message the processors took is acknowledged, a failed one stays with the
+// broker for redelivery.
+func settle(sub messaging.Subscription, err error) {
+	if st, ok := sub.(messaging.Settler); ok {
+		st.Settle(err)
+	}
+}
+
 // processMessageEvent forwards the payload-carrying event to every registered
 // processor, then reports the fire to the hub. It never removes itself — the
 // EventHub is the sole remover (ADR-006 v.1 §2.5). A processor's ProcessEvent is
@@ -376,7 +393,8 @@ func (mw *messageWaiter) deliver(
 
 // fireDefinition builds the event definition delivered to the processors: the
 // broker payload is reconstructed as a typed, Ready datum for the message's
-// item (ADR-014 v.1 §2.6) and woven into a cloned definition.
+// item (ADR-014 v.1 §2.6) and woven into a cloned definition, stamped with the
+// envelope's MessageID when it carries one.
 func (mw *messageWaiter) fireDefinition(
 	env messaging.Envelope,
 ) (flow.EventDefinition, error) {
@@ -388,7 +406,17 @@ func (mw *messageWaiter) fireDefinition(
 		return nil, payloadErr(mw.eDef.Message().Name(), err)
 	}
 
-	return mw.eDef.CloneEventDefinition([]data.Data{datum})
+	fired, err := mw.eDef.CloneEventDefinition([]data.Data{datum})
+	if err != nil || env.MessageID == "" {
+		return fired, err
+	}
+
+	// the broker id rides the fire, so a receiver drops a redelivery.
+	if med, ok := fired.(*events.MessageEventDefinition); ok {
+		return med.WithMessageID(env.MessageID), nil
+	}
+
+	return fired, nil
 }
 
 // payloadErr classifies a payload datum build failure (FIX-026 — a bad
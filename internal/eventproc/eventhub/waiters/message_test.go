@@ -455,3 +455,80 @@ func TestMessageWaiterUnsubscribeErrorIsLogged(t *testing.T) {
 		t.Fatal("Done did not close after Stop despite the unsubscribe error")
 	}
 }
+
+// settleSub is a settling subscription (messaging.Settler) recording its
+// verdicts.
+type settleSub struct {
+	chanSub
+	manual  chan struct{}
+	settled chan error
+}
+
+func (s settleSub) SettleManually()  { close(s.manual) }
+func (s settleSub) Settle(err error) { s.settled <- err }
+
+// settleBroker hands out one settleSub.
+type settleBroker struct{ sub settleSub }
+
+func (settleBroker) Publish(context.Context, messaging.Envelope) error { return nil }
+
+func (b settleBroker) Subscribe(
+	context.Context, string, ...string,
+) (messaging.Subscription, error) {
+	return b.sub, nil
+}
+
+// TestMessageWaiterSettles: a waiter over a settling broker switches it to
+// manual settlement and settles every envelope once its processors took it —
+// with nil on success, with the failure otherwise.
+func TestMessageWaiterSettles(t *testing.T) {
+	require.NoError(t, data.CreateDefaultStates())
+
+	for _, tc := range []struct {
+		name string
+		err  error
+	}{
+		{name: "taken", err: nil},
+		{name: "failed", err: fmt.Errorf("processing failed")},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ch := make(chan messaging.Envelope)
+			sub := settleSub{
+				chanSub: chanSub{ch: ch},
+				manual:  make(chan struct{}),
+				settled: make(chan error, 1),
+			}
+
+			hub := mockeventproc.NewMockEventHub(t)
+			hub.EXPECT().WaiterFired(mock.Anything).Return(nil).Maybe()
+
+			ep := mockeventproc.NewMockEventProcessor(t)
+			ep.EXPECT().ProcessEvent(mock.Anything, mock.Anything).
+				Return(tc.err)
+
+			rt := brokerRT{EngineRuntime: enginert.Default(),
+				broker: settleBroker{sub: sub}}
+
+			w, err := waiters.NewMessageWaiter(hub, ep, msgEventDef(t), "", rt)
+			require.NoError(t, err)
+			require.NoError(t, w.Service(context.Background()))
+
+			t.Cleanup(func() { _ = w.Stop() })
+
+			select {
+			case <-sub.manual:
+			default:
+				t.Fatal("Service didn't switch the subscription to manual settlement")
+			}
+
+			ch <- messaging.Envelope{Name: "order placed", Payload: "x"}
+
+			select {
+			case err := <-sub.settled:
+				require.Equal(t, tc.err == nil, err == nil)
+			case <-time.After(time.Second):
+				t.Fatal("the envelope wasn't settled")
+			}
+		})
+	}
+}
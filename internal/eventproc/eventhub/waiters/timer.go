@@ -25,6 +25,11 @@ import (
 // TimerWaiterError is the error class for timer waiter errors.
 const TimerWaiterError = "TIMER_WAITER_ERROR"
 
+// TimerLagMetric is the histogram of how late timers fire: the seconds from
+// a timer's due instant to its firing. Its "holder" label names what held
+// the timer — "hub" for a waiter, "engine" for the engine timer service.
+const TimerLagMetric = "gobpm_timer_lag_seconds"
+
 // timeWaiter defines details of timer event described by
 // eDef.
 type timeWaiter struct {
@@ -349,7 +354,17 @@ var errTimerCompleted = errors.New("timer completed")
 func (tw *timeWaiter) runTimerService(ctx context.Context) {
 	defer close(tw.done) // signal goroutine exit for EventHub.Shutdown drain
 
+	// due is the instant the next fire belongs to — the absolute deadline
+	// first, when there is one (a restored overdue one is in the past), then
+	// each interval from the previous fire — so the lag is measured against
+	// the plan, not against the wait actually armed.
+	due := tw.next
+
 	for {
+		if due.IsZero() {
+			due = tw.rt.Clock().Now().Add(tw.duration)
+		}
+
 		fire := tw.rt.Clock().After(tw.duration)
 
 		select {
@@ -367,6 +382,12 @@ func (tw *timeWaiter) runTimerService(ctx context.Context) {
 			return
 
 		case <-fire:
+			tw.rt.MetricsRecorder().Histogram(TimerLagMetric).Record(ctx,
+				tw.rt.Clock().Now().Sub(due).Seconds(),
+				observability.Attr{Key: "holder", Value: "hub"})
+
+			due = time.Time{}
+
 			if err := tw.processTimerEvent(ctx); err != nil {
 				// errTimerCompleted is the normal one-shot / exhausted
 				// termination — silent. Any other error is a real delivery or
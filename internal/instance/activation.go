@@ -2,6 +2,7 @@ package instance
 
 import (
 	"context"
+	"strconv"
 
 	"github.com/dr-dobermann/gobpm/pkg/errs"
 	"github.com/dr-dobermann/gobpm/pkg/exec"
@@ -52,11 +53,16 @@ func (inst *Instance) guardEval(ctx context.Context) exec.GuardEval {
 // sets the state directly rather than through setState, so this is the one place
 // Created is observable. No local handle observer can exist yet (the handle
 // reaches the host only after StartProcess), so it reaches the engine sink +
-// echo.
+// echo. It names the process version the instance runs, so a history store
+// keys the instance without reading its checkpoint.
 func (inst *Instance) announceCreated() {
 	inst.report(observability.Fact{
 		Kind:  observability.KindInstanceState,
 		Phase: observability.PhaseCreated,
+		Details: map[string]string{
+			observability.AttrProcessID: inst.s.ProcessID,
+			observability.AttrVersion:   strconv.Itoa(inst.s.Version),
+		},
 	})
 }
 
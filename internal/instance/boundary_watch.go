@@ -487,6 +487,10 @@ func (ls *loopState) fireBoundary(ctx context.Context, ev trackEvent) {
 		return // the host already completed and disarmed — the fire lost the race.
 	}
 
+	if !ls.acceptMessage(ev.eDef) {
+		return // a redelivered message boundary — it already fired.
+	}
+
 	// ev.node is the boundaryWatch's own boundary (set in ProcessEvent). A
 	// different node here means the fire was routed to the wrong watch — the
 	// instance cannot reason about which boundary caught, so it fails rather
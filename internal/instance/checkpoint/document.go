@@ -29,7 +29,18 @@ import (
 // compensation sweeps. Additive again: a Schema-3 document was only
 // ever written with no construct in flight (the retired capture guards
 // guaranteed it), so absent records mean "nothing to rebuild".
-const CurrentSchema = 4
+//
+// 4 → 5 added the message outbox. Additive: a Schema-4 document was
+// written by an engine that published at send time, so it has nothing
+// left to relay.
+//
+// 5 → 6 added the accepted message ids. Additive: a Schema-5 document
+// remembers no delivery, so a redelivery after the upgrade is taken
+// once more, exactly as before.
+//
+// 6 → 7 added the triggers a suspended instance holds. Additive: a
+// Schema-6 document kept them in memory, so it has none to carry.
+const CurrentSchema = 7
 
 // Document is one instance's durable state (SRD-070 FR-3): identity +
 // the version pin, status, the scope table, conversation keys, the
@@ -63,6 +74,9 @@ type Document struct {
 	CallNodeID string `json:"call_node_id,omitempty"`
 	ProcessID  string `json:"process_id"`
 	Status     string `json:"status"`
+	// BusinessKey is the host's identifier for the instance. Empty for a
+	// keyless instance and on a checkpoint written before the field existed.
+	BusinessKey string `json:"business_key,omitempty"`
 
 	Scopes  []ScopeRecord  `json:"scopes"`
 	Ledgers []LedgerRecord `json:"ledgers,omitempty"`
@@ -88,6 +102,20 @@ type Document struct {
 	// FR-1): the remaining queue and the entry being undone — the
 	// ledger alone is not the state once a sweep has consumed from it.
 	Sweeps []SweepRecord `json:"sweeps,omitempty"`
+	// Outbox is the messages sent but not yet relayed to the broker
+	// (Schema 5): in outbox mode a send is recorded here, and the
+	// engine publishes it only once the document holding it is saved.
+	Outbox []OutboxRecord `json:"outbox,omitempty"`
+	// AcceptedMessages are the broker MessageIDs the instance consumed,
+	// oldest first (Schema 6): a redelivery of one of them is dropped,
+	// before and after a restart. Bounded — only the most recent ids
+	// are kept.
+	AcceptedMessages []string `json:"accepted_messages,omitempty"`
+	// Held are the messages and signals that reached the instance while
+	// an operator held it suspended, in arrival order (Schema 7): Resume
+	// applies them, so they ride the document to outlive a restart or a
+	// handoff.
+	Held []HeldRecord `json:"held,omitempty"`
 
 	Schema  int `json:"schema"`
 	Version int `json:"version"` // the FR-1 pin
@@ -161,9 +189,20 @@ type TrackRecord struct {
 	// of a host that drives its own passes — sequential MI or a
 	// Standard Loop. nil for every other track.
 	MI *MIRecord `json:"mi,omitempty"`
+	// Eligible is a parked UserTask's assignment as it was distributed —
+	// the create listeners' changes included — so a restore re-announces
+	// it unchanged instead of re-resolving. nil for every other track, and
+	// in a document written before it was recorded (the restore then
+	// re-resolves).
+	Eligible *EligibilityRecord `json:"eligible,omitempty"`
 
 	Prev      []string `json:"prev,omitempty"`
 	MsgDefIDs []string `json:"msg_def_ids,omitempty"`
+	// Relocate is the node-id chain an operator's start-before still has to
+	// enter (an instance modification) — the composites below the track's
+	// node, ending with the target. Empty for every other track, and in a
+	// document written before it was recorded.
+	Relocate []string `json:"relocate,omitempty"`
 
 	LoopCounter int `json:"loop_counter,omitempty"`
 }
@@ -225,6 +264,36 @@ type SweepRecord struct {
 	Wait         bool           `json:"wait,omitempty"`
 }
 
+// OutboxRecord is one staged outgoing message (Schema 5): the
+// broker envelope, its payload in the canonical codec. ID is the
+// envelope's MessageID — minted once at the send and kept across
+// relay attempts, so a relay repeated after a crash is recognizable
+// as the same message downstream.
+type OutboxRecord struct {
+	Payload        json.RawMessage `json:"payload,omitempty"`
+	ID             string          `json:"id"`
+	Name           string          `json:"name"`
+	CorrelationKey string          `json:"correlation_key,omitempty"`
+}
+
+// HeldRecord is one message or signal a suspended instance holds for
+// Resume (Schema 7). A message is located by the catch definition it
+// fired — NodeID and DefIndex, as a BoundaryRecord locates its
+// definition, since ids are minted per model build — and carries its
+// broker id. A signal names itself and the parked track it reached.
+// Payload is the fired item's value in the canonical codec.
+type HeldRecord struct {
+	Payload   json.RawMessage `json:"payload,omitempty"`
+	Trigger   string          `json:"trigger"`
+	TrackID   string          `json:"track_id,omitempty"`
+	NodeID    string          `json:"node_id,omitempty"`
+	MessageID string          `json:"message_id,omitempty"`
+	Signal    string          `json:"signal,omitempty"`
+	ItemID    string          `json:"item_id,omitempty"`
+	Origin    string          `json:"origin,omitempty"`
+	DefIndex  int             `json:"def_index,omitempty"`
+}
+
 // BoundaryRecord is one ARMED boundary event guarding a captured track
 // (SRD-071 FR-9a). A boundary is not a track — it has no token and no
 // lineage — so it rides its host's record set rather than becoming one.
@@ -245,6 +314,21 @@ type BoundaryRecord struct {
 	DefIndex   int              `json:"def_index"`
 }
 
+// EligibilityRecord is a parked UserTask's resolved assignment: the triad
+// and the authorizing roles, each an identifier set.
+type EligibilityRecord struct {
+	Assignee        SlotRecord `json:"assignee"`
+	CandidateUsers  SlotRecord `json:"candidate_users"`
+	CandidateGroups SlotRecord `json:"candidate_groups"`
+	Roles           SlotRecord `json:"roles"`
+}
+
+// SlotRecord is one resolved member of an EligibilityRecord.
+type SlotRecord struct {
+	IDs      []string `json:"ids,omitempty"`
+	Declared bool     `json:"declared,omitempty"`
+}
+
 // TimerDescriptor pins a parked timer wait.
 type TimerDescriptor struct {
 	Deadline   time.Time `json:"deadline"`
@@ -15,11 +15,12 @@ func TestDocumentRoundTrip(t *testing.T) {
 	deadline := time.Date(2026, 8, 1, 12, 0, 0, 0, time.UTC)
 
 	d := &checkpoint.Document{
-		InstanceID: "inst-1",
-		ProcessID:  "order",
-		Version:    3,
-		Status:     "Active",
-		ConvKeys:   map[string]string{"orderID": "42"},
+		InstanceID:  "inst-1",
+		ProcessID:   "order",
+		BusinessKey: "ord-42",
+		Version:     3,
+		Status:      "Active",
+		ConvKeys:    map[string]string{"orderID": "42"},
 		Scopes: []checkpoint.ScopeRecord{
 			{Path: "/order", Data: []byte(`[]`)},
 		},
@@ -28,9 +29,10 @@ func TestDocumentRoundTrip(t *testing.T) {
 		},
 		Tracks: []checkpoint.TrackRecord{
 			{
-				ID:     "tr-1",
-				State:  "TrackWaitForEvent",
-				NodeID: "wait-timer",
+				ID:       "tr-1",
+				State:    "TrackWaitForEvent",
+				NodeID:   "wait-timer",
+				Relocate: []string{"check"},
 				Timer: &checkpoint.TimerDescriptor{
 					Deadline:   deadline,
 					CyclesLeft: 2,
@@ -52,7 +54,9 @@ func TestDocumentRoundTrip(t *testing.T) {
 	require.NotNil(t, back.Tracks[0].Timer)
 	require.True(t, deadline.Equal(back.Tracks[0].Timer.Deadline))
 	require.Equal(t, 2, back.Tracks[0].Timer.CyclesLeft)
+	require.Equal(t, []string{"check"}, back.Tracks[0].Relocate)
 	require.Equal(t, "42", back.ConvKeys["orderID"])
+	require.Equal(t, "ord-42", back.BusinessKey)
 }
 
 // TestDocumentValidation: the loud gates.
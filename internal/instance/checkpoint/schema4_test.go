@@ -11,7 +11,7 @@ import (
 )
 
 // SRD-082 T-1 — the schema-4 position records round-trip, the schema
-// stamps 4, and the future-schema refusal still fires.
+// stamps the current one, and the future-schema refusal still fires.
 func TestSchemaFourRoundTrip(t *testing.T) {
 	doc := &Document{
 		InstanceID: "i1",
@@ -59,7 +59,8 @@ func TestSchemaFourRoundTrip(t *testing.T) {
 
 	back, err := Unmarshal(raw)
 	require.NoError(t, err)
-	require.Equal(t, 4, back.Schema, "Marshal stamps the current schema")
+	require.Equal(t, CurrentSchema, back.Schema,
+		"Marshal stamps the current schema")
 	require.Equal(t, doc.Calls, back.Calls)
 	require.Equal(t, doc.MIGroups, back.MIGroups)
 	require.Equal(t, doc.Sweeps, back.Sweeps)
@@ -67,11 +68,11 @@ func TestSchemaFourRoundTrip(t *testing.T) {
 }
 
 func TestFutureSchemaStillRefused(t *testing.T) {
-	raw := []byte(`{"instance_id":"i","process_id":"p","schema":5}`)
+	raw := []byte(`{"instance_id":"i","process_id":"p","schema":8}`)
 
 	_, err := Unmarshal(raw)
 	require.Error(t, err)
-	require.Contains(t, err.Error(), "schema 1..4")
+	require.Contains(t, err.Error(), "schema 1..7")
 }
 
 // TestEncodeDecodeValue pins the staging codec (SRD-082 FR-1): a
@@ -0,0 +1,40 @@
+package checkpoint
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// The schema-5 outbox round-trips, and a schema-4 document — written
+// before the outbox existed — reads with nothing to relay.
+func TestSchemaFiveOutbox(t *testing.T) {
+	doc := &Document{
+		InstanceID: "i1",
+		ProcessID:  "p1",
+		Status:     "Active",
+		Outbox: []OutboxRecord{
+			{
+				Payload:        json.RawMessage(`{"kind":"string","value":"x"}`),
+				ID:             "i1/m-1",
+				Name:           "order-placed",
+				CorrelationKey: "o-42",
+			},
+			{ID: "i1/m-2", Name: "ping"},
+		},
+	}
+
+	raw, err := doc.Marshal()
+	require.NoError(t, err)
+
+	back, err := Unmarshal(raw)
+	require.NoError(t, err)
+	require.Equal(t, CurrentSchema, back.Schema)
+	require.Equal(t, doc.Outbox, back.Outbox)
+
+	old, err := Unmarshal([]byte(
+		`{"instance_id":"i","process_id":"p","schema":4}`))
+	require.NoError(t, err)
+	require.Empty(t, old.Outbox)
+}
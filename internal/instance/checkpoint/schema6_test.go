@@ -0,0 +1,31 @@
+package checkpoint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// The schema-6 accepted message ids round-trip in order, and a schema-5
+// document — written before deliveries were remembered — reads with none.
+func TestSchemaSixAcceptedMessages(t *testing.T) {
+	doc := &Document{
+		InstanceID:       "i1",
+		ProcessID:        "p1",
+		Status:           "Active",
+		AcceptedMessages: []string{"src/m-2", "src/m-1"},
+	}
+
+	raw, err := doc.Marshal()
+	require.NoError(t, err)
+
+	back, err := Unmarshal(raw)
+	require.NoError(t, err)
+	require.Equal(t, CurrentSchema, back.Schema)
+	require.Equal(t, doc.AcceptedMessages, back.AcceptedMessages)
+
+	old, err := Unmarshal([]byte(
+		`{"instance_id":"i","process_id":"p","schema":5}`))
+	require.NoError(t, err)
+	require.Empty(t, old.AcceptedMessages)
+}
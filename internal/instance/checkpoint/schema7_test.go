@@ -0,0 +1,38 @@
+package checkpoint
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// The schema-7 held triggers round-trip in order, and a schema-6 document
+// — written while they were kept in memory — reads with none.
+func TestSchemaSevenHeldTriggers(t *testing.T) {
+	doc := &Document{
+		InstanceID: "i1",
+		ProcessID:  "p1",
+		Status:     "Suspended",
+		Held: []HeldRecord{
+			{
+				Trigger: "Message", NodeID: "catch", DefIndex: 1,
+				MessageID: "src/m-1", Payload: json.RawMessage(`{"v":1}`),
+			},
+			{Trigger: "Signal", TrackID: "t1", Signal: "go", Origin: "e2"},
+		},
+	}
+
+	raw, err := doc.Marshal()
+	require.NoError(t, err)
+
+	back, err := Unmarshal(raw)
+	require.NoError(t, err)
+	require.Equal(t, CurrentSchema, back.Schema)
+	require.Equal(t, doc.Held, back.Held)
+
+	old, err := Unmarshal([]byte(
+		`{"instance_id":"i","process_id":"p","schema":6}`))
+	require.NoError(t, err)
+	require.Empty(t, old.Held)
+}
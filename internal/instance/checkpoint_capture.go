@@ -7,8 +7,10 @@ import (
 	"time"
 
 	"github.com/dr-dobermann/gobpm/internal/instance/checkpoint"
+	"github.com/dr-dobermann/gobpm/pkg/interactor"
 	"github.com/dr-dobermann/gobpm/pkg/observability"
 	"github.com/dr-dobermann/gobpm/pkg/repository"
+	"github.com/dr-dobermann/gobpm/pkg/repository/payloadcodec"
 )
 
 // WithCheckpointing arms the instance's consistent-cut checkpoints
@@ -32,6 +34,15 @@ func WithCheckpointing(owner, group string, ttl time.Duration) Option {
 	}
 }
 
+// WithCheckpointCodec sets the codec chain every checkpoint payload passes
+// through before Save — compression, encryption. A nil chain (the default)
+// writes the plain document.
+func WithCheckpointCodec(c *payloadcodec.Chain) Option {
+	return func(cfg *newConfig) {
+		cfg.cpCodec = c
+	}
+}
+
 // checkpointTransitions lists the trackEvent kinds that close an
 // observable lifecycle transition — the persist points (ADR-033 §2.2;
 // the loop checkpoints AFTER applying one of these).
@@ -79,7 +90,13 @@ var liveTrackStates = map[trackState]bool{
 // the instance runs on volatile until the next transition retries.
 func (ls *loopState) maybeCheckpoint(ctx context.Context, kind trackEventKind) {
 	inst := ls.inst
-	if inst.cpOwner == "" || !checkpointTransitions[kind] {
+	if inst.cpOwner == "" {
+		return
+	}
+
+	// an unrelayed outbox message makes any event a persist point: the
+	// message leaves only behind a saved checkpoint (outbox.go).
+	if !checkpointTransitions[kind] && inst.outboxPending.Load() == 0 {
 		return
 	}
 
@@ -87,25 +104,48 @@ func (ls *loopState) maybeCheckpoint(ctx context.Context, kind trackEventKind) {
 }
 
 // checkpointNow builds and saves the document unconditionally (the
-// activation and terminal writes call it directly).
+// activation and terminal writes call it directly), then relays the
+// outbox messages the saved document holds — saving again after each
+// relay, so the record stops holding what already left.
 func (ls *loopState) checkpointNow(ctx context.Context) {
-	inst := ls.inst
-	if inst.cpOwner == "" {
+	if ls.inst.cpOwner == "" {
 		return
 	}
 
-	doc, deferReason := ls.captureDocument(ctx)
+	defer ls.rearmRelayRetry()
+
+	for {
+		cut, saved := ls.saveCheckpoint(ctx)
+		if !saved || len(cut) == 0 || !ls.relayOutbox(ctx, cut) {
+			return
+		}
+	}
+}
+
+// saveCheckpoint captures and saves the document once, returning the
+// outbox messages it holds and whether the save landed.
+func (ls *loopState) saveCheckpoint(ctx context.Context) ([]outboxEntry, bool) {
+	inst := ls.inst
+
+	doc, cut, deferReason := ls.captureDocument(ctx)
 	if deferReason != "" {
 		inst.reportCheckpointDeferred(deferReason)
 
-		return
+		return nil, false
 	}
 
 	payload, err := doc.Marshal()
 	if err != nil {
 		inst.reportCheckpointDeferred("marshal: " + err.Error())
 
-		return
+		return nil, false
+	}
+
+	payload, err = inst.cpCodec.Encode(ctx, inst.ID(), payload)
+	if err != nil {
+		inst.reportCheckpointDeferred("encode: " + err.Error())
+
+		return nil, false
 	}
 
 	rec := repository.InstanceRecord{
@@ -117,6 +157,9 @@ func (ls *loopState) checkpointNow(ctx context.Context) {
 		// wires real assignment.
 		Group:      inst.cpGroup,
 		RecVersion: inst.cpRecVersion,
+		// lifted from the payload for the business-key lookup.
+		ProcessID:   inst.s.ProcessID,
+		BusinessKey: inst.businessKey,
 		Lease: repository.Lease{
 			Owner:       inst.cpOwner,
 			Incarnation: inst.cpIncarnation,
@@ -127,19 +170,22 @@ func (ls *loopState) checkpointNow(ctx context.Context) {
 	if err := inst.Repository().Save(ctx, rec); err != nil {
 		inst.reportCheckpointDeferred("save: " + err.Error())
 
-		return
+		return nil, false
 	}
 
 	inst.cpRecVersion++
+
+	return cut, true
 }
 
 // captureDocument builds the Schema-1 document from the loop-confined
 // state — a consistent cut by construction (the loop is the single
-// writer). A non-empty defer reason means the document cannot be
-// captured faithfully yet (SRD-070 FR-4's defer-don't-die list).
+// writer) — and returns the outbox messages the document holds. A
+// non-empty defer reason means the document cannot be captured
+// faithfully yet (SRD-070 FR-4's defer-don't-die list).
 func (ls *loopState) captureDocument(
 	ctx context.Context,
-) (*checkpoint.Document, string) {
+) (*checkpoint.Document, []outboxEntry, string) {
 	inst := ls.inst
 
 	// No capture-deferral guards remain (SRD-082 FR-8): every composite
@@ -157,17 +203,20 @@ func (ls *loopState) captureDocument(
 		ConvKeys:    inst.corr.snapshotKeys(),
 		CompletedBy: inst.performers.snapshot(),
 		StartedAt:   inst.startedAtRFC3339(),
+		BusinessKey: inst.businessKey,
+
+		AcceptedMessages: inst.accepted.list(),
 	}
 
 	for _, path := range inst.sc.plane.OpenPaths() {
 		dd, err := inst.sc.plane.OwnData(path)
 		if err != nil {
-			return nil, "scope read: " + err.Error()
+			return nil, nil, "scope read: " + err.Error()
 		}
 
 		raw, err := checkpoint.EncodeData(ctx, string(path), dd)
 		if err != nil {
-			return nil, "encode: " + err.Error()
+			return nil, nil, "encode: " + err.Error()
 		}
 
 		doc.Scopes = append(doc.Scopes,
@@ -177,40 +226,56 @@ func (ls *loopState) captureDocument(
 	for path, entries := range ls.ledgers {
 		rr, err := ledgerRecords(ctx, string(path), entries)
 		if err != nil {
-			return nil, "ledger encode: " + err.Error()
+			return nil, nil, "ledger encode: " + err.Error()
 		}
 
 		doc.Ledgers = append(doc.Ledgers, rr...)
 	}
 
+	// the restored outbox goes first: it was sent before anything the
+	// tracks staged since.
+	cut := append([]outboxEntry(nil), inst.restoredOutbox...)
+
 	for _, t := range inst.tracks {
 		// a sweep handler's track is fully represented by its
 		// SweepRecord.Running — restoring it as a plain track TOO would
-		// run the handler twice (SRD-082 FR-6).
+		// run the handler twice (SRD-082 FR-6). Its sends still ride.
 		if _, isHandler := ls.sweeps[t.ID()]; isHandler {
+			cut = append(cut, t.outboxSnap()...)
+
 			continue
 		}
 
-		rec, live, err := trackRecord(ctx, t, ls.iter[t.ID()])
+		rec, sent, live, err := trackRecord(ctx, t, ls.iter[t.ID()])
+		cut = append(cut, sent...)
+
 		if err != nil {
-			return nil, "encode: " + err.Error()
+			return nil, nil, "encode: " + err.Error()
 		}
 
-		if live {
-			doc.Tracks = append(doc.Tracks, rec)
+		if !live {
+			continue
+		}
+
+		// a parked UserTask keeps the eligibility it was distributed with
+		// — the create listeners' changes included — across a restore.
+		if e, parked := ls.tasks[rec.TaskID]; parked && e.track == t {
+			rec.Eligible = eligibilityRecord(&e.eligible)
 		}
+
+		doc.Tracks = append(doc.Tracks, rec)
 	}
 
 	groups, encErr := ls.miGroupRecords(ctx)
 	if encErr != "" {
-		return nil, encErr
+		return nil, nil, encErr
 	}
 
 	doc.MIGroups = groups
 
 	sweeps, encErr := ls.sweepRecords(ctx)
 	if encErr != "" {
-		return nil, encErr
+		return nil, nil, encErr
 	}
 
 	doc.Sweeps = sweeps
@@ -218,7 +283,18 @@ func (ls *loopState) captureDocument(
 	doc.Boundaries = ls.boundaryRecords()
 	doc.Incidents = inst.incidentRecords()
 
-	return doc, ""
+	held, err := ls.heldRecords(ctx)
+	if err != nil {
+		return nil, nil, "held encode: " + err.Error()
+	}
+
+	doc.Held = held
+
+	for _, e := range cut {
+		doc.Outbox = append(doc.Outbox, e.record())
+	}
+
+	return doc, cut, ""
 }
 
 // miGroupRecords captures the parallel Multi-Instance open sets
@@ -443,15 +519,20 @@ func (ls *loopState) sweepRecords(
 // rides the track mutex: the capture runs on the loop goroutine while
 // the track's own goroutine may be mid-arming (writes guarded on its
 // side too). mirror is the loop-owned iteration position of an
-// own-iteration host (SRD-082 FR-2), nil for every other track.
+// own-iteration host (SRD-082 FR-2), nil for every other track. The
+// track's outbox is returned live or not — an ended track's sends
+// still have to leave — and from the same critical section as its
+// position, the outbox's consistency rule (outbox.go).
 func trackRecord(
 	ctx context.Context, t *track, mirror *iterMirror,
-) (checkpoint.TrackRecord, bool, error) {
+) (checkpoint.TrackRecord, []outboxEntry, bool, error) {
 	t.m.RLock()
 	defer t.m.RUnlock()
 
+	sent := append([]outboxEntry(nil), t.outbox...)
+
 	if !liveTrackStates[t.state] {
-		return checkpoint.TrackRecord{}, false, nil
+		return checkpoint.TrackRecord{}, sent, false, nil
 	}
 
 	rec := checkpoint.TrackRecord{
@@ -466,7 +547,11 @@ func trackRecord(
 		LoopCounter: t.loopCounter,
 	}
 
-	if t.state == TrackWaitForEvent && !t.timerDeadline.IsZero() {
+	// a released wait keeps its plan too: a suspended instance is rebuilt
+	// from this record with no pending trigger, so its timer re-arms at the
+	// recorded deadline — already past, after a long suspension.
+	if (t.state == TrackWaitForEvent || t.state == TrackDehydrated) &&
+		!t.timerDeadline.IsZero() {
 		rec.Timer = &checkpoint.TimerDescriptor{
 			Deadline:   t.timerDeadline,
 			CyclesLeft: t.timerCycles,
@@ -489,7 +574,7 @@ func trackRecord(
 			raw, err := checkpoint.EncodeValue(
 				ctx, "track "+t.ID(), mirror.staging)
 			if err != nil {
-				return checkpoint.TrackRecord{}, false, err
+				return checkpoint.TrackRecord{}, nil, false, err
 			}
 
 			mi.Staging = raw
@@ -498,7 +583,13 @@ func trackRecord(
 		rec.MI = mi
 	}
 
-	return rec, true, nil
+	// an operator's start still on its way into a composite: the chain rides
+	// the record, so a rebuild re-enters the relocated node, not the entry.
+	for _, n := range t.relocate {
+		rec.Relocate = append(rec.Relocate, n.ID())
+	}
+
+	return rec, sent, true, nil
 }
 
 // persistedStatus maps the runtime lifecycle onto the repository's
@@ -513,6 +604,9 @@ var persistedStatusMap = map[State]repository.Status{
 	// operator-suspended — recovery claims it as an active record and wakes
 	// it from its checkpoint (SRD-071 FR-2).
 	Dehydrated: repository.StatusActive,
+	// an operator-suspended instance refuses triggers and recovery skips it
+	// (ADR-033 §2.6); only Resume brings it back.
+	Suspended: repository.StatusSuspended,
 }
 
 func persistedStatus(s State) repository.Status {
@@ -551,3 +645,20 @@ func (inst *Instance) reportCheckpointDeferred(reason string) {
 		},
 	})
 }
+
+// eligibilityRecord captures a task's resolved eligibility.
+func eligibilityRecord(e *interactor.Eligibility) *checkpoint.EligibilityRecord {
+	slot := func(s interactor.ResolvedSlot) checkpoint.SlotRecord {
+		return checkpoint.SlotRecord{
+			IDs:      append([]string(nil), s.IDs...),
+			Declared: s.Declared,
+		}
+	}
+
+	return &checkpoint.EligibilityRecord{
+		Assignee:        slot(e.Assignee),
+		CandidateUsers:  slot(e.CandidateUsers),
+		CandidateGroups: slot(e.CandidateGroups),
+		Roles:           slot(e.Roles),
+	}
+}
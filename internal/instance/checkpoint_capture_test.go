@@ -326,7 +326,7 @@ func TestCaptureArms(t *testing.T) {
 			ls.calls["c"] = &callEntry{
 				track: host, node: host.currentStep().node,
 			}
-			doc, reason := ls.captureDocument(context.Background())
+			doc, _, reason := ls.captureDocument(context.Background())
 			require.Empty(t, reason)
 			require.Len(t, doc.MIGroups, 1)
 			require.Len(t, doc.Sweeps, 1)
@@ -350,7 +350,7 @@ func TestCaptureArms(t *testing.T) {
 				}},
 			}}
 
-			doc, reason := ls.captureDocument(context.Background())
+			doc, _, reason := ls.captureDocument(context.Background())
 			require.Empty(t, reason)
 			require.Len(t, doc.Ledgers, 2, "the folded child records too")
 			require.Equal(t, "book", doc.Ledgers[0].ActivityID)
@@ -368,7 +368,7 @@ func TestCaptureArms(t *testing.T) {
 			_, err = inst.sc.plane.Commit(inst.sc.root, bad)
 			require.NoError(t, err)
 
-			_, reason := ls.captureDocument(context.Background())
+			_, _, reason := ls.captureDocument(context.Background())
 			require.Contains(t, reason, "encode:")
 		})
 
@@ -393,7 +393,7 @@ func TestCaptureArms(t *testing.T) {
 
 			inst.corr.keys["orderID"] = "42"
 
-			doc, reason := ls.captureDocument(context.Background())
+			doc, _, reason := ls.captureDocument(context.Background())
 			require.Empty(t, reason)
 			require.Equal(t, "42", doc.ConvKeys["orderID"])
 		})
@@ -808,7 +808,7 @@ func TestRestoreKeysAndLedgerEncodeArms(t *testing.T) {
 				snapshot:   []data.Data{bad},
 			}}
 
-			_, reason := ls.captureDocument(context.Background())
+			_, _, reason := ls.captureDocument(context.Background())
 			require.Contains(t, reason, "ledger encode:")
 		})
 }
@@ -18,16 +18,25 @@ var dataChangePhase = map[data.ChangeType]observability.Phase{
 
 // reportDataChanges publishes one DataChange fact per committed changed path
 // (SRD-044 FR-4, ADR-011 v.6 §2.9.4): the activity-boundary change signal a
-// node's frame commit produced, attributed to the committing node. DataChange
-// is observer-only (no operator-log echo — the kindNoEcho flood guard);
-// Instance.report's no-listener guard keeps the no-observer path cheap.
+// node's frame commit produced, attributed to the committing node.
 func (t *track) reportDataChanges(node flow.Node, changes []data.Change) {
+	t.instance.reportDataChanges(node.ID(), node.Name(), changes)
+}
+
+// reportDataChanges is the instance-level body of the DataChange report; an
+// operator's variable change carries no node, so nodeID and nodeName are
+// empty then. DataChange is observer-only (no operator-log echo — the
+// kindNoEcho flood guard); Instance.report's no-listener guard keeps the
+// no-observer path cheap.
+func (inst *Instance) reportDataChanges(
+	nodeID, nodeName string, changes []data.Change,
+) {
 	for _, c := range changes {
-		t.instance.report(observability.Fact{
+		inst.report(observability.Fact{
 			Kind:     observability.KindDataChange,
 			Phase:    dataChangePhase[c.Type],
-			NodeID:   node.ID(),
-			NodeName: node.Name(),
+			NodeID:   nodeID,
+			NodeName: nodeName,
 			Details: map[string]string{
 				observability.AttrDataPath: c.Path,
 			},
@@ -0,0 +1,325 @@
+package instance
+
+import (
+	"context"
+
+	"github.com/dr-dobermann/gobpm/internal/instance/checkpoint"
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/model/data"
+	"github.com/dr-dobermann/gobpm/pkg/model/events"
+	"github.com/dr-dobermann/gobpm/pkg/model/flow"
+	"github.com/dr-dobermann/gobpm/pkg/model/foundation"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+)
+
+// heldTrigger is a message or signal that reached a suspended instance after
+// it released its goroutines, riding the rebuild that holds it: from is the
+// released instance it was addressed to, whose graph locates its definition.
+type heldTrigger struct {
+	from    *Instance
+	pending PendingTrigger
+}
+
+// WithHeldTrigger hands a rebuild of a suspended instance a message or signal
+// that reached the instance after it released its goroutines: the rebuilt
+// loop holds it after the ones its checkpoint records, and its next
+// checkpoint persists it for Resume. released is the instance object the
+// trigger was addressed to.
+func WithHeldTrigger(released *Instance, p PendingTrigger) Option {
+	return func(cfg *newConfig) {
+		cfg.heldTrigger = &heldTrigger{from: released, pending: p}
+	}
+}
+
+// adoptRestoredHeld holds the triggers a restore rebuilt. An instance that
+// isn't suspended — Resume already applied what it held — has nothing to
+// hold them for, so they apply at once.
+func (ls *loopState) adoptRestoredHeld(ctx context.Context) {
+	held := ls.inst.restoredHeld
+	ls.inst.restoredHeld = nil
+
+	if ls.suspended {
+		ls.held = append(ls.held, held...)
+
+		return
+	}
+
+	for _, ev := range held {
+		ls.apply(ctx, ev)
+	}
+}
+
+// heldRecords renders the deliveries the suspended loop holds for the
+// document. A timer is left out — it re-arms at its track's recorded
+// deadline — and so are boundary and handler fires, which keep the instance
+// resident instead.
+func (ls *loopState) heldRecords(
+	ctx context.Context,
+) ([]checkpoint.HeldRecord, error) {
+	var rr []checkpoint.HeldRecord
+
+	for _, ev := range ls.held {
+		if ev.kind != evDeliver {
+			continue
+		}
+
+		trackID := ""
+		if ev.track != nil {
+			trackID = ev.track.ID()
+		}
+
+		rec, ok, err := ls.inst.heldRecord(ctx, trackID, ev.eDef)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			rr = append(rr, rec)
+		}
+	}
+
+	return rr, nil
+}
+
+// heldRecord renders one held delivery, reporting false for one the document
+// doesn't carry: a timer, or a message no definition of the instance catches
+// — its delivery would drop anyway.
+func (inst *Instance) heldRecord(
+	ctx context.Context, trackID string, eDef flow.EventDefinition,
+) (checkpoint.HeldRecord, bool, error) {
+	switch d := eDef.(type) {
+	case *events.MessageEventDefinition:
+		nodeID, idx, ok := inst.definitionOwner(d.ID())
+		if !ok {
+			return checkpoint.HeldRecord{}, false, nil
+		}
+
+		raw, err := encodeItem(ctx, d.Message().Item())
+
+		return checkpoint.HeldRecord{
+			Trigger:   string(flow.TriggerMessage),
+			NodeID:    nodeID,
+			DefIndex:  idx,
+			MessageID: d.MessageID(),
+			Payload:   raw,
+		}, err == nil, err
+
+	case *events.SignalEventDefinition:
+		rec := checkpoint.HeldRecord{
+			Trigger: string(flow.TriggerSignal),
+			TrackID: trackID,
+			Signal:  d.Signal().Name(),
+			Origin:  d.Origin(),
+		}
+
+		if item := d.Signal().Item(); item != nil {
+			rec.ItemID = item.ID()
+		}
+
+		raw, err := encodeItem(ctx, d.Signal().Item())
+		rec.Payload = raw
+
+		return rec, err == nil, err
+	}
+
+	return checkpoint.HeldRecord{}, false, nil
+}
+
+// encodeItem encodes a fired item's value; a definition without one has no
+// payload.
+func encodeItem(
+	ctx context.Context, item *data.ItemDefinition,
+) ([]byte, error) {
+	if item == nil || item.Structure() == nil {
+		return nil, nil
+	}
+
+	return checkpoint.EncodeValue(ctx, "held trigger", item.Structure())
+}
+
+// definitionOwner finds the node whose event definitions include the one
+// with id defID — anywhere in the graph, nested containers included — and
+// the definition's index there.
+func (inst *Instance) definitionOwner(defID string) (string, int, bool) {
+	var find func(nn []flow.Node) (string, int, bool)
+
+	find = func(nn []flow.Node) (string, int, bool) {
+		for _, n := range nn {
+			if en, ok := n.(flow.EventNode); ok {
+				for i, d := range en.Definitions() {
+					if d.ID() == defID {
+						return n.ID(), i, true
+					}
+				}
+			}
+
+			if c, ok := n.(interface{ Nodes() []flow.Node }); ok {
+				if id, i, ok := find(c.Nodes()); ok {
+					return id, i, true
+				}
+			}
+		}
+
+		return "", 0, false
+	}
+
+	nn := make([]flow.Node, 0, len(inst.s.Nodes))
+	for _, n := range inst.s.Nodes {
+		nn = append(nn, n)
+	}
+
+	return find(nn)
+}
+
+// restoreHeld rebuilds the triggers the document records as held, then the
+// one a rebuild brought along (WithHeldTrigger), for the loop to hold again.
+// Called by Restore after the track table is rebuilt: a signal returns to its
+// recorded track.
+func (inst *Instance) restoreHeld(
+	ctx context.Context, rr []checkpoint.HeldRecord, extra *heldTrigger,
+) error {
+	if extra != nil {
+		rec, ok, err := extra.from.heldRecord(
+			ctx, extra.pending.TrackID, extra.pending.EDef)
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			rr = append(rr, rec)
+		}
+	}
+
+	for _, rec := range rr {
+		ev, ok, err := inst.heldEvent(ctx, rec)
+		if err != nil {
+			return errs.New(
+				errs.M("Restore: couldn't rebuild a held %s", rec.Trigger),
+				errs.C(errorClass, errs.OperationFailed),
+				errs.D(observability.AttrNodeID, rec.NodeID),
+				errs.D(observability.AttrTrackID, rec.TrackID),
+				errs.E(err))
+		}
+
+		if ok {
+			inst.restoredHeld = append(inst.restoredHeld, ev)
+		}
+	}
+
+	return nil
+}
+
+// heldEvent rebuilds one held delivery as the fire that brought it. A signal
+// whose track the document no longer carries reports false: its wait is gone,
+// as it would be for a delivery to a resident instance.
+func (inst *Instance) heldEvent(
+	ctx context.Context, rec checkpoint.HeldRecord,
+) (trackEvent, bool, error) {
+	var v data.Value
+
+	if len(rec.Payload) != 0 {
+		dv, err := checkpoint.DecodeValue(ctx, rec.Payload)
+		if err != nil {
+			return trackEvent{}, false, err
+		}
+
+		v = dv
+	}
+
+	switch flow.EventTrigger(rec.Trigger) {
+	case flow.TriggerMessage:
+		eDef, err := inst.heldMessage(rec, v)
+
+		return trackEvent{kind: evDeliver, eDef: eDef}, err == nil, err
+
+	case flow.TriggerSignal:
+		tr, ok := inst.tracks[rec.TrackID]
+		if !ok {
+			return trackEvent{}, false, nil
+		}
+
+		eDef, err := heldSignal(rec, v)
+
+		return trackEvent{kind: evDeliver, track: tr, eDef: eDef}, err == nil, err
+	}
+
+	return trackEvent{}, false, errs.New(
+		errs.M("unknown trigger %q", rec.Trigger),
+		errs.C(errorClass, errs.InvalidParameter))
+}
+
+// heldMessage clones the recorded catch definition with the held payload, as
+// the message waiter builds the fire from the broker's envelope.
+func (inst *Instance) heldMessage(
+	rec checkpoint.HeldRecord, v data.Value,
+) (flow.EventDefinition, error) {
+	n, ok := inst.nodeByID(rec.NodeID)
+
+	en, isEvent := n.(flow.EventNode)
+	if !ok || !isEvent || rec.DefIndex < 0 ||
+		rec.DefIndex >= len(en.Definitions()) {
+		return nil, errs.New(
+			errs.M("no message definition #%d at node %q",
+				rec.DefIndex, rec.NodeID),
+			errs.C(errorClass, errs.ObjectNotFound))
+	}
+
+	med, ok := en.Definitions()[rec.DefIndex].(*events.MessageEventDefinition)
+	if !ok {
+		return nil, errs.New(
+			errs.M("definition #%d at node %q isn't a message one",
+				rec.DefIndex, rec.NodeID),
+			errs.C(errorClass, errs.InvalidParameter))
+	}
+
+	var dd []data.Data
+
+	if item := med.Message().Item(); item != nil && v != nil {
+		datum, err := data.ReadyValueParameter(item.ID(), v,
+			foundation.WithID(item.ID()))
+		if err != nil {
+			return nil, err
+		}
+
+		dd = append(dd, datum)
+	}
+
+	fired, err := med.CloneEventDefinition(dd)
+	if err != nil || rec.MessageID == "" {
+		return fired, err
+	}
+
+	if fm, ok := fired.(*events.MessageEventDefinition); ok {
+		return fm.WithMessageID(rec.MessageID), nil
+	}
+
+	return fired, nil
+}
+
+// heldSignal rebuilds a held signal with its payload and origin.
+func heldSignal(
+	rec checkpoint.HeldRecord, v data.Value,
+) (flow.EventDefinition, error) {
+	var item *data.ItemDefinition
+
+	if v != nil {
+		i, err := data.NewItemDefinition(v, foundation.WithID(rec.ItemID))
+		if err != nil {
+			return nil, err
+		}
+
+		item = i
+	}
+
+	sig, err := events.NewSignal(rec.Signal, item)
+	if err != nil {
+		return nil, err
+	}
+
+	sed, err := events.NewSignalEventDefinition(sig)
+	if err != nil || rec.Origin == "" {
+		return sed, err
+	}
+
+	return sed.WithOrigin(rec.Origin), nil
+}
@@ -13,6 +13,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/dr-dobermann/gobpm/pkg/observability"
+	"slices"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -26,9 +27,11 @@ import (
 	"github.com/dr-dobermann/gobpm/pkg/exec"
 	"github.com/dr-dobermann/gobpm/pkg/interactor"
 	"github.com/dr-dobermann/gobpm/pkg/model/data"
+	"github.com/dr-dobermann/gobpm/pkg/model/events"
 	"github.com/dr-dobermann/gobpm/pkg/model/flow"
 	"github.com/dr-dobermann/gobpm/pkg/model/foundation"
 	engrenv "github.com/dr-dobermann/gobpm/pkg/renv"
+	"github.com/dr-dobermann/gobpm/pkg/repository/payloadcodec"
 )
 
 const errorClass = "INSTANCE_ERROR"
@@ -47,6 +50,10 @@ type Instance struct {
 	callReq             chan callRequest
 	scopeReq            chan scopeRequest
 	incidentReq         chan incidentRequest
+	suspendReq          chan suspendRequest
+	ownershipReq        chan ownershipRequest
+	varReq              chan varRequest
+	modReq              chan modRequest
 	invoker             exec.ProcessInvoker
 	waitHolders         exec.WaitHolders
 	sc                  instanceScope
@@ -70,6 +77,19 @@ type Instance struct {
 	pendingIncidentOp *incidentRequest
 	// pendingCancel is an operator cancel riding a rebuild (FIX-038 §1.10).
 	pendingCancel *cancelRequest
+	// pendingSuspension is an operator Suspend/Resume riding a rebuild
+	// (ADR-033 §2.6), the pendingCancel shape.
+	pendingSuspension *suspendRequest
+	// pendingVariables is an operator variable change riding a rebuild, the
+	// pendingSuspension shape.
+	pendingVariables *varRequest
+	// pendingModification is an operator modification riding a rebuild, the
+	// pendingSuspension shape.
+	pendingModification *modRequest
+	// suspendGate is the operator hold (ADR-033 §2.6): non-nil while the
+	// instance is suspended, closed and cleared by Resume. A track reads it
+	// before each node it executes. The loop is its only writer.
+	suspendGate atomic.Pointer[chan struct{}]
 	// incidentsSnap is the copy-on-write projection IncidentViews serves —
 	// rebuilt by the loop after every incident mutation (the tracksSnap
 	// pattern).
@@ -88,12 +108,37 @@ type Instance struct {
 	// back to its caller's Call Activity node. Empty for a top-level instance.
 	parentInstanceID string
 	callNodeID       string
+	// startVars is the sorted, comma-joined names of the host-passed start
+	// variables (WithStartData) Run stamps on the Active fact; empty when the
+	// instance was started without any.
+	startVars string
+	// businessKey is the host's own identifier for the instance
+	// (WithBusinessKey); it rides the checkpoint and the persisted record.
+	// Empty for a keyless instance.
+	businessKey string
 	// cpOwner arms checkpointing (SRD-070 FR-4); its int-sized siblings
 	// (cpTTL/cpRecVersion/cpIncarnation) sit at the struct tail, outside
 	// the GC pointer scan (fieldalignment). cpGroup is the engine's
 	// group every record is stamped with (SRD-078 FR-2, ADR-033 §2.8).
 	cpOwner string
 	cpGroup string
+	// cpCodec encodes every checkpoint payload before Save (compression,
+	// encryption); nil writes the plain document.
+	cpCodec *payloadcodec.Chain
+	// restoredOutbox holds the recorded outbox messages a restore found
+	// (outbox.go); written before the loop starts, then loop-owned until
+	// the relay drains it.
+	restoredOutbox []outboxEntry
+	// restoredHeld are the triggers a suspended instance's checkpoint
+	// records as held (held.go); written before the loop starts, which
+	// holds them again.
+	restoredHeld []trackEvent
+	// heldTrigger is the trigger a rebuild holds with the recorded ones
+	// (WithHeldTrigger); Restore consumes it.
+	heldTrigger *heldTrigger
+	// accepted remembers the broker MessageIDs the instance consumed
+	// (message_dedup.go); seeded before the loop starts, then loop-owned.
+	accepted acceptedMessages
 	// waitHeld reports whether a parked track's wait has an engine-level holder
 	// that can wake a released instance (SRD-071 FR-2). nil (the default, and
 	// production without an injected WaitHolders) means "nothing held" — the
@@ -141,12 +186,23 @@ type Instance struct {
 	// openIncCount mirrors the number of OPEN incidents for lock-free reads
 	// off the loop (OpenIncidents); the loop is its only writer.
 	openIncCount atomic.Int32
+	// outboxPending counts the outbox messages not yet relayed: tracks
+	// add on promotion, the relay subtracts. Non-zero makes every applied
+	// event a persist point, so a sent message never waits for the next
+	// lifecycle transition.
+	outboxPending atomic.Int64
 	// The checkpoint cursors (SRD-070 FR-4): the lease TTL, the CAS
 	// record version, the lease fencing incarnation (grows on reclaim,
 	// SRD-071+). Non-pointer tail — see cpOwner above.
 	cpTTL         time.Duration
 	cpRecVersion  int64
 	cpIncarnation int64
+	// outbox stages sends for the checkpoint to relay (WithMessageOutbox).
+	outbox bool
+	// suspendAtStart marks an instance restored from a Suspended checkpoint:
+	// Run keeps it Suspended and the loop closes the gate before any track
+	// runs (ADR-033 §2.6).
+	suspendAtStart bool
 }
 
 // validatedTemplate checks New's required collaborators and returns the
@@ -365,6 +421,10 @@ type newConfig struct {
 	cpOwner    string
 	cpGroup    string
 	restoredID string
+	// cpCodec is the checkpoint payload codec chain (WithCheckpointCodec).
+	cpCodec *payloadcodec.Chain
+	// outbox stages sends for the checkpoint to relay (WithMessageOutbox).
+	outbox bool
 	// pendingIncidentOp is an operator incident operation riding a rebuild
 	// (SRD-079 §3.6) — applied by the loop before its park decision.
 	pendingIncidentOp *incidentRequest
@@ -393,8 +453,22 @@ type newConfig struct {
 	cpTTL         time.Duration
 	cpRecVersion  int64
 	cpIncarnation int64
+	// startVars names the host-passed start variables (WithStartData), stamped
+	// on the Active transition; empty for any other birth.
+	startVars string
+	// businessKey is the instance's business key (WithBusinessKey).
+	businessKey string
 	// residentPin starts the instance pinned against dehydration (SRD-071 FR-8).
 	residentPin bool
+	// pendingSuspension is an operator Suspend/Resume riding a rebuild.
+	pendingSuspension *suspendRequest
+	// pendingVariables is an operator variable change riding a rebuild.
+	pendingVariables *varRequest
+	// pendingModification is an operator modification riding a rebuild.
+	pendingModification *modRequest
+	// heldTrigger is a trigger a rebuild of a suspended instance holds
+	// with its recorded ones (WithHeldTrigger).
+	heldTrigger *heldTrigger
 }
 
 // newOption tunes New. The born-event / conversation-key options are exposed
@@ -427,6 +501,37 @@ func withRootData(dd []data.Data) newOption {
 	}
 }
 
+// WithStartData seeds the host-passed start variables into the root scope at
+// construction — the same injection point as a Call Activity's inputs
+// (withRootData) — and records their names for the Active transition fact.
+// The engine validates and clones the data before passing it; an empty slice
+// is a no-op.
+func WithStartData(dd []data.Data) Option {
+	return func(c *newConfig) {
+		c.rootData = dd
+
+		names := make([]string, 0, len(dd))
+		for _, d := range dd {
+			names = append(names, d.Name())
+		}
+
+		slices.Sort(names)
+		c.startVars = strings.Join(names, ",")
+	}
+}
+
+// WithBusinessKey gives the instance its business key — the host's own
+// identifier for it (an order number, a case id). The key rides every
+// checkpoint and the persisted record, so it survives dehydration and
+// restart. Surrounding blanks are trimmed; an empty key is a no-op.
+func WithBusinessKey(key string) Option {
+	return func(c *newConfig) {
+		if key = strings.TrimSpace(key); key != "" {
+			c.businessKey = key
+		}
+	}
+}
+
 // withCallLinkage stamps the call linkage (SRD-050 FR-4) onto every fact the
 // instance emits, stitching a child's trace back to its caller. Exposed via
 // NewChild. Empty ids leave the instance top-level (unstamped).
@@ -525,12 +630,20 @@ func New(
 		incidents:           map[string]*incident{},
 		pendingIncidentOp:   cfg.pendingIncidentOp,
 		pendingCancel:       cfg.pendingCancel,
+		pendingSuspension:   cfg.pendingSuspension,
+		pendingVariables:    cfg.pendingVariables,
+		pendingModification: cfg.pendingModification,
+		heldTrigger:         cfg.heldTrigger,
 		events:              make(chan trackEvent),
 		taskReq:             make(chan taskRequest),
 		jobReq:              make(chan jobRequest),
 		callReq:             make(chan callRequest),
 		scopeReq:            make(chan scopeRequest),
 		incidentReq:         make(chan incidentRequest),
+		suspendReq:          make(chan suspendRequest),
+		ownershipReq:        make(chan ownershipRequest),
+		varReq:              make(chan varRequest),
+		modReq:              make(chan modRequest),
 		invoker:             cfg.invoker,
 		callReattach:        cfg.callReattach,
 		waitHolders:         cfg.waitHolders,
@@ -540,8 +653,12 @@ func New(
 		td:                  td,
 		parentInstanceID:    cfg.parentInstanceID,
 		callNodeID:          cfg.callNodeID,
+		startVars:           cfg.startVars,
+		businessKey:         cfg.businessKey,
 		cpOwner:             cfg.cpOwner,
 		cpGroup:             cfg.cpGroup,
+		cpCodec:             cfg.cpCodec,
+		outbox:              cfg.outbox,
 		cpTTL:               cfg.cpTTL,
 		cpRecVersion:        cfg.cpRecVersion,
 		cpIncarnation:       cfg.cpIncarnation,
@@ -622,6 +739,9 @@ func (inst *Instance) seedInitialData(cfg *newConfig) (flow.Node, error) {
 		if err := inst.sc.bindEventPayload(cfg.bornEvent); err != nil {
 			return nil, err
 		}
+
+		// the born-from message is the conversation's first delivery.
+		inst.accepted.add(events.MessageIDOf(cfg.bornEvent))
 	}
 
 	if err := inst.sc.bindRootData(cfg.rootData); err != nil {
@@ -870,5 +990,11 @@ func (inst *Instance) ParentID() string { return inst.parentInstanceID }
 // "" for a root instance.
 func (inst *Instance) CallNodeID() string { return inst.callNodeID }
 
+// BusinessKey returns the instance's business key, "" for a keyless one.
+func (inst *Instance) BusinessKey() string { return inst.businessKey }
+
+// ProcessID returns the id of the process this instance runs.
+func (inst *Instance) ProcessID() string { return inst.s.ProcessID }
+
 // Version returns the pinned process version this instance runs.
 func (inst *Instance) Version() int { return inst.s.Version }
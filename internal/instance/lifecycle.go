@@ -16,8 +16,8 @@ import (
 type State uint32
 
 // Instance lifecycle states — the in-memory runtime lifecycle the instance
-// actually exercises (mirrors ADR-001 §4.2). The error branch and suspend are
-// owned by their future ADRs, not this runtime, and are absent here.
+// actually exercises (mirrors ADR-001 §4.2). The error branch is owned by its
+// future ADR, not this runtime, and is absent here.
 const (
 	// Created is a created instance, not yet running.
 	Created State = iota
@@ -34,6 +34,11 @@ const (
 	// terminal — a trigger hydrates it back to Active; its checkpoint is the
 	// hydration source.
 	Dehydrated
+	// Suspended is an instance an operator put on hold (ADR-033 §2.6): no
+	// token moves past the node it stands on, triggers wait for Resume, and
+	// the persisted status keeps recovery away from it. NOT terminal — it may
+	// stay resident or release its goroutines like a dehydrated instance.
+	Suspended
 )
 
 // String returns the human-readable name of the instance state.
@@ -45,6 +50,7 @@ func (s State) String() string {
 		"Terminating",
 		"Terminated",
 		"Dehydrated",
+		"Suspended",
 	}[s]
 }
 
@@ -75,6 +81,17 @@ func (inst *Instance) setStateDetailed(
 	})
 }
 
+// setStateAs is setState for a transition an operator asked for: the fact
+// names the act — Paused for a suspension, Resumed for its end (ADR-033 §2.6)
+// — rather than the state the instance lands in.
+func (inst *Instance) setStateAs(newState State, phase observability.Phase) {
+	inst.state.Store(uint32(newState))
+	inst.report(observability.Fact{
+		Kind:  observability.KindInstanceState,
+		Phase: phase,
+	})
+}
+
 // LastErr returns the fatal error that stopped the instance (e.g. a fork
 // whose target node could not be constructed), or nil. Set only by loop().
 func (inst *Instance) LastErr() error {
@@ -116,7 +133,23 @@ func (inst *Instance) Run(
 	// canceling either drives the loop's ctx.Done() termination path.
 	inst.ctx, inst.cancel = context.WithCancel(ctx)
 	inst.startTime = inst.now()
-	inst.setState(Active)
+
+	// Host-passed start variables ride the Active transition by name — their
+	// values are already in the root scope (WithStartData).
+	var details map[string]string
+	if inst.startVars != "" {
+		details = map[string]string{
+			observability.AttrStartVariables: inst.startVars,
+		}
+	}
+
+	// A suspended instance rebuilt for an operator's request stays suspended:
+	// it is the same instance on hold, so nothing is announced.
+	if inst.suspendAtStart {
+		inst.state.Store(uint32(Suspended))
+	} else {
+		inst.setStateDetailed(Active, details)
+	}
 
 	// initial tracks were built by createTracks() during New; hand them to the
 	// loop, which becomes the sole owner of lifecycle state from here on.
@@ -0,0 +1,158 @@
+package instance
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dr-dobermann/gobpm/internal/scope"
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/listener"
+	"github.com/dr-dobermann/gobpm/pkg/model/data"
+	"github.com/dr-dobermann/gobpm/pkg/model/flow"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+)
+
+// frameVariables is the listener.Variables view of a node's execution frame: a
+// read resolves frame-first like the node's own, a write is a frame put — it
+// commits with the node's results or is discarded with them.
+type frameVariables struct {
+	f *scope.Frame
+}
+
+// Get returns the value of the variable name.
+func (v frameVariables) Get(name string) (data.Value, error) {
+	d, err := v.f.GetData(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.Value(), nil
+}
+
+// Set puts the variable name into the frame.
+func (v frameVariables) Set(name string, val data.Value) error {
+	if val == nil {
+		return errs.New(
+			errs.M("listener variable %q has a nil value", name),
+			errs.C(errorClass, errs.EmptyNotAllowed),
+			errs.D(observability.AttrDataName, name))
+	}
+
+	p, err := data.ReadyValueParameter(name, val)
+	if err != nil {
+		return err
+	}
+
+	return v.f.Put(p)
+}
+
+// notifyListeners runs the execution listeners bound to node for ev, in their
+// stamped order, on the track's own goroutine. flowID names the taken flow of
+// an EventTake. The first failing listener stops the rest and fails the node:
+// the caller returns the error, the frame is discarded, and the track fails
+// into the Error-boundary / incident path like any execution failure.
+func (t *track) notifyListeners(
+	ctx context.Context,
+	ev listener.Event,
+	node flow.Node,
+	flowID string,
+	f *scope.Frame,
+) error {
+	bb := t.instance.s.Listeners
+	if len(bb) == 0 {
+		return nil
+	}
+
+	ex := listener.Execution{
+		Event:      ev,
+		InstanceID: t.instance.ID(),
+		ProcessID:  t.instance.ProcessID(),
+		NodeID:     node.ID(),
+		NodeName:   node.Name(),
+		FlowID:     flowID,
+	}
+
+	for _, b := range bb {
+		if !b.Matches(ex) {
+			continue
+		}
+
+		if err := callListener(ctx, b.Listener, ex, frameVariables{f: f}); err != nil {
+			return errs.New(
+				errs.M("%s listener of node %q failed", ev, node.Name()),
+				errs.C(errorClass, errs.OperationFailed),
+				errs.D(observability.AttrNodeID, node.ID()),
+				errs.D(observability.AttrNodeName, node.Name()),
+				errs.E(err))
+		}
+	}
+
+	return nil
+}
+
+// callListener runs one listener, turning a panic into its error: a broken
+// listener fails the activity it hooks, never the engine.
+func callListener(
+	ctx context.Context,
+	l listener.Listener,
+	ex listener.Execution,
+	vars listener.Variables,
+) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("listener panicked: %v", r)
+		}
+	}()
+
+	return l.Notify(ctx, ex, vars)
+}
+
+// NotifyTask runs the task listeners bound to task's UserTask for its event, in
+// their stamped order, filling in the task's node name. The first failing
+// listener stops the rest and its error is returned; what the error means is
+// the caller's — a vetoed assignment, a rejected completion, or a logged
+// create/delete. It reads only the immutable snapshot, so the engine calls it
+// for an ownership change without hydrating a released instance.
+func (inst *Instance) NotifyTask(ctx context.Context, task *listener.Task) error {
+	bb := inst.s.TaskListeners
+	if len(bb) == 0 {
+		return nil
+	}
+
+	if node, ok := inst.nodeByID(task.NodeID); ok {
+		task.NodeName = node.Name()
+	}
+
+	for _, b := range bb {
+		if !b.Matches(task) {
+			continue
+		}
+
+		if err := callTaskListener(ctx, b.Listener, task); err != nil {
+			return errs.New(
+				errs.M("%s listener of user task %q failed",
+					task.Event, task.NodeName),
+				errs.C(errorClass, errs.OperationFailed),
+				errs.D(observability.AttrTaskID, task.TaskID),
+				errs.D(observability.AttrNodeID, task.NodeID),
+				errs.E(err))
+		}
+	}
+
+	return nil
+}
+
+// callTaskListener runs one task listener, turning a panic into its error.
+func callTaskListener(
+	ctx context.Context,
+	l listener.TaskListener,
+	task *listener.Task,
+) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("task listener panicked: %v", r)
+		}
+	}()
+
+	return l.NotifyTask(ctx, task)
+}
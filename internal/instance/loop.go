@@ -59,6 +59,11 @@ type loopState struct {
 	// §3.4) — ONE Clock.After per instance, re-armed on every incident
 	// mutation; nil (blocking its select case) when nothing is scheduled.
 	retryC <-chan time.Time
+	// relayC delivers the retry of an outbox relay the broker refused — one
+	// Clock.After at a time, its delay doubling from relayRetryMin up to
+	// relayRetryMax while the refusals go on; nil when nothing waits.
+	relayC     <-chan time.Time
+	relayDelay time.Duration
 	// tasks is the loop-owned human-task registry (SRD-034): taskID → the parked
 	// UserTask track and node. Populated on evTaskWaiting (and at spawn for a task
 	// parked at construction), read by a Take/Complete taskReq, and cleared when
@@ -137,6 +142,17 @@ type loopState struct {
 	// every parked track has been released (SRD-071 FR-2): the loop tail then
 	// parks the instance (Dehydrated) instead of settling it Completed/Terminated.
 	dehydrating bool
+	// handingOff is set by handOff: the engine gave the instance up to its
+	// group, so the loop leaves at once — no settle, no further checkpoint —
+	// and the last checkpoint is what another engine recovers.
+	handingOff bool
+	// suspended is set while an operator holds the instance (ADR-033 §2.6):
+	// tracks stop at the suspension gate before their next node and arriving
+	// triggers are buffered in held instead of being applied.
+	suspended bool
+	// held are the triggers that arrived while suspended, in arrival order;
+	// Resume applies them.
+	held []trackEvent
 }
 
 // newLoopState builds the loop's empty registry state over its instance.
@@ -227,10 +243,21 @@ func (inst *Instance) loop(ctx context.Context, initial []*track) {
 		return
 	}
 
+	// A suspended instance rebuilt from its checkpoint comes back suspended:
+	// the gate goes up before its tracks start, so none of them moves.
+	if inst.suspendAtStart {
+		ls.suspended = true
+		inst.closeGate()
+	}
+
 	if !inst.spawnInitial(ctx, ls, initial) {
 		return
 	}
 
+	// the triggers the checkpoint records as held are held again — after
+	// the spawns, so each finds its track parked when Resume applies it.
+	ls.adoptRestoredHeld(ctx)
+
 	// arm the process's top-level Event Sub-Process handlers at the instance
 	// root scope — they guard the whole instance's window (SRD-052 FR-5).
 	ls.armScopeHandlers(ctx, rootNodes(inst), inst.sc.root)
@@ -254,7 +281,7 @@ func (inst *Instance) loop(ctx context.Context, initial []*track) {
 	ls.maybeDehydrate(ctx)
 
 	done := ctx.Done()
-	for ls.active > 0 || ls.pendingRetries() > 0 {
+	for !ls.handingOff && (ls.active > 0 || ls.pendingRetries() > 0) {
 		done = ls.drainCancel(done)
 
 		select {
@@ -268,6 +295,13 @@ func (inst *Instance) loop(ctx context.Context, initial []*track) {
 			ls.applyDueIncidentRetries(ctx)
 			ls.maybeCheckpoint(ctx, evIncident)
 
+		case <-ls.relayC:
+			// the outbox still holds a message the broker refused: save and
+			// relay again, with no event needed to drive it.
+			ls.relayC = nil
+			ls.checkpointNow(ctx)
+			ls.maybeDehydrate(ctx)
+
 		case ev := <-inst.events:
 			// Lock-free attrs only (ID is immutable): this runs per event, and the
 			// observability.Logger has no Enabled() gate, so the args are built even
@@ -278,6 +312,18 @@ func (inst *Instance) loop(ctx context.Context, initial []*track) {
 				"kind", ev.kind.String(),
 				observability.AttrTrackID, eventTrackID(ev))
 
+			// a suspended instance buffers its triggers until Resume; a held
+			// delivery is a persist point, so a restart or a handoff keeps it.
+			if ls.holdTrigger(ev) {
+				if ev.kind == evDeliver {
+					ls.checkpointNow(ctx)
+				}
+
+				ls.maybeDehydrate(ctx)
+
+				continue
+			}
+
 			ls.apply(ctx, ev)
 			ls.maybeCheckpoint(ctx, ev.kind)
 			ls.maybeDehydrate(ctx)
@@ -306,6 +352,28 @@ func (inst *Instance) loop(ctx context.Context, initial []*track) {
 			// table, the spawns and the checkpoint stay single-writer.
 			ls.handleIncidentRequest(ctx, req)
 
+		case req := <-inst.suspendReq:
+			// An operator Suspend/Resume (ADR-033 §2.6), serviced on the loop
+			// goroutine like an incident operation.
+			ls.handleSuspendRequest(ctx, req)
+
+		case req := <-inst.ownershipReq:
+			// The engine's lease upkeep — a heartbeat or a handoff to the
+			// group — serviced on the loop goroutine, the checkpoint's
+			// single writer.
+			ls.handleOwnershipRequest(ctx, req)
+
+		case req := <-inst.varReq:
+			// An operator's live variable change, serviced on the loop
+			// goroutine so the commit and the conditional sweep it drives stay
+			// single-writer.
+			ls.handleVarRequest(ctx, req)
+
+		case req := <-inst.modReq:
+			// An operator's instance modification, serviced on the loop
+			// goroutine so its cancels and starts apply as one step.
+			ls.handleModRequest(ctx, req)
+
 		case req := <-inst.scopeReq:
 			// A looped composite's off-loop iteration decorator asking to open the
 			// child scope for a pass; serviced on the loop goroutine so OpenScope /
@@ -327,17 +395,28 @@ func (ls *loopState) exitLoop(ctx context.Context) {
 	// Dehydration exit (SRD-071 FR-2): the loop released every goroutine while
 	// idle — the instance is not finishing, it is parking. Set Dehydrated —
 	// which emits the KindInstanceState/Dehydrated fact at Info (FR-10, the
-	// wake counterpart Hydrated rides the engine's wake) — and take the
-	// consistent-cut checkpoint (its tracks are now TrackDehydrated, the
-	// hydration source); do NOT disarm handlers, discard ledgers, or settle a
-	// terminal state — a trigger hydrates it back.
+	// wake counterpart Hydrated rides the engine's wake) — or keep Suspended,
+	// and take the consistent-cut checkpoint (its tracks are now
+	// TrackDehydrated, the hydration source); do NOT disarm handlers, discard
+	// ledgers, or settle a terminal state — a trigger or a Resume hydrates it
+	// back.
 	if ls.dehydrating {
-		inst.setStateDetailed(Dehydrated, ls.dehydrationDetails())
+		ls.releaseState()
 		ls.checkpointNow(ctx)
 
 		return
 	}
 
+	// Handoff exit: the instance is released to the engine group, not
+	// finished. Its tracks were stopped without their ends being applied —
+	// a parked task stays announced, a called child keeps running — and
+	// nothing is written: the record keeps the last transition's cut.
+	if ls.handingOff {
+		inst.state.Store(uint32(Dehydrated))
+
+		return
+	}
+
 	if ls.parkOnIncidents(ctx) {
 		return
 	}
@@ -430,6 +509,27 @@ func (ls *loopState) applyPendingOps(ctx context.Context) {
 		ls.handleIncidentRequest(ctx, *req)
 	}
 
+	// A Suspend/Resume: applied before the park decision, so a Resume's
+	// instance runs on and a Suspend's re-parks as Suspended.
+	if req := inst.pendingSuspension; req != nil {
+		inst.pendingSuspension = nil
+		ls.handleSuspendRequest(ctx, *req)
+	}
+
+	// A variable change: committed before the park decision, so the
+	// checkpoint a re-park takes carries it.
+	if req := inst.pendingVariables; req != nil {
+		inst.pendingVariables = nil
+		ls.handleVarRequest(ctx, *req)
+	}
+
+	// A modification: its starts keep the loop alive, its cancels may leave
+	// nothing to run — either way it is applied first.
+	if req := inst.pendingModification; req != nil {
+		inst.pendingModification = nil
+		ls.handleModRequest(ctx, *req)
+	}
+
 	// A cancel. stopAll — not inst.Cancel — is what makes it stick: it sets
 	// ls.stopping, which maybeDehydrate checks, so the instance cannot park
 	// again before observing the request. Canceling the context alone would
@@ -743,6 +843,12 @@ func (ls *loopState) dispatchToParked(ctx context.Context, ev trackEvent) {
 		return // correlation mismatch — drop, keep the track parked
 	}
 
+	// a redelivered message was consumed already — drop, keep the track
+	// parked for the next one.
+	if !ls.acceptMessage(ev.eDef) {
+		return
+	}
+
 	ls.flipNotParked(tr)
 	tr.evtCh <- ev.eDef
 }
@@ -1213,6 +1319,18 @@ func (ls *loopState) maybeDehydrate(ctx context.Context) {
 		return
 	}
 
+	// an unrelayed outbox message waits for the relay retry (relayC), which
+	// needs the loop.
+	if inst.outboxPending.Load() > 0 {
+		return
+	}
+
+	// a held boundary or handler fire can't be re-derived from a checkpoint,
+	// so a suspended instance holding one stays resident until Resume.
+	if ls.suspended && !ls.heldReleasable() {
+		return
+	}
+
 	parked := ls.dehydratableParked(ctx)
 	if parked == nil {
 		return // not fully idle, or nothing to release
@@ -1220,6 +1338,12 @@ func (ls *loopState) maybeDehydrate(ctx context.Context) {
 
 	ls.dehydrating = true
 	for _, t := range parked {
+		// A suspended instance lets go of its holds as well: nothing may wake
+		// it before Resume, which re-arms every wait from the checkpoint.
+		if ls.suspended {
+			t.releaseHolds()
+		}
+
 		ls.dehydrateTrack(t)
 	}
 }
@@ -6,13 +6,15 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/dr-dobermann/gobpm/internal/instance/snapshot"
 	"github.com/dr-dobermann/gobpm/pkg/interactor"
 	"github.com/dr-dobermann/gobpm/pkg/model/events"
 	"github.com/dr-dobermann/gobpm/pkg/model/foundation"
 )
 
 // newBareLoopInstance builds the minimal Instance a direct loop-method call
-// needs: the loop channels and an empty tracks registry, no engine wiring.
+// needs: the loop channels, an empty tracks registry and an empty snapshot, no
+// engine wiring.
 func newBareLoopInstance() *Instance {
 	return &Instance{
 		events:   make(chan trackEvent, 1),
@@ -20,6 +22,7 @@ func newBareLoopInstance() *Instance {
 		jobReq:   make(chan jobRequest),
 		tracks:   map[string]*track{},
 		loopDone: make(chan struct{}),
+		s:        &snapshot.Snapshot{},
 	}
 }
 
@@ -0,0 +1,87 @@
+package instance
+
+import (
+	"github.com/dr-dobermann/gobpm/pkg/model/events"
+	"github.com/dr-dobermann/gobpm/pkg/model/flow"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+)
+
+// maxAcceptedMessages bounds the message ids an instance remembers. A
+// broker redelivers within its own retry horizon, not across the whole life
+// of a long-running conversation, so the most recent ids are the ones a
+// duplicate can name.
+const maxAcceptedMessages = 256
+
+// acceptedMessages is the instance's memory of the broker MessageIDs it
+// consumed — its born-from message, every delivery a receiver, boundary or
+// event sub-process took — so a redelivery is processed at most once per
+// conversation. It rides the checkpoint, so the memory survives a restart
+// and a dehydration. Loop-owned once the loop runs; New and Restore seed it
+// before.
+type acceptedMessages struct {
+	ids   map[string]struct{}
+	order []string
+}
+
+// has reports whether id was accepted; an empty id — an anonymous
+// message — never was.
+func (am *acceptedMessages) has(id string) bool {
+	_, ok := am.ids[id]
+
+	return id != "" && ok
+}
+
+// add remembers id, forgetting the oldest past maxAcceptedMessages. An
+// empty or known id is a no-op.
+func (am *acceptedMessages) add(id string) {
+	if id == "" || am.has(id) {
+		return
+	}
+
+	if am.ids == nil {
+		am.ids = make(map[string]struct{})
+	}
+
+	am.ids[id] = struct{}{}
+	am.order = append(am.order, id)
+
+	if len(am.order) > maxAcceptedMessages {
+		delete(am.ids, am.order[0])
+		am.order = am.order[1:]
+	}
+}
+
+// list returns the remembered ids, oldest first, for the checkpoint.
+func (am *acceptedMessages) list() []string {
+	return append([]string(nil), am.order...)
+}
+
+// acceptMessage admits a fired message for consumption: a MessageID the
+// instance already consumed is a redelivery, dropped with an EventFlow
+// Dropped fact; anything else is remembered and admitted. A definition
+// without an id is always admitted. Runs on the loop goroutine, at the point
+// the delivery is about to take effect.
+func (ls *loopState) acceptMessage(eDef flow.EventDefinition) bool {
+	id := events.MessageIDOf(eDef)
+	if id == "" {
+		return true
+	}
+
+	if ls.inst.accepted.has(id) {
+		ls.inst.report(observability.Fact{
+			Kind:  observability.KindEventFlow,
+			Phase: observability.PhaseDropped,
+			Details: map[string]string{
+				observability.AttrEventDefinitionID: eDef.ID(),
+				observability.AttrMessageID:         id,
+				"reason":                            "duplicate",
+			},
+		})
+
+		return false
+	}
+
+	ls.inst.accepted.add(id)
+
+	return true
+}
@@ -0,0 +1,431 @@
+package instance
+
+import (
+	"reflect"
+	"slices"
+
+	"github.com/dr-dobermann/gobpm/internal/instance/checkpoint"
+	"github.com/dr-dobermann/gobpm/internal/instance/snapshot"
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/model/events"
+	"github.com/dr-dobermann/gobpm/pkg/model/flow"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+)
+
+// TokenMove is one recorded token a migration moves — a live track, or the
+// failed track of an open incident: the node it waits at in the source
+// version and the node it waits at in the target one. WithdrawnTask is the
+// human task the move retires — a parked UserTask token that moves to
+// another node is announced there under a new id.
+type TokenMove struct {
+	TrackID       string
+	From          string
+	To            string
+	WithdrawnTask string
+}
+
+// MigrateDocument rewrites the checkpoint doc of an instance of version from
+// to the same instance of version to: the version pin, every live track's
+// node, the open incidents' nodes and the compensation ledger's activities.
+// nodes maps a source node id to its target; a node it doesn't name keeps its
+// id. Everything a re-entered node rebuilds — its subscriptions, its timer,
+// its task, its boundaries — is left to the restore of the rewritten
+// document: a moved track drops its recorded wait, and an armed boundary
+// keeps its recorded deadline only while its host stays put.
+//
+// The document is rewritten only when every record migrates; a refused one
+// fails the call with doc untouched. Refused are the constructs whose
+// position isn't a plain node: an in-flight call activity, multi-instance or
+// loop iteration, compensation sweep or pending modification start; a token
+// moved to a node of another kind, into other sub-process scopes, or away
+// from a join it waits at; and a sub-process with an open scope mapped to
+// another node.
+//
+// Besides the moves it returns the held triggers the rewrite drops — the
+// ones held for a moved token or a node the target version lacks — so the
+// caller can account for them.
+func MigrateDocument(
+	doc *checkpoint.Document,
+	from, to *snapshot.Snapshot,
+	nodes map[string]string,
+) ([]TokenMove, []checkpoint.HeldRecord, error) {
+	if doc == nil || from == nil || to == nil {
+		return nil, nil, errs.New(
+			errs.M("MigrateDocument: a document and both versions are required"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	if doc.Version != from.Version {
+		return nil, nil, errs.New(
+			errs.M("MigrateDocument: instance %q runs version %d, not %d",
+				doc.InstanceID, doc.Version, from.Version),
+			errs.C(errorClass, errs.InvalidParameter),
+			errs.D(observability.AttrInstanceID, doc.InstanceID))
+	}
+
+	m := migration{doc: doc, from: from, to: to, nodes: nodes}
+
+	if err := m.checkConstructs(); err != nil {
+		return nil, nil, err
+	}
+
+	tracks, moves, err := m.tracks()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	incidents, incMoves, err := m.incidents()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ledgers, err := m.ledgers()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	moves = append(moves, incMoves...)
+
+	held, dropped := m.held(moves)
+
+	doc.Tracks = tracks
+	doc.Held = held
+	doc.Incidents = incidents
+	doc.Ledgers = ledgers
+	doc.Boundaries = m.boundaries(tracks)
+	doc.Version = to.Version
+
+	return moves, dropped, nil
+}
+
+// migration carries one document's rewrite.
+type migration struct {
+	doc      *checkpoint.Document
+	from, to *snapshot.Snapshot
+	nodes    map[string]string
+}
+
+// target returns the target-version id of the source node id.
+func (m *migration) target(id string) string {
+	if to, ok := m.nodes[id]; ok {
+		return to
+	}
+
+	return id
+}
+
+// refuse builds one migration refusal for the document's instance.
+func (m *migration) refuse(class, format string, args ...any) error {
+	return errs.New(
+		errs.M("can't migrate instance %q: "+format,
+			append([]any{m.doc.InstanceID}, args...)...),
+		errs.C(errorClass, class),
+		errs.D(observability.AttrInstanceID, m.doc.InstanceID))
+}
+
+// checkConstructs refuses the in-flight constructs whose position a node id
+// doesn't capture.
+func (m *migration) checkConstructs() error {
+	switch {
+	case len(m.doc.Calls) > 0:
+		return m.refuse(errs.InvalidState,
+			"a call activity waits for child instance %q",
+			m.doc.Calls[0].ChildID)
+
+	case len(m.doc.MIGroups) > 0:
+		return m.refuse(errs.InvalidState,
+			"a parallel multi-instance activity is in flight")
+
+	case len(m.doc.Sweeps) > 0:
+		return m.refuse(errs.InvalidState, "a compensation is in progress")
+	}
+
+	for i := range m.doc.Tracks {
+		rec := &m.doc.Tracks[i]
+
+		switch {
+		case rec.MI != nil:
+			return m.refuse(errs.InvalidState,
+				"node %q is iterating", rec.NodeID)
+
+		case len(rec.Relocate) > 0:
+			return m.refuse(errs.InvalidState,
+				"track %q still enters an operator's start", rec.ID)
+		}
+	}
+
+	return nil
+}
+
+// tracks returns the rewritten track table and the moves it makes.
+func (m *migration) tracks() ([]checkpoint.TrackRecord, []TokenMove, error) {
+	tracks := make([]checkpoint.TrackRecord, 0, len(m.doc.Tracks))
+
+	var moves []TokenMove
+
+	for _, rec := range m.doc.Tracks {
+		toID := m.target(rec.NodeID)
+
+		err := m.checkMove(rec.NodeID, toID,
+			rec.State == TrackAwaitingMerge.String())
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if toID != rec.NodeID {
+			moves = append(moves, TokenMove{
+				TrackID:       rec.ID,
+				From:          rec.NodeID,
+				To:            toID,
+				WithdrawnTask: rec.TaskID,
+			})
+
+			// the recorded wait belongs to the old node: the new one arms,
+			// announces and resolves its own when it's re-entered.
+			rec.NodeID = toID
+			rec.TaskID = ""
+			rec.Eligible = nil
+			rec.Timer = nil
+			rec.MsgDefIDs = nil
+		}
+
+		tracks = append(tracks, rec)
+	}
+
+	return tracks, moves, nil
+}
+
+// held splits the held triggers into those that still reach their waits and
+// those dropped. One held for a moved token — a signal for its track, a
+// message for a definition of the node it left — is dropped, as the token
+// arms its wait anew at its new node, and so is a message whose node the
+// target version lacks.
+func (m *migration) held(
+	moves []TokenMove,
+) (kept, dropped []checkpoint.HeldRecord) {
+	tracks := make(map[string]struct{}, len(moves))
+	nodes := make(map[string]struct{}, len(moves))
+
+	for _, mv := range moves {
+		tracks[mv.TrackID] = struct{}{}
+		nodes[mv.From] = struct{}{}
+	}
+
+	for _, rec := range m.doc.Held {
+		if m.reaches(rec, tracks, nodes) {
+			kept = append(kept, rec)
+		} else {
+			dropped = append(dropped, rec)
+		}
+	}
+
+	return kept, dropped
+}
+
+// reaches tells whether the held trigger rec still reaches its wait: its
+// track didn't move, and its node, if any, neither moved nor vanished.
+func (m *migration) reaches(
+	rec checkpoint.HeldRecord, tracks, nodes map[string]struct{},
+) bool {
+	if _, moved := tracks[rec.TrackID]; moved && rec.TrackID != "" {
+		return false
+	}
+
+	if rec.NodeID == "" {
+		return true
+	}
+
+	if _, moved := nodes[rec.NodeID]; moved {
+		return false
+	}
+
+	_, ok := m.to.NodeByID(rec.NodeID)
+
+	return ok
+}
+
+// checkMove refuses moving a token from the source node fromID to the
+// target node toID; joined tells a token waiting at a join.
+func (m *migration) checkMove(fromID, toID string, joined bool) error {
+	src, ok := m.from.NodeByID(fromID)
+	if !ok {
+		return m.refuse(errs.ObjectNotFound,
+			"its node %q isn't in version %d", fromID, m.from.Version)
+	}
+
+	dst, ok := m.to.NodeByID(toID)
+	if !ok {
+		return m.refuse(errs.ObjectNotFound,
+			"node %q has no counterpart in version %d: map it",
+			fromID, m.to.Version)
+	}
+
+	if reflect.TypeOf(src) != reflect.TypeOf(dst) {
+		return m.refuse(errs.InvalidParameter,
+			"a token at %q can't move to %q, a node of another kind",
+			fromID, toID)
+	}
+
+	if !sameScopes(m.from, m.to, fromID, toID) {
+		return m.refuse(errs.InvalidParameter,
+			"a token at %q can't move to %q in other sub-process scopes",
+			fromID, toID)
+	}
+
+	if toID == fromID {
+		return nil
+	}
+
+	if joined {
+		return m.refuse(errs.InvalidParameter,
+			"a token waits at the join %q", fromID)
+	}
+
+	if _, host := src.(scopeHost); host {
+		return m.refuse(errs.InvalidParameter,
+			"sub-process %q has an open scope and can't be mapped to %q",
+			fromID, toID)
+	}
+
+	return nil
+}
+
+// sameScopes reports whether the source node fromID of from and the target
+// node toID of to sit in the same chain of composites — the scopes a track's
+// recorded path names.
+func sameScopes(from, to *snapshot.Snapshot, fromID, toID string) bool {
+	ids := func(s *snapshot.Snapshot, id string) []string {
+		chain := nodeChain(snapshotRoots(s), id)
+		if len(chain) == 0 {
+			return nil
+		}
+
+		hosts := make([]string, 0, len(chain)-1)
+		for _, n := range chain[:len(chain)-1] {
+			hosts = append(hosts, n.ID())
+		}
+
+		return hosts
+	}
+
+	return slices.Equal(ids(from, fromID), ids(to, toID))
+}
+
+// snapshotRoots returns the top-level nodes of the snapshot s.
+func snapshotRoots(s *snapshot.Snapshot) []flow.Node {
+	nodes := make([]flow.Node, 0, len(s.Nodes))
+	for _, n := range s.Nodes {
+		nodes = append(nodes, n)
+	}
+
+	return nodes
+}
+
+// ledgers returns the compensation ledger with its activities and handlers
+// re-pinned to the target version.
+func (m *migration) ledgers() ([]checkpoint.LedgerRecord, error) {
+	if len(m.doc.Ledgers) == 0 {
+		return m.doc.Ledgers, nil
+	}
+
+	ledgers := make([]checkpoint.LedgerRecord, 0, len(m.doc.Ledgers))
+
+	for _, rec := range m.doc.Ledgers {
+		act, ok := m.to.NodeByID(m.target(rec.ActivityID))
+		if !ok {
+			return nil, m.refuse(errs.ObjectNotFound,
+				"the completed activity %q has no counterpart in "+
+					"version %d to compensate", rec.ActivityID, m.to.Version)
+		}
+
+		rec.ActivityID, rec.ActivityName = act.ID(), act.Name()
+
+		if rec.HandlerID != "" {
+			h, ok := m.to.NodeByID(m.target(rec.HandlerID))
+			if !ok {
+				return nil, m.refuse(errs.ObjectNotFound,
+					"the compensation handler %q has no counterpart in "+
+						"version %d", rec.HandlerID, m.to.Version)
+			}
+
+			rec.HandlerID, rec.HandlerName = h.ID(), h.Name()
+		}
+
+		ledgers = append(ledgers, rec)
+	}
+
+	return ledgers, nil
+}
+
+// boundaries returns the armed-boundary set of the migrated tracks: a
+// boundary keeps its recorded deadline while the target version still
+// attaches it to its host's node; the others are dropped and re-arm fresh, as
+// over a document that recorded none.
+func (m *migration) boundaries(
+	tracks []checkpoint.TrackRecord,
+) []checkpoint.BoundaryRecord {
+	hosts := make(map[string]string, len(tracks))
+	for i := range tracks {
+		hosts[tracks[i].ID] = tracks[i].NodeID
+	}
+
+	var bb []checkpoint.BoundaryRecord
+
+	for _, rec := range m.doc.Boundaries {
+		rec.BoundaryID = m.target(rec.BoundaryID)
+
+		n, ok := m.to.NodeByID(rec.BoundaryID)
+		if !ok {
+			continue
+		}
+
+		be, ok := n.(*events.BoundaryEvent)
+		if !ok || be.AttachedTo() == nil ||
+			be.AttachedTo().ID() != hosts[rec.HostTrack] {
+			continue
+		}
+
+		bb = append(bb, rec)
+	}
+
+	return bb
+}
+
+// incidents returns the incident table with every open incident moved to its
+// node's target — its failed track re-enters there — and the moves. A closed
+// one is history and keeps the node it failed at.
+func (m *migration) incidents() (
+	[]checkpoint.IncidentRecord, []TokenMove, error,
+) {
+	ii := append([]checkpoint.IncidentRecord(nil), m.doc.Incidents...)
+
+	var moves []TokenMove
+
+	for i := range ii {
+		st, ok := incidentStateFromName[ii[i].State]
+		if !ok || !st.open() {
+			continue
+		}
+
+		toID := m.target(ii[i].NodeID)
+
+		if err := m.checkMove(ii[i].NodeID, toID, false); err != nil {
+			return nil, nil, err
+		}
+
+		if toID == ii[i].NodeID {
+			continue
+		}
+
+		moves = append(moves, TokenMove{
+			TrackID: ii[i].TrackID,
+			From:    ii[i].NodeID,
+			To:      toID,
+		})
+
+		n, _ := m.to.NodeByID(toID)
+		ii[i].NodeID, ii[i].NodeName = toID, n.Name()
+	}
+
+	return ii, moves, nil
+}
@@ -0,0 +1,209 @@
+package instance
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gobpm/internal/instance/checkpoint"
+	"github.com/dr-dobermann/gobpm/internal/instance/snapshot"
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/model/activities"
+	"github.com/dr-dobermann/gobpm/pkg/model/data"
+	"github.com/dr-dobermann/gobpm/pkg/model/events"
+	"github.com/dr-dobermann/gobpm/pkg/model/flow"
+	"github.com/dr-dobermann/gobpm/pkg/model/foundation"
+	"github.com/dr-dobermann/gobpm/pkg/model/process"
+)
+
+// migVersion builds version v of start → <wait> → sub[s-start → <inner> →
+// s-end] → end, both named nodes UserTasks with the ids given.
+func migVersion(t *testing.T, v int, wait, inner string) *snapshot.Snapshot {
+	t.Helper()
+
+	require.NoError(t, data.CreateDefaultStates())
+
+	userTask := func(id string) *activities.UserTask {
+		ut, err := activities.NewUserTask(id,
+			activities.WithCandidateUsers("operator"),
+			activities.WithOutput("result", "string", true),
+			activities.WithoutParams(), foundation.WithID(id))
+		require.NoError(t, err)
+
+		return ut
+	}
+
+	sub, err := activities.NewSubProcess("sub", foundation.WithID("sub"))
+	require.NoError(t, err)
+
+	sStart, err := events.NewStartEvent("s-start", foundation.WithID("s-start"))
+	require.NoError(t, err)
+
+	in := userTask(inner)
+
+	sEnd, err := events.NewEndEvent("s-end", foundation.WithID("s-end"))
+	require.NoError(t, err)
+
+	for _, e := range []flow.Element{sStart, in, sEnd} {
+		require.NoError(t, sub.Add(e))
+	}
+
+	link(t, sStart, in)
+	link(t, in, sEnd)
+
+	p, err := process.New("mig", foundation.WithID("mig"))
+	require.NoError(t, err)
+
+	start, err := events.NewStartEvent("start", foundation.WithID("start"))
+	require.NoError(t, err)
+
+	w := userTask(wait)
+
+	end, err := events.NewEndEvent("end", foundation.WithID("end"))
+	require.NoError(t, err)
+
+	for _, e := range []flow.Element{start, w, sub, end} {
+		require.NoError(t, p.Add(e))
+	}
+
+	link(t, start, w)
+	link(t, w, sub)
+	link(t, sub, end)
+
+	s, err := snapshot.New(p)
+	require.NoError(t, err)
+
+	s.Version = v
+
+	return s
+}
+
+// migDoc is a version-1 document with one track parked at node.
+func migDoc(node string) *checkpoint.Document {
+	return &checkpoint.Document{
+		InstanceID: "inst-1",
+		ProcessID:  "mig",
+		Version:    1,
+		Tracks: []checkpoint.TrackRecord{{
+			ID:     "tr-1",
+			State:  TrackWaitForEvent.String(),
+			NodeID: node,
+			TaskID: "task-1",
+		}},
+		Boundaries: []checkpoint.BoundaryRecord{{
+			HostTrack:  "tr-1",
+			BoundaryID: "gone",
+		}},
+	}
+}
+
+// TestMigrateDocument verifies the checkpoint rewrite: a mapped token moves
+// with its recorded wait dropped, an unmapped one stays, and the refused
+// shapes leave the document untouched.
+func TestMigrateDocument(t *testing.T) {
+	v1 := migVersion(t, 1, "wait", "inner")
+	v2 := migVersion(t, 2, "wait2", "inner2")
+
+	t.Run("moves a mapped token", func(t *testing.T) {
+		doc := migDoc("wait")
+
+		moves, dropped, err := MigrateDocument(doc, v1, v2,
+			map[string]string{"wait": "wait2"})
+		require.NoError(t, err)
+		require.Empty(t, dropped)
+		require.Equal(t, []TokenMove{{
+			TrackID: "tr-1", From: "wait", To: "wait2",
+			WithdrawnTask: "task-1",
+		}}, moves)
+
+		require.Equal(t, 2, doc.Version)
+		require.Equal(t, "wait2", doc.Tracks[0].NodeID)
+		require.Empty(t, doc.Tracks[0].TaskID)
+		require.Empty(t, doc.Boundaries, "a dropped boundary re-arms fresh")
+	})
+
+	t.Run("moves a token inside a sub-process", func(t *testing.T) {
+		doc := migDoc("inner")
+
+		moves, _, err := MigrateDocument(doc, v1, v2,
+			map[string]string{"inner": "inner2", "wait": "wait2"})
+		require.NoError(t, err)
+		require.Len(t, moves, 1)
+		require.Equal(t, "inner2", doc.Tracks[0].NodeID)
+	})
+
+	t.Run("drops the triggers held for a moved token", func(t *testing.T) {
+		doc := migDoc("wait")
+		doc.Held = []checkpoint.HeldRecord{
+			{TrackID: "tr-1", Signal: "go"},
+			{NodeID: "wait", MessageID: "m-1"},
+			{NodeID: "gone", MessageID: "m-2"},
+		}
+
+		_, dropped, err := MigrateDocument(doc, v1, v2,
+			map[string]string{"wait": "wait2"})
+		require.NoError(t, err)
+		require.Len(t, dropped, 3)
+		require.Empty(t, doc.Held)
+
+		doc = migDoc("wait")
+		doc.Held = []checkpoint.HeldRecord{{TrackID: "tr-9", Signal: "go"}}
+
+		_, dropped, err = MigrateDocument(doc, v1, v2,
+			map[string]string{"wait": "wait2"})
+		require.NoError(t, err)
+		require.Empty(t, dropped, "a trigger of an unmoved track is kept")
+		require.Len(t, doc.Held, 1)
+	})
+
+	refusals := []struct {
+		name  string
+		doc   func() *checkpoint.Document
+		nodes map[string]string
+		class string
+	}{
+		{"a node the target lacks", func() *checkpoint.Document {
+			return migDoc("wait")
+		}, nil, errs.ObjectNotFound},
+		{"a node of another kind", func() *checkpoint.Document {
+			return migDoc("wait")
+		}, map[string]string{"wait": "sub"}, errs.InvalidParameter},
+		{"other sub-process scopes", func() *checkpoint.Document {
+			return migDoc("inner")
+		}, map[string]string{"inner": "wait2"}, errs.InvalidParameter},
+		{"a token at a join", func() *checkpoint.Document {
+			d := migDoc("wait")
+			d.Tracks[0].State = TrackAwaitingMerge.String()
+
+			return d
+		}, map[string]string{"wait": "wait2"}, errs.InvalidParameter},
+		{"an in-flight call", func() *checkpoint.Document {
+			d := migDoc("wait")
+			d.Calls = []checkpoint.CallRecord{{ChildID: "child"}}
+
+			return d
+		}, map[string]string{"wait": "wait2"}, errs.InvalidState},
+		{"an iterating node", func() *checkpoint.Document {
+			d := migDoc("wait")
+			d.Tracks[0].MI = &checkpoint.MIRecord{}
+
+			return d
+		}, map[string]string{"wait": "wait2"}, errs.InvalidState},
+	}
+
+	for _, tc := range refusals {
+		t.Run(tc.name, func(t *testing.T) {
+			doc := tc.doc()
+			node := doc.Tracks[0].NodeID
+
+			_, _, err := MigrateDocument(doc, v1, v2, tc.nodes)
+
+			var ae *errs.ApplicationError
+			require.ErrorAs(t, err, &ae)
+			require.True(t, ae.HasClass(tc.class), err.Error())
+
+			require.Equal(t, 1, doc.Version, "a refusal leaves doc untouched")
+			require.Equal(t, node, doc.Tracks[0].NodeID)
+		})
+	}
+}
@@ -0,0 +1,633 @@
+package instance
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/dr-dobermann/gobpm/internal/instance/checkpoint"
+	"github.com/dr-dobermann/gobpm/internal/scope"
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/exec"
+	"github.com/dr-dobermann/gobpm/pkg/model/flow"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+)
+
+// ModificationOp names the instruction of one Modification.
+type ModificationOp uint8
+
+const (
+	// ModCancel cancels every token at the node.
+	ModCancel ModificationOp = iota + 1
+	// ModStartBefore starts a token that enters the node.
+	ModStartBefore
+	// ModStartAfter starts a token on the node's single outgoing flow.
+	ModStartAfter
+)
+
+// String returns the instruction's name as the modification facts carry it.
+func (op ModificationOp) String() string {
+	switch op {
+	case ModCancel:
+		return "cancel"
+
+	case ModStartBefore:
+		return "start_before"
+
+	case ModStartAfter:
+		return "start_after"
+
+	default:
+		return "invalid"
+	}
+}
+
+// Modification is one instruction of an operator's instance modification:
+// what to do at which node. The node may be nested in an embedded
+// sub-process.
+type Modification struct {
+	NodeID string
+	Op     ModificationOp
+}
+
+// modRequest is an operator's instance modification crossing into the loop.
+type modRequest struct {
+	resp chan error
+	mods []Modification
+}
+
+// errReleasing is the loop's answer to a modification that arrives while it
+// releases its goroutines: SubmitModification turns it into a non-delivery,
+// so the engine applies the modification to the rebuilt instance instead.
+var errReleasing = errs.New(
+	errs.M("the instance is releasing its goroutines"),
+	errs.C(errorClass, errs.InvalidState))
+
+// SubmitModification submits an operator's instance modification to the
+// instance's loop and waits for its verdict. The boolean reports DELIVERY,
+// exactly as SubmitSuspension does: false means the loop has exited — or is
+// exiting to release the instance — and the engine must rebuild the instance
+// to apply the modification.
+func (inst *Instance) SubmitModification(
+	ctx context.Context, mods []Modification,
+) (bool, error) {
+	req := modRequest{mods: mods, resp: make(chan error, 1)}
+
+	select {
+	case inst.modReq <- req:
+
+	case <-inst.loopDone:
+		return false, nil
+
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+
+	select {
+	case err := <-req.resp:
+		if err != errReleasing {
+			return true, err
+		}
+
+	case <-ctx.Done():
+		return true, ctx.Err()
+	}
+
+	select {
+	case <-inst.loopDone:
+		return false, nil
+
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
+
+// WithPendingModification hands a rebuild the operator's modification that
+// caused it — the WithPendingSuspension shape: the fresh loop applies it
+// BEFORE deciding whether to release again. The verdict lands on resp.
+func WithPendingModification(mods []Modification, resp chan error) Option {
+	return func(cfg *newConfig) {
+		cfg.pendingModification = &modRequest{mods: mods, resp: resp}
+	}
+}
+
+// handleModRequest applies one operator modification on the loop goroutine,
+// so its cancels and starts land as a single step no track event can
+// interleave with. A modification of a released-and-rebuilt instance is
+// followed by the usual park decision.
+func (ls *loopState) handleModRequest(ctx context.Context, req modRequest) {
+	var err error
+
+	if ls.dehydrating {
+		err = errReleasing
+	} else {
+		err = ls.modify(ctx, req.mods)
+	}
+
+	if req.resp != nil {
+		req.resp <- err
+	}
+
+	ls.maybeDehydrate(ctx)
+}
+
+// modFrameID names the track of the frame a start instruction's inputs are
+// resolved through before the modification applies.
+const modFrameID = "modification"
+
+// modStart is a validated start instruction: the composites to enter,
+// outermost first, ending with the node the token starts at, and the flow a
+// start-after token arrives on. at is the deepest scope open once the
+// modification's cancels land, rest the part of the chain still to enter from
+// it, and track the token built to start there.
+type modStart struct {
+	node   flow.Node
+	inFlow *flow.SequenceFlow
+	track  *track
+	at     scope.DataPath
+	chain  []flow.Node
+	rest   []flow.Node
+	mod    Modification
+}
+
+// modCancel is a validated cancel instruction: the tracks whose tokens are
+// at the node.
+type modCancel struct {
+	node   flow.Node
+	tracks []string
+}
+
+// modify validates every instruction against the snapshot graph and the
+// live tokens, and only then applies them all: the cancels first, so a
+// canceled composite's scope is gone before a start looks for it, then the
+// starts, each in the deepest open scope on its way to the node. Every start
+// is built and checked before any cancel lands, so a start that can't run
+// refuses the whole modification instead of failing the instance. The scopes
+// the starts enter are pinned across the cancels, so canceling their last
+// token can't complete them under the starts. Every instruction is reported
+// as a KindModification fact, and the result is persisted at once.
+func (ls *loopState) modify(ctx context.Context, mods []Modification) error {
+	if ls.stopping {
+		return errs.New(
+			errs.M("can't modify instance %q: it is terminating",
+				ls.inst.ID()),
+			errs.C(errorClass, errs.InvalidState),
+			errs.D(observability.AttrInstanceID, ls.inst.ID()))
+	}
+
+	cancels, starts, err := ls.planModification(ctx, mods)
+	if err != nil {
+		return err
+	}
+
+	pinned := ls.pinStartScopes(starts)
+
+	for _, c := range cancels {
+		ls.cancelTokensAt(ctx, c)
+	}
+
+	for _, s := range starts {
+		ls.startToken(ctx, s)
+	}
+
+	for _, p := range pinned {
+		ls.decScopePinned(ctx, p)
+	}
+
+	ls.checkpointNow(ctx)
+
+	return nil
+}
+
+// planModification validates the instructions in order, failing on the
+// first refused one with nothing applied. Once the cancels are known, each
+// start is placed in the scope that stays open past them and its token is
+// built, its inputs resolved where it starts at once.
+func (ls *loopState) planModification(
+	ctx context.Context, mods []Modification,
+) ([]modCancel, []modStart, error) {
+	var (
+		cancels []modCancel
+		starts  []modStart
+	)
+
+	for _, m := range mods {
+		switch m.Op {
+		case ModCancel:
+			c, err := ls.planCancel(m)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			cancels = append(cancels, c)
+
+		case ModStartBefore, ModStartAfter:
+			s, err := ls.planStart(m)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			starts = append(starts, s)
+
+		default:
+			return nil, nil, errs.New(
+				errs.M("unknown modification instruction %d", m.Op),
+				errs.C(errorClass, errs.InvalidParameter))
+		}
+	}
+
+	dying := ls.dyingScopes(cancels)
+
+	for i := range starts {
+		if err := ls.prepareStart(ctx, &starts[i], dying); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return cancels, starts, nil
+}
+
+// dyingScopes returns the composite scopes the cancels tear down with their
+// hosts.
+func (ls *loopState) dyingScopes(cancels []modCancel) map[scope.DataPath]bool {
+	dying := map[scope.DataPath]bool{}
+
+	for _, c := range cancels {
+		for _, id := range c.tracks {
+			if t, ok := ls.inst.tracks[id]; ok {
+				if child, ok := ls.hostChildScope(t); ok {
+					dying[child] = true
+				}
+			}
+		}
+	}
+
+	return dying
+}
+
+// prepareStart places a planned start in the deepest scope still open once
+// the dying scopes are gone and builds its token. A token that starts at its
+// target at once must resolve the target's inputs there; one that enters a
+// composite first resolves them when the composite seeds it.
+func (ls *loopState) prepareStart(
+	ctx context.Context, s *modStart, dying map[scope.DataPath]bool,
+) error {
+	s.at, s.rest = ls.openScopeOf(s.chain, dying)
+
+	nt, err := newTrack(s.rest[0], ls.inst, nil)
+	if err != nil {
+		return errs.New(
+			errs.M("couldn't start a token at %q", s.node.ID()),
+			errs.C(errorClass, errs.BulidingFailed),
+			errs.D(observability.AttrNodeID, s.node.ID()),
+			errs.E(err))
+	}
+
+	s.track = nt
+
+	dc, ok := s.rest[0].(exec.NodeDataConsumer)
+	if !ok || len(s.rest) > 1 {
+		return nil
+	}
+
+	f, err := ls.inst.sc.openFrameAt(modFrameID, s.rest[0].ID(), s.at)
+	if err != nil {
+		return err
+	}
+	defer f.Discard()
+
+	if err := dc.LoadData(ctx, f); err != nil {
+		return errs.New(
+			errs.M("can't start a token at %q: its inputs don't resolve",
+				s.rest[0].ID()),
+			errs.C(errorClass, errs.InvalidState),
+			errs.D(observability.AttrNodeID, s.rest[0].ID()),
+			errs.E(err))
+	}
+
+	return nil
+}
+
+// planCancel resolves a cancel instruction to the tracks at its node: the
+// live ones the position view holds and those parked on an open incident
+// there. A node holding no token, or a token waiting at a join — whose
+// arrival is already counted by the join — is refused.
+func (ls *loopState) planCancel(m Modification) (modCancel, error) {
+	node, ok := ls.inst.nodeByID(m.NodeID)
+	if !ok {
+		return modCancel{}, modNodeNotFound(m)
+	}
+
+	c := modCancel{node: node}
+	seen := map[string]bool{}
+
+	for id, at := range ls.position {
+		if at.ID() != node.ID() {
+			continue
+		}
+
+		if _, parked := ls.parked[id]; parked || ls.awaitingMerge(id) {
+			return modCancel{}, errs.New(
+				errs.M("can't cancel the token at %q: it waits at a join",
+					node.ID()),
+				errs.C(errorClass, errs.InvalidState),
+				errs.D(observability.AttrNodeID, node.ID()),
+				errs.D(observability.AttrTrackID, id))
+		}
+
+		c.tracks = append(c.tracks, id)
+		seen[id] = true
+	}
+
+	for _, inc := range ls.inst.incidents {
+		if inc.state.open() && inc.nodeID == node.ID() && !seen[inc.trackID] {
+			c.tracks = append(c.tracks, inc.trackID)
+			seen[inc.trackID] = true
+		}
+	}
+
+	if len(c.tracks) == 0 {
+		return modCancel{}, errs.New(
+			errs.M("no token is at node %q", node.ID()),
+			errs.C(errorClass, errs.ObjectNotFound),
+			errs.D(observability.AttrNodeID, node.ID()))
+	}
+
+	return c, nil
+}
+
+// awaitingMerge reports whether the track reached a synchronizing join and
+// waits there with its goroutine gone.
+func (ls *loopState) awaitingMerge(id string) bool {
+	t, ok := ls.inst.tracks[id]
+
+	return ok && t.currentState() == TrackAwaitingMerge
+}
+
+// planStart resolves a start instruction to the node its token starts at
+// and the composites on the way there. A start-after takes the node's single
+// outgoing flow; the target must be a node a token can sit on, outside any
+// construct whose scopes the engine opens on its own terms.
+func (ls *loopState) planStart(m Modification) (modStart, error) {
+	node, ok := ls.inst.nodeByID(m.NodeID)
+	if !ok {
+		return modStart{}, modNodeNotFound(m)
+	}
+
+	s := modStart{mod: m, node: node}
+	target := node
+
+	if m.Op == ModStartAfter {
+		out := node.Outgoing()
+		if len(out) != 1 {
+			return modStart{}, errs.New(
+				errs.M("can't start after %q: it has %d outgoing flows, "+
+					"not one", node.ID(), len(out)),
+				errs.C(errorClass, errs.InvalidParameter),
+				errs.D(observability.AttrNodeID, node.ID()))
+		}
+
+		s.inFlow = out[0]
+		target = out[0].Target().Node()
+	}
+
+	s.chain = nodeChain(rootNodes(ls.inst), target.ID())
+	if s.chain == nil {
+		return modStart{}, modNodeNotFound(Modification{NodeID: target.ID()})
+	}
+
+	if err := checkStartTarget(s.chain); err != nil {
+		return modStart{}, err
+	}
+
+	return s, nil
+}
+
+// checkStartTarget refuses a start chain the engine can't enter: a target
+// that is a boundary event or an Event Sub-Process (both fire from their
+// trigger, never from a token), one that can't execute, and a chain through
+// an Event Sub-Process, an Ad-Hoc Sub-Process or a looped or multi-instance
+// composite.
+func checkStartTarget(chain []flow.Node) error {
+	target := chain[len(chain)-1]
+
+	refuse := func(why string) error {
+		return errs.New(
+			errs.M("can't start a token at %q: %s", target.ID(), why),
+			errs.C(errorClass, errs.InvalidParameter),
+			errs.D(observability.AttrNodeID, target.ID()))
+	}
+
+	if _, ok := target.(flow.BoundaryEvent); ok {
+		return refuse("a boundary event fires from its activity")
+	}
+
+	if isEventSubHandler(target) {
+		return refuse("an Event Sub-Process is started by its trigger")
+	}
+
+	if _, ok := target.(exec.NodeExecutor); !ok {
+		return refuse("it isn't executable")
+	}
+
+	for _, c := range chain[:len(chain)-1] {
+		switch {
+		case isEventSubHandler(c):
+			return refuse("it is inside Event Sub-Process " + c.ID())
+
+		case adHocOf(c) != nil:
+			return refuse("it is inside Ad-Hoc Sub-Process " + c.ID())
+
+		case drivesOwnIteration(c):
+			return refuse("it is inside looped composite " + c.ID())
+		}
+	}
+
+	return nil
+}
+
+// nodeChain returns the composites enclosing the node id, outermost first,
+// followed by the node itself; nil when no node of nodes or of their
+// composites has the id.
+func nodeChain(nodes []flow.Node, id string) []flow.Node {
+	for _, n := range nodes {
+		if n.ID() == id {
+			return []flow.Node{n}
+		}
+
+		sh, ok := n.(scopeHost)
+		if !ok {
+			continue
+		}
+
+		if inner := nodeChain(sh.Nodes(), id); inner != nil {
+			return append([]flow.Node{n}, inner...)
+		}
+	}
+
+	return nil
+}
+
+// modNodeNotFound is the refusal of an instruction naming no node of the
+// instance's process version.
+func modNodeNotFound(m Modification) error {
+	return errs.New(
+		errs.M("the process has no node %q", m.NodeID),
+		errs.C(errorClass, errs.ObjectNotFound),
+		errs.D(observability.AttrNodeID, m.NodeID))
+}
+
+// openScopeOf walks a start chain from the root scope through the scopes
+// already open and not dying, returning the deepest one and the part of the
+// chain still to enter from it — the target alone when every composite is
+// open.
+func (ls *loopState) openScopeOf(
+	chain []flow.Node, dying map[scope.DataPath]bool,
+) (scope.DataPath, []flow.Node) {
+	at := ls.inst.sc.root
+
+	for i, c := range chain[:len(chain)-1] {
+		child, err := at.Append(scopeSegment(c))
+		if err != nil {
+			return at, chain[i:]
+		}
+
+		if _, open := ls.scopes[child]; !open || dying[child] {
+			return at, chain[i:]
+		}
+
+		at = child
+	}
+
+	return at, chain[len(chain)-1:]
+}
+
+// pinStartScopes counts one extra token into the deepest open scope each
+// start is about to enter — its enclosing scopes are held by its host —
+// returning the pinned paths for the release.
+func (ls *loopState) pinStartScopes(starts []modStart) []scope.DataPath {
+	var pinned []scope.DataPath
+
+	for _, s := range starts {
+		if s.at != ls.inst.sc.root {
+			ls.scopes[s.at].active++
+			pinned = append(pinned, s.at)
+		}
+	}
+
+	return pinned
+}
+
+// cancelTokensAt cancels the tracks of a cancel instruction the way an
+// interrupting boundary cancels its host: a composite's open scope dies
+// with it, and an incident parked there closes as overtaken. The track's
+// own end then withdraws whatever it waited on; until it lands, the track is
+// flipped out of the parked set, so no trigger reaches it and the idle
+// detector doesn't release it as a waiter.
+func (ls *loopState) cancelTokensAt(ctx context.Context, c modCancel) {
+	for _, id := range c.tracks {
+		if t, ok := ls.inst.tracks[id]; ok && t.cancel != nil {
+			ls.cancelHostScope(t)
+			ls.flipNotParked(t)
+			t.cancel()
+		}
+
+		ls.disarmBoundaries(id)
+		ls.closeIncidentsOvertaken(ctx, id)
+	}
+
+	ls.inst.report(observability.Fact{
+		Kind:     observability.KindModification,
+		Phase:    observability.PhaseCanceled,
+		NodeID:   c.node.ID(),
+		NodeName: c.node.Name(),
+		Details: map[string]string{
+			"instruction": ModCancel.String(),
+			"tokens":      strconv.Itoa(len(c.tracks)),
+		},
+	})
+}
+
+// startToken spawns a start instruction's prepared token in the deepest open
+// scope on its way: at the target itself when its scope is open, otherwise at
+// the first composite still closed, carrying the rest of the chain into the
+// scope it opens.
+func (ls *loopState) startToken(ctx context.Context, s modStart) {
+	nt, at := s.track, s.at
+
+	if len(s.rest) == 1 {
+		nt.steps[0].inFlow = s.inFlow
+	} else {
+		nt.relocate = s.rest[1:]
+	}
+
+	nt.scopePath = at
+
+	ls.inst.trackCount.Add(1)
+	ls.spawn(ctx, nt)
+
+	ls.inst.report(observability.Fact{
+		Kind:     observability.KindModification,
+		Phase:    observability.PhaseStarted,
+		NodeID:   s.node.ID(),
+		NodeName: s.node.Name(),
+		Details: map[string]string{
+			"instruction":               s.mod.Op.String(),
+			observability.AttrTrackID:   nt.ID(),
+			observability.AttrScopePath: string(at),
+		},
+	})
+}
+
+// seedRelocated seeds a composite's fresh scope with the next node of its
+// host's relocation chain — an operator's start inside the composite — in
+// place of the composite's entry nodes. The seed carries the rest of the
+// chain into a deeper composite. Runs on the loop goroutine.
+func (ls *loopState) seedRelocated(
+	ctx context.Context, host *track, child scope.DataPath,
+) {
+	next, rest := host.relocate[0], host.relocate[1:]
+	host.relocate = nil
+
+	nt, err := newTrack(next, ls.inst, nil)
+	if err != nil {
+		ls.inst.fail(errs.New(
+			errs.M("couldn't seed sub-process scope %q", string(child)),
+			errs.C(errorClass, errs.BulidingFailed),
+			errs.E(err)))
+		ls.stopAll()
+
+		return
+	}
+
+	nt.scopePath = child
+	if len(rest) > 0 {
+		nt.relocate = rest
+	}
+
+	ls.inst.trackCount.Add(1)
+	ls.spawn(ctx, nt)
+}
+
+// restoredRelocation resolves a track record's relocation chain against the
+// pinned process version.
+func (inst *Instance) restoredRelocation(
+	rec *checkpoint.TrackRecord,
+) ([]flow.Node, error) {
+	var chain []flow.Node
+
+	for _, id := range rec.Relocate {
+		n, ok := inst.s.NodeByID(id)
+		if !ok {
+			return nil, errs.New(
+				errs.M("Restore: the relocation node isn't in the pinned "+
+					"process version"),
+				errs.C(errorClass, errs.ObjectNotFound),
+				errs.D(observability.AttrNodeID, id),
+				errs.D(observability.AttrTrackID, rec.ID))
+		}
+
+		chain = append(chain, n)
+	}
+
+	return chain, nil
+}
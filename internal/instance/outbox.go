@@ -0,0 +1,267 @@
+package instance
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/dr-dobermann/gobpm/internal/instance/checkpoint"
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/messaging"
+	"github.com/dr-dobermann/gobpm/pkg/model/data"
+	"github.com/dr-dobermann/gobpm/pkg/model/foundation"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+)
+
+// The transactional outbox. Without it a send publishes at once and the
+// checkpoint follows at the next transition, so a crash in between restores
+// the instance from before the send and recovery sends the message again.
+// With it a send is only STAGED on its track; the track's advance past the
+// sending node moves the staged messages into its outbox in the same
+// critical section that moves its position, so every checkpoint holds
+// either "before the send" with no message or "after the send" with it.
+// The loop publishes the outbox only after that checkpoint is saved, then
+// saves again without the relayed messages. A crash between the relay and
+// the second save relays again on recovery — under the same MessageID,
+// which a deduplicating broker drops.
+
+// WithMessageOutbox turns the transactional outbox on (off, the default,
+// publishes at send time): a Send/throw Message publishes through the
+// instance's checkpoint. It needs checkpointing (WithCheckpointing) — a
+// volatile instance has no record to stage into and keeps publishing
+// directly.
+func WithMessageOutbox(on bool) Option {
+	return func(cfg *newConfig) {
+		cfg.outbox = on
+	}
+}
+
+// outboxEntry is one staged outgoing message: the envelope to publish (its
+// MessageID minted at the send) and its payload in the canonical codec —
+// encoded at the send, so an uncodable payload fails the send, not every
+// later checkpoint.
+type outboxEntry struct {
+	raw json.RawMessage
+	env messaging.Envelope
+}
+
+// record renders e for the checkpoint document.
+func (e outboxEntry) record() checkpoint.OutboxRecord {
+	return checkpoint.OutboxRecord{
+		Payload:        e.raw,
+		ID:             e.env.MessageID,
+		Name:           e.env.Name,
+		CorrelationKey: e.env.CorrelationKey,
+	}
+}
+
+// StageMessage is msgflow's outbox capability (its messageStager): with the
+// outbox on it stages env on the executing track and reports staged=true; a
+// volatile instance, an instance without the outbox or a track-less frame
+// reports false and the caller publishes directly.
+func (e *execEnv) StageMessage(
+	ctx context.Context, env messaging.Envelope, v data.Value,
+) (bool, error) {
+	if !e.outbox || e.cpOwner == "" || e.track == nil {
+		return false, nil
+	}
+
+	var raw json.RawMessage
+
+	if v != nil {
+		var err error
+
+		raw, err = checkpoint.EncodeValue(ctx, "outbox "+env.Name, v)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	env.MessageID = e.ID() + "/" + foundation.GenerateID()
+
+	// the staging is track-goroutine confined: only the node executing on
+	// this track stages, and only this track's advance promotes.
+	e.track.staged = append(e.track.staged, outboxEntry{raw: raw, env: env})
+
+	return true, nil
+}
+
+// promoteStagedLocked moves the messages the track's current step staged
+// into its outbox. The caller holds t.m and moves the track's position in
+// the same critical section — the capture reads both under it.
+func (t *track) promoteStagedLocked() {
+	if len(t.staged) == 0 {
+		return
+	}
+
+	t.outbox = append(t.outbox, t.staged...)
+	t.instance.outboxPending.Add(int64(len(t.staged)))
+	t.staged = nil
+}
+
+// dropRelayed removes the relayed messages from the track's outbox.
+func (t *track) dropRelayed(relayed map[string]bool) {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	if len(t.outbox) == 0 {
+		return
+	}
+
+	kept := t.outbox[:0]
+
+	for _, e := range t.outbox {
+		if !relayed[e.env.MessageID] {
+			kept = append(kept, e)
+		}
+	}
+
+	t.outbox = kept
+}
+
+// outboxSnap copies the track's outbox under its mutex.
+func (t *track) outboxSnap() []outboxEntry {
+	t.m.RLock()
+	defer t.m.RUnlock()
+
+	return append([]outboxEntry(nil), t.outbox...)
+}
+
+// relayOutbox publishes the messages the last saved checkpoint holds, in
+// order, and drops the relayed ones from the tracks and the restored set.
+// The first refusal stops the relay — the rest stays for the relay retry
+// (rearmRelayRetry), so a message is never overtaken by a later one from the
+// same outbox. It reports whether anything was relayed: the caller then saves
+// the checkpoint that no longer holds it. Runs on the loop goroutine.
+func (ls *loopState) relayOutbox(ctx context.Context, cut []outboxEntry) bool {
+	inst := ls.inst
+	relayed := make(map[string]bool, len(cut))
+
+	for _, e := range cut {
+		if err := inst.MessageBroker().Publish(ctx, e.env); err != nil {
+			inst.reportRelay(observability.PhaseRelayDeferred, e.env,
+				map[string]string{observability.AttrError: err.Error()})
+
+			break
+		}
+
+		relayed[e.env.MessageID] = true
+
+		inst.reportRelay(observability.PhaseRelayed, e.env, nil)
+	}
+
+	if len(relayed) == 0 {
+		return false
+	}
+
+	for _, t := range inst.tracks {
+		t.dropRelayed(relayed)
+	}
+
+	kept := inst.restoredOutbox[:0]
+
+	for _, e := range inst.restoredOutbox {
+		if !relayed[e.env.MessageID] {
+			kept = append(kept, e)
+		}
+	}
+
+	inst.restoredOutbox = kept
+	inst.outboxPending.Add(-int64(len(relayed)))
+
+	return true
+}
+
+// The bounds of the relay retry's backoff.
+const (
+	relayRetryMin = time.Second
+	relayRetryMax = time.Minute
+)
+
+// rearmRelayRetry schedules the next relay when the outbox still holds a
+// message — the broker refused it, or the checkpoint holding it wasn't
+// saved — so a relay doesn't wait for the next loop event. Each retry that
+// finds the outbox still pending doubles the delay; an empty outbox resets
+// it. Loop goroutine only.
+func (ls *loopState) rearmRelayRetry() {
+	if ls.inst.outboxPending.Load() == 0 {
+		ls.relayC = nil
+		ls.relayDelay = 0
+
+		return
+	}
+
+	if ls.relayC != nil || ls.handingOff {
+		return
+	}
+
+	switch {
+	case ls.relayDelay == 0:
+		ls.relayDelay = relayRetryMin
+
+	case ls.relayDelay < relayRetryMax:
+		ls.relayDelay = min(2*ls.relayDelay, relayRetryMax)
+	}
+
+	ls.relayC = ls.inst.Clock().After(ls.relayDelay)
+}
+
+// reportRelay emits one outbox relay fact.
+func (inst *Instance) reportRelay(
+	phase observability.Phase, env messaging.Envelope, extra map[string]string,
+) {
+	details := map[string]string{
+		observability.AttrMessageName: env.Name,
+		observability.AttrMessageID:   env.MessageID,
+	}
+
+	if env.CorrelationKey != "" {
+		details[observability.AttrCorrelationValue] = env.CorrelationKey
+	}
+
+	for k, v := range extra {
+		details[k] = v
+	}
+
+	inst.report(observability.Fact{
+		Kind:    observability.KindEventFlow,
+		Phase:   phase,
+		Details: details,
+	})
+}
+
+// restoreOutbox rebuilds the recorded outbox: messages a previous
+// incarnation staged and never relayed — or relayed without saving the
+// record that dropped them. They relay at the first checkpoint, under
+// their recorded MessageIDs.
+func (inst *Instance) restoreOutbox(
+	ctx context.Context, doc *checkpoint.Document,
+) error {
+	for _, rec := range doc.Outbox {
+		env := messaging.Envelope{
+			Name:           rec.Name,
+			CorrelationKey: rec.CorrelationKey,
+			MessageID:      rec.ID,
+		}
+
+		if len(rec.Payload) > 0 {
+			v, err := checkpoint.DecodeValue(ctx, rec.Payload)
+			if err != nil {
+				return errs.New(
+					errs.M("Restore: couldn't decode an outbox message"),
+					errs.C(errorClass, errs.OperationFailed),
+					errs.D(observability.AttrMessageID, rec.ID),
+					errs.E(err))
+			}
+
+			env.Payload = v.Get(ctx)
+		}
+
+		inst.restoredOutbox = append(inst.restoredOutbox,
+			outboxEntry{raw: rec.Payload, env: env})
+	}
+
+	inst.outboxPending.Add(int64(len(inst.restoredOutbox)))
+
+	return nil
+}
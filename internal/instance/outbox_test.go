@@ -0,0 +1,314 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gobpm/generated/mockeventproc"
+	"github.com/dr-dobermann/gobpm/internal/enginert"
+	"github.com/dr-dobermann/gobpm/internal/instance/checkpoint"
+	"github.com/dr-dobermann/gobpm/internal/instance/snapshot"
+	"github.com/dr-dobermann/gobpm/internal/scope"
+	"github.com/dr-dobermann/gobpm/pkg/clock/clocktest"
+	"github.com/dr-dobermann/gobpm/pkg/messaging"
+	"github.com/dr-dobermann/gobpm/pkg/messaging/membroker"
+	"github.com/dr-dobermann/gobpm/pkg/model/activities"
+	"github.com/dr-dobermann/gobpm/pkg/model/bpmncommon"
+	"github.com/dr-dobermann/gobpm/pkg/model/data"
+	"github.com/dr-dobermann/gobpm/pkg/model/data/values"
+	"github.com/dr-dobermann/gobpm/pkg/model/events"
+	"github.com/dr-dobermann/gobpm/pkg/model/flow"
+	"github.com/dr-dobermann/gobpm/pkg/model/foundation"
+	"github.com/dr-dobermann/gobpm/pkg/model/process"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+	"github.com/dr-dobermann/gobpm/pkg/repository"
+)
+
+// refusingBroker refuses the first refuse publishes, then delivers.
+type refusingBroker struct {
+	messaging.MessageBroker
+	refuse atomic.Int32
+}
+
+func (rb *refusingBroker) Publish(
+	ctx context.Context, e messaging.Envelope,
+) error {
+	if rb.refuse.Add(-1) >= 0 {
+		return errors.New("broker down")
+	}
+
+	return rb.MessageBroker.Publish(ctx, e)
+}
+
+// brokerRuntime swaps the default runtime's broker.
+type brokerRuntime struct {
+	*enginert.Runtime
+	broker messaging.MessageBroker
+}
+
+func (br brokerRuntime) MessageBroker() messaging.MessageBroker {
+	return br.broker
+}
+
+// hasFlow reports an EventFlow fact of the given phase.
+func (cs *cpSink) hasFlow(phase observability.Phase) bool {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	for _, f := range cs.facts {
+		if f.Kind == observability.KindEventFlow && f.Phase == phase {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sendSnapshot builds start → send "order placed" (order_out) → end.
+func sendSnapshot(t *testing.T) *snapshot.Snapshot {
+	t.Helper()
+
+	require.NoError(t, data.CreateDefaultStates())
+
+	p, err := process.New("outbox-send",
+		data.WithProperties(
+			data.MustProperty("order_out",
+				data.MustItemDefinition(values.NewVariable("ORD-7"),
+					foundation.WithID("order_out")),
+				data.ReadyDataState)))
+	require.NoError(t, err)
+
+	start, err := events.NewStartEvent("start")
+	require.NoError(t, err)
+
+	send, err := activities.NewSendTask("send-order",
+		bpmncommon.MustMessage("order placed",
+			data.MustItemDefinition(values.NewVariable(""),
+				foundation.WithID("order_out"))),
+		activities.WithoutParams())
+	require.NoError(t, err)
+
+	end, err := events.NewEndEvent("end")
+	require.NoError(t, err)
+
+	for _, e := range []flow.Element{start, send, end} {
+		require.NoError(t, p.Add(e))
+	}
+
+	link(t, start, send)
+	link(t, send, end)
+
+	s, err := snapshot.New(p)
+	require.NoError(t, err)
+
+	return s
+}
+
+// sendParkSnapshot builds start → send "order placed" → conditional catch
+// that never holds → end: a send followed by a park.
+func sendParkSnapshot(t *testing.T) *snapshot.Snapshot {
+	t.Helper()
+
+	require.NoError(t, data.CreateDefaultStates())
+
+	val := false
+	evals := 0
+
+	def, err := events.NewConditionalEventDefinition(
+		condExpr(t, &val, &evals))
+	require.NoError(t, err)
+
+	catch, err := events.NewIntermediateCatchEvent("cond-catch", def)
+	require.NoError(t, err)
+
+	p, err := process.New("outbox-park",
+		data.WithProperties(
+			data.MustProperty("order_out",
+				data.MustItemDefinition(values.NewVariable("ORD-7"),
+					foundation.WithID("order_out")),
+				data.ReadyDataState)))
+	require.NoError(t, err)
+
+	start, err := events.NewStartEvent("start")
+	require.NoError(t, err)
+
+	send, err := activities.NewSendTask("send-order",
+		bpmncommon.MustMessage("order placed",
+			data.MustItemDefinition(values.NewVariable(""),
+				foundation.WithID("order_out"))),
+		activities.WithoutParams())
+	require.NoError(t, err)
+
+	end, err := events.NewEndEvent("end")
+	require.NoError(t, err)
+
+	for _, e := range []flow.Element{start, send, catch, end} {
+		require.NoError(t, p.Add(e))
+	}
+
+	link(t, start, send)
+	link(t, send, catch)
+	link(t, catch, end)
+
+	s, err := snapshot.New(p)
+	require.NoError(t, err)
+
+	return s
+}
+
+// runOutbox runs the send snapshot to completion over broker with the
+// outbox on; it returns the terminal record's document and the facts.
+func runOutbox(
+	t *testing.T, broker messaging.MessageBroker,
+) (*checkpoint.Document, *cpSink) {
+	t.Helper()
+
+	sink := &cpSink{}
+	rt := cpRuntime(t).WithReporter(sink)
+	ep := mockeventproc.NewMockEventProducer(t)
+
+	inst, err := New(sendSnapshot(t), scope.EmptyDataPath,
+		brokerRuntime{Runtime: rt, broker: broker}, ep, nil,
+		WithCheckpointing(engineA, engineA, time.Minute),
+		WithMessageOutbox(true))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	require.NoError(t, inst.Run(ctx))
+
+	var doc *checkpoint.Document
+
+	require.Eventually(t, func() bool {
+		rec, ok, _ := rt.Repository().Load(ctx, inst.ID())
+		if !ok || rec.Status != repository.StatusCompleted {
+			return false
+		}
+
+		d, err := checkpoint.Unmarshal(rec.Payload)
+		if err != nil {
+			return false
+		}
+
+		doc = d
+
+		return len(d.Outbox) == 0
+	}, 2*time.Second, 5*time.Millisecond,
+		"the terminal record must hold no unrelayed message")
+
+	return doc, sink
+}
+
+// TestOutboxRelay covers the instance half of the outbox: a send that
+// ends its track is promoted with the end and relayed behind a saved
+// checkpoint, and a refused relay retries at the next persist point or, with
+// none coming, on the relay retry's timer.
+func TestOutboxRelay(t *testing.T) {
+	t.Run("relayed behind the checkpoint", func(t *testing.T) {
+		broker := membroker.New()
+		sub, err := broker.Subscribe(context.Background(), "order placed")
+		require.NoError(t, err)
+
+		doc, sink := runOutbox(t, broker)
+
+		select {
+		case env := <-sub.C():
+			require.Equal(t, "ORD-7", env.Payload)
+			require.True(t,
+				strings.HasPrefix(env.MessageID, doc.InstanceID+"/"))
+		default:
+			t.Fatal("the outbox never relayed the message")
+		}
+
+		require.True(t, sink.hasFlow(observability.PhaseRelayed))
+		require.False(t, sink.hasFlow(observability.PhaseRelayDeferred))
+	})
+
+	t.Run("a refused relay retries", func(t *testing.T) {
+		inner := membroker.New()
+		sub, err := inner.Subscribe(context.Background(), "order placed")
+		require.NoError(t, err)
+
+		broker := &refusingBroker{MessageBroker: inner}
+		broker.refuse.Store(1)
+
+		_, sink := runOutbox(t, broker)
+
+		require.True(t, sink.hasFlow(observability.PhaseRelayDeferred))
+		require.True(t, sink.hasFlow(observability.PhaseRelayed))
+		require.Len(t, sub.C(), 1, "the retry delivers the message once")
+	})
+
+	t.Run("a refusal before a park retries on its own", func(t *testing.T) {
+		inner := membroker.New()
+		sub, err := inner.Subscribe(context.Background(), "order placed")
+		require.NoError(t, err)
+
+		broker := &refusingBroker{MessageBroker: inner}
+		broker.refuse.Store(1 << 20)
+
+		clk := clocktest.New(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+		sink := &cpSink{}
+		rt := cpRuntime(t).WithReporter(sink).WithClock(clk)
+
+		inst, err := New(sendParkSnapshot(t), scope.EmptyDataPath,
+			brokerRuntime{Runtime: rt, broker: broker},
+			mockeventproc.NewMockEventProducer(t), nil,
+			WithCheckpointing(engineA, engineA, time.Minute),
+			WithMessageOutbox(true))
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		require.NoError(t, inst.Run(ctx))
+
+		require.Eventually(t, func() bool {
+			return sink.hasFlow(observability.PhaseRelayDeferred) &&
+				inst.outboxPending.Load() == 1
+		}, 2*time.Second, 5*time.Millisecond)
+
+		// parked with nothing to drive it: only the relay retry sends now.
+		broker.refuse.Store(0)
+
+		require.Eventually(t, func() bool {
+			clk.Advance(relayRetryMin)
+
+			return len(sub.C()) == 1
+		}, 2*time.Second, 10*time.Millisecond,
+			"the relay retry must deliver the refused message")
+
+		require.Eventually(t, func() bool {
+			return inst.outboxPending.Load() == 0
+		}, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("off without checkpointing", func(t *testing.T) {
+		broker := membroker.New()
+		sub, err := broker.Subscribe(context.Background(), "order placed")
+		require.NoError(t, err)
+
+		sink := &cpSink{}
+		rt := enginert.Default().WithReporter(sink)
+
+		inst, err := New(sendSnapshot(t), scope.EmptyDataPath,
+			brokerRuntime{Runtime: rt, broker: broker},
+			mockeventproc.NewMockEventProducer(t), nil,
+			WithMessageOutbox(true))
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		require.NoError(t, inst.Run(ctx))
+
+		env := <-sub.C()
+		require.Empty(t, env.MessageID,
+			"a volatile instance publishes directly")
+		require.False(t, sink.hasFlow(observability.PhaseRelayed))
+	})
+}
@@ -0,0 +1,124 @@
+package instance
+
+import (
+	"context"
+)
+
+// ownershipRequest is the engine's lease upkeep crossing into the loop: a
+// heartbeat renews the instance's lease with a fresh checkpoint, a handoff
+// gives the instance up to the engine group.
+type ownershipRequest struct {
+	done    chan struct{}
+	handOff bool
+}
+
+// RenewLease renews the instance's ownership lease: the loop saves a
+// checkpoint, which stamps a fresh expiry under the instance's incarnation.
+// A failed save degrades to the CheckpointDeferred fact, as every checkpoint
+// does. The boolean reports DELIVERY, exactly as SubmitSuspension does: false
+// means the loop has exited — a released instance has no loop to renew
+// through, and the engine renews its record itself.
+func (inst *Instance) RenewLease(ctx context.Context) (bool, error) {
+	return inst.submitOwnership(ctx, false)
+}
+
+// HandOff stops the instance's loop without settling it, so another engine of
+// the group recovers it from its last checkpoint. Its tracks are stopped
+// mid-step; what they were doing re-runs on the recovering engine, exactly as
+// after a crash. Its waits' engine-level holds and its task announcements are
+// released on this engine. The instance ends Dehydrated: released, not
+// finished. It returns once the loop has exited; false means it already had.
+func (inst *Instance) HandOff(ctx context.Context) (bool, error) {
+	delivered, err := inst.submitOwnership(ctx, true)
+	if !delivered || err != nil {
+		return delivered, err
+	}
+
+	select {
+	case <-inst.loopDone:
+		return true, nil
+
+	case <-ctx.Done():
+		return true, ctx.Err()
+	}
+}
+
+// submitOwnership delivers one ownership request to the loop and waits for it
+// to be serviced.
+func (inst *Instance) submitOwnership(
+	ctx context.Context, handOff bool,
+) (bool, error) {
+	req := ownershipRequest{handOff: handOff, done: make(chan struct{})}
+
+	select {
+	case inst.ownershipReq <- req:
+
+	case <-inst.loopDone:
+		return false, nil
+
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+
+	select {
+	case <-req.done:
+		return true, nil
+
+	case <-ctx.Done():
+		return true, ctx.Err()
+	}
+}
+
+// handleOwnershipRequest services a heartbeat or a handoff on the loop
+// goroutine. A terminating instance renews nothing and hands nothing off: its
+// terminal checkpoint is on its way.
+func (ls *loopState) handleOwnershipRequest(
+	ctx context.Context, req ownershipRequest,
+) {
+	defer close(req.done)
+
+	if ls.stopping {
+		return
+	}
+
+	if !req.handOff {
+		ls.checkpointNow(ctx)
+
+		return
+	}
+
+	ls.handOff()
+}
+
+// handOff stops every track and leaves the loop for the handoff exit. The
+// tracks' ends are never applied: applying them would run the task delete
+// listeners and terminate the called children, and the tasks and children
+// belong to the instance the group recovers. What this engine holds for the
+// instance is let go: the engine-level holds of every wait, the message
+// index and the task announcements — the recovering engine re-arms the
+// waits and re-announces the tasks from the checkpoint. A track's late
+// report finds the loop gone and is dropped. Loop goroutine only.
+func (ls *loopState) handOff() {
+	ls.handingOff = true
+	ls.stopping = true
+	ls.retryC = nil
+	ls.relayC = nil
+
+	for _, t := range ls.inst.tracks {
+		t.releaseHolds()
+
+		t.stop()
+		t.cancel()
+		close(t.evtCh)
+	}
+
+	clear(ls.waiting)
+	clear(ls.msgIdx)
+
+	for id := range ls.tasks {
+		ls.inst.withdrawTask(context.Background(), id)
+	}
+
+	clear(ls.tasks)
+	ls.disarmAllScopeHandlers()
+}
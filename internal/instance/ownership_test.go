@@ -0,0 +1,60 @@
+package instance
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gobpm/pkg/interactor"
+)
+
+// withdrawSpy is a TaskDistributor recording the task ids it withdrew.
+type withdrawSpy struct {
+	mu        sync.Mutex
+	withdrawn []string
+}
+
+func (*withdrawSpy) Distribute(context.Context, interactor.TaskInfo) error { return nil }
+
+func (w *withdrawSpy) Withdraw(_ context.Context, taskID string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.withdrawn = append(w.withdrawn, taskID)
+
+	return nil
+}
+
+// TestHandOffReleasesHolds: a handoff lets go of everything the engine holds
+// for the instance — the held subscription of its wait, its message index and
+// its task announcements — and leaves the loop for the handoff exit without
+// running the tracks' ends.
+func TestHandOffReleasesHolds(t *testing.T) {
+	inst, tr, holders := signalTrack(t)
+	tr.cancel = func() {}
+
+	spy := &withdrawSpy{}
+	inst.td = spy
+
+	require.Equal(t, 1, holders.subCount(), "the wait is held by the engine")
+
+	ls := newLoopState(inst)
+	ls.waiting[tr.ID()] = struct{}{}
+	ls.msgIdx["m1"] = tr
+	ls.tasks["task-1"] = taskEntry{track: tr}
+
+	ls.handOff()
+
+	require.True(t, ls.handingOff)
+	require.True(t, ls.stopping)
+	require.Zero(t, holders.subCount(), "the held subscription is withdrawn")
+	require.Equal(t, 1, holders.released[inst.ID()+"|"+tr.ID()])
+	require.Empty(t, ls.waiting)
+	require.Empty(t, ls.msgIdx)
+	require.Empty(t, ls.tasks)
+	require.Equal(t, []string{"task-1"}, spy.withdrawn,
+		"the announcement is withdrawn from this engine")
+	require.True(t, tr.stopIt.Load())
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/dr-dobermann/gobpm/pkg/errs"
 	"github.com/dr-dobermann/gobpm/pkg/interactor"
 	"github.com/dr-dobermann/gobpm/pkg/model/data"
+	"github.com/dr-dobermann/gobpm/pkg/model/events"
 	"github.com/dr-dobermann/gobpm/pkg/model/flow"
 	"github.com/dr-dobermann/gobpm/pkg/model/foundation"
 	engrenv "github.com/dr-dobermann/gobpm/pkg/renv"
@@ -37,6 +38,11 @@ func WithCheckpointCursor(recVersion, incarnation int64) Option {
 // on from, never an instance failure.
 const CorrelationDropClass = "WAKE_CORRELATION_DROP"
 
+// DuplicateDropClass marks a wake refused because its message was already
+// consumed — a broker redelivery. Benign like CorrelationDropClass: the
+// instance stays as it was.
+const DuplicateDropClass = "WAKE_DUPLICATE_DROP"
+
 // PendingTrigger carries a trigger that accompanies a hydration, turning
 // a cold RE-ENTER into a wake-on-trigger CONTINUATION (ADR-007 v.2 §2.3,
 // SRD-071 FR-4). The single discriminator of Restore's two modes:
@@ -98,7 +104,8 @@ func Restore(
 
 	opts = append(opts,
 		withRestoredIdentity(doc.InstanceID),
-		withCallLinkage(doc.ParentID, doc.CallNodeID))
+		withCallLinkage(doc.ParentID, doc.CallNodeID),
+		WithBusinessKey(doc.BusinessKey))
 
 	inst, err := New(s, parentRoot, er, ep, td, opts...)
 	if err != nil {
@@ -115,6 +122,10 @@ func Restore(
 	inst.performers.restore(doc.CompletedBy)
 	inst.restoreStartedAt(doc.StartedAt)
 
+	for _, id := range doc.AcceptedMessages {
+		inst.accepted.add(id)
+	}
+
 	if err := inst.restoreLedgers(ctx, doc); err != nil {
 		return nil, err
 	}
@@ -123,6 +134,15 @@ func Restore(
 		return nil, err
 	}
 
+	// the triggers a suspended instance held return to its loop, which
+	// holds them again for Resume.
+	err = inst.restoreHeld(ctx, doc.Held, inst.heldTrigger)
+	inst.heldTrigger = nil
+
+	if err != nil {
+		return nil, err
+	}
+
 	// the parallel open sets, the resolving sweeps and the in-flight
 	// calls ride to the loop's adoption (SRD-082 FR-4/FR-6/FR-7) — the
 	// substrates are loop-owned.
@@ -130,6 +150,11 @@ func Restore(
 	inst.restoredSweeps = doc.Sweeps
 	inst.restoredCalls = doc.Calls
 
+	// an operator-suspended instance comes back on hold: whatever rebuilt it
+	// (a cancel, an incident op, a task action, its resume) meets the hold
+	// first (ADR-033 §2.6).
+	inst.suspendAtStart = doc.Status == Suspended.String()
+
 	// a recorded caller re-PARKS instead of re-invoking its child — the
 	// adoption re-links to the recorded child; a second InvokeProcess
 	// would duplicate the child instance (SRD-082 FR-7).
@@ -154,6 +179,10 @@ func Restore(
 		return nil, err
 	}
 
+	if err := inst.restoreOutbox(ctx, doc); err != nil {
+		return nil, err
+	}
+
 	return inst, nil
 }
 
@@ -168,7 +197,8 @@ func withRestoredIdentity(id string) Option {
 
 // restoreScopes reopens the recorded scope tree (parent-first) and
 // recommits the recorded data — overriding the freshly seeded property
-// values with the checkpointed ones.
+// values with the checkpointed ones, and dropping a seeded one the record
+// lacks: an operator deleted it (InstanceHandle.DeleteVariables).
 func (inst *Instance) restoreScopes(
 	ctx context.Context, doc *checkpoint.Document,
 ) error {
@@ -190,13 +220,21 @@ func (inst *Instance) restoreScopes(
 			}
 		}
 
-		if len(rec.Data) == 0 {
-			continue
+		var (
+			dd  []data.Data
+			err error
+		)
+
+		if len(rec.Data) != 0 {
+			if dd, err = checkpoint.DecodeData(ctx, rec.Data); err != nil {
+				return err
+			}
 		}
 
-		dd, err := checkpoint.DecodeData(ctx, rec.Data)
-		if err != nil {
-			return err
+		if path == inst.sc.root {
+			if err := inst.dropUnrecorded(dd); err != nil {
+				return err
+			}
 		}
 
 		if len(dd) == 0 {
@@ -216,6 +254,47 @@ func (inst *Instance) restoreScopes(
 	return nil
 }
 
+// dropUnrecorded deletes the data seeded into the root scope at New that the
+// recorded root data dd doesn't hold, so a deleted process property stays
+// deleted across a rebuild.
+func (inst *Instance) dropUnrecorded(dd []data.Data) error {
+	seeded, err := inst.sc.plane.List("")
+	if err != nil {
+		return err
+	}
+
+	recorded := make(map[string]struct{}, len(dd))
+	for _, d := range dd {
+		recorded[d.Name()] = struct{}{}
+	}
+
+	var gone []string
+
+	for _, name := range seeded {
+		if _, ok := recorded[name]; !ok {
+			gone = append(gone, name)
+		}
+	}
+
+	if len(gone) == 0 {
+		return nil
+	}
+
+	f, err := inst.sc.openFrame("restore", "restore")
+	if err != nil {
+		return err
+	}
+
+	if err := f.Delete(gone...); err != nil {
+		return err
+	}
+
+	// restored state, not a change — the changed-path set is dropped.
+	_, err = f.Commit()
+
+	return err
+}
+
 // restoreLedgers rebuilds the compensation ledger the loop adopts at
 // start. The capture flattened folded children (SRD-070 M3); they
 // restore as sibling entries under their recorded scope path — full
@@ -312,6 +391,10 @@ func (inst *Instance) restoreTracks(
 			return err
 		}
 
+		if t.relocate, err = inst.restoredRelocation(rec); err != nil {
+			return err
+		}
+
 		inst.tracks[t.ID()] = t
 		inst.addToSnap(t)
 	}
@@ -387,6 +470,17 @@ func (inst *Instance) continuationTrack(
 	// conversation), and a mismatch refuses the wake. The engine's holder-side
 	// gate is the cheap early-out that avoids rebuilding for a foreign
 	// conversation; this is the authoritative decision.
+	// A redelivered message the instance consumed before it dehydrated —
+	// the resident gate's rule (acceptMessage), applied on the rebuilt
+	// instance. Checked first: a duplicate must not associate anything.
+	if id := events.MessageIDOf(pending.EDef); inst.accepted.has(id) {
+		return nil, errs.New(
+			errs.M("Restore: the trigger is a redelivered message"),
+			errs.C(errorClass, DuplicateDropClass),
+			errs.D(observability.AttrTrackID, rec.ID),
+			errs.D(observability.AttrMessageID, id))
+	}
+
 	if pending.EDef.Type() == flow.TriggerMessage &&
 		inst.corr.validateAndAssociate(context.Background(), pending.EDef) {
 		return nil, errs.New(
@@ -396,6 +490,8 @@ func (inst *Instance) continuationTrack(
 			errs.D(observability.AttrEventDefinitionID, pending.EDef.ID()))
 	}
 
+	inst.accepted.add(events.MessageIDOf(pending.EDef))
+
 	// preload the trigger: run() enters awaitTrigger, reads it, and fires the
 	// node through deliver() — the exact resident fire path, minus the wait.
 	t.evtCh <- pending.EDef
@@ -445,6 +541,15 @@ func restoredTrack(
 	// recorded pass instead of iterating from zero (SRD-082 FR-3).
 	t.miSeed = rec.MI
 
+	// a parked UserTask was created before the release: it is re-announced,
+	// not created again, with its recorded eligibility when there is one.
+	_, isTask := node.(interactor.HumanTask)
+	if isTask && rec.TaskID != "" && (rec.State == TrackWaitForEvent.String() ||
+		rec.State == TrackDehydrated.String()) {
+		t.taskRestored = true
+		t.taskEligible = restoredEligibility(rec.Eligible)
+	}
+
 	if err := t.checkNodeType(node, true); err != nil {
 		return nil, err
 	}
@@ -464,3 +569,27 @@ func (t *track) TimerDeadlineHint(string) (time.Time, int, bool) {
 }
 
 func itoa(v int) string { return strconv.Itoa(v) }
+
+// restoredEligibility rebuilds a recorded task eligibility; nil when none was
+// recorded.
+func restoredEligibility(
+	rec *checkpoint.EligibilityRecord,
+) *interactor.Eligibility {
+	if rec == nil {
+		return nil
+	}
+
+	slot := func(s checkpoint.SlotRecord) interactor.ResolvedSlot {
+		return interactor.ResolvedSlot{
+			IDs:      append([]string(nil), s.IDs...),
+			Declared: s.Declared,
+		}
+	}
+
+	return &interactor.Eligibility{
+		Assignee:        slot(rec.Assignee),
+		CandidateUsers:  slot(rec.CandidateUsers),
+		CandidateGroups: slot(rec.CandidateGroups),
+		Roles:           slot(rec.Roles),
+	}
+}
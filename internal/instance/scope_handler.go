@@ -201,6 +201,12 @@ func (ls *loopState) fireScopeHandler(ctx context.Context, ev trackEvent) {
 		return
 	}
 
+	// a redelivered message must not start its handler twice — a
+	// non-interrupting one would fork a second instance of it.
+	if !ls.acceptMessage(ev.eDef) {
+		return
+	}
+
 	// non-interrupting: fork a concurrent handler instance and leave the watch
 	// armed to fire again — no budget, no sibling-cancel, no disarm (SRD-053
 	// FR-3/FR-5), the scope-level twin of a non-interrupting boundary.
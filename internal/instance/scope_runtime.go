@@ -236,7 +236,13 @@ func (ls *loopState) onScopeOpen(ctx context.Context, host *track, node flow.Nod
 	ls.reportScope(observability.PhaseOpened, node, child,
 		scopeLoopCounter(node, host))
 
-	ls.seedScope(ctx, sh, child)
+	// an operator's start inside the composite enters only the relocated
+	// node, not the composite's entry shape.
+	if len(host.relocate) > 0 {
+		ls.seedRelocated(ctx, host, child)
+	} else {
+		ls.seedScope(ctx, sh, child)
+	}
 
 	// arm the scope's Event Sub-Process handlers while it is open (SRD-052
 	// FR-5) — the boundary-watch pattern at scope granularity.
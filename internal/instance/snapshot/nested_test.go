@@ -245,3 +245,26 @@ func TestSnapshotRejectsInvalidInnerShape(t *testing.T) {
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "needs an entry")
 }
+
+// TestSnapshotHasElement — the element lookup an execution listener binding
+// is checked against reaches nested nodes and their flows.
+func TestSnapshotHasElement(t *testing.T) {
+	p, srcOuter := nestedProcess(t, "nested-elements")
+
+	s, err := snapshot.New(p)
+	require.NoError(t, err)
+
+	srcInner := innerByName(t, srcOuter, "inner").(*activities.SubProcess)
+	leaf := innerByName(t, srcInner, "leaf")
+
+	for _, id := range []string{
+		srcOuter.ID(),
+		srcOuter.Outgoing()[0].ID(),
+		leaf.ID(),
+		leaf.Outgoing()[0].ID(),
+	} {
+		require.True(t, s.HasElement(id), id)
+	}
+
+	require.False(t, s.HasElement("no-such-element"))
+}
@@ -3,6 +3,7 @@ package snapshot
 
 import (
 	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/listener"
 	"github.com/dr-dobermann/gobpm/pkg/model/bpmncommon"
 	"github.com/dr-dobermann/gobpm/pkg/model/data"
 	dataobjects "github.com/dr-dobermann/gobpm/pkg/model/data_objects"
@@ -34,6 +35,10 @@ type Snapshot struct {
 	// an incoming message's payload to grow the instance's conversation key-set
 	// (lazy association — SRD-017 §4.5). Immutable config, shared by Clone.
 	CorrelationKeys []*bpmncommon.CorrelationKey
+	// Inputs is the process's declared input set — the start variables a host
+	// passes at StartProcess are validated against it. Immutable
+	// declarations, shared by Clone.
+	Inputs []*data.Parameter
 	// InstantiatingStarts are the process's instantiating start triggers
 	// (message / signal StartEvents and instantiate ReceiveTasks), discovered
 	// once by New after the graph is wired. The thresher wraps each into a
@@ -46,6 +51,16 @@ type Snapshot struct {
 	// exactly the version the instance started from. Zero means
 	// "unregistered" (a snapshot built outside the registry).
 	Version int
+	// Listeners are the execution listeners bound to the process — the
+	// engine-wide ones first, then the process's and its elements', in
+	// registration order. The thresher stamps them at registration, so every
+	// instance of the version, rebuilt ones included, runs the same set.
+	// Immutable, shared by Clone.
+	Listeners []listener.Binding
+	// TaskListeners are the UserTask lifecycle listeners bound to the
+	// process, in the same order and stamped the same way as Listeners.
+	// Immutable, shared by Clone.
+	TaskListeners []listener.TaskBinding
 
 	// HasConditionals reports whether any node carries a Conditional event
 	// definition (catch, boundary, or event-based-gateway arm), precomputed
@@ -116,6 +131,7 @@ func New(
 	}
 
 	s.CorrelationKeys = correlationKeys(p)
+	s.Inputs = p.Inputs()
 
 	seExists := false
 	eeExists := false
@@ -260,6 +276,33 @@ func (s *Snapshot) NodeByID(id string) (flow.Node, bool) {
 	return found, found != nil
 }
 
+// HasElement reports whether id names a node or a sequence flow anywhere in
+// the snapshot's graph, nested containers included — the elements an
+// execution listener binds to.
+func (s *Snapshot) HasElement(id string) bool {
+	if _, ok := s.Flows[id]; ok {
+		return true
+	}
+
+	if _, ok := s.Nodes[id]; ok {
+		return true
+	}
+
+	return !walkNodesDeep(s.Nodes, func(n flow.Node) bool {
+		if n.ID() == id {
+			return false
+		}
+
+		for _, f := range n.Outgoing() {
+			if f.ID() == id {
+				return false
+			}
+		}
+
+		return true
+	})
+}
+
 // walkNodesDeep visits every node of the graph and, recursively, of every
 // nested container (SRD-049 FR-5). visit returning false stops the walk.
 func walkNodesDeep(nodes map[string]flow.Node, visit func(flow.Node) bool) bool {
@@ -337,8 +380,9 @@ func cloneNode(n flow.Node) (flow.Node, error) {
 // flow graph is relinked between the clones, so an instance built from the clone
 // mutates only its own nodes. Properties are cloned too — they carry per-instance
 // mutable runtime state, so each instance owns its own (FIX-016). The genuinely
-// immutable header — process id/name, correlation-key definitions and
-// instantiating-start descriptors — is shared by reference. See ADR-009.
+// immutable header — process id/name, correlation-key definitions, input
+// declarations and instantiating-start descriptors — is shared by reference.
+// See ADR-009.
 func (s *Snapshot) Clone() (*Snapshot, error) {
 	props, err := data.CloneProperties(s.Properties)
 	if err != nil {
@@ -358,9 +402,12 @@ func (s *Snapshot) Clone() (*Snapshot, error) {
 		Properties:          props,
 		DataObjects:         dobjs,
 		CorrelationKeys:     s.CorrelationKeys,
+		Inputs:              s.Inputs,
 		InstantiatingStarts: s.InstantiatingStarts,
 		HasConditionals:     s.HasConditionals,
 		Version:             s.Version,
+		Listeners:           s.Listeners,
+		TaskListeners:       s.TaskListeners,
 	}
 
 	// Clone every node (its immutable configuration shared by reference, its
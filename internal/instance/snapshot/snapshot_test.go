@@ -346,6 +346,39 @@ func TestSnapshotCorrelationKeys(t *testing.T) {
 	require.Equal(t, s.CorrelationKeys, clone.CorrelationKeys)
 }
 
+// TestSnapshotInputs verifies the snapshot carries the process's declared
+// input set and that Clone shares it.
+func TestSnapshotInputs(t *testing.T) {
+	require.NoError(t, data.CreateDefaultStates())
+
+	in, err := data.ReadyValueParameter("order_id", values.NewVariable(""))
+	require.NoError(t, err)
+
+	p, err := process.New("p-inputs", process.WithInputs(in))
+	require.NoError(t, err)
+
+	start, err := events.NewStartEvent("start")
+	require.NoError(t, err)
+
+	end, err := events.NewEndEvent("end")
+	require.NoError(t, err)
+
+	require.NoError(t, p.Add(start))
+	require.NoError(t, p.Add(end))
+
+	_, err = flow.Link(start, end)
+	require.NoError(t, err)
+
+	s, err := snapshot.New(p)
+	require.NoError(t, err)
+	require.Len(t, s.Inputs, 1)
+	require.Equal(t, "order_id", s.Inputs[0].Name())
+
+	clone, err := s.Clone()
+	require.NoError(t, err)
+	require.Equal(t, s.Inputs, clone.Inputs)
+}
+
 // TestSnapshotNewIsolatesFromModel verifies that New takes its own copy of the
 // definition's graph: editing the source process after registration — here,
 // adding a node — does not reach the already-taken snapshot. This is the
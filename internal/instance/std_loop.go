@@ -47,6 +47,10 @@ func standardLoopOf(node flow.Node) standardLoop {
 func (t *track) executeStep(
 	ctx context.Context, step *stepInfo,
 ) ([]*flow.SequenceFlow, error) {
+	// the staging is per step: what an abandoned step staged never leaves
+	// (outbox.go).
+	t.staged = nil
+
 	if sl := standardLoopOf(step.node); sl != nil {
 		// a composite (scopeHost) Standard Loop iterates off the loop via the
 		// scope decorator (SRD-054 §2.12); a leaf Task iterates in place.
@@ -0,0 +1,240 @@
+package instance
+
+import (
+	"context"
+
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+)
+
+// suspendRequest is an operator's Suspend or Resume crossing into the loop
+// (ADR-033 §2.6).
+type suspendRequest struct {
+	resp   chan error
+	resume bool
+}
+
+// SubmitSuspension submits an operator Suspend (resume false) or Resume
+// (resume true) to the instance's loop and waits for its verdict. The boolean
+// reports DELIVERY, exactly as SubmitIncidentOp does: false means the loop has
+// exited and the engine must rebuild the instance to apply the request.
+func (inst *Instance) SubmitSuspension(
+	ctx context.Context, resume bool,
+) (bool, error) {
+	req := suspendRequest{resume: resume, resp: make(chan error, 1)}
+
+	select {
+	case inst.suspendReq <- req:
+
+	case <-inst.loopDone:
+		return false, nil
+
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+
+	select {
+	case err := <-req.resp:
+		return true, err
+
+	case <-ctx.Done():
+		return true, ctx.Err()
+	}
+}
+
+// WithPendingSuspension hands a rebuild the operator's Suspend or Resume that
+// caused it — the WithPendingCancel shape: a released instance has no loop to
+// deliver to, so the request rides the rebuild and the fresh loop applies it
+// BEFORE deciding whether to release again. The verdict lands on resp.
+func WithPendingSuspension(resume bool, resp chan error) Option {
+	return func(cfg *newConfig) {
+		cfg.pendingSuspension = &suspendRequest{resume: resume, resp: resp}
+	}
+}
+
+// Released reports whether the instance holds no loop while still in flight:
+// it dehydrated, or it was suspended and has since released its goroutines.
+// Such an instance is reached only by rebuilding it from its checkpoint.
+func (inst *Instance) Released() bool {
+	switch inst.State() {
+	case Dehydrated:
+		return true
+
+	case Suspended:
+		select {
+		case <-inst.loopDone:
+			return true
+
+		default:
+		}
+	}
+
+	return false
+}
+
+// closeGate puts the operator hold up: every track reads it before the next
+// node it executes. Loop goroutine only.
+func (inst *Instance) closeGate() {
+	gate := make(chan struct{})
+	inst.suspendGate.Store(&gate)
+}
+
+// openGate lifts the operator hold, releasing every track waiting at it.
+// Loop goroutine only.
+func (inst *Instance) openGate() {
+	if gate := inst.suspendGate.Swap(nil); gate != nil {
+		close(*gate)
+	}
+}
+
+// awaitResume holds the track while its instance is suspended (ADR-033 §2.6).
+// It is called between nodes, so the step that was in flight has reached its
+// transition and the token waits on the node it is about to execute — the
+// node a rebuild re-enters. It returns false if the track's context ended
+// first.
+func (t *track) awaitResume(ctx context.Context) bool {
+	gate := t.instance.suspendGate.Load()
+	if gate == nil {
+		return true
+	}
+
+	select {
+	case <-*gate:
+		return true
+
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// handleSuspendRequest applies one operator Suspend/Resume on the loop
+// goroutine, so the hold, the buffered triggers and the checkpoint stay
+// single-writer. A suspended instance whose tracks are all parked releases its
+// goroutines right after the verdict.
+func (ls *loopState) handleSuspendRequest(
+	ctx context.Context, req suspendRequest,
+) {
+	var err error
+
+	if req.resume {
+		err = ls.resume(ctx)
+	} else {
+		err = ls.suspend(ctx)
+	}
+
+	if req.resp != nil {
+		req.resp <- err
+	}
+
+	ls.maybeDehydrate(ctx)
+}
+
+// suspend puts the instance on hold (ADR-033 §2.6): the gate stops every
+// track before its next node, triggers are buffered from here on, and the
+// checkpoint persists StatusSuspended so recovery leaves the instance alone.
+// Steps already executing run to their transition. Idempotent; a terminating
+// instance refuses.
+func (ls *loopState) suspend(ctx context.Context) error {
+	if ls.stopping {
+		return ls.suspensionRefused("suspend")
+	}
+
+	if ls.suspended {
+		return nil
+	}
+
+	ls.suspended = true
+	ls.inst.closeGate()
+	ls.inst.setStateAs(Suspended, observability.PhasePaused)
+	ls.checkpointNow(ctx)
+
+	return nil
+}
+
+// resume lifts the hold: the instance is Active again, the gate opens and
+// the triggers buffered while suspended are applied in the order they
+// arrived. Idempotent; a terminating instance refuses.
+func (ls *loopState) resume(ctx context.Context) error {
+	if ls.stopping {
+		return ls.suspensionRefused("resume")
+	}
+
+	if !ls.suspended {
+		return nil
+	}
+
+	ls.suspended = false
+	ls.inst.setStateAs(Active, observability.PhaseResumed)
+	ls.inst.openGate()
+
+	held := ls.held
+	ls.held = nil
+
+	for _, ev := range held {
+		ls.apply(ctx, ev)
+	}
+
+	ls.checkpointNow(ctx)
+
+	return nil
+}
+
+// suspensionRefused is the refusal of a Suspend/Resume reaching an instance
+// that is already tearing down.
+func (ls *loopState) suspensionRefused(op string) error {
+	return errs.New(
+		errs.M("can't %s instance %q: it is terminating", op, ls.inst.ID()),
+		errs.C(errorClass, errs.InvalidState),
+		errs.D(observability.AttrInstanceID, ls.inst.ID()))
+}
+
+// holdTrigger buffers an arriving trigger while the instance is suspended,
+// reporting whether it did. Only the external fires are held — a delivery to
+// a parked track, a boundary or an Event Sub-Process handler firing; the
+// instance's own bookkeeping events still apply, so in-flight steps can
+// finish.
+func (ls *loopState) holdTrigger(ev trackEvent) bool {
+	if !ls.suspended || ls.stopping {
+		return false
+	}
+
+	switch ev.kind {
+	case evDeliver, evBoundary, evScopeHandlerFire:
+		ls.held = append(ls.held, ev)
+
+		return true
+	}
+
+	return false
+}
+
+// heldReleasable reports whether every buffered trigger survives a release:
+// a delivery does — a timer re-arms from the checkpoint, a message or signal
+// rides it (heldRecords) — while a boundary or handler fire has no
+// checkpoint form and keeps the instance resident.
+func (ls *loopState) heldReleasable() bool {
+	for _, ev := range ls.held {
+		if ev.kind != evDeliver {
+			return false
+		}
+	}
+
+	return true
+}
+
+// releaseState lands the state of an instance whose loop released its
+// goroutines: Dehydrated, or — for a suspended one — still Suspended, with
+// the same residency fact so an operator counts it among the released.
+func (ls *loopState) releaseState() {
+	if !ls.suspended {
+		ls.inst.setStateDetailed(Dehydrated, ls.dehydrationDetails())
+
+		return
+	}
+
+	ls.inst.report(observability.Fact{
+		Kind:    observability.KindInstanceState,
+		Phase:   observability.PhaseDehydrated,
+		Details: ls.dehydrationDetails(),
+	})
+}
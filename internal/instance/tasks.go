@@ -6,15 +6,17 @@ import (
 
 	"github.com/dr-dobermann/gobpm/pkg/errs"
 	"github.com/dr-dobermann/gobpm/pkg/interactor"
+	"github.com/dr-dobermann/gobpm/pkg/listener"
 	"github.com/dr-dobermann/gobpm/pkg/model/data"
 	"github.com/dr-dobermann/gobpm/pkg/model/flow"
 	hi "github.com/dr-dobermann/gobpm/pkg/model/hinteraction"
 	"github.com/dr-dobermann/gobpm/pkg/observability"
 )
 
-// distributorTimeout bounds every TaskDistributor call (Distribute/Withdraw): it
-// runs on the instance-loop goroutine, so a slow or hung embedder must not block
-// the loop. Distribution is best-effort — a timeout is logged, not fatal.
+// distributorTimeout bounds every TaskDistributor call (Distribute/Withdraw) and
+// every task listener run on the loop: they run on the instance-loop goroutine,
+// so a slow or hung embedder must not block the loop. Distribution is
+// best-effort — a timeout is logged, not fatal.
 const distributorTimeout = 5 * time.Second
 
 // taskReqKind selects a human-task operation serviced by the instance loop.
@@ -155,6 +157,15 @@ func (inst *Instance) taskRoundtrip(
 	case r := <-req.reply:
 		return r.view, r.err
 	case <-inst.loopDone:
+		// a loop that answered and then ran the instance to its end closes
+		// loopDone with the reply already buffered — select picks either, and
+		// a retry would replay an action that took effect.
+		select {
+		case r := <-req.reply:
+			return r.view, r.err
+		default:
+		}
+
 		return interactor.TaskView{}, errs.New(
 			errs.M("instance %q stopped before task reply", inst.ID()),
 			errs.C(errorClass, errs.InvalidState, TaskRetryClass))
@@ -181,6 +192,17 @@ func (ls *loopState) handleTaskRequest(ctx context.Context, req taskRequest) {
 		return
 	}
 
+	// A suspended instance keeps its tasks announced and claimable, but moves
+	// no token: a completion is refused until Resume (ADR-033 §2.6).
+	if ls.suspended && req.kind == reqComplete {
+		req.reply <- taskReply{err: errs.New(
+			errs.M("instance %q is suspended", ls.inst.ID()),
+			errs.C(errorClass, errs.InvalidState),
+			errs.D(observability.AttrInstanceID, ls.inst.ID()))}
+
+		return
+	}
+
 	// The ad-hoc requests route by container node, not by task id, so they are
 	// serviced before the task registry lookup below.
 	switch req.kind {
@@ -248,6 +270,20 @@ func (ls *loopState) completeTask(
 		return
 	}
 
+	// The complete listeners see only outputs that passed the specification,
+	// and may still refuse them — the same non-terminal verdict.
+	if err := ls.inst.notifyTaskLocal(ctx, &listener.Task{
+		TaskRef:  ls.inst.taskRef(req.taskID, entry.node),
+		Event:    listener.TaskComplete,
+		Eligible: entry.eligible,
+		UserID:   req.actor.UserID(),
+		Outputs:  req.outputs,
+	}); err != nil {
+		req.reply <- taskReply{err: err}
+
+		return
+	}
+
 	// Record WHO performed the work before resuming. It is written here, past every
 	// rejectable stage, so only an accepted completion leaves a record (ADR-020 v.2
 	// §2.4.2).
@@ -320,9 +356,25 @@ func (ls *loopState) addTask(
 
 	inst := ls.inst
 
+	// A restored task was created before the instance was released: it keeps
+	// the eligibility it was distributed with — the create listeners' changes
+	// included — and is not created again. A checkpoint that predates the
+	// recorded eligibility re-resolves it.
+	restored, recorded := tr.taskRestored, tr.taskEligible
+	tr.taskRestored, tr.taskEligible = false, nil
+
 	// Resolve the triad once, here, and keep the snapshot on the registry entry:
 	// every later check reads it instead of re-resolving (ADR-020 v.2 §2.7).
-	info := inst.buildTaskInfo(ctx, taskID, node)
+	var info interactor.TaskInfo
+	if recorded != nil {
+		info = inst.taskInfo(taskID, node, *recorded)
+	} else {
+		info = inst.buildTaskInfo(ctx, taskID, node)
+	}
+
+	if !restored {
+		inst.createTask(ctx, &info)
+	}
 
 	ls.tasks[taskID] = taskEntry{
 		track:    tr,
@@ -445,6 +497,7 @@ func (ls *loopState) onTaskWaiting(ctx context.Context, ev trackEvent) {
 // context is used since the instance context is already canceled at that point.
 func (ls *loopState) withdrawAllTasks() {
 	for id := range ls.tasks {
+		ls.deleteTask(context.Background(), id)
 		ls.inst.withdrawTask(context.Background(), id)
 	}
 
@@ -462,11 +515,61 @@ func (ls *loopState) cleanupTask(ctx context.Context, tr *track) {
 			continue
 		}
 
+		ls.deleteTask(ctx, id)
 		delete(ls.tasks, id)
 		ls.inst.withdrawTask(ctx, id)
 	}
 }
 
+// createTask runs the create listeners over a freshly parked task's
+// announcement: their assignment changes land on info before it is registered
+// and distributed. Like distribution, creation is best-effort — a failing
+// listener is logged and the task is distributed as the listeners left it.
+func (inst *Instance) createTask(ctx context.Context, info *interactor.TaskInfo) {
+	task := listener.Task{
+		TaskRef:  info.TaskRef,
+		Event:    listener.TaskCreate,
+		Eligible: info.Eligible,
+	}
+
+	err := inst.notifyTaskLocal(ctx, &task)
+	info.Eligible = task.Eligible
+
+	if err != nil {
+		inst.Logger().Warn("user task create listener failed",
+			observability.AttrInstanceID, inst.ID(), observability.AttrTaskID, info.TaskID, observability.AttrError, err.Error())
+	}
+}
+
+// deleteTask runs the delete listeners of a task withdrawn without completing.
+// The task is going regardless, so a listener error is logged only.
+func (ls *loopState) deleteTask(ctx context.Context, taskID string) {
+	if len(ls.inst.s.TaskListeners) == 0 {
+		return
+	}
+
+	entry := ls.tasks[taskID]
+
+	err := ls.inst.notifyTaskLocal(ctx, &listener.Task{
+		TaskRef:  ls.inst.taskRef(taskID, entry.node),
+		Event:    listener.TaskDelete,
+		Eligible: entry.eligible,
+	})
+	if err != nil {
+		ls.inst.Logger().Warn("user task delete listener failed",
+			observability.AttrInstanceID, ls.inst.ID(), observability.AttrTaskID, taskID, observability.AttrError, err.Error())
+	}
+}
+
+// notifyTaskLocal runs the task listeners on the loop goroutine, bounded like a
+// distributor call.
+func (inst *Instance) notifyTaskLocal(ctx context.Context, task *listener.Task) error {
+	lctx, cancel := context.WithTimeout(ctx, distributorTimeout)
+	defer cancel()
+
+	return inst.NotifyTask(lctx, task)
+}
+
 // withdrawTask retracts a task from the distributor, logging a distributor error
 // without failing the instance.
 func (inst *Instance) withdrawTask(ctx context.Context, taskID string) {
@@ -496,13 +599,23 @@ func (inst *Instance) buildTaskInfo(
 	ctx context.Context,
 	taskID string,
 	node flow.Node,
+) interactor.TaskInfo {
+	return inst.taskInfo(taskID, node,
+		inst.resolveEligibility(ctx, taskID, node))
+}
+
+// taskInfo builds a UserTask's announcement around an already resolved triad.
+func (inst *Instance) taskInfo(
+	taskID string,
+	node flow.Node,
+	eligible interactor.Eligibility,
 ) interactor.TaskInfo {
 	ht, _ := node.(interactor.HumanTask)
 
 	return interactor.TaskInfo{
 		TaskRef:  inst.taskRef(taskID, node),
 		Roles:    ht.Roles(),
-		Eligible: inst.resolveEligibility(ctx, taskID, node),
+		Eligible: eligible,
 		Priority: ht.TaskPriority(),
 	}
 }
@@ -52,6 +52,7 @@ import (
 	"github.com/dr-dobermann/gobpm/pkg/errs"
 	"github.com/dr-dobermann/gobpm/pkg/exec"
 	"github.com/dr-dobermann/gobpm/pkg/interactor"
+	"github.com/dr-dobermann/gobpm/pkg/listener"
 	"github.com/dr-dobermann/gobpm/pkg/model/data"
 	"github.com/dr-dobermann/gobpm/pkg/model/events"
 	"github.com/dr-dobermann/gobpm/pkg/model/flow"
@@ -199,11 +200,23 @@ type track struct {
 	// as completed.
 	adHocActivity string
 	scopeSeg      string
+	// relocate is the rest of an operator's start-before chain (an instance
+	// modification): the composites still to enter, innermost last, ending
+	// with the target node. A host carrying it seeds its scope with the
+	// chain's head instead of the composite's entry nodes. Loop-owned: set
+	// pre-spawn, consumed once when the scope opens.
+	relocate []flow.Node
 	foundation.BaseElement
 	prev      []string
 	msgDefIDs []string
 	condDefs  []*events.ConditionalEventDefinition
 	steps     []*stepInfo
+	// staged holds the messages the executing step sent in outbox mode;
+	// track-goroutine confined, reset at every step and promoted into
+	// outbox when the step settles. outbox holds the promoted messages
+	// until the loop relays them — guarded by m, read by the capture.
+	staged []outboxEntry
+	outbox []outboxEntry
 	// compWaitRef holds the target ref of the wait-for-completion Compensation
 	// throw this track is parked on (SRD-059 FR-5); informational.
 	compWaitRef string
@@ -221,6 +234,10 @@ type track struct {
 	// instead of iterating from zero. Set by restore before spawn,
 	// consumed once by the runner.
 	miSeed *checkpoint.MIRecord
+	// taskEligible is a RESTORED UserTask's recorded eligibility: the
+	// task is re-announced with it instead of re-resolving. Set by
+	// restore before spawn, consumed once by the loop's addTask.
+	taskEligible *interactor.Eligibility
 	// miParallelSeed is the parallel counterpart (SRD-082 FR-4): the
 	// runner re-attaches to its restored group instead of fanning out.
 	// Set by the loop's adoption BEFORE the spawns, consumed once.
@@ -256,6 +273,10 @@ type track struct {
 	// FR-7): it re-parks without re-invoking — the adoption re-links to
 	// the recorded child. Same lifecycle as compWaitRestored.
 	callRestored bool
+	// taskRestored marks a RESTORED UserTask wait: its task was created
+	// before the release, so the create listeners don't run again. Same
+	// lifecycle as taskEligible.
+	taskRestored bool
 	// skipInitialArm suppresses the spawn-time boundary arming ONCE — for an
 	// incident-retry respawn, whose watches transfer from the failed attempt
 	// instead of re-arming (SRD-079 FR-6: a repeated failure must not reset
@@ -309,6 +330,7 @@ func (t *track) record(state trackState) {
 		Phase:    nodePhaseFor(state),
 		NodeID:   node.ID(),
 		NodeName: node.Name(),
+		Details:  map[string]string{observability.AttrTrackID: t.ID()},
 	})
 }
 
@@ -671,7 +693,8 @@ func (t *track) holdTask(flow.Node) bool {
 // interrupting boundary canceling its host lands here), or the instance tore
 // down (stopAll). The one path that deliberately does NOT call it is
 // dehydration: there the hold is the wake source and must outlive the
-// goroutine.
+// goroutine — unless the instance is suspended, when nothing may wake it and
+// Resume re-arms the wait from the checkpoint.
 //
 // Idempotent — the flag is swapped, so a teardown following a delivery is a
 // no-op. A hold that outlives its wait is never benign: a stale deadline or
@@ -751,7 +774,7 @@ func (t *track) checkActivityWaitKind(
 	atConstruction bool,
 ) (bool, error) {
 	if _, ok := node.(interactor.HumanTask); ok {
-		return true, t.parkHumanTask(node)
+		return true, t.parkHumanTask(node, atConstruction)
 	}
 
 	if _, ok := node.(scopeHost); ok {
@@ -878,11 +901,12 @@ func (t *track) parkCallActivity(node flow.Node, atConstruction bool) error {
 // parkHumanTask parks the track on a UserTask (SRD-034): it mints a task id, marks
 // the track WaitForEvent (so run parks it on evtCh), and — when the loop is running
 // — emits evTaskWaiting so the loop registers the task and announces it to the
-// TaskDistributor. At construction the loop isn't draining events yet, so spawn
+// TaskDistributor. At construction the loop isn't draining events yet — or IS
+// the constructing goroutine, for a track the loop spawns itself — so spawn
 // reads t.taskID and registers it instead (mirroring evWaiting's construction
 // path). The UserTask registers NO hub waiter — completion arrives via Complete,
 // delivered to evtCh as a synthetic event, not fired through the hub.
-func (t *track) parkHumanTask(node flow.Node) error {
+func (t *track) parkHumanTask(node flow.Node, atConstruction bool) error {
 	t.m.Lock()
 	// A RESTORED track carries its recorded task id (SRD-071 FR-8): the task
 	// outlives the instance's residency in the distributor's inbox, so the id a
@@ -901,7 +925,7 @@ func (t *track) parkHumanTask(node flow.Node) error {
 	// simply stays resident.
 	t.held.Store(t.holdTask(node))
 
-	if t.instance.State() == Active {
+	if !atConstruction && t.instance.State() == Active {
 		t.instance.emit(trackEvent{
 			kind:   evTaskWaiting,
 			track:  t,
@@ -976,6 +1000,14 @@ func (t *track) updateState(newState trackState) {
 
 	t.m.Lock()
 	t.state = newState
+
+	// an ending track takes its last step's staged sends into the outbox
+	// in the same critical section: a capture sees it live before the
+	// send, or ended with the messages (outbox.go).
+	if newState == TrackEnded {
+		t.promoteStagedLocked()
+	}
+
 	t.m.Unlock()
 
 	// Per-node Executing entries are recorded in prepareNodeExecution so each
@@ -1085,6 +1117,15 @@ func (t *track) run(
 		default:
 		}
 
+		// An operator suspension holds the token here, before its next node
+		// executes (ADR-033 §2.6).
+		if !t.awaitResume(ctx) {
+			t.updateState(TrackCanceled)
+			t.lastErr = ctx.Err()
+
+			return
+		}
+
 		// Read the current step here, after the park: for an Event-Based gateway,
 		// deliver() (on THIS goroutine, just above) advanced the track onto the winning
 		// arm before returning Ready, so currentStep() observes the arm step, not the
@@ -1345,15 +1386,16 @@ func (t *track) executeNode(
 		return nil, err
 	}
 
-	if err := t.finalizeNodeExecution(ctx, step, f); err != nil {
+	if err := t.finalizeNodeExecution(ctx, step, f, nexts); err != nil {
 		return nil, err
 	}
 
 	return nexts, nil
 }
 
-// prepareNodeExecution marks the step started and runs the consumer role:
-// the node loads its inputs and properties into the execution frame.
+// prepareNodeExecution marks the step started, runs the node's start
+// listeners and then the consumer role: the node loads its inputs and
+// properties into the execution frame, seeing what the listeners put.
 func (t *track) prepareNodeExecution(
 	ctx context.Context,
 	step *stepInfo,
@@ -1363,6 +1405,11 @@ func (t *track) prepareNodeExecution(
 	step.state = StepStarted
 	t.record(TrackExecutingStep) // record this node visit (path + timing)
 
+	if err := t.notifyListeners(
+		ctx, listener.EventStart, step.node, "", f); err != nil {
+		return err
+	}
+
 	return t.loadIncomingData(ctx, step.node, f)
 }
 
@@ -1429,10 +1476,15 @@ func (t *track) announceGatewayDecision(
 // real and observable in the token history, not just a declared constant. The
 // stage projects TokenAlive (the token still sits on the node until the
 // outgoing flows are resolved).
+//
+// The node's end listeners, then the take listeners of each chosen flow, run
+// between the producer role and the commit: what they put lands in the same
+// batch, and a failing listener discards the node's results.
 func (t *track) finalizeNodeExecution(
 	ctx context.Context,
 	step *stepInfo,
 	f *scope.Frame,
+	nexts []*flow.SequenceFlow,
 ) error {
 	step.state = StepEnded
 	t.updateState(TrackProcessStepResults)
@@ -1441,6 +1493,18 @@ func (t *track) finalizeNodeExecution(
 		return err
 	}
 
+	if err := t.notifyListeners(
+		ctx, listener.EventEnd, step.node, "", f); err != nil {
+		return err
+	}
+
+	for _, sf := range nexts {
+		if err := t.notifyListeners(
+			ctx, listener.EventTake, step.node, sf.ID(), f); err != nil {
+			return err
+		}
+	}
+
 	// The changed-path set is the activity-boundary change signal — one
 	// DataChange fact per changed path (SRD-044 FR-4). A failed Commit
 	// returns a nil set, so the report is naturally a no-op then.
@@ -1522,6 +1586,9 @@ func (t *track) advance(succs []successor) error {
 	// from its own goroutine via ProcessEvent -> updateState -> record.
 	t.m.Lock()
 	t.steps = append(t.steps, &nextStep)
+	// the settled step's staged sends join the outbox with the move
+	// (outbox.go).
+	t.promoteStagedLocked()
 	t.m.Unlock()
 
 	// The token continues on this track to nextStep's node. newTrack only
@@ -1591,7 +1658,15 @@ func (t *track) unregisterEvent(n flow.Node) error {
 			continue
 		}
 
-		if err := t.instance.UnregisterEvent(t, eDef.ID()); err != nil {
+		// the processor a Message was registered under is the Instance
+		// (registerEvent, SRD-027 FR-8); unregistering the track would miss
+		// and leave the consumed catch's waiter claiming later messages.
+		proc := eventproc.EventProcessor(t)
+		if eDef.Type() == flow.TriggerMessage {
+			proc = t.instance
+		}
+
+		if err := t.instance.UnregisterEvent(proc, eDef.ID()); err != nil {
 			return errs.New(
 				errs.M("failed to unregister event"),
 				errs.C(errorClass, errs.OperationFailed),
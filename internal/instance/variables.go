@@ -0,0 +1,188 @@
+package instance
+
+import (
+	"context"
+	"strings"
+
+	"github.com/dr-dobermann/gobpm/internal/scope"
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/model/data"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+)
+
+// operatorFrameID names the track and node of the frame an operator's
+// variable change commits through — it belongs to no execution.
+const operatorFrameID = "operator"
+
+// varRequest is an operator's live variable change crossing into the loop:
+// the data dd to set and the names to delete at the container scope path.
+type varRequest struct {
+	resp    chan error
+	path    string
+	dd      []data.Data
+	deleted []string
+}
+
+// SubmitVariables submits an operator's variable change — dd set and deleted
+// removed at the container scope path — to the instance's loop and waits for
+// its verdict. The boolean reports DELIVERY, exactly as SubmitSuspension does:
+// false means the loop has exited and the engine must rebuild the instance to
+// apply the change.
+func (inst *Instance) SubmitVariables(
+	ctx context.Context, path string, dd []data.Data, deleted []string,
+) (bool, error) {
+	req := varRequest{
+		path:    path,
+		dd:      dd,
+		deleted: deleted,
+		resp:    make(chan error, 1),
+	}
+
+	select {
+	case inst.varReq <- req:
+
+	case <-inst.loopDone:
+		return false, nil
+
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+
+	select {
+	case err := <-req.resp:
+		return true, err
+
+	case <-ctx.Done():
+		return true, ctx.Err()
+	}
+}
+
+// WithPendingVariables hands a rebuild the operator's variable change that
+// caused it — the WithPendingSuspension shape: the fresh loop applies it
+// BEFORE deciding whether to release again. The verdict lands on resp.
+func WithPendingVariables(
+	path string, dd []data.Data, deleted []string, resp chan error,
+) Option {
+	return func(cfg *newConfig) {
+		cfg.pendingVariables = &varRequest{
+			path:    path,
+			dd:      dd,
+			deleted: deleted,
+			resp:    resp,
+		}
+	}
+}
+
+// handleVarRequest applies one operator variable change on the loop
+// goroutine, so the commit, its facts and the conditional sweep stay
+// single-writer. A change to a released-and-rebuilt instance is followed by
+// the usual park decision.
+func (ls *loopState) handleVarRequest(ctx context.Context, req varRequest) {
+	err := ls.setVariables(ctx, req)
+
+	if req.resp != nil {
+		req.resp <- err
+	}
+
+	ls.maybeDehydrate(ctx)
+}
+
+// setVariables commits an operator's change through a frame at the addressed
+// scope — the path node outputs take — then reports the changed paths as
+// DataChange facts, re-evaluates the armed conditionals against them and
+// persists the result. A terminating instance refuses.
+func (ls *loopState) setVariables(ctx context.Context, req varRequest) error {
+	inst := ls.inst
+
+	if ls.stopping {
+		return errs.New(
+			errs.M("can't change the variables of instance %q: "+
+				"it is terminating", inst.ID()),
+			errs.C(errorClass, errs.InvalidState),
+			errs.D(observability.AttrInstanceID, inst.ID()))
+	}
+
+	at, err := inst.sc.scopeAt(req.path)
+	if err != nil {
+		return err
+	}
+
+	f, err := inst.sc.openFrameAt(operatorFrameID, operatorFrameID, at)
+	if err != nil {
+		return err
+	}
+	defer f.Discard()
+
+	if err := checkVariableTypes(inst, f, req.dd); err != nil {
+		return err
+	}
+
+	if err := f.Put(req.dd...); err != nil {
+		return err
+	}
+
+	if err := f.Delete(req.deleted...); err != nil {
+		return err
+	}
+
+	changes, err := f.Commit()
+	if err != nil {
+		return err
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+
+	inst.reportDataChanges("", "", changes)
+
+	if inst.s.HasConditionals {
+		ls.sweepConditionals(ctx, changes)
+	}
+
+	ls.checkpointNow(ctx)
+
+	return nil
+}
+
+// checkVariableTypes refuses a datum whose value type differs from the one
+// its name already resolves to at the frame's scope — an operator's fix keeps
+// the variable's type, as a start variable keeps its declaration's. A name
+// the scope doesn't resolve yet is free.
+func checkVariableTypes(inst *Instance, f *scope.Frame, dd []data.Data) error {
+	for _, d := range dd {
+		held, err := f.GetData(d.Name())
+		if err != nil || held.Value() == nil {
+			continue
+		}
+
+		if want, got := held.Value().Type(), d.Value().Type(); want != got {
+			return errs.New(
+				errs.M("variable %q type mismatch: want %q, got %q",
+					d.Name(), want, got),
+				errs.C(errorClass, errs.TypeCastingError),
+				errs.D(observability.AttrInstanceID, inst.ID()),
+				errs.D(observability.AttrDataName, d.Name()))
+		}
+	}
+
+	return nil
+}
+
+// scopeAt resolves an operator's container scope address: empty is the
+// instance's root scope, an absolute path ("/process/sp-node") is taken as is,
+// and a relative one ("sp-node") is appended to the root.
+func (sc *instanceScope) scopeAt(path string) (scope.DataPath, error) {
+	path = strings.TrimSpace(path)
+
+	switch {
+	case path == "":
+		return sc.root, nil
+
+	case strings.HasPrefix(path, scope.PathSeparator):
+		return scope.NewDataPath(path)
+
+	default:
+		return sc.root.Append(path)
+	}
+}
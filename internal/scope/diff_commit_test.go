@@ -160,3 +160,70 @@ func TestFrameCommitPropagatesDiff(t *testing.T) {
 	require.Equal(t, []data.Change{
 		{Path: "order.total", Type: data.ValueUpdated}}, changes)
 }
+
+// TestFrameDeleteCommitsDiff verifies a frame's deletions commit with its
+// puts as ValueDeleted changes, hide the name from the frame's reads, and
+// refuse a name the frame's own scope doesn't hold.
+func TestFrameDeleteCommitsDiff(t *testing.T) {
+	root := mustPath(t, "/proc")
+	child := mustPath(t, "/proc/sp-1")
+
+	p, err := New(root, nil)
+	require.NoError(t, err)
+	require.NoError(t, p.OpenScope(child))
+
+	_, err = p.Commit(root, orderData(t, 100, 50), testData(t, "note", "x"))
+	require.NoError(t, err)
+
+	f, err := NewFrame("track-1", "node-1", root, p)
+	require.NoError(t, err)
+
+	require.NoError(t, f.Delete("order"))
+	require.NoError(t, f.Put(testData(t, "status", "fixed")))
+
+	_, err = f.GetData("order")
+	require.Error(t, err, "a deleted name no longer resolves")
+
+	changes, err := f.Commit()
+	require.NoError(t, err)
+	require.ElementsMatch(t, []data.Change{
+		{Path: "status", Type: data.ValueAdded},
+		{Path: "order", Type: data.ValueDeleted},
+	}, changes)
+
+	_, err = p.GetData(root, "order")
+	require.Error(t, err)
+
+	t.Run("a put cancels the delete", func(t *testing.T) {
+		f, err := NewFrame("track-1", "node-2", root, p)
+		require.NoError(t, err)
+
+		require.NoError(t, f.Delete("note"))
+		require.NoError(t, f.Put(testData(t, "note", "y")))
+
+		changes, err := f.Commit()
+		require.NoError(t, err)
+		require.Equal(t, []data.Change{
+			{Path: "note", Type: data.ValueUpdated}}, changes)
+	})
+
+	t.Run("only the frame's own scope", func(t *testing.T) {
+		f, err := NewFrame("track-1", "node-3", child, p)
+		require.NoError(t, err)
+
+		require.NoError(t, f.Delete("note"))
+
+		_, err = f.Commit()
+		require.Error(t, err, "note belongs to the enclosing scope")
+
+		_, err = p.GetData(root, "note")
+		require.NoError(t, err)
+	})
+
+	t.Run("empty name rejected", func(t *testing.T) {
+		f, err := NewFrame("track-1", "node-4", root, p)
+		require.NoError(t, err)
+
+		require.Error(t, f.Delete(" "))
+	})
+}
@@ -2,6 +2,7 @@ package scope
 
 import (
 	"context"
+	"sort"
 	"strings"
 
 	"github.com/dr-dobermann/gobpm/pkg/datastore"
@@ -31,11 +32,20 @@ type Frame struct {
 	outputs   map[string]*data.Parameter
 	props     map[string]data.Data
 	puts      map[string]data.Data
+	deletes   map[string]struct{}
 	at        DataPath
 	trackID   string
 	nodeID    string
 	movements []DataMovement
-	state     frameState
+	// versions holds the Data Store versions LoadData read, keyed by
+	// storeKey (exec.StoreVersions).
+	versions map[storeKey]int64
+	state    frameState
+}
+
+// storeKey addresses one datum of one engine Data Store.
+type storeKey struct {
+	ref, name string
 }
 
 // DataMovement records one value moving through a task's data associations for
@@ -64,6 +74,24 @@ func (f *Frame) RecordDataMovement(engineStore, write bool, name, storeRef strin
 	})
 }
 
+// NoteStoreVersion records the version of the datum the node read from the
+// Data Store storeRef under name (exec.StoreVersions).
+func (f *Frame) NoteStoreVersion(storeRef, name string, version int64) {
+	if f.versions == nil {
+		f.versions = map[storeKey]int64{}
+	}
+
+	f.versions[storeKey{ref: storeRef, name: name}] = version
+}
+
+// StoreVersion returns the version noted for storeRef and name; the bool is
+// false when the node didn't read it (exec.StoreVersions).
+func (f *Frame) StoreVersion(storeRef, name string) (int64, bool) {
+	v, ok := f.versions[storeKey{ref: storeRef, name: name}]
+
+	return v, ok
+}
+
 // DataMovements returns the Data Object / Data Store movements recorded on the
 // frame during its data phases (SRD-063 / SRD-068), in occurrence order.
 func (f *Frame) DataMovements() []DataMovement {
@@ -124,6 +152,7 @@ func NewFrame(
 		outputs: map[string]*data.Parameter{},
 		props:   map[string]data.Data{},
 		puts:    map[string]data.Data{},
+		deletes: map[string]struct{}{},
 		at:      at,
 		trackID: trackID,
 		nodeID:  nodeID,
@@ -197,7 +226,8 @@ func (f *Frame) Outputs() []*data.Parameter {
 
 // Put stores node-produced values in the frame. Puts are committed to the
 // container scope together with the outputs. A repeated name overwrites the
-// previous value (last write wins within one execution).
+// previous value (last write wins within one execution), and a Put cancels an
+// earlier Delete of the same name.
 func (f *Frame) Put(dd ...data.Data) error {
 	if err := f.checkOpen("Put"); err != nil {
 		return err
@@ -210,6 +240,36 @@ func (f *Frame) Put(dd ...data.Data) error {
 
 	for i, d := range dd {
 		f.puts[names[i]] = d
+		delete(f.deletes, names[i])
+	}
+
+	return nil
+}
+
+// Delete marks the data names for removal from the frame's container scope;
+// the removal is committed together with the outputs and puts, one
+// ValueDeleted change per name. Each name must be held by the container scope
+// itself — the commit fails otherwise. A Delete cancels an earlier Put of the
+// same name, and a deleted name no longer resolves through the frame.
+func (f *Frame) Delete(names ...string) error {
+	if err := f.checkOpen("Delete"); err != nil {
+		return err
+	}
+
+	nn := make([]string, len(names))
+
+	for i, name := range names {
+		nn[i] = strings.TrimSpace(name)
+		if nn[i] == "" {
+			return errs.New(
+				errs.M("Delete: an empty data name isn't allowed (index %d)", i),
+				errs.C(errorClass, errs.EmptyNotAllowed))
+		}
+	}
+
+	for _, name := range nn {
+		f.deletes[name] = struct{}{}
+		delete(f.puts, name)
 	}
 
 	return nil
@@ -254,6 +314,12 @@ func (f *Frame) resolveHead(name string) (data.Data, error) {
 		return d, nil
 	}
 
+	if _, ok := f.deletes[name]; ok {
+		return nil, errs.New(
+			errs.M("GetData: data %q is deleted by the frame", name),
+			errs.C(errorClass, errs.ObjectNotFound))
+	}
+
 	return f.plane.GetData(f.at, name)
 }
 
@@ -296,8 +362,8 @@ func (f *Frame) GetDataByID(id string) (data.Data, error) {
 	return f.plane.GetDataByID(f.at, id)
 }
 
-// Commit flushes the frame's outputs and puts into the container scope as
-// one atomic Scope batch and seals the frame. A frame commits at most once
+// Commit flushes the frame's outputs, puts and deletions into the container
+// scope as one atomic Scope batch and seals the frame. A frame commits at most once
 // and never after Discard (ADR-010 §2.3). It returns the scope's committed
 // changed-path set (ADR-011 v.6 §2.9.4, SRD-044) — the activity-boundary
 // change signal its track caller turns into DataChange facts.
@@ -316,7 +382,14 @@ func (f *Frame) Commit() ([]data.Change, error) {
 		batch = append(batch, p)
 	}
 
-	changes, err := f.plane.Commit(f.at, batch...)
+	deleted := make([]string, 0, len(f.deletes))
+	for name := range f.deletes {
+		deleted = append(deleted, name)
+	}
+
+	sort.Strings(deleted)
+
+	changes, err := f.plane.commit("Commit", f.at, batch, deleted)
 	if err != nil {
 		return nil, errs.New(
 			errs.M("Commit: frame of node %q on track %q failed to commit",
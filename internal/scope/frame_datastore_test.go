@@ -48,3 +48,31 @@ func TestFrameDataMovements(t *testing.T) {
 		{Name: "total", StoreRef: "kv", EngineStore: true, Write: true},
 	}, f.DataMovements())
 }
+
+// TestFrameStoreVersions covers the Data Store version notes on a frame
+// (exec.StoreVersions): none until noted, then per store and name — a version
+// 0 read (the datum was absent) is a note like any other.
+func TestFrameStoreVersions(t *testing.T) {
+	pl, err := New(RootDataPath, nil)
+	require.NoError(t, err)
+
+	f, err := NewFrame("track", "node", pl.Root(), pl)
+	require.NoError(t, err)
+
+	_, ok := f.StoreVersion("kv", "total")
+	require.False(t, ok)
+
+	f.NoteStoreVersion("kv", "total", 3)
+	f.NoteStoreVersion("kv", "fresh", 0)
+
+	v, ok := f.StoreVersion("kv", "total")
+	require.True(t, ok)
+	require.Equal(t, int64(3), v)
+
+	v, ok = f.StoreVersion("kv", "fresh")
+	require.True(t, ok)
+	require.Zero(t, v)
+
+	_, ok = f.StoreVersion("other", "total")
+	require.False(t, ok, "the notes are per store")
+}
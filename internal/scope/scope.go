@@ -1,6 +1,7 @@
 package scope
 
 import (
+	"slices"
 	"sort"
 	"strings"
 	"sync"
@@ -447,33 +448,61 @@ func (p *Scope) dataByIDLocked(from DataPath, id string) (data.Data, error) {
 // one Value_Added at its root. An unchanged re-commit contributes nothing; the
 // scope produces the set, its consumers decide what it means.
 func (p *Scope) Commit(at DataPath, dd ...data.Data) ([]data.Change, error) {
-	if err := p.checkContained("Commit", at); err != nil {
+	return p.commit("Commit", at, dd, nil)
+}
+
+// commit is Commit with deletions: the names in deleted are removed from the
+// container scope at in the same critical section that stores dd, each one
+// diffed against nothing (a ValueDeleted at its root). A deleted name must be
+// held by the scope at itself — a datum visible only through the walk-up
+// belongs to an enclosing scope — and must not also be stored by dd.
+func (p *Scope) commit(
+	op string, at DataPath, dd []data.Data, deleted []string,
+) ([]data.Change, error) {
+	if err := p.checkContained(op, at); err != nil {
 		return nil, err
 	}
 
-	if err := p.checkWritable("Commit", at); err != nil {
+	if err := p.checkWritable(op, at); err != nil {
 		return nil, err
 	}
 
-	names, err := batchNames("Commit", dd)
+	names, err := batchNames(op, dd)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(dd) == 0 {
+	if len(dd) == 0 && len(deleted) == 0 {
 		return nil, nil
 	}
 
+	for _, name := range deleted {
+		if slices.Contains(names, name) {
+			return nil, errs.New(
+				errs.M("%s: %q is both stored and deleted", op, name),
+				errs.C(errorClass, errs.InvalidParameter))
+		}
+	}
+
 	p.m.Lock()
 	defer p.m.Unlock()
 
 	vv, ok := p.scopes[at]
 	if !ok {
 		return nil, errs.New(
-			errs.M("Commit: container scope %q isn't open", at),
+			errs.M("%s: container scope %q isn't open", op, at),
 			errs.C(errorClass, errs.ObjectNotFound))
 	}
 
+	for _, name := range deleted {
+		if _, ok := vv[name]; !ok {
+			return nil, errs.New(
+				errs.M("%s: container scope %q holds no data %q",
+					op, at, name),
+				errs.C(errorClass, errs.ObjectNotFound))
+		}
+	}
+
 	var changes []data.Change
 
 	for i, d := range dd {
@@ -488,6 +517,13 @@ func (p *Scope) Commit(at DataPath, dd ...data.Data) ([]data.Change, error) {
 			data.DiffValues(names[i], prior, d.Value())...)
 	}
 
+	for _, name := range deleted {
+		changes = append(changes,
+			data.DiffValues(name, vv[name].Value(), nil)...)
+
+		delete(vv, name)
+	}
+
 	return changes, nil
 }
 
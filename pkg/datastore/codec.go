@@ -0,0 +1,43 @@
+package datastore
+
+import (
+	"context"
+
+	"github.com/dr-dobermann/gobpm/internal/instance/checkpoint"
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/model/data"
+)
+
+const errorClass = "DATASTORE"
+
+// EncodeDatum encodes d in the schema-stable form a durable DataStore
+// persists: the checkpoint value codec (ADR-033, SRD-070) — tagged JSON
+// that keeps the datum's name, its data-state and its value's exact
+// typing. where names the store in error context; a payload the codec
+// can't carry is an error, never a silent drop.
+func EncodeDatum(ctx context.Context, where string, d data.Data) ([]byte, error) {
+	if d == nil {
+		return nil, errs.New(
+			errs.M("EncodeDatum: a nil datum isn't allowed"),
+			errs.C(errorClass, errs.EmptyNotAllowed),
+			errs.D("store", where))
+	}
+
+	return checkpoint.EncodeData(ctx, where, []data.Data{d})
+}
+
+// DecodeDatum rebuilds the datum EncodeDatum encoded.
+func DecodeDatum(ctx context.Context, raw []byte) (data.Data, error) {
+	dd, err := checkpoint.DecodeData(ctx, raw)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(dd) != 1 {
+		return nil, errs.New(
+			errs.M("DecodeDatum: the payload holds %d data, want one", len(dd)),
+			errs.C(errorClass, errs.InvalidObject))
+	}
+
+	return dd[0], nil
+}
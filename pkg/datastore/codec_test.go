@@ -0,0 +1,56 @@
+package datastore_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gobpm/pkg/datastore"
+	"github.com/dr-dobermann/gobpm/pkg/datastore/datastoretest"
+	"github.com/dr-dobermann/gobpm/pkg/model/data"
+	"github.com/dr-dobermann/gobpm/pkg/model/data/values"
+)
+
+func TestDatumCodec(t *testing.T) {
+	require.NoError(t, data.CreateDefaultStates())
+
+	ctx := context.Background()
+
+	t.Run("round trip", func(t *testing.T) {
+		rec, err := values.NewRecord(
+			values.F("sku", values.NewVariable("A-1")),
+			values.F("qty", values.NewVariable(3)))
+		require.NoError(t, err)
+
+		raw, err := datastore.EncodeDatum(ctx, "orders",
+			datastoretest.Datum(t, "line", rec))
+		require.NoError(t, err)
+
+		d, err := datastore.DecodeDatum(ctx, raw)
+		require.NoError(t, err)
+		require.Equal(t, "line", d.Name())
+		require.Equal(t, data.ReadyDataState.Name(), d.State().Name())
+
+		r, ok := d.Value().(data.Record)
+		require.True(t, ok, "a record decodes as a record")
+		require.Equal(t, []string{"sku", "qty"}, r.Keys())
+
+		qty, err := r.Field(ctx, "qty")
+		require.NoError(t, err)
+		require.Equal(t, 3, qty.Get(ctx))
+	})
+
+	t.Run("nil datum rejected", func(t *testing.T) {
+		_, err := datastore.EncodeDatum(ctx, "orders", nil)
+		require.Error(t, err)
+	})
+
+	t.Run("a malformed payload is refused", func(t *testing.T) {
+		_, err := datastore.DecodeDatum(ctx, []byte(`[]`))
+		require.Error(t, err)
+
+		_, err = datastore.DecodeDatum(ctx, []byte(`not json`))
+		require.Error(t, err)
+	})
+}
@@ -1,8 +1,9 @@
 // Package datastore defines the engine-global Data Store port (BPMN §10.4.1,
 // ADR-030 §2.5): item-aware data that outlives the Process instance and is
 // shared across instances within the running engine. The default in-memory
-// adapter lives in the memstore subpackage; durability is a swappable adapter
-// behind this interface (the future Persistence & State workstream).
+// adapter lives in the memstore subpackage; the durable ones live in the
+// postgres and sqlite adapter modules and persist each datum in the
+// EncodeDatum form. Every implementation proves the datastoretest suite.
 package datastore
 
 import (
@@ -31,6 +32,27 @@ type DataStore interface {
 	IsUnlimited() bool
 }
 
+// Versioned is the optional DataStore capability behind conditional writes:
+// every stored datum carries a version the store bumps on each write, and PutIf
+// writes only over the version the caller read. A task that reads a key of a
+// Versioned store and writes the same key back does so with PutIf, so two
+// instances updating one counter can't silently lose an update — the later one
+// fails with errs.ConcurrentUpdate, and an incident retry re-reads and re-runs
+// the task. A store without it stays last-writer-wins.
+type Versioned interface {
+	// GetVersioned returns the datum stored under name and its version; the
+	// bool is false (and the version 0) when none exists.
+	GetVersioned(ctx context.Context, name string) (data.Data, int64, bool, error)
+
+	// PutIf stores d under name iff the stored version equals version (0: iff
+	// none exists) and returns the new version. A mismatch MUST fail with an
+	// errs.ConcurrentUpdate-classified error and store nothing.
+	PutIf(ctx context.Context, name string, d data.Data, version int64) (int64, error)
+
+	// Delete removes the datum under name (a no-op if it is absent).
+	Delete(ctx context.Context, name string) error
+}
+
 // Registry resolves an engine-global DataStore by its reference id (a BPMN
 // DataStore is a Definitions-level root element; a DataStoreReference names one
 // by dataStoreRef, and a process may reference many, §10.4.1). Each store has
@@ -0,0 +1,426 @@
+// Package datastoretest publishes the datastore.DataStore conformance suite:
+// the in-memory default and every durable adapter prove the same contract by
+// calling Conformance from a one-line test. The suite covers the get/put round
+// trip, replacement, misses, the input checks and value fidelity across the
+// codec's scalar and composite shapes; a store that implements
+// datastore.Versioned also proves the compare-and-swap contract, including
+// under concurrent writers (the Versioned subtests skip for a store without
+// it). Capacity is adapter policy (advisory in memstore, enforced by the
+// durable adapters), so each adapter tests its own.
+package datastoretest
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dr-dobermann/gobpm/pkg/datastore"
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/model/data"
+	"github.com/dr-dobermann/gobpm/pkg/model/data/values"
+)
+
+// Factory builds a fresh, empty, unlimited DataStore under test. It is called
+// once per subtest, so implementations must return isolated stores (for a
+// shared backend: a distinct store name or a wiped namespace).
+type Factory func(t *testing.T) datastore.DataStore
+
+// Conformance runs the full DataStore contract against factory-built stores.
+// Adapter tests are one-liners:
+//
+//	func TestConformance(t *testing.T) {
+//		datastoretest.Conformance(t, func(t *testing.T) datastore.DataStore {
+//			return memstore.New()
+//		})
+//	}
+func Conformance(t *testing.T, factory Factory) {
+	t.Helper()
+
+	if factory == nil {
+		t.Fatal("Conformance: a nil Factory isn't allowed")
+	}
+
+	for name, test := range conformanceTests {
+		t.Run(name, func(t *testing.T) { test(t, factory(t)) })
+	}
+}
+
+// conformanceTests is the contract as a declarative table.
+var conformanceTests = map[string]func(*testing.T, datastore.DataStore){
+	"PutGet":            testPutGet,
+	"Missing":           testMissing,
+	"PutReplaces":       testPutReplaces,
+	"KeysAreIsolated":   testKeysAreIsolated,
+	"EmptyNameRejected": testEmptyNameRejected,
+	"NilDatumRejected":  testNilDatumRejected,
+	"ValueFidelity":     testValueFidelity,
+	"Unlimited":         testUnlimited,
+	"VersionedGet":      testVersionedGet,
+	"PutIfCreates":      testPutIfCreates,
+	"PutIfConflict":     testPutIfConflict,
+	"PutBumpsVersion":   testPutBumpsVersion,
+	"Delete":            testDelete,
+	"PutIfConcurrent":   testPutIfConcurrent,
+}
+
+// Datum builds a Ready parameter named name holding v — the shape the engine
+// writes into a store. It creates the default data states when missing.
+func Datum(t *testing.T, name string, v data.Value) data.Data {
+	t.Helper()
+
+	if err := data.CreateDefaultStates(); err != nil {
+		t.Fatalf("default data states: %v", err)
+	}
+
+	item, err := data.NewItemDefinition(v)
+	if err != nil {
+		t.Fatalf("datum %s: %v", name, err)
+	}
+
+	iae, err := data.NewItemAwareElement(item, data.ReadyDataState)
+	if err != nil {
+		t.Fatalf("datum %s: %v", name, err)
+	}
+
+	p, err := data.NewParameter(name, iae)
+	if err != nil {
+		t.Fatalf("datum %s: %v", name, err)
+	}
+
+	return p
+}
+
+func testPutGet(t *testing.T, s datastore.DataStore) {
+	ctx := context.Background()
+
+	mustPut(t, s, "total", Datum(t, "total", values.NewVariable(42)))
+
+	d := mustGet(t, s, "total")
+	if got := d.Value().Get(ctx); got != 42 {
+		t.Fatalf("Get = %v (%T), want 42", got, got)
+	}
+
+	if d.Name() != "total" {
+		t.Fatalf("datum name = %q, want total", d.Name())
+	}
+
+	if d.State().Name() != data.ReadyDataState.Name() {
+		t.Fatalf("datum state = %q, want %q",
+			d.State().Name(), data.ReadyDataState.Name())
+	}
+}
+
+func testMissing(t *testing.T, s datastore.DataStore) {
+	d, ok, err := s.Get(context.Background(), "absent")
+	if err != nil {
+		t.Fatalf("Get of a missing name: %v", err)
+	}
+
+	if ok || d != nil {
+		t.Fatalf("Get of a missing name = (%v, %t), want (nil, false)", d, ok)
+	}
+}
+
+func testPutReplaces(t *testing.T, s datastore.DataStore) {
+	mustPut(t, s, "k", Datum(t, "k", values.NewVariable(1)))
+	mustPut(t, s, "k", Datum(t, "k", values.NewVariable(2)))
+
+	if got := mustGet(t, s, "k").Value().Get(context.Background()); got != 2 {
+		t.Fatalf("Get after a replace = %v, want 2", got)
+	}
+}
+
+func testKeysAreIsolated(t *testing.T, s datastore.DataStore) {
+	mustPut(t, s, "a", Datum(t, "a", values.NewVariable("alpha")))
+	mustPut(t, s, "b", Datum(t, "b", values.NewVariable("beta")))
+
+	ctx := context.Background()
+	if got := mustGet(t, s, "a").Value().Get(ctx); got != "alpha" {
+		t.Fatalf("Get(a) = %v, want alpha", got)
+	}
+
+	if got := mustGet(t, s, "b").Value().Get(ctx); got != "beta" {
+		t.Fatalf("Get(b) = %v, want beta", got)
+	}
+}
+
+func testEmptyNameRejected(t *testing.T, s datastore.DataStore) {
+	ctx := context.Background()
+
+	wantClass(t, s.Put(ctx, "", Datum(t, "x", values.NewVariable(1))),
+		errs.EmptyNotAllowed, "Put")
+
+	_, _, err := s.Get(ctx, "")
+	wantClass(t, err, errs.EmptyNotAllowed, "Get")
+}
+
+func testNilDatumRejected(t *testing.T, s datastore.DataStore) {
+	wantClass(t, s.Put(context.Background(), "k", nil),
+		errs.EmptyNotAllowed, "Put")
+}
+
+func testValueFidelity(t *testing.T, s datastore.DataStore) {
+	ctx := context.Background()
+	at := time.Date(2026, time.August, 4, 12, 0, 0, 123456789, time.UTC)
+
+	for name, v := range map[string]data.Value{
+		"int64":   values.NewVariable(int64(-1) << 62),
+		"uint8":   values.NewVariable(uint8(255)),
+		"float64": values.NewVariable(0.1),
+		"bool":    values.NewVariable(true),
+		"string":  values.NewVariable("hello, world"),
+		"time":    values.NewVariable(at),
+		"array":   values.NewArray(1, 2, 3),
+	} {
+		mustPut(t, s, name, Datum(t, name, v))
+
+		got := mustGet(t, s, name).Value().Get(ctx)
+		if want := v.Get(ctx); !reflect.DeepEqual(got, want) {
+			t.Fatalf("%s didn't round-trip: got %#v, want %#v", name, got, want)
+		}
+	}
+}
+
+func testUnlimited(t *testing.T, s datastore.DataStore) {
+	if !s.IsUnlimited() {
+		t.Fatalf("a factory store must be unlimited, Capacity() = %d",
+			s.Capacity())
+	}
+}
+
+// versioned returns s as a datastore.Versioned, skipping the subtest for a
+// store without the capability.
+func versioned(t *testing.T, s datastore.DataStore) datastore.Versioned {
+	t.Helper()
+
+	v, ok := s.(datastore.Versioned)
+	if !ok {
+		t.Skip("the store isn't datastore.Versioned")
+	}
+
+	return v
+}
+
+func testVersionedGet(t *testing.T, s datastore.DataStore) {
+	v := versioned(t, s)
+	ctx := context.Background()
+
+	d, ver, ok, err := v.GetVersioned(ctx, "k")
+	if err != nil || ok || d != nil || ver != 0 {
+		t.Fatalf("GetVersioned of a missing name = (%v, %d, %t, %v),"+
+			" want (nil, 0, false, nil)", d, ver, ok, err)
+	}
+
+	mustPut(t, s, "k", Datum(t, "k", values.NewVariable(7)))
+
+	d, ver, ok, err = v.GetVersioned(ctx, "k")
+	if err != nil || !ok {
+		t.Fatalf("GetVersioned(k) = (%t, %v), want a hit", ok, err)
+	}
+
+	if ver <= 0 {
+		t.Fatalf("a stored datum's version = %d, want positive", ver)
+	}
+
+	if got := d.Value().Get(ctx); got != 7 {
+		t.Fatalf("GetVersioned(k) = %v, want 7", got)
+	}
+
+	_, _, _, err = v.GetVersioned(ctx, "")
+	wantClass(t, err, errs.EmptyNotAllowed, "GetVersioned")
+}
+
+func testPutIfCreates(t *testing.T, s datastore.DataStore) {
+	v := versioned(t, s)
+	ctx := context.Background()
+
+	ver, err := v.PutIf(ctx, "k", Datum(t, "k", values.NewVariable(1)), 0)
+	if err != nil {
+		t.Fatalf("PutIf(0) of a missing name: %v", err)
+	}
+
+	if _, got, _, _ := v.GetVersioned(ctx, "k"); got != ver {
+		t.Fatalf("PutIf returned version %d, the store holds %d", ver, got)
+	}
+
+	_, err = v.PutIf(ctx, "k", Datum(t, "k", values.NewVariable(2)), 0)
+	wantClass(t, err, errs.ConcurrentUpdate, "PutIf(0) over an existing name")
+
+	wantClass(t, func() error {
+		_, err := v.PutIf(ctx, "k", nil, ver)
+
+		return err
+	}(), errs.EmptyNotAllowed, "PutIf of a nil datum")
+}
+
+func testPutIfConflict(t *testing.T, s datastore.DataStore) {
+	v := versioned(t, s)
+	ctx := context.Background()
+
+	v1, err := v.PutIf(ctx, "k", Datum(t, "k", values.NewVariable(1)), 0)
+	if err != nil {
+		t.Fatalf("PutIf(0): %v", err)
+	}
+
+	v2, err := v.PutIf(ctx, "k", Datum(t, "k", values.NewVariable(2)), v1)
+	if err != nil {
+		t.Fatalf("PutIf over the current version: %v", err)
+	}
+
+	if v2 <= v1 {
+		t.Fatalf("PutIf must raise the version: %d after %d", v2, v1)
+	}
+
+	_, err = v.PutIf(ctx, "k", Datum(t, "k", values.NewVariable(3)), v1)
+	wantClass(t, err, errs.ConcurrentUpdate, "PutIf over a stale version")
+
+	if got := mustGet(t, s, "k").Value().Get(ctx); got != 2 {
+		t.Fatalf("a refused PutIf changed the value to %v", got)
+	}
+}
+
+func testPutBumpsVersion(t *testing.T, s datastore.DataStore) {
+	v := versioned(t, s)
+	ctx := context.Background()
+
+	v1, err := v.PutIf(ctx, "k", Datum(t, "k", values.NewVariable(1)), 0)
+	if err != nil {
+		t.Fatalf("PutIf(0): %v", err)
+	}
+
+	// a blind Put is a write like any other: a reader of v1 is now stale.
+	mustPut(t, s, "k", Datum(t, "k", values.NewVariable(2)))
+
+	_, err = v.PutIf(ctx, "k", Datum(t, "k", values.NewVariable(3)), v1)
+	wantClass(t, err, errs.ConcurrentUpdate, "PutIf after a Put")
+}
+
+func testDelete(t *testing.T, s datastore.DataStore) {
+	v := versioned(t, s)
+	ctx := context.Background()
+
+	mustPut(t, s, "k", Datum(t, "k", values.NewVariable(1)))
+
+	if err := v.Delete(ctx, "k"); err != nil {
+		t.Fatalf("Delete(k): %v", err)
+	}
+
+	if _, ok, _ := s.Get(ctx, "k"); ok {
+		t.Fatal("a deleted name still reads")
+	}
+
+	if err := v.Delete(ctx, "k"); err != nil {
+		t.Fatalf("Delete of a missing name must be a no-op: %v", err)
+	}
+
+	if _, err := v.PutIf(ctx, "k",
+		Datum(t, "k", values.NewVariable(2)), 0); err != nil {
+		t.Fatalf("PutIf(0) after a Delete: %v", err)
+	}
+
+	wantClass(t, v.Delete(ctx, ""), errs.EmptyNotAllowed, "Delete")
+}
+
+// testPutIfConcurrent runs read-increment-PutIf loops from many goroutines
+// against one counter: with compare-and-swap no increment is lost.
+func testPutIfConcurrent(t *testing.T, s datastore.DataStore) {
+	const writers, increments = 8, 5
+
+	v := versioned(t, s)
+	ctx := context.Background()
+
+	if _, err := v.PutIf(ctx, "counter",
+		Datum(t, "counter", values.NewVariable(0)), 0); err != nil {
+		t.Fatalf("PutIf(0): %v", err)
+	}
+
+	var (
+		wg   sync.WaitGroup
+		errc = make(chan error, writers)
+	)
+
+	for range writers {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for done := 0; done < increments; {
+				d, ver, _, err := v.GetVersioned(ctx, "counter")
+				if err != nil {
+					errc <- err
+
+					return
+				}
+
+				n, _ := d.Value().Get(ctx).(int)
+
+				_, err = v.PutIf(ctx, "counter",
+					Datum(t, "counter", values.NewVariable(n+1)), ver)
+
+				var ae *errs.ApplicationError
+
+				switch {
+				case err == nil:
+					done++
+
+				case errors.As(err, &ae) && ae.HasClass(errs.ConcurrentUpdate):
+					// lost the race: re-read and retry.
+
+				default:
+					errc <- err
+
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(errc)
+
+	for err := range errc {
+		t.Fatalf("a concurrent writer failed: %v", err)
+	}
+
+	if got := mustGet(t, s, "counter").Value().Get(ctx); got != writers*increments {
+		t.Fatalf("counter = %v, want %d: an increment was lost",
+			got, writers*increments)
+	}
+}
+
+func mustPut(t *testing.T, s datastore.DataStore, name string, d data.Data) {
+	t.Helper()
+
+	if err := s.Put(context.Background(), name, d); err != nil {
+		t.Fatalf("Put(%s): %v", name, err)
+	}
+}
+
+func mustGet(t *testing.T, s datastore.DataStore, name string) data.Data {
+	t.Helper()
+
+	d, ok, err := s.Get(context.Background(), name)
+	if err != nil {
+		t.Fatalf("Get(%s): %v", name, err)
+	}
+
+	if !ok || d == nil {
+		t.Fatalf("Get(%s): not found", name)
+	}
+
+	return d
+}
+
+// wantClass fails unless err is an errs.ApplicationError of the class.
+func wantClass(t *testing.T, err error, class, what string) {
+	t.Helper()
+
+	var ae *errs.ApplicationError
+	if !errors.As(err, &ae) || !ae.HasClass(class) {
+		t.Fatalf("%s: error %v, want class %s", what, err, class)
+	}
+}
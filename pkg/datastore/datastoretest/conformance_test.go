@@ -0,0 +1,18 @@
+package datastoretest_test
+
+import (
+	"testing"
+
+	"github.com/dr-dobermann/gobpm/pkg/datastore"
+	"github.com/dr-dobermann/gobpm/pkg/datastore/datastoretest"
+	"github.com/dr-dobermann/gobpm/pkg/datastore/memstore"
+)
+
+// TestConformanceSuite proves the suite itself against the reference
+// in-memory store — the suite is library code shipped to adapter authors,
+// so it carries its own green run.
+func TestConformanceSuite(t *testing.T) {
+	datastoretest.Conformance(t, func(*testing.T) datastore.DataStore {
+		return memstore.New()
+	})
+}
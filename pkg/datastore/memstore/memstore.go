@@ -1,7 +1,9 @@
 // Package memstore provides the engine's default DataStore: a non-durable,
 // in-memory, concurrency-safe store of item-aware data by name (ADR-030 §2.5).
 // Capacity is advisory — a Put past a nominal capacity is not rejected
-// (ADR-030 §2.6); a durable adapter may enforce it.
+// (ADR-030 §2.6); a durable adapter may enforce it. The store is
+// datastore.Versioned: every write bumps the datum's version, and PutIf
+// compare-and-swaps against it.
 package memstore
 
 import (
@@ -20,11 +22,17 @@ const Unlimited = 0
 
 // Store is an in-memory datastore.DataStore.
 type Store struct {
-	items    map[string]data.Data
+	items    map[string]item
 	capacity int
 	mu       sync.RWMutex
 }
 
+// item is one stored datum and its version (1 on the first write).
+type item struct {
+	d       data.Data
+	version int64
+}
+
 // Option configures a Store.
 type Option func(*Store)
 
@@ -41,7 +49,7 @@ func WithCapacity(n int) Option {
 // New returns an in-memory Store, unbounded unless WithCapacity is given.
 func New(opts ...Option) *Store {
 	s := &Store{
-		items:    map[string]data.Data{},
+		items:    map[string]item{},
 		capacity: Unlimited,
 	}
 
@@ -53,9 +61,19 @@ func New(opts ...Option) *Store {
 }
 
 // Get returns the datum stored under name; the bool is false when none exists.
-func (s *Store) Get(_ context.Context, name string) (data.Data, bool, error) {
+func (s *Store) Get(ctx context.Context, name string) (data.Data, bool, error) {
+	d, _, ok, err := s.GetVersioned(ctx, name)
+
+	return d, ok, err
+}
+
+// GetVersioned returns the datum stored under name and its version; the bool is
+// false (and the version 0) when none exists.
+func (s *Store) GetVersioned(
+	_ context.Context, name string,
+) (data.Data, int64, bool, error) {
 	if name == "" {
-		return nil, false, errs.New(
+		return nil, 0, false, errs.New(
 			errs.M("memstore.Get: an empty name isn't allowed"),
 			errs.C(errorClass, errs.EmptyNotAllowed))
 	}
@@ -63,14 +81,70 @@ func (s *Store) Get(_ context.Context, name string) (data.Data, bool, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	d, ok := s.items[name]
+	it, ok := s.items[name]
 
-	return d, ok, nil
+	return it.d, it.version, ok, nil
 }
 
 // Put stores (or replaces) d under name. Capacity is advisory — a Put past the
 // nominal capacity is accepted, not rejected (ADR-030 §2.6).
 func (s *Store) Put(_ context.Context, name string, d data.Data) error {
+	if err := checkPut(name, d); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.items[name] = item{d: d, version: s.items[name].version + 1}
+
+	return nil
+}
+
+// PutIf stores d under name iff the stored version equals version (0: iff none
+// exists) and returns the new version; a mismatch fails with
+// errs.ConcurrentUpdate.
+func (s *Store) PutIf(
+	_ context.Context, name string, d data.Data, version int64,
+) (int64, error) {
+	if err := checkPut(name, d); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cur := s.items[name].version
+	if cur != version {
+		return 0, errs.New(
+			errs.M("memstore.PutIf: %q is at version %d, not %d",
+				name, cur, version),
+			errs.C(errorClass, errs.ConcurrentUpdate))
+	}
+
+	s.items[name] = item{d: d, version: cur + 1}
+
+	return cur + 1, nil
+}
+
+// Delete removes the datum under name (a no-op if it is absent).
+func (s *Store) Delete(_ context.Context, name string) error {
+	if name == "" {
+		return errs.New(
+			errs.M("memstore.Delete: an empty name isn't allowed"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.items, name)
+
+	return nil
+}
+
+// checkPut rejects an empty name and a nil datum.
+func checkPut(name string, d data.Data) error {
 	if name == "" {
 		return errs.New(
 			errs.M("memstore.Put: an empty name isn't allowed"),
@@ -83,11 +157,6 @@ func (s *Store) Put(_ context.Context, name string, d data.Data) error {
 			errs.C(errorClass, errs.EmptyNotAllowed))
 	}
 
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	s.items[name] = d
-
 	return nil
 }
 
@@ -107,4 +176,7 @@ func (s *Store) IsUnlimited() bool {
 	return s.capacity == Unlimited
 }
 
-var _ datastore.DataStore = (*Store)(nil)
+var (
+	_ datastore.DataStore = (*Store)(nil)
+	_ datastore.Versioned = (*Store)(nil)
+)
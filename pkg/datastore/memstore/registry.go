@@ -1,6 +1,8 @@
 package memstore
 
 import (
+	"maps"
+	"slices"
 	"sync"
 
 	"github.com/dr-dobermann/gobpm/pkg/datastore"
@@ -61,4 +63,12 @@ func (r *Registry) Store(ref string) (datastore.DataStore, error) {
 	return s, nil
 }
 
+// Refs lists the registered store refs in sorted order.
+func (r *Registry) Refs() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return slices.Sorted(maps.Keys(r.stores))
+}
+
 var _ datastore.Registry = (*Registry)(nil)
@@ -46,6 +46,17 @@ func TestRegistry(t *testing.T) {
 		require.Same(t, last, got)
 	})
 
+	t.Run("refs are sorted", func(t *testing.T) {
+		reg := memstore.NewRegistry()
+		require.Empty(t, reg.Refs())
+
+		for _, ref := range []string{"stock", "orders", "audit"} {
+			require.NoError(t, reg.Register(ref, memstore.New()))
+		}
+
+		require.Equal(t, []string{"audit", "orders", "stock"}, reg.Refs())
+	})
+
 	// interface conformance.
 	var _ datastore.Registry = memstore.NewRegistry()
 }
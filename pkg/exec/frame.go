@@ -67,6 +67,22 @@ type Frame interface {
 	RecordDataMovement(engineStore, write bool, name, storeRef string)
 }
 
+// StoreVersions is the optional Frame capability behind conditional Data Store
+// writes (datastore.Versioned): LoadData notes the version of each datum it
+// reads from a Versioned store, and UploadData writes the same key back with
+// PutIf against it — so a concurrent writer between the two surfaces as
+// errs.ConcurrentUpdate instead of a lost update. A frame without it leaves
+// every store write last-writer-wins.
+type StoreVersions interface {
+	// NoteStoreVersion records the version of the datum read from the store
+	// storeRef under name (0: none existed).
+	NoteStoreVersion(storeRef, name string, version int64)
+
+	// StoreVersion returns the version noted for storeRef and name; the bool is
+	// false when the execution didn't read it.
+	StoreVersion(storeRef, name string) (int64, bool)
+}
+
 // NodeDataConsumer is implemented by nodes that consume data: LoadData
 // instantiates the node's inputs and properties in the execution Frame and
 // fills the inputs from the node's incoming data associations. The track calls
@@ -0,0 +1,181 @@
+// Package history defines the History extension: the engine's audit-trail
+// port, a peer to repository.Repository. A Store keeps what an instance did
+// after the instance itself is gone — its start and end, each node's
+// activity with its duration, the ownership changes of its user tasks, its
+// incidents and the names of the variables it updated. The engine feeds it
+// from the observation stream (Recorder), never from the execution path, so
+// a slow store costs history, not throughput. The in-memory default lives in
+// the memhistory sibling subpackage; historytest publishes the conformance
+// suite every adapter runs.
+package history
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+)
+
+const errorClass = "HISTORY"
+
+// Type classifies a history Event.
+type Type string
+
+// The recorded event types. The vocabulary is open: a store keeps a type it
+// doesn't know, and a query filter names the types it wants.
+const (
+	// InstanceStarted: an instance was created. Carries the process and the
+	// version it runs.
+	InstanceStarted Type = "InstanceStarted"
+	// InstanceEnded: an instance completed or terminated. Outcome names which,
+	// Duration runs from its start and Error carries the fault of a failed
+	// one.
+	InstanceEnded Type = "InstanceEnded"
+	// ActivityStarted: a token entered a node.
+	ActivityStarted Type = "ActivityStarted"
+	// ActivityEnded: a token left a node. Outcome names how — Completed,
+	// Merged, Canceled or Failed — and Duration runs from its start.
+	ActivityEnded Type = "ActivityEnded"
+	// TaskOwnerChanged: a user task was claimed, unclaimed or reassigned.
+	// Outcome names the change; UserID is the new owner and PrevUserID the
+	// previous one.
+	TaskOwnerChanged Type = "TaskOwnerChanged"
+	// IncidentChanged: an incident was raised, retried, resolved or closed.
+	// Outcome names the action.
+	IncidentChanged Type = "IncidentChanged"
+	// VariableUpdated: a variable was added, updated or deleted. Variable is
+	// its data path and Outcome the change — never the value (the masking
+	// rule).
+	VariableUpdated Type = "VariableUpdated"
+)
+
+// Event is one history record. Every event names its instance; the other
+// fields are set as the Type describes and stay empty otherwise.
+type Event struct {
+	At   time.Time
+	Type Type
+	// InstanceID is the instance the event is about; never empty.
+	InstanceID string
+	// ProcessID and Version pin the process the instance runs; Version is
+	// zero where the recording didn't see it.
+	ProcessID string
+	// ParentInstanceID is the calling instance of a call-activity child.
+	ParentInstanceID string
+	TrackID          string
+	NodeID           string
+	NodeName         string
+	// Outcome qualifies the event's Type: the end state, the ownership
+	// change, the incident action or the variable change.
+	Outcome    string
+	TaskID     string
+	UserID     string
+	PrevUserID string
+	IncidentID string
+	Variable   string
+	Error      string
+	Version    int
+	Duration   time.Duration
+	// Seq is the event's position in its store, assigned on Append: strictly
+	// increasing in append order. It is the paging cursor.
+	Seq int64
+}
+
+// DefaultLimit is the page size of a Query that sets none; MaxLimit caps
+// the one it sets.
+const (
+	DefaultLimit = 100
+	MaxLimit     = 1000
+)
+
+// Query selects history events. Every set field narrows the selection; the
+// zero Query selects everything, a page at a time, in Seq order.
+type Query struct {
+	// From and To bound the events' At to [From, To); a zero bound is open.
+	From time.Time
+	To   time.Time
+	// Types selects the event types; empty selects every type.
+	Types      []Type
+	InstanceID string
+	ProcessID  string
+	NodeID     string
+	TaskID     string
+	// UserID selects the events naming the user as owner or previous owner.
+	UserID string
+	// After is the paging cursor: only events with a greater Seq are
+	// selected. Zero starts at the beginning; Page.Next continues.
+	After int64
+	// Limit is the page size: zero means DefaultLimit, and a larger one than
+	// MaxLimit is capped. A negative one is refused.
+	Limit int
+}
+
+// Page is one page of a Query's events in Seq order. Next is the After of
+// the following page; it is zero on the last one.
+type Page struct {
+	Events []Event
+	Next   int64
+}
+
+// Store persists history events and answers queries over them. Append is
+// atomic: an invalid event — one without a Type, an InstanceID or an At —
+// fails the whole batch with an errs.EmptyNotAllowed-classified error and
+// stores nothing.
+type Store interface {
+	// Append stores the events in order, assigning each the next Seq.
+	// Appending none is a no-op.
+	Append(ctx context.Context, ee ...Event) error
+	// Query returns the page of events q selects. A negative Limit fails
+	// with errs.InvalidParameter.
+	Query(ctx context.Context, q Query) (Page, error)
+}
+
+// Check refuses an event a Store must not keep: one without a Type, an
+// InstanceID or an At.
+func (e *Event) Check() error {
+	if e.Type == "" || e.InstanceID == "" || e.At.IsZero() {
+		return errs.New(
+			errs.M("a history event needs a type, an instance and a time"),
+			errs.C(errorClass, errs.EmptyNotAllowed),
+			errs.D(observability.AttrInstanceID, e.InstanceID),
+			errs.D("type", string(e.Type)))
+	}
+
+	return nil
+}
+
+// PageSize returns the page size q asks for: DefaultLimit for a zero Limit,
+// MaxLimit past it. A negative Limit is refused.
+func (q *Query) PageSize() (int, error) {
+	switch {
+	case q.Limit < 0:
+		return 0, errs.New(
+			errs.M("a negative page size (%d) isn't allowed", q.Limit),
+			errs.C(errorClass, errs.InvalidParameter))
+
+	case q.Limit == 0:
+		return DefaultLimit, nil
+
+	case q.Limit > MaxLimit:
+		return MaxLimit, nil
+	}
+
+	return q.Limit, nil
+}
+
+// Match reports whether q selects e, the cursor included — the filter an
+// adapter that can't push it down to its backend applies itself.
+func (q *Query) Match(e *Event) bool {
+	eq := func(want, got string) bool { return want == "" || want == got }
+
+	return e.Seq > q.After &&
+		(q.From.IsZero() || !e.At.Before(q.From)) &&
+		(q.To.IsZero() || e.At.Before(q.To)) &&
+		(len(q.Types) == 0 || slices.Contains(q.Types, e.Type)) &&
+		eq(q.InstanceID, e.InstanceID) &&
+		eq(q.ProcessID, e.ProcessID) &&
+		eq(q.NodeID, e.NodeID) &&
+		eq(q.TaskID, e.TaskID) &&
+		(q.UserID == "" || q.UserID == e.UserID || q.UserID == e.PrevUserID)
+}
@@ -0,0 +1,366 @@
+// Package historytest publishes the history.Store conformance suite: every
+// Store implementation — the in-memory default and any durable adapter —
+// proves the same contract by calling Conformance from a one-line test. The
+// suite covers Seq assignment, batch atomicity, event fidelity, every query
+// filter, the time window and cursor paging with its limits.
+package historytest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/history"
+)
+
+// Factory builds a fresh, empty Store under test. It is called once per
+// subtest, so implementations must return isolated stores (for a shared
+// backend: a wiped namespace).
+type Factory func(t *testing.T) history.Store
+
+// Conformance runs the full Store contract against factory-built stores.
+// Adapter tests are one-liners:
+//
+//	func TestConformance(t *testing.T) {
+//		historytest.Conformance(t, func(t *testing.T) history.Store {
+//			return memhistory.New()
+//		})
+//	}
+func Conformance(t *testing.T, factory Factory) {
+	t.Helper()
+
+	if factory == nil {
+		t.Fatal("Conformance: a nil Factory isn't allowed")
+	}
+
+	for name, test := range conformanceTests {
+		t.Run(name, func(t *testing.T) { test(t, factory(t)) })
+	}
+}
+
+// now is an arbitrary fixed instant — the suite drives time explicitly.
+// Durable stores round times to their precision, so the suite's instants are
+// whole milliseconds in UTC.
+var now = time.Date(2026, time.August, 4, 12, 0, 0, 0, time.UTC)
+
+// ev is the baseline valid event the subtests derive from: the i-th second
+// after now.
+func ev(i int) history.Event {
+	return history.Event{
+		At:         now.Add(time.Duration(i) * time.Second),
+		Type:       history.ActivityStarted,
+		InstanceID: "inst-1",
+		ProcessID:  "order-flow",
+		Version:    1,
+		TrackID:    "track-1",
+		NodeID:     fmt.Sprintf("node-%d", i),
+		NodeName:   "node",
+	}
+}
+
+// conformanceTests is the contract as a declarative table.
+var conformanceTests = map[string]func(*testing.T, history.Store){
+	"AppendAssignsSeq":         testAppendAssignsSeq,
+	"AppendNoneIsNoop":         testAppendNoneIsNoop,
+	"AppendInvalidRejected":    testAppendInvalidRejected,
+	"EventFidelity":            testEventFidelity,
+	"QueryFilters":             testQueryFilters,
+	"QueryTypes":               testQueryTypes,
+	"QueryTimeWindow":          testQueryTimeWindow,
+	"QueryPaging":              testQueryPaging,
+	"QueryDefaultLimit":        testQueryDefaultLimit,
+	"QueryLimitCapped":         testQueryLimitCapped,
+	"QueryNegativeLimitRefuse": testQueryNegativeLimit,
+	"QueryEmptyStore":          testQueryEmptyStore,
+}
+
+func testAppendAssignsSeq(t *testing.T, s history.Store) {
+	mustAppend(t, s, ev(0), ev(1))
+	mustAppend(t, s, ev(2))
+
+	got := all(t, s, history.Query{})
+	if len(got) != 3 {
+		t.Fatalf("stored %d events, want 3", len(got))
+	}
+
+	for i := range got {
+		if got[i].NodeID != ev(i).NodeID {
+			t.Fatalf("event %d is %q, want append order", i, got[i].NodeID)
+		}
+
+		if i > 0 && got[i].Seq <= got[i-1].Seq {
+			t.Fatalf("Seq must increase in append order: %d after %d",
+				got[i].Seq, got[i-1].Seq)
+		}
+	}
+
+	if got[0].Seq <= 0 {
+		t.Fatalf("the first Seq = %d, want positive (0 is the cursor start)",
+			got[0].Seq)
+	}
+}
+
+func testAppendNoneIsNoop(t *testing.T, s history.Store) {
+	mustAppend(t, s)
+
+	if got := all(t, s, history.Query{}); len(got) != 0 {
+		t.Fatalf("appending none stored %d events", len(got))
+	}
+}
+
+func testAppendInvalidRejected(t *testing.T, s history.Store) {
+	for name, spoil := range map[string]func(*history.Event){
+		"no type":     func(e *history.Event) { e.Type = "" },
+		"no instance": func(e *history.Event) { e.InstanceID = "" },
+		"no time":     func(e *history.Event) { e.At = time.Time{} },
+	} {
+		bad := ev(1)
+		spoil(&bad)
+
+		err := s.Append(context.Background(), ev(0), bad)
+		wantClass(t, err, errs.EmptyNotAllowed, name)
+	}
+
+	if got := all(t, s, history.Query{}); len(got) != 0 {
+		t.Fatalf("a refused batch stored %d events", len(got))
+	}
+}
+
+func testEventFidelity(t *testing.T, s history.Store) {
+	want := history.Event{
+		At:               now,
+		Type:             history.InstanceEnded,
+		InstanceID:       "inst-1",
+		ProcessID:        "order-flow",
+		ParentInstanceID: "parent-1",
+		TrackID:          "track-1",
+		NodeID:           "review",
+		NodeName:         "Review order",
+		Outcome:          "Terminated",
+		TaskID:           "task-1",
+		UserID:           "bob",
+		PrevUserID:       "alice",
+		IncidentID:       "inc-1",
+		Variable:         `order.items["a"]`,
+		Error:            "boom",
+		Version:          3,
+		Duration:         1500 * time.Millisecond,
+	}
+
+	mustAppend(t, s, want)
+
+	got := all(t, s, history.Query{})
+	if len(got) != 1 {
+		t.Fatalf("stored %d events, want 1", len(got))
+	}
+
+	want.Seq = got[0].Seq
+	if !got[0].At.Equal(want.At) {
+		t.Fatalf("At = %v, want %v", got[0].At, want.At)
+	}
+
+	got[0].At = want.At
+	if got[0] != want {
+		t.Fatalf("event didn't round-trip:\n got %+v\nwant %+v", got[0], want)
+	}
+}
+
+func testQueryFilters(t *testing.T, s history.Store) {
+	other := ev(1)
+	other.InstanceID, other.ProcessID = "inst-2", "refund-flow"
+	other.NodeID, other.TaskID = "node-x", "task-2"
+	other.UserID, other.PrevUserID = "bob", "alice"
+
+	mustAppend(t, s, ev(0), other, ev(2))
+
+	for name, tc := range map[string]struct {
+		q    history.Query
+		want int
+	}{
+		"instance":      {history.Query{InstanceID: "inst-2"}, 1},
+		"process":       {history.Query{ProcessID: "order-flow"}, 2},
+		"node":          {history.Query{NodeID: "node-x"}, 1},
+		"task":          {history.Query{TaskID: "task-2"}, 1},
+		"owner":         {history.Query{UserID: "bob"}, 1},
+		"prev owner":    {history.Query{UserID: "alice"}, 1},
+		"combined":      {history.Query{InstanceID: "inst-1", NodeID: "node-x"}, 0},
+		"unknown value": {history.Query{InstanceID: "ghost"}, 0},
+	} {
+		if got := all(t, s, tc.q); len(got) != tc.want {
+			t.Fatalf("filter %s: %d events, want %d", name, len(got), tc.want)
+		}
+	}
+}
+
+func testQueryTypes(t *testing.T, s history.Store) {
+	ended := ev(1)
+	ended.Type = history.ActivityEnded
+
+	owner := ev(2)
+	owner.Type = history.TaskOwnerChanged
+
+	mustAppend(t, s, ev(0), ended, owner)
+
+	got := all(t, s, history.Query{
+		Types: []history.Type{history.ActivityEnded, history.TaskOwnerChanged},
+	})
+	if len(got) != 2 || got[0].Type != history.ActivityEnded ||
+		got[1].Type != history.TaskOwnerChanged {
+		t.Fatalf("type filter selected %+v", got)
+	}
+}
+
+func testQueryTimeWindow(t *testing.T, s history.Store) {
+	mustAppend(t, s, ev(0), ev(1), ev(2), ev(3))
+
+	got := all(t, s, history.Query{
+		From: now.Add(time.Second),
+		To:   now.Add(3 * time.Second),
+	})
+
+	names := make([]string, 0, len(got))
+	for _, e := range got {
+		names = append(names, e.NodeID)
+	}
+
+	if want := []string{"node-1", "node-2"}; !slices.Equal(names, want) {
+		t.Fatalf("window [1s, 3s) selected %v, want %v", names, want)
+	}
+}
+
+func testQueryPaging(t *testing.T, s history.Store) {
+	for i := range 7 {
+		mustAppend(t, s, ev(i))
+	}
+
+	q := history.Query{Limit: 3}
+
+	var sizes []int
+
+	for {
+		p, err := s.Query(context.Background(), q)
+		if err != nil {
+			t.Fatalf("Query: %v", err)
+		}
+
+		sizes = append(sizes, len(p.Events))
+
+		if p.Next == 0 {
+			break
+		}
+
+		if p.Next != p.Events[len(p.Events)-1].Seq {
+			t.Fatalf("Next = %d, want the page's last Seq %d",
+				p.Next, p.Events[len(p.Events)-1].Seq)
+		}
+
+		q.After = p.Next
+	}
+
+	if want := []int{3, 3, 1}; !slices.Equal(sizes, want) {
+		t.Fatalf("page sizes %v, want %v", sizes, want)
+	}
+}
+
+func testQueryDefaultLimit(t *testing.T, s history.Store) {
+	fill(t, s, history.DefaultLimit+1)
+
+	p, err := s.Query(context.Background(), history.Query{})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+
+	if len(p.Events) != history.DefaultLimit || p.Next == 0 {
+		t.Fatalf("a zero Limit paged %d events (Next %d), want %d and more",
+			len(p.Events), p.Next, history.DefaultLimit)
+	}
+}
+
+func testQueryLimitCapped(t *testing.T, s history.Store) {
+	fill(t, s, history.MaxLimit+1)
+
+	p, err := s.Query(context.Background(),
+		history.Query{Limit: history.MaxLimit + 1})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+
+	if len(p.Events) != history.MaxLimit || p.Next == 0 {
+		t.Fatalf("Limit past MaxLimit paged %d events (Next %d), want %d",
+			len(p.Events), p.Next, history.MaxLimit)
+	}
+}
+
+func testQueryNegativeLimit(t *testing.T, s history.Store) {
+	_, err := s.Query(context.Background(), history.Query{Limit: -1})
+	wantClass(t, err, errs.InvalidParameter, "a negative Limit")
+}
+
+func testQueryEmptyStore(t *testing.T, s history.Store) {
+	p, err := s.Query(context.Background(), history.Query{})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+
+	if len(p.Events) != 0 || p.Next != 0 {
+		t.Fatalf("an empty store paged %d events (Next %d)",
+			len(p.Events), p.Next)
+	}
+}
+
+// mustAppend appends ee or fails the test.
+func mustAppend(t *testing.T, s history.Store, ee ...history.Event) {
+	t.Helper()
+
+	if err := s.Append(context.Background(), ee...); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+}
+
+// fill appends n baseline events in one batch.
+func fill(t *testing.T, s history.Store, n int) {
+	t.Helper()
+
+	ee := make([]history.Event, 0, n)
+	for i := range n {
+		ee = append(ee, ev(i))
+	}
+
+	mustAppend(t, s, ee...)
+}
+
+// all pages through every event q selects.
+func all(t *testing.T, s history.Store, q history.Query) []history.Event {
+	t.Helper()
+
+	var ee []history.Event
+
+	for {
+		p, err := s.Query(context.Background(), q)
+		if err != nil {
+			t.Fatalf("Query: %v", err)
+		}
+
+		ee = append(ee, p.Events...)
+
+		if p.Next == 0 {
+			return ee
+		}
+
+		q.After = p.Next
+	}
+}
+
+// wantClass fails the test unless err carries the errs class.
+func wantClass(t *testing.T, err error, class, what string) {
+	t.Helper()
+
+	var ae *errs.ApplicationError
+	if !errors.As(err, &ae) || !ae.HasClass(class) {
+		t.Fatalf("%s: want a %s-classified error, got %v", what, class, err)
+	}
+}
@@ -0,0 +1,18 @@
+package historytest_test
+
+import (
+	"testing"
+
+	"github.com/dr-dobermann/gobpm/pkg/history"
+	"github.com/dr-dobermann/gobpm/pkg/history/historytest"
+	"github.com/dr-dobermann/gobpm/pkg/history/memhistory"
+)
+
+// TestConformanceSuite proves the suite itself against the reference
+// in-memory store — the suite is library code shipped to adapter authors,
+// so it carries its own green run.
+func TestConformanceSuite(t *testing.T) {
+	historytest.Conformance(t, func(*testing.T) history.Store {
+		return memhistory.New()
+	})
+}
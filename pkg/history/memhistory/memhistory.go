@@ -0,0 +1,128 @@
+// Package memhistory provides the engine's default history.Store: a
+// non-durable, in-memory event log. It is capped, evicting the oldest events
+// and warning once past the cap, so it cannot grow unbounded (the
+// bounded-in-memory-defaults principle, ADR-002 §4.2).
+package memhistory
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/dr-dobermann/gobpm/pkg/history"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+)
+
+// DefaultMaxEvents is the default cap on retained events.
+const DefaultMaxEvents = 65536
+
+// Store is an in-memory history.Store.
+type Store struct {
+	logger    observability.Logger
+	events    []history.Event
+	seq       int64
+	maxEvents int
+	mu        sync.Mutex
+	warnOnce  sync.Once
+}
+
+// Option configures a Store.
+type Option func(*Store)
+
+// WithMaxEvents sets the cap on retained events; n <= 0 disables it.
+func WithMaxEvents(n int) Option { return func(s *Store) { s.maxEvents = n } }
+
+// WithLogger sets the logger used for the eviction warning.
+func WithLogger(l observability.Logger) Option { return func(s *Store) { s.logger = l } }
+
+// New returns an in-memory Store with the default cap and slog.Default()
+// logger, overridden by opts.
+func New(opts ...Option) *Store {
+	s := &Store{
+		logger:    slog.Default(),
+		maxEvents: DefaultMaxEvents,
+	}
+
+	for _, o := range opts {
+		o(s)
+	}
+
+	return s
+}
+
+// Append stores the events in order, each under the next Seq. An invalid
+// event fails the batch with nothing stored.
+func (s *Store) Append(_ context.Context, ee ...history.Event) error {
+	for i := range ee {
+		if err := ee[i].Check(); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, e := range ee {
+		s.seq++
+		e.Seq = s.seq
+		s.events = append(s.events, e)
+	}
+
+	s.evictLocked()
+
+	return nil
+}
+
+// Query returns the page of events q selects, in Seq order.
+func (s *Store) Query(_ context.Context, q history.Query) (history.Page, error) {
+	limit, err := q.PageSize()
+	if err != nil {
+		return history.Page{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var p history.Page
+
+	for i := range s.events {
+		if !q.Match(&s.events[i]) {
+			continue
+		}
+
+		if len(p.Events) == limit {
+			p.Next = p.Events[limit-1].Seq
+
+			break
+		}
+
+		p.Events = append(p.Events, s.events[i])
+	}
+
+	return p, nil
+}
+
+// ClusterCompatibility declares memhistory unfit to back a multi-engine
+// deployment (renv.ClusterAware): the log lives in one process, so one
+// engine's history is invisible to the others.
+func (s *Store) ClusterCompatibility() (bool, string) {
+	return false, "in-memory; history is not shared across nodes"
+}
+
+// evictLocked drops the oldest events past the cap. Caller holds mu.
+func (s *Store) evictLocked() {
+	if s.maxEvents <= 0 || len(s.events) <= s.maxEvents {
+		return
+	}
+
+	// re-slicing, not copying: the next growth of events reallocates only
+	// the retained tail, so eviction stays amortized O(1) per event.
+	s.events = s.events[len(s.events)-s.maxEvents:]
+
+	s.warnOnce.Do(func() {
+		s.logger.Warn("memhistory: event cap reached, evicting oldest",
+			"cap", s.maxEvents)
+	})
+}
+
+var _ history.Store = (*Store)(nil)
@@ -0,0 +1,58 @@
+package memhistory_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dr-dobermann/gobpm/pkg/history"
+	"github.com/dr-dobermann/gobpm/pkg/history/historytest"
+	"github.com/dr-dobermann/gobpm/pkg/history/memhistory"
+	"github.com/dr-dobermann/gobpm/pkg/renv"
+)
+
+// TestConformance proves memhistory against the published Store contract
+// suite — the same suite every durable adapter runs.
+func TestConformance(t *testing.T) {
+	historytest.Conformance(t, func(*testing.T) history.Store {
+		return memhistory.New()
+	})
+}
+
+// TestEvictsOldest: past the cap the oldest events go, and the retained ones
+// keep their Seq — a cursor taken before the eviction still continues.
+func TestEvictsOldest(t *testing.T) {
+	s := memhistory.New(memhistory.WithMaxEvents(2))
+	ctx := context.Background()
+
+	for i := range 3 {
+		err := s.Append(ctx, history.Event{
+			At:         time.Now(),
+			Type:       history.ActivityStarted,
+			InstanceID: "inst-1",
+			NodeID:     string(rune('a' + i)),
+		})
+		if err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+
+	p, err := s.Query(ctx, history.Query{})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+
+	if len(p.Events) != 2 || p.Events[0].NodeID != "b" || p.Events[0].Seq != 2 {
+		t.Fatalf("retained %+v, want b and c under their Seq", p.Events)
+	}
+}
+
+// TestClusterDeclaration: memhistory declares itself single-node
+// (renv.ClusterAware — satisfied structurally, the store never imports renv).
+func TestClusterDeclaration(t *testing.T) {
+	var ca renv.ClusterAware = memhistory.New()
+
+	if ok, reason := ca.ClusterCompatibility(); ok || reason == "" {
+		t.Fatal("an in-memory store can never back a cluster, and says why")
+	}
+}
@@ -0,0 +1,331 @@
+package history
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+)
+
+// Recorder turns the engine's observation stream into history: it is an
+// observability.Observer that appends one Event per recordable Fact to its
+// Store. Register it engine-wide — thresher.WithHistory does — so it sees
+// every instance's facts.
+//
+// OnFact runs on the observer's own drain goroutine, never on the execution
+// path: a slow Store makes the Recorder fall behind and the engine drop the
+// facts past its observer buffer, it never stalls an instance. A failed
+// Append is logged and the events are lost — history is an audit trail, not
+// the state of record.
+//
+// Durations are measured between the facts the Recorder saw: an activity or
+// instance whose start it didn't see — one begun before the Recorder was
+// registered, or on another engine — ends with a zero Duration.
+type Recorder struct {
+	store  Store
+	logger observability.Logger
+	masked map[string]struct{}
+	// runs are the instances seen and not yet ended, by id.
+	runs map[string]*run
+	mu   sync.Mutex
+}
+
+// run is what the Recorder remembers of a running instance.
+type run struct {
+	// started is the instance's Created time; zero when it wasn't seen.
+	started   time.Time
+	processID string
+	err       string
+	// tokens are the activities the instance's tokens are at, by track.
+	tokens  map[string]*activity
+	version int
+}
+
+// activity is the node a token is at and since when.
+type activity struct {
+	started  time.Time
+	nodeID   string
+	nodeName string
+}
+
+// RecorderOption configures a Recorder.
+type RecorderOption func(*Recorder)
+
+// WithMaskedVariables keeps the named variables out of history: an update of
+// any path under one of them is never recorded.
+func WithMaskedVariables(names ...string) RecorderOption {
+	return func(r *Recorder) {
+		for _, n := range names {
+			r.masked[n] = struct{}{}
+		}
+	}
+}
+
+// WithLogger sets the logger the Recorder reports a failed Append to.
+func WithLogger(l observability.Logger) RecorderOption {
+	return func(r *Recorder) {
+		if l != nil {
+			r.logger = l
+		}
+	}
+}
+
+// NewRecorder returns a Recorder appending to store, with the slog.Default()
+// logger and no masked variables unless opts say otherwise. A nil store is a
+// programming error: the Recorder panics on its first fact.
+func NewRecorder(store Store, opts ...RecorderOption) *Recorder {
+	r := &Recorder{
+		store:  store,
+		logger: slog.Default(),
+		masked: map[string]struct{}{},
+		runs:   map[string]*run{},
+	}
+
+	for _, o := range opts {
+		o(r)
+	}
+
+	return r
+}
+
+// OnFact records the history events f makes (observability.Observer).
+func (r *Recorder) OnFact(f observability.Fact) {
+	ee := r.events(f)
+	if len(ee) == 0 {
+		return
+	}
+
+	if err := r.store.Append(context.Background(), ee...); err != nil {
+		r.logger.Warn("history: couldn't record events",
+			observability.AttrInstanceID, ee[0].InstanceID,
+			"type", string(ee[0].Type),
+			observability.AttrError, err.Error())
+	}
+}
+
+// events translates f into its history events — none for a fact history
+// doesn't keep.
+func (r *Recorder) events(f observability.Fact) []Event {
+	id := f.Details[observability.AttrInstanceID]
+	if id == "" {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e := Event{
+		At:               f.At,
+		InstanceID:       id,
+		ParentInstanceID: f.Details[observability.AttrParentInstanceID],
+		NodeID:           f.NodeID,
+		NodeName:         f.NodeName,
+	}
+
+	if e.At.IsZero() {
+		e.At = time.Now()
+	}
+
+	rn := r.runOf(id)
+
+	var ee []Event
+
+	switch f.Kind {
+	case observability.KindInstanceState:
+		ee = rn.instanceEvent(e, f)
+
+	case observability.KindNodeProgress:
+		ee = rn.activityEvents(e, f)
+
+	case observability.KindTaskState:
+		ee = taskOwnerEvent(e, f)
+
+	case observability.KindFault:
+		ee = incidentEvent(e, f)
+
+	case observability.KindDataChange:
+		ee = r.variableEvent(e, f)
+	}
+
+	for i := range ee {
+		ee[i].ProcessID, ee[i].Version = rn.processID, rn.version
+	}
+
+	if len(ee) == 1 && ee[0].Type == InstanceEnded {
+		delete(r.runs, id)
+	}
+
+	return ee
+}
+
+// runOf returns the remembered run of the instance id, starting to remember
+// it when it's new.
+func (r *Recorder) runOf(id string) *run {
+	rn, ok := r.runs[id]
+	if !ok {
+		rn = &run{tokens: map[string]*activity{}}
+		r.runs[id] = rn
+	}
+
+	return rn
+}
+
+// instanceEvent returns the event of an InstanceState fact: a start, an end,
+// or — none — a fact that tells the instance's process or fault.
+func (rn *run) instanceEvent(e Event, f observability.Fact) []Event {
+	if p := f.Details[observability.AttrProcessID]; p != "" {
+		rn.processID = p
+	}
+
+	if v, err := strconv.Atoi(f.Details[observability.AttrVersion]); err == nil {
+		rn.version = v
+	}
+
+	switch f.Phase {
+	case observability.PhaseCreated:
+		rn.started = e.At
+		e.Type = InstanceStarted
+
+	case observability.PhaseFailed:
+		rn.err = f.Details[observability.AttrError]
+
+		return nil
+
+	case observability.PhaseCompleted, observability.PhaseTerminated:
+		e.Type = InstanceEnded
+		e.Outcome = string(f.Phase)
+		e.Error = rn.err
+
+		if !rn.started.IsZero() {
+			e.Duration = e.At.Sub(rn.started)
+		}
+
+	default:
+		return nil
+	}
+
+	return []Event{e}
+}
+
+// activityEnds are the node phases a token ends with.
+var activityEnds = map[observability.Phase]bool{
+	observability.PhaseCompleted: true,
+	observability.PhaseMerged:    true,
+	observability.PhaseCanceled:  true,
+	observability.PhaseFailed:    true,
+}
+
+// activityEvents returns the events of a NodeProgress fact. A token's node
+// phases don't bracket its nodes — it steps on to the next node without a
+// fact closing the one it leaves — so an activity starts with the first fact
+// of a token at a node and ends, Completed, when the token shows up at
+// another, or with the phase the token itself ends with.
+func (rn *run) activityEvents(e Event, f observability.Fact) []Event {
+	e.TrackID = f.Details[observability.AttrTrackID]
+
+	var ee []Event
+
+	cur := rn.tokens[e.TrackID]
+	if cur != nil && cur.nodeID != e.NodeID {
+		ee = append(ee, cur.end(e, observability.PhaseCompleted))
+		cur = nil
+	}
+
+	if cur == nil {
+		cur = &activity{nodeID: e.NodeID, nodeName: e.NodeName, started: e.At}
+		rn.tokens[e.TrackID] = cur
+
+		s := e
+		s.Type = ActivityStarted
+		ee = append(ee, s)
+	}
+
+	if activityEnds[f.Phase] {
+		ee = append(ee, cur.end(e, f.Phase))
+		delete(rn.tokens, e.TrackID)
+	}
+
+	return ee
+}
+
+// end returns the ActivityEnded event of the activity at e's time.
+func (a *activity) end(e Event, outcome observability.Phase) Event {
+	e.Type = ActivityEnded
+	e.NodeID, e.NodeName = a.nodeID, a.nodeName
+	e.Outcome = string(outcome)
+	e.Duration = e.At.Sub(a.started)
+
+	return e
+}
+
+// taskOwnerEvent returns the event of a TaskState ownership fact.
+func taskOwnerEvent(e Event, f observability.Fact) []Event {
+	e.TaskID = f.Details[observability.AttrTaskID]
+	e.NodeID = f.Details[observability.AttrNodeID]
+
+	switch f.Phase {
+	case observability.PhaseClaimed:
+		e.UserID = f.Details[observability.AttrUserID]
+
+	case observability.PhaseUnclaimed:
+		e.PrevUserID = f.Details[observability.AttrUserID]
+
+	case observability.PhaseReassigned:
+		e.UserID = f.Details[observability.AttrToUserID]
+		e.PrevUserID = f.Details[observability.AttrFromUserID]
+
+	default:
+		return nil
+	}
+
+	e.Type = TaskOwnerChanged
+	e.Outcome = string(f.Phase)
+
+	return []Event{e}
+}
+
+// incidentEvent returns the event of an incident fact.
+func incidentEvent(e Event, f observability.Fact) []Event {
+	if f.Phase != observability.PhaseIncident {
+		return nil
+	}
+
+	e.Type = IncidentChanged
+	e.Outcome = f.Details["action"]
+	e.IncidentID = f.Details["incident_id"]
+	e.Error = f.Details[observability.AttrError]
+
+	return []Event{e}
+}
+
+// variableEvent returns the event of a DataChange fact, unless the variable
+// is masked.
+func (r *Recorder) variableEvent(e Event, f observability.Fact) []Event {
+	path := f.Details[observability.AttrDataPath]
+
+	if _, masked := r.masked[variableName(path)]; masked {
+		return nil
+	}
+
+	e.Type = VariableUpdated
+	e.Variable = path
+	e.Outcome = string(f.Phase)
+
+	return []Event{e}
+}
+
+// variableName returns the variable a data path starts at: the path up to
+// its first field or key step.
+func variableName(path string) string {
+	if i := strings.IndexAny(path, ".["); i >= 0 {
+		return path[:i]
+	}
+
+	return path
+}
+
+var _ observability.Observer = (*Recorder)(nil)
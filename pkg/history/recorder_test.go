@@ -0,0 +1,169 @@
+package history_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gobpm/pkg/history"
+	"github.com/dr-dobermann/gobpm/pkg/history/memhistory"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+)
+
+var epoch = time.Date(2026, time.August, 4, 12, 0, 0, 0, time.UTC)
+
+// fact builds an instance fact of inst-1 at the sec-th second after epoch.
+func fact(
+	sec int, kind observability.Kind, phase observability.Phase,
+	node string, details map[string]string,
+) observability.Fact {
+	d := map[string]string{observability.AttrInstanceID: "inst-1"}
+	for k, v := range details {
+		d[k] = v
+	}
+
+	return observability.Fact{
+		At:      epoch.Add(time.Duration(sec) * time.Second),
+		Kind:    kind,
+		Phase:   phase,
+		NodeID:  node,
+		Details: d,
+	}
+}
+
+// recorded returns every event in s.
+func recorded(t *testing.T, s history.Store) []history.Event {
+	t.Helper()
+
+	p, err := s.Query(context.Background(), history.Query{})
+	require.NoError(t, err)
+
+	return p.Events
+}
+
+// TestRecorderActivities verifies a token's activity ends when it shows up at
+// the next node and with the phase it ends with, each with its duration.
+func TestRecorderActivities(t *testing.T) {
+	s := memhistory.New()
+	r := history.NewRecorder(s)
+
+	track := map[string]string{observability.AttrTrackID: "tr-1"}
+
+	for _, f := range []observability.Fact{
+		fact(0, observability.KindInstanceState, observability.PhaseCreated,
+			"", map[string]string{
+				observability.AttrProcessID: "order",
+				observability.AttrVersion:   "2",
+			}),
+		fact(1, observability.KindNodeProgress, observability.PhaseExecuting,
+			"a", track),
+		fact(2, observability.KindNodeProgress, observability.PhaseParked,
+			"b", track),
+		fact(5, observability.KindNodeProgress, observability.PhaseEntered,
+			"b", track),
+		fact(6, observability.KindNodeProgress, observability.PhaseCanceled,
+			"b", track),
+		fact(7, observability.KindInstanceState, observability.PhaseFailed,
+			"", map[string]string{observability.AttrError: "boom"}),
+		fact(8, observability.KindInstanceState, observability.PhaseTerminated,
+			"", nil),
+	} {
+		r.OnFact(f)
+	}
+
+	ee := recorded(t, s)
+
+	type step struct {
+		typ      history.Type
+		node     string
+		outcome  string
+		duration time.Duration
+	}
+
+	got := make([]step, 0, len(ee))
+	for _, e := range ee {
+		require.Equal(t, "order", e.ProcessID)
+		require.Equal(t, 2, e.Version)
+
+		got = append(got, step{e.Type, e.NodeID, e.Outcome, e.Duration})
+	}
+
+	require.Equal(t, []step{
+		{history.InstanceStarted, "", "", 0},
+		{history.ActivityStarted, "a", "", 0},
+		{history.ActivityEnded, "a", "Completed", time.Second},
+		{history.ActivityStarted, "b", "", 0},
+		{history.ActivityEnded, "b", "Canceled", 4 * time.Second},
+		{history.InstanceEnded, "", "Terminated", 8 * time.Second},
+	}, got)
+
+	require.Equal(t, "boom", ee[len(ee)-1].Error)
+}
+
+// TestRecorderOwnershipAndIncidents verifies the ownership changes name both
+// parties and the incident actions are kept.
+func TestRecorderOwnershipAndIncidents(t *testing.T) {
+	s := memhistory.New()
+	r := history.NewRecorder(s)
+
+	task := func(extra map[string]string) map[string]string {
+		d := map[string]string{observability.AttrTaskID: "task-1"}
+		for k, v := range extra {
+			d[k] = v
+		}
+
+		return d
+	}
+
+	r.OnFact(fact(1, observability.KindTaskState, observability.PhaseReassigned,
+		"", task(map[string]string{
+			observability.AttrFromUserID: "alice",
+			observability.AttrToUserID:   "bob",
+		})))
+	r.OnFact(fact(2, observability.KindTaskState, observability.PhaseUnclaimed,
+		"", task(map[string]string{observability.AttrUserID: "bob"})))
+	r.OnFact(fact(3, observability.KindTaskState, observability.PhaseAnnounced,
+		"", task(nil)))
+	r.OnFact(fact(4, observability.KindFault, observability.PhaseIncident,
+		"pay", map[string]string{
+			"action":                "raised",
+			"incident_id":           "inc-1",
+			observability.AttrError: "timeout",
+		}))
+	r.OnFact(fact(5, observability.KindFault, observability.PhaseThrown,
+		"pay", nil))
+
+	ee := recorded(t, s)
+	require.Len(t, ee, 3, "an announcement and a thrown fault aren't history")
+
+	require.Equal(t, "bob", ee[0].UserID)
+	require.Equal(t, "alice", ee[0].PrevUserID)
+	require.Equal(t, "Unclaimed", ee[1].Outcome)
+	require.Empty(t, ee[1].UserID)
+	require.Equal(t, "bob", ee[1].PrevUserID)
+
+	require.Equal(t, history.IncidentChanged, ee[2].Type)
+	require.Equal(t, "raised", ee[2].Outcome)
+	require.Equal(t, "inc-1", ee[2].IncidentID)
+	require.Equal(t, "timeout", ee[2].Error)
+}
+
+// TestRecorderMasksVariables verifies a masked variable's updates — at any
+// path under it — are dropped, and the others are kept by path.
+func TestRecorderMasksVariables(t *testing.T) {
+	s := memhistory.New()
+	r := history.NewRecorder(s, history.WithMaskedVariables("card"))
+
+	for _, path := range []string{"card", "card.number", `card["cvv"]`, "order.total"} {
+		r.OnFact(fact(1, observability.KindDataChange,
+			observability.PhaseValueUpdated, "pay",
+			map[string]string{observability.AttrDataPath: path}))
+	}
+
+	ee := recorded(t, s)
+	require.Len(t, ee, 1)
+	require.Equal(t, "order.total", ee[0].Variable)
+	require.Equal(t, "Value_Updated", ee[0].Outcome)
+}
@@ -0,0 +1,113 @@
+// Package listener carries the execution-listener contract: synchronous hooks
+// the engine runs on a token's own track as a node executes, in the style of
+// the start/end/take listeners of other BPMN engines.
+//
+// Unlike an Observer, which receives a lossy, read-only Fact stream after the
+// fact, an execution listener runs in-band. It may read and write the
+// instance's variables through a scoped Variables view, and its error fails
+// the activity: the node's results are discarded and the failure takes the
+// engine's ordinary path — an Error boundary for a BpmnError, otherwise an
+// incident the operator retries or resolves (ADR-036).
+//
+// A listener is bound engine-wide (thresher.WithExecutionListener), per
+// process (thresher.WithProcessListener) or per element
+// (thresher.WithNodeListener).
+//
+// A task listener (TaskListener) follows a UserTask's lifecycle the same way:
+// create, assign, complete and delete. It is bound engine-wide
+// (thresher.WithTaskListener) or per UserTask (thresher.WithUserTaskListener).
+package listener
+
+import (
+	"context"
+	"slices"
+
+	"github.com/dr-dobermann/gobpm/pkg/model/data"
+)
+
+// Event names the moment an execution listener runs.
+type Event string
+
+const (
+	// EventStart runs before the node executes — after a waiting node's
+	// trigger arrived, before the node loads its inputs. A variable set here
+	// is visible to the node.
+	EventStart Event = "start"
+	// EventEnd runs after the node executed and produced its outputs, before
+	// its results are committed.
+	EventEnd Event = "end"
+	// EventTake runs for each outgoing sequence flow the node's execution
+	// chose, after EventEnd and before the results are committed.
+	EventTake Event = "take"
+)
+
+// Valid reports whether e is one of the defined events.
+func (e Event) Valid() bool {
+	return e == EventStart || e == EventEnd || e == EventTake
+}
+
+// Execution describes the node execution a listener is notified of.
+type Execution struct {
+	Event      Event
+	InstanceID string
+	ProcessID  string
+	// NodeID and NodeName name the executing node; for EventTake, the
+	// source of the taken flow.
+	NodeID   string
+	NodeName string
+	// FlowID is the taken sequence flow's id; empty unless Event is
+	// EventTake.
+	FlowID string
+}
+
+// Variables is a listener's view of the instance data, scoped to the
+// executing node: a read resolves exactly as the node's own reads do (its
+// inputs first, then the enclosing scopes), and a write lands together with
+// the node's results — committed when the node completes, discarded when it
+// fails.
+type Variables interface {
+	// Get returns the value of the variable name.
+	Get(name string) (data.Value, error)
+	// Set writes the variable name.
+	Set(name string, v data.Value) error
+}
+
+// Listener is a synchronous execution hook. Notify runs on the executing
+// token's track, so it blocks that token — keep it prompt. A non-nil error
+// fails the activity.
+type Listener interface {
+	Notify(ctx context.Context, ex Execution, vars Variables) error
+}
+
+// Func adapts a function to a Listener.
+type Func func(ctx context.Context, ex Execution, vars Variables) error
+
+// Notify calls f.
+func (f Func) Notify(ctx context.Context, ex Execution, vars Variables) error {
+	return f(ctx, ex, vars)
+}
+
+// Binding is a Listener bound to the events and the element it runs for.
+type Binding struct {
+	Listener Listener
+	// Events are the events the listener runs for; empty means every event.
+	Events []Event
+	// ElementID restricts the listener to one element; empty means every
+	// node. A node id matches the node's start and end and the takes of
+	// its outgoing flows; a sequence flow id matches that flow's take.
+	ElementID string
+}
+
+// Matches reports whether b runs for ex.
+func (b Binding) Matches(ex Execution) bool {
+	if len(b.Events) != 0 && !slices.Contains(b.Events, ex.Event) {
+		return false
+	}
+
+	if b.ElementID == "" {
+		return true
+	}
+
+	return b.ElementID == ex.NodeID ||
+		(ex.Event == EventTake && b.ElementID == ex.FlowID)
+}
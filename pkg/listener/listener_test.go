@@ -0,0 +1,54 @@
+package listener_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gobpm/pkg/listener"
+)
+
+func TestBindingMatches(t *testing.T) {
+	start := listener.Execution{Event: listener.EventStart, NodeID: "task"}
+	take := listener.Execution{
+		Event: listener.EventTake, NodeID: "task", FlowID: "flow",
+	}
+
+	for _, tc := range []struct {
+		name    string
+		b       listener.Binding
+		ex      listener.Execution
+		matches bool
+	}{
+		{"unbound", listener.Binding{}, start, true},
+		{"event filtered in",
+			listener.Binding{Events: []listener.Event{listener.EventStart}},
+			start, true},
+		{"event filtered out",
+			listener.Binding{Events: []listener.Event{listener.EventEnd}},
+			start, false},
+		{"node", listener.Binding{ElementID: "task"}, start, true},
+		{"other node", listener.Binding{ElementID: "other"}, start, false},
+		{"node take", listener.Binding{ElementID: "task"}, take, true},
+		{"flow take", listener.Binding{ElementID: "flow"}, take, true},
+		{"flow id on a start",
+			listener.Binding{ElementID: "flow"},
+			listener.Execution{Event: listener.EventStart, NodeID: "task",
+				FlowID: "flow"}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.matches, tc.b.Matches(tc.ex))
+		})
+	}
+}
+
+func TestEventValid(t *testing.T) {
+	for _, ev := range []listener.Event{
+		listener.EventStart, listener.EventEnd, listener.EventTake,
+	} {
+		require.True(t, ev.Valid(), ev)
+	}
+
+	require.False(t, listener.Event("resume").Valid())
+	require.False(t, listener.Event("").Valid())
+}
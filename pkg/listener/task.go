@@ -0,0 +1,121 @@
+package listener
+
+import (
+	"context"
+	"slices"
+
+	"github.com/dr-dobermann/gobpm/pkg/interactor"
+	"github.com/dr-dobermann/gobpm/pkg/model/data"
+)
+
+// TaskEvent names the moment of a UserTask's lifecycle a task listener runs
+// for.
+type TaskEvent string
+
+const (
+	// TaskCreate runs when a UserTask parks, before it is announced to the
+	// TaskDistributor. The listener may change the task's assignment
+	// (Task.SetAssignee, Task.SetCandidateUsers, Task.SetCandidateGroups).
+	TaskCreate TaskEvent = "create"
+	// TaskAssign runs before the task's owner changes — on Claim, Unclaim
+	// and Reassign. An error vetoes the change.
+	TaskAssign TaskEvent = "assign"
+	// TaskComplete runs when an authorized actor completes the task, after
+	// the outputs passed the task's output specification and before the
+	// token resumes. An error rejects the completion.
+	TaskComplete TaskEvent = "complete"
+	// TaskDelete runs when the task is withdrawn without completing — its
+	// activity was interrupted or canceled, or its instance ended.
+	TaskDelete TaskEvent = "delete"
+)
+
+// Valid reports whether e is one of the defined task events.
+func (e TaskEvent) Valid() bool {
+	return e == TaskCreate || e == TaskAssign || e == TaskComplete ||
+		e == TaskDelete
+}
+
+// Task describes the UserTask moment a task listener is notified of.
+type Task struct {
+	interactor.TaskRef
+
+	Event    TaskEvent
+	NodeName string
+
+	// Eligible is the task's assignment resolved from the model. A
+	// TaskCreate listener may change it; the task is distributed and
+	// authorized with the result. Changes made for any other event are
+	// ignored.
+	Eligible interactor.Eligibility
+
+	// Owner is the task's owner after a TaskAssign — empty when the task is
+	// released — and PreviousOwner the one before it.
+	Owner         string
+	PreviousOwner string
+
+	// UserID is the acting user: the claiming or releasing one for
+	// TaskAssign (empty on a Reassign), the completing one for TaskComplete.
+	UserID string
+
+	// Outputs are the submitted outputs of a TaskComplete.
+	Outputs []data.Data
+}
+
+// SetAssignee assigns the task to userID: only that user may act on it, and
+// the task is born owned by them.
+func (t *Task) SetAssignee(userID string) {
+	t.Eligible.Assignee = interactor.ResolvedSlot{
+		IDs:      []string{userID},
+		Declared: true,
+	}
+}
+
+// SetCandidateUsers offers the task to the users userIDs.
+func (t *Task) SetCandidateUsers(userIDs ...string) {
+	t.Eligible.CandidateUsers = interactor.ResolvedSlot{
+		IDs:      append([]string(nil), userIDs...),
+		Declared: true,
+	}
+}
+
+// SetCandidateGroups offers the task to the members of groups.
+func (t *Task) SetCandidateGroups(groups ...string) {
+	t.Eligible.CandidateGroups = interactor.ResolvedSlot{
+		IDs:      append([]string(nil), groups...),
+		Declared: true,
+	}
+}
+
+// TaskListener is a synchronous UserTask lifecycle hook. It runs in-band with
+// the lifecycle step, so keep it prompt. A non-nil error vetoes a TaskAssign
+// and rejects a TaskComplete; for TaskCreate and TaskDelete it is logged.
+type TaskListener interface {
+	NotifyTask(ctx context.Context, task *Task) error
+}
+
+// TaskFunc adapts a function to a TaskListener.
+type TaskFunc func(ctx context.Context, task *Task) error
+
+// NotifyTask calls f.
+func (f TaskFunc) NotifyTask(ctx context.Context, task *Task) error {
+	return f(ctx, task)
+}
+
+// TaskBinding is a TaskListener bound to the events and the UserTask it runs
+// for.
+type TaskBinding struct {
+	Listener TaskListener
+	// Events are the events the listener runs for; empty means every event.
+	Events []TaskEvent
+	// NodeID restricts the listener to one UserTask; empty means every one.
+	NodeID string
+}
+
+// Matches reports whether b runs for task.
+func (b TaskBinding) Matches(task *Task) bool {
+	if len(b.Events) != 0 && !slices.Contains(b.Events, task.Event) {
+		return false
+	}
+
+	return b.NodeID == "" || b.NodeID == task.NodeID
+}
@@ -0,0 +1,58 @@
+package listener_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gobpm/pkg/interactor"
+	"github.com/dr-dobermann/gobpm/pkg/listener"
+)
+
+func TestTaskBindingMatches(t *testing.T) {
+	create := &listener.Task{
+		TaskRef: interactor.TaskRef{NodeID: "review"},
+		Event:   listener.TaskCreate,
+	}
+
+	for _, tc := range []struct {
+		name    string
+		b       listener.TaskBinding
+		matches bool
+	}{
+		{"unbound", listener.TaskBinding{}, true},
+		{"event filtered in",
+			listener.TaskBinding{
+				Events: []listener.TaskEvent{listener.TaskCreate}},
+			true},
+		{"event filtered out",
+			listener.TaskBinding{
+				Events: []listener.TaskEvent{listener.TaskDelete}},
+			false},
+		{"node", listener.TaskBinding{NodeID: "review"}, true},
+		{"other node", listener.TaskBinding{NodeID: "approve"}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.matches, tc.b.Matches(create))
+		})
+	}
+}
+
+func TestTaskSetAssignment(t *testing.T) {
+	var task listener.Task
+
+	task.SetAssignee("alice")
+	task.SetCandidateUsers("bob", "carol")
+	task.SetCandidateGroups("reviewers")
+
+	require.Equal(t, interactor.ResolvedSlot{
+		IDs: []string{"alice"}, Declared: true}, task.Eligible.Assignee)
+	require.Equal(t, []string{"bob", "carol"},
+		task.Eligible.CandidateUsers.IDs)
+	require.Equal(t, []string{"reviewers"}, task.Eligible.CandidateGroups.IDs)
+	require.True(t, task.Eligible.CandidateGroups.Declared)
+	require.False(t, task.Eligible.Roles.Declared)
+
+	require.True(t, listener.TaskDelete.Valid())
+	require.False(t, listener.TaskEvent("suspend").Valid())
+}
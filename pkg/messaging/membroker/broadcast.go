@@ -0,0 +1,78 @@
+package membroker
+
+import (
+	"context"
+
+	"github.com/dr-dobermann/gobpm/pkg/messaging"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+)
+
+// Broadcast hands msg to every broadcast subscriber of its name
+// (messaging.Broadcaster). It never buffers: with no subscriber the message
+// is dropped, and a subscriber whose channel is full misses it with a
+// warning. A MessageID already taken within the dedup window is dropped
+// like a duplicate publish.
+func (b *Broker) Broadcast(ctx context.Context, msg messaging.Envelope) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.duplicateLocked(msg) {
+		b.logger.Debug("membroker: duplicate broadcast dropped",
+			observability.AttrMessageName, msg.Name, observability.AttrMessageID, msg.MessageID)
+
+		return nil
+	}
+
+	reached := 0
+
+	for _, s := range b.casts {
+		if s.name != msg.Name {
+			continue
+		}
+
+		if !trySend(s.ch, msg) {
+			b.logger.Warn("membroker: broadcast subscriber busy; broadcast missed",
+				observability.AttrMessageName, msg.Name, observability.AttrMessageID, msg.MessageID)
+
+			continue
+		}
+
+		reached++
+	}
+
+	b.logger.Debug("membroker: broadcast",
+		observability.AttrMessageName, msg.Name, "reached", reached)
+
+	return nil
+}
+
+// SubscribeBroadcast registers interest in the broadcasts named name
+// (messaging.Broadcaster). The subscription receives only broadcasts made
+// after the call; its AddKey is rejected.
+func (b *Broker) SubscribeBroadcast(
+	ctx context.Context, name string,
+) (messaging.Subscription, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	sub := &subscription{
+		ch:        make(chan messaging.Envelope, subBuffer),
+		keys:      map[string]struct{}{},
+		name:      name,
+		broadcast: true,
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.casts = append(b.casts, sub)
+
+	b.logger.Debug("membroker: broadcast subscribed", observability.AttrMessageName, name)
+
+	return brokerSub{b: b, sub: sub}, nil
+}
@@ -0,0 +1,182 @@
+package membroker
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/dr-dobermann/gobpm/pkg/messaging"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+)
+
+// DeadLetterReason tells why the broker gave up on a message.
+type DeadLetterReason string
+
+const (
+	// ReasonExpired: the message's TTL lapsed before a subscriber took it.
+	ReasonExpired DeadLetterReason = "expired"
+	// ReasonEvicted: the inbox cap pushed the message out.
+	ReasonEvicted DeadLetterReason = "evicted"
+)
+
+// DeadLetter is an undelivered message the broker gave up on, kept for
+// inspection until it is replayed or purged.
+type DeadLetter struct {
+	// At is when the message was dead-lettered, on the broker's clock.
+	At time.Time
+	// Envelope is the message as it was published.
+	Envelope messaging.Envelope
+	// ID is broker-assigned and names the entry for ReplayDeadLetters and
+	// PurgeDeadLetters.
+	ID string
+	// Reason tells why the message was dead-lettered.
+	Reason DeadLetterReason
+}
+
+// DeadLetters returns the dead-letter queue, oldest first. Buffered
+// messages whose TTL has lapsed are moved there first, so the list is exact
+// at the time of the call.
+func (b *Broker) DeadLetters() []DeadLetter {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.expireLocked()
+
+	return slices.Clone(b.dead)
+}
+
+// ReplayDeadLetters publishes the dead letters named by ids again — every
+// dead letter when ids is empty — and removes them from the queue. A replay
+// is deliberate, so it is not deduplicated; it routes like a fresh publish
+// and its TTL, if any, starts over. Unknown ids are skipped. It returns how
+// many messages were replayed.
+func (b *Broker) ReplayDeadLetters(ctx context.Context, ids ...string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.expireLocked()
+
+	taken := b.takeDeadLocked(ids)
+	for _, dl := range taken {
+		b.reportLocked(observability.PhaseReplayed, dl)
+		b.routeLocked(dl.Envelope)
+	}
+
+	return len(taken), nil
+}
+
+// PurgeDeadLetters discards the dead letters named by ids — every dead
+// letter when ids is empty. Unknown ids are skipped. It returns how many
+// messages were purged.
+func (b *Broker) PurgeDeadLetters(ids ...string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	taken := b.takeDeadLocked(ids)
+	for _, dl := range taken {
+		b.reportLocked(observability.PhasePurged, dl)
+	}
+
+	return len(taken)
+}
+
+// takeDeadLocked removes and returns the dead letters named by ids, or all
+// of them when ids is empty, in queue order. Caller holds mu.
+func (b *Broker) takeDeadLocked(ids []string) []DeadLetter {
+	if len(ids) == 0 {
+		taken := b.dead
+		b.dead = nil
+
+		return taken
+	}
+
+	var taken []DeadLetter
+
+	b.dead = slices.DeleteFunc(b.dead, func(dl DeadLetter) bool {
+		if slices.Contains(ids, dl.ID) {
+			taken = append(taken, dl)
+
+			return true
+		}
+
+		return false
+	})
+
+	return taken
+}
+
+// expireLocked dead-letters every buffered envelope whose TTL has lapsed.
+// Caller holds mu.
+func (b *Broker) expireLocked() {
+	now := b.clock.Now()
+
+	b.inbox = slices.DeleteFunc(b.inbox, func(e buffered) bool {
+		if e.expires.IsZero() || now.Before(e.expires) {
+			return false
+		}
+
+		b.deadLetterLocked(e.env, ReasonExpired)
+
+		return true
+	})
+}
+
+// deadLetterLocked parks msg in the dead-letter queue, dropping the oldest
+// entry past the cap with a Dropped fact — the message is gone for good.
+// Caller holds mu.
+func (b *Broker) deadLetterLocked(msg messaging.Envelope, reason DeadLetterReason) {
+	b.deadSeq++
+
+	dl := DeadLetter{
+		At:       b.clock.Now(),
+		Envelope: msg,
+		ID:       strconv.FormatUint(b.deadSeq, 10),
+		Reason:   reason,
+	}
+
+	b.dead = append(b.dead, dl)
+	b.reportLocked(observability.PhaseDeadLettered, dl)
+
+	if b.maxDead <= 0 {
+		return
+	}
+
+	for len(b.dead) > b.maxDead {
+		b.reportLocked(observability.PhaseDropped, b.dead[0])
+		b.dead = b.dead[1:]
+
+		b.deadWarnOnce.Do(func() {
+			b.logger.Warn("membroker: dead-letter cap reached, dropping oldest",
+				"cap", b.maxDead)
+		})
+	}
+}
+
+// reportLocked emits an EventFlow fact of phase for dl — names and ids
+// only, never the payload. Caller holds mu.
+func (b *Broker) reportLocked(phase observability.Phase, dl DeadLetter) {
+	details := map[string]string{
+		observability.AttrDeadLetterID: dl.ID,
+		observability.AttrMessageName:  dl.Envelope.Name,
+		"reason":                       string(dl.Reason),
+	}
+
+	if dl.Envelope.MessageID != "" {
+		details[observability.AttrMessageID] = dl.Envelope.MessageID
+	}
+
+	if dl.Envelope.CorrelationKey != "" {
+		details[observability.AttrCorrelationValue] = dl.Envelope.CorrelationKey
+	}
+
+	b.reporter.Report(observability.Fact{
+		Kind:    observability.KindEventFlow,
+		Phase:   phase,
+		Details: details,
+	})
+}
@@ -1,6 +1,6 @@
 // Package membroker provides the engine's default MessageBroker: an in-memory
 // inbox + correlation router. Undelivered envelopes are buffered in a bounded
-// inbox that drops the oldest and warns once past the cap, so uncorrelated
+// inbox that dead-letters the oldest and warns once past the cap, so uncorrelated
 // messages cannot grow unbounded (the bounded-in-memory-defaults principle,
 // ADR-002 §4.2).
 //
@@ -9,13 +9,35 @@
 // wildcard subscription, so a follow-up message routes to the conversation that
 // owns it rather than to the engine-level instance-starter. A subscription's
 // key-set can grow at runtime via AddKey (lazy secondary-key association).
+//
+// An envelope carrying a MessageID is delivered at most once per dedup window:
+// a re-publish of an id the broker already took within the window is dropped
+// as a duplicate. The window runs on the broker's clock.Clock, so tests drive
+// it with clocktest; the remembered ids are bounded like the inbox.
+//
+// A buffered envelope may carry a time-to-live — its own Envelope.TTL, or the
+// per-name TTL set by WithMessageTTL. An envelope whose TTL lapses before a
+// subscriber claims it, and one the inbox cap evicts, is moved to a bounded
+// dead-letter queue instead of vanishing; DeadLetters lists the queue,
+// ReplayDeadLetters publishes entries again and PurgeDeadLetters discards
+// them. Expiry is swept on every broker call, so the queue is exact whenever
+// it is read. Each move emits a KindEventFlow fact through the broker's
+// Reporter (echo-only until the engine binds its own, see BindReporter).
+//
+// The broker is also a messaging.Broadcaster: Broadcast hands a message to
+// every broadcast subscriber of its name at once — the fan-out an engine
+// group sharing one broker carries its signals over. A broadcast is never
+// buffered or dead-lettered; a subscriber whose channel is full misses it.
 package membroker
 
 import (
 	"context"
 	"log/slog"
 	"sync"
+	"time"
 
+	"github.com/dr-dobermann/gobpm/pkg/clock"
+	"github.com/dr-dobermann/gobpm/pkg/clock/syscl"
 	"github.com/dr-dobermann/gobpm/pkg/errs"
 	"github.com/dr-dobermann/gobpm/pkg/messaging"
 	"github.com/dr-dobermann/gobpm/pkg/observability"
@@ -24,6 +46,13 @@ import (
 const (
 	// DefaultMaxInbox is the default cap on buffered undelivered envelopes.
 	DefaultMaxInbox = 1024
+	// DefaultDedupWindow is how long a published MessageID is remembered.
+	DefaultDedupWindow = 10 * time.Minute
+	// DefaultMaxDeadLetters is the default cap on the dead-letter queue.
+	DefaultMaxDeadLetters = 1024
+	// maxSeen caps the remembered MessageIDs; past it the oldest is forgotten
+	// before its window ends.
+	maxSeen = 4 * DefaultMaxInbox
 	// subBuffer is the per-subscription channel buffer.
 	subBuffer = 16
 
@@ -32,21 +61,47 @@ const (
 
 // Broker is an in-memory messaging.MessageBroker.
 type Broker struct {
-	logger   observability.Logger
-	inbox    []messaging.Envelope
-	subs     []*subscription
-	maxInbox int
-	mu       sync.Mutex
-	warnOnce sync.Once
+	logger       observability.Logger
+	clock        clock.Clock
+	reporter     observability.Reporter
+	seen         map[string]struct{}
+	nameTTL      map[string]time.Duration
+	inbox        []buffered
+	dead         []DeadLetter
+	subs         []*subscription
+	casts        []*subscription
+	seenOrder    []seenID
+	maxInbox     int
+	maxDead      int
+	dedupWindow  time.Duration
+	deadSeq      uint64
+	mu           sync.Mutex
+	warnOnce     sync.Once
+	deadWarnOnce sync.Once
+}
+
+// buffered is an inbox entry: the envelope and when its TTL lapses (zero
+// when it has none).
+type buffered struct {
+	expires time.Time
+	env     messaging.Envelope
+}
+
+// seenID is a remembered MessageID and when the broker took it.
+type seenID struct {
+	at time.Time
+	id string
 }
 
 // subscription is a live registration. An empty keys set is a wildcard that
 // matches any correlation key for the name; a non-empty set matches only a
-// message whose CorrelationKey is in it.
+// message whose CorrelationKey is in it. A broadcast subscription lives in
+// the broker's casts, never in subs, and takes no keys.
 type subscription struct {
-	ch   chan messaging.Envelope
-	keys map[string]struct{}
-	name string
+	ch        chan messaging.Envelope
+	keys      map[string]struct{}
+	name      string
+	broadcast bool
 }
 
 // keyed reports whether s restricts delivery to its key-set (vs wildcard).
@@ -92,9 +147,18 @@ func (h brokerSub) AddKey(key string) error {
 			errs.C(errorClass, errs.EmptyNotAllowed))
 	}
 
+	if h.sub.broadcast {
+		return errs.New(
+			errs.M("membroker.AddKey: a broadcast subscription takes no keys"),
+			errs.C(errorClass, errs.InvalidParameter),
+			errs.D(observability.AttrMessageName, h.sub.name))
+	}
+
 	h.b.mu.Lock()
 	defer h.b.mu.Unlock()
 
+	h.b.expireLocked()
+
 	before := len(h.b.inbox)
 	h.sub.keys[key] = struct{}{}
 	h.b.drainInboxLocked(h.sub)
@@ -113,9 +177,14 @@ func (h brokerSub) Unsubscribe() error {
 	h.b.mu.Lock()
 	defer h.b.mu.Unlock()
 
-	for i, s := range h.b.subs {
+	list := &h.b.subs
+	if h.sub.broadcast {
+		list = &h.b.casts
+	}
+
+	for i, s := range *list {
 		if s == h.sub {
-			h.b.subs = append(h.b.subs[:i], h.b.subs[i+1:]...)
+			*list = append((*list)[:i], (*list)[i+1:]...)
 
 			h.b.logger.Debug("membroker: unsubscribed", observability.AttrMessageName, h.sub.name)
 
@@ -132,29 +201,90 @@ type Option func(*Broker)
 // WithMaxInbox sets the cap on buffered undelivered envelopes; n <= 0 disables it.
 func WithMaxInbox(n int) Option { return func(b *Broker) { b.maxInbox = n } }
 
-// WithLogger sets the logger used for the inbox-eviction warning.
+// WithMaxDeadLetters sets the cap on the dead-letter queue; past it the
+// oldest dead letter is dropped and reported as a Dropped EventFlow fact.
+// n <= 0 disables the cap.
+func WithMaxDeadLetters(n int) Option { return func(b *Broker) { b.maxDead = n } }
+
+// WithMessageTTL sets the time-to-live of buffered messages named name that
+// carry no TTL of their own; ttl <= 0 removes it.
+func WithMessageTTL(name string, ttl time.Duration) Option {
+	return func(b *Broker) {
+		if ttl <= 0 {
+			delete(b.nameTTL, name)
+
+			return
+		}
+
+		b.nameTTL[name] = ttl
+	}
+}
+
+// WithLogger sets the logger used for the inbox-eviction warning and, until a
+// Reporter is bound, the fact echo.
 func WithLogger(l observability.Logger) Option { return func(b *Broker) { b.logger = l } }
 
-// New returns an in-memory Broker with the default inbox cap and slog.Default()
-// logger, overridden by opts.
+// WithDedupWindow sets how long a published MessageID is remembered; d <= 0
+// disables deduplication.
+func WithDedupWindow(d time.Duration) Option {
+	return func(b *Broker) { b.dedupWindow = d }
+}
+
+// WithClock sets the clock the dedup window and the message TTLs run on; a
+// nil clock keeps the system clock.
+func WithClock(c clock.Clock) Option {
+	return func(b *Broker) {
+		if c != nil {
+			b.clock = c
+		}
+	}
+}
+
+// New returns an in-memory Broker with the default inbox and dead-letter
+// caps, dedup window, system clock and slog.Default() logger, no message TTL
+// and an echo-only Reporter, overridden by opts.
 func New(opts ...Option) *Broker {
 	b := &Broker{
-		logger:   slog.Default(),
-		maxInbox: DefaultMaxInbox,
+		logger:      slog.Default(),
+		clock:       syscl.New(),
+		seen:        map[string]struct{}{},
+		nameTTL:     map[string]time.Duration{},
+		maxInbox:    DefaultMaxInbox,
+		maxDead:     DefaultMaxDeadLetters,
+		dedupWindow: DefaultDedupWindow,
 	}
 
 	for _, o := range opts {
 		o(b)
 	}
 
+	b.reporter = observability.NewEchoReporter(b.logger)
+
 	return b
 }
 
+// BindReporter installs the engine's observable-event sink
+// (messaging.ReporterBinder), so the broker's dead-letter facts land on the
+// engine seam (echo + observers). A nil sink is ignored, keeping the
+// echo-only default.
+func (b *Broker) BindReporter(sink observability.Reporter) {
+	if sink == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.reporter = sink
+}
+
 // Publish delivers the message most-specifically: to a keyed subscriber whose
 // key-set contains the message key if one exists, else to a wildcard subscriber,
 // else it is buffered in the bounded inbox. A message claimed by a keyed
 // subscriber whose channel is momentarily full is buffered (for that
-// subscriber's later drain), never handed to a wildcard subscriber.
+// subscriber's later drain), never handed to a wildcard subscriber. A message
+// whose MessageID was already published within the dedup window is dropped
+// and reported as published — the first copy is the one delivered.
 func (b *Broker) Publish(ctx context.Context, msg messaging.Envelope) error {
 	if err := ctx.Err(); err != nil {
 		return err
@@ -163,6 +293,23 @@ func (b *Broker) Publish(ctx context.Context, msg messaging.Envelope) error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
+	b.expireLocked()
+
+	if b.duplicateLocked(msg) {
+		b.logger.Debug("membroker: duplicate dropped",
+			observability.AttrMessageName, msg.Name, observability.AttrMessageID, msg.MessageID)
+
+		return nil
+	}
+
+	b.routeLocked(msg)
+
+	return nil
+}
+
+// routeLocked is Publish past the dedup check: it delivers msg
+// most-specifically or buffers it. Caller holds mu.
+func (b *Broker) routeLocked(msg messaging.Envelope) {
 	keyedMatched := false
 
 	for _, s := range b.subs {
@@ -176,7 +323,7 @@ func (b *Broker) Publish(ctx context.Context, msg messaging.Envelope) error {
 			b.logger.Debug("membroker: routed (keyed)",
 				observability.AttrMessageName, msg.Name, observability.AttrCorrelationValue, msg.CorrelationKey)
 
-			return nil
+			return
 		}
 	}
 
@@ -185,7 +332,7 @@ func (b *Broker) Publish(ctx context.Context, msg messaging.Envelope) error {
 			observability.AttrMessageName, msg.Name, observability.AttrCorrelationValue, msg.CorrelationKey)
 		b.bufferLocked(msg)
 
-		return nil
+		return
 	}
 
 	for _, s := range b.subs {
@@ -197,15 +344,13 @@ func (b *Broker) Publish(ctx context.Context, msg messaging.Envelope) error {
 			b.logger.Debug("membroker: routed (wildcard)",
 				observability.AttrMessageName, msg.Name, observability.AttrCorrelationValue, msg.CorrelationKey)
 
-			return nil
+			return
 		}
 	}
 
 	b.logger.Debug("membroker: buffered (no subscriber)",
 		observability.AttrMessageName, msg.Name, observability.AttrCorrelationValue, msg.CorrelationKey)
 	b.bufferLocked(msg)
-
-	return nil
 }
 
 // Subscribe registers interest in messages named name. With no keys (or only
@@ -228,6 +373,7 @@ func (b *Broker) Subscribe(
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
+	b.expireLocked()
 	b.drainInboxLocked(sub)
 	b.subs = append(b.subs, sub)
 
@@ -243,7 +389,7 @@ func (b *Broker) drainInboxLocked(sub *subscription) {
 	kept := b.inbox[:0]
 
 	for _, e := range b.inbox {
-		if sub.matches(e) && trySend(sub.ch, e) {
+		if sub.matches(e.env) && trySend(sub.ch, e.env) {
 			continue
 		}
 
@@ -253,20 +399,32 @@ func (b *Broker) drainInboxLocked(sub *subscription) {
 	b.inbox = kept
 }
 
-// bufferLocked appends to the bounded inbox, evicting the oldest past the cap.
-// Caller holds mu.
+// bufferLocked appends to the bounded inbox, stamping the envelope's TTL
+// deadline and evicting the oldest past the cap. Caller holds mu.
 func (b *Broker) bufferLocked(msg messaging.Envelope) {
-	b.inbox = append(b.inbox, msg)
+	ttl := msg.TTL
+	if ttl <= 0 {
+		ttl = b.nameTTL[msg.Name]
+	}
+
+	e := buffered{env: msg}
+	if ttl > 0 {
+		e.expires = b.clock.Now().Add(ttl)
+	}
+
+	b.inbox = append(b.inbox, e)
 	b.evictInboxLocked()
 }
 
-// evictInboxLocked drops oldest buffered envelopes past the cap. Caller holds mu.
+// evictInboxLocked dead-letters the oldest buffered envelopes past the cap.
+// Caller holds mu.
 func (b *Broker) evictInboxLocked() {
 	if b.maxInbox <= 0 {
 		return
 	}
 
 	for len(b.inbox) > b.maxInbox {
+		b.deadLetterLocked(b.inbox[0].env, ReasonEvicted)
 		b.inbox = b.inbox[1:]
 
 		b.warnOnce.Do(func() {
@@ -276,6 +434,37 @@ func (b *Broker) evictInboxLocked() {
 	}
 }
 
+// duplicateLocked reports whether msg repeats a MessageID taken within the
+// dedup window, remembering it otherwise. Anonymous messages are never
+// duplicates. Caller holds mu.
+func (b *Broker) duplicateLocked(msg messaging.Envelope) bool {
+	if msg.MessageID == "" || b.dedupWindow <= 0 {
+		return false
+	}
+
+	now := b.clock.Now()
+
+	for len(b.seenOrder) > 0 &&
+		now.Sub(b.seenOrder[0].at) >= b.dedupWindow {
+		delete(b.seen, b.seenOrder[0].id)
+		b.seenOrder = b.seenOrder[1:]
+	}
+
+	if _, ok := b.seen[msg.MessageID]; ok {
+		return true
+	}
+
+	b.seen[msg.MessageID] = struct{}{}
+	b.seenOrder = append(b.seenOrder, seenID{at: now, id: msg.MessageID})
+
+	if len(b.seenOrder) > maxSeen {
+		delete(b.seen, b.seenOrder[0].id)
+		b.seenOrder = b.seenOrder[1:]
+	}
+
+	return false
+}
+
 // trySend delivers e to ch without blocking; it reports whether it succeeded.
 func trySend(ch chan messaging.Envelope, e messaging.Envelope) bool {
 	select {
@@ -302,5 +491,6 @@ func keySet(keys []string) map[string]struct{} {
 
 var (
 	_ messaging.MessageBroker = (*Broker)(nil)
+	_ messaging.Broadcaster   = (*Broker)(nil)
 	_ messaging.Subscription  = brokerSub{}
 )
@@ -0,0 +1,94 @@
+package membroker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dr-dobermann/gobpm/pkg/messaging"
+)
+
+func subscribeBroadcast(t *testing.T, b *Broker, name string) messaging.Subscription {
+	t.Helper()
+
+	s, err := b.SubscribeBroadcast(context.Background(), name)
+	if err != nil {
+		t.Fatalf("subscribe broadcast: %v", err)
+	}
+
+	return s
+}
+
+// TestBroadcastReachesEverySubscriber: every broadcast subscriber of the
+// name gets its own copy; a regular subscriber and another name get none.
+func TestBroadcastReachesEverySubscriber(t *testing.T) {
+	b := New()
+	ctx := context.Background()
+
+	a := subscribeBroadcast(t, b, "sig").C()
+	c := subscribeBroadcast(t, b, "sig").C()
+	other := subscribeBroadcast(t, b, "other").C()
+	plain := subscribe(t, b, "sig").C()
+
+	if err := b.Broadcast(ctx, messaging.Envelope{Name: "sig", Payload: 1}); err != nil {
+		t.Fatalf("broadcast: %v", err)
+	}
+
+	for i, ch := range []<-chan messaging.Envelope{a, c} {
+		if got := drain(ch); len(got) != 1 || got[0].Payload != 1 {
+			t.Fatalf("subscriber %d got %v, want one copy", i, got)
+		}
+	}
+
+	if got := drain(other); len(got) != 0 {
+		t.Fatalf("another name got %v", got)
+	}
+
+	if got := drain(plain); len(got) != 0 {
+		t.Fatalf("a Subscribe subscription got a broadcast: %v", got)
+	}
+}
+
+// TestBroadcastIsNeverBuffered: a broadcast without subscribers is dropped —
+// neither a later broadcast subscriber nor a Subscribe gets it.
+func TestBroadcastIsNeverBuffered(t *testing.T) {
+	b := New()
+	ctx := context.Background()
+
+	_ = b.Broadcast(ctx, messaging.Envelope{Name: "sig"})
+
+	if got := drain(subscribeBroadcast(t, b, "sig").C()); len(got) != 0 {
+		t.Fatalf("a late broadcast subscriber got %v", got)
+	}
+
+	if got := drain(subscribe(t, b, "sig").C()); len(got) != 0 {
+		t.Fatalf("a broadcast was buffered: %v", got)
+	}
+}
+
+func TestBroadcastDedupAndUnsubscribe(t *testing.T) {
+	b := New()
+	ctx := context.Background()
+	sub := subscribeBroadcast(t, b, "sig")
+
+	for range 2 {
+		_ = b.Broadcast(ctx, idEnv("sig", "s-1"))
+	}
+
+	if got := drain(sub.C()); len(got) != 1 {
+		t.Fatalf("delivered %d copies, want 1", len(got))
+	}
+
+	if err := sub.AddKey("k"); err == nil {
+		t.Fatal("AddKey on a broadcast subscription must be rejected")
+	}
+
+	if err := sub.Unsubscribe(); err != nil {
+		t.Fatalf("unsubscribe: %v", err)
+	}
+
+	_ = b.Broadcast(ctx, messaging.Envelope{Name: "sig"})
+
+	if got := drain(sub.C()); len(got) != 0 {
+		t.Fatalf("an unsubscribed subscriber got %v", got)
+	}
+}
@@ -0,0 +1,166 @@
+package membroker
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gobpm/pkg/clock/clocktest"
+	"github.com/dr-dobermann/gobpm/pkg/messaging"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+)
+
+// recReporter records the facts the broker reports.
+type recReporter struct {
+	facts []observability.Fact
+	mu    sync.Mutex
+}
+
+func (r *recReporter) Report(f observability.Fact) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.facts = append(r.facts, f)
+}
+
+// phases returns the phases reported so far, in order.
+func (r *recReporter) phases() []observability.Phase {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var out []observability.Phase
+	for _, f := range r.facts {
+		out = append(out, f.Phase)
+	}
+
+	return out
+}
+
+// ttlBroker is a broker on a test clock with a bound recording reporter.
+func ttlBroker(opts ...Option) (*Broker, *clocktest.Clock, *recReporter) {
+	ck := clocktest.New(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	rep := &recReporter{}
+
+	b := New(append([]Option{WithClock(ck)}, opts...)...)
+	b.BindReporter(rep)
+
+	return b, ck, rep
+}
+
+// TestTTLExpiresToDeadLetters: a buffered message past its TTL — its own
+// or its name's — is dead-lettered instead of delivered; one without a TTL
+// waits on.
+func TestTTLExpiresToDeadLetters(t *testing.T) {
+	ctx := context.Background()
+	b, ck, rep := ttlBroker(WithMessageTTL("order", time.Minute))
+
+	require.NoError(t, b.Publish(ctx, messaging.Envelope{
+		Name: "ping", MessageID: "p-1", TTL: 10 * time.Second}))
+	require.NoError(t, b.Publish(ctx, messaging.Envelope{
+		Name: "order", CorrelationKey: "ORD-1"}))
+	require.NoError(t, b.Publish(ctx, env("note", "")))
+
+	ck.Advance(10 * time.Second)
+
+	dead := b.DeadLetters()
+	require.Len(t, dead, 1)
+	require.Equal(t, "p-1", dead[0].Envelope.MessageID)
+	require.Equal(t, ReasonExpired, dead[0].Reason)
+	require.Equal(t, ck.Now(), dead[0].At)
+
+	ck.Advance(50 * time.Second)
+
+	require.Empty(t, drain(subscribe(t, b, "order").C()),
+		"an expired message must not reach a late subscriber")
+	require.Len(t, drain(subscribe(t, b, "note").C()), 1)
+	require.Len(t, b.DeadLetters(), 2)
+
+	require.Equal(t, []observability.Phase{
+		observability.PhaseDeadLettered, observability.PhaseDeadLettered,
+	}, rep.phases())
+
+	f := rep.facts[1]
+	require.Equal(t, observability.KindEventFlow, f.Kind)
+	require.Equal(t, "order", f.Details[observability.AttrMessageName])
+	require.Equal(t, "ORD-1", f.Details[observability.AttrCorrelationValue])
+	require.Equal(t, string(ReasonExpired), f.Details["reason"])
+}
+
+// TestEvictedToDeadLetters: the inbox cap dead-letters the oldest message
+// instead of dropping it, and the dead-letter queue is bounded in turn, each
+// entry dropped past its cap reported.
+func TestEvictedToDeadLetters(t *testing.T) {
+	ctx := context.Background()
+	b, _, rep := ttlBroker(WithMaxInbox(1), WithMaxDeadLetters(2))
+
+	for _, k := range []string{"a", "b", "c", "d"} {
+		require.NoError(t, b.Publish(ctx, env("m", k)))
+	}
+
+	dead := b.DeadLetters()
+	require.Len(t, dead, 2, "the oldest dead letter is dropped past the cap")
+	require.Equal(t, "b", dead[0].Envelope.CorrelationKey)
+	require.Equal(t, "c", dead[1].Envelope.CorrelationKey)
+	require.Equal(t, ReasonEvicted, dead[0].Reason)
+
+	require.Equal(t, []observability.Phase{
+		observability.PhaseDeadLettered, observability.PhaseDeadLettered,
+		observability.PhaseDeadLettered, observability.PhaseDropped,
+	}, rep.phases())
+
+	f := rep.facts[3]
+	require.Equal(t, observability.KindEventFlow, f.Kind)
+	require.Equal(t, "a", f.Details[observability.AttrCorrelationValue])
+	require.Equal(t, "1", f.Details[observability.AttrDeadLetterID])
+}
+
+// TestReplayAndPurgeDeadLetters: a replay publishes the message again —
+// past the dedup window's memory of its id — and a purge discards it; both
+// leave the queue and report a fact.
+func TestReplayAndPurgeDeadLetters(t *testing.T) {
+	ctx := context.Background()
+	b, ck, rep := ttlBroker()
+
+	for _, id := range []string{"m-1", "m-2", "m-3"} {
+		require.NoError(t, b.Publish(ctx, messaging.Envelope{
+			Name: "m", MessageID: id, TTL: time.Second}))
+	}
+
+	ck.Advance(time.Second)
+
+	dead := b.DeadLetters()
+	require.Len(t, dead, 3)
+
+	ch := subscribe(t, b, "m").C()
+
+	n, err := b.ReplayDeadLetters(ctx, dead[1].ID, "no-such")
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	got := drain(ch)
+	require.Len(t, got, 1)
+	require.Equal(t, "m-2", got[0].MessageID)
+
+	require.Equal(t, 1, b.PurgeDeadLetters(dead[0].ID))
+
+	n, err = b.ReplayDeadLetters(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, n, "an empty id list replays the rest")
+	require.Empty(t, b.DeadLetters())
+	require.Equal(t, 0, b.PurgeDeadLetters())
+
+	require.Equal(t, []observability.Phase{
+		observability.PhaseDeadLettered, observability.PhaseDeadLettered,
+		observability.PhaseDeadLettered, observability.PhaseReplayed,
+		observability.PhasePurged, observability.PhaseReplayed,
+	}, rep.phases())
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	_, err = b.ReplayDeadLetters(canceled)
+	require.Error(t, err)
+}
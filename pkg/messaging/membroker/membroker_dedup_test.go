@@ -0,0 +1,114 @@
+package membroker
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/dr-dobermann/gobpm/pkg/clock/clocktest"
+	"github.com/dr-dobermann/gobpm/pkg/messaging"
+)
+
+func idEnv(name, id string) messaging.Envelope {
+	return messaging.Envelope{Payload: id, Name: name, MessageID: id}
+}
+
+// TestDedupDropsRepeatWithinWindow: a MessageID published twice inside the
+// window delivers once; after the window passes it is a new message again.
+func TestDedupDropsRepeatWithinWindow(t *testing.T) {
+	ck := clocktest.New(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	b := New(WithClock(ck), WithDedupWindow(time.Minute))
+	ctx := context.Background()
+	ch := subscribe(t, b, "m").C()
+
+	for range 2 {
+		if err := b.Publish(ctx, idEnv("m", "x-1")); err != nil {
+			t.Fatalf("publish: %v", err)
+		}
+	}
+
+	if got := drain(ch); len(got) != 1 {
+		t.Fatalf("delivered %d copies, want 1", len(got))
+	}
+
+	ck.Advance(59 * time.Second)
+	_ = b.Publish(ctx, idEnv("m", "x-1"))
+
+	if got := drain(ch); len(got) != 0 {
+		t.Fatalf("a repeat inside the window delivered %d", len(got))
+	}
+
+	ck.Advance(time.Second)
+	_ = b.Publish(ctx, idEnv("m", "x-1"))
+
+	if got := drain(ch); len(got) != 1 {
+		t.Fatalf("a repeat after the window delivered %d, want 1", len(got))
+	}
+}
+
+// TestDedupSkipsAnonymousAndDisabled: messages without an id are never
+// deduplicated, and a non-positive window turns deduplication off.
+func TestDedupSkipsAnonymousAndDisabled(t *testing.T) {
+	ctx := context.Background()
+
+	b := New()
+	ch := subscribe(t, b, "m").C()
+
+	for range 2 {
+		_ = b.Publish(ctx, env("m", ""))
+	}
+
+	if got := drain(ch); len(got) != 2 {
+		t.Fatalf("anonymous messages delivered %d, want 2", len(got))
+	}
+
+	off := New(WithDedupWindow(0), WithClock(nil))
+	ch = subscribe(t, off, "m").C()
+
+	for range 2 {
+		_ = off.Publish(ctx, idEnv("m", "x-1"))
+	}
+
+	if got := drain(ch); len(got) != 2 {
+		t.Fatalf("a disabled window delivered %d, want 2", len(got))
+	}
+}
+
+// TestDedupCoversBufferedMessages: the id is taken at publish, so a repeat
+// of a message still waiting in the inbox is dropped too.
+func TestDedupCoversBufferedMessages(t *testing.T) {
+	ctx := context.Background()
+	b := New(WithClock(clocktest.New(time.Unix(0, 0))))
+
+	for range 3 {
+		_ = b.Publish(ctx, idEnv("m", "x-1"))
+	}
+
+	if got := drain(subscribe(t, b, "m").C()); len(got) != 1 {
+		t.Fatalf("buffered copies delivered %d, want 1", len(got))
+	}
+}
+
+// TestDedupRemembersBoundedIDs: past the cap the oldest id is forgotten even
+// inside its window, so the remembered set cannot grow without bound.
+func TestDedupRemembersBoundedIDs(t *testing.T) {
+	ctx := context.Background()
+	b := New(WithMaxInbox(0), WithClock(clocktest.New(time.Unix(0, 0))))
+
+	for i := range maxSeen + 1 {
+		_ = b.Publish(ctx, idEnv("m", strconv.Itoa(i)))
+	}
+
+	if len(b.seenOrder) != maxSeen || len(b.seen) != maxSeen {
+		t.Fatalf("remembered %d/%d ids, want %d",
+			len(b.seenOrder), len(b.seen), maxSeen)
+	}
+
+	_ = b.Publish(ctx, idEnv("m", "0"))
+
+	if got := len(b.inbox); got != maxSeen+2 {
+		t.Fatalf("a forgotten id must publish again: inbox %d, want %d",
+			got, maxSeen+2)
+	}
+}
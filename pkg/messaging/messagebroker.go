@@ -6,7 +6,12 @@
 // (ADR-001 v.4 §9); this is the minimal skeleton contract.
 package messaging
 
-import "context"
+import (
+	"context"
+	"time"
+
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+)
 
 // Envelope is an incoming message instance awaiting correlation/delivery.
 type Envelope struct {
@@ -17,6 +22,15 @@ type Envelope struct {
 	// CorrelationKey selects the target instance/subscription; empty means
 	// "no key" (a wildcard subscription matches any key for Name).
 	CorrelationKey string
+	// MessageID identifies the message for deduplication; empty means
+	// "anonymous" — never deduplicated. A producer that may publish the same
+	// message twice (the engine's outbox relaying after a crash) stamps the
+	// same id on every attempt, so a deduplicating broker delivers it once.
+	MessageID string
+	// TTL bounds how long an undelivered message waits for a subscriber;
+	// zero leaves it to the broker (a per-name TTL, or none). A broker that
+	// keeps a dead-letter queue moves an expired message there.
+	TTL time.Duration
 }
 
 // Subscription is a live subscription handle returned by MessageBroker.Subscribe.
@@ -49,3 +63,43 @@ type MessageBroker interface {
 	// matches only a message whose CorrelationKey is in the key-set.
 	Subscribe(ctx context.Context, name string, keys ...string) (Subscription, error)
 }
+
+// ReporterBinder is an optional broker capability (the tasks.ReporterBinder
+// shape): the engine binds its observable-event sink at startup so the broker
+// can emit KindEventFlow facts for the messages it dead-letters, replays and
+// purges. A broker that doesn't implement it simply doesn't emit.
+type ReporterBinder interface {
+	BindReporter(sink observability.Reporter)
+}
+
+// Broadcaster is an optional broker capability: a live fan-out next to the
+// single-delivery Publish. A broadcast reaches EVERY broadcast subscriber of
+// its name — one per engine of a group — where a published message reaches
+// one. It is never buffered: a broadcast with no subscriber is dropped, and
+// one published before a subscriber joined never reaches it. The engine group
+// carries its thrown signals over it (signaling/brokerfanout); a broker that
+// doesn't implement it leaves signals local to their engine.
+type Broadcaster interface {
+	// Broadcast hands msg to every live broadcast subscriber of msg.Name.
+	// CorrelationKey and TTL are ignored; a MessageID already broadcast
+	// within the broker's dedup window may be dropped.
+	Broadcast(ctx context.Context, msg Envelope) error
+	// SubscribeBroadcast returns a subscription receiving the broadcasts of
+	// name. Its AddKey is rejected — a broadcast has no conversation.
+	SubscribeBroadcast(ctx context.Context, name string) (Subscription, error)
+}
+
+// Settler is an optional Subscription capability for a broker that keeps a
+// message until its receiver is done with it. By default such a broker
+// settles — acknowledges — an envelope once the receiver takes it from C. A
+// receiver that calls SettleManually before its first receive settles each
+// envelope itself instead, once the engine has taken the message into an
+// instance, so a crash in between leaves the message with the broker for
+// redelivery.
+type Settler interface {
+	// SettleManually makes every envelope taken from C wait for Settle.
+	SettleManually()
+	// Settle settles the envelope last taken from C: a nil err acknowledges
+	// it; an error leaves it with the broker, which redelivers it.
+	Settle(err error)
+}
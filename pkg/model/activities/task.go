@@ -2,6 +2,7 @@ package activities
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/dr-dobermann/gobpm/pkg/observability"
 	"slices"
@@ -387,7 +388,7 @@ func (t *task) loadFromStore(
 
 	if src := ia.SourceNames(); len(src) > 0 {
 		var ok bool
-		if d, ok, err = store.Get(ctx, src[0]); err != nil {
+		if d, ok, err = readStore(ctx, f, store, ref, src[0]); err != nil {
 			return errs.New(
 				errs.M("couldn't read DataStore %q key %q for task %q",
 					ref, src[0], t.Name()),
@@ -447,11 +448,18 @@ func (t *task) uploadToStore(
 		return t.opErr("couldn't clone output "+src.Name()+" for DataStore "+ref, cerr)
 	}
 
-	if perr := store.Put(ctx, oa.TargetName(), datum); perr != nil {
+	if perr := writeStore(ctx, f, store, ref, oa.TargetName(), datum); perr != nil {
+		// a lost compare-and-swap keeps its class at the node, so an incident
+		// retry policy can tell the conflict from a broken store.
+		classes := []string{errorClass, errs.OperationFailed}
+		if isConflict(perr) {
+			classes = append(classes, errs.ConcurrentUpdate)
+		}
+
 		return errs.New(
 			errs.M("couldn't write output %q into DataStore %q key %q for "+
 				"task %q", src.Name(), ref, oa.TargetName(), t.Name()),
-			errs.C(errorClass, errs.OperationFailed),
+			errs.C(classes...),
 			errs.E(perr))
 	}
 
@@ -462,6 +470,57 @@ func (t *task) uploadToStore(
 	return nil
 }
 
+// readStore reads name from the store. From a datastore.Versioned store through
+// a frame with exec.StoreVersions it notes the version read, so the execution's
+// write of the same key compare-and-swaps against it.
+func readStore(
+	ctx context.Context, f exec.Frame, store datastore.DataStore, ref, name string,
+) (data.Data, bool, error) {
+	vs, vok := store.(datastore.Versioned)
+	fv, fok := f.(exec.StoreVersions)
+
+	if !vok || !fok {
+		return store.Get(ctx, name)
+	}
+
+	d, version, ok, err := vs.GetVersioned(ctx, name)
+	if err != nil {
+		return nil, false, err
+	}
+
+	fv.NoteStoreVersion(ref, name, version)
+
+	return d, ok, nil
+}
+
+// writeStore writes d under name: with PutIf against the version the execution
+// read (readStore), or a plain Put when it read none.
+func writeStore(
+	ctx context.Context, f exec.Frame, store datastore.DataStore,
+	ref, name string, d data.Data,
+) error {
+	vs, vok := store.(datastore.Versioned)
+	fv, fok := f.(exec.StoreVersions)
+
+	if vok && fok {
+		if version, read := fv.StoreVersion(ref, name); read {
+			_, err := vs.PutIf(ctx, name, d, version)
+
+			return err
+		}
+	}
+
+	return store.Put(ctx, name, d)
+}
+
+// isConflict reports whether err is a datastore.Versioned compare-and-swap
+// conflict.
+func isConflict(err error) bool {
+	var ae *errs.ApplicationError
+
+	return errors.As(err, &ae) && ae.HasClass(errs.ConcurrentUpdate)
+}
+
 // updateOutputs checks the frame's output instances and fills every not-Ready
 // one from the frame's resolution (puts, inputs, container walk). It is the
 // completion-gate (ADR-011 v.2 §2.2): a required output that cannot be produced
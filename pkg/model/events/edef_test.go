@@ -171,6 +171,13 @@ func TestErrorDefinitions(t *testing.T) {
 			require.NotEmpty(t, sed)
 			require.Equal(t, "success!", sed.Signal().Item().Structure().Get(ctx))
 			require.Equal(t, s.ID(), sed.Signal().ID())
+
+			// a received signal is a stamped copy
+			require.Empty(t, sed.Origin())
+			remote := sed.WithOrigin("engine-b")
+			require.Equal(t, "engine-b", remote.Origin())
+			require.Empty(t, sed.Origin(), "WithOrigin must not touch the original")
+			require.Equal(t, sed.ID(), remote.ID())
 		})
 
 	t.Run("timer",
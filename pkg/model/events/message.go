@@ -14,6 +14,9 @@ import (
 type MessageEventDefinition struct {
 	message   *bpmncommon.Message
 	operation service.Operation
+	// messageID is the broker id of the delivered message a FIRED definition
+	// carries (messaging.Envelope.MessageID); empty on a model definition.
+	messageID string
 	definition
 }
 
@@ -75,6 +78,35 @@ func (med *MessageEventDefinition) Operation() service.Operation {
 	return med.operation
 }
 
+// MessageID returns the broker id of the message a fired definition
+// delivers, or "" for a model definition and an anonymous message.
+func (med *MessageEventDefinition) MessageID() string {
+	return med.messageID
+}
+
+// MessageIDOf returns the broker id a fired definition eDef delivers, or ""
+// for an anonymous message and any non-message trigger. It is the one
+// reading of a trigger's id the engine's deduplication keys on.
+func MessageIDOf(eDef flow.EventDefinition) string {
+	if med, ok := eDef.(*MessageEventDefinition); ok {
+		return med.MessageID()
+	}
+
+	return ""
+}
+
+// WithMessageID returns a copy of a fired definition stamped with the
+// delivered message's broker id. The message waiter stamps every fire, so a
+// receiver can tell a redelivery from a new message.
+func (med *MessageEventDefinition) WithMessageID(
+	id string,
+) *MessageEventDefinition {
+	c := *med
+	c.messageID = id
+
+	return &c
+}
+
 // ---------------- flow.EventDefinition interface -----------------------------
 
 // Type returns the MessageEventDefition's flow.EventTrigger.
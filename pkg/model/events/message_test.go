@@ -95,3 +95,20 @@ func TestNewMessageEventDefinition(t *testing.T) {
 			require.Equal(t, 100, nmed.GetItemsList()[0].Structure().Get(ctx))
 		})
 }
+
+// TestMessageIDOf: only a fired message definition carries a broker id.
+func TestMessageIDOf(t *testing.T) {
+	med := events.MustMessageEventDefinition(
+		bpmncommon.MustMessage("order", data.MustItemDefinition(
+			values.NewVariable(""), foundation.WithID("order_in"))), nil)
+
+	require.Empty(t, events.MessageIDOf(med), "a model definition has no id")
+	require.Equal(t, "m-1", events.MessageIDOf(med.WithMessageID("m-1")))
+
+	sig, err := events.NewSignal("go", data.MustItemDefinition(
+		values.NewVariable(""), foundation.WithID("go_in")))
+	require.NoError(t, err)
+
+	require.Empty(t, events.MessageIDOf(events.MustSignalEventDefinition(sig)))
+	require.Empty(t, events.MessageIDOf(nil))
+}
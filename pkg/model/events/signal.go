@@ -58,6 +58,10 @@ func (s *Signal) Item() *data.ItemDefinition {
 // SignalEventDefinition represents a signal event definition.
 type SignalEventDefinition struct {
 	signal *Signal
+	// origin is the id of the engine that threw a signal another engine of
+	// its group received (signaling.Signal.Origin); empty on a model
+	// definition and a locally thrown signal.
+	origin string
 	definition
 }
 
@@ -109,6 +113,24 @@ func (sed *SignalEventDefinition) Signal() *Signal {
 	return sed.signal
 }
 
+// Origin returns the id of the engine that threw a signal received from the
+// engine group, or "" for a model definition and a local throw.
+func (sed *SignalEventDefinition) Origin() string {
+	return sed.origin
+}
+
+// WithOrigin returns a copy of the definition stamped with the id of the
+// engine that threw it. The engine stamps every signal it receives from its
+// group, so a receiver can tell a remote throw from a local one.
+func (sed *SignalEventDefinition) WithOrigin(
+	engineID string,
+) *SignalEventDefinition {
+	c := *sed
+	c.origin = engineID
+
+	return &c
+}
+
 // ---------------- flow.EventDefinition interface -----------------------------
 
 // Type returns the SignalEventDefinition's flow.EventTrigger.
@@ -6,6 +6,7 @@ import (
 	"github.com/dr-dobermann/gobpm/pkg/errs"
 	"github.com/dr-dobermann/gobpm/pkg/messaging"
 	"github.com/dr-dobermann/gobpm/pkg/model/bpmncommon"
+	"github.com/dr-dobermann/gobpm/pkg/model/data"
 	"github.com/dr-dobermann/gobpm/pkg/model/service"
 	"github.com/dr-dobermann/gobpm/pkg/renv"
 )
@@ -18,6 +19,15 @@ type conversationKeyRecorder interface {
 	AssociateConversationKey(name, value string)
 }
 
+// messageStager is the optional runtime capability of the transactional
+// outbox: StageMessage records env (its payload value v, nil for an itemless
+// message) to be published only once the instance's next checkpoint commits,
+// and reports whether it took the message. A runtime without it, or one whose
+// outbox is off (staged=false), publishes at once.
+type messageStager interface {
+	StageMessage(ctx context.Context, env messaging.Envelope, v data.Value) (bool, error)
+}
+
 // Send binds msg's item from the execution scope (service.BindInput) and
 // publishes it to the runtime's MessageBroker as an Envelope keyed by the
 // message name (ADR-014 v.1 §2.6). When key is non-nil, Send derives the
@@ -26,6 +36,8 @@ type conversationKeyRecorder interface {
 // left empty (name-match only). A message that carries no item is published
 // with a nil payload. Send is the producer choreography shared by SendTask and
 // the throw message event; it names the BPMN intent and hides the broker hop.
+// A runtime with an outbox stages the Envelope instead of publishing it; the
+// runtime publishes it after the send's effects are durable.
 func Send(
 	ctx context.Context,
 	re renv.RuntimeEnvironment,
@@ -52,9 +64,14 @@ func Send(
 			errs.E(err))
 	}
 
-	var payload any
+	var (
+		value   data.Value
+		payload any
+	)
+
 	if item != nil {
-		payload = item.Structure().Get(ctx)
+		value = item.Structure()
+		payload = value.Get(ctx)
 	}
 
 	var corrKey string
@@ -81,11 +98,27 @@ func Send(
 		}
 	}
 
-	if err := re.MessageBroker().Publish(ctx, messaging.Envelope{
+	env := messaging.Envelope{
 		Name:           msg.Name(),
 		Payload:        payload,
 		CorrelationKey: corrKey,
-	}); err != nil {
+	}
+
+	if s, ok := re.(messageStager); ok {
+		staged, err := s.StageMessage(ctx, env, value)
+		if err != nil {
+			return errs.New(
+				errs.M("msgflow.Send: couldn't stage message %q", msg.Name()),
+				errs.C(errorClass, errs.OperationFailed),
+				errs.E(err))
+		}
+
+		if staged {
+			return nil
+		}
+	}
+
+	if err := re.MessageBroker().Publish(ctx, env); err != nil {
 		return errs.New(
 			errs.M("msgflow.Send: broker rejected message %q", msg.Name()),
 			errs.C(errorClass, errs.OperationFailed),
@@ -33,6 +33,27 @@ func (r *recordingRE) AssociateConversationKey(name, value string) {
 	r.recorded[name] = value
 }
 
+// stagingRE wraps a RuntimeEnvironment with the optional outbox capability:
+// it takes (or, with decline, refuses) every envelope Send hands it.
+type stagingRE struct {
+	renv.RuntimeEnvironment
+	err     error
+	staged  []messaging.Envelope
+	decline bool
+}
+
+func (r *stagingRE) StageMessage(
+	_ context.Context, env messaging.Envelope, _ data.Value,
+) (bool, error) {
+	if r.err != nil || r.decline {
+		return false, r.err
+	}
+
+	r.staged = append(r.staged, env)
+
+	return true, nil
+}
+
 // errBroker is a MessageBroker whose Publish always fails — it exercises the
 // broker-rejection path of msgflow.Send.
 type errBroker struct{}
@@ -146,6 +167,48 @@ func TestSend(t *testing.T) {
 			require.Equal(t, "ORD-99", rec.recorded["orderKey"])
 		})
 
+	t.Run("an outbox runtime stages instead of publishing",
+		func(t *testing.T) {
+			msg := bpmncommon.MustMessage("order placed",
+				data.MustItemDefinition(values.NewVariable(""),
+					foundation.WithID("order_item")))
+
+			re := mockrenv.NewMockRuntimeEnvironment(t)
+			re.EXPECT().
+				GetDataByID("order_item").
+				Return(readyParam("order_item", "ORD-5"), nil)
+
+			// no MessageBroker expectation: a staged send never publishes.
+			st := &stagingRE{RuntimeEnvironment: re}
+			require.NoError(t, msgflow.Send(ctx, st, msg, nil))
+			require.Len(t, st.staged, 1)
+			require.Equal(t, "ORD-5", st.staged[0].Payload)
+
+			st = &stagingRE{RuntimeEnvironment: re, err: fmt.Errorf("uncodable")}
+			err := msgflow.Send(ctx, st, msg, nil)
+			require.ErrorContains(t, err, "stage message")
+
+			var appErr *errs.ApplicationError
+			require.ErrorAs(t, err, &appErr)
+			require.True(t, appErr.HasClass(errs.OperationFailed))
+
+			broker := membroker.New()
+			sub, err := broker.Subscribe(ctx, "order placed")
+			require.NoError(t, err)
+
+			re.EXPECT().MessageBroker().Return(broker)
+
+			st = &stagingRE{RuntimeEnvironment: re, decline: true}
+			require.NoError(t, msgflow.Send(ctx, st, msg, nil))
+
+			select {
+			case env := <-sub.C():
+				require.Equal(t, "ORD-5", env.Payload)
+			default:
+				t.Fatal("a declined staging must publish directly")
+			}
+		})
+
 	t.Run("a failing key derivation fails the send",
 		func(t *testing.T) {
 			msg := bpmncommon.MustMessage("order placed",
@@ -40,6 +40,7 @@ type Process struct {
 	dataObjects   map[string]*dataobjects.DataObject
 	dataStoreRefs map[string]*datastores.DataStoreReference
 	laneSets      []*lanes.LaneSet
+	inputs        []*data.Parameter
 	name          string
 	foundation.BaseElement
 	CorrelationSubscriptions []*bpmncommon.CorrelationSubscription
@@ -50,6 +51,7 @@ type Process struct {
 //
 //	activities.WithRoles
 //	data.WithProperties
+//	process.WithInputs
 //	foundation.WithID
 //	foundation.WithDoc
 func New(
@@ -82,6 +84,9 @@ func New(
 		case lanes.LaneSetOption: // *processConfig implements lanes.LaneSetAdder
 			addErr(opt(&pc))
 
+		case InputOption:
+			addErr(opt(&pc))
+
 		case foundation.BaseOption:
 			pc.baseOpts = append(pc.baseOpts, opt)
 
@@ -121,6 +126,12 @@ func (p *Process) LaneSets() []*lanes.LaneSet {
 	return slices.Clone(p.laneSets)
 }
 
+// Inputs returns a copy of the Process's declared start inputs, in
+// declaration order (empty when the Process declares none).
+func (p *Process) Inputs() []*data.Parameter {
+	return slices.Clone(p.inputs)
+}
+
 // Properties returns the Process properties.
 func (p *Process) Properties() []*data.Property {
 	return slices.Collect(maps.Values(p.properties))
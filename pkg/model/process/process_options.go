@@ -22,9 +22,50 @@ type processConfig struct {
 	// no uniqueness rule, and lane order is visible in every diagram (SRD-076).
 	laneSets []*lanes.LaneSet
 
+	// inputs is the Process's input set — the start variables a host may (or,
+	// for a required input, must) pass when it starts an instance. Ordered as
+	// declared; names are unique.
+	inputs []*data.Parameter
+
 	baseOpts []options.Option
 }
 
+// InputOption declares the Process's input set (BPMN Table 10.1
+// ioSpecification — the data a Process needs at start). It is applied by
+// process.New.
+type InputOption func(*processConfig) error
+
+// Option marks InputOption as an options.Option.
+func (InputOption) Option() {}
+
+// WithInputs declares the Process's start inputs. A host starting an instance
+// passes values for them (thresher.WithStartVariables); a required input must
+// be passed, an Optional one may be omitted, and each value must match its
+// parameter's ItemDefinition. A nil parameter and a repeated name are refused.
+func WithInputs(params ...*data.Parameter) InputOption {
+	return func(pc *processConfig) error {
+		for _, p := range params {
+			if p == nil {
+				return errs.New(
+					errs.M("process input couldn't be empty"),
+					errs.C(errorClass, errs.EmptyNotAllowed))
+			}
+
+			for _, in := range pc.inputs {
+				if in.Name() == p.Name() {
+					return errs.New(
+						errs.M("duplicate process input %q", p.Name()),
+						errs.C(errorClass, errs.DuplicateObject))
+				}
+			}
+
+			pc.inputs = append(pc.inputs, p)
+		}
+
+		return nil
+	}
+}
+
 // AddLaneSet implements lanes.LaneSetAdder — a Process is one of the two
 // FlowElementsContainers BPMN hangs laneSets off.
 // A nil set cannot arrive here: lanes.WithLaneSets refuses one before calling,
@@ -99,6 +140,7 @@ func (pc *processConfig) newProcess() (*Process, error) {
 		properties:               pc.props,
 		roles:                    pc.roles,
 		laneSets:                 pc.laneSets,
+		inputs:                   pc.inputs,
 		CorrelationSubscriptions: []*bpmncommon.CorrelationSubscription{},
 		nodes:                    map[string]flow.Node{},
 		flows:                    map[string]*flow.SequenceFlow{},
@@ -504,3 +504,48 @@ func TestProcessLaneSets(t *testing.T) {
 		require.Error(t, err)
 	})
 }
+
+func TestProcessInputs(t *testing.T) {
+	require.NoError(t, data.CreateDefaultStates())
+
+	orderID, err := data.ReadyValueParameter("order_id",
+		values.NewVariable(""))
+	require.NoError(t, err)
+
+	note, err := data.ReadyValueParameter("note", values.NewVariable(""))
+	require.NoError(t, err)
+
+	t.Run("declared and exposed in order", func(t *testing.T) {
+		p, err := process.New("with-inputs",
+			process.WithInputs(orderID, note))
+		require.NoError(t, err)
+
+		in := p.Inputs()
+		require.Len(t, in, 2)
+		require.Equal(t, "order_id", in[0].Name())
+		require.Equal(t, "note", in[1].Name())
+
+		// the getter hands out a copy
+		in[0] = nil
+		require.NotNil(t, p.Inputs()[0])
+	})
+
+	t.Run("no inputs by default", func(t *testing.T) {
+		p, err := process.New("no-inputs")
+		require.NoError(t, err)
+		require.Empty(t, p.Inputs())
+	})
+
+	t.Run("a nil input is refused", func(t *testing.T) {
+		_, err := process.New("nil-input", process.WithInputs(orderID, nil))
+		require.Error(t, err)
+	})
+
+	t.Run("a repeated name is refused", func(t *testing.T) {
+		_, err := process.New("dup-input",
+			process.WithInputs(orderID),
+			process.WithInputs(orderID))
+		require.Error(t, err)
+		require.ErrorContains(t, err, "order_id")
+	})
+}
@@ -34,6 +34,9 @@ var kindLevel = map[Kind]slog.Level{
 	// milestone, so it echoes at Info beside KindScope and KindTaskState rather
 	// than at flow-tracing Debug (SRD-074 §3.6).
 	KindAdHoc: slog.LevelInfo,
+	// An operator moving tokens by hand is an audit milestone — every
+	// instruction is logged at Info, whatever the flow-tracing level.
+	KindModification: slog.LevelInfo,
 }
 
 // kindNoEcho lists kinds that never reach the operator log — the observer stream
@@ -74,6 +77,9 @@ var phaseOverride = map[phaseKey]slog.Level{
 	// A deferred checkpoint means durability is OFF while the instance
 	// runs — operator-relevant degradation (SRD-070 FR-4/FR-8).
 	{KindInstanceState, PhaseCheckpointDeferred}: slog.LevelWarn,
+	// A deferred outbox relay means a sent message hasn't left yet — the
+	// same degradation posture.
+	{KindEventFlow, PhaseRelayDeferred}: slog.LevelWarn,
 	// A script-execution failure — same posture (SRD-064 FR-5).
 	{KindScript, PhaseFailed}: slog.LevelWarn,
 	// Same rule for a compensation throw that resolved to nothing (SRD-059
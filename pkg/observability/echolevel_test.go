@@ -63,6 +63,7 @@ func TestEchoLevel(t *testing.T) {
 		{"runtime deploy is an info milestone", KindRules, PhaseDeployed, slog.LevelInfo},
 		{"rules invocation stays debug", KindRules, PhaseInvoked, slog.LevelDebug},
 		{"ordinary job phase stays debug", KindJobState, PhaseEnqueued, slog.LevelDebug},
+		{"operator modification is an info milestone", KindModification, PhaseCanceled, slog.LevelInfo},
 		{"unclassified kind surfaces at error", Kind("Mystery"), Phase("X"), slog.LevelError},
 	}
 
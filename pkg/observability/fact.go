@@ -36,6 +36,7 @@ const (
 	KindDataObject       Kind = "DataObject"       // per-instance Data Object read/write (observer-only, SRD-063)
 	KindDataStore        Kind = "DataStore"        // engine-global Data Store read/write (SRD-068)
 	KindAdHoc            Kind = "AdHoc"            // ad-hoc routing decisions (ADR-035)
+	KindModification     Kind = "Modification"     // operator token cancel/start on a live instance
 )
 
 // Phase names the transition within a Kind (ADR-013 v.2 §2.6). Open and
@@ -51,8 +52,8 @@ type Phase string
 const (
 	PhaseStarting Phase = "Starting" // EngineState / HubState
 	PhaseStarted  Phase = "Started"
-	PhasePaused   Phase = "Paused"  // engine live; hub ⏳
-	PhaseResumed  Phase = "Resumed" // ⏳ (resuming re-emits Started meanwhile)
+	PhasePaused   Phase = "Paused"  // engine, instance live; hub ⏳
+	PhaseResumed  Phase = "Resumed" // instance live; engine ⏳ (re-emits Started)
 	PhaseStopping Phase = "Stopping"
 	PhaseStopped  Phase = "Stopped"
 
@@ -160,6 +161,11 @@ const (
 	// resumed (SRD-070 FR-8) — the recovery milestone, echoed at Info.
 	PhaseRecovered Phase = "Recovered" // InstanceState
 
+	// PhaseHandedOff: a draining engine gave the instance up to its engine
+	// group — its loop stopped unsettled and its lease was surrendered, so
+	// another engine recovers it from its last checkpoint. Echoed at Info.
+	PhaseHandedOff Phase = "HandedOff" // InstanceState
+
 	// PhaseCheckpointDeferred: a checkpoint could not be captured or
 	// saved (an in-flight construct the document doesn't cover yet, an
 	// uncodable payload, a failed save) — the instance keeps running on
@@ -176,6 +182,36 @@ const (
 	PhaseDehydrated Phase = "Dehydrated" // InstanceState
 	PhaseHydrated   Phase = "Hydrated"
 
+	// PhaseMigrated: an operator moved a released instance to another
+	// version of its process (Thresher.Migrate) — its checkpoint now pins
+	// the target version and it was rebuilt over it. InstanceState, echoed
+	// at Info.
+	PhaseMigrated Phase = "Migrated" // InstanceState
+
+	// PhaseImported: an instance exported from another engine
+	// (Thresher.ExportInstance) was imported here (Thresher.ImportInstance) —
+	// claimed under this engine's lease and rebuilt from its checkpoint.
+	// InstanceState, echoed at Info.
+	PhaseImported Phase = "Imported" // InstanceState
+
+	// PhaseRelayed: the message outbox published a message staged by a send
+	// once the checkpoint holding it was saved — the send's effect left the
+	// instance. PhaseRelayDeferred: the broker refused it; the message stays
+	// in the outbox and the next checkpoint, or the instance's relay
+	// backoff, retries. EventFlow; a deferred
+	// relay echoes at Warn — a message is late and the operator must see it.
+	PhaseRelayed       Phase = "Relayed" // EventFlow
+	PhaseRelayDeferred Phase = "RelayDeferred"
+
+	// A broker dead-letter queue (membroker): PhaseDeadLettered marks an
+	// undelivered message the broker gave up on — its TTL expired or the
+	// inbox cap evicted it — and parked for inspection; PhaseReplayed marks
+	// one published again from the queue, PhasePurged one discarded from it.
+	// EventFlow.
+	PhaseDeadLettered Phase = "DeadLettered" // EventFlow
+	PhaseReplayed     Phase = "Replayed"
+	PhasePurged       Phase = "Purged"
+
 	// An Ad-Hoc Sub-Process routing decision (ADR-035 v.1 §2.2, SRD-074 FR-12):
 	// PhaseOffered names the candidate set one Router answer produced,
 	// PhaseActivated the candidate that started and who selected it. The
@@ -226,7 +262,10 @@ const (
 	AttrEventProcessorID    = "event_processor_id"
 	AttrWaiterID            = "waiter_id"
 	AttrSignal              = "signal"
+	AttrSignalID            = "signal_id"
 	AttrMessageName         = "message_name"
+	AttrMessageID           = "message_id"
+	AttrDeadLetterID        = "dead_letter_id"
 	AttrCorrelationKey      = "correlation_key"
 	AttrCorrelationValue    = "correlation_value"
 	AttrError               = "error"
@@ -265,6 +304,12 @@ const (
 	AttrDataName  = "data_name"
 	AttrDataStore = "data_store"
 
+	// AttrStartVariables is the sorted, comma-joined names of the variables a
+	// host passed at StartProcess, stamped on the instance's Active transition.
+	// Names only — never the passed values (the masking rule); the values
+	// themselves land in the root scope and the first checkpoint.
+	AttrStartVariables = "start_variables"
+
 	// AttrLoopCounter (SRD-054): the 0-based iteration ordinal a looped composite
 	// activity's scope carries, so each Standard-Loop pass is individually
 	// observable on its scope facts.
@@ -0,0 +1,28 @@
+package filerepo_test
+
+import (
+	"testing"
+
+	"github.com/dr-dobermann/gobpm/pkg/renv"
+	"github.com/dr-dobermann/gobpm/pkg/repository"
+	"github.com/dr-dobermann/gobpm/pkg/repository/repositorytest"
+)
+
+// TestConformance proves filerepo against the published Repository
+// contract suite — the same suite memrepo and every durable adapter run.
+func TestConformance(t *testing.T) {
+	repositorytest.Conformance(t, func(t *testing.T) repository.Repository {
+		return open(t, t.TempDir())
+	})
+}
+
+// TestClusterDeclaration: filerepo declares itself single-node
+// (renv.ClusterAware — satisfied structurally, the store never imports
+// renv).
+func TestClusterDeclaration(t *testing.T) {
+	var ca renv.ClusterAware = open(t, t.TempDir())
+
+	if ok, reason := ca.ClusterCompatibility(); ok || reason == "" {
+		t.Fatal("a local directory can never back a cluster, and says why")
+	}
+}
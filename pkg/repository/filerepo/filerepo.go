@@ -0,0 +1,652 @@
+// Package filerepo provides a zero-dependency durable Repository: every
+// InstanceRecord is a JSON file in a directory, so CLI tools and tests
+// survive a restart without any database. It complements memrepo for local
+// development; it is not a server store.
+//
+// Layout of the directory:
+//
+//	records/<hex id>.json   one record per file
+//	index.json              the group registry and a summary per record
+//
+// Every write is atomic — a temp file in the same directory, synced, then
+// renamed over the target, and the directory synced after the rename — so a
+// crash leaves either the old file or the new one, never a torn one. Save's
+// compare-and-set reads the stored version from the record file under the
+// Repo's lock. ListInFlight and FindByBusinessKey scan the index instead of
+// the records. The record file is the truth: once it is written, a failed
+// index write is logged, not returned, and Open reconciles the index with
+// the record files, so a crash or a failure between the two writes heals at
+// the next start.
+//
+// One process owns a directory: the lock is in-process, so two processes
+// over one directory aren't fenced. The Repo declares itself unfit for a
+// cluster (renv.ClusterAware).
+package filerepo
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+	"github.com/dr-dobermann/gobpm/pkg/repository"
+)
+
+const errorClass = "FILEREPO"
+
+const (
+	recordsDir = "records"
+	indexFile  = "index.json"
+	recordExt  = ".json"
+	tempPrefix = ".tmp-"
+)
+
+// Repo is a file-system repository.Repository. Build it with Open.
+type Repo struct {
+	logger observability.Logger
+	dir    string
+	idx    index
+	mu     sync.Mutex
+}
+
+// index is the persisted index.json: the group registry and what the
+// listings need of every record, so they never open the record files.
+type index struct {
+	Groups  map[string]bool  `json:"groups"`
+	Records map[string]entry `json:"records"`
+}
+
+// entry is a record's summary in the index.
+type entry struct {
+	Expiry      time.Time         `json:"lease_expiry"`
+	Group       string            `json:"group"`
+	ProcessID   string            `json:"process_id,omitempty"`
+	BusinessKey string            `json:"business_key,omitempty"`
+	LeaseOwner  string            `json:"lease_owner,omitempty"`
+	Status      repository.Status `json:"status"`
+}
+
+// same reports whether e and o summarize the record alike.
+func (e entry) same(o entry) bool {
+	return e.Expiry.Equal(o.Expiry) &&
+		e.Group == o.Group &&
+		e.ProcessID == o.ProcessID &&
+		e.BusinessKey == o.BusinessKey &&
+		e.LeaseOwner == o.LeaseOwner &&
+		e.Status == o.Status
+}
+
+// summary builds the index entry of rec.
+func summary(rec *repository.InstanceRecord) entry {
+	return entry{
+		Expiry:      rec.Lease.Expiry,
+		Group:       rec.Group,
+		ProcessID:   rec.ProcessID,
+		BusinessKey: rec.BusinessKey,
+		LeaseOwner:  rec.Lease.Owner,
+		Status:      rec.Status,
+	}
+}
+
+// Option configures a Repo at Open.
+type Option func(*Repo)
+
+// WithLogger sets the logger used for the index-reconciliation warnings.
+func WithLogger(l observability.Logger) Option { return func(r *Repo) { r.logger = l } }
+
+// Open returns the Repo stored in dir, creating the directory if it is
+// absent. It loads the index and reconciles it with the record files.
+func Open(dir string, opts ...Option) (*Repo, error) {
+	if dir == "" {
+		return nil, errs.New(
+			errs.M("Open: a directory is required"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	r := &Repo{
+		logger: slog.Default(),
+		dir:    dir,
+	}
+
+	for _, o := range opts {
+		o(r)
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, recordsDir), 0o750); err != nil {
+		return nil, fsErr("creating the directory", "", err)
+	}
+
+	if err := r.loadIndex(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Dir returns the directory the Repo stores its files in.
+func (r *Repo) Dir() string { return r.dir }
+
+// Save stores the record under its ID with compare-and-set semantics:
+// rec.RecVersion must equal the version in the record's file (0 creates);
+// the stored version increments on success. A mismatch fails with
+// errs.ConcurrentUpdate. The record must carry its creator's engine group.
+func (r *Repo) Save(_ context.Context, rec repository.InstanceRecord) error {
+	if rec.ID == "" {
+		return errs.New(
+			errs.M("Save: a record needs an ID"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	if rec.Group == "" {
+		return errs.New(
+			errs.M("Save: a record needs an engine group"),
+			errs.C(errorClass, errs.EmptyNotAllowed),
+			errs.D("id", rec.ID))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.idx.Groups[rec.Group] {
+		return errs.New(
+			errs.M("Save: engine group %q isn't registered", rec.Group),
+			errs.C(errorClass, errs.ObjectNotFound),
+			errs.D("id", rec.ID))
+	}
+
+	cur, ok, err := r.readRecord(rec.ID)
+	if err != nil {
+		return err
+	}
+
+	var stored int64
+	if ok {
+		stored = cur.RecVersion
+	}
+
+	if stored != rec.RecVersion {
+		return errs.New(
+			errs.M("Save: the record changed under the writer"),
+			errs.C(errorClass, errs.ConcurrentUpdate),
+			errs.D("id", rec.ID))
+	}
+
+	rec.RecVersion++
+
+	body, err := json.Marshal(&rec)
+	if err != nil {
+		return errs.New(
+			errs.M("Save: encoding the record"),
+			errs.C(errorClass, errs.InvalidObject),
+			errs.D("id", rec.ID),
+			errs.E(err))
+	}
+
+	if err := r.writeAtomic(r.recordPath(rec.ID), body); err != nil {
+		return fsErr("Save", rec.ID, err)
+	}
+
+	r.idx.Records[rec.ID] = summary(&rec)
+	r.saveIndexAfter("Save", rec.ID)
+
+	return nil
+}
+
+// Load returns the record for id; the bool is false when none exists.
+func (r *Repo) Load(_ context.Context, id string) (repository.InstanceRecord, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.readRecord(id)
+}
+
+// Delete removes the record for id (a no-op if absent).
+func (r *Repo) Delete(_ context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	err := os.Remove(r.recordPath(id))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fsErr("Delete", id, err)
+	}
+
+	if err == nil {
+		if err := syncDir(filepath.Join(r.dir, recordsDir)); err != nil {
+			return fsErr("Delete", id, err)
+		}
+	}
+
+	if _, ok := r.idx.Records[id]; !ok {
+		return nil
+	}
+
+	delete(r.idx.Records, id)
+	r.saveIndexAfter("Delete", id)
+
+	return nil
+}
+
+// ListInFlight returns the IDs of the CLAIMABLE in-flight instances of the
+// given engine group — non-terminal, not suspended, with no live lease at
+// now — sorted for determinism. It scans the index only.
+func (r *Repo) ListInFlight(
+	_ context.Context,
+	group string,
+	now time.Time,
+) ([]string, error) {
+	if group == "" {
+		return nil, errs.New(
+			errs.M("ListInFlight: an engine group is required"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.scan(func(e *entry) bool {
+		lease := repository.Lease{Owner: e.LeaseOwner, Expiry: e.Expiry}
+
+		return e.Group == group &&
+			!e.Status.IsTerminal() &&
+			e.Status != repository.StatusSuspended &&
+			lease.Expired(now)
+	}), nil
+}
+
+// FindByBusinessKey returns the IDs of the group's non-terminal records of
+// processID carrying businessKey, sorted (repository.BusinessKeyFinder).
+func (r *Repo) FindByBusinessKey(
+	_ context.Context,
+	group, processID, businessKey string,
+) ([]string, error) {
+	if group == "" || processID == "" || businessKey == "" {
+		return nil, errs.New(
+			errs.M("FindByBusinessKey: a group, a process and a key are required"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.scan(func(e *entry) bool {
+		return e.Group == group &&
+			e.ProcessID == processID &&
+			e.BusinessKey == businessKey &&
+			!e.Status.IsTerminal()
+	}), nil
+}
+
+// scan returns the sorted IDs of the index entries match selects. Caller
+// holds mu.
+func (r *Repo) scan(match func(*entry) bool) []string {
+	var ids []string
+
+	for id, e := range r.idx.Records {
+		if match(&e) {
+			ids = append(ids, id)
+		}
+	}
+
+	sort.Strings(ids)
+
+	return ids
+}
+
+// RegisterGroup establishes the engine group in the registry, idempotently.
+func (r *Repo) RegisterGroup(_ context.Context, group string) error {
+	if group == "" {
+		return errs.New(
+			errs.M("RegisterGroup: an engine group is required"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.idx.Groups[group] {
+		return nil
+	}
+
+	r.idx.Groups[group] = true
+
+	if err := r.saveIndex(); err != nil {
+		delete(r.idx.Groups, group)
+
+		return err
+	}
+
+	return nil
+}
+
+// GroupExists reports whether the group is established in the registry.
+func (r *Repo) GroupExists(_ context.Context, group string) (bool, error) {
+	if group == "" {
+		return false, errs.New(
+			errs.M("GroupExists: an engine group is required"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.idx.Groups[group], nil
+}
+
+// ClusterCompatibility declares filerepo unfit to back a multi-engine
+// deployment (renv.ClusterAware): its lock is in one process, so engines
+// elsewhere are never fenced.
+func (r *Repo) ClusterCompatibility() (bool, string) {
+	return false, "local directory; the CAS lock is per process"
+}
+
+// recordPath is the file of the record id. The id is hex-encoded, so any id
+// is a safe file name on every file system, case-insensitive ones included.
+func (r *Repo) recordPath(id string) string {
+	return filepath.Join(r.dir, recordsDir, hex.EncodeToString([]byte(id))+recordExt)
+}
+
+// readRecord reads the record file of id. Caller holds mu.
+func (r *Repo) readRecord(id string) (repository.InstanceRecord, bool, error) {
+	return readRecordFile(r.recordPath(id), id)
+}
+
+// readRecordFile reads and decodes one record file; id names it in errors.
+func readRecordFile(path, id string) (repository.InstanceRecord, bool, error) {
+	body, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return repository.InstanceRecord{}, false, nil
+	}
+
+	if err != nil {
+		return repository.InstanceRecord{}, false, fsErr("reading the record", id, err)
+	}
+
+	var rec repository.InstanceRecord
+	if err := json.Unmarshal(body, &rec); err != nil {
+		return repository.InstanceRecord{}, false, errs.New(
+			errs.M("the record file is corrupt"),
+			errs.C(errorClass, errs.InvalidObject),
+			errs.D("id", id),
+			errs.D("path", path),
+			errs.E(err))
+	}
+
+	return rec, true, nil
+}
+
+// loadIndex reads index.json and reconciles it with the record files: a
+// record file the index misses or that is newer than the index is re-read,
+// and an entry without a file is dropped. Open calls it before the Repo is
+// shared.
+func (r *Repo) loadIndex() error {
+	path := filepath.Join(r.dir, indexFile)
+
+	r.idx = index{Groups: map[string]bool{}, Records: map[string]entry{}}
+
+	var indexed time.Time
+
+	body, err := os.ReadFile(path)
+
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+
+	case err != nil:
+		return fsErr("reading the index", "", err)
+
+	default:
+		if err := json.Unmarshal(body, &r.idx); err != nil {
+			return errs.New(
+				errs.M("the index file is corrupt; remove it to rebuild it"+
+					" from the records (the group registry is lost)"),
+				errs.C(errorClass, errs.InvalidObject),
+				errs.D("path", path),
+				errs.E(err))
+		}
+
+		if fi, err := os.Stat(path); err == nil {
+			indexed = fi.ModTime()
+		}
+
+		if r.idx.Groups == nil {
+			r.idx.Groups = map[string]bool{}
+		}
+
+		if r.idx.Records == nil {
+			r.idx.Records = map[string]entry{}
+		}
+	}
+
+	if left, err := filepath.Glob(filepath.Join(r.dir, tempPrefix+"*")); err == nil {
+		for _, path := range left {
+			r.removeLeftover(path)
+		}
+	}
+
+	healed, err := r.reconcile(indexed)
+	if err != nil {
+		return err
+	}
+
+	if healed == 0 {
+		return nil
+	}
+
+	r.logger.Warn("filerepo: the index was out of step with the records, reconciled",
+		"dir", r.dir, "records", healed)
+
+	r.saveIndexAfter("Open", "")
+
+	return nil
+}
+
+// reconcile brings the index in step with the record files, returning how
+// many entries it changed.
+func (r *Repo) reconcile(indexed time.Time) (int, error) {
+	dd, err := os.ReadDir(filepath.Join(r.dir, recordsDir))
+	if err != nil {
+		return 0, fsErr("listing the records", "", err)
+	}
+
+	var (
+		healed int
+		seen   = make(map[string]bool, len(dd))
+	)
+
+	for _, d := range dd {
+		name := d.Name()
+
+		if strings.HasPrefix(name, tempPrefix) {
+			r.removeLeftover(filepath.Join(r.dir, recordsDir, name))
+
+			continue
+		}
+
+		if d.IsDir() || !strings.HasSuffix(name, recordExt) {
+			continue
+		}
+
+		raw, err := hex.DecodeString(strings.TrimSuffix(name, recordExt))
+		if err != nil {
+			continue // not a record file
+		}
+
+		id := string(raw)
+		seen[id] = true
+
+		if _, ok := r.idx.Records[id]; ok && !newer(d, indexed) {
+			continue
+		}
+
+		rec, ok, err := readRecordFile(filepath.Join(r.dir, recordsDir, name), id)
+		if err != nil {
+			return 0, err
+		}
+
+		if !ok {
+			continue
+		}
+
+		if e, indexed := r.idx.Records[id]; !indexed || !e.same(summary(&rec)) {
+			r.idx.Records[id] = summary(&rec)
+			r.idx.Groups[rec.Group] = true
+			healed++
+		}
+	}
+
+	for id := range r.idx.Records {
+		if !seen[id] {
+			delete(r.idx.Records, id)
+			healed++
+		}
+	}
+
+	return healed, nil
+}
+
+// removeLeftover removes a temp file a crash left behind mid-write; the
+// target it was meant to replace is intact.
+func (r *Repo) removeLeftover(path string) {
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		r.logger.Warn("filerepo: removing a leftover temp file",
+			"path", path, observability.AttrError, err.Error())
+	}
+}
+
+// newer reports whether the directory entry was modified after t (an
+// unknown time counts as newer, so it is re-read).
+func newer(d fs.DirEntry, t time.Time) bool {
+	fi, err := d.Info()
+
+	return err != nil || t.IsZero() || !fi.ModTime().Before(t)
+}
+
+// saveIndex writes index.json atomically. Caller holds mu.
+func (r *Repo) saveIndex() error {
+	body, err := json.Marshal(&r.idx)
+	if err != nil {
+		return errs.New(
+			errs.M("encoding the index"),
+			errs.C(errorClass, errs.InvalidObject),
+			errs.E(err))
+	}
+
+	if err := r.writeAtomic(filepath.Join(r.dir, indexFile), body); err != nil {
+		return fsErr("writing the index", "", err)
+	}
+
+	return nil
+}
+
+// saveIndexAfter writes the index once op's record write landed. A failure
+// is logged, not returned: the record file is already the truth, the
+// in-memory index serves this process, and the next Open reconciles the
+// file. Caller holds mu.
+func (r *Repo) saveIndexAfter(op, id string) {
+	if err := r.saveIndex(); err != nil {
+		r.logger.Warn("filerepo: the index wasn't written; the next Open reconciles it",
+			"op", op, "id", id, observability.AttrError, err.Error())
+	}
+}
+
+// writeAtomic replaces path with body: a synced temp file in the same
+// directory, renamed over the target.
+func (r *Repo) writeAtomic(path string, body []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), tempPrefix+"*")
+	if err != nil {
+		return err
+	}
+
+	tmp := f.Name()
+
+	if _, err := f.Write(body); err != nil {
+		return r.discard(f, tmp, err)
+	}
+
+	if err := f.Sync(); err != nil {
+		return r.discard(f, tmp, err)
+	}
+
+	if err := f.Close(); err != nil {
+		return r.discard(nil, tmp, err)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return r.discard(nil, tmp, err)
+	}
+
+	return syncDir(filepath.Dir(path))
+}
+
+// syncDir flushes a directory's entries, so a rename or a removal in it
+// survives a crash: syncing the file alone makes its bytes durable, not the
+// name pointing at them. Windows can't sync a directory handle, and its
+// renames are durable without it, so there it does nothing.
+func syncDir(dir string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+
+	if err := d.Sync(); err != nil {
+		_ = d.Close()
+
+		return err
+	}
+
+	return d.Close()
+}
+
+// discard closes (when f is open) and removes a failed temp file, returning
+// the failure that doomed it.
+func (r *Repo) discard(f *os.File, tmp string, cause error) error {
+	if f != nil {
+		if err := f.Close(); err != nil {
+			r.logger.Warn("filerepo: closing a failed temp file",
+				"path", tmp, observability.AttrError, err.Error())
+		}
+	}
+
+	if err := os.Remove(tmp); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		r.logger.Warn("filerepo: removing a failed temp file",
+			"path", tmp, observability.AttrError, err.Error())
+	}
+
+	return cause
+}
+
+// fsErr wraps a file-system failure into the classified error idiom; id
+// tags the record when the operation has one ("" omits the detail).
+func fsErr(op, id string, err error) error {
+	if id == "" {
+		return errs.New(
+			errs.M("%s failed", op),
+			errs.C(errorClass, errs.OperationFailed),
+			errs.E(err))
+	}
+
+	return errs.New(
+		errs.M("%s failed", op),
+		errs.C(errorClass, errs.OperationFailed),
+		errs.D("id", id),
+		errs.E(err))
+}
+
+var (
+	_ repository.Repository        = (*Repo)(nil)
+	_ repository.BusinessKeyFinder = (*Repo)(nil)
+)
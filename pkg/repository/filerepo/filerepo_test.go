@@ -0,0 +1,235 @@
+package filerepo_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/dr-dobermann/gobpm/pkg/repository"
+	"github.com/dr-dobermann/gobpm/pkg/repository/filerepo"
+)
+
+const group = "test-group"
+
+// open opens the Repo in dir with the test group registered.
+func open(t *testing.T, dir string) *filerepo.Repo {
+	t.Helper()
+
+	r, err := filerepo.Open(dir)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	if err := r.RegisterGroup(context.Background(), group); err != nil {
+		t.Fatalf("RegisterGroup: %v", err)
+	}
+
+	return r
+}
+
+// save stores an Active record of id at version 0.
+func save(t *testing.T, r *filerepo.Repo, id string) {
+	t.Helper()
+
+	err := r.Save(context.Background(), repository.InstanceRecord{
+		ID: id, Group: group, Status: repository.StatusActive,
+		Payload: []byte(`{"schema":1}`),
+	})
+	if err != nil {
+		t.Fatalf("Save %s: %v", id, err)
+	}
+}
+
+// inFlight lists the test group's claimable records.
+func inFlight(t *testing.T, r *filerepo.Repo) []string {
+	t.Helper()
+
+	ids, err := r.ListInFlight(context.Background(), group, time.Now())
+	if err != nil {
+		t.Fatalf("ListInFlight: %v", err)
+	}
+
+	return ids
+}
+
+func TestOpenRequiresDir(t *testing.T) {
+	if _, err := filerepo.Open(""); err == nil {
+		t.Fatal("an empty directory must be refused")
+	}
+}
+
+// TestSurvivesRestart: a second Repo over the same directory sees the
+// records, their versions and the group registry.
+func TestSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	save(t, open(t, dir), "a")
+
+	r, err := filerepo.Open(dir)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+
+	if ok, _ := r.GroupExists(ctx, group); !ok {
+		t.Fatal("the group registry didn't survive the restart")
+	}
+
+	rec, ok, err := r.Load(ctx, "a")
+	if err != nil || !ok || rec.RecVersion != 1 ||
+		string(rec.Payload) != `{"schema":1}` {
+		t.Fatalf("Load after restart = %+v, %v, %v", rec, ok, err)
+	}
+
+	if ids := inFlight(t, r); !slices.Equal(ids, []string{"a"}) {
+		t.Fatalf("in-flight after restart = %v", ids)
+	}
+}
+
+// TestIndexReconciled: Open heals an index a crash left behind — a record
+// written after the index, an entry whose file is gone, and a lost index.
+func TestIndexReconciled(t *testing.T) {
+	dir := t.TempDir()
+	r := open(t, dir)
+
+	save(t, r, "kept")
+	save(t, r, "gone")
+
+	index, err := os.ReadFile(filepath.Join(dir, "index.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	save(t, r, "late")
+
+	// the crash: the index on disk predates "late", and "gone" lost its
+	// file without the index hearing of it.
+	if err := os.WriteFile(filepath.Join(dir, "index.json"), index, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Remove(filepath.Join(dir, "records", "676f6e65.json")); err != nil {
+		t.Fatal(err)
+	}
+
+	if ids := inFlight(t, open(t, dir)); !slices.Equal(ids, []string{"kept", "late"}) {
+		t.Fatalf("reconciled in-flight = %v, want [kept late]", ids)
+	}
+
+	if err := os.Remove(filepath.Join(dir, "index.json")); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err = filerepo.Open(dir)
+	if err != nil {
+		t.Fatalf("Open without an index: %v", err)
+	}
+
+	if ok, _ := r.GroupExists(context.Background(), group); !ok {
+		t.Fatal("the records' group must be re-registered from them")
+	}
+
+	if ids := inFlight(t, r); !slices.Equal(ids, []string{"kept", "late"}) {
+		t.Fatalf("rebuilt in-flight = %v, want [kept late]", ids)
+	}
+}
+
+// TestIndexWriteFailureNotFatal: once the record file is written, a failed
+// index write doesn't fail Save or Delete — the record is the truth, and the
+// next Open reconciles the index with it.
+func TestIndexWriteFailureNotFatal(t *testing.T) {
+	dir := t.TempDir()
+	r := open(t, dir)
+
+	save(t, r, "old")
+
+	indexPath := filepath.Join(dir, "index.json")
+
+	index, err := os.ReadFile(indexPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a directory in the index's place: every index write now fails.
+	if err := os.Remove(indexPath); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.MkdirAll(filepath.Join(indexPath, "blocker"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+
+	save(t, r, "new")
+
+	if err := r.Delete(context.Background(), "old"); err != nil {
+		t.Fatalf("Delete after its record went: %v", err)
+	}
+
+	if ids := inFlight(t, r); !slices.Equal(ids, []string{"new"}) {
+		t.Fatalf("in-flight = %v, want [new]", ids)
+	}
+
+	if err := os.RemoveAll(indexPath); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(indexPath, index, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if ids := inFlight(t, open(t, dir)); !slices.Equal(ids, []string{"new"}) {
+		t.Fatalf("reconciled in-flight = %v, want [new]", ids)
+	}
+}
+
+// TestLeftoverTempFilesRemoved: a temp file of an interrupted write is
+// cleaned up at Open and never read as a record.
+func TestLeftoverTempFilesRemoved(t *testing.T) {
+	dir := t.TempDir()
+	save(t, open(t, dir), "a")
+
+	left := filepath.Join(dir, "records", ".tmp-123")
+	if err := os.WriteFile(left, []byte("torn"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if ids := inFlight(t, open(t, dir)); !slices.Equal(ids, []string{"a"}) {
+		t.Fatalf("in-flight = %v, want [a]", ids)
+	}
+
+	if _, err := os.Stat(left); !os.IsNotExist(err) {
+		t.Fatalf("the leftover temp file must be removed, stat: %v", err)
+	}
+}
+
+// TestCorruptFilesFailLoud: a corrupt index refuses Open, a corrupt record
+// fails its Load — never an empty store.
+func TestCorruptFilesFailLoud(t *testing.T) {
+	dir := t.TempDir()
+	save(t, open(t, dir), "a")
+
+	rec := filepath.Join(dir, "records", "61.json")
+	if err := os.WriteFile(rec, []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := filerepo.Open(dir)
+	if err == nil {
+		_, _, err = r.Load(context.Background(), "a")
+	}
+
+	if err == nil {
+		t.Fatal("a corrupt record must fail loud")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "index.json"), []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := filerepo.Open(dir); err == nil {
+		t.Fatal("a corrupt index must refuse Open")
+	}
+}
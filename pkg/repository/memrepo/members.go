@@ -0,0 +1,100 @@
+package memrepo
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/repository"
+)
+
+// PutMember records the member, replacing the record under the same group
+// and engine id (repository.MembershipRegistry).
+func (r *Repo) PutMember(_ context.Context, member repository.GroupMember) error {
+	if member.Group == "" || member.EngineID == "" {
+		return errs.New(
+			errs.M("PutMember: a group and an engine id are required"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	group, ok := r.members[member.Group]
+	if !ok {
+		group = map[string]repository.GroupMember{}
+		r.members[member.Group] = group
+	}
+
+	group[member.EngineID] = member
+
+	return nil
+}
+
+// RemoveMember removes the engine's record from the group
+// (repository.MembershipRegistry).
+func (r *Repo) RemoveMember(_ context.Context, group, engineID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.members[group], engineID)
+
+	if len(r.members[group]) == 0 {
+		delete(r.members, group)
+	}
+
+	return nil
+}
+
+// Members returns the group's records ordered by engine id
+// (repository.MembershipRegistry).
+func (r *Repo) Members(
+	_ context.Context, group string,
+) ([]repository.GroupMember, error) {
+	if group == "" {
+		return nil, errs.New(
+			errs.M("Members: an engine group is required"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	r.mu.Lock()
+
+	members := make([]repository.GroupMember, 0, len(r.members[group]))
+	for _, m := range r.members[group] {
+		members = append(members, m)
+	}
+
+	r.mu.Unlock()
+
+	slices.SortFunc(members, func(a, b repository.GroupMember) int {
+		return strings.Compare(a.EngineID, b.EngineID)
+	})
+
+	return members, nil
+}
+
+// OwnedCounts counts the group's unfinished records by lease owner
+// (repository.MembershipRegistry).
+func (r *Repo) OwnedCounts(
+	_ context.Context, group string,
+) (map[string]int, error) {
+	if group == "" {
+		return nil, errs.New(
+			errs.M("OwnedCounts: an engine group is required"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	owned := map[string]int{}
+
+	for _, rec := range r.records {
+		if rec.Group == group && !rec.Status.IsTerminal() && rec.Lease.Owner != "" {
+			owned[rec.Lease.Owner]++
+		}
+	}
+
+	return owned, nil
+}
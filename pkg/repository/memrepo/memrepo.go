@@ -27,6 +27,8 @@ type Repo struct {
 	logger      observability.Logger
 	records     map[string]*repository.InstanceRecord
 	groups      map[string]struct{}
+	timers      map[timerKey]repository.DueTimer
+	members     map[string]map[string]repository.GroupMember
 	termSet     map[string]struct{}
 	termOrder   []string
 	maxTerminal int
@@ -50,6 +52,8 @@ func New(opts ...Option) *Repo {
 		logger:      slog.Default(),
 		records:     map[string]*repository.InstanceRecord{},
 		groups:      map[string]struct{}{},
+		timers:      map[timerKey]repository.DueTimer{},
+		members:     map[string]map[string]repository.GroupMember{},
 		termSet:     map[string]struct{}{},
 		maxTerminal: DefaultMaxTerminal,
 	}
@@ -186,6 +190,37 @@ func (r *Repo) ListInFlight(
 	return ids, nil
 }
 
+// FindByBusinessKey returns the IDs of the group's non-terminal records
+// of processID carrying businessKey, sorted (repository.BusinessKeyFinder).
+func (r *Repo) FindByBusinessKey(
+	_ context.Context,
+	group, processID, businessKey string,
+) ([]string, error) {
+	if group == "" || processID == "" || businessKey == "" {
+		return nil, errs.New(
+			errs.M("FindByBusinessKey: a group, a process and a key are required"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var ids []string
+
+	for id, rec := range r.records {
+		if rec.Group == group &&
+			rec.ProcessID == processID &&
+			rec.BusinessKey == businessKey &&
+			!rec.Status.IsTerminal() {
+			ids = append(ids, id)
+		}
+	}
+
+	sort.Strings(ids)
+
+	return ids, nil
+}
+
 // RegisterGroup establishes the engine group in the registry (SRD-078
 // FR-1), idempotently.
 func (r *Repo) RegisterGroup(_ context.Context, group string) error {
@@ -255,4 +290,9 @@ func removeFirst(s []string, v string) []string {
 	return s
 }
 
-var _ repository.Repository = (*Repo)(nil)
+var (
+	_ repository.Repository         = (*Repo)(nil)
+	_ repository.BusinessKeyFinder  = (*Repo)(nil)
+	_ repository.TimerIndex         = (*Repo)(nil)
+	_ repository.MembershipRegistry = (*Repo)(nil)
+)
@@ -0,0 +1,102 @@
+package memrepo
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/repository"
+)
+
+// timerKey identifies a deadline in the due-timer index.
+type timerKey struct {
+	instanceID string
+	trackID    string
+	eventID    string
+}
+
+// PutTimer records the deadline, replacing one under the same instance,
+// track and event (repository.TimerIndex).
+func (r *Repo) PutTimer(_ context.Context, timer repository.DueTimer) error {
+	if timer.Group == "" || timer.InstanceID == "" || timer.TrackID == "" {
+		return errs.New(
+			errs.M("PutTimer: a group, an instance and a track are required"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.timers[timerKey{timer.InstanceID, timer.TrackID, timer.EventID}] = timer
+
+	return nil
+}
+
+// DeleteTimers removes the instance's deadlines of one timer, one track
+// or — with an empty trackID — all of them (repository.TimerIndex).
+func (r *Repo) DeleteTimers(
+	_ context.Context, instanceID, trackID, eventID string,
+) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for k := range r.timers {
+		if k.instanceID == instanceID &&
+			(trackID == "" || k.trackID == trackID) &&
+			(eventID == "" || k.eventID == eventID) {
+			delete(r.timers, k)
+		}
+	}
+
+	return nil
+}
+
+// DueTimers returns the group's deadlines at or before `before`, earliest
+// first, at most limit of them, leaving out those of a suspended instance
+// and of one leased at now (repository.TimerIndex).
+func (r *Repo) DueTimers(
+	_ context.Context, group string, before, now time.Time, limit int,
+) ([]repository.DueTimer, error) {
+	if group == "" {
+		return nil, errs.New(
+			errs.M("DueTimers: an engine group is required"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	r.mu.Lock()
+
+	var due []repository.DueTimer
+
+	for _, t := range r.timers {
+		if t.Group != group || t.Deadline.After(before) {
+			continue
+		}
+
+		if rec, ok := r.records[t.InstanceID]; ok && !rec.Status.IsTerminal() &&
+			(rec.Status == repository.StatusSuspended || !rec.Lease.Expired(now)) {
+			continue
+		}
+
+		due = append(due, t)
+	}
+
+	r.mu.Unlock()
+
+	slices.SortFunc(due, func(a, b repository.DueTimer) int {
+		if c := a.Deadline.Compare(b.Deadline); c != 0 {
+			return c
+		}
+
+		return strings.Compare(
+			a.InstanceID+"\x00"+a.TrackID+"\x00"+a.EventID,
+			b.InstanceID+"\x00"+b.TrackID+"\x00"+b.EventID)
+	})
+
+	if limit > 0 && len(due) > limit {
+		due = due[:limit]
+	}
+
+	return due, nil
+}
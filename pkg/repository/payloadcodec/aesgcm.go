@@ -0,0 +1,136 @@
+package payloadcodec
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"strings"
+
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+)
+
+// AESGCMID is the AESGCM codec's frame id.
+const AESGCMID = "aes-gcm"
+
+// AESGCM is the at-rest encryption Codec: AES in GCM mode over a keyring.
+// Every payload it seals carries the id of the key that sealed it, so keys
+// rotate without a migration: make the new key active and keep the old ones
+// in the ring — a record is re-sealed with the active key at its next
+// checkpoint, and the old key can go once no record names it.
+//
+// The sealed form is the key id's length (one byte), the key id, the nonce
+// and the ciphertext with its tag. The associated data — the payload's frame
+// header and record id — is authenticated with it, so a payload doesn't open
+// under a rewritten header or in another record.
+type AESGCM struct {
+	aeads  map[string]cipher.AEAD
+	active string
+}
+
+// NewAESGCM builds the codec over keys — key id → AES-128, -192 or -256 key —
+// sealing with the key active names. Key ids are 1..255 bytes.
+func NewAESGCM(active string, keys map[string][]byte) (*AESGCM, error) {
+	if _, ok := keys[active]; !ok {
+		return nil, errs.New(
+			errs.M("NewAESGCM: the active key %q isn't in the keyring", active),
+			errs.C(errorClass, errs.InvalidParameter))
+	}
+
+	c := &AESGCM{
+		aeads:  make(map[string]cipher.AEAD, len(keys)),
+		active: active,
+	}
+
+	for id, key := range keys {
+		if strings.TrimSpace(id) == "" || len(id) > maxIDLen {
+			return nil, errs.New(
+				errs.M("NewAESGCM: key id %q must be 1..%d bytes", id, maxIDLen),
+				errs.C(errorClass, errs.InvalidParameter))
+		}
+
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, errs.New(
+				errs.M("NewAESGCM: key %q isn't an AES key", id),
+				errs.C(errorClass, errs.InvalidParameter),
+				errs.E(err))
+		}
+
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, errs.New(
+				errs.M("NewAESGCM: key %q doesn't make a GCM cipher", id),
+				errs.C(errorClass, errs.OperationFailed),
+				errs.E(err))
+		}
+
+		c.aeads[id] = aead
+	}
+
+	return c, nil
+}
+
+var _ Authenticator = (*AESGCM)(nil)
+
+// ID returns AESGCMID.
+func (*AESGCM) ID() string { return AESGCMID }
+
+// Authenticates reports true: GCM opens only what it sealed.
+func (*AESGCM) Authenticates() bool { return true }
+
+// Encode seals p and ad with the active key under a fresh random nonce.
+func (c *AESGCM) Encode(_ context.Context, ad, p []byte) ([]byte, error) {
+	aead := c.aeads[c.active]
+
+	out := make([]byte, 0,
+		1+len(c.active)+aead.NonceSize()+len(p)+aead.Overhead())
+	out = append(out, byte(len(c.active)))
+	out = append(out, c.active...)
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	out = append(out, nonce...)
+
+	return aead.Seal(out, nonce, p, ad), nil
+}
+
+// Decode opens p with the key its header names, authenticating ad with it.
+func (c *AESGCM) Decode(_ context.Context, ad, p []byte) ([]byte, error) {
+	if len(p) < 1 || len(p) < 1+int(p[0]) {
+		return nil, errs.New(
+			errs.M("AESGCM: truncated key id"),
+			errs.C(errorClass, errs.InvalidObject))
+	}
+
+	id := string(p[1 : 1+int(p[0])])
+	p = p[1+int(p[0]):]
+
+	aead, ok := c.aeads[id]
+	if !ok {
+		return nil, errs.New(
+			errs.M("AESGCM: key %q isn't in the keyring", id),
+			errs.C(errorClass, errs.ObjectNotFound))
+	}
+
+	if len(p) < aead.NonceSize() {
+		return nil, errs.New(
+			errs.M("AESGCM: truncated nonce"),
+			errs.C(errorClass, errs.InvalidObject))
+	}
+
+	nonce, sealed := p[:aead.NonceSize()], p[aead.NonceSize():]
+
+	plain, err := aead.Open(nil, nonce, sealed, ad)
+	if err != nil {
+		return nil, errs.New(
+			errs.M("AESGCM: the payload doesn't authenticate under key %q", id),
+			errs.C(errorClass, errs.InvalidObject),
+			errs.E(err))
+	}
+
+	return plain, nil
+}
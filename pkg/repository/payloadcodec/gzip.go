@@ -0,0 +1,102 @@
+package payloadcodec
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+)
+
+// GzipID is the Gzip codec's frame id.
+const GzipID = "gzip"
+
+// DefaultGzipLimit is the most a NewGzip codec inflates one payload to.
+const DefaultGzipLimit = 256 << 20
+
+// Gzip is the compression Codec: a checkpoint is JSON and compresses well,
+// which keeps large instances' records small. Put it before an encryption
+// codec — ciphertext doesn't compress. Decode refuses a payload that inflates
+// past the codec's limit, so a crafted record can't exhaust the memory.
+type Gzip struct {
+	limit int64
+	level int
+}
+
+// NewGzip builds the gzip codec at a compress/gzip level:
+// gzip.DefaultCompression, gzip.HuffmanOnly, or 1 (fastest) to 9 (smallest),
+// inflating at most DefaultGzipLimit bytes.
+func NewGzip(level int) (*Gzip, error) {
+	return NewGzipLimit(level, DefaultGzipLimit)
+}
+
+// NewGzipLimit builds the gzip codec as NewGzip does, inflating at most limit
+// bytes of one payload. A non-positive limit is rejected.
+func NewGzipLimit(level int, limit int64) (*Gzip, error) {
+	if _, err := gzip.NewWriterLevel(io.Discard, level); err != nil {
+		return nil, errs.New(
+			errs.M("NewGzip: invalid compression level %d", level),
+			errs.C(errorClass, errs.InvalidParameter),
+			errs.E(err))
+	}
+
+	if limit <= 0 {
+		return nil, errs.New(
+			errs.M("NewGzipLimit: the limit must be positive"),
+			errs.C(errorClass, errs.InvalidParameter))
+	}
+
+	return &Gzip{level: level, limit: limit}, nil
+}
+
+var _ Codec = (*Gzip)(nil)
+
+// ID returns GzipID.
+func (*Gzip) ID() string { return GzipID }
+
+// Encode compresses p.
+func (g *Gzip) Encode(_ context.Context, _, p []byte) ([]byte, error) {
+	var b bytes.Buffer
+
+	w, err := gzip.NewWriterLevel(&b, g.level)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := w.Write(p); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+// Decode decompresses p, up to the codec's limit.
+func (g *Gzip) Decode(_ context.Context, _, p []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(p))
+	if err != nil {
+		return nil, err
+	}
+
+	plain, err := io.ReadAll(io.LimitReader(r, g.limit+1))
+	if err != nil {
+		return nil, errors.Join(err, r.Close())
+	}
+
+	if err := r.Close(); err != nil {
+		return nil, err
+	}
+
+	if int64(len(plain)) > g.limit {
+		return nil, errs.New(
+			errs.M("Gzip: the payload inflates past %d bytes", g.limit),
+			errs.C(errorClass, errs.InvalidObject))
+	}
+
+	return plain, nil
+}
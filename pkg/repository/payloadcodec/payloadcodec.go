@@ -0,0 +1,311 @@
+// Package payloadcodec transforms checkpoint payloads on their way to and from
+// a Repository: compression, at-rest encryption, or any byte-to-byte step a
+// Codec implements. The engine applies the chain (thresher.WithCheckpointCodec)
+// before Save and after Load, so every Repository adapter stores the result as
+// opaque bytes, unchanged.
+//
+// An encoded payload is framed: a short header names the codecs applied, in
+// order, so a reader decodes it whatever chain it was written with — as long
+// as it knows those codecs. A payload without the header is a plain checkpoint
+// written before any codec was configured, and reads as is — unless the chain
+// is strict (NewStrictChain), which reads only payloads an authenticating
+// codec sealed, so a record can't be swapped for forged plain bytes.
+//
+// An authenticating codec (AESGCM) binds the frame header and the record id to
+// what it seals: a payload whose header was rewritten, or which was copied
+// into another record, fails to open.
+package payloadcodec
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+)
+
+const errorClass = "PAYLOAD_CODEC"
+
+// Codec is one reversible step of a payload chain. ID names it in the frame
+// header of every payload it encoded, so it must be stable across releases and
+// unique within a chain; Decode must invert Encode. ad is the payload's
+// associated data — its frame header and record id — which an authenticating
+// codec binds to what it seals; other codecs ignore it.
+type Codec interface {
+	ID() string
+	Encode(ctx context.Context, ad, p []byte) ([]byte, error)
+	Decode(ctx context.Context, ad, p []byte) ([]byte, error)
+}
+
+// Authenticator is a Codec whose Decode authenticates what it opens, an AEAD
+// such as AESGCM. A strict chain needs one.
+type Authenticator interface {
+	Codec
+
+	// Authenticates reports whether Decode refuses a payload, or associated
+	// data, that this codec didn't seal.
+	Authenticates() bool
+}
+
+// magic opens a framed payload. A plain checkpoint is a JSON object, so it
+// never starts with a NUL byte.
+var magic = []byte{0, 'g', 'p', 'c'}
+
+// frameVersion is the frame header's layout version.
+const frameVersion = 1
+
+// maxIDLen bounds a codec id and maxCodecs a chain: the header stores each
+// in one byte.
+const (
+	maxIDLen  = 255
+	maxCodecs = 255
+)
+
+// Chain is an ordered set of codecs. Encode applies them first to last and
+// frames the result; Decode reads the frame and undoes the codecs it names,
+// last to first. A nil or empty Chain writes plain payloads and still reads
+// them.
+type Chain struct {
+	byID   map[string]Codec
+	codecs []Codec
+	// strict refuses a payload no authenticating codec of the chain sealed
+	// (NewStrictChain).
+	strict bool
+}
+
+// NewChain builds the chain of codecs, applied in the given order. A chain
+// holds at most 255 codecs, and every codec needs a non-empty id of at most
+// 255 bytes, unique in the chain.
+func NewChain(codecs ...Codec) (*Chain, error) {
+	if len(codecs) > maxCodecs {
+		return nil, errs.New(
+			errs.M("NewChain: %d codecs exceed the chain's %d",
+				len(codecs), maxCodecs),
+			errs.C(errorClass, errs.InvalidParameter))
+	}
+
+	c := &Chain{byID: make(map[string]Codec, len(codecs))}
+
+	for _, cd := range codecs {
+		if cd == nil {
+			return nil, errs.New(
+				errs.M("NewChain: a nil codec isn't allowed"),
+				errs.C(errorClass, errs.EmptyNotAllowed))
+		}
+
+		id := cd.ID()
+		if strings.TrimSpace(id) == "" || len(id) > maxIDLen {
+			return nil, errs.New(
+				errs.M("NewChain: codec id %q must be 1..%d bytes", id, maxIDLen),
+				errs.C(errorClass, errs.InvalidParameter))
+		}
+
+		if _, dup := c.byID[id]; dup {
+			return nil, errs.New(
+				errs.M("NewChain: codec %q is in the chain twice", id),
+				errs.C(errorClass, errs.DuplicateObject))
+		}
+
+		c.byID[id] = cd
+		c.codecs = append(c.codecs, cd)
+	}
+
+	return c, nil
+}
+
+// NewStrictChain builds a chain as NewChain does that reads only what an
+// authenticating codec of it sealed: Decode refuses a plain payload and a
+// frame that names none of the chain's Authenticators. Records written before
+// the chain was configured no longer load — re-save them under a lenient
+// chain first. The chain needs at least one Authenticator.
+func NewStrictChain(codecs ...Codec) (*Chain, error) {
+	c, err := NewChain(codecs...)
+	if err != nil {
+		return nil, err
+	}
+
+	if !slices.ContainsFunc(c.codecs, authenticates) {
+		return nil, errs.New(
+			errs.M("NewStrictChain: no codec of the chain authenticates"),
+			errs.C(errorClass, errs.InvalidParameter))
+	}
+
+	c.strict = true
+
+	return c, nil
+}
+
+// authenticates reports whether cd is an Authenticator that authenticates.
+func authenticates(cd Codec) bool {
+	a, ok := cd.(Authenticator)
+
+	return ok && a.Authenticates()
+}
+
+// IDs returns the chain's codec ids in the order Encode applies them.
+func (c *Chain) IDs() []string {
+	if c == nil {
+		return nil
+	}
+
+	ids := make([]string, 0, len(c.codecs))
+	for _, cd := range c.codecs {
+		ids = append(ids, cd.ID())
+	}
+
+	return ids
+}
+
+// Encode runs p, the payload of record recordID, through every codec of the
+// chain and frames the result. An empty chain returns p unchanged — the plain
+// form.
+func (c *Chain) Encode(
+	ctx context.Context, recordID string, p []byte,
+) ([]byte, error) {
+	if c == nil || len(c.codecs) == 0 {
+		return p, nil
+	}
+
+	var b bytes.Buffer
+
+	b.Write(magic)
+	b.WriteByte(frameVersion)
+	b.WriteByte(byte(len(c.codecs)))
+
+	for _, cd := range c.codecs {
+		b.WriteByte(byte(len(cd.ID())))
+		b.WriteString(cd.ID())
+	}
+
+	ad := associatedData(b.Bytes(), recordID)
+
+	var err error
+
+	for _, cd := range c.codecs {
+		if p, err = cd.Encode(ctx, ad, p); err != nil {
+			return nil, errs.New(
+				errs.M("Encode: codec %q failed", cd.ID()),
+				errs.C(errorClass, errs.OperationFailed),
+				errs.E(err))
+		}
+	}
+
+	b.Write(p)
+
+	return b.Bytes(), nil
+}
+
+// Decode undoes the codecs a framed payload of record recordID names, last to
+// first, with the chain's codecs of those ids. An unframed payload is returned
+// unchanged, unless the chain is strict; a frame naming a codec the chain
+// lacks fails.
+func (c *Chain) Decode(
+	ctx context.Context, recordID string, p []byte,
+) ([]byte, error) {
+	if !Framed(p) {
+		if c != nil && c.strict {
+			return nil, errs.New(
+				errs.M("Decode: a strict chain refuses an unsealed payload"),
+				errs.C(errorClass, errs.InvalidObject))
+		}
+
+		return p, nil
+	}
+
+	ids, body, err := readFrame(p)
+	if err != nil {
+		return nil, err
+	}
+
+	if c != nil && c.strict &&
+		!slices.ContainsFunc(ids, func(id string) bool {
+			cd, ok := c.byID[id]
+
+			return ok && authenticates(cd)
+		}) {
+		return nil, errs.New(
+			errs.M("Decode: a strict chain refuses a payload no "+
+				"authenticating codec sealed (codecs %v)", ids),
+			errs.C(errorClass, errs.InvalidObject))
+	}
+
+	ad := associatedData(p[:len(p)-len(body)], recordID)
+
+	for i := len(ids) - 1; i >= 0; i-- {
+		var cd Codec
+		if c != nil {
+			cd = c.byID[ids[i]]
+		}
+
+		if cd == nil {
+			return nil, errs.New(
+				errs.M("Decode: the payload needs codec %q, which isn't "+
+					"configured", ids[i]),
+				errs.C(errorClass, errs.ObjectNotFound))
+		}
+
+		if body, err = cd.Decode(ctx, ad, body); err != nil {
+			return nil, errs.New(
+				errs.M("Decode: codec %q failed", ids[i]),
+				errs.C(errorClass, errs.InvalidObject),
+				errs.E(err))
+		}
+	}
+
+	return body, nil
+}
+
+// associatedData joins a payload's frame header and its record id into the
+// data an authenticating codec binds: the header is fixed-layout, so the id
+// simply follows it.
+func associatedData(header []byte, recordID string) []byte {
+	ad := make([]byte, 0, len(header)+len(recordID))
+	ad = append(ad, header...)
+
+	return append(ad, recordID...)
+}
+
+// Framed reports whether p is a framed (encoded) payload rather than a plain
+// one.
+func Framed(p []byte) bool {
+	return bytes.HasPrefix(p, magic)
+}
+
+// readFrame splits a framed payload into its codec ids and body.
+func readFrame(p []byte) ([]string, []byte, error) {
+	bad := func(what string) error {
+		return errs.New(
+			errs.M("Decode: malformed payload frame: %s", what),
+			errs.C(errorClass, errs.InvalidObject))
+	}
+
+	p = p[len(magic):]
+	if len(p) < 2 {
+		return nil, nil, bad("truncated header")
+	}
+
+	if p[0] != frameVersion {
+		return nil, nil, errs.New(
+			errs.M("Decode: unsupported payload frame version %d "+
+				"(this engine reads %d)", p[0], frameVersion),
+			errs.C(errorClass, errs.InvalidState))
+	}
+
+	n := int(p[1])
+	p = p[2:]
+
+	ids := make([]string, 0, n)
+
+	for range n {
+		if len(p) < 1 || len(p) < 1+int(p[0]) {
+			return nil, nil, bad("truncated codec id")
+		}
+
+		l := int(p[0])
+		ids = append(ids, string(p[1:1+l]))
+		p = p[1+l:]
+	}
+
+	return ids, p, nil
+}
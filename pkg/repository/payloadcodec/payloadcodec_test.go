@@ -0,0 +1,360 @@
+package payloadcodec_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/repository/payloadcodec"
+)
+
+var doc = []byte(`{"id":"i-1","process_id":"order","tracks":[` +
+	`{"state":"TrackWaitForEvent"},{"state":"TrackWaitForEvent"}]}`)
+
+func keyring() map[string][]byte {
+	return map[string][]byte{
+		"2026-01": bytes.Repeat([]byte{1}, 32),
+		"2026-07": bytes.Repeat([]byte{2}, 16),
+	}
+}
+
+func mustGzip(t *testing.T) *payloadcodec.Gzip {
+	t.Helper()
+
+	g, err := payloadcodec.NewGzip(gzip.BestCompression)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return g
+}
+
+func mustAES(t *testing.T, active string) *payloadcodec.AESGCM {
+	t.Helper()
+
+	a, err := payloadcodec.NewAESGCM(active, keyring())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return a
+}
+
+func mustChain(t *testing.T, codecs ...payloadcodec.Codec) *payloadcodec.Chain {
+	t.Helper()
+
+	c, err := payloadcodec.NewChain(codecs...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c
+}
+
+func requireClass(t *testing.T, err error, class string) {
+	t.Helper()
+
+	var ae *errs.ApplicationError
+	if !errors.As(err, &ae) || !ae.HasClass(class) {
+		t.Fatalf("error %v doesn't carry class %s", err, class)
+	}
+}
+
+// TestChainRoundTrip: every chain decodes what it encoded, and the encoded
+// form is framed and no longer carries the plain document.
+func TestChainRoundTrip(t *testing.T) {
+	ctx := context.Background()
+
+	for name, c := range map[string]*payloadcodec.Chain{
+		"gzip":          mustChain(t, mustGzip(t)),
+		"aes-gcm":       mustChain(t, mustAES(t, "2026-01")),
+		"gzip, aes-gcm": mustChain(t, mustGzip(t), mustAES(t, "2026-07")),
+	} {
+		t.Run(name, func(t *testing.T) {
+			enc, err := c.Encode(ctx, "i-1", doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !payloadcodec.Framed(enc) {
+				t.Fatal("an encoded payload must be framed")
+			}
+
+			if bytes.Contains(enc, []byte("process_id")) {
+				t.Fatal("the encoded payload still carries the document")
+			}
+
+			dec, err := c.Decode(ctx, "i-1", enc)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(dec, doc) {
+				t.Fatalf("decoded %q, want %q", dec, doc)
+			}
+		})
+	}
+}
+
+// TestChainPlainPayloads: a payload written before any codec was configured
+// reads unchanged through any chain, and an empty chain writes plain ones.
+func TestChainPlainPayloads(t *testing.T) {
+	ctx := context.Background()
+
+	for name, c := range map[string]*payloadcodec.Chain{
+		"nil":   nil,
+		"empty": mustChain(t),
+		"full":  mustChain(t, mustGzip(t), mustAES(t, "2026-01")),
+	} {
+		dec, err := c.Decode(ctx, "i-1", doc)
+		if err != nil || !bytes.Equal(dec, doc) {
+			t.Fatalf("%s: a plain payload must read as is: %q, %v",
+				name, dec, err)
+		}
+	}
+
+	enc, err := (*payloadcodec.Chain)(nil).Encode(ctx, "i-1", doc)
+	if err != nil || !bytes.Equal(enc, doc) || payloadcodec.Framed(enc) {
+		t.Fatalf("a nil chain must write plain payloads: %q, %v", enc, err)
+	}
+}
+
+// TestChainReadsOtherChains: the frame names the codecs a payload was written
+// with, so a chain reads payloads of any subset of its codecs, in any order,
+// and fails on a codec it lacks.
+func TestChainReadsOtherChains(t *testing.T) {
+	ctx := context.Background()
+	gz, aes := mustGzip(t), mustAES(t, "2026-01")
+
+	enc, err := mustChain(t, gz).Encode(ctx, "i-1", doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dec, err := mustChain(t, aes, gz).Decode(ctx, "i-1", enc)
+	if err != nil || !bytes.Equal(dec, doc) {
+		t.Fatalf("a gzip payload must read through a wider chain: %v", err)
+	}
+
+	_, err = mustChain(t, aes).Decode(ctx, "i-1", enc)
+	requireClass(t, err, errs.ObjectNotFound)
+}
+
+// TestAESGCMKeyRotation: a payload sealed under a retired key still opens
+// while the key stays in the ring, and fails once it is dropped.
+func TestAESGCMKeyRotation(t *testing.T) {
+	ctx := context.Background()
+
+	old, err := mustChain(t, mustAES(t, "2026-01")).Encode(ctx, "i-1", doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rotated := mustChain(t, mustAES(t, "2026-07"))
+
+	dec, err := rotated.Decode(ctx, "i-1", old)
+	if err != nil || !bytes.Equal(dec, doc) {
+		t.Fatalf("a retired key must still open its payloads: %v", err)
+	}
+
+	fresh, err := rotated.Encode(ctx, "i-1", doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Contains(fresh, []byte("2026-07")) {
+		t.Fatal("a payload must name the key that sealed it")
+	}
+
+	dropped, err := payloadcodec.NewAESGCM("2026-07", map[string][]byte{
+		"2026-07": keyring()["2026-07"],
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = mustChain(t, dropped).Decode(ctx, "i-1", old)
+	requireClass(t, err, errs.InvalidObject)
+}
+
+// TestChainRejectsDamage: tampered, truncated and newer-frame payloads fail
+// loud rather than decode to garbage.
+func TestChainRejectsDamage(t *testing.T) {
+	ctx := context.Background()
+	c := mustChain(t, mustGzip(t), mustAES(t, "2026-01"))
+
+	enc, err := c.Encode(ctx, "i-1", doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tampered := bytes.Clone(enc)
+	tampered[len(tampered)-1] ^= 0xff
+
+	_, err = c.Decode(ctx, "i-1", tampered)
+	requireClass(t, err, errs.InvalidObject)
+
+	_, err = c.Decode(ctx, "i-1", enc[:7])
+	requireClass(t, err, errs.InvalidObject)
+
+	newer := bytes.Clone(enc)
+	newer[4]++
+
+	_, err = c.Decode(ctx, "i-1", newer)
+	requireClass(t, err, errs.InvalidState)
+}
+
+// TestConstructorsValidate: the constructors refuse what can't work.
+func TestConstructorsValidate(t *testing.T) {
+	if _, err := payloadcodec.NewGzip(42); err == nil {
+		t.Fatal("an invalid gzip level must be refused")
+	}
+
+	if _, err := payloadcodec.NewAESGCM("none", keyring()); err == nil {
+		t.Fatal("an active key outside the ring must be refused")
+	}
+
+	if _, err := payloadcodec.NewAESGCM("k", map[string][]byte{
+		"k": []byte("short"),
+	}); err == nil {
+		t.Fatal("a key of the wrong size must be refused")
+	}
+
+	if _, err := payloadcodec.NewAESGCM("", map[string][]byte{
+		"": bytes.Repeat([]byte{1}, 32),
+	}); err == nil {
+		t.Fatal("an empty key id must be refused")
+	}
+
+	_, err := payloadcodec.NewChain(mustGzip(t), nil)
+	requireClass(t, err, errs.EmptyNotAllowed)
+
+	_, err = payloadcodec.NewChain(mustGzip(t), mustGzip(t))
+	requireClass(t, err, errs.DuplicateObject)
+
+	_, err = payloadcodec.NewChain(passCodec(strings.Repeat("x", 256)))
+	requireClass(t, err, errs.InvalidParameter)
+
+	many := make([]payloadcodec.Codec, 256)
+	for i := range many {
+		many[i] = passCodec(fmt.Sprintf("pass-%d", i))
+	}
+
+	_, err = payloadcodec.NewChain(many...)
+	requireClass(t, err, errs.InvalidParameter)
+
+	if _, err := payloadcodec.NewChain(many[:255]...); err != nil {
+		t.Fatalf("a chain of 255 codecs must build: %v", err)
+	}
+}
+
+// passCodec is a pass-through codec of a given id.
+type passCodec string
+
+func (c passCodec) ID() string { return string(c) }
+
+func (passCodec) Encode(_ context.Context, _, p []byte) ([]byte, error) {
+	return p, nil
+}
+
+func (passCodec) Decode(_ context.Context, _, p []byte) ([]byte, error) {
+	return p, nil
+}
+
+// TestAESGCMBindsFrameAndRecord: a sealed payload opens only under the
+// frame header and the record id it was sealed with.
+func TestAESGCMBindsFrameAndRecord(t *testing.T) {
+	ctx := context.Background()
+	aes := mustAES(t, "2026-01")
+
+	enc, err := mustChain(t, passCodec("pass-a"), aes).Encode(ctx, "i-1", doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reader := mustChain(t, passCodec("pass-a"), passCodec("pass-b"), aes)
+
+	if _, err := reader.Decode(ctx, "i-1", enc); err != nil {
+		t.Fatalf("the payload must open in its own record: %v", err)
+	}
+
+	_, err = reader.Decode(ctx, "i-2", enc)
+	requireClass(t, err, errs.InvalidObject)
+
+	rewritten := bytes.Replace(enc, []byte("pass-a"), []byte("pass-b"), 1)
+
+	_, err = reader.Decode(ctx, "i-1", rewritten)
+	requireClass(t, err, errs.InvalidObject)
+}
+
+// TestStrictChain: a strict chain reads only what its authenticating codec
+// sealed, and needs one.
+func TestStrictChain(t *testing.T) {
+	ctx := context.Background()
+
+	strict, err := payloadcodec.NewStrictChain(mustGzip(t), mustAES(t, "2026-01"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	enc, err := strict.Encode(ctx, "i-1", doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if dec, err := strict.Decode(ctx, "i-1", enc); err != nil || !bytes.Equal(dec, doc) {
+		t.Fatalf("a strict chain must read its own payloads: %v", err)
+	}
+
+	_, err = strict.Decode(ctx, "i-1", doc)
+	requireClass(t, err, errs.InvalidObject)
+
+	gzipped, err := mustChain(t, mustGzip(t)).Encode(ctx, "i-1", doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = strict.Decode(ctx, "i-1", gzipped)
+	requireClass(t, err, errs.InvalidObject)
+
+	_, err = payloadcodec.NewStrictChain(mustGzip(t))
+	requireClass(t, err, errs.InvalidParameter)
+}
+
+// TestGzipLimit: a payload that inflates past the codec's limit is refused.
+func TestGzipLimit(t *testing.T) {
+	ctx := context.Background()
+
+	small, err := payloadcodec.NewGzipLimit(gzip.BestCompression, int64(len(doc)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := mustChain(t, small)
+
+	enc, err := c.Encode(ctx, "i-1", doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.Decode(ctx, "i-1", enc); err != nil {
+		t.Fatalf("a payload at the limit must inflate: %v", err)
+	}
+
+	enc, err = c.Encode(ctx, "i-1", append(bytes.Clone(doc), ' '))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.Decode(ctx, "i-1", enc)
+	requireClass(t, err, errs.InvalidObject)
+
+	if _, err := payloadcodec.NewGzipLimit(gzip.BestSpeed, 0); err == nil {
+		t.Fatal("a non-positive limit must be refused")
+	}
+}
@@ -77,10 +77,16 @@ type InstanceRecord struct {
 	// default tenant; resolution to a concrete registry entry is the
 	// store's concern, the engine stamps "" until the Multi-tenancy ADR
 	// wires real assignment.
-	Tenant     string
-	Lease      Lease
-	RecVersion int64
-	Status     Status
+	Tenant string
+	// ProcessID is the instance's process key and BusinessKey the host's
+	// own identifier for it (an order id, say) — empty when none was set.
+	// Both are copies of what the payload carries, lifted out of it so a
+	// store can answer FindByBusinessKey without decoding payloads.
+	ProcessID   string
+	BusinessKey string
+	Lease       Lease
+	RecVersion  int64
+	Status      Status
 }
 
 // Repository persists Process Instance checkpoints. Save is
@@ -116,3 +122,102 @@ type Repository interface {
 	// fresh partition). An empty group MUST fail loud.
 	GroupExists(ctx context.Context, group string) (bool, error)
 }
+
+// BusinessKeyFinder is the optional Repository capability behind the
+// best-effort cross-engine business-key check: it lets an engine see
+// instances its own registry doesn't track — another group member's, or
+// its own not yet recovered. It is a lookup, not a reservation, so it
+// narrows duplicates across engines without excluding them; uniqueness is
+// guaranteed per engine only, with or without it.
+type BusinessKeyFinder interface {
+	// FindByBusinessKey returns the IDs of the NON-TERMINAL instances of
+	// the given engine group and process carrying businessKey, sorted. An
+	// empty group, process or key MUST fail loud.
+	FindByBusinessKey(
+		ctx context.Context, group, processID, businessKey string,
+	) ([]string, error)
+}
+
+// DueTimer is one armed timer deadline in a group's due-timer index: the
+// instance and track it wakes, the timer's event definition and the
+// absolute deadline. A track may hold several (an Event-Based Gateway
+// racing two timers), so a timer is keyed by InstanceID, TrackID and
+// EventID together.
+type DueTimer struct {
+	Deadline   time.Time
+	Group      string
+	InstanceID string
+	TrackID    string
+	EventID    string
+}
+
+// TimerIndex is the optional Repository capability behind cluster-wide
+// timer firing: the engines of a group record their armed deadlines in
+// it, and any of them fires one that is overdue while its owner is gone
+// by claiming the instance — the claim's lease and incarnation are the
+// fencing. A store without it keeps timers in the arming engine's
+// memory only; an instance whose engine died then fires only once
+// another engine recovers it at start.
+type TimerIndex interface {
+	// PutTimer records the deadline, replacing one under the same
+	// instance, track and event. An empty group, instance or track
+	// MUST fail loud.
+	PutTimer(ctx context.Context, timer DueTimer) error
+	// DeleteTimers removes the instance's deadlines: the one of eventID
+	// on the track, every one of the track when eventID is empty, and
+	// every one of the instance when trackID is empty too. Removing
+	// what is absent is a no-op.
+	DeleteTimers(ctx context.Context, instanceID, trackID, eventID string) error
+	// DueTimers returns the group's deadlines at or before `before`,
+	// earliest first — ties by instance, track and event — and at most
+	// limit of them (limit <= 0: all). It leaves out the deadlines no
+	// takeover can fire: those of a suspended instance and of one whose
+	// lease is live at now, so they never crowd a claimable deadline out
+	// of the limit. A deadline whose instance has no record, or a
+	// finished one, is listed for the caller to drop. An empty group
+	// MUST fail loud.
+	DueTimers(
+		ctx context.Context, group string, before, now time.Time, limit int,
+	) ([]DueTimer, error)
+}
+
+// GroupMember is one engine's membership record in its group's registry:
+// who it is, the build it runs, when it started and when it last proved
+// itself alive.
+type GroupMember struct {
+	Started   time.Time
+	Heartbeat time.Time
+	Group     string
+	EngineID  string
+	// Refresh is how often the engine refreshes its Heartbeat; the group
+	// judges the member alive against it, not against its own cadence.
+	Refresh time.Duration
+	// Version and Revision are the engine's build info: the module
+	// version and the VCS revision it was built from, empty when the
+	// binary doesn't carry them.
+	Version  string
+	Revision string
+}
+
+// MembershipRegistry is the optional Repository capability behind the
+// engine-group health view: every engine of a group keeps a membership
+// record, refreshed by its lease heartbeat, and any of them can list the
+// group with the count of instances each one holds. A store without it
+// records group names only (RegisterGroup); the engines stay invisible
+// to one another.
+type MembershipRegistry interface {
+	// PutMember records the member, replacing the record under the same
+	// group and engine id. An empty group or engine id MUST fail loud.
+	PutMember(ctx context.Context, member GroupMember) error
+	// RemoveMember removes the engine's record from the group. Removing
+	// what is absent is a no-op.
+	RemoveMember(ctx context.Context, group, engineID string) error
+	// Members returns the group's records ordered by engine id. An empty
+	// group MUST fail loud.
+	Members(ctx context.Context, group string) ([]GroupMember, error)
+	// OwnedCounts counts the group's non-terminal records by lease
+	// owner, lapsed leases included: an engine that crashed still owns
+	// its instances until the group takes them over. A record without
+	// an owner counts for none. An empty group MUST fail loud.
+	OwnedCounts(ctx context.Context, group string) (map[string]int, error)
+}
@@ -3,7 +3,9 @@
 // in-memory default and any durable adapter — proves the same contract
 // by calling Conformance from a one-line test. The suite covers the
 // CAS discipline, the ADR-033 §2.8 group scoping, lease and tenant
-// round-trips, payload isolation and the recovery-listing filters.
+// round-trips, payload isolation and the recovery-listing filters — and,
+// for a store offering them, the business-key lookup, the due-timer
+// index and the membership registry.
 package repositorytest
 
 import (
@@ -75,6 +77,9 @@ var conformanceTests = map[string]func(*testing.T, repository.Repository){
 	"ListEmptyGroupRejected":        testListEmptyGroupRejected,
 	"ListUnregisteredGroupEmpty":    testListUnregisteredGroupEmpty,
 	"ListDeterministicOrder":        testListDeterministicOrder,
+	"FindByBusinessKey":             testFindByBusinessKey,
+	"TimerIndex":                    testTimerIndex,
+	"MembershipRegistry":            testMembershipRegistry,
 }
 
 func testCASCreateAndUpdate(t *testing.T, r repository.Repository) {
@@ -180,6 +185,8 @@ func testRegistryEmptyGroupRejected(t *testing.T, r repository.Repository) {
 func testLoadFidelity(t *testing.T, r repository.Repository) {
 	in := rec("i1")
 	in.Tenant = "acme"
+	in.ProcessID = "order-flow"
+	in.BusinessKey = "ord-1"
 	in.Lease = repository.Lease{
 		Owner:       "engine-a",
 		Incarnation: 3,
@@ -197,6 +204,11 @@ func testLoadFidelity(t *testing.T, r repository.Repository) {
 		t.Fatalf("partitions = %q/%q, want %q/%q", got.Group, got.Tenant, in.Group, in.Tenant)
 	}
 
+	if got.ProcessID != in.ProcessID || got.BusinessKey != in.BusinessKey {
+		t.Fatalf("business identity = %q/%q, want %q/%q",
+			got.ProcessID, got.BusinessKey, in.ProcessID, in.BusinessKey)
+	}
+
 	if got.Lease.Owner != in.Lease.Owner ||
 		got.Lease.Incarnation != in.Lease.Incarnation ||
 		!got.Lease.Expiry.Equal(in.Lease.Expiry) {
@@ -349,6 +361,303 @@ func testListDeterministicOrder(t *testing.T, r repository.Repository) {
 	}
 }
 
+// testFindByBusinessKey proves the optional lookup: only non-terminal
+// records of the same group, process and key list, sorted; a store
+// without the capability skips.
+func testFindByBusinessKey(t *testing.T, r repository.Repository) {
+	f, ok := r.(repository.BusinessKeyFinder)
+	if !ok {
+		t.Skip("the store doesn't offer repository.BusinessKeyFinder")
+	}
+
+	ctx := context.Background()
+
+	saveVariant := func(id string, mut func(*repository.InstanceRecord)) {
+		v := rec(id)
+		v.ProcessID = "order-flow"
+		v.BusinessKey = "ord-1"
+		mut(&v)
+		mustSave(t, r, v)
+	}
+
+	saveVariant("b", func(*repository.InstanceRecord) {})
+	saveVariant("a", func(v *repository.InstanceRecord) {
+		v.Status = repository.StatusSuspended
+	})
+	saveVariant("done", func(v *repository.InstanceRecord) {
+		v.Status = repository.StatusCompleted
+	})
+	saveVariant("other-key", func(v *repository.InstanceRecord) {
+		v.BusinessKey = "ord-2"
+	})
+	saveVariant("other-process", func(v *repository.InstanceRecord) {
+		v.ProcessID = "refund-flow"
+	})
+	saveVariant("other-group", func(v *repository.InstanceRecord) {
+		v.Group = "group-b"
+	})
+
+	ids, err := f.FindByBusinessKey(ctx, "conformance-group", "order-flow", "ord-1")
+	if err != nil {
+		t.Fatalf("FindByBusinessKey: %v", err)
+	}
+
+	if want := []string{"a", "b"}; !slices.Equal(ids, want) {
+		t.Fatalf("FindByBusinessKey = %v, want %v", ids, want)
+	}
+
+	for _, args := range [][3]string{
+		{"", "order-flow", "ord-1"},
+		{"conformance-group", "", "ord-1"},
+		{"conformance-group", "order-flow", ""},
+	} {
+		if _, err := f.FindByBusinessKey(ctx, args[0], args[1], args[2]); err == nil {
+			t.Fatalf("FindByBusinessKey%v must fail loud", args)
+		}
+	}
+}
+
+// testTimerIndex proves the optional due-timer index: a put replaces
+// the deadline under the same instance, track and event; the listing is
+// group-scoped, due-bounded, ordered and limited; deletes narrow by
+// event, track and instance; the deadlines of a suspended or live-leased
+// instance stay out of the listing while those of a lapsed or finished
+// one stay in. A store without the capability skips.
+func testTimerIndex(t *testing.T, r repository.Repository) {
+	ti, ok := r.(repository.TimerIndex)
+	if !ok {
+		t.Skip("the store doesn't offer repository.TimerIndex")
+	}
+
+	ctx := context.Background()
+
+	put := func(inst, track, event string, at time.Time, group string) {
+		t.Helper()
+
+		if err := ti.PutTimer(ctx, repository.DueTimer{
+			Deadline:   at,
+			Group:      group,
+			InstanceID: inst,
+			TrackID:    track,
+			EventID:    event,
+		}); err != nil {
+			t.Fatalf("PutTimer %s/%s/%s: %v", inst, track, event, err)
+		}
+	}
+
+	due := func(limit int) []string {
+		t.Helper()
+
+		got, err := ti.DueTimers(ctx, "conformance-group", now, now, limit)
+		if err != nil {
+			t.Fatalf("DueTimers: %v", err)
+		}
+
+		keys := make([]string, 0, len(got))
+		for _, d := range got {
+			if d.Group != "conformance-group" {
+				t.Fatalf("DueTimers listed group %q", d.Group)
+			}
+
+			keys = append(keys, d.InstanceID+"/"+d.TrackID+"/"+d.EventID)
+		}
+
+		return keys
+	}
+
+	put("i1", "t1", "e1", now.Add(time.Hour), "conformance-group")
+	put("i1", "t1", "e1", now.Add(-time.Minute), "conformance-group") // replaces
+	put("i1", "t1", "e2", now.Add(-time.Minute), "conformance-group")
+	put("i1", "t2", "e1", now, "conformance-group")
+	put("i2", "t1", "e1", now.Add(-time.Hour), "conformance-group")
+	put("i3", "t1", "e1", now.Add(time.Second), "conformance-group")
+	put("i4", "t1", "e1", now.Add(-time.Hour), "group-b")
+
+	want := []string{"i2/t1/e1", "i1/t1/e1", "i1/t1/e2", "i1/t2/e1"}
+	if got := due(0); !slices.Equal(got, want) {
+		t.Fatalf("DueTimers = %v, want %v", got, want)
+	}
+
+	if got := due(2); !slices.Equal(got, want[:2]) {
+		t.Fatalf("DueTimers(limit 2) = %v, want %v", got, want[:2])
+	}
+
+	steps := []struct {
+		inst, track, event string
+		want               []string
+	}{
+		{"i1", "t1", "e1", []string{"i2/t1/e1", "i1/t1/e2", "i1/t2/e1"}},
+		{"i1", "t1", "", []string{"i2/t1/e1", "i1/t2/e1"}},
+		{"i1", "", "", []string{"i2/t1/e1"}},
+		{"absent", "", "", []string{"i2/t1/e1"}},
+	}
+
+	for _, s := range steps {
+		if err := ti.DeleteTimers(ctx, s.inst, s.track, s.event); err != nil {
+			t.Fatalf("DeleteTimers(%q, %q, %q): %v", s.inst, s.track, s.event, err)
+		}
+
+		if got := due(0); !slices.Equal(got, s.want) {
+			t.Fatalf("after DeleteTimers(%q, %q, %q): %v, want %v",
+				s.inst, s.track, s.event, got, s.want)
+		}
+	}
+
+	for id, mod := range map[string]func(*repository.InstanceRecord){
+		"c1": func(v *repository.InstanceRecord) { v.Status = repository.StatusCompleted },
+		"l1": func(v *repository.InstanceRecord) {
+			v.Lease = repository.Lease{
+				Owner: "engine-b", Incarnation: 1, Expiry: now.Add(time.Hour),
+			}
+		},
+		"s1": func(v *repository.InstanceRecord) { v.Status = repository.StatusSuspended },
+		"x1": func(v *repository.InstanceRecord) {
+			v.Lease = repository.Lease{
+				Owner: "engine-b", Incarnation: 1, Expiry: now.Add(-time.Second),
+			}
+		},
+	} {
+		v := rec(id)
+		mod(&v)
+
+		mustSave(t, r, v)
+		put(id, "t1", "e1", now.Add(-30*time.Minute), "conformance-group")
+	}
+
+	want = []string{"i2/t1/e1", "c1/t1/e1", "x1/t1/e1"}
+	if got := due(0); !slices.Equal(got, want) {
+		t.Fatalf("DueTimers over suspended and leased records = %v, want %v", got, want)
+	}
+
+	if got := due(2); !slices.Equal(got, want[:2]) {
+		t.Fatalf("DueTimers(limit 2) over skipped records = %v, want %v", got, want[:2])
+	}
+
+	for _, bad := range []repository.DueTimer{
+		{Deadline: now, InstanceID: "i", TrackID: "t"},
+		{Deadline: now, Group: "conformance-group", TrackID: "t"},
+		{Deadline: now, Group: "conformance-group", InstanceID: "i"},
+	} {
+		if err := ti.PutTimer(ctx, bad); err == nil {
+			t.Fatalf("PutTimer(%+v) must fail loud", bad)
+		}
+	}
+
+	if _, err := ti.DueTimers(ctx, "", now, now, 0); err == nil {
+		t.Fatal("DueTimers with an empty group must fail loud")
+	}
+}
+
+// testMembershipRegistry proves the optional membership registry: a put
+// replaces the record under the same group and engine id, the listing is
+// group-scoped and ordered by engine id, a removal is idempotent, and the
+// owned counts cover the group's unfinished records by lease owner, lapsed
+// leases included. A store without the capability skips.
+func testMembershipRegistry(t *testing.T, r repository.Repository) {
+	mr, ok := r.(repository.MembershipRegistry)
+	if !ok {
+		t.Skip("the store doesn't offer repository.MembershipRegistry")
+	}
+
+	ctx := context.Background()
+
+	put := func(group, engine string, beat time.Time) {
+		t.Helper()
+
+		if err := mr.PutMember(ctx, repository.GroupMember{
+			Started:   now.Add(-time.Hour),
+			Heartbeat: beat,
+			Group:     group,
+			EngineID:  engine,
+			Refresh:   20 * time.Second,
+			Version:   "v1.2.3",
+			Revision:  "abc123",
+		}); err != nil {
+			t.Fatalf("PutMember %s/%s: %v", group, engine, err)
+		}
+	}
+
+	put("conformance-group", "engine-b", now.Add(-time.Minute))
+	put("conformance-group", "engine-a", now.Add(-time.Minute))
+	put("conformance-group", "engine-b", now) // replaces
+	put("group-b", "engine-c", now)
+
+	members, err := mr.Members(ctx, "conformance-group")
+	if err != nil {
+		t.Fatalf("Members: %v", err)
+	}
+
+	if len(members) != 2 || members[0].EngineID != "engine-a" ||
+		members[1].EngineID != "engine-b" {
+		t.Fatalf("Members = %+v, want engine-a and engine-b", members)
+	}
+
+	b := members[1]
+	if b.Group != "conformance-group" || !b.Heartbeat.Equal(now) ||
+		!b.Started.Equal(now.Add(-time.Hour)) ||
+		b.Refresh != 20*time.Second ||
+		b.Version != "v1.2.3" || b.Revision != "abc123" {
+		t.Fatalf("member round-trip: %+v", b)
+	}
+
+	for range 2 {
+		if err := mr.RemoveMember(ctx, "conformance-group", "engine-a"); err != nil {
+			t.Fatalf("RemoveMember: %v", err)
+		}
+	}
+
+	if members, _ = mr.Members(ctx, "conformance-group"); len(members) != 1 ||
+		members[0].EngineID != "engine-b" {
+		t.Fatalf("after RemoveMember: %+v, want engine-b only", members)
+	}
+
+	live := repository.Lease{Owner: "engine-a", Incarnation: 1, Expiry: now.Add(time.Minute)}
+	lapsed := repository.Lease{Owner: "engine-b", Incarnation: 1, Expiry: now}
+
+	for id, v := range map[string]struct {
+		lease  repository.Lease
+		status repository.Status
+		group  string
+	}{
+		"o1": {live, repository.StatusActive, "conformance-group"},
+		"o2": {live, repository.StatusSuspended, "conformance-group"},
+		"o3": {live, repository.StatusCompleted, "conformance-group"},
+		"o4": {lapsed, repository.StatusActive, "conformance-group"},
+		"o5": {live, repository.StatusActive, "group-b"},
+		"o6": {repository.Lease{}, repository.StatusActive, "conformance-group"},
+	} {
+		x := rec(id)
+		x.Lease, x.Status, x.Group = v.lease, v.status, v.group
+		mustSave(t, r, x)
+	}
+
+	owned, err := mr.OwnedCounts(ctx, "conformance-group")
+	if err != nil {
+		t.Fatalf("OwnedCounts: %v", err)
+	}
+
+	if len(owned) != 2 || owned["engine-a"] != 2 || owned["engine-b"] != 1 {
+		t.Fatalf("OwnedCounts = %v, want engine-a: 2, engine-b: 1", owned)
+	}
+
+	for _, bad := range []repository.GroupMember{
+		{EngineID: "engine-a"},
+		{Group: "conformance-group"},
+	} {
+		if err := mr.PutMember(ctx, bad); err == nil {
+			t.Fatalf("PutMember(%+v) must fail loud", bad)
+		}
+	}
+
+	if _, err := mr.Members(ctx, ""); err == nil {
+		t.Fatal("Members with an empty group must fail loud")
+	}
+
+	if _, err := mr.OwnedCounts(ctx, ""); err == nil {
+		t.Fatal("OwnedCounts with an empty group must fail loud")
+	}
+}
+
 // mustRegister establishes the baseline conformance group.
 func mustRegister(t *testing.T, r repository.Repository) {
 	t.Helper()
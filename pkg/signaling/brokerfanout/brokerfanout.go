@@ -0,0 +1,224 @@
+// Package brokerfanout provides the default signaling.Fanout: it carries the
+// engine group's signals as broadcasts of a messaging.Broadcaster — the
+// group's own MessageBroker, when it implements the capability. A signal
+// travels as one envelope named MessageName (WithMessageName overrides it)
+// whose MessageID is the signal's ID and whose payload is the Signal itself;
+// a broker that encodes payloads hands it back as its JSON decoding, which
+// the Fanout reads back into a Signal.
+package brokerfanout
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/messaging"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+	"github.com/dr-dobermann/gobpm/pkg/signaling"
+)
+
+const (
+	// MessageName is the default name of the broadcasts carrying signals.
+	MessageName = "gobpm.signal"
+
+	// listenBuffer is the Listen channel buffer.
+	listenBuffer = 16
+
+	errorClass = "SIGNAL_FANOUT"
+)
+
+// Fanout is the broadcast-backed signaling.Fanout. Build it with New.
+type Fanout struct {
+	broadcaster messaging.Broadcaster
+	logger      observability.Logger
+	name        string
+}
+
+// Option configures a Fanout at New.
+type Option func(*Fanout) error
+
+// WithMessageName overrides the name of the broadcasts carrying signals
+// (default: MessageName). Every engine of the group must use the same one.
+func WithMessageName(name string) Option {
+	return func(f *Fanout) error {
+		if name == "" {
+			return errs.New(
+				errs.M("WithMessageName: an empty message name isn't allowed"),
+				errs.C(errorClass, errs.EmptyNotAllowed))
+		}
+
+		f.name = name
+
+		return nil
+	}
+}
+
+// WithLogger sets the structured logger (default: slog.Default()).
+func WithLogger(l observability.Logger) Option {
+	return func(f *Fanout) error {
+		if l == nil {
+			return errs.New(
+				errs.M("WithLogger: a nil Logger isn't allowed"),
+				errs.C(errorClass, errs.EmptyNotAllowed))
+		}
+
+		f.logger = l
+
+		return nil
+	}
+}
+
+// New builds a Fanout over b.
+func New(b messaging.Broadcaster, opts ...Option) (*Fanout, error) {
+	if b == nil {
+		return nil, errs.New(
+			errs.M("New: a nil Broadcaster isn't allowed"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	f := &Fanout{
+		broadcaster: b,
+		logger:      slog.Default(),
+		name:        MessageName,
+	}
+
+	for _, o := range opts {
+		if err := o(f); err != nil {
+			return nil, err
+		}
+	}
+
+	return f, nil
+}
+
+// Broadcast hands sig to the broker as one broadcast (signaling.Fanout).
+func (f *Fanout) Broadcast(ctx context.Context, sig signaling.Signal) error {
+	if sig.ID == "" || sig.Name == "" {
+		return errs.New(
+			errs.M("Broadcast: a signal needs an ID and a name"),
+			errs.C(errorClass, errs.InvalidParameter),
+			errs.D(observability.AttrSignalID, sig.ID),
+			errs.D(observability.AttrSignal, sig.Name))
+	}
+
+	if err := f.broadcaster.Broadcast(ctx, messaging.Envelope{
+		Payload:   sig,
+		Name:      f.name,
+		MessageID: sig.ID,
+	}); err != nil {
+		return errs.New(
+			errs.M("the signal couldn't be broadcast"),
+			errs.C(errorClass, errs.OperationFailed),
+			errs.D(observability.AttrSignal, sig.Name),
+			errs.E(err))
+	}
+
+	return nil
+}
+
+// Listen subscribes to the broker's signal broadcasts (signaling.Fanout).
+// A broadcast that doesn't decode into a signal is dropped with a warning.
+func (f *Fanout) Listen(ctx context.Context) (signaling.Subscription, error) {
+	bs, err := f.broadcaster.SubscribeBroadcast(ctx, f.name)
+	if err != nil {
+		return nil, errs.New(
+			errs.M("the signal broadcasts couldn't be subscribed"),
+			errs.C(errorClass, errs.OperationFailed),
+			errs.E(err))
+	}
+
+	s := &subscription{
+		bs:   bs,
+		ch:   make(chan signaling.Signal, listenBuffer),
+		stop: make(chan struct{}),
+	}
+
+	go s.relay(f.logger)
+
+	return s, nil
+}
+
+// subscription relays the decoded broadcasts of a broker subscription.
+type subscription struct {
+	bs   messaging.Subscription
+	ch   chan signaling.Signal
+	stop chan struct{}
+	once sync.Once
+}
+
+// C returns the channel of the group's signals.
+func (s *subscription) C() <-chan signaling.Signal { return s.ch }
+
+// Unsubscribe stops the relay and the broker subscription. Idempotent.
+func (s *subscription) Unsubscribe() error {
+	var err error
+
+	s.once.Do(func() {
+		close(s.stop)
+		err = s.bs.Unsubscribe()
+	})
+
+	return err
+}
+
+// relay decodes the broadcasts until the subscription stops.
+func (s *subscription) relay(logger observability.Logger) {
+	for {
+		select {
+		case <-s.stop:
+			return
+
+		case env := <-s.bs.C():
+			sig, err := decode(env.Payload)
+			if err != nil {
+				logger.Warn("brokerfanout: undecodable signal dropped",
+					observability.AttrMessageID, env.MessageID,
+					observability.AttrError, err.Error())
+
+				continue
+			}
+
+			select {
+			case s.ch <- sig:
+			case <-s.stop:
+				return
+			}
+		}
+	}
+}
+
+// decode reads a broadcast payload back into a Signal: as is, or through
+// its JSON form when the broker handed over a decoding.
+func decode(payload any) (signaling.Signal, error) {
+	switch p := payload.(type) {
+	case signaling.Signal:
+		return p, nil
+
+	case *signaling.Signal:
+		if p != nil {
+			return *p, nil
+		}
+	}
+
+	var sig signaling.Signal
+
+	body, err := json.Marshal(payload)
+	if err == nil {
+		err = json.Unmarshal(body, &sig)
+	}
+
+	if err == nil && (sig.ID == "" || sig.Name == "") {
+		err = errs.New(
+			errs.M("the broadcast carries no signal"),
+			errs.C(errorClass, errs.InvalidObject))
+	}
+
+	return sig, err
+}
+
+var (
+	_ signaling.Fanout       = (*Fanout)(nil)
+	_ signaling.Subscription = (*subscription)(nil)
+)
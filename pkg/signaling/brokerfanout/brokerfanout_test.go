@@ -0,0 +1,100 @@
+package brokerfanout_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gobpm/pkg/messaging"
+	"github.com/dr-dobermann/gobpm/pkg/messaging/membroker"
+	"github.com/dr-dobermann/gobpm/pkg/signaling"
+	"github.com/dr-dobermann/gobpm/pkg/signaling/brokerfanout"
+)
+
+func listen(t *testing.T, f signaling.Fanout) signaling.Subscription {
+	t.Helper()
+
+	sub, err := f.Listen(context.Background())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = sub.Unsubscribe() })
+
+	return sub
+}
+
+func receive(t *testing.T, sub signaling.Subscription) signaling.Signal {
+	t.Helper()
+
+	select {
+	case sig := <-sub.C():
+		return sig
+	case <-time.After(time.Second):
+		t.Fatal("no signal delivered")
+	}
+
+	return signaling.Signal{}
+}
+
+func TestNew(t *testing.T) {
+	_, err := brokerfanout.New(nil)
+	require.Error(t, err)
+
+	for _, o := range []brokerfanout.Option{
+		brokerfanout.WithMessageName(""),
+		brokerfanout.WithLogger(nil),
+	} {
+		_, err := brokerfanout.New(membroker.New(), o)
+		require.Error(t, err)
+	}
+}
+
+// TestBroadcastReachesEveryListener: both engines' listeners get the
+// signal, the thrower's own included.
+func TestBroadcastReachesEveryListener(t *testing.T) {
+	ctx := context.Background()
+	broker := membroker.New()
+
+	fa, err := brokerfanout.New(broker)
+	require.NoError(t, err)
+
+	fb, err := brokerfanout.New(broker)
+	require.NoError(t, err)
+
+	subs := []signaling.Subscription{listen(t, fa), listen(t, fb)}
+
+	sig := signaling.Signal{
+		Payload: "go", ID: "s-1", Name: "start", ItemID: "go_in", Origin: "engine-a",
+	}
+
+	require.Error(t, fa.Broadcast(ctx, signaling.Signal{Name: "start"}),
+		"a signal without an ID is rejected")
+	require.NoError(t, fa.Broadcast(ctx, sig))
+
+	for _, sub := range subs {
+		require.Equal(t, sig, receive(t, sub))
+	}
+}
+
+// TestDecodedPayload: a broker handing the payload over as its JSON
+// decoding still yields the signal; a foreign broadcast is dropped.
+func TestDecodedPayload(t *testing.T) {
+	ctx := context.Background()
+	broker := membroker.New()
+
+	f, err := brokerfanout.New(broker, brokerfanout.WithMessageName("sig"))
+	require.NoError(t, err)
+
+	sub := listen(t, f)
+
+	require.NoError(t, broker.Broadcast(ctx, messaging.Envelope{
+		Name: "sig", Payload: map[string]any{"name": "foreign"}}))
+	require.NoError(t, broker.Broadcast(ctx, messaging.Envelope{
+		Name: "sig", Payload: map[string]any{
+			"id": "s-2", "name": "start", "origin": "engine-b",
+			"payload": float64(7)}}))
+
+	require.Equal(t, signaling.Signal{
+		Payload: float64(7), ID: "s-2", Name: "start", Origin: "engine-b",
+	}, receive(t, sub))
+}
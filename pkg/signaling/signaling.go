@@ -0,0 +1,52 @@
+// Package signaling defines the engine group's signal fan-out seam. A BPMN
+// signal is a broadcast: every catch and signal start listening for its name
+// reacts, on whichever engine of the group it waits — where a message
+// reaches exactly one receiver. The engine hands each locally thrown signal
+// to its Fanout, which delivers it to every other engine of the group;
+// each receiver drops the signals it already took (by Signal.ID) and those
+// it threw itself (by Signal.Origin) and propagates the rest through its
+// own event hub, waking dehydrated waits as a local throw would.
+//
+// The default Fanout, in the brokerfanout sibling subpackage, rides a
+// messaging.Broadcaster — the engine wires it when the group's broker
+// implements one. thresher.WithSignalFanout plugs in any other transport.
+package signaling
+
+import (
+	"context"
+)
+
+// Signal is a thrown signal on its way across the engine group.
+type Signal struct {
+	// Payload is the value of the signal's structure item; nil for a
+	// signal without one. A transport may hand it over in a decoded form
+	// (JSON numbers as float64, objects as map[string]any).
+	Payload any `json:"payload,omitempty"`
+	// ID names this throw; a receiver processes each ID once.
+	ID string `json:"id"`
+	// Name is the signal name a catch matches on.
+	Name string `json:"name"`
+	// ItemID is the id of the signal's structure item, the id a catch binds
+	// the payload under; empty for a signal without one.
+	ItemID string `json:"itemId,omitempty"`
+	// Origin is the id of the engine that threw the signal.
+	Origin string `json:"origin"`
+}
+
+// Subscription is a live Listen registration.
+type Subscription interface {
+	// C is the channel of the signals thrown across the group, the
+	// listener's own included.
+	C() <-chan Signal
+	// Unsubscribe stops the delivery. Idempotent.
+	Unsubscribe() error
+}
+
+// Fanout carries signals between the engines of a group.
+type Fanout interface {
+	// Broadcast delivers sig to every engine listening in the group. It is
+	// best-effort and live: an engine not listening at the time misses it.
+	Broadcast(ctx context.Context, sig Signal) error
+	// Listen subscribes to the group's signals.
+	Listen(ctx context.Context) (Subscription, error)
+}
@@ -0,0 +1,116 @@
+package thresher
+
+import (
+	"context"
+	"strings"
+
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+	"github.com/dr-dobermann/gobpm/pkg/repository"
+)
+
+// InstanceByBusinessKey returns the handle of the tracked instance of the
+// process key processKey that carries businessKey (WithBusinessKey, or the
+// correlation key of the message that started it), or false if there is none.
+// When several instances carry the key — possible without
+// WithUniqueBusinessKey — a live instance is preferred over a finished one,
+// and the smallest instance id breaks a remaining tie. Only the instances this
+// engine tracks are searched; a recovered or hydrated instance is found again
+// because its key rides the checkpoint.
+func (t *Thresher) InstanceByBusinessKey(
+	processKey, businessKey string,
+) (*InstanceHandle, bool) {
+	processKey = strings.TrimSpace(processKey)
+	businessKey = strings.TrimSpace(businessKey)
+
+	if processKey == "" || businessKey == "" {
+		return nil, false
+	}
+
+	return t.instanceByBusinessKeyLocked(processKey, businessKey)
+}
+
+// claimBusinessKey enforces WithUniqueBusinessKey for a new instance of
+// processID keyed businessKey. On success it returns the release the caller
+// runs once the launch has either failed or tracked its instance; a taken key
+// is a DuplicateObject error. An empty key, or a process without the
+// guarantee, claims nothing.
+//
+// The engine's own registry answers first and is the guarantee. A Repository
+// that implements repository.BusinessKeyFinder is asked next, best effort, so
+// a live instance another engine of the group owns — or one this engine has
+// not recovered yet — is usually seen too.
+func (t *Thresher) claimBusinessKey(
+	ctx context.Context, processID, businessKey string,
+) (func(), error) {
+	release := func() {}
+
+	if businessKey == "" {
+		return release, nil
+	}
+
+	reserved, ok := t.reserveBusinessKeyLocked(processID, businessKey)
+	if !ok {
+		return nil, businessKeyTaken(processID, businessKey)
+	}
+
+	if !reserved {
+		return release, nil
+	}
+
+	release = func() { t.releaseBusinessKeyLocked(processID, businessKey) }
+
+	if err := t.businessKeyInStore(ctx, processID, businessKey); err != nil {
+		release()
+
+		return nil, err
+	}
+
+	return release, nil
+}
+
+// businessKeyInStore fails when the configured Repository lists a live record
+// of processID carrying businessKey. A store without the BusinessKeyFinder
+// capability, or the volatile default, is not consulted. The lookup reserves
+// nothing in the store, so another engine's concurrent start of the same key
+// is not seen: across engines the check is best effort (WithUniqueBusinessKey).
+func (t *Thresher) businessKeyInStore(
+	ctx context.Context, processID, businessKey string,
+) error {
+	if !t.cfg.repoSet {
+		return nil
+	}
+
+	finder, ok := t.cfg.Repository().(repository.BusinessKeyFinder)
+	if !ok {
+		return nil
+	}
+
+	ids, err := finder.FindByBusinessKey(ctx, t.group, processID, businessKey)
+	if err != nil {
+		return errs.New(
+			errs.M("couldn't check the business key of process %q", processID),
+			errs.C(errorClass, errs.OperationFailed),
+			errs.D(observability.AttrProcessID, processID),
+			errs.E(err))
+	}
+
+	for _, id := range ids {
+		if !t.terminalTrackedLocked(id) {
+			return businessKeyTaken(processID, businessKey)
+		}
+	}
+
+	return nil
+}
+
+// businessKeyTaken is the refusal of a start whose business key a live
+// instance already holds. The key itself is the host's identifier, not a
+// variable value, so it is named in the error.
+func businessKeyTaken(processID, businessKey string) error {
+	return errs.New(
+		errs.M("process %q already has a live instance with business key %q",
+			processID, businessKey),
+		errs.C(errorClass, errs.DuplicateObject),
+		errs.D(observability.AttrProcessID, processID))
+}
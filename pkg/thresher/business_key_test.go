@@ -0,0 +1,292 @@
+package thresher_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gobpm/internal/instance/checkpoint"
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/messaging"
+	"github.com/dr-dobermann/gobpm/pkg/messaging/membroker"
+	"github.com/dr-dobermann/gobpm/pkg/model/process"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+	"github.com/dr-dobermann/gobpm/pkg/repository"
+	"github.com/dr-dobermann/gobpm/pkg/repository/memrepo"
+	"github.com/dr-dobermann/gobpm/pkg/thresher"
+)
+
+// uniqueKeyEngine runs an engine over repo (volatile when nil) in the recovery
+// group and registers p WithUniqueBusinessKey.
+func uniqueKeyEngine(
+	t *testing.T, name string, repo repository.Repository, p *process.Process,
+) *thresher.Thresher {
+	t.Helper()
+
+	opts := []thresher.Option{
+		thresher.WithoutBanner(),
+		thresher.WithoutStartupConfig(),
+	}
+	if repo != nil {
+		opts = append(opts,
+			thresher.WithRepository(repo),
+			thresher.WithEngineGroup(recoveryGroup))
+	}
+
+	th, err := thresher.New(name, opts...)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	require.NoError(t, th.Run(ctx))
+
+	_, err = th.RegisterProcess(p, thresher.WithUniqueBusinessKey())
+	require.NoError(t, err)
+
+	return th
+}
+
+// TestBusinessKeyAtStart verifies a key passed at start is on the handle and
+// finds the instance again, and that an empty key is refused.
+func TestBusinessKeyAtStart(t *testing.T) {
+	proc := startVarsProcess(t, "bk-start")
+	th, cancel := runEngine(t, proc)
+	defer cancel()
+
+	h, err := th.StartLatest(proc.ID(), thresher.WithBusinessKey(" ord-1 "))
+	require.NoError(t, err)
+	require.Equal(t, "ord-1", h.BusinessKey(), "the key is trimmed")
+
+	got, ok := th.InstanceByBusinessKey(proc.ID(), "ord-1")
+	require.True(t, ok)
+	require.Equal(t, h.ID(), got.ID())
+
+	_, ok = th.InstanceByBusinessKey(proc.ID(), "ord-2")
+	require.False(t, ok)
+
+	_, ok = th.InstanceByBusinessKey("no-such-process", "ord-1")
+	require.False(t, ok)
+
+	_, ok = th.InstanceByBusinessKey(proc.ID(), "")
+	require.False(t, ok)
+
+	keyless, err := th.StartLatest(proc.ID())
+	require.NoError(t, err)
+	require.Empty(t, keyless.BusinessKey())
+
+	_, err = th.StartLatest(proc.ID(), thresher.WithBusinessKey("  "))
+	require.Error(t, err)
+	requireClass(t, err, errs.InvalidParameter)
+}
+
+// TestBusinessKeyLookupPrefersLive verifies that without the uniqueness
+// guarantee two instances may share a key, and the lookup answers with the
+// live one once the other has finished.
+func TestBusinessKeyLookupPrefersLive(t *testing.T) {
+	proc := startVarsProcess(t, "bk-live")
+	th, cancel := runEngine(t, proc)
+	defer cancel()
+
+	first, err := th.StartLatest(proc.ID(), thresher.WithBusinessKey("case"))
+	require.NoError(t, err)
+
+	ctx, wcancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer wcancel()
+
+	st, err := first.WaitCompletion(ctx)
+	require.NoError(t, err)
+	require.Equal(t, thresher.StateCompleted, st)
+
+	second, err := th.StartLatest(proc.ID(), thresher.WithBusinessKey("case"))
+	require.NoError(t, err)
+
+	got, ok := th.InstanceByBusinessKey(proc.ID(), "case")
+	require.True(t, ok)
+	require.Equal(t, second.ID(), got.ID(),
+		"a live instance wins over a finished one")
+}
+
+// TestBusinessKeyFromCorrelationKey verifies a message-born instance takes
+// the correlation key of the message that started it as its business key.
+func TestBusinessKeyFromCorrelationKey(t *testing.T) {
+	broker := membroker.New()
+
+	th, err := thresher.New("bk-msg", thresher.WithMessageBroker(broker))
+	require.NoError(t, err)
+
+	proc := orderConversationProcess(t, make(chan string, 1))
+	_, err = th.RegisterProcess(proc)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, th.Run(ctx))
+
+	require.NoError(t, broker.Publish(ctx, messaging.Envelope{
+		Name: "order placed", Payload: "ORD-7", CorrelationKey: "ORD-7"}))
+
+	var h *thresher.InstanceHandle
+
+	require.Eventually(t, func() bool {
+		var ok bool
+		h, ok = th.InstanceByBusinessKey(proc.ID(), "ORD-7")
+
+		return ok
+	}, 2*time.Second, 5*time.Millisecond)
+
+	require.Equal(t, "ORD-7", h.BusinessKey())
+}
+
+// TestUniqueBusinessKey verifies a process registered WithUniqueBusinessKey
+// refuses a second live instance with the same key, accepts other keys, and
+// frees the key once its instance has finished.
+func TestUniqueBusinessKey(t *testing.T) {
+	proc := startVarsProcess(t, "bk-unique")
+	th := uniqueKeyEngine(t, "bk-unique-engine", nil, proc)
+
+	h, err := th.StartLatest(proc.ID(), thresher.WithBusinessKey("ord-1"))
+	require.NoError(t, err)
+
+	_, err = th.StartLatest(proc.ID(), thresher.WithBusinessKey("ord-1"))
+	require.Error(t, err)
+	requireClass(t, err, errs.DuplicateObject)
+
+	other, err := th.StartLatest(proc.ID(), thresher.WithBusinessKey("ord-2"))
+	require.NoError(t, err)
+	require.NotEqual(t, h.ID(), other.ID())
+
+	_, err = th.StartLatest(proc.ID())
+	require.NoError(t, err, "a keyless start is never refused")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err = h.WaitCompletion(ctx)
+	require.NoError(t, err)
+
+	again, err := th.StartLatest(proc.ID(), thresher.WithBusinessKey("ord-1"))
+	require.NoError(t, err, "a finished instance releases its key")
+	require.NotEqual(t, h.ID(), again.ID())
+}
+
+// TestUniqueBusinessKeyAcrossEngines verifies the guarantee reaches the live
+// instances another engine of the group owns, through the store's
+// business-key lookup.
+func TestUniqueBusinessKeyAcrossEngines(t *testing.T) {
+	repo := memrepo.New()
+	proc := startVarsProcess(t, "bk-group")
+
+	th1 := uniqueKeyEngine(t, "bk-engine-1", repo, proc)
+	th2 := uniqueKeyEngine(t, "bk-engine-2", repo, proc)
+
+	h, err := th1.StartLatest(proc.ID(), thresher.WithBusinessKey("ord-1"))
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		rec, ok, _ := repo.Load(context.Background(), h.ID())
+
+		return ok && rec.BusinessKey == "ord-1" && rec.ProcessID == proc.ID()
+	}, 2*time.Second, 5*time.Millisecond,
+		"the record carries the process and the key")
+
+	_, err = th2.StartLatest(proc.ID(), thresher.WithBusinessKey("ord-1"))
+	require.Error(t, err)
+	requireClass(t, err, errs.DuplicateObject)
+}
+
+// TestBusinessKeySurvivesDehydration verifies the key rides the checkpoint:
+// a dehydrated instance is still found by it, and the rebuilt instance keeps
+// it through to completion.
+func TestBusinessKeySurvivesDehydration(t *testing.T) {
+	repo := memrepo.New()
+	deadline := dehydrationEpoch.Add(2 * time.Hour)
+
+	var hit atomic.Bool
+
+	p := longTimerProc(t, "bk-dehy", deadline, &hit)
+
+	th, fw, clk, cancel := bootDehydrationEngine(t, "engine-BK", repo, p)
+	defer cancel()
+
+	h, err := th.StartLatest(p.ID(), thresher.WithBusinessKey("case-9"))
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return fw.saw(observability.KindInstanceState,
+			observability.PhaseDehydrated)
+	}, 3*time.Second, 10*time.Millisecond)
+
+	rec, ok, err := repo.Load(context.Background(), h.ID())
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "case-9", rec.BusinessKey)
+
+	doc, err := checkpoint.Unmarshal(rec.Payload)
+	require.NoError(t, err)
+	require.Equal(t, "case-9", doc.BusinessKey)
+
+	got, ok := th.InstanceByBusinessKey(p.ID(), "case-9")
+	require.True(t, ok, "a dehydrated instance is found by its key")
+	require.Equal(t, h.ID(), got.ID())
+
+	clk.Advance(3 * time.Hour)
+
+	require.Eventually(t, func() bool {
+		return fw.saw(observability.KindInstanceState,
+			observability.PhaseHydrated)
+	}, 3*time.Second, 10*time.Millisecond)
+
+	ctx, wcancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer wcancel()
+
+	st, err := h.WaitCompletion(ctx)
+	require.NoError(t, err)
+	require.Equal(t, thresher.StateCompleted, st)
+	require.Equal(t, "case-9", h.BusinessKey(),
+		"the rebuilt instance keeps its key")
+
+	got, ok = th.InstanceByBusinessKey(p.ID(), "case-9")
+	require.True(t, ok)
+	require.Equal(t, h.ID(), got.ID())
+}
+
+// TestBusinessKeySurvivesRestartRecovery verifies an engine recovering an
+// abandoned instance restores its key and finds the instance by it.
+func TestBusinessKeySurvivesRestartRecovery(t *testing.T) {
+	repo := memrepo.New()
+	deadline := time.Now().Add(700 * time.Millisecond)
+
+	var hit1, hit2 atomic.Bool
+
+	p1 := timerProc(t, "bk-restart", deadline, &hit1)
+
+	th1, _, cancel1 := bootEngine(t, "engine-1", repo,
+		80*time.Millisecond, p1)
+	defer cancel1() // teardown only; the "crash" is abandonment
+
+	h, err := th1.StartLatest(p1.ID(), thresher.WithBusinessKey("case-3"))
+	require.NoError(t, err)
+
+	waitParkedRecord(t, repo, h.ID(), true)
+
+	time.Sleep(120 * time.Millisecond) // > engine-1's lease TTL
+
+	p2 := timerProc(t, "bk-restart", deadline, &hit2)
+
+	th2, fw2, cancel2 := bootEngine(t, "engine-2", repo, time.Minute, p2)
+	defer cancel2()
+
+	require.Eventually(t, func() bool {
+		return fw2.saw(observability.KindInstanceState,
+			observability.PhaseRecovered)
+	}, 2*time.Second, 5*time.Millisecond)
+
+	got, ok := th2.InstanceByBusinessKey(p2.ID(), "case-3")
+	require.True(t, ok, "the recovered instance is found by its key")
+	require.Equal(t, h.ID(), got.ID())
+	require.Equal(t, "case-3", got.BusinessKey())
+}
@@ -0,0 +1,268 @@
+package thresher_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+	"github.com/dr-dobermann/gobpm/pkg/repository"
+	"github.com/dr-dobermann/gobpm/pkg/repository/memrepo"
+	"github.com/dr-dobermann/gobpm/pkg/repository/payloadcodec"
+	"github.com/dr-dobermann/gobpm/pkg/thresher"
+)
+
+// codecChain builds the gzip → AES-GCM codecs under the key id active of a
+// two-key ring.
+func codecChain(t *testing.T, active string) []payloadcodec.Codec {
+	t.Helper()
+
+	gz, err := payloadcodec.NewGzip(gzip.BestSpeed)
+	require.NoError(t, err)
+
+	aes, err := payloadcodec.NewAESGCM(active, map[string][]byte{
+		"k1": bytes.Repeat([]byte{1}, 32),
+		"k2": bytes.Repeat([]byte{2}, 32),
+	})
+	require.NoError(t, err)
+
+	return []payloadcodec.Codec{gz, aes}
+}
+
+// bootCodecEngine boots a recovery-group engine over repo whose checkpoints
+// pass through codecs (none: plain payloads). The returned cancel stops it.
+func bootCodecEngine(
+	t *testing.T, name string, repo repository.Repository, key string,
+	dist *annCollector, codecs ...payloadcodec.Codec,
+) (*thresher.Thresher, *factWatch, context.CancelFunc) {
+	t.Helper()
+
+	var opts []thresher.Option
+	if len(codecs) > 0 {
+		opts = append(opts, thresher.WithCheckpointCodec(codecs...))
+	}
+
+	return bootCodecEngineWith(t, name, repo, key, dist, opts...)
+}
+
+// bootCodecEngineWith is bootCodecEngine with the codec options given
+// explicitly.
+func bootCodecEngineWith(
+	t *testing.T, name string, repo repository.Repository, key string,
+	dist *annCollector, codecOpts ...thresher.Option,
+) (*thresher.Thresher, *factWatch, context.CancelFunc) {
+	t.Helper()
+
+	opts := append([]thresher.Option{
+		thresher.WithoutBanner(), thresher.WithoutStartupConfig(),
+		thresher.WithRepository(repo),
+		thresher.WithEngineGroup(recoveryGroup),
+		thresher.WithLeaseTTL(80 * time.Millisecond),
+		thresher.WithTaskDistributor(dist),
+	}, codecOpts...)
+
+	th, err := thresher.New(name, opts...)
+	require.NoError(t, err)
+
+	fw := &factWatch{}
+	sub := th.Observe(fw)
+	t.Cleanup(sub.Cancel)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	_, err = th.RegisterProcess(utProc(t, key))
+	require.NoError(t, err)
+	require.NoError(t, th.Run(ctx))
+
+	return th, fw, cancel
+}
+
+// parkOnTask starts utProc(key) on th and waits until its task is announced
+// and its record holds the park.
+func parkOnTask(
+	t *testing.T, th *thresher.Thresher, repo repository.Repository,
+	key string, dist *annCollector, framed bool,
+) string {
+	t.Helper()
+
+	h, err := th.StartLatest(key)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool { return dist.count() == 1 },
+		2*time.Second, 5*time.Millisecond)
+
+	if !framed {
+		waitParkedRecord(t, repo, h.ID(), false)
+
+		return h.ID()
+	}
+
+	require.Eventually(t, func() bool {
+		rec, ok, _ := repo.Load(context.Background(), h.ID())
+
+		return ok && rec.Status == repository.StatusActive &&
+			payloadcodec.Framed(rec.Payload)
+	}, 2*time.Second, 5*time.Millisecond)
+
+	return h.ID()
+}
+
+// completeRecovered waits for engine th to re-announce the recovered task,
+// completes it and waits for the instance record to complete.
+func completeRecovered(
+	t *testing.T, th *thresher.Thresher, repo repository.Repository,
+	id string, dist *annCollector,
+) repository.InstanceRecord {
+	t.Helper()
+
+	require.Eventually(t, func() bool { return dist.count() == 1 },
+		2*time.Second, 5*time.Millisecond,
+		"the recovered task must re-announce")
+
+	ctx := context.Background()
+	taskID := dist.taskIDs()[0]
+	operator := utActor{id: "operator"}
+
+	require.NoError(t, th.Claim(ctx, taskID, operator))
+	require.NoError(t, th.Complete(ctx, taskID, operator,
+		resultOutput("approved")))
+
+	var rec repository.InstanceRecord
+
+	require.Eventually(t, func() bool {
+		var ok bool
+		rec, ok, _ = repo.Load(ctx, id)
+
+		return ok && rec.Status == repository.StatusCompleted
+	}, 2*time.Second, 5*time.Millisecond)
+
+	return rec
+}
+
+// TestCheckpointCodecRecovers verifies an engine with a codec chain stores
+// framed, encrypted checkpoints and another engine with the same codecs —
+// sealing under a rotated key — recovers and completes the instance.
+func TestCheckpointCodecRecovers(t *testing.T) {
+	const key = "codec-recover"
+
+	repo := memrepo.New()
+
+	dist1 := &annCollector{}
+	th1, _, _ := bootCodecEngine(t, "engine-1", repo, key, dist1,
+		codecChain(t, "k1")...)
+	id := parkOnTask(t, th1, repo, key, dist1, true)
+
+	rec, ok, err := repo.Load(context.Background(), id)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.NotContains(t, string(rec.Payload), key,
+		"an encrypted payload must not leak the document")
+
+	time.Sleep(120 * time.Millisecond) // the lease lapses
+
+	dist2 := &annCollector{}
+	th2, _, _ := bootCodecEngine(t, "engine-2", repo, key, dist2,
+		codecChain(t, "k2")...)
+
+	rec = completeRecovered(t, th2, repo, id, dist2)
+	require.True(t, payloadcodec.Framed(rec.Payload))
+}
+
+// TestCheckpointCodecReadsPlainRecords verifies the upgrade path: a record
+// written plain, before any codec was configured, recovers on an engine with
+// a codec chain, which seals its later checkpoints.
+func TestCheckpointCodecReadsPlainRecords(t *testing.T) {
+	const key = "codec-legacy"
+
+	repo := memrepo.New()
+
+	dist1 := &annCollector{}
+	th1, _, _ := bootCodecEngine(t, "engine-1", repo, key, dist1)
+	id := parkOnTask(t, th1, repo, key, dist1, false)
+
+	rec, _, err := repo.Load(context.Background(), id)
+	require.NoError(t, err)
+	require.False(t, payloadcodec.Framed(rec.Payload))
+
+	time.Sleep(120 * time.Millisecond) // the lease lapses
+
+	dist2 := &annCollector{}
+	th2, _, _ := bootCodecEngine(t, "engine-2", repo, key, dist2,
+		codecChain(t, "k1")...)
+
+	rec = completeRecovered(t, th2, repo, id, dist2)
+	require.True(t, payloadcodec.Framed(rec.Payload),
+		"the recovering engine seals the checkpoints it writes")
+}
+
+// TestCheckpointCodecMissingKey verifies a sealed record fails its recovery
+// loud on an engine without the codecs it names, and WithCheckpointCodec
+// refuses an empty or malformed chain.
+func TestCheckpointCodecMissingKey(t *testing.T) {
+	const key = "codec-nokey"
+
+	repo := memrepo.New()
+
+	dist1 := &annCollector{}
+	th1, _, _ := bootCodecEngine(t, "engine-1", repo, key, dist1,
+		codecChain(t, "k1")...)
+	parkOnTask(t, th1, repo, key, dist1, true)
+
+	time.Sleep(120 * time.Millisecond) // the lease lapses
+
+	dist2 := &annCollector{}
+	_, fw, _ := bootCodecEngine(t, "engine-2", repo, key, dist2)
+
+	require.Eventually(t, func() bool {
+		return fw.saw(observability.KindInstanceState,
+			observability.PhaseFailed)
+	}, 2*time.Second, 5*time.Millisecond,
+		"a payload sealed with unknown codecs must fail its recovery")
+	require.Zero(t, dist2.count())
+
+	_, err := thresher.New("codec-bad", thresher.WithCheckpointCodec())
+	requireClass(t, err, errs.InvalidParameter)
+
+	gz, err := payloadcodec.NewGzip(gzip.DefaultCompression)
+	require.NoError(t, err)
+
+	_, err = thresher.New("codec-bad", thresher.WithCheckpointCodec(gz, gz))
+	requireClass(t, err, errs.InvalidParameter)
+}
+
+// TestStrictCheckpointCodec verifies a strict chain recovers what it sealed
+// but refuses a plain record, and needs an authenticating codec.
+func TestStrictCheckpointCodec(t *testing.T) {
+	const key = "codec-strict"
+
+	repo := memrepo.New()
+
+	dist1 := &annCollector{}
+	th1, _, _ := bootCodecEngine(t, "engine-1", repo, key, dist1)
+	parkOnTask(t, th1, repo, key, dist1, false)
+
+	time.Sleep(120 * time.Millisecond) // the lease lapses
+
+	dist2 := &annCollector{}
+	_, fw, _ := bootCodecEngineWith(t, "engine-2", repo, key, dist2,
+		thresher.WithStrictCheckpointCodec(codecChain(t, "k1")...))
+
+	require.Eventually(t, func() bool {
+		return fw.saw(observability.KindInstanceState,
+			observability.PhaseFailed)
+	}, 2*time.Second, 5*time.Millisecond,
+		"a strict chain must refuse a plain payload")
+	require.Zero(t, dist2.count())
+
+	gz, err := payloadcodec.NewGzip(gzip.DefaultCompression)
+	require.NoError(t, err)
+
+	_, err = thresher.New("codec-bad", thresher.WithStrictCheckpointCodec(gz))
+	requireClass(t, err, errs.InvalidParameter)
+}
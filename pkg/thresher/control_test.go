@@ -101,20 +101,6 @@ func TestCancelCtxBounded(t *testing.T) {
 	require.NotEqual(t, thresher.StateCompleted, state)
 }
 
-// TestSuspendResumeReserved verifies the reserved control ops return the stable
-// ErrNotImplemented sentinel (FR-3).
-func TestSuspendResumeReserved(t *testing.T) {
-	proc := blockingProcess(t, "ctl-reserved")
-	th, cancel := runEngine(t, proc)
-	defer cancel()
-
-	h, err := th.StartLatest(proc.ID())
-	require.NoError(t, err)
-
-	require.ErrorIs(t, h.Suspend(context.Background()), thresher.ErrNotImplemented)
-	require.ErrorIs(t, h.Resume(context.Background()), thresher.ErrNotImplemented)
-}
-
 // TestThresherShutdown verifies graceful shutdown cancels running instances,
 // flips to Stopped, drains the hub, then rejects further lifecycle ops, and is
 // idempotent (FR-4, FR-5, FR-6).
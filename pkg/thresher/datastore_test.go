@@ -2,13 +2,16 @@ package thresher_test
 
 import (
 	"context"
+	"errors"
 	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/dr-dobermann/gobpm/pkg/datastore/datastoretest"
 	"github.com/dr-dobermann/gobpm/pkg/datastore/memstore"
+	"github.com/dr-dobermann/gobpm/pkg/errs"
 	"github.com/dr-dobermann/gobpm/pkg/model/activities"
 	"github.com/dr-dobermann/gobpm/pkg/model/data"
 	"github.com/dr-dobermann/gobpm/pkg/model/data/values"
@@ -140,3 +143,123 @@ func TestDataStoreSharedAcrossInstances(t *testing.T) {
 	require.EqualValues(t, 42, got.Load(),
 		"the reader read the writer's value from the shared engine DataStore")
 }
+
+// TestDataStoreConcurrentIncrements: many instances run a read-increment-write
+// task against one counter in a Versioned store at once. A lost race fails the
+// write with errs.ConcurrentUpdate, the incident retry re-runs the task from a
+// fresh read, and no increment is lost.
+func TestDataStoreConcurrentIncrements(t *testing.T) {
+	require.NoError(t, data.CreateDefaultStates())
+
+	const (
+		instances = 20
+		in        = "counter"
+		out       = "counter-next"
+	)
+
+	var conflicts atomic.Int64
+
+	incOp, err := gooper.New("inc-op",
+		func(ctx context.Context, r service.DataReader,
+			_ *data.ItemDefinition) (*data.ItemDefinition, error) {
+			d, derr := r.GetDataByID(in)
+			if derr != nil {
+				return nil, derr
+			}
+
+			n, _ := d.Value().Get(ctx).(int)
+
+			// widen the read-write window so the instances collide.
+			time.Sleep(time.Millisecond)
+
+			return data.MustItemDefinition(values.NewVariable(n+1),
+				foundation.WithID(out)), nil
+		})
+	require.NoError(t, err)
+
+	inc, err := activities.NewServiceTask("inc", incOp,
+		activities.WithParameters(data.Input, data.MustParameter("in",
+			data.MustItemAwareElement(
+				data.MustItemDefinition(values.NewVariable(0),
+					foundation.WithID(in)),
+				data.UnavailableDataState))),
+		activities.WithParameters(data.Output, data.MustParameter("out",
+			data.MustItemAwareElement(
+				data.MustItemDefinition(values.NewVariable(0),
+					foundation.WithID(out)),
+				data.UnavailableDataState))))
+	require.NoError(t, err)
+
+	ref, err := datastores.New("counter", "shared",
+		data.MustItemDefinition(values.NewVariable(0), foundation.WithID(in)),
+		data.ReadyDataState)
+	require.NoError(t, err)
+	require.NoError(t, ref.AssociateTarget(inc, nil))
+	require.NoError(t, ref.AssociateSource(inc, []string{out}, nil))
+
+	p, err := process.New("inc-proc")
+	require.NoError(t, err)
+	start, err := events.NewStartEvent("start")
+	require.NoError(t, err)
+	end, err := events.NewEndEvent("end")
+	require.NoError(t, err)
+
+	for _, e := range []flow.Element{start, inc, end} {
+		require.NoError(t, p.Add(e))
+	}
+	link(t, start, inc)
+	link(t, inc, end)
+
+	store := memstore.New()
+	require.NoError(t, store.Put(context.Background(), "counter",
+		datastoretest.Datum(t, "counter", values.NewVariable(0))))
+
+	th, err := thresher.New("datastore-cas",
+		thresher.WithoutBanner(), thresher.WithoutStartupConfig(),
+		thresher.WithDataStore("shared", store),
+		thresher.WithIncidentRetryPolicy(retryOnConflict{&conflicts}))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, th.Run(ctx))
+
+	_, err = th.RegisterProcess(p)
+	require.NoError(t, err)
+
+	hh := make([]*thresher.InstanceHandle, instances)
+	for i := range hh {
+		hh[i], err = th.StartLatest(p.ID())
+		require.NoError(t, err)
+	}
+
+	for _, h := range hh {
+		wctx, wcancel := context.WithTimeout(ctx, 10*time.Second)
+		state, werr := h.WaitCompletion(wctx)
+		wcancel()
+
+		require.NoError(t, werr)
+		require.Equal(t, thresher.StateCompleted, state)
+	}
+
+	d, ok, err := store.Get(ctx, "counter")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, instances, d.Value().Get(ctx), "no increment is lost")
+	require.Positive(t, conflicts.Load(),
+		"the instances must have collided for the test to prove anything")
+}
+
+// retryOnConflict retries a Data Store conflict at once, and nothing else.
+type retryOnConflict struct{ conflicts *atomic.Int64 }
+
+func (r retryOnConflict) Retry(_ int, cause error) (time.Duration, bool) {
+	var ae *errs.ApplicationError
+	if !errors.As(cause, &ae) || !ae.HasClass(errs.ConcurrentUpdate) {
+		return 0, false
+	}
+
+	r.conflicts.Add(1)
+
+	return time.Millisecond, true
+}
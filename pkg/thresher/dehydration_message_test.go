@@ -93,19 +93,22 @@ func msgWaitProcess(
 	return proc
 }
 
-// msgEngine boots a checkpoint-armed engine over repo and broker.
+// msgEngine boots a checkpoint-armed engine over repo and broker; opts are
+// applied after the defaults.
 func msgEngine(
 	t *testing.T, name string, repo repository.Repository,
 	broker messaging.MessageBroker, p *process.Process,
+	opts ...thresher.Option,
 ) (*thresher.Thresher, *factWatch, context.CancelFunc) {
 	t.Helper()
 
-	th, err := thresher.New(name,
+	th, err := thresher.New(name, append([]thresher.Option{
 		thresher.WithoutBanner(),
 		thresher.WithoutStartupConfig(),
 		thresher.WithRepository(repo),
 		thresher.WithMessageBroker(broker),
-		thresher.WithLeaseTTL(time.Minute))
+		thresher.WithLeaseTTL(time.Minute),
+	}, opts...)...)
 	require.NoError(t, err)
 
 	fw := &factWatch{}
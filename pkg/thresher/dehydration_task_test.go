@@ -165,10 +165,10 @@ func TestDehydrationUnknownTaskIsLoud(t *testing.T) {
 }
 
 // bootTaskEngine boots a checkpoint-armed engine on a controlled clock with the
-// given task distributor.
+// given task distributor, registering p with opts.
 func bootTaskEngine(
 	t *testing.T, name string, repo repository.Repository,
-	dist *annCollector, p *process.Process,
+	dist *annCollector, p *process.Process, opts ...thresher.RegisterOption,
 ) (*thresher.Thresher, *factWatch, context.CancelFunc) {
 	t.Helper()
 
@@ -187,7 +187,7 @@ func bootTaskEngine(
 
 	ctx, cancel := context.WithCancel(context.Background())
 
-	_, err = th.RegisterProcess(p)
+	_, err = th.RegisterProcess(p, opts...)
 	require.NoError(t, err)
 	require.NoError(t, th.Run(ctx))
 
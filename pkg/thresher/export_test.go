@@ -1,5 +1,7 @@
 package thresher
 
+import "strings"
+
 // SignalCatchers reports how many signal-catch processors the engine's hub
 // currently holds for name — the deterministic readiness gate for black-box
 // signal tests (FIX-021): a catcher's token parks before its hub registration
@@ -22,3 +24,39 @@ func SignalCatchers(th *Thresher, name string) int {
 // harness that can produce a parked instance lives in thresher_test, and the
 // window it aims at is here; this is the bridge. Pass nil to clear it.
 func SetCancelParkSeam(f func()) { cancelParkSeam = f }
+
+// Holds reports how many engine-side holds an instance still owns: durable
+// timer deadlines, hub subscriptions and correlation-key reservations. A
+// handed-off instance must own none — the next owner re-arms them on
+// rehydration.
+func Holds(th *Thresher, instanceID string) int {
+	n := 0
+
+	if ts := th.timerSvc; ts != nil {
+		ts.mu.Lock()
+		for k := range ts.holds {
+			if strings.HasPrefix(k, instanceID+"|") {
+				n++
+			}
+		}
+		ts.mu.Unlock()
+	}
+
+	th.subMu.Lock()
+	for k := range th.subs {
+		if k.instanceID == instanceID {
+			n++
+		}
+	}
+	th.subMu.Unlock()
+
+	th.m.Lock()
+	for _, id := range th.seenKeys {
+		if id == instanceID {
+			n++
+		}
+	}
+	th.m.Unlock()
+
+	return n
+}
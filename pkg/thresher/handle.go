@@ -14,8 +14,9 @@ import (
 )
 
 // ErrNotImplemented marks a control operation that is part of the stable handle
-// contract but not yet implemented — Suspend/Resume await the Paused subsystem
-// (ADR-013 §2.3, SRD-019).
+// contract but not yet implemented (ADR-013 §2.3, SRD-019). No operation
+// returns it today — Suspend/Resume, its first holders, have landed — and it
+// stays so a host matching on it keeps compiling.
 var ErrNotImplemented = errs.New(
 	errs.M("operation reserved, not yet implemented"),
 	errs.C(errorClass, errs.OperationFailed))
@@ -53,8 +54,11 @@ type InstanceHandle struct {
 	observers  map[uint64]*handleObserver
 	parentID   string
 	callNodeID string
-	nextObs    uint64
-	obsMu      sync.Mutex
+	// businessKey caches the instance's business key, immutable like the
+	// call linkage and captured at adopt for the same reason.
+	businessKey string
+	nextObs     uint64
+	obsMu       sync.Mutex
 }
 
 // cancelParkSeam runs inside Cancel between the state check and the direct
@@ -127,6 +131,7 @@ func (h *InstanceHandle) adopt(inst *instance.Instance) {
 	h.inst.Store(inst)
 	h.parentID = inst.ParentID()
 	h.callNodeID = inst.CallNodeID()
+	h.businessKey = inst.BusinessKey()
 }
 
 // ID returns the instance id.
@@ -142,7 +147,8 @@ func (h *InstanceHandle) State() InstanceState {
 }
 
 // Data returns a read-only reader over the instance's process properties and
-// runtime variables. Read-only by interface (service.DataReader has no mutator).
+// runtime variables. Read-only by interface (service.DataReader has no mutator);
+// an operator changes a variable through SetVariables and DeleteVariables.
 func (h *InstanceHandle) Data() service.DataReader {
 	return h.current().DataReader()
 }
@@ -327,7 +333,8 @@ func (h *InstanceHandle) WaitCompletion(
 // second call, or Cancel of an already-terminal instance, returns the terminal
 // state at once.
 func (h *InstanceHandle) Cancel(ctx context.Context) (InstanceState, error) {
-	// A DEHYDRATED instance has no loop to observe a context cancellation, so
+	// A RELEASED instance — dehydrated, or suspended with its goroutines let
+	// go — has no loop to observe a context cancellation, so
 	// canceling here canceled a context nobody was reading: the request was
 	// lost and the next wake resumed the instance as if it had never been made
 	// (FIX-038 §1.10). It rides a rebuild instead, like an incident operation.
@@ -343,7 +350,7 @@ func (h *InstanceHandle) Cancel(ctx context.Context) (InstanceState, error) {
 	for range cancelRouteAttempts {
 		inst := h.current()
 
-		if inst.State() == instance.Dehydrated && h.th != nil {
+		if inst.Released() && h.th != nil {
 			if err := h.th.cancelParked(ctx, h); err != nil {
 				return h.State(), err
 			}
@@ -358,7 +365,7 @@ func (h *InstanceHandle) Cancel(ctx context.Context) (InstanceState, error) {
 		inst.Cancel()
 
 		// It parked while that cancel was in flight, so nothing observed it.
-		if h.current().State() != instance.Dehydrated || h.th == nil {
+		if !h.current().Released() || h.th == nil {
 			break
 		}
 	}
@@ -366,17 +373,59 @@ func (h *InstanceHandle) Cancel(ctx context.Context) (InstanceState, error) {
 	return h.WaitCompletion(ctx)
 }
 
-// Suspend is reserved (ADR-013 §2.3): pausing token movement needs the deferred
-// Paused subsystem. The method exists so the control contract is stable; it
-// returns ErrNotImplemented until that subsystem lands.
-func (h *InstanceHandle) Suspend(_ context.Context) error {
-	return ErrNotImplemented
+// Suspend puts the instance on hold (ADR-033 §2.6): no token moves to its
+// next node — a step already executing runs to its transition — and the
+// triggers that arrive meanwhile are not applied but buffered for Resume to
+// replay. A held message or signal is written to the instance's checkpoint,
+// so it survives dehydration, Drain and restart; a held timer re-arms from
+// its recorded deadline. A held boundary or Event Sub-Process fire keeps the
+// instance resident until Resume. Human tasks stay announced while their
+// completion is refused. The suspension itself is persisted, so recovery
+// leaves the instance alone. Idempotent; a finished or terminating instance refuses with
+// InvalidState. Cancel still terminates a suspended instance.
+func (h *InstanceHandle) Suspend(ctx context.Context) error {
+	return h.submitSuspension(ctx, false)
 }
 
-// Resume is reserved (ADR-013 §2.3) — the counterpart of Suspend; returns
-// ErrNotImplemented until the Paused subsystem lands.
-func (h *InstanceHandle) Resume(_ context.Context) error {
-	return ErrNotImplemented
+// Resume lifts a suspension: the instance is Active again, its tokens move on,
+// and what arrived while it was held is delivered — a timer whose deadline
+// passed fires once. Resuming an instance that is not suspended is a no-op; a
+// finished or terminating one refuses with InvalidState.
+func (h *InstanceHandle) Resume(ctx context.Context) error {
+	return h.submitSuspension(ctx, true)
+}
+
+// submitSuspension delivers a Suspend/Resume like an incident operation: a
+// resident instance takes it on its loop, and one whose loop has exited — it
+// released its goroutines or parked on an incident — is rebuilt from its
+// checkpoint through the engine first.
+func (h *InstanceHandle) submitSuspension(
+	ctx context.Context, resume bool,
+) error {
+	inst := h.current()
+
+	switch inst.State() {
+	case instance.Completed, instance.Terminating, instance.Terminated:
+		return errs.New(
+			errs.M("can't %s instance %q: it is %s",
+				suspensionOp(resume), inst.ID(), inst.State()),
+			errs.C(errorClass, errs.InvalidState),
+			errs.D(observability.AttrInstanceID, inst.ID()))
+	}
+
+	delivered, err := inst.SubmitSuspension(ctx, resume)
+	if err != nil || delivered {
+		return err
+	}
+
+	if h.th == nil {
+		return errs.New(
+			errs.M("%s on a parked instance needs its engine",
+				suspensionOp(resume)),
+			errs.C(errorClass, errs.InvalidState))
+	}
+
+	return h.th.wakeForSuspension(ctx, h, resume)
 }
 
 // InstanceState is the standard-named, OPEN instance lifecycle vocabulary
@@ -392,6 +441,10 @@ const (
 	StateCompleted   InstanceState = "Completed"
 	StateTerminating InstanceState = "Terminating"
 	StateTerminated  InstanceState = "Terminated"
+	// StateSuspended: an operator holds the instance (InstanceHandle.Suspend)
+	// — its tokens do not move until Resume. It is IN-FLIGHT, not terminal,
+	// whether it still holds goroutines or has released them.
+	StateSuspended InstanceState = "Suspended"
 	// StateDehydrated: the instance is waiting with NO goroutines — it released
 	// them while every track was parked on a held, dehydratable wait, and its
 	// checkpoint is the wake source (SRD-071). It is IN-FLIGHT, not terminal:
@@ -477,3 +530,8 @@ func (h *InstanceHandle) ParentID() string { return h.parentID }
 // CallNodeID returns the caller's Call Activity node id for a child,
 // "" for a root instance.
 func (h *InstanceHandle) CallNodeID() string { return h.callNodeID }
+
+// BusinessKey returns the instance's business key — set at start
+// (WithBusinessKey) or derived from the correlation key of the message
+// that started it — or "" for a keyless instance.
+func (h *InstanceHandle) BusinessKey() string { return h.businessKey }
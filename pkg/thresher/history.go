@@ -0,0 +1,10 @@
+package thresher
+
+import "github.com/dr-dobermann/gobpm/pkg/history"
+
+// History returns the history store WithHistory armed, or nil when the engine
+// records none. Its events outlive the instances they describe: query it for
+// an instance already completed and forgotten.
+func (t *Thresher) History() history.Store {
+	return t.cfg.history
+}
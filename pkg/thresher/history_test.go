@@ -0,0 +1,146 @@
+package thresher_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gobpm/pkg/history"
+	"github.com/dr-dobermann/gobpm/pkg/history/memhistory"
+	"github.com/dr-dobermann/gobpm/pkg/thresher"
+)
+
+// historyRun runs utProc to completion on an engine recording into a fresh
+// memhistory with opts, and returns the store and the instance id once the
+// instance's end is recorded.
+func historyRun(
+	t *testing.T, key string, opts ...history.RecorderOption,
+) (history.Store, string) {
+	t.Helper()
+
+	dist := &annCollector{}
+
+	th, err := thresher.New("engine-"+key,
+		thresher.WithoutBanner(),
+		thresher.WithoutStartupConfig(),
+		thresher.WithTaskDistributor(dist),
+		thresher.WithHistory(memhistory.New(), opts...))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	_, err = th.RegisterProcess(utProc(t, key))
+	require.NoError(t, err)
+	require.NoError(t, th.Run(ctx))
+
+	h, err := th.StartLatest(key)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool { return dist.count() == 1 },
+		2*time.Second, 10*time.Millisecond)
+
+	task := dist.taskIDs()[0]
+	operator := utActor{id: "operator"}
+
+	require.NoError(t, th.Claim(ctx, task, operator))
+	require.NoError(t, th.Complete(ctx, task, operator,
+		resultOutput("approved")))
+
+	waitCompleted(t, h)
+
+	s := th.History()
+
+	require.Eventually(t, func() bool {
+		p, err := s.Query(ctx, history.Query{
+			InstanceID: h.ID(),
+			Types:      []history.Type{history.InstanceEnded},
+		})
+
+		return err == nil && len(p.Events) == 1
+	}, 2*time.Second, 10*time.Millisecond)
+
+	return s, h.ID()
+}
+
+// TestHistoryRecordsInstance verifies an engine armed WithHistory records an
+// instance's run: its start and end under its process version, each node's
+// activity in order, the task's claim and the variable its completion set.
+func TestHistoryRecordsInstance(t *testing.T) {
+	key := "hist-run"
+	s, id := historyRun(t, key)
+
+	p, err := s.Query(context.Background(), history.Query{InstanceID: id})
+	require.NoError(t, err)
+
+	var started []string
+
+	byType := map[history.Type][]history.Event{}
+
+	for _, e := range p.Events {
+		require.Equal(t, key, e.ProcessID)
+		require.Equal(t, 1, e.Version)
+
+		byType[e.Type] = append(byType[e.Type], e)
+
+		if e.Type == history.ActivityStarted {
+			started = append(started, e.NodeID)
+		}
+	}
+
+	require.Len(t, byType[history.InstanceStarted], 1)
+	require.Equal(t, []string{key + "-start", key + "-approve", key + "-end"},
+		started)
+
+	ended := byType[history.ActivityEnded]
+	require.Len(t, ended, 3)
+
+	for _, e := range ended {
+		require.Equal(t, "Completed", e.Outcome)
+	}
+
+	require.Equal(t, key+"-approve", ended[1].NodeID)
+	require.Positive(t, ended[1].Duration, "the task waited for its operator")
+
+	owner := byType[history.TaskOwnerChanged]
+	require.Len(t, owner, 1)
+	require.Equal(t, "Claimed", owner[0].Outcome)
+	require.Equal(t, "operator", owner[0].UserID)
+	require.Equal(t, key+"-approve", owner[0].NodeID)
+
+	vars := byType[history.VariableUpdated]
+	require.Len(t, vars, 1)
+	require.Equal(t, "result", vars[0].Variable)
+
+	end := byType[history.InstanceEnded]
+	require.Len(t, end, 1)
+	require.Equal(t, "Completed", end[0].Outcome)
+	require.Positive(t, end[0].Duration)
+}
+
+// TestHistoryMasksVariables verifies a masked variable's updates never reach
+// the store.
+func TestHistoryMasksVariables(t *testing.T) {
+	s, id := historyRun(t, "hist-mask", history.WithMaskedVariables("result"))
+
+	p, err := s.Query(context.Background(), history.Query{
+		InstanceID: id,
+		Types:      []history.Type{history.VariableUpdated},
+	})
+	require.NoError(t, err)
+	require.Empty(t, p.Events)
+}
+
+// TestWithHistoryRefusesNil verifies a nil store is refused at New, and an
+// engine without one records nothing.
+func TestWithHistoryRefusesNil(t *testing.T) {
+	_, err := thresher.New("hist-nil", thresher.WithoutBanner(),
+		thresher.WithHistory(nil))
+	require.Error(t, err)
+
+	th, err := thresher.New("hist-none", thresher.WithoutBanner())
+	require.NoError(t, err)
+	require.Nil(t, th.History())
+}
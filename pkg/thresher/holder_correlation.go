@@ -1,6 +1,8 @@
 package thresher
 
 import (
+	"errors"
+
 	gerrs "github.com/dr-dobermann/gobpm/pkg/errs"
 
 	"github.com/dr-dobermann/gobpm/internal/instance"
@@ -13,6 +15,20 @@ import (
 // would drop it and stay parked.
 const correlationDropClass = instance.CorrelationDropClass
 
+// duplicateDropClass marks a wake refused because its message was already
+// consumed by the instance — a broker redelivery. Benign in the same way: the
+// instance stays dehydrated, as a resident one drops the duplicate and stays
+// parked.
+const duplicateDropClass = instance.DuplicateDropClass
+
+// benignDrop reports whether err is a wake refusal of either benign class.
+func benignDrop(err error) bool {
+	var ae *gerrs.ApplicationError
+
+	return errors.As(err, &ae) &&
+		(ae.HasClass(correlationDropClass) || ae.HasClass(duplicateDropClass))
+}
+
 // errNoHold builds the classified "the hold was not taken" error a caller
 // treats as "keep this wait resident".
 func errNoHold(msg string) error {
@@ -0,0 +1,406 @@
+package thresher
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dr-dobermann/gobpm/internal/instance"
+	"github.com/dr-dobermann/gobpm/internal/instance/checkpoint"
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+	"github.com/dr-dobermann/gobpm/pkg/repository"
+)
+
+const (
+	// ExportFormat names the document ExportInstance writes.
+	ExportFormat = "gobpm-instance-export"
+
+	// ExportFormatVersion is the export document's own version. It is
+	// independent of the checkpoint schema the document wraps: an import
+	// refuses a newer export version, and the wrapped checkpoint's schema
+	// is checked as recovery checks it.
+	ExportFormatVersion = 1
+)
+
+// instanceExport is the document ExportInstance writes and ImportInstance
+// reads: the instance's checkpoint with the definition it runs — the process
+// key and the pinned version — and where it came from.
+type instanceExport struct {
+	ExportedAt     time.Time       `json:"exported_at"`
+	Format         string          `json:"format"`
+	InstanceID     string          `json:"instance_id"`
+	ProcessKey     string          `json:"process_key"`
+	ExportedBy     string          `json:"exported_by"`
+	Checkpoint     json.RawMessage `json:"checkpoint"`
+	FormatVersion  int             `json:"format_version"`
+	ProcessVersion int             `json:"process_version"`
+}
+
+// ExportInstance returns the instance id as a self-contained document another
+// engine can import with ImportInstance: its schema-versioned checkpoint with
+// the process key and version it runs. The export changes nothing — the
+// instance keeps running here until the caller cancels or deletes it.
+//
+// An instance this engine tracks must be released — dehydrated, parked on an
+// incident, or suspended — so its checkpoint is its whole state: a running
+// instance is refused, so suspend it first. An instance it doesn't track is
+// exported from the Repository when it belongs to this engine's group and no
+// other engine holds its lease.
+func (t *Thresher) ExportInstance(ctx context.Context, id string) ([]byte, error) {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return nil, errs.New(
+			errs.M("ExportInstance: an instance id is required"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	if engCtx, running := t.engineContext(); !running || engCtx.Err() != nil {
+		return nil, t.errEngineNotRunning("ExportInstance")
+	}
+
+	if err := t.awaitClaim(id, "ExportInstance"); err != nil {
+		return nil, err
+	}
+
+	defer t.releaseWake(id)
+
+	doc, err := t.exportableCheckpoint(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := doc.Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := json.Marshal(instanceExport{
+		Format:         ExportFormat,
+		FormatVersion:  ExportFormatVersion,
+		InstanceID:     doc.InstanceID,
+		ProcessKey:     doc.ProcessID,
+		ProcessVersion: doc.Version,
+		ExportedBy:     t.id,
+		ExportedAt:     t.cfg.Clock().Now().UTC(),
+		Checkpoint:     raw,
+	})
+	if err != nil {
+		return nil, errs.New(
+			errs.M("ExportInstance: the export of %q doesn't serialize", id),
+			errs.C(errorClass, errs.OperationFailed),
+			errs.D(observability.AttrInstanceID, id),
+			errs.E(err))
+	}
+
+	return out, nil
+}
+
+// exportableCheckpoint loads and decodes the checkpoint of the instance id,
+// refusing an instance whose checkpoint isn't its whole state or isn't this
+// engine's to read.
+func (t *Thresher) exportableCheckpoint(
+	ctx context.Context, id string,
+) (*checkpoint.Document, error) {
+	inst, tracked := t.trackedInstance(id)
+	if tracked && !inst.Released() {
+		return nil, errs.New(
+			errs.M("can't export instance %q: it is %s and holds its "+
+				"goroutines — suspend it first", id, inst.State()),
+			errs.C(errorClass, errs.InvalidState),
+			errs.D(observability.AttrInstanceID, id))
+	}
+
+	rec, ok, err := t.cfg.Repository().Load(ctx, id)
+	if err != nil || !ok {
+		return nil, errs.New(
+			errs.M("can't export instance %q: it has no checkpoint", id),
+			errs.C(errorClass, errs.ObjectNotFound),
+			errs.D(observability.AttrInstanceID, id),
+			errs.E(err))
+	}
+
+	if !tracked {
+		if err := t.checkForeignRecord("export", rec); err != nil {
+			return nil, err
+		}
+	}
+
+	return t.openCheckpoint(ctx, rec.ID, rec.Payload)
+}
+
+// ImportInstance runs on this engine the instance an ExportInstance document
+// carries, under the same instance id, and returns its handle. The process
+// version the document pins must be registered here; the instance is claimed
+// under this engine's lease and group, rebuilt from the checkpoint and
+// continued — a suspended instance stays suspended.
+//
+// Refused are a document this engine doesn't read (another format, a newer
+// export version or checkpoint schema), a finished instance, one half of a
+// call — a child, or a caller waiting on one — and an id this engine or its
+// Repository already holds. A business key taken under WithUniqueBusinessKey
+// refuses the import as it refuses a start.
+func (t *Thresher) ImportInstance(
+	ctx context.Context, export []byte,
+) (*InstanceHandle, error) {
+	if engCtx, running := t.engineContext(); !running || engCtx.Err() != nil {
+		return nil, t.errEngineNotRunning("ImportInstance")
+	}
+
+	doc, err := decodeExport(export)
+	if err != nil {
+		return nil, err
+	}
+
+	id := doc.InstanceID
+
+	if err := importable(doc); err != nil {
+		return nil, err
+	}
+
+	s := t.snapshotForVersionLocked(doc.ProcessID, doc.Version)
+	if s == nil {
+		return nil, errs.New(
+			errs.M("can't import instance %q: version %d of process %q "+
+				"isn't registered with this engine", id, doc.Version,
+				doc.ProcessID),
+			errs.C(errorClass, errs.ObjectNotFound),
+			errs.D(observability.AttrInstanceID, id),
+			errs.D(observability.AttrProcessID, doc.ProcessID))
+	}
+
+	if err := t.awaitClaim(id, "ImportInstance"); err != nil {
+		return nil, err
+	}
+
+	defer t.releaseWake(id)
+
+	release, err := t.claimBusinessKey(ctx, doc.ProcessID, doc.BusinessKey)
+	if err != nil {
+		return nil, err
+	}
+
+	defer release()
+
+	rec, err := t.claimImported(ctx, doc)
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := t.runClaimed(rec, doc, s, "import", importErr)
+	if err != nil {
+		// the record is this import's own: leaving it would strand an owned
+		// instance no engine runs.
+		if derr := t.cfg.Repository().Delete(
+			context.WithoutCancel(ctx), id); derr != nil {
+			t.cfg.logger.Warn("import: the failed import's record doesn't delete",
+				observability.AttrInstanceID, id,
+				observability.AttrError, derr.Error())
+		}
+
+		return nil, err
+	}
+
+	h.current().Report(observability.Fact{
+		Kind:  observability.KindInstanceState,
+		Phase: observability.PhaseImported,
+		Details: map[string]string{
+			observability.AttrProcessID: doc.ProcessID,
+			observability.AttrVersion:   strconv.Itoa(doc.Version),
+			"live_tracks":               strconv.Itoa(len(doc.Tracks)),
+		},
+	})
+
+	return h, nil
+}
+
+// decodeExport reads an ExportInstance document and its checkpoint, checking
+// the two agree on the instance and its definition.
+func decodeExport(export []byte) (*checkpoint.Document, error) {
+	var ex instanceExport
+	if err := json.Unmarshal(export, &ex); err != nil {
+		return nil, errs.New(
+			errs.M("ImportInstance: the export doesn't parse"),
+			errs.C(errorClass, errs.InvalidObject),
+			errs.E(err))
+	}
+
+	if ex.Format != ExportFormat {
+		return nil, errs.New(
+			errs.M("ImportInstance: %q isn't an instance export", ex.Format),
+			errs.C(errorClass, errs.InvalidObject))
+	}
+
+	if ex.FormatVersion < 1 || ex.FormatVersion > ExportFormatVersion {
+		return nil, errs.New(
+			errs.M("ImportInstance: unsupported export version %d "+
+				"(this engine reads 1..%d)", ex.FormatVersion,
+				ExportFormatVersion),
+			errs.C(errorClass, errs.InvalidState))
+	}
+
+	doc, err := checkpoint.Unmarshal(ex.Checkpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	if doc.InstanceID != ex.InstanceID || doc.ProcessID != ex.ProcessKey ||
+		doc.Version != ex.ProcessVersion {
+		return nil, errs.New(
+			errs.M("ImportInstance: the export names instance %q of %s v%d, "+
+				"its checkpoint %q of %s v%d", ex.InstanceID, ex.ProcessKey,
+				ex.ProcessVersion, doc.InstanceID, doc.ProcessID, doc.Version),
+			errs.C(errorClass, errs.InvalidObject))
+	}
+
+	return doc, nil
+}
+
+// importable refuses a checkpoint that doesn't move on its own: a finished
+// instance has nothing to run, and a call's two ends are separate records
+// that would be split.
+func importable(doc *checkpoint.Document) error {
+	id := doc.InstanceID
+
+	switch doc.Status {
+	case instance.Completed.String(), instance.Terminating.String(),
+		instance.Terminated.String():
+		return errs.New(
+			errs.M("can't import instance %q: it is %s", id, doc.Status),
+			errs.C(errorClass, errs.InvalidState),
+			errs.D(observability.AttrInstanceID, id))
+	}
+
+	if doc.ParentID != "" || len(doc.Calls) > 0 {
+		return errs.New(
+			errs.M("can't import instance %q: it is one end of an in-flight "+
+				"call, and a call moves only as a whole", id),
+			errs.C(errorClass, errs.InvalidState),
+			errs.D(observability.AttrInstanceID, id))
+	}
+
+	return nil
+}
+
+// claimImported creates the imported instance's record owned by this engine —
+// a new record under a fresh lease, so an id the Repository already holds is
+// refused and a concurrent import of the same id loses the compare-and-set.
+func (t *Thresher) claimImported(
+	ctx context.Context, doc *checkpoint.Document,
+) (repository.InstanceRecord, error) {
+	id := doc.InstanceID
+	repo := t.cfg.Repository()
+
+	if _, tracked := t.trackedInstance(id); tracked {
+		return repository.InstanceRecord{}, errs.New(
+			errs.M("can't import instance %q: this engine already runs it", id),
+			errs.C(errorClass, errs.DuplicateObject),
+			errs.D(observability.AttrInstanceID, id))
+	}
+
+	prev, ok, err := repo.Load(ctx, id)
+	if err != nil {
+		return repository.InstanceRecord{},
+			importErr("the instance's record isn't readable", err)
+	}
+
+	if ok {
+		if err := t.checkForeignRecord("import", prev); err != nil {
+			return repository.InstanceRecord{}, err
+		}
+
+		return repository.InstanceRecord{}, errs.New(
+			errs.M("can't import instance %q: the Repository already holds it",
+				id),
+			errs.C(errorClass, errs.DuplicateObject),
+			errs.D(observability.AttrInstanceID, id))
+	}
+
+	raw, err := t.sealCheckpoint(ctx, id, doc)
+	if err != nil {
+		return repository.InstanceRecord{}, err
+	}
+
+	rec := repository.InstanceRecord{
+		ID:          id,
+		Payload:     raw,
+		Group:       t.group,
+		ProcessID:   doc.ProcessID,
+		BusinessKey: doc.BusinessKey,
+		Status:      repository.StatusActive,
+		Lease: repository.Lease{
+			Owner:       t.id,
+			Incarnation: 1,
+			Expiry:      t.cfg.Clock().Now().Add(t.cfg.leaseTTL),
+		},
+	}
+
+	if doc.Status == instance.Suspended.String() {
+		rec.Status = repository.StatusSuspended
+	}
+
+	if err := repo.Save(ctx, rec); err != nil {
+		if lostClaim(err) {
+			return repository.InstanceRecord{}, errs.New(
+				errs.M("can't import instance %q: another import created it "+
+					"first", id),
+				errs.C(errorClass, errs.DuplicateObject, errs.ConcurrentUpdate),
+				errs.D(observability.AttrInstanceID, id),
+				errs.E(err))
+		}
+
+		return repository.InstanceRecord{},
+			importErr("the instance's record doesn't save", err)
+	}
+
+	rec.RecVersion++ // Save advanced the stored version; continue from it
+
+	return rec, nil
+}
+
+// checkForeignRecord refuses a record this engine may not take over: another
+// group's, or one another engine holds a live lease on.
+func (t *Thresher) checkForeignRecord(
+	op string, rec repository.InstanceRecord,
+) error {
+	if rec.Group != t.group {
+		return errs.New(
+			errs.M("can't %s instance %q: it belongs to engine group %q, "+
+				"this engine runs in %q", op, rec.ID, rec.Group, t.group),
+			errs.C(errorClass, errs.InvalidState),
+			errs.D(observability.AttrInstanceID, rec.ID))
+	}
+
+	if rec.Lease.Owner != t.id && !rec.Lease.Expired(t.cfg.Clock().Now()) {
+		return errs.New(
+			errs.M("can't %s instance %q: engine %q holds its lease",
+				op, rec.ID, rec.Lease.Owner),
+			errs.C(errorClass, errs.InvalidState),
+			errs.D(observability.AttrInstanceID, rec.ID))
+	}
+
+	return nil
+}
+
+// trackedInstance returns the instance object this engine tracks under id.
+func (t *Thresher) trackedInstance(id string) (*instance.Instance, bool) {
+	inst, err := t.instanceByID(id)
+
+	return inst, err == nil
+}
+
+// importErr builds one classified import error.
+func importErr(msg string, cause error) error {
+	if cause == nil {
+		return errs.New(
+			errs.M("import: "+msg),
+			errs.C(errorClass, errs.OperationFailed))
+	}
+
+	return errs.New(
+		errs.M("import: "+msg),
+		errs.C(errorClass, errs.OperationFailed),
+		errs.E(cause))
+}
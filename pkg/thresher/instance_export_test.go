@@ -0,0 +1,185 @@
+package thresher_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+	"github.com/dr-dobermann/gobpm/pkg/repository"
+	"github.com/dr-dobermann/gobpm/pkg/repository/memrepo"
+	"github.com/dr-dobermann/gobpm/pkg/thresher"
+)
+
+// exportedInstance boots a staging engine over its own Repository, starts an
+// instance of utProc(key) parked on its task and exports it once it
+// dehydrated.
+func exportedInstance(
+	t *testing.T, key string,
+) (*thresher.Thresher, *thresher.InstanceHandle, []byte) {
+	t.Helper()
+
+	th, fw, cancel := bootTaskEngine(t, "staging-"+key, memrepo.New(),
+		&annCollector{}, utProc(t, key))
+	t.Cleanup(cancel)
+
+	h, err := th.StartLatest(key)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return fw.count(observability.KindInstanceState,
+			observability.PhaseDehydrated) >= 1
+	}, 3*time.Second, 10*time.Millisecond)
+
+	raw, err := th.ExportInstance(context.Background(), h.ID())
+	require.NoError(t, err)
+
+	return th, h, raw
+}
+
+// TestExportImportMovesInstance verifies an instance exported from one engine
+// runs to completion on another with its own Repository: the same id, the
+// task announced again there, and the record owned by the importing engine.
+func TestExportImportMovesInstance(t *testing.T) {
+	key := "export-move"
+	_, src, raw := exportedInstance(t, key)
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(raw, &doc))
+	require.Equal(t, thresher.ExportFormat, doc["format"])
+	require.Equal(t, key, doc["process_key"])
+	require.EqualValues(t, 1, doc["process_version"])
+
+	repo := memrepo.New()
+	dist := &annCollector{}
+
+	prod, fw, cancel := bootTaskEngine(t, "prod-"+key, repo, dist,
+		utProc(t, key))
+	t.Cleanup(cancel)
+
+	ctx := context.Background()
+
+	h, err := prod.ImportInstance(ctx, raw)
+	require.NoError(t, err)
+	require.Equal(t, src.ID(), h.ID())
+	require.Eventually(t, func() bool {
+		return fw.count(observability.KindInstanceState,
+			observability.PhaseImported) == 1
+	}, 3*time.Second, 10*time.Millisecond)
+
+	rec, ok, err := repo.Load(ctx, h.ID())
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "prod-"+key, rec.Lease.Owner)
+	require.Equal(t, "prod-"+key, rec.Group)
+
+	require.Eventually(t, func() bool { return dist.count() == 1 },
+		3*time.Second, 10*time.Millisecond,
+		"the imported task must be announced on the importing engine")
+
+	taskID := dist.taskIDs()[0]
+	operator := utActor{id: "operator"}
+
+	require.NoError(t, prod.Claim(ctx, taskID, operator))
+	require.NoError(t, prod.Complete(ctx, taskID, operator,
+		resultOutput("approved")))
+
+	waitCompleted(t, h)
+
+	_, err = prod.ImportInstance(ctx, raw)
+	requireClass(t, err, errs.DuplicateObject)
+}
+
+// TestImportRefusals verifies the imports ImportInstance refuses: a missing
+// definition, an unreadable document, an id the engine or its Repository
+// already holds, and a record another engine leases.
+func TestImportRefusals(t *testing.T) {
+	key := "export-refuse"
+	src, h, raw := exportedInstance(t, key)
+	ctx := context.Background()
+
+	t.Run("the definition is missing", func(t *testing.T) {
+		th, _, cancel := bootTaskEngine(t, "prod-other", memrepo.New(),
+			&annCollector{}, utProc(t, "export-other"))
+		t.Cleanup(cancel)
+
+		_, err := th.ImportInstance(ctx, raw)
+		requireClass(t, err, errs.ObjectNotFound)
+	})
+
+	t.Run("the document isn't an export", func(t *testing.T) {
+		_, err := src.ImportInstance(ctx, []byte("{"))
+		requireClass(t, err, errs.InvalidObject)
+
+		var doc map[string]any
+		require.NoError(t, json.Unmarshal(raw, &doc))
+
+		doc["format_version"] = thresher.ExportFormatVersion + 1
+		newer, err := json.Marshal(doc)
+		require.NoError(t, err)
+
+		_, err = src.ImportInstance(ctx, newer)
+		requireClass(t, err, errs.InvalidState)
+
+		doc["format_version"] = thresher.ExportFormatVersion
+		doc["process_version"] = 7
+		forged, err := json.Marshal(doc)
+		require.NoError(t, err)
+
+		_, err = src.ImportInstance(ctx, forged)
+		requireClass(t, err, errs.InvalidObject)
+	})
+
+	t.Run("the instance is already here", func(t *testing.T) {
+		_, err := src.ImportInstance(ctx, raw)
+		requireClass(t, err, errs.DuplicateObject)
+	})
+
+	t.Run("another engine leases the record", func(t *testing.T) {
+		repo := memrepo.New()
+
+		th, _, cancel := bootTaskEngine(t, "prod-leased", repo,
+			&annCollector{}, utProc(t, key))
+		t.Cleanup(cancel)
+
+		require.NoError(t, repo.Save(ctx, repository.InstanceRecord{
+			ID:      h.ID(),
+			Payload: []byte("{}"),
+			Group:   "prod-leased",
+			Lease: repository.Lease{
+				Owner:       "prod-elsewhere",
+				Incarnation: 1,
+				Expiry:      dehydrationEpoch.Add(time.Hour),
+			},
+		}))
+
+		_, err := th.ImportInstance(ctx, raw)
+		requireClass(t, err, errs.InvalidState)
+	})
+}
+
+// TestExportRefusals verifies the exports ExportInstance refuses.
+func TestExportRefusals(t *testing.T) {
+	src, _, _ := exportedInstance(t, "export-none")
+	ctx := context.Background()
+
+	_, err := src.ExportInstance(ctx, " ")
+	requireClass(t, err, errs.EmptyNotAllowed)
+
+	_, err = src.ExportInstance(ctx, "no-such-instance")
+	requireClass(t, err, errs.ObjectNotFound)
+
+	idle, err := thresher.New("export-idle",
+		thresher.WithoutBanner(), thresher.WithoutStartupConfig())
+	require.NoError(t, err)
+
+	_, err = idle.ExportInstance(ctx, "any")
+	require.Error(t, err, "a stopped engine exports nothing")
+
+	_, err = idle.ImportInstance(ctx, nil)
+	require.Error(t, err, "a stopped engine imports nothing")
+}
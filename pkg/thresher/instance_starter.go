@@ -41,10 +41,48 @@ func (s *instanceStarter) ID() string {
 // waiter on a matching message: it derives the incoming message's correlation
 // key from the payload (ADR-016 v.1 §2.2) and asks the Thresher to resolve
 // create-or-route-or-join by that key (§2.3), launching a new instance born
-// from the event when the key is unseen.
+// from the event when the key is unseen. A message whose broker MessageID a
+// starter already took is a redelivery and is dropped before any of that, as
+// is a signal received from another engine of the group (signal_fanout.go).
 func (s *instanceStarter) ProcessEvent(
 	ctx context.Context,
 	eDef flow.EventDefinition,
+) error {
+	// A signal another engine of the group threw has started its instance
+	// there: a signal start instantiates once per group, not once per
+	// engine that hears the broadcast.
+	if sed, ok := eDef.(*events.SignalEventDefinition); ok && sed.Origin() != "" {
+		s.thr.cfg.logger.Debug("instance-starter: remote signal left to its engine",
+			observability.AttrStartNodeID, s.startNode.ID(),
+			observability.AttrSignal, sed.Signal().Name())
+
+		return nil
+	}
+
+	msgID := events.MessageIDOf(eDef)
+	if !s.thr.reserveStartMessageLocked(s.snapshot.ProcessID, msgID) {
+		s.thr.cfg.logger.Debug("instance-starter: redelivered message dropped",
+			observability.AttrStartNodeID, s.startNode.ID(),
+			observability.AttrMessageName, s.messageName(),
+			observability.AttrMessageID, msgID)
+
+		return nil
+	}
+
+	if err := s.start(ctx, eDef); err != nil {
+		// the message didn't start anything — a redelivery may retry it.
+		s.thr.releaseStartMessageLocked(s.snapshot.ProcessID, msgID)
+
+		return err
+	}
+
+	return nil
+}
+
+// start is ProcessEvent past the redelivery check.
+func (s *instanceStarter) start(
+	ctx context.Context,
+	eDef flow.EventDefinition,
 ) error {
 	key, err := s.deriveKey(ctx, eDef)
 	if err != nil {
@@ -206,6 +206,10 @@ func (t *Thresher) reattachChild(childID string) (exec.ChildProcess, error) {
 	}
 
 	if rec.Status.IsTerminal() {
+		if rec.Payload, err = t.cfg.cpCodec.Decode(ctx, childID, rec.Payload); err != nil {
+			return nil, err
+		}
+
 		return newSettledChild(childID, rec)
 	}
 
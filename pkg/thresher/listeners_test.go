@@ -0,0 +1,313 @@
+package thresher_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/listener"
+	"github.com/dr-dobermann/gobpm/pkg/model/activities"
+	"github.com/dr-dobermann/gobpm/pkg/model/data"
+	"github.com/dr-dobermann/gobpm/pkg/model/data/values"
+	"github.com/dr-dobermann/gobpm/pkg/model/events"
+	"github.com/dr-dobermann/gobpm/pkg/model/flow"
+	"github.com/dr-dobermann/gobpm/pkg/model/process"
+	"github.com/dr-dobermann/gobpm/pkg/repository/memrepo"
+	"github.com/dr-dobermann/gobpm/pkg/thresher"
+)
+
+// listenerProcess builds start -> work(service) -> end and returns the
+// process with its work task.
+func listenerProcess(
+	t *testing.T, id string, sleep time.Duration,
+) (*process.Process, *activities.ServiceTask) {
+	t.Helper()
+
+	require.NoError(t, data.CreateDefaultStates())
+
+	proc, err := process.New(id)
+	require.NoError(t, err)
+
+	start, err := events.NewStartEvent("start")
+	require.NoError(t, err)
+
+	work, err := activities.NewServiceTask("work",
+		nopOp(t, "work-op", sleep), activities.WithoutParams())
+	require.NoError(t, err)
+
+	end, err := events.NewEndEvent("end")
+	require.NoError(t, err)
+
+	for _, e := range []flow.Element{start, work, end} {
+		require.NoError(t, proc.Add(e))
+	}
+
+	link(t, start, work)
+	link(t, work, end)
+
+	return proc, work
+}
+
+// runListening starts a Repository-backed thresher — an incident parks the
+// instance, and the retry rebuilds it — and registers proc with opts.
+func runListening(
+	t *testing.T, proc *process.Process, opts ...thresher.RegisterOption,
+) (*thresher.Thresher, context.CancelFunc) {
+	t.Helper()
+
+	th, err := thresher.New("test-"+proc.ID(), thresher.WithoutBanner(),
+		thresher.WithRepository(memrepo.New()))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, th.Run(ctx))
+
+	_, err = th.RegisterProcess(proc, opts...)
+	require.NoError(t, err)
+
+	return th, cancel
+}
+
+// callLog records listener calls as "<tag>:<event>:<node>[:<flow>]".
+type callLog struct {
+	m     sync.Mutex
+	calls []string
+}
+
+func (l *callLog) listener(tag string) listener.Listener {
+	return listener.Func(func(
+		_ context.Context, ex listener.Execution, _ listener.Variables,
+	) error {
+		entry := fmt.Sprintf("%s:%s:%s", tag, ex.Event, ex.NodeName)
+		if ex.FlowID != "" {
+			entry += ":" + ex.FlowID
+		}
+
+		l.m.Lock()
+		l.calls = append(l.calls, entry)
+		l.m.Unlock()
+
+		return nil
+	})
+}
+
+func (l *callLog) snapshot() []string {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	return append([]string(nil), l.calls...)
+}
+
+// TestExecutionListenersRunInOrder verifies the three binding levels run in
+// order — engine, process, element — around the node, and that the event
+// filter and the flow binding select the right calls.
+func TestExecutionListenersRunInOrder(t *testing.T) {
+	proc, work := listenerProcess(t, "lst-order", 0)
+	out := work.Outgoing()[0].ID()
+
+	var log callLog
+
+	th, err := thresher.New("test-"+proc.ID(),
+		thresher.WithExecutionListener(log.listener("engine"),
+			listener.EventStart))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	require.NoError(t, th.Run(ctx))
+
+	reg, err := th.RegisterProcess(proc,
+		thresher.WithProcessListener(log.listener("process"),
+			listener.EventEnd),
+		thresher.WithNodeListener(work.ID(), log.listener("node")),
+		thresher.WithNodeListener(out, log.listener("flow")))
+	require.NoError(t, err)
+
+	h, err := th.StartProcess(reg)
+	require.NoError(t, err)
+
+	wctx, wcancel := context.WithTimeout(ctx, 5*time.Second)
+	defer wcancel()
+
+	st, err := h.WaitCompletion(wctx)
+	require.NoError(t, err)
+	require.Equal(t, thresher.StateCompleted, st)
+
+	require.Equal(t, []string{
+		"engine:start:start",
+		"process:end:start",
+		"engine:start:work",
+		"node:start:work",
+		"process:end:work",
+		"node:end:work",
+		"node:take:work:" + out,
+		"flow:take:work:" + out,
+		"engine:start:end",
+		"process:end:end",
+	}, log.snapshot())
+}
+
+// TestExecutionListenerWritesVariables verifies a listener reads the node's
+// view of the data and its writes commit with the node's results.
+func TestExecutionListenerWritesVariables(t *testing.T) {
+	proc, work := listenerProcess(t, "lst-vars", 300*time.Millisecond)
+
+	th, err := thresher.New("test-" + proc.ID())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	require.NoError(t, th.Run(ctx))
+
+	var seen atomic.Value
+
+	reg, err := th.RegisterProcess(proc,
+		thresher.WithProcessListener(listener.Func(func(
+			ctx context.Context, ex listener.Execution, vars listener.Variables,
+		) error {
+			if ex.NodeID == work.ID() {
+				v, err := vars.Get("order_id")
+				if err != nil {
+					return err
+				}
+
+				seen.Store(v.Get(ctx))
+
+				return nil
+			}
+
+			return vars.Set("note", values.NewVariable("enriched"))
+		}), listener.EventEnd))
+	require.NoError(t, err)
+
+	h, err := th.StartProcess(reg,
+		thresher.WithStartValues(map[string]data.Value{
+			"order_id": values.NewVariable("ord-7"),
+		}))
+	require.NoError(t, err)
+
+	// the start event's end listener committed with the start event, while
+	// the work task is still running
+	require.Eventually(t, func() bool {
+		d, err := h.Data().GetData("note")
+
+		return err == nil && d.Value().Get(ctx) == "enriched"
+	}, 2*time.Second, 10*time.Millisecond)
+
+	wctx, wcancel := context.WithTimeout(ctx, 5*time.Second)
+	defer wcancel()
+
+	_, err = h.WaitCompletion(wctx)
+	require.NoError(t, err)
+	require.Equal(t, "ord-7", seen.Load())
+}
+
+// TestExecutionListenerVetoRaisesIncident verifies a failing listener fails
+// its activity into an incident, and a retry after the cause clears runs the
+// node again — on the rebuilt instance, listeners included — to completion.
+func TestExecutionListenerVetoRaisesIncident(t *testing.T) {
+	proc, work := listenerProcess(t, "lst-veto", 0)
+
+	var (
+		veto  atomic.Bool
+		calls atomic.Int32
+	)
+
+	veto.Store(true)
+
+	th, cancel := runListening(t, proc,
+		thresher.WithNodeListener(work.ID(), listener.Func(func(
+			context.Context, listener.Execution, listener.Variables,
+		) error {
+			calls.Add(1)
+
+			if veto.Load() {
+				return errors.New("order is on hold")
+			}
+
+			return nil
+		}), listener.EventStart))
+	defer cancel()
+
+	h, err := th.StartLatest(proc.ID())
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool { return h.OpenIncidents() == 1 },
+		5*time.Second, 10*time.Millisecond)
+
+	inc := h.Incidents()[0]
+	require.Equal(t, work.ID(), inc.NodeID)
+	require.Contains(t, inc.Cause, "order is on hold")
+
+	veto.Store(false)
+	require.NoError(t, h.RetryIncident(context.Background(), inc.ID))
+
+	wctx, wcancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer wcancel()
+
+	st, err := h.WaitCompletion(wctx)
+	require.NoError(t, err)
+	require.Equal(t, thresher.StateCompleted, st)
+	require.EqualValues(t, 2, calls.Load())
+}
+
+// TestExecutionListenerPanicFailsActivity verifies a panicking listener
+// fails only its activity, into an incident.
+func TestExecutionListenerPanicFailsActivity(t *testing.T) {
+	proc, work := listenerProcess(t, "lst-panic", 0)
+
+	th, cancel := runListening(t, proc,
+		thresher.WithNodeListener(work.ID(), listener.Func(func(
+			context.Context, listener.Execution, listener.Variables,
+		) error {
+			panic("boom")
+		}), listener.EventEnd))
+	defer cancel()
+
+	h, err := th.StartLatest(proc.ID())
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool { return h.OpenIncidents() == 1 },
+		5*time.Second, 10*time.Millisecond)
+	require.Contains(t, h.Incidents()[0].Cause, "listener panicked: boom")
+}
+
+// TestExecutionListenerOptionValidation verifies the listener options refuse
+// a nil listener, an unknown event and an empty element id.
+func TestExecutionListenerOptionValidation(t *testing.T) {
+	nop := listener.Func(func(
+		context.Context, listener.Execution, listener.Variables,
+	) error {
+		return nil
+	})
+
+	_, err := thresher.New("test-lst-nil", thresher.WithExecutionListener(nil))
+	require.ErrorContains(t, err, "a nil listener isn't allowed")
+
+	proc, _ := listenerProcess(t, "lst-validate", 0)
+	th, cancel := runEngine(t, proc)
+	defer cancel()
+
+	for _, opt := range []thresher.RegisterOption{
+		thresher.WithProcessListener(nop, listener.Event("resume")),
+		thresher.WithNodeListener(" ", nop),
+		thresher.WithNodeListener("work", nil),
+	} {
+		_, err := th.RegisterProcess(proc, opt)
+		require.Error(t, err)
+	}
+
+	// an element id the process doesn't have is refused at registration.
+	_, err = th.RegisterProcess(proc, thresher.WithNodeListener("no-such-node", nop))
+	require.ErrorContains(t, err, "no-such-node")
+	requireClass(t, err, errs.ObjectNotFound)
+}
@@ -2,6 +2,7 @@ package thresher
 
 import (
 	"context"
+	"slices"
 	"sort"
 
 	"github.com/dr-dobermann/gobpm/internal/instance"
@@ -11,9 +12,9 @@ import (
 )
 
 // This file holds every t.m-confined registry operation. Each helper acquires
-// t.m, touches ONLY the four registry maps (registrations, nextVersion,
-// instances, seenKeys), and returns plain data — it never takes a callback or
-// runs an EventHub / launchInstance call. Callers do the hub/launch work AFTER
+// t.m, touches ONLY the registry maps (registrations, nextVersion, instances,
+// seenKeys, bizKeysInFlight), and returns plain data — it never takes a
+// callback or runs an EventHub / launchInstance call. Callers do the hub/launch work AFTER
 // the helper has returned (and the lock released), so it is impossible by
 // construction to hold t.m across an engine-subsystem call — the FIX-002 RC2
 // deadlock class the audit (§2.6) flagged.
@@ -25,7 +26,7 @@ import (
 func (t *Thresher) appendVersionLocked(
 	s *snapshot.Snapshot,
 	starters []*instanceStarter,
-	manual bool,
+	rc registerConfig,
 ) (reg, prevLatest *ProcessRegistration) {
 	t.m.Lock()
 	defer t.m.Unlock()
@@ -49,7 +50,9 @@ func (t *Thresher) appendVersionLocked(
 		id:       foundation.GenerateID(),
 		snapshot: s,
 		starters: starters,
-		manual:   manual,
+		manual:   rc.manualStart,
+
+		uniqueBusinessKey: rc.uniqueBusinessKey,
 	}
 	t.registrations[s.ProcessID] = append(prev, reg)
 
@@ -273,6 +276,93 @@ func (t *Thresher) rebindKeysLocked(
 	}
 }
 
+// maxStartMessages bounds the start-message ids the engine remembers; the
+// oldest are forgotten first. The memory is this process's alone: a restart
+// re-seeds it only from the instances the engine rebuilds, so a redelivery
+// of an id it no longer holds — evicted, or whose instance finished before
+// the restart — starts a second instance.
+const maxStartMessages = 4096
+
+// reserveStartMessageLocked claims a start message's broker id for one
+// launch of processID, reporting false when its instance-starter already
+// took it — a redelivery. Namespaced by process: two processes starting on
+// one message are two conversations. An empty id (an anonymous message)
+// always reserves.
+func (t *Thresher) reserveStartMessageLocked(processID, msgID string) bool {
+	if msgID == "" {
+		return true
+	}
+
+	id := nsKeyFor(processID, msgID)
+
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	if seen(t.startMsgs, id) {
+		return false
+	}
+
+	t.rememberStartMessage(id)
+
+	return true
+}
+
+// releaseStartMessageLocked drops a reservation whose launch failed, so a
+// redelivery may retry it.
+func (t *Thresher) releaseStartMessageLocked(processID, msgID string) {
+	if msgID == "" {
+		return
+	}
+
+	id := nsKeyFor(processID, msgID)
+
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	delete(t.startMsgs, id)
+
+	if i := slices.Index(t.startMsgOrder, id); i >= 0 {
+		t.startMsgOrder = slices.Delete(t.startMsgOrder, i, i+1)
+	}
+}
+
+// rebindMessagesLocked re-seeds the start-message memory from a checkpoint
+// the engine has just rebuilt: its accepted ids include the message the
+// instance was born from, so a redelivery after a restart still finds it.
+func (t *Thresher) rebindMessagesLocked(processID string, ids []string) {
+	if len(ids) == 0 {
+		return
+	}
+
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	for _, msgID := range ids {
+		if id := nsKeyFor(processID, msgID); !seen(t.startMsgs, id) {
+			t.rememberStartMessage(id)
+		}
+	}
+}
+
+// seen reports whether set holds id.
+func seen(set map[string]struct{}, id string) bool {
+	_, ok := set[id]
+
+	return ok
+}
+
+// rememberStartMessage records id, forgetting the oldest past
+// maxStartMessages. Caller holds m.
+func (t *Thresher) rememberStartMessage(id string) {
+	t.startMsgs[id] = struct{}{}
+	t.startMsgOrder = append(t.startMsgOrder, id)
+
+	for len(t.startMsgOrder) > maxStartMessages {
+		delete(t.startMsgs, t.startMsgOrder[0])
+		t.startMsgOrder = t.startMsgOrder[1:]
+	}
+}
+
 // releaseKeysOfLocked drops every reservation owned by instanceID — the
 // forgetting half of §1.2, so the map shrinks with the instances it tracks
 // rather than growing for the engine's lifetime. Caller holds m.
@@ -284,6 +374,114 @@ func (t *Thresher) releaseKeysOfLocked(instanceID string) {
 	}
 }
 
+// dropKeysLocked forgets the correlation reservations of an instance the
+// engine hands off to its group (Drain): the engine that recovers it re-takes
+// them (rebindKeysLocked), and until then a message for the conversation is
+// not this engine's to route.
+func (t *Thresher) dropKeysLocked(instanceID string) {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	t.releaseKeysOfLocked(instanceID)
+}
+
+// reserveBusinessKeyLocked claims businessKey for a new instance of processID
+// when the key's latest registration demands unique business keys
+// (WithUniqueBusinessKey). It returns reserved=true when the caller now holds
+// an in-flight reservation it must release (releaseBusinessKeyLocked), and
+// ok=false when the key is taken — by a start still in flight or a live
+// tracked instance of the process. A process without the guarantee reserves
+// nothing and is always ok. The check-and-record is atomic, so two concurrent
+// same-key starts cannot both pass.
+func (t *Thresher) reserveBusinessKeyLocked(
+	processID, businessKey string,
+) (reserved, ok bool) {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	regs := t.registrations[processID]
+	if len(regs) == 0 || !regs[len(regs)-1].uniqueBusinessKey {
+		return false, true
+	}
+
+	nsKey := nsKeyFor(processID, businessKey)
+	if _, inFlight := t.bizKeysInFlight[nsKey]; inFlight {
+		return false, false
+	}
+
+	for _, r := range t.instances {
+		if r.inst.ProcessID() == processID &&
+			r.inst.BusinessKey() == businessKey &&
+			!instanceTerminal(r.inst.State()) {
+			return false, false
+		}
+	}
+
+	t.bizKeysInFlight[nsKey] = struct{}{}
+
+	return true, true
+}
+
+// releaseBusinessKeyLocked drops an in-flight business-key reservation — after
+// its launch failed, or once the launched instance is tracked and so found by
+// the instances scan itself.
+func (t *Thresher) releaseBusinessKeyLocked(processID, businessKey string) {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	delete(t.bizKeysInFlight, nsKeyFor(processID, businessKey))
+}
+
+// instanceByBusinessKeyLocked returns the handle of the tracked instance of
+// processID carrying businessKey. A live instance wins over a finished one;
+// among equals the smallest id does, so the answer is deterministic.
+func (t *Thresher) instanceByBusinessKeyLocked(
+	processID, businessKey string,
+) (*InstanceHandle, bool) {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	var (
+		best     instanceReg
+		bestLive bool
+		found    bool
+	)
+
+	for id, r := range t.instances {
+		if r.inst.ProcessID() != processID ||
+			r.inst.BusinessKey() != businessKey {
+			continue
+		}
+
+		live := !instanceTerminal(r.inst.State())
+
+		switch {
+		case !found,
+			live && !bestLive,
+			live == bestLive && id < best.inst.ID():
+			best, bestLive, found = r, live, true
+		}
+	}
+
+	if !found {
+		return nil, false
+	}
+
+	return best.handle, true
+}
+
+// terminalTrackedLocked reports whether id is tracked here AND finished — a
+// record the store may still list as live for the instant between the
+// terminal transition and its last checkpoint.
+func (t *Thresher) terminalTrackedLocked(id string) bool {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	r, ok := t.instances[id]
+
+	return ok && instanceTerminal(r.inst.State())
+}
+
 // pendingInstancesLocked returns the tracked instances whose ids are NOT in
 // settled — the work one Shutdown drain pass still has to await. A fresh call
 // picks up anything born since the previous pass (FIX-036 §1.7).
@@ -0,0 +1,232 @@
+package thresher
+
+import (
+	"context"
+	"time"
+
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+	"github.com/dr-dobermann/gobpm/pkg/repository"
+)
+
+// The engine group's membership. Over a Repository that implements
+// repository.MembershipRegistry every engine records itself in its group at
+// Run — its id, its build, its start and its refresh interval — refreshes
+// the record every third of its lease window and removes it at Shutdown,
+// once its instances are drained. A crashed engine leaves its
+// record behind with a heartbeat that no longer moves; GroupMembers reports
+// it as not alive, and the lease counts show what it still holds until the
+// rest of the group takes it over.
+
+// GroupMember is one engine of the thresher's group as GroupMembers reports
+// it: its membership record, whether it is alive, and how many of the
+// group's unfinished instances it holds.
+type GroupMember struct {
+	// Started is when the engine joined the group (Run); Heartbeat is when
+	// it last refreshed its record.
+	Started   time.Time
+	Heartbeat time.Time
+	EngineID  string
+	// Version and Revision are the engine's build: its version and the
+	// abbreviated VCS revision, "-dirty" when built from a modified tree.
+	Version  string
+	Revision string
+	// Refresh is how often the engine refreshes its Heartbeat.
+	Refresh time.Duration
+	// Owned counts the group's unfinished instances the engine holds the
+	// lease of — lapsed leases included, so a crashed engine's count shows
+	// what is still to take over.
+	Owned int
+	// Alive reports a heartbeat within three of the member's own refresh
+	// intervals — its lease window: a member that missed them has crashed
+	// or hangs.
+	Alive bool
+}
+
+// memberRegistry returns the Repository's membership registry, or nil when
+// the store has none or the engine runs without a Repository.
+func (t *Thresher) memberRegistry() repository.MembershipRegistry {
+	if !t.cfg.repoSet {
+		return nil
+	}
+
+	reg, _ := t.cfg.Repository().(repository.MembershipRegistry)
+
+	return reg
+}
+
+// joinGroup records the engine in its group's registry at Run and keeps the
+// record fresh for as long as the engine runs. A failure costs the group its
+// view of this engine, never the engine its start, so it is logged rather
+// than returned.
+func (t *Thresher) joinGroup(ctx context.Context) {
+	if t.memberRegistry() == nil {
+		return
+	}
+
+	bi := readBuildInfo()
+
+	t.memberMu.Lock()
+	t.member = repository.GroupMember{
+		Started:  t.cfg.Clock().Now(),
+		Group:    t.group,
+		EngineID: t.id,
+		Refresh:  t.memberRefresh(),
+		Version:  bi.version,
+		Revision: bi.shortRevision(),
+	}
+	t.memberMu.Unlock()
+
+	t.touchMembership(ctx)
+	t.keepMembership(ctx)
+}
+
+// keepMembership refreshes the membership record every third of the lease
+// window — the window GroupMembers judges a heartbeat against — until the
+// engine context ends. It runs apart from the lease heartbeat, which is
+// opt-in (WithLeaseHeartbeat).
+func (t *Thresher) keepMembership(ctx context.Context) {
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case <-t.cfg.Clock().After(t.memberRefresh()):
+				t.touchMembership(ctx)
+			}
+		}
+	}()
+}
+
+// memberRefreshes is how many refreshes a member may miss, and the parts
+// its lease window divides into.
+const memberRefreshes = 3
+
+// memberRefresh is how often the engine refreshes its membership record: a
+// third of its lease window.
+func (t *Thresher) memberRefresh() time.Duration {
+	return t.cfg.leaseTTL / memberRefreshes
+}
+
+// memberAlive tells whether the member m refreshed its record at now
+// within memberRefreshes of its own intervals. A record without one — a
+// store that doesn't keep it — is judged against this engine's lease
+// window instead.
+func (t *Thresher) memberAlive(m repository.GroupMember, now time.Time) bool {
+	window := t.cfg.leaseTTL
+	if m.Refresh > 0 {
+		window = memberRefreshes * m.Refresh
+	}
+
+	return now.Sub(m.Heartbeat) < window
+}
+
+// touchMembership refreshes the engine's membership record — the heartbeat
+// the rest of the group reads as a sign of life. It does nothing once the
+// engine has left the group.
+func (t *Thresher) touchMembership(ctx context.Context) {
+	reg := t.memberRegistry()
+	if reg == nil {
+		return
+	}
+
+	t.memberMu.Lock()
+	defer t.memberMu.Unlock()
+
+	if t.member.EngineID == "" {
+		return
+	}
+
+	m := t.member
+	m.Heartbeat = t.cfg.Clock().Now()
+
+	if err := reg.PutMember(ctx, m); err != nil {
+		t.cfg.logger.Warn("engine group membership not recorded",
+			"engine_group", t.group, observability.AttrError, err.Error())
+	}
+}
+
+// leaveGroup removes the engine's record from its group's registry at
+// Shutdown. A refresh still in flight lands before the removal, never after
+// it.
+func (t *Thresher) leaveGroup(ctx context.Context) {
+	reg := t.memberRegistry()
+	if reg == nil {
+		return
+	}
+
+	t.memberMu.Lock()
+	defer t.memberMu.Unlock()
+
+	if t.member.EngineID == "" {
+		return
+	}
+
+	t.member = repository.GroupMember{}
+
+	if err := reg.RemoveMember(ctx, t.group, t.id); err != nil {
+		t.cfg.logger.Warn("engine group membership not removed",
+			"engine_group", t.group, observability.AttrError, err.Error())
+	}
+}
+
+// GroupMembers lists the engines of the thresher's group, ordered by engine
+// id, each with the count of the group's unfinished instances it holds.
+// Liveness is judged on this engine's clock against each member's own
+// refresh interval, so members with different lease windows are judged
+// fairly; the view is as current as their refreshes.
+//
+// GroupMembers needs an explicitly configured Repository (WithRepository)
+// that implements repository.MembershipRegistry; the engine need not be
+// running.
+func (t *Thresher) GroupMembers(ctx context.Context) ([]GroupMember, error) {
+	if !t.cfg.repoSet {
+		return nil, errs.New(
+			errs.M("GroupMembers needs an explicitly configured Repository (WithRepository)"),
+			errs.C(errorClass, errs.InvalidState))
+	}
+
+	reg := t.memberRegistry()
+	if reg == nil {
+		return nil, errs.New(
+			errs.M("GroupMembers: the Repository keeps no membership registry"+
+				" (repository.MembershipRegistry)"),
+			errs.C(errorClass, errs.InvalidState))
+	}
+
+	records, err := reg.Members(ctx, t.group)
+	if err != nil {
+		return nil, errs.New(
+			errs.M("couldn't list the members of engine group %q", t.group),
+			errs.C(errorClass, errs.OperationFailed),
+			errs.E(err))
+	}
+
+	now := t.cfg.Clock().Now()
+
+	owned, err := reg.OwnedCounts(ctx, t.group)
+	if err != nil {
+		return nil, errs.New(
+			errs.M("couldn't count the instances of engine group %q", t.group),
+			errs.C(errorClass, errs.OperationFailed),
+			errs.E(err))
+	}
+
+	members := make([]GroupMember, 0, len(records))
+
+	for _, r := range records {
+		members = append(members, GroupMember{
+			Started:   r.Started,
+			Heartbeat: r.Heartbeat,
+			EngineID:  r.EngineID,
+			Version:   r.Version,
+			Revision:  r.Revision,
+			Refresh:   r.Refresh,
+			Owned:     owned[r.EngineID],
+			Alive:     t.memberAlive(r, now),
+		})
+	}
+
+	return members, nil
+}
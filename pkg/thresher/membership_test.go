@@ -0,0 +1,212 @@
+package thresher_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gobpm/pkg/clock/clocktest"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+	"github.com/dr-dobermann/gobpm/pkg/repository"
+	"github.com/dr-dobermann/gobpm/pkg/repository/memrepo"
+	"github.com/dr-dobermann/gobpm/pkg/thresher"
+)
+
+// The engine group's membership: each engine records itself in its group,
+// keeps the record fresh while it runs and leaves it on a clean stop; GroupMembers tells live engines from dead ones and shows what each
+// holds.
+
+// groupMembers lists th's group, keyed by engine id.
+func groupMembers(t *testing.T, th *thresher.Thresher) map[string]thresher.GroupMember {
+	t.Helper()
+
+	members, err := th.GroupMembers(context.Background())
+	require.NoError(t, err)
+
+	byID := make(map[string]thresher.GroupMember, len(members))
+	for _, m := range members {
+		byID[m.EngineID] = m
+	}
+
+	return byID
+}
+
+// TestGroupMembers: two engines join the group and both are alive, the
+// first holding the instance it started. The first then dies — its clock,
+// and so its heartbeat, stops — and, once the second has taken the instance
+// over, it is reported dead and owning nothing. The second leaves the group
+// on Shutdown.
+func TestGroupMembers(t *testing.T) {
+	repo := memrepo.New()
+	deadline := dehydrationEpoch.Add(2 * time.Hour)
+	clk1 := clocktest.New(dehydrationEpoch)
+	clk2 := clocktest.New(dehydrationEpoch)
+
+	var hit1, hit2 atomic.Bool
+
+	th1, fw1 := upkeepEngine(t, "engine-1", repo, clk1,
+		longTimerProc(t, "members", deadline, &hit1))
+	th2, fw2 := upkeepEngine(t, "engine-2", repo, clk2,
+		longTimerProc(t, "members", deadline, &hit2))
+
+	_, err := th1.StartLatest("members")
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return fw1.saw(observability.KindInstanceState,
+			observability.PhaseDehydrated)
+	}, 3*time.Second, 5*time.Millisecond)
+
+	members, err := th2.GroupMembers(context.Background())
+	require.NoError(t, err)
+	require.Len(t, members, 2)
+	require.Equal(t, "engine-1", members[0].EngineID, "ordered by engine id")
+	require.Equal(t, "engine-2", members[1].EngineID)
+
+	for _, m := range members {
+		require.True(t, m.Alive, m.EngineID)
+		require.True(t, m.Started.Equal(dehydrationEpoch), m.EngineID)
+		require.NotEmpty(t, m.Revision, m.EngineID)
+	}
+
+	require.Equal(t, 1, members[0].Owned)
+	require.Equal(t, 0, members[1].Owned)
+
+	// engine-1 dies: from here on only engine-2's clock moves.
+	require.Eventually(t, func() bool {
+		clk2.Advance(10 * time.Second)
+
+		return fw2.saw(observability.KindInstanceState,
+			observability.PhaseRecovered)
+	}, 3*time.Second, 10*time.Millisecond)
+
+	// the heartbeats of the lease window past the takeover refresh engine-2
+	// alone.
+	require.Eventually(t, func() bool {
+		clk2.Advance(10 * time.Second)
+
+		m := groupMembers(t, th2)
+
+		return !m["engine-1"].Alive && m["engine-1"].Owned == 0 &&
+			m["engine-2"].Alive && m["engine-2"].Owned == 1
+	}, 3*time.Second, 10*time.Millisecond,
+		"engine-1 is reported dead, its instance on engine-2")
+
+	require.Contains(t, groupMembers(t, th2), "engine-1",
+		"a dead engine leaves its record behind")
+
+	require.NoError(t, th2.Shutdown(context.Background()))
+
+	m := groupMembers(t, th1)
+	require.Len(t, m, 1)
+	require.Contains(t, m, "engine-1", "a clean stop leaves the group")
+}
+
+// TestGroupMembershipRefresh: an engine without a lease heartbeat still
+// refreshes its membership record, so it stays alive however long it runs.
+func TestGroupMembershipRefresh(t *testing.T) {
+	clk := clocktest.New(dehydrationEpoch)
+
+	th, err := thresher.New("engine-R",
+		thresher.WithoutBanner(), thresher.WithoutStartupConfig(),
+		thresher.WithRepository(memrepo.New()),
+		thresher.WithClock(clk),
+		thresher.WithLeaseTTL(time.Minute))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	require.NoError(t, th.Run(ctx))
+
+	later := dehydrationEpoch.Add(10 * time.Minute)
+
+	require.Eventually(t, func() bool {
+		clk.Advance(10 * time.Second)
+
+		m := groupMembers(t, th)["engine-R"]
+
+		return !m.Heartbeat.Before(later) && m.Alive
+	}, 3*time.Second, 5*time.Millisecond,
+		"the record must keep up with the clock")
+
+	require.NoError(t, th.Shutdown(context.Background()))
+	require.Empty(t, groupMembers(t, th), "a clean stop leaves the group")
+}
+
+// TestGroupMembersJudgesEachRefresh: a member is judged alive against its
+// own refresh interval, not the asking engine's lease window, and a crashed
+// engine's lapsed leases still count as what it owns.
+func TestGroupMembersJudgesEachRefresh(t *testing.T) {
+	ctx := context.Background()
+	repo := memrepo.New()
+	clk := clocktest.New(dehydrationEpoch)
+
+	require.NoError(t, repo.RegisterGroup(ctx, "members-refresh"))
+
+	for id, m := range map[string]struct {
+		refresh time.Duration
+		since   time.Duration
+	}{
+		"engine-slow": {time.Minute, 2 * time.Minute},
+		"engine-fast": {10 * time.Second, 40 * time.Second},
+	} {
+		require.NoError(t, repo.PutMember(ctx, repository.GroupMember{
+			Started:   dehydrationEpoch.Add(-time.Hour),
+			Heartbeat: dehydrationEpoch.Add(-m.since),
+			Group:     "members-refresh",
+			EngineID:  id,
+			Refresh:   m.refresh,
+		}))
+	}
+
+	require.NoError(t, repo.Save(ctx, repository.InstanceRecord{
+		ID:     "orphan",
+		Group:  "members-refresh",
+		Status: repository.StatusActive,
+		Lease: repository.Lease{
+			Owner: "engine-fast", Incarnation: 1,
+			Expiry: dehydrationEpoch.Add(-time.Second),
+		},
+	}))
+
+	th, err := thresher.New("engine-Q",
+		thresher.WithoutBanner(), thresher.WithoutStartupConfig(),
+		thresher.WithRepository(repo),
+		thresher.WithEngineGroup("members-refresh"),
+		thresher.WithClock(clk),
+		thresher.WithLeaseTTL(time.Minute))
+	require.NoError(t, err)
+
+	m := groupMembers(t, th)
+	require.True(t, m["engine-slow"].Alive,
+		"three of its own minute-long refreshes haven't passed")
+	require.Equal(t, time.Minute, m["engine-slow"].Refresh)
+	require.False(t, m["engine-fast"].Alive,
+		"it missed three of its own refreshes")
+	require.Equal(t, 1, m["engine-fast"].Owned,
+		"a lapsed lease is still the crashed engine's")
+}
+
+// bareRepo hides the optional capabilities of the store it wraps.
+type bareRepo struct{ repository.Repository }
+
+func TestGroupMembersNeedsRegistry(t *testing.T) {
+	th, err := thresher.New("engine-M",
+		thresher.WithoutBanner(), thresher.WithoutStartupConfig())
+	require.NoError(t, err)
+
+	_, err = th.GroupMembers(context.Background())
+	require.ErrorContains(t, err, "WithRepository")
+
+	th, err = thresher.New("engine-M",
+		thresher.WithoutBanner(), thresher.WithoutStartupConfig(),
+		thresher.WithRepository(bareRepo{memrepo.New()}))
+	require.NoError(t, err)
+
+	_, err = th.GroupMembers(context.Background())
+	require.ErrorContains(t, err, "MembershipRegistry")
+}
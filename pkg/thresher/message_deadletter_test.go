@@ -0,0 +1,47 @@
+package thresher_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gobpm/pkg/clock/clocktest"
+	"github.com/dr-dobermann/gobpm/pkg/messaging"
+	"github.com/dr-dobermann/gobpm/pkg/messaging/membroker"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+	"github.com/dr-dobermann/gobpm/pkg/thresher"
+)
+
+// TestBrokerDeadLettersReachObservers verifies the engine binds its
+// Reporter into a broker that accepts one: a message that expires unclaimed
+// surfaces to an engine observer as an EventFlow/DeadLettered fact.
+func TestBrokerDeadLettersReachObservers(t *testing.T) {
+	ck := clocktest.New(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	broker := membroker.New(membroker.WithClock(ck))
+
+	th, err := thresher.New("engine-dlq",
+		thresher.WithoutBanner(), thresher.WithoutStartupConfig(),
+		thresher.WithMessageBroker(broker))
+	require.NoError(t, err)
+
+	fw := &factWatch{}
+	sub := th.Observe(fw)
+	t.Cleanup(sub.Cancel)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	require.NoError(t, th.Run(ctx))
+
+	require.NoError(t, broker.Publish(ctx, messaging.Envelope{
+		Name: "nobody listens", MessageID: "lost-1", TTL: time.Minute}))
+
+	ck.Advance(time.Minute)
+	require.Len(t, broker.DeadLetters(), 1)
+
+	require.Eventually(t, func() bool {
+		return fw.saw(observability.KindEventFlow,
+			observability.PhaseDeadLettered)
+	}, 2*time.Second, 5*time.Millisecond)
+}
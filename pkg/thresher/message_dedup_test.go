@@ -0,0 +1,335 @@
+package thresher_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gobpm/pkg/messaging"
+	"github.com/dr-dobermann/gobpm/pkg/messaging/membroker"
+	"github.com/dr-dobermann/gobpm/pkg/model/activities"
+	"github.com/dr-dobermann/gobpm/pkg/model/bpmncommon"
+	"github.com/dr-dobermann/gobpm/pkg/model/data"
+	"github.com/dr-dobermann/gobpm/pkg/model/data/values"
+	"github.com/dr-dobermann/gobpm/pkg/model/events"
+	"github.com/dr-dobermann/gobpm/pkg/model/flow"
+	"github.com/dr-dobermann/gobpm/pkg/model/foundation"
+	"github.com/dr-dobermann/gobpm/pkg/model/process"
+	"github.com/dr-dobermann/gobpm/pkg/model/service"
+	"github.com/dr-dobermann/gobpm/pkg/model/service/gooper"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+	"github.com/dr-dobermann/gobpm/pkg/repository/memrepo"
+	"github.com/dr-dobermann/gobpm/pkg/thresher"
+)
+
+// rawBroker is an in-memory broker with its own dedup window off, so a
+// redelivery reaches the engine and the engine-side gates are what is
+// tested.
+func rawBroker() messaging.MessageBroker {
+	return membroker.New(membroker.WithDedupWindow(0))
+}
+
+// paymentCatch builds one "payment received" message catch binding the
+// payload into item.
+func paymentCatch(t *testing.T, id, item string) *events.IntermediateCatchEvent {
+	t.Helper()
+
+	c, err := events.NewIntermediateCatchEvent(id,
+		events.MustMessageEventDefinition(
+			bpmncommon.MustMessage("payment received", data.MustItemDefinition(
+				values.NewVariable(""), foundation.WithID(item))), nil),
+		foundation.WithID(id))
+	require.NoError(t, err)
+
+	return c
+}
+
+// twoPaymentsProcess is msgWaitProcess with TWO payment catches in a row:
+//
+//	start("order placed", keyed) -> catch1 -> catch2 -> report(pay2_in) -> end
+//
+// report publishes what catch2 bound into pay2_in — a redelivery of catch1's message
+// taken by catch2 shows up there.
+func twoPaymentsProcess(
+	t *testing.T, key string, got chan<- string,
+) *process.Process {
+	t.Helper()
+
+	require.NoError(t, data.CreateDefaultStates())
+
+	proc, err := process.New(key, foundation.WithID(key))
+	require.NoError(t, err)
+
+	start, err := events.NewStartEvent("start",
+		events.WithMessageTrigger(events.MustMessageEventDefinition(
+			bpmncommon.MustMessage("order placed", data.MustItemDefinition(
+				values.NewVariable(""), foundation.WithID("order_in"))), nil)),
+		events.WithCorrelationKey(orderKeyFor(t, "order placed")),
+		foundation.WithID(key+"-start"))
+	require.NoError(t, err)
+
+	reportOp, err := gooper.New(key+"-report",
+		func(ctx context.Context, r service.DataReader,
+			_ *data.ItemDefinition) (*data.ItemDefinition, error) {
+			pay, err := r.GetDataByID("pay2_in")
+			if err != nil {
+				return nil, fmt.Errorf("read pay2_in: %w", err)
+			}
+
+			got <- fmt.Sprint(pay.Value().Get(ctx))
+
+			return nil, nil
+		})
+	require.NoError(t, err)
+
+	report, err := activities.NewServiceTask(key+"-report", reportOp,
+		activities.WithoutParams(), foundation.WithID(key+"-report"))
+	require.NoError(t, err)
+
+	end, err := events.NewEndEvent("end", foundation.WithID(key+"-end"))
+	require.NoError(t, err)
+
+	first, second := paymentCatch(t, key+"-catch1", "pay1_in"),
+		paymentCatch(t, key+"-catch2", "pay2_in")
+
+	for _, e := range []flow.Element{start, first, second, report, end} {
+		require.NoError(t, proc.Add(e))
+	}
+
+	link(t, start, first)
+	link(t, first, second)
+	link(t, second, report)
+	link(t, report, end)
+
+	return proc
+}
+
+// parkedAt reports whether a track parked on node.
+func (fw *factWatch) parkedAt(node string) bool {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	for _, f := range fw.facts {
+		if f.Kind == observability.KindNodeProgress &&
+			f.Phase == observability.PhaseParked && f.NodeID == node {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TestMessageRedeliveryReceivedOnce verifies the receiving side: a message
+// the instance already consumed is dropped when the broker delivers it
+// again — to a resident instance by its loop, to a dehydrated one before
+// the wake rebuilds it — and the next catch waits for a NEW message.
+func TestMessageRedeliveryReceivedOnce(t *testing.T) {
+	for _, dehydrate := range []bool{false, true} {
+		name := "resident"
+		if dehydrate {
+			name = "dehydrated"
+		}
+
+		t.Run(name, func(t *testing.T) {
+			key := "redeliver-" + name
+			got := make(chan string, 2)
+			broker := rawBroker()
+
+			opts := []thresher.Option{
+				thresher.WithoutBanner(), thresher.WithoutStartupConfig(),
+				thresher.WithMessageBroker(broker),
+			}
+			if dehydrate {
+				opts = append(opts, thresher.WithRepository(memrepo.New()))
+			}
+
+			th, err := thresher.New("engine-"+name, opts...)
+			require.NoError(t, err)
+
+			fw := &factWatch{}
+			sub := th.Observe(fw)
+			t.Cleanup(sub.Cancel)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			t.Cleanup(cancel)
+
+			_, err = th.RegisterProcess(twoPaymentsProcess(t, key, got))
+			require.NoError(t, err)
+			require.NoError(t, th.Run(ctx))
+
+			pay := func(id, payload string) {
+				require.NoError(t, broker.Publish(ctx, messaging.Envelope{
+					Name: "payment received", Payload: payload,
+					CorrelationKey: "ORD-1", MessageID: id}))
+			}
+
+			require.NoError(t, broker.Publish(ctx, messaging.Envelope{
+				Name: "order placed", Payload: "ORD-1",
+				CorrelationKey: "ORD-1", MessageID: "order-1"}))
+
+			// parked — and, with a repository, dehydrated — at catch1 and
+			// then at catch2.
+			settled := func(node string, dehydrations int) func() bool {
+				return func() bool {
+					if dehydrate {
+						return fw.count(observability.KindInstanceState,
+							observability.PhaseDehydrated) == dehydrations
+					}
+
+					return fw.parkedAt(node)
+				}
+			}
+
+			require.Eventually(t, settled(key+"-catch1", 1),
+				3*time.Second, 5*time.Millisecond)
+
+			pay("pay-1", "first")
+
+			require.Eventually(t, settled(key+"-catch2", 2),
+				3*time.Second, 5*time.Millisecond,
+				"the first payment must move the instance to catch2")
+
+			pay("pay-1", "first") // the broker redelivers
+
+			select {
+			case p := <-got:
+				t.Fatalf("catch2 took the redelivered message: %q", p)
+			case <-time.After(200 * time.Millisecond):
+			}
+
+			if dehydrate {
+				require.Equal(t, 1, fw.count(observability.KindInstanceState,
+					observability.PhaseHydrated),
+					"a redelivery must not rebuild the instance")
+			}
+
+			pay("pay-2", "second")
+
+			select {
+			case p := <-got:
+				require.Equal(t, "second", p)
+			case <-time.After(3 * time.Second):
+				t.Fatal("a new message must still reach catch2")
+			}
+		})
+	}
+}
+
+// ticketProcess is start("ticket opened", uncorrelated) -> catch("ticket
+// closed") -> end: every start message is a new conversation, so only the
+// MessageID tells a redelivery apart.
+func ticketProcess(t *testing.T, key string) *process.Process {
+	t.Helper()
+
+	require.NoError(t, data.CreateDefaultStates())
+
+	proc, err := process.New(key, foundation.WithID(key))
+	require.NoError(t, err)
+
+	start, err := events.NewStartEvent("start",
+		events.WithMessageTrigger(events.MustMessageEventDefinition(
+			bpmncommon.MustMessage("ticket opened", data.MustItemDefinition(
+				values.NewVariable(""), foundation.WithID("ticket_in"))), nil)),
+		foundation.WithID(key+"-start"))
+	require.NoError(t, err)
+
+	catch, err := events.NewIntermediateCatchEvent("await-close",
+		events.MustMessageEventDefinition(
+			bpmncommon.MustMessage("ticket closed", data.MustItemDefinition(
+				values.NewVariable(""), foundation.WithID("close_in"))), nil),
+		foundation.WithID(key+"-catch"))
+	require.NoError(t, err)
+
+	end, err := events.NewEndEvent("end", foundation.WithID(key+"-end"))
+	require.NoError(t, err)
+
+	for _, e := range []flow.Element{start, catch, end} {
+		require.NoError(t, proc.Add(e))
+	}
+
+	link(t, start, catch)
+	link(t, catch, end)
+
+	return proc
+}
+
+// TestMessageRedeliveryStartsOnce verifies the starting side: a redelivered
+// start message launches no second instance — on the engine that took it,
+// and on an engine that recovered the instance after a restart, where the
+// memory comes back from the checkpoint.
+func TestMessageRedeliveryStartsOnce(t *testing.T) {
+	const key = "ticket"
+
+	repo := memrepo.New()
+
+	boot := func(name string, broker messaging.MessageBroker) (
+		*thresher.Thresher, *factWatch,
+	) {
+		th, err := thresher.New(name,
+			thresher.WithoutBanner(), thresher.WithoutStartupConfig(),
+			thresher.WithRepository(repo),
+			thresher.WithEngineGroup(recoveryGroup),
+			thresher.WithLeaseTTL(80*time.Millisecond),
+			thresher.WithMessageBroker(broker))
+		require.NoError(t, err)
+
+		fw := &factWatch{}
+		sub := th.Observe(fw)
+		t.Cleanup(sub.Cancel)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+
+		_, err = th.RegisterProcess(ticketProcess(t, key))
+		require.NoError(t, err)
+		require.NoError(t, th.Run(ctx))
+
+		return th, fw
+	}
+
+	open := func(broker messaging.MessageBroker, id string) {
+		require.NoError(t, broker.Publish(context.Background(),
+			messaging.Envelope{Name: "ticket opened", Payload: id, MessageID: id}))
+	}
+
+	instances := func(th *thresher.Thresher) func() int {
+		return func() int { return len(th.Instances(thresher.InstancesAll)) }
+	}
+
+	broker1 := rawBroker()
+	th1, _ := boot("engine-1", broker1)
+
+	open(broker1, "ticket-1")
+	open(broker1, "ticket-1")
+	open(broker1, "ticket-2")
+
+	require.Eventually(t, func() bool { return instances(th1)() == 2 },
+		2*time.Second, 5*time.Millisecond)
+	require.Never(t, func() bool { return instances(th1)() > 2 },
+		200*time.Millisecond, 10*time.Millisecond,
+		"a redelivered start message must not launch a second instance")
+
+	time.Sleep(120 * time.Millisecond) // the lease lapses
+
+	// engine-2 listens on its own broker: engine-1 never sees what follows.
+	broker2 := rawBroker()
+	th2, fw2 := boot("engine-2", broker2)
+
+	require.Eventually(t, func() bool {
+		return fw2.count(observability.KindInstanceState,
+			observability.PhaseRecovered) == 2
+	}, 2*time.Second, 5*time.Millisecond)
+
+	open(broker2, "ticket-1")
+	require.Never(t, func() bool { return instances(th2)() > 2 },
+		200*time.Millisecond, 10*time.Millisecond,
+		"the recovered checkpoint must remember the start message")
+
+	open(broker2, "ticket-3")
+	require.Eventually(t, func() bool { return instances(th2)() == 3 },
+		2*time.Second, 5*time.Millisecond,
+		"a new start message still starts")
+}
@@ -0,0 +1,311 @@
+package thresher_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gobpm/internal/instance/checkpoint"
+	"github.com/dr-dobermann/gobpm/pkg/clock/clocktest"
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/messaging"
+	"github.com/dr-dobermann/gobpm/pkg/messaging/membroker"
+	"github.com/dr-dobermann/gobpm/pkg/model/activities"
+	"github.com/dr-dobermann/gobpm/pkg/model/bpmncommon"
+	"github.com/dr-dobermann/gobpm/pkg/model/data"
+	"github.com/dr-dobermann/gobpm/pkg/model/data/values"
+	"github.com/dr-dobermann/gobpm/pkg/model/events"
+	"github.com/dr-dobermann/gobpm/pkg/model/flow"
+	"github.com/dr-dobermann/gobpm/pkg/model/foundation"
+	"github.com/dr-dobermann/gobpm/pkg/model/process"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+	"github.com/dr-dobermann/gobpm/pkg/repository"
+	"github.com/dr-dobermann/gobpm/pkg/repository/memrepo"
+	"github.com/dr-dobermann/gobpm/pkg/thresher"
+)
+
+const outboxMsg = "order placed"
+
+// sendProc is start → send "order placed" → approve (UserTask) → end; the
+// message carries the order_out property.
+func sendProc(t *testing.T, key string) *process.Process {
+	t.Helper()
+
+	require.NoError(t, data.CreateDefaultStates())
+
+	p, err := process.New(key, foundation.WithID(key),
+		data.WithProperties(
+			data.MustProperty("order_out",
+				data.MustItemDefinition(values.NewVariable("ORD-7"),
+					foundation.WithID("order_out")),
+				data.ReadyDataState)))
+	require.NoError(t, err)
+
+	start, err := events.NewStartEvent("start",
+		foundation.WithID(key+"-start"))
+	require.NoError(t, err)
+
+	send, err := activities.NewSendTask("send-order",
+		bpmncommon.MustMessage(outboxMsg,
+			data.MustItemDefinition(values.NewVariable(""),
+				foundation.WithID("order_out"))),
+		activities.WithoutParams(),
+		foundation.WithID(key+"-send"))
+	require.NoError(t, err)
+
+	ut, err := activities.NewUserTask("approve",
+		activities.WithCandidateUsers("operator"),
+		activities.WithOutput("result", "string", true),
+		activities.WithoutParams(),
+		foundation.WithID(key+"-approve"))
+	require.NoError(t, err)
+
+	end, err := events.NewEndEvent("end", foundation.WithID(key+"-end"))
+	require.NoError(t, err)
+
+	for _, e := range []flow.Element{start, send, ut, end} {
+		require.NoError(t, p.Add(e))
+	}
+
+	link(t, start, send)
+	link(t, send, ut)
+	link(t, ut, end)
+
+	return p
+}
+
+// outboxOf reads the outbox of the instance's durable record.
+func outboxOf(
+	t *testing.T, repo repository.Repository, id string,
+) ([]checkpoint.OutboxRecord, bool) {
+	t.Helper()
+
+	rec, ok, err := repo.Load(context.Background(), id)
+	if err != nil || !ok {
+		return nil, false
+	}
+
+	doc, err := checkpoint.Unmarshal(rec.Payload)
+	if err != nil {
+		return nil, false
+	}
+
+	return doc.Outbox, true
+}
+
+// spyBroker records every publish attempt before handing it to the broker;
+// onPublish, when set, runs first.
+type spyBroker struct {
+	messaging.MessageBroker
+	onPublish func(messaging.Envelope)
+	mu        sync.Mutex
+	ids       []string
+}
+
+func (sb *spyBroker) Publish(ctx context.Context, e messaging.Envelope) error {
+	if sb.onPublish != nil {
+		sb.onPublish(e)
+	}
+
+	sb.mu.Lock()
+	sb.ids = append(sb.ids, e.MessageID)
+	sb.mu.Unlock()
+
+	return sb.MessageBroker.Publish(ctx, e)
+}
+
+func (sb *spyBroker) published() []string {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+
+	return append([]string(nil), sb.ids...)
+}
+
+// freezingRepo refuses every Save once frozen — the engine above it has
+// crashed as far as the record is concerned.
+type freezingRepo struct {
+	*memrepo.Repo
+	frozen atomic.Bool
+}
+
+func (fr *freezingRepo) Save(
+	ctx context.Context, rec repository.InstanceRecord,
+) error {
+	if fr.frozen.Load() {
+		return errors.New("engine crashed")
+	}
+
+	return fr.Repo.Save(ctx, rec)
+}
+
+// bootOutboxEngine boots a recovery-group outbox engine over repo
+// publishing through broker.
+func bootOutboxEngine(
+	t *testing.T, name string, repo repository.Repository,
+	broker messaging.MessageBroker, dist *annCollector, key string,
+) (*thresher.Thresher, *factWatch) {
+	t.Helper()
+
+	th, err := thresher.New(name,
+		thresher.WithoutBanner(), thresher.WithoutStartupConfig(),
+		thresher.WithRepository(repo),
+		thresher.WithEngineGroup(recoveryGroup),
+		thresher.WithLeaseTTL(80*time.Millisecond),
+		thresher.WithTaskDistributor(dist),
+		thresher.WithMessageBroker(broker),
+		thresher.WithMessageOutbox())
+	require.NoError(t, err)
+
+	fw := &factWatch{}
+	sub := th.Observe(fw)
+	t.Cleanup(sub.Cancel)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	_, err = th.RegisterProcess(sendProc(t, key))
+	require.NoError(t, err)
+	require.NoError(t, th.Run(ctx))
+
+	return th, fw
+}
+
+// recvOne waits for one envelope on sub.
+func recvOne(t *testing.T, sub messaging.Subscription) messaging.Envelope {
+	t.Helper()
+
+	select {
+	case env := <-sub.C():
+		return env
+	case <-time.After(2 * time.Second):
+		t.Fatal("the outbox never relayed the message")
+	}
+
+	return messaging.Envelope{}
+}
+
+// TestMessageOutboxRelaysAfterSave verifies an outbox send publishes only
+// once a saved checkpoint holds it, under a MessageID naming the instance,
+// and that the record stops holding it once it left.
+func TestMessageOutboxRelaysAfterSave(t *testing.T) {
+	const key = "outbox-relay"
+
+	repo := memrepo.New()
+
+	var heldAtPublish atomic.Bool
+
+	spy := &spyBroker{MessageBroker: membroker.New()}
+	spy.onPublish = func(e messaging.Envelope) {
+		id, _, _ := strings.Cut(e.MessageID, "/")
+		out, _ := outboxOf(t, repo, id)
+
+		for _, m := range out {
+			if m.ID == e.MessageID {
+				heldAtPublish.Store(true)
+			}
+		}
+	}
+
+	sub, err := spy.Subscribe(context.Background(), outboxMsg)
+	require.NoError(t, err)
+
+	dist := &annCollector{}
+	th, fw := bootOutboxEngine(t, "engine-1", repo, spy, dist, key)
+
+	h, err := th.StartLatest(key)
+	require.NoError(t, err)
+
+	env := recvOne(t, sub)
+	require.Equal(t, "ORD-7", env.Payload)
+	require.True(t, strings.HasPrefix(env.MessageID, h.ID()+"/"),
+		"the MessageID names the sending instance: %q", env.MessageID)
+	require.True(t, heldAtPublish.Load(),
+		"the message must leave only behind a saved checkpoint")
+
+	require.Eventually(t, func() bool {
+		out, ok := outboxOf(t, repo, h.ID())
+
+		return ok && len(out) == 0 && dist.count() == 1
+	}, 2*time.Second, 5*time.Millisecond,
+		"the record must stop holding a relayed message")
+	require.Equal(t, 1, fw.count(observability.KindEventFlow,
+		observability.PhaseRelayed))
+}
+
+// TestMessageOutboxRedeliveryDeduplicated verifies the crash window: the
+// engine relays, then dies before the record drops the message. The
+// recovering engine relays it again under the same MessageID, and the
+// in-memory broker's dedup window — on a test clock — delivers it once.
+func TestMessageOutboxRedeliveryDeduplicated(t *testing.T) {
+	const key = "outbox-crash"
+
+	base := memrepo.New()
+	crashing := &freezingRepo{Repo: base}
+
+	ck := clocktest.New(time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC))
+	spy := &spyBroker{
+		MessageBroker: membroker.New(
+			membroker.WithClock(ck), membroker.WithDedupWindow(time.Hour)),
+		// the first relay is the crash point: the record keeps the message.
+		onPublish: func(messaging.Envelope) { crashing.frozen.Store(true) },
+	}
+
+	sub, err := spy.Subscribe(context.Background(), outboxMsg)
+	require.NoError(t, err)
+
+	dist1 := &annCollector{}
+	th1, _ := bootOutboxEngine(t, "engine-1", crashing, spy, dist1, key)
+
+	h, err := th1.StartLatest(key)
+	require.NoError(t, err)
+
+	first := recvOne(t, sub)
+
+	out, ok := outboxOf(t, base, h.ID())
+	require.True(t, ok)
+	require.Len(t, out, 1, "the crash left the relayed message recorded")
+	require.Equal(t, first.MessageID, out[0].ID)
+
+	time.Sleep(120 * time.Millisecond) // the lease lapses
+
+	dist2 := &annCollector{}
+	_, fw2 := bootOutboxEngine(t, "engine-2", base, spy, dist2, key)
+
+	require.Eventually(t, func() bool {
+		return fw2.count(observability.KindEventFlow,
+			observability.PhaseRelayed) == 1
+	}, 2*time.Second, 5*time.Millisecond,
+		"the recovering engine relays the recorded message")
+	require.Equal(t, []string{first.MessageID, first.MessageID},
+		spy.published(), "the relay repeats under the recorded id")
+
+	select {
+	case dup := <-sub.C():
+		t.Fatalf("the broker delivered a duplicate: %+v", dup)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	require.Eventually(t, func() bool {
+		out, ok := outboxOf(t, base, h.ID())
+
+		return ok && len(out) == 0
+	}, 2*time.Second, 5*time.Millisecond)
+
+	// the same id past the window is a new message again.
+	ck.Advance(time.Hour)
+	require.NoError(t, spy.Publish(context.Background(), first))
+	require.Equal(t, first.MessageID, recvOne(t, sub).MessageID)
+}
+
+// TestMessageOutboxNeedsRepository verifies WithMessageOutbox refuses an
+// engine without a Repository to stage into.
+func TestMessageOutboxNeedsRepository(t *testing.T) {
+	_, err := thresher.New("outbox-norepo", thresher.WithMessageOutbox())
+	requireClass(t, err, errs.InvalidParameter)
+}
@@ -0,0 +1,538 @@
+package thresher
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/dr-dobermann/gobpm/internal/instance"
+	"github.com/dr-dobermann/gobpm/internal/instance/checkpoint"
+	"github.com/dr-dobermann/gobpm/internal/instance/snapshot"
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+	"github.com/dr-dobermann/gobpm/pkg/repository"
+)
+
+// NodeMapping maps a node of a migration's source version to its counterpart
+// in the target version. Build it with MapNode.
+type NodeMapping struct {
+	from, to string
+}
+
+// MapNode maps the source-version node from to the target-version node to.
+func MapNode(from, to string) NodeMapping {
+	return NodeMapping{from: from, to: to}
+}
+
+// MigrationPlan moves instances of one process version to another version of
+// the same process. A node the plan doesn't map keeps its id: a token at it
+// migrates to the target version's node of that id. Build it with
+// NewMigrationPlan and apply it with Thresher.Migrate.
+type MigrationPlan struct {
+	from, to *snapshot.Snapshot
+	nodes    map[string]string
+	key      string
+}
+
+// NewMigrationPlan builds the plan moving instances of the registration from
+// to the registration to — two versions of one process — with the node
+// mappings mm. Each mapping must name a node of its version, and a source
+// node is mapped once.
+func NewMigrationPlan(
+	from, to *ProcessRegistration, mm ...NodeMapping,
+) (*MigrationPlan, error) {
+	if from == nil || to == nil {
+		return nil, errs.New(
+			errs.M("NewMigrationPlan: both registrations are required"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	if from.key != to.key || from.version == to.version {
+		return nil, errs.New(
+			errs.M("NewMigrationPlan: a plan moves between two versions of "+
+				"one process, not %s v%d → %s v%d",
+				from.key, from.version, to.key, to.version),
+			errs.C(errorClass, errs.InvalidParameter))
+	}
+
+	p := &MigrationPlan{
+		from:  from.snapshot,
+		to:    to.snapshot,
+		nodes: make(map[string]string, len(mm)),
+		key:   from.key,
+	}
+
+	for _, m := range mm {
+		if err := p.addMapping(m); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// addMapping checks and records one node mapping.
+func (p *MigrationPlan) addMapping(m NodeMapping) error {
+	from, to := strings.TrimSpace(m.from), strings.TrimSpace(m.to)
+	if from == "" || to == "" {
+		return errs.New(
+			errs.M("NewMigrationPlan: a mapping needs both node ids"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	if _, dup := p.nodes[from]; dup {
+		return errs.New(
+			errs.M("NewMigrationPlan: node %q is mapped twice", from),
+			errs.C(errorClass, errs.DuplicateObject))
+	}
+
+	for _, side := range []struct {
+		s  *snapshot.Snapshot
+		id string
+	}{{p.from, from}, {p.to, to}} {
+		if _, ok := side.s.NodeByID(side.id); !ok {
+			return errs.New(
+				errs.M("NewMigrationPlan: version %d of %q has no node %q",
+					side.s.Version, p.key, side.id),
+				errs.C(errorClass, errs.ObjectNotFound))
+		}
+	}
+
+	p.nodes[from] = to
+
+	return nil
+}
+
+// Key returns the process key the plan migrates within.
+func (p *MigrationPlan) Key() string { return p.key }
+
+// From returns the version the plan migrates instances from.
+func (p *MigrationPlan) From() int { return p.from.Version }
+
+// To returns the version the plan migrates instances to.
+func (p *MigrationPlan) To() int { return p.to.Version }
+
+// TokenMove is one token a migration moves to another node: its track, the
+// source-version node it waits at and the target-version node it waits at
+// after the migration.
+type TokenMove struct {
+	TrackID string
+	From    string
+	To      string
+}
+
+// InstanceMigration is one instance's outcome in a MigrationReport: the
+// tokens the plan moves — a token at a node that keeps its id isn't listed —
+// or the reason the instance can't migrate.
+type InstanceMigration struct {
+	Err        error
+	InstanceID string
+	Moves      []TokenMove
+}
+
+// MigrationReport is the per-instance outcome of ValidateMigration or
+// Migrate, in the order the instances were named.
+type MigrationReport struct {
+	Instances []InstanceMigration
+}
+
+// Err returns the first instance's failure, or nil when every instance
+// migrates.
+func (r MigrationReport) Err() error {
+	for _, im := range r.Instances {
+		if im.Err != nil {
+			return im.Err
+		}
+	}
+
+	return nil
+}
+
+// ValidateMigration is Migrate's dry run: it checks the plan against each of
+// the instances instanceIDs and reports the tokens it would move, or why an
+// instance can't migrate, changing nothing. The returned error is the plan's
+// own — a refused instance is reported, not returned.
+func (t *Thresher) ValidateMigration(
+	ctx context.Context, plan *MigrationPlan, instanceIDs ...string,
+) (MigrationReport, error) {
+	if err := t.checkMigrationPlan("ValidateMigration", plan, instanceIDs); err != nil {
+		return MigrationReport{}, err
+	}
+
+	var r MigrationReport
+
+	for _, id := range instanceIDs {
+		im := InstanceMigration{InstanceID: id}
+
+		if planned, err := t.planInstanceMigration(ctx, plan, id); err != nil {
+			im.Err = err
+		} else {
+			im.Moves = tokenMoves(planned.moves)
+		}
+
+		r.Instances = append(r.Instances, im)
+	}
+
+	return r, nil
+}
+
+// Migrate moves the instances instanceIDs to the plan's target version. Each
+// must be an instance of the plan's source version this engine tracks, and
+// released — dehydrated, parked on an incident, or suspended: a running
+// instance is refused, so suspend it first.
+//
+// The whole set is validated first, and a refused instance fails the call
+// with nothing migrated; the report then tells which and why. Each instance
+// is then migrated on its own: its checkpoint is rewritten to the target
+// version — the version pin and its tokens' nodes — and it is rebuilt from
+// it exactly as a wake rebuilds it, so its subscriptions, timers, tasks and
+// boundary events re-arm over the target version. A moved UserTask token is
+// announced again under a new task id, and the old task is withdrawn. A
+// suspended instance stays suspended; a trigger it held for a token that
+// moved is dropped with the token's wait. Each migrated instance reports a
+// Migrated InstanceState fact.
+//
+// Refused are the instances whose state a node mapping can't carry: an
+// in-flight call activity, multi-instance or loop iteration or compensation,
+// a token moved to a node of another kind or into other sub-process scopes,
+// a token moved away from a join it waits at, an open sub-process mapped to
+// another node, and a token at a node the target version lacks.
+func (t *Thresher) Migrate(
+	ctx context.Context, plan *MigrationPlan, instanceIDs ...string,
+) (MigrationReport, error) {
+	r, err := t.ValidateMigration(ctx, plan, instanceIDs...)
+	if err != nil {
+		return r, err
+	}
+
+	if err := r.Err(); err != nil {
+		return r, err
+	}
+
+	for i := range r.Instances {
+		im := &r.Instances[i]
+		im.Moves, im.Err = t.migrateOne(ctx, plan, im.InstanceID)
+	}
+
+	return r, r.Err()
+}
+
+// migrateOne migrates the released instance id. The triggers it held while
+// suspended ride the rewritten checkpoint.
+func (t *Thresher) migrateOne(
+	ctx context.Context, plan *MigrationPlan, id string,
+) ([]TokenMove, error) {
+	moves, err := t.migrateLatched(ctx, plan, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return tokenMoves(moves), nil
+}
+
+// checkMigrationPlan refuses a call with no plan or instances, a plan whose
+// versions this engine no longer holds, and a stopped engine.
+func (t *Thresher) checkMigrationPlan(
+	op string, plan *MigrationPlan, instanceIDs []string,
+) error {
+	if plan == nil || len(instanceIDs) == 0 {
+		return errs.New(
+			errs.M("%s: a plan and at least one instance are required", op),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	if engCtx, running := t.engineContext(); !running || engCtx.Err() != nil {
+		return t.errEngineNotRunning(op)
+	}
+
+	for _, s := range []*snapshot.Snapshot{plan.from, plan.to} {
+		if t.snapshotForVersionLocked(plan.key, s.Version) != s {
+			return errs.New(
+				errs.M("%s: version %d of %q isn't registered with this engine",
+					op, s.Version, plan.key),
+				errs.C(errorClass, errs.ObjectNotFound))
+		}
+	}
+
+	return nil
+}
+
+// instanceMigration is one released instance's planned rewrite: its record,
+// the rewritten document, the tokens it moves and the held triggers it
+// drops.
+type instanceMigration struct {
+	rec     repository.InstanceRecord
+	doc     *checkpoint.Document
+	moves   []instance.TokenMove
+	dropped []checkpoint.HeldRecord
+}
+
+// planInstanceMigration loads the checkpoint of the released instance id and
+// rewrites it to the plan's target version.
+func (t *Thresher) planInstanceMigration(
+	ctx context.Context, plan *MigrationPlan, id string,
+) (instanceMigration, error) {
+	var im instanceMigration
+
+	inst, err := t.instanceByID(id)
+	if err != nil {
+		return im, err
+	}
+
+	if !inst.Released() {
+		return im, errs.New(
+			errs.M("can't migrate instance %q: it is %s and holds its "+
+				"goroutines — suspend it first", id, inst.State()),
+			errs.C(errorClass, errs.InvalidState),
+			errs.D(observability.AttrInstanceID, id))
+	}
+
+	rec, ok, err := t.cfg.Repository().Load(ctx, id)
+	if err != nil || !ok {
+		return im, errs.New(
+			errs.M("can't migrate instance %q: it has no checkpoint", id),
+			errs.C(errorClass, errs.ObjectNotFound),
+			errs.D(observability.AttrInstanceID, id),
+			errs.E(err))
+	}
+
+	doc, err := t.openCheckpoint(ctx, rec.ID, rec.Payload)
+	if err != nil {
+		return im, err
+	}
+
+	if doc.ProcessID != plan.key || doc.Version != plan.from.Version {
+		return im, errs.New(
+			errs.M("can't migrate instance %q: it runs %s v%d, the plan "+
+				"migrates %s v%d", id, doc.ProcessID, doc.Version,
+				plan.key, plan.from.Version),
+			errs.C(errorClass, errs.InvalidParameter),
+			errs.D(observability.AttrInstanceID, id))
+	}
+
+	im.rec, im.doc = rec, doc
+	im.moves, im.dropped, err = instance.MigrateDocument(
+		doc, plan.from, plan.to, plan.nodes)
+
+	return im, err
+}
+
+// migrateLatched migrates the released instance id under its wake latch: the
+// rewritten checkpoint is saved, the waits the old version holds are let go,
+// and the instance is rebuilt from the rewritten checkpoint. A rebuild that
+// fails puts the original checkpoint back and rebuilds the instance from it,
+// so a refused migration leaves the instance as it was. It returns the moves.
+func (t *Thresher) migrateLatched(
+	ctx context.Context, plan *MigrationPlan, id string,
+) ([]instance.TokenMove, error) {
+	if err := t.awaitClaim(id, "migrate"); err != nil {
+		return nil, err
+	}
+
+	defer t.releaseWake(id)
+
+	im, err := t.planInstanceMigration(ctx, plan, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// The rebuild needs a running engine; refuse here, with nothing
+	// changed, rather than after the waits are gone.
+	if engCtx, running := t.engineContext(); !running || engCtx.Err() != nil {
+		return nil, t.errEngineNotRunning("Migrate")
+	}
+
+	if t.draining.Load() {
+		return nil, t.errEngineDraining("Migrate")
+	}
+
+	raw, err := t.sealCheckpoint(ctx, id, im.doc)
+	if err != nil {
+		return nil, err
+	}
+
+	original := im.rec.Payload
+
+	im.rec.Payload = raw
+	if err := t.cfg.Repository().Save(ctx, im.rec); err != nil {
+		return nil, errs.New(
+			errs.M("can't migrate instance %q: its checkpoint doesn't save", id),
+			errs.C(errorClass, errs.OperationFailed),
+			errs.D(observability.AttrInstanceID, id),
+			errs.E(err))
+	}
+
+	// ONLY after the save: the held waits are the released instance's way
+	// back, and they belong to the old version — the rebuild re-takes every
+	// one of them over the new. Holds are keyed by track and definition, so
+	// they can't outlive the rebuild that re-takes them.
+	t.releaseDocWaits(id, im.doc)
+
+	if err := t.rebuildAndContinue(id, nil); err != nil {
+		return nil, t.rollBackMigration(ctx, id, im.doc, original, err)
+	}
+
+	t.withdrawMovedTasks(ctx, im.moves)
+
+	inst, err := t.instanceByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	reportDroppedHeld(inst, im.dropped)
+
+	inst.Report(observability.Fact{
+		Kind:  observability.KindInstanceState,
+		Phase: observability.PhaseMigrated,
+		Details: map[string]string{
+			observability.AttrProcessID: plan.key,
+			"from_version":              strconv.Itoa(plan.from.Version),
+			observability.AttrVersion:   strconv.Itoa(plan.to.Version),
+			"tokens":                    strconv.Itoa(len(im.moves)),
+		},
+	})
+
+	return im.moves, nil
+}
+
+// releaseDocWaits lets go the waits every track of doc holds.
+func (t *Thresher) releaseDocWaits(id string, doc *checkpoint.Document) {
+	for i := range doc.Tracks {
+		t.ReleaseWaits(id, doc.Tracks[i].ID)
+	}
+}
+
+// rollBackMigration undoes the migration of instance id whose rebuild from
+// the rewritten document doc failed with cause: the waits the failed rebuild
+// took are let go, the original checkpoint payload is saved back and the
+// instance is rebuilt from it. It returns the migration's error; an instance
+// the rollback can't restore is also reported as failed.
+func (t *Thresher) rollBackMigration(
+	ctx context.Context,
+	id string,
+	doc *checkpoint.Document,
+	original []byte,
+	cause error,
+) error {
+	failed := errs.New(
+		errs.M("migrate: the instance %q doesn't rebuild", id),
+		errs.C(errorClass, errs.OperationFailed),
+		errs.D(observability.AttrInstanceID, id),
+		errs.E(cause))
+
+	t.releaseDocWaits(id, doc)
+
+	err := t.restoreCheckpoint(ctx, id, original)
+	if err == nil {
+		err = t.rebuildAndContinue(id, nil)
+	}
+
+	if err != nil {
+		err = errs.New(
+			errs.M("migrate: the instance %q doesn't roll back", id),
+			errs.C(errorClass, errs.OperationFailed),
+			errs.D(observability.AttrInstanceID, id),
+			errs.E(errors.Join(failed, err)))
+
+		t.cfg.logger.Warn("migrate: a failed migration doesn't roll back",
+			observability.AttrInstanceID, id, observability.AttrError, err.Error())
+
+		t.producer.Report(observability.Fact{
+			Kind:  observability.KindInstanceState,
+			Phase: observability.PhaseFailed,
+			Details: map[string]string{
+				observability.AttrInstanceID: id,
+				"reason":                     "migrate",
+				observability.AttrError:      err.Error(),
+			},
+		})
+
+		return err
+	}
+
+	return failed
+}
+
+// restoreCheckpoint saves payload back as instance id's checkpoint. The
+// record is loaded afresh: the failed rebuild's claim moved its version and
+// lease.
+func (t *Thresher) restoreCheckpoint(
+	ctx context.Context, id string, payload []byte,
+) error {
+	repo := t.cfg.Repository()
+
+	rec, ok, err := repo.Load(ctx, id)
+	if err != nil || !ok {
+		return errs.New(
+			errs.M("the checkpoint of instance %q vanished", id),
+			errs.C(errorClass, errs.ObjectNotFound),
+			errs.D(observability.AttrInstanceID, id),
+			errs.E(err))
+	}
+
+	rec.Payload = payload
+
+	return repo.Save(ctx, rec)
+}
+
+// reportDroppedHeld reports each held trigger a migration dropped with the
+// wait of the token that moved: a Dropped EventFlow fact the operator can
+// re-publish it from.
+func reportDroppedHeld(inst *instance.Instance, dropped []checkpoint.HeldRecord) {
+	for _, rec := range dropped {
+		details := map[string]string{
+			"trigger":                 rec.Trigger,
+			observability.AttrTrackID: rec.TrackID,
+			"reason":                  "migrated",
+		}
+
+		if rec.MessageID != "" {
+			details[observability.AttrMessageID] = rec.MessageID
+		}
+
+		if rec.Signal != "" {
+			details[observability.AttrSignal] = rec.Signal
+		}
+
+		inst.Report(observability.Fact{
+			Kind:    observability.KindEventFlow,
+			Phase:   observability.PhaseDropped,
+			NodeID:  rec.NodeID,
+			Details: details,
+		})
+	}
+}
+
+// withdrawMovedTasks withdraws the human tasks of the moved UserTask tokens:
+// each is announced again at its new node. A failure only leaves a task the
+// engine no longer routes, so it is logged, never returned.
+func (t *Thresher) withdrawMovedTasks(
+	ctx context.Context, moves []instance.TokenMove,
+) {
+	for _, mv := range moves {
+		if mv.WithdrawnTask == "" {
+			continue
+		}
+
+		if err := t.taskDist.Withdraw(ctx, mv.WithdrawnTask); err != nil {
+			t.cfg.logger.Warn("migrate: a moved task doesn't withdraw",
+				observability.AttrTaskID, mv.WithdrawnTask,
+				observability.AttrError, err.Error())
+		}
+	}
+}
+
+// tokenMoves converts the instance-level moves into the report's.
+func tokenMoves(moves []instance.TokenMove) []TokenMove {
+	if len(moves) == 0 {
+		return nil
+	}
+
+	mm := make([]TokenMove, 0, len(moves))
+	for _, mv := range moves {
+		mm = append(mm, TokenMove{TrackID: mv.TrackID, From: mv.From, To: mv.To})
+	}
+
+	return mm
+}
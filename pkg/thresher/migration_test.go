@@ -0,0 +1,350 @@
+package thresher_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/model/activities"
+	"github.com/dr-dobermann/gobpm/pkg/model/data"
+	"github.com/dr-dobermann/gobpm/pkg/model/events"
+	"github.com/dr-dobermann/gobpm/pkg/model/flow"
+	"github.com/dr-dobermann/gobpm/pkg/model/foundation"
+	"github.com/dr-dobermann/gobpm/pkg/model/process"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+	"github.com/dr-dobermann/gobpm/pkg/repository"
+	"github.com/dr-dobermann/gobpm/pkg/repository/memrepo"
+	"github.com/dr-dobermann/gobpm/pkg/thresher"
+)
+
+// reviewProc builds the next version of utProc: start → review(UserTask) →
+// audit → end, where audit flags its run.
+func reviewProc(t *testing.T, key string, audit *atomic.Bool) *process.Process {
+	t.Helper()
+
+	require.NoError(t, data.CreateDefaultStates())
+
+	p, err := process.New(key, foundation.WithID(key))
+	require.NoError(t, err)
+
+	start, err := events.NewStartEvent("start",
+		foundation.WithID(key+"-start"))
+	require.NoError(t, err)
+
+	ut, err := activities.NewUserTask("review",
+		activities.WithCandidateUsers("operator"),
+		activities.WithOutput("result", "string", true),
+		activities.WithoutParams(),
+		foundation.WithID(key+"-review"))
+	require.NoError(t, err)
+
+	at := laneTask(t, "audit", audit)
+
+	end, err := events.NewEndEvent("end", foundation.WithID(key+"-end"))
+	require.NoError(t, err)
+
+	for _, e := range []flow.Element{start, ut, at, end} {
+		require.NoError(t, p.Add(e))
+	}
+
+	link(t, start, ut)
+	link(t, ut, at)
+	link(t, at, end)
+
+	return p
+}
+
+// migrationEngine boots a dehydrating engine with utProc as version 1 of key
+// and next as version 2, and starts an instance of version 1 parked on its
+// task.
+func migrationEngine(
+	t *testing.T, key string, next *process.Process,
+) (*thresher.Thresher, *factWatch, *annCollector,
+	[2]*thresher.ProcessRegistration, *thresher.InstanceHandle,
+) {
+	t.Helper()
+
+	return migrationEngineOver(t, key, next, memrepo.New())
+}
+
+// migrationEngineOver is migrationEngine over repo.
+func migrationEngineOver(
+	t *testing.T, key string, next *process.Process, repo repository.Repository,
+) (*thresher.Thresher, *factWatch, *annCollector,
+	[2]*thresher.ProcessRegistration, *thresher.InstanceHandle,
+) {
+	t.Helper()
+
+	dist := &annCollector{}
+
+	th, fw, cancel := bootTaskEngine(t, "engine-"+key, repo, dist,
+		utProc(t, key))
+	t.Cleanup(cancel)
+
+	v2, err := th.RegisterProcess(next)
+	require.NoError(t, err)
+
+	regs := [2]*thresher.ProcessRegistration{th.Registrations(key)[0], v2}
+
+	h, err := th.StartProcess(regs[0])
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return fw.count(observability.KindInstanceState,
+			observability.PhaseDehydrated) >= 1
+	}, 3*time.Second, 10*time.Millisecond)
+
+	return th, fw, dist, regs, h
+}
+
+// TestMigrateMovesDehydratedTask verifies a dry run reports the move and
+// changes nothing, and a migration re-pins a dehydrated instance to the next
+// version: its task moves to the mapped node under a new id, and the instance
+// completes through the next version's graph.
+func TestMigrateMovesDehydratedTask(t *testing.T) {
+	var audit atomic.Bool
+
+	key := "mig-move"
+	th, fw, dist, regs, h := migrationEngine(t, key,
+		reviewProc(t, key, &audit))
+
+	plan, err := thresher.NewMigrationPlan(regs[0], regs[1],
+		thresher.MapNode(key+"-approve", key+"-review"))
+	require.NoError(t, err)
+	require.Equal(t, 1, plan.From())
+	require.Equal(t, 2, plan.To())
+
+	ctx := context.Background()
+
+	r, err := th.ValidateMigration(ctx, plan, h.ID())
+	require.NoError(t, err)
+	require.NoError(t, r.Err())
+	require.Len(t, r.Instances, 1)
+	require.Len(t, r.Instances[0].Moves, 1)
+	require.Equal(t, key+"-approve", r.Instances[0].Moves[0].From)
+	require.Equal(t, key+"-review", r.Instances[0].Moves[0].To)
+	require.Equal(t, 1, dist.count(), "a dry run changes nothing")
+
+	oldTask := dist.taskIDs()[0]
+
+	r, err = th.Migrate(ctx, plan, h.ID())
+	require.NoError(t, err)
+	require.Len(t, r.Instances[0].Moves, 1)
+
+	require.Eventually(t, func() bool {
+		return fw.count(observability.KindInstanceState,
+			observability.PhaseMigrated) == 1 &&
+			fw.count(observability.KindInstanceState,
+				observability.PhaseDehydrated) >= 2
+	}, 3*time.Second, 10*time.Millisecond)
+
+	ids := dist.taskIDs()
+	newTask := ids[len(ids)-1]
+	require.NotEqual(t, oldTask, newTask)
+
+	operator := utActor{id: "operator"}
+
+	_, err = th.Take(ctx, oldTask, operator)
+	require.Error(t, err, "the moved task is withdrawn")
+
+	view, err := th.Take(ctx, newTask, operator)
+	require.NoError(t, err)
+	require.Equal(t, key+"-review", view.NodeID)
+
+	require.NoError(t, th.Claim(ctx, newTask, operator))
+	require.NoError(t, th.Complete(ctx, newTask, operator,
+		resultOutput("approved")))
+
+	waitCompleted(t, h)
+	require.True(t, audit.Load(), "the instance ran the next version")
+}
+
+// claimFailingRepo, once armed, saves the first record and refuses the next
+// three Saves — every claim of the rebuild that follows a migration's save.
+type claimFailingRepo struct {
+	*memrepo.Repo
+	armed atomic.Bool
+	saves atomic.Int32
+}
+
+func (r *claimFailingRepo) Save(
+	ctx context.Context, rec repository.InstanceRecord,
+) error {
+	if r.armed.Load() {
+		if n := r.saves.Add(1); n > 1 && n <= 4 {
+			return errors.New("claim refused")
+		}
+	}
+
+	return r.Repo.Save(ctx, rec)
+}
+
+// TestMigrateRollsBackFailedRebuild verifies an instance that doesn't rebuild
+// over the target version is put back on its original checkpoint: Migrate
+// fails, no Migrated fact is reported, and the instance completes through
+// the source version.
+func TestMigrateRollsBackFailedRebuild(t *testing.T) {
+	var audit atomic.Bool
+
+	key := "mig-rollback"
+	repo := &claimFailingRepo{Repo: memrepo.New()}
+	th, fw, dist, regs, h := migrationEngineOver(t, key,
+		reviewProc(t, key, &audit), repo)
+
+	plan, err := thresher.NewMigrationPlan(regs[0], regs[1],
+		thresher.MapNode(key+"-approve", key+"-review"))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	repo.armed.Store(true)
+
+	_, err = th.Migrate(ctx, plan, h.ID())
+	requireClass(t, err, errs.OperationFailed)
+
+	repo.armed.Store(false)
+
+	require.Zero(t, fw.count(observability.KindInstanceState,
+		observability.PhaseMigrated))
+	require.Zero(t, fw.count(observability.KindInstanceState,
+		observability.PhaseFailed), "the rollback restored the instance")
+
+	ids := dist.taskIDs()
+	task := ids[len(ids)-1]
+	operator := utActor{id: "operator"}
+
+	view, err := th.Take(ctx, task, operator)
+	require.NoError(t, err)
+	require.Equal(t, key+"-approve", view.NodeID)
+	require.NoError(t, th.Claim(ctx, task, operator))
+	require.NoError(t, th.Complete(ctx, task, operator,
+		resultOutput("approved")))
+
+	waitCompleted(t, h)
+	require.False(t, audit.Load(), "the instance ran the source version")
+}
+
+// TestMigrationPlanRefusals verifies the plans NewMigrationPlan refuses.
+func TestMigrationPlanRefusals(t *testing.T) {
+	key := "mig-plan"
+	_, _, _, regs, _ := migrationEngine(t, key, utProc(t, key))
+
+	_, err := thresher.NewMigrationPlan(nil, regs[1])
+	requireClass(t, err, errs.EmptyNotAllowed)
+
+	_, err = thresher.NewMigrationPlan(regs[0], regs[0])
+	requireClass(t, err, errs.InvalidParameter)
+
+	_, err = thresher.NewMigrationPlan(regs[0], regs[1],
+		thresher.MapNode("ghost", key+"-approve"))
+	requireClass(t, err, errs.ObjectNotFound)
+
+	_, err = thresher.NewMigrationPlan(regs[0], regs[1],
+		thresher.MapNode(key+"-approve", " "))
+	requireClass(t, err, errs.EmptyNotAllowed)
+
+	_, err = thresher.NewMigrationPlan(regs[0], regs[1],
+		thresher.MapNode(key+"-approve", key+"-approve"),
+		thresher.MapNode(key+"-approve", key+"-end"))
+	requireClass(t, err, errs.DuplicateObject)
+}
+
+// TestMigrateRefusals verifies an instance the plan can't carry is reported
+// by the dry run and fails Migrate with nothing migrated: a token whose node
+// the next version lacks, and a token mapped to a node of another kind.
+func TestMigrateRefusals(t *testing.T) {
+	var audit atomic.Bool
+
+	key := "mig-refuse"
+	next := reviewProc(t, key, &audit)
+	th, fw, dist, regs, h := migrationEngine(t, key, next)
+
+	ctx := context.Background()
+
+	unmapped, err := thresher.NewMigrationPlan(regs[0], regs[1])
+	require.NoError(t, err)
+
+	r, err := th.ValidateMigration(ctx, unmapped, h.ID())
+	require.NoError(t, err)
+	requireClass(t, r.Instances[0].Err, errs.ObjectNotFound)
+
+	var auditID string
+
+	for _, n := range next.Nodes() {
+		if n.Name() == "audit" {
+			auditID = n.ID()
+		}
+	}
+
+	wrongKind, err := thresher.NewMigrationPlan(regs[0], regs[1],
+		thresher.MapNode(key+"-approve", auditID))
+	require.NoError(t, err)
+
+	_, err = th.Migrate(ctx, wrongKind, h.ID())
+	requireClass(t, err, errs.InvalidParameter)
+
+	_, err = th.Migrate(ctx, unmapped, h.ID())
+	requireClass(t, err, errs.ObjectNotFound)
+
+	require.Zero(t, fw.count(observability.KindInstanceState,
+		observability.PhaseMigrated))
+
+	// the instance still runs version 1: its task completes there.
+	task := dist.taskIDs()[0]
+	operator := utActor{id: "operator"}
+
+	_, err = th.Take(ctx, task, operator)
+	require.NoError(t, err)
+	require.NoError(t, th.Claim(ctx, task, operator))
+	require.NoError(t, th.Complete(ctx, task, operator,
+		resultOutput("approved")))
+
+	waitCompleted(t, h)
+	require.False(t, audit.Load())
+}
+
+// TestMigrateRefusesRunningInstance verifies an instance holding its
+// goroutines is refused until it is released.
+func TestMigrateRefusesRunningInstance(t *testing.T) {
+	var ran modRan
+
+	proc, _ := modProc(t, "mig-running", &ran)
+
+	th, err := thresher.New("test-mig-running", thresher.WithoutBanner())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	require.NoError(t, th.Run(ctx))
+
+	v1, err := th.RegisterProcess(proc)
+	require.NoError(t, err)
+
+	v2, err := th.RegisterProcess(proc)
+	require.NoError(t, err)
+
+	h, err := th.StartProcess(v1)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		tt := h.Tokens()
+
+		return len(tt) == 1 && tt[0].State == thresher.TokenWaitForEvent
+	}, 2*time.Second, 10*time.Millisecond)
+
+	plan, err := thresher.NewMigrationPlan(v1, v2)
+	require.NoError(t, err)
+
+	r, err := th.ValidateMigration(ctx, plan, h.ID())
+	require.NoError(t, err)
+	requireClass(t, r.Instances[0].Err, errs.InvalidState)
+
+	_, err = th.ValidateMigration(ctx, plan)
+	requireClass(t, err, errs.EmptyNotAllowed)
+}
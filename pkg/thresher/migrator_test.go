@@ -6,6 +6,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/dr-dobermann/gobpm/pkg/datastore/memstore"
 	"github.com/dr-dobermann/gobpm/pkg/repository/memrepo"
 	"github.com/dr-dobermann/gobpm/pkg/thresher"
 )
@@ -83,3 +84,57 @@ func TestMigratorHook(t *testing.T) {
 		require.NoError(t, th.Run(ctx))
 	})
 }
+
+// migratingStore wraps memstore with a Migrate the tests observe.
+type migratingStore struct {
+	*memstore.Store
+	migrateErr error
+	calls      *[]string
+	ref        string
+}
+
+func (m *migratingStore) Migrate(context.Context) error {
+	*m.calls = append(*m.calls, m.ref)
+
+	return m.migrateErr
+}
+
+func TestDataStoreMigratorHook(t *testing.T) {
+	t.Run("Run migrates every Migrator store in ref order", func(t *testing.T) {
+		var calls []string
+
+		th, err := thresher.New("mig-ds-1",
+			thresher.WithoutBanner(), thresher.WithoutStartupConfig(),
+			thresher.WithDataStore("stock",
+				&migratingStore{Store: memstore.New(), calls: &calls, ref: "stock"}),
+			thresher.WithDataStore("plain", memstore.New()),
+			thresher.WithDataStore("orders",
+				&migratingStore{Store: memstore.New(), calls: &calls, ref: "orders"}))
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		require.NoError(t, th.Run(ctx))
+		require.Equal(t, []string{"orders", "stock"}, calls)
+	})
+
+	t.Run("a failing store migration aborts the start", func(t *testing.T) {
+		var calls []string
+
+		th, err := thresher.New("mig-ds-2",
+			thresher.WithoutBanner(), thresher.WithoutStartupConfig(),
+			thresher.WithDataStore("orders", &migratingStore{
+				Store: memstore.New(), calls: &calls, ref: "orders",
+				migrateErr: context.DeadlineExceeded,
+			}))
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		err = th.Run(ctx)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), `DataStore "orders"`)
+	})
+}
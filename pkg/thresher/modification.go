@@ -0,0 +1,114 @@
+package thresher
+
+import (
+	"context"
+	"strings"
+
+	"github.com/dr-dobermann/gobpm/internal/instance"
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+)
+
+// Modification is one instruction of an instance modification — an
+// operator's repair of a token parked on the wrong node. Build it with
+// CancelAt, StartBefore or StartAfter.
+type Modification struct {
+	nodeID string
+	op     instance.ModificationOp
+}
+
+// CancelAt cancels every token at the node nodeID, exactly as an
+// interrupting boundary cancels its activity: a waiting task is withdrawn, a
+// sub-process's inner tokens are canceled with it and an incident open there
+// closes as overtaken.
+func CancelAt(nodeID string) Modification {
+	return Modification{nodeID: nodeID, op: instance.ModCancel}
+}
+
+// StartBefore starts a new token that enters the node nodeID. A node nested
+// in an embedded sub-process is entered through the sub-process scopes
+// already open, and those that are not are opened on the way — with the
+// token, not the sub-process's start, as their only entry.
+func StartBefore(nodeID string) Modification {
+	return Modification{nodeID: nodeID, op: instance.ModStartBefore}
+}
+
+// StartAfter starts a new token on the single outgoing flow of the node
+// nodeID, as if the node had just completed — without running it. A node
+// with no or several outgoing flows is refused.
+func StartAfter(nodeID string) Modification {
+	return Modification{nodeID: nodeID, op: instance.ModStartAfter}
+}
+
+// Modify applies the instructions mods to the instance in one step on its
+// loop, so no token moves between them. Every instruction is checked against
+// the instance's process version and its live tokens first; a refused one —
+// an unknown node, a cancel where no token is or where a token waits at a
+// join, a start at a boundary event or inside an Event Sub-Process, an Ad-Hoc
+// Sub-Process or a looped composite, a start whose node's required inputs
+// don't resolve in the scope it starts in — fails the call with nothing
+// applied, so a start that can't run never costs the instance the tokens its
+// cancels would remove. The cancels then run before the starts, so a token is moved by canceling
+// it at one node and starting it at another. A scope left without tokens
+// completes as it would on its own.
+//
+// Each instruction is reported as a KindModification fact, and the
+// instance's checkpoint is written at once. A released instance —
+// dehydrated, suspended or parked on an incident — is rebuilt to apply it; a
+// suspended one stays suspended, its new tokens waiting for Resume. A
+// finished or terminating instance refuses with InvalidState.
+func (h *InstanceHandle) Modify(ctx context.Context, mods ...Modification) error {
+	if len(mods) == 0 {
+		return errs.New(
+			errs.M("Modify: no instructions to apply"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	ii := make([]instance.Modification, 0, len(mods))
+
+	for _, m := range mods {
+		id := strings.TrimSpace(m.nodeID)
+		if id == "" || m.op == 0 {
+			return errs.New(
+				errs.M("Modify: an instruction needs a node id"),
+				errs.C(errorClass, errs.EmptyNotAllowed))
+		}
+
+		ii = append(ii, instance.Modification{NodeID: id, Op: m.op})
+	}
+
+	inst := h.current()
+
+	switch inst.State() {
+	case instance.Completed, instance.Terminating, instance.Terminated:
+		return errs.New(
+			errs.M("can't modify instance %q: it is %s",
+				inst.ID(), inst.State()),
+			errs.C(errorClass, errs.InvalidState),
+			errs.D(observability.AttrInstanceID, inst.ID()))
+	}
+
+	delivered, err := inst.SubmitModification(ctx, ii)
+	if err != nil || delivered {
+		return err
+	}
+
+	if h.th == nil {
+		return errs.New(
+			errs.M("modify on a parked instance needs its engine"),
+			errs.C(errorClass, errs.InvalidState))
+	}
+
+	return h.th.wakeForModification(ctx, h, ii)
+}
+
+// wakeForModification applies a modification to an instance whose loop has
+// exited, riding a rebuild as wakeForVariables does.
+func (t *Thresher) wakeForModification(
+	ctx context.Context, h *InstanceHandle, mods []instance.Modification,
+) error {
+	resp := make(chan error, 1)
+
+	return t.rebuildForOp(ctx, h, "modify", resp,
+		instance.WithPendingModification(mods, resp))
+}
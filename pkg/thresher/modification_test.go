@@ -0,0 +1,388 @@
+package thresher_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/model/activities"
+	"github.com/dr-dobermann/gobpm/pkg/model/data"
+	"github.com/dr-dobermann/gobpm/pkg/model/data/values"
+	"github.com/dr-dobermann/gobpm/pkg/model/events"
+	"github.com/dr-dobermann/gobpm/pkg/model/flow"
+	"github.com/dr-dobermann/gobpm/pkg/model/foundation"
+	"github.com/dr-dobermann/gobpm/pkg/model/process"
+	"github.com/dr-dobermann/gobpm/pkg/model/service"
+	"github.com/dr-dobermann/gobpm/pkg/model/service/gooper"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+	"github.com/dr-dobermann/gobpm/pkg/repository/memrepo"
+	"github.com/dr-dobermann/gobpm/pkg/thresher"
+)
+
+// modifications lists the Modification facts fw saw as
+// "<phase>:<instruction>:<node name>".
+func (fw *factWatch) modifications() []string {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	var mm []string
+
+	for _, f := range fw.facts {
+		if f.Kind == observability.KindModification {
+			mm = append(mm, string(f.Phase)+":"+
+				f.Details["instruction"]+":"+f.NodeName)
+		}
+	}
+
+	return mm
+}
+
+// modRan flags which service tasks of modProc ran.
+type modRan struct {
+	prep, check, tail atomic.Bool
+}
+
+// modProc builds start → approve → review[start → prep → check → end] →
+// tail → end, approve a UserTask parking the token. It returns the node ids
+// by name.
+func modProc(
+	t *testing.T, key string, ran *modRan,
+) (*process.Process, map[string]string) {
+	t.Helper()
+
+	require.NoError(t, data.CreateDefaultStates())
+
+	review, err := activities.NewSubProcess("review")
+	require.NoError(t, err)
+
+	iStart, err := events.NewStartEvent("i-start")
+	require.NoError(t, err)
+
+	prep := laneTask(t, "prep", &ran.prep)
+	check := laneTask(t, "check", &ran.check)
+
+	iEnd, err := events.NewEndEvent("i-end")
+	require.NoError(t, err)
+
+	for _, e := range []flow.Element{iStart, prep, check, iEnd} {
+		require.NoError(t, review.Add(e))
+	}
+
+	link(t, iStart, prep)
+	link(t, prep, check)
+	link(t, check, iEnd)
+
+	proc, err := process.New(key)
+	require.NoError(t, err)
+
+	start, err := events.NewStartEvent("start")
+	require.NoError(t, err)
+
+	ut, err := activities.NewUserTask("approve",
+		activities.WithCandidateUsers("operator"),
+		activities.WithOutput("result", "string", true),
+		activities.WithoutParams())
+	require.NoError(t, err)
+
+	tail := laneTask(t, "tail", &ran.tail)
+
+	end, err := events.NewEndEvent("end")
+	require.NoError(t, err)
+
+	for _, e := range []flow.Element{start, ut, review, tail, end} {
+		require.NoError(t, proc.Add(e))
+	}
+
+	link(t, start, ut)
+	link(t, ut, review)
+	link(t, review, tail)
+	link(t, tail, end)
+
+	ids := map[string]string{}
+	for _, n := range []flow.Node{ut, review, prep, check, tail, end} {
+		ids[n.Name()] = n.ID()
+	}
+
+	return proc, ids
+}
+
+// startParked runs proc on a fresh engine watched by a factWatch and starts
+// it, waiting for the token to park on approve.
+func startParked(
+	t *testing.T, proc *process.Process,
+) (*thresher.InstanceHandle, *factWatch) {
+	t.Helper()
+
+	th, err := thresher.New("test-"+proc.ID(), thresher.WithoutBanner())
+	require.NoError(t, err)
+
+	fw := &factWatch{}
+	sub := th.Observe(fw)
+	t.Cleanup(sub.Cancel)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	require.NoError(t, th.Run(ctx))
+
+	_, err = th.RegisterProcess(proc)
+	require.NoError(t, err)
+
+	h, err := th.StartLatest(proc.ID())
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		tt := h.Tokens()
+
+		return len(tt) == 1 && tt[0].NodeName == "approve" &&
+			tt[0].State == thresher.TokenWaitForEvent
+	}, 2*time.Second, 10*time.Millisecond)
+
+	return h, fw
+}
+
+// waitCompleted waits for h to complete.
+func waitCompleted(t *testing.T, h *thresher.InstanceHandle) {
+	t.Helper()
+
+	wctx, wcancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer wcancel()
+
+	st, err := h.WaitCompletion(wctx)
+	require.NoError(t, err)
+	require.Equal(t, thresher.StateCompleted, st)
+}
+
+// TestModifyMovesParkedToken verifies a cancel at the parked UserTask and a
+// start before a later node move the token: the skipped sub-process never
+// runs, and both instructions are reported.
+func TestModifyMovesParkedToken(t *testing.T) {
+	var ran modRan
+
+	proc, ids := modProc(t, "mod-move", &ran)
+	h, fw := startParked(t, proc)
+
+	require.NoError(t, h.Modify(context.Background(),
+		thresher.CancelAt(ids["approve"]),
+		thresher.StartBefore(ids["tail"])))
+
+	waitCompleted(t, h)
+
+	require.False(t, ran.prep.Load())
+	require.False(t, ran.check.Load())
+	require.True(t, ran.tail.Load())
+
+	require.Equal(t, []string{
+		"Canceled:cancel:approve",
+		"Started:start_before:tail",
+	}, fw.modifications())
+}
+
+// TestModifyStartsInsideSubProcess verifies a start before a node nested in
+// an embedded sub-process opens the sub-process with that node as its only
+// entry, and a start after a node continues on its outgoing flow.
+func TestModifyStartsInsideSubProcess(t *testing.T) {
+	t.Run("start before", func(t *testing.T) {
+		var ran modRan
+
+		proc, ids := modProc(t, "mod-nested", &ran)
+		h, _ := startParked(t, proc)
+
+		require.NoError(t, h.Modify(context.Background(),
+			thresher.CancelAt(ids["approve"]),
+			thresher.StartBefore(ids["check"])))
+
+		waitCompleted(t, h)
+
+		require.False(t, ran.prep.Load(), "the sub-process's start is skipped")
+		require.True(t, ran.check.Load())
+		require.True(t, ran.tail.Load(), "the sub-process completes normally")
+	})
+
+	t.Run("start after", func(t *testing.T) {
+		var ran modRan
+
+		proc, ids := modProc(t, "mod-after", &ran)
+		h, fw := startParked(t, proc)
+
+		require.NoError(t, h.Modify(context.Background(),
+			thresher.CancelAt(ids["approve"]),
+			thresher.StartAfter(ids["prep"])))
+
+		waitCompleted(t, h)
+
+		require.False(t, ran.prep.Load(), "a start after doesn't run the node")
+		require.True(t, ran.check.Load())
+		require.True(t, ran.tail.Load())
+
+		require.Equal(t, []string{
+			"Canceled:cancel:approve",
+			"Started:start_after:prep",
+		}, fw.modifications())
+	})
+}
+
+// TestModifyRefusals verifies the refused instructions and that a refused
+// modification applies none of its instructions.
+func TestModifyRefusals(t *testing.T) {
+	var ran modRan
+
+	proc, ids := modProc(t, "mod-refuse", &ran)
+	h, fw := startParked(t, proc)
+
+	ctx := context.Background()
+
+	requireClass(t, h.Modify(ctx), errs.EmptyNotAllowed)
+	requireClass(t, h.Modify(ctx, thresher.StartBefore(" ")),
+		errs.EmptyNotAllowed)
+	requireClass(t, h.Modify(ctx, thresher.CancelAt("ghost")),
+		errs.ObjectNotFound)
+	requireClass(t, h.Modify(ctx, thresher.CancelAt(ids["tail"])),
+		errs.ObjectNotFound)
+	requireClass(t, h.Modify(ctx, thresher.StartAfter(ids["end"])),
+		errs.InvalidParameter)
+
+	// the valid start rides with a refused cancel: nothing is applied
+	require.Error(t, h.Modify(ctx,
+		thresher.StartBefore(ids["tail"]),
+		thresher.CancelAt(ids["check"])))
+
+	tt := h.Tokens()
+	require.Len(t, tt, 1)
+	require.Equal(t, "approve", tt[0].NodeName)
+	require.False(t, ran.tail.Load())
+	require.Empty(t, fw.modifications())
+
+	cctx, ccancel := context.WithTimeout(ctx, 3*time.Second)
+	defer ccancel()
+
+	_, err := h.Cancel(cctx)
+	require.NoError(t, err)
+
+	requireClass(t, h.Modify(ctx, thresher.StartBefore(ids["tail"])),
+		errs.InvalidState)
+}
+
+// TestModifyRefusesUnrunnableStart verifies a start whose node can't run —
+// its required input resolves to nothing — refuses the whole modification
+// before its cancel lands: the instance stays healthy at its parked token.
+func TestModifyRefusesUnrunnableStart(t *testing.T) {
+	require.NoError(t, data.CreateDefaultStates())
+
+	proc, err := process.New("mod-unrunnable")
+	require.NoError(t, err)
+
+	start, err := events.NewStartEvent("start")
+	require.NoError(t, err)
+
+	ut, err := activities.NewUserTask("approve",
+		activities.WithCandidateUsers("operator"),
+		activities.WithOutput("result", "string", true),
+		activities.WithoutParams())
+	require.NoError(t, err)
+
+	var ran atomic.Bool
+
+	op, err := gooper.New("gated",
+		func(_ context.Context, _ service.DataReader,
+			_ *data.ItemDefinition) (*data.ItemDefinition, error) {
+			ran.Store(true)
+
+			return nil, nil
+		})
+	require.NoError(t, err)
+
+	// a required input no association fills
+	amount := data.MustParameter("amount",
+		data.MustItemAwareElement(
+			data.MustItemDefinition(values.NewVariable(0),
+				foundation.WithID("amount")),
+			data.UnavailableDataState))
+
+	gated, err := activities.NewServiceTask("gated", op,
+		activities.WithParameters(data.Input, amount))
+	require.NoError(t, err)
+
+	end, err := events.NewEndEvent("end")
+	require.NoError(t, err)
+
+	for _, e := range []flow.Element{start, ut, gated, end} {
+		require.NoError(t, proc.Add(e))
+	}
+
+	link(t, start, ut)
+	link(t, ut, gated)
+	link(t, gated, end)
+
+	h, fw := startParked(t, proc)
+
+	requireClass(t, h.Modify(context.Background(),
+		thresher.CancelAt(ut.ID()),
+		thresher.StartBefore(gated.ID())), errs.InvalidState)
+
+	tt := h.Tokens()
+	require.Len(t, tt, 1)
+	require.Equal(t, "approve", tt[0].NodeName)
+	require.Equal(t, thresher.StateActive, h.State())
+	require.False(t, ran.Load())
+	require.Empty(t, fw.modifications())
+}
+
+// TestModifyDehydratedInstance verifies a modification of a dehydrated
+// instance rebuilds it from its checkpoint, applies and persists the
+// modification and lets it release again: the restarted UserTask is
+// re-announced under a new id, and the rebuilt instance completes through it.
+func TestModifyDehydratedInstance(t *testing.T) {
+	repo := memrepo.New()
+	dist := &annCollector{}
+	p := utProc(t, "mod-dehy")
+
+	th, fw, cancel := bootTaskEngine(t, "engine-M", repo, dist, p)
+	defer cancel()
+
+	h, err := th.StartLatest(p.ID())
+	require.NoError(t, err)
+
+	dehydrated := func(n int) func() bool {
+		return func() bool {
+			return fw.count(observability.KindInstanceState,
+				observability.PhaseDehydrated) >= n
+		}
+	}
+
+	require.Eventually(t, dehydrated(1), 3*time.Second, 10*time.Millisecond)
+
+	oldTask := dist.taskIDs()[0]
+	approve := p.ID() + "-approve"
+	ctx := context.Background()
+
+	require.NoError(t, h.Modify(ctx,
+		thresher.CancelAt(approve), thresher.StartBefore(approve)))
+	require.Eventually(t, dehydrated(2), 3*time.Second, 10*time.Millisecond)
+
+	ids := dist.taskIDs()
+	newTask := ids[len(ids)-1]
+	require.NotEqual(t, oldTask, newTask)
+
+	operator := utActor{id: "operator"}
+
+	_, err = th.Take(ctx, oldTask, operator)
+	require.Error(t, err, "the canceled task is gone")
+
+	_, err = th.Take(ctx, newTask, operator)
+	require.NoError(t, err)
+	require.NoError(t, th.Claim(ctx, newTask, operator))
+	require.NoError(t, th.Complete(ctx, newTask, operator,
+		resultOutput("approved")))
+
+	waitCompleted(t, h)
+
+	require.Equal(t, []string{
+		"Canceled:cancel:approve",
+		"Started:start_before:approve",
+	}, fw.modifications())
+}
@@ -12,7 +12,9 @@ import (
 	"github.com/dr-dobermann/gobpm/pkg/datastore"
 	"github.com/dr-dobermann/gobpm/pkg/datastore/memstore"
 	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/history"
 	"github.com/dr-dobermann/gobpm/pkg/interactor"
+	"github.com/dr-dobermann/gobpm/pkg/listener"
 	"github.com/dr-dobermann/gobpm/pkg/messaging"
 	"github.com/dr-dobermann/gobpm/pkg/messaging/membroker"
 	"github.com/dr-dobermann/gobpm/pkg/model/expression"
@@ -22,9 +24,11 @@ import (
 	"github.com/dr-dobermann/gobpm/pkg/renv"
 	"github.com/dr-dobermann/gobpm/pkg/repository"
 	"github.com/dr-dobermann/gobpm/pkg/repository/memrepo"
+	"github.com/dr-dobermann/gobpm/pkg/repository/payloadcodec"
 	"github.com/dr-dobermann/gobpm/pkg/rules"
 	"github.com/dr-dobermann/gobpm/pkg/rules/gorules"
 	"github.com/dr-dobermann/gobpm/pkg/script"
+	"github.com/dr-dobermann/gobpm/pkg/signaling"
 	"github.com/dr-dobermann/gobpm/pkg/tasks"
 	"github.com/dr-dobermann/gobpm/pkg/tasks/localdispatcher"
 )
@@ -39,6 +43,7 @@ type thresherConfig struct {
 	ruleEngine          rules.Engine
 	clock               clock.Clock
 	repository          repository.Repository
+	history             history.Store
 	msgBroker           messaging.MessageBroker
 	tracer              observability.Tracer
 	dispatcher          tasks.WorkerDispatcher
@@ -64,6 +69,31 @@ type thresherConfig struct {
 	repoSet               bool
 	leaseTTL              time.Duration
 	wakeBackoff           time.Duration
+	timerPoll             time.Duration
+	timerGrace            time.Duration
+	// heartbeat is the lease-renewal interval (WithLeaseHeartbeat) and
+	// rescan the recovery-rescan interval (WithRecoveryRescan); zero
+	// leaves each off.
+	heartbeat time.Duration
+	rescan    time.Duration
+	// listeners are the engine-wide execution listeners
+	// (WithExecutionListener), stamped ahead of each registered process's
+	// own.
+	listeners []listener.Binding
+	// taskListeners are the engine-wide UserTask lifecycle listeners
+	// (WithTaskListener), stamped ahead of each registered process's own.
+	taskListeners []listener.TaskBinding
+	// historyOpts configure the Recorder WithHistory arms.
+	historyOpts []history.RecorderOption
+	// cpCodec is the checkpoint payload codec chain
+	// (WithCheckpointCodec); nil stores plain documents.
+	cpCodec *payloadcodec.Chain
+	// outbox relays sent messages through the checkpoint
+	// (WithMessageOutbox).
+	outbox bool
+	// signalFanout carries thrown signals across the engine group
+	// (WithSignalFanout); nil until New resolves the broker default.
+	signalFanout signaling.Fanout
 }
 
 // Option overrides one engine-level extension at thresher.New. An Option may
@@ -153,6 +183,27 @@ func WithRepository(r repository.Repository) Option {
 	}
 }
 
+// WithHistory arms the history store: the engine registers a history.Recorder
+// on its observation stream at New, so every instance's start and end, node
+// activities, task ownership changes, incidents and variable updates land in
+// s — and outlive the instance (Thresher.History queries them). opts
+// configure the Recorder, history.WithMaskedVariables keeping variables out;
+// the Recorder logs to the engine's logger. Without it nothing is recorded.
+func WithHistory(s history.Store, opts ...history.RecorderOption) Option {
+	return func(c *thresherConfig) error {
+		if s == nil {
+			return errs.New(
+				errs.M("WithHistory: a nil history.Store isn't allowed"),
+				errs.C(errorClass, errs.EmptyNotAllowed))
+		}
+
+		c.history = s
+		c.historyOpts = opts
+
+		return nil
+	}
+}
+
 // WithMessageBroker sets the message broker (default: in-memory inbox).
 func WithMessageBroker(b messaging.MessageBroker) Option {
 	return func(c *thresherConfig) error {
@@ -288,7 +339,9 @@ func WithScriptEngine(e script.Engine) Option {
 // with dataStoreRef=ref reads and writes (BPMN §10.4.1, ADR-030 §2.5). Each
 // store outlives every instance and is shared across them; call it once per
 // distinct store. A store may be any datastore.DataStore — the in-memory
-// memstore, or a durable adapter. Registering an already-used ref replaces it.
+// memstore, or a durable adapter; one that implements renv.Migrator is
+// migrated at Run, after the Repository. Registering an already-used ref
+// replaces it.
 func WithDataStore(ref string, store datastore.DataStore) Option {
 	return func(c *thresherConfig) error {
 		return c.dataStores.Register(ref, store)
@@ -410,6 +463,17 @@ const DefaultLeaseTTL = 30 * time.Second
 // cadence that outpaced it would just churn.
 const DefaultWakeRetryBackoff = DefaultLeaseTTL / 2
 
+// DefaultTimerPollInterval is how often an engine over a Repository with a
+// due-timer index (repository.TimerIndex) looks for the group's overdue
+// timers whose engine is gone.
+const DefaultTimerPollInterval = 5 * time.Second
+
+// DefaultTimerTakeoverGrace is how long past its deadline a timer is left to
+// the engine that armed it before another engine of the group takes it over —
+// a lease window: an engine that hasn't fired its own timer by then is
+// presumed gone.
+const DefaultTimerTakeoverGrace = DefaultLeaseTTL
+
 // setEngineGroup validates and stores the group both group options
 // share; joinOnly marks the WithExistingEngineGroup assertion.
 func setEngineGroup(
@@ -475,6 +539,113 @@ func WithLeaseTTL(d time.Duration) Option {
 	}
 }
 
+// WithCheckpointCodec passes every checkpoint payload through codecs, in
+// order, before the Repository saves it, and back after it loads — compression
+// (payloadcodec.NewGzip) and at-rest encryption (payloadcodec.NewAESGCM), say.
+// Every Repository adapter benefits unchanged: it stores the framed result as
+// opaque bytes. A payload names the codecs it was written with, so records
+// written before the option, or with fewer codecs, still load; one naming a
+// codec the chain lacks fails loud. Without codecs the option is rejected.
+func WithCheckpointCodec(codecs ...payloadcodec.Codec) Option {
+	return checkpointCodec("WithCheckpointCodec", payloadcodec.NewChain, codecs)
+}
+
+// WithStrictCheckpointCodec is WithCheckpointCodec over a strict chain
+// (payloadcodec.NewStrictChain): a checkpoint loads only if an authenticating
+// codec of the chain — AES-GCM, say — sealed it, so a plain or unsealed
+// payload planted in the Repository is refused instead of run. Records written
+// before the option no longer load. A chain without an authenticating codec is
+// rejected.
+func WithStrictCheckpointCodec(codecs ...payloadcodec.Codec) Option {
+	return checkpointCodec("WithStrictCheckpointCodec",
+		payloadcodec.NewStrictChain, codecs)
+}
+
+// checkpointCodec builds the option's codec chain with build.
+func checkpointCodec(
+	option string,
+	build func(...payloadcodec.Codec) (*payloadcodec.Chain, error),
+	codecs []payloadcodec.Codec,
+) Option {
+	return func(c *thresherConfig) error {
+		if len(codecs) == 0 {
+			return errs.New(
+				errs.M("%s: at least one codec is required", option),
+				errs.C(errorClass, errs.EmptyNotAllowed))
+		}
+
+		chain, err := build(codecs...)
+		if err != nil {
+			return errs.New(
+				errs.M("%s: invalid codec chain", option),
+				errs.C(errorClass, errs.InvalidParameter),
+				errs.E(err))
+		}
+
+		c.cpCodec = chain
+
+		return nil
+	}
+}
+
+// WithMessageOutbox makes message sends transactional: a Send Task or a
+// throw Message event records its message in the instance's checkpoint
+// instead of publishing it, and the engine publishes it once that
+// checkpoint is saved — a send's data and its message become durable
+// together. Each relayed message carries a messaging.Envelope MessageID that
+// stays the same when a crash makes the engine relay it again, so a
+// deduplicating broker (membroker's dedup window) delivers it once. Needs a
+// Repository (WithRepository).
+func WithMessageOutbox() Option {
+	return func(c *thresherConfig) error {
+		c.outbox = true
+
+		return nil
+	}
+}
+
+// WithSignalFanout sets the transport that carries thrown signals to the
+// other engines of the group (default: the MessageBroker's broadcast, when
+// the engine has an explicit group and its broker is a
+// messaging.Broadcaster; otherwise signals stay local). Every signal thrown
+// on the engine is broadcast through f, and every signal f delivers from
+// another engine is propagated locally — once per signal — so it reaches
+// that engine's catches, dehydrated ones included.
+func WithSignalFanout(f signaling.Fanout) Option {
+	return func(c *thresherConfig) error {
+		if f == nil {
+			return errs.New(
+				errs.M("WithSignalFanout: a nil Fanout isn't allowed"),
+				errs.C(errorClass, errs.EmptyNotAllowed))
+		}
+
+		c.signalFanout = f
+
+		return nil
+	}
+}
+
+// WithExecutionListener binds an execution listener to every node of every
+// process the engine registers: l runs on the executing token's track for
+// each of events (every event when none is given), ahead of the process's
+// and the node's own listeners. A listener error fails the activity into the
+// incident machinery. The option may be given more than once; the listeners
+// run in the order given.
+func WithExecutionListener(
+	l listener.Listener, events ...listener.Event,
+) Option {
+	return func(c *thresherConfig) error {
+		b, err := bindListener("WithExecutionListener", "", l, events)
+		if err != nil {
+			return err
+		}
+
+		c.listeners = append(c.listeners, b)
+
+		return nil
+	}
+}
+
 // WithWakeRetryBackoff sets the pause before a failed wake is re-attempted
 // (FIX-027). A dehydrated instance is woken by its hold; when the wake fails —
 // an unregistered pinned version, a checkpoint that will not decode — the hold
@@ -495,6 +666,74 @@ func WithWakeRetryBackoff(d time.Duration) Option {
 		return nil
 	}
 }
+
+// WithTimerTakeover tunes the group's timer takeover: every poll the engine
+// asks the Repository's due-timer index (repository.TimerIndex) for the
+// group's timers more than grace past their deadline, and claims and
+// rehydrates an instance whose lease has lapsed, so its timer fires on this
+// engine. Non-positive values are rejected.
+//
+// Default: DefaultTimerPollInterval and DefaultTimerTakeoverGrace.
+func WithTimerTakeover(poll, grace time.Duration) Option {
+	return func(c *thresherConfig) error {
+		if poll <= 0 || grace <= 0 {
+			return errs.New(
+				errs.M("WithTimerTakeover: the poll interval and the grace must be positive"),
+				errs.C(errorClass, errs.InvalidParameter))
+		}
+
+		c.timerPoll, c.timerGrace = poll, grace
+
+		return nil
+	}
+}
+
+// WithLeaseHeartbeat makes a running engine over a Repository renew the
+// leases of the instances it owns every d: a resident instance saves a fresh
+// checkpoint, a released one has its record's lease extended. Without it a
+// lease is stamped by checkpoints alone, so an instance that makes no
+// transition for a lease window looks abandoned to the rest of the group. d
+// must be shorter than the lease window (WithLeaseTTL) — New refuses
+// otherwise. Non-positive values are rejected.
+//
+// Default: off.
+func WithLeaseHeartbeat(d time.Duration) Option {
+	return func(c *thresherConfig) error {
+		if d <= 0 {
+			return errs.New(
+				errs.M("WithLeaseHeartbeat: the heartbeat interval must be positive"),
+				errs.C(errorClass, errs.InvalidParameter))
+		}
+
+		c.heartbeat = d
+
+		return nil
+	}
+}
+
+// WithRecoveryRescan makes a running engine over a Repository list its
+// group's claimable in-flight instances every d and recover them, as it does
+// at Run — so the instances of an engine that crashed, or drained (Drain),
+// are taken over by the rest of the group without a restart. It needs
+// WithLeaseHeartbeat — New refuses it alone — and every engine of the group
+// should renew its leases: a lease that isn't renewed makes a live engine's
+// idle instances claimable. Non-positive values are rejected.
+//
+// Default: off.
+func WithRecoveryRescan(d time.Duration) Option {
+	return func(c *thresherConfig) error {
+		if d <= 0 {
+			return errs.New(
+				errs.M("WithRecoveryRescan: the rescan interval must be positive"),
+				errs.C(errorClass, errs.InvalidParameter))
+		}
+
+		c.rescan = d
+
+		return nil
+	}
+}
+
 func (c *thresherConfig) MessageBroker() messaging.MessageBroker { return c.msgBroker }
 func (c *thresherConfig) ExpressionEngine() expression.Engine    { return c.exprRegistry }
 func (c *thresherConfig) RuleEngine() rules.Engine               { return c.ruleEngine }
@@ -543,6 +782,8 @@ func defaultConfig() thresherConfig {
 		repository:  memrepo.New(),
 		leaseTTL:    DefaultLeaseTTL,
 		wakeBackoff: DefaultWakeRetryBackoff,
+		timerPoll:   DefaultTimerPollInterval,
+		timerGrace:  DefaultTimerTakeoverGrace,
 		msgBroker:   membroker.New(),
 		authz:       allowall.New(),
 		dispatcher:  localdispatcher.New(nil, 0),
@@ -551,3 +792,25 @@ func defaultConfig() thresherConfig {
 		taskDist:    interactor.NopDistributor(),
 	}
 }
+
+// WithTaskListener binds a task listener to every UserTask of every process
+// the engine registers: l runs for each of events (every event when none is
+// given) ahead of the tasks' own listeners. A create listener may change the
+// task's assignment before it is distributed; an assign listener's error vetoes
+// a Claim, Unclaim or Reassign, and a complete listener's error rejects the
+// completion — both returned to the caller. The option may be given more than
+// once; the listeners run in the order given.
+func WithTaskListener(
+	l listener.TaskListener, events ...listener.TaskEvent,
+) Option {
+	return func(c *thresherConfig) error {
+		b, err := bindTaskListener("WithTaskListener", "", l, events)
+		if err != nil {
+			return err
+		}
+
+		c.taskListeners = append(c.taskListeners, b)
+
+		return nil
+	}
+}
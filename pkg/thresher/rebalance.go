@@ -0,0 +1,354 @@
+package thresher
+
+import (
+	"context"
+
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+
+	"github.com/dr-dobermann/gobpm/internal/instance"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+	"github.com/dr-dobermann/gobpm/pkg/repository"
+)
+
+// Ownership across the engine group. An instance belongs to the engine whose
+// lease its record carries, and the lease is stamped by every checkpoint — so
+// an instance that makes no transition for a lease window, or one released to
+// a held wait, looks abandoned to the rest of the group. Both halves of the
+// upkeep are opt-in, and run for as long as the engine does:
+//
+//   - the heartbeat (WithLeaseHeartbeat) renews the lease of every instance
+//     it owns: a resident instance through its loop (a fresh checkpoint), a
+//     released one on its record;
+//   - the rescan (WithRecoveryRescan) repeats the start-up recovery every
+//     interval, so the instances of an engine that crashed move to a
+//     surviving one without a restart;
+//   - Drain, always available, gives every instance up before the engine
+//     stops: each loop stops unsettled and each lease is surrendered, for
+//     the next rescan, timer takeover or restart of another engine to
+//     recover.
+
+// keepLeases runs the lease heartbeat for the engine's lifetime.
+func (t *Thresher) keepLeases(ctx context.Context) {
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case <-t.upkeepStop:
+				return
+
+			case <-t.cfg.Clock().After(t.cfg.heartbeat):
+				t.renewLeases(ctx)
+			}
+		}
+	}()
+}
+
+// stopUpkeep ends the lease heartbeat and the rescans. Idempotent.
+func (t *Thresher) stopUpkeep() {
+	t.upkeepOnce.Do(func() { close(t.upkeepStop) })
+}
+
+// renewLeases renews the lease of every unfinished instance the engine
+// tracks. A draining engine renews nothing: its leases are being given up.
+func (t *Thresher) renewLeases(ctx context.Context) {
+	for _, r := range t.pendingInstancesLocked(nil) {
+		if ctx.Err() != nil || t.draining.Load() {
+			return
+		}
+
+		if instanceTerminal(r.inst.State()) {
+			continue
+		}
+
+		delivered, err := r.inst.RenewLease(ctx)
+		if err != nil {
+			return // the engine is stopping
+		}
+
+		if !delivered {
+			t.renewRecordLease(ctx, r.inst.ID())
+		}
+	}
+}
+
+// renewRecordLease extends the lease on the record of an instance without a
+// loop — released, or parked on its incidents. It holds the wake latch, so
+// the write never races a rebuild; an instance being rebuilt is renewed by
+// the rebuild's own claim.
+func (t *Thresher) renewRecordLease(ctx context.Context, id string) {
+	if _, claimed := t.claimWake(id); !claimed {
+		return
+	}
+	defer t.releaseWake(id)
+
+	repo := t.cfg.Repository()
+
+	rec, ok, err := repo.Load(ctx, id)
+	if err != nil {
+		t.cfg.logger.Warn("couldn't load the instance to renew its lease",
+			observability.AttrInstanceID, id, observability.AttrError, err.Error())
+
+		return
+	}
+
+	// a record gone, finished or claimed by another engine is not this
+	// engine's to renew.
+	if !ok || rec.Status.IsTerminal() || rec.Lease.Owner != t.id {
+		return
+	}
+
+	rec.Lease.Expiry = t.cfg.Clock().Now().Add(t.cfg.leaseTTL)
+
+	if err := repo.Save(ctx, rec); err != nil && !lostClaim(err) {
+		t.cfg.logger.Warn("instance lease not renewed",
+			observability.AttrInstanceID, id, observability.AttrError, err.Error())
+	}
+}
+
+// rescanInFlight recovers the group's claimable in-flight instances every
+// rescan interval for the engine's lifetime.
+func (t *Thresher) rescanInFlight(ctx context.Context) {
+	go func() {
+		// an instance that fails to recover is listed again on every pass;
+		// its failure is reported once.
+		failed := map[string]bool{}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case <-t.upkeepStop:
+				return
+
+			case <-t.cfg.Clock().After(t.cfg.rescan):
+				t.recoverClaimable(ctx, failed)
+			}
+		}
+	}()
+}
+
+// recoverClaimable is one rescan pass: recoverInstances for the instances
+// the engine doesn't already run.
+func (t *Thresher) recoverClaimable(ctx context.Context, failed map[string]bool) {
+	if t.draining.Load() {
+		return
+	}
+
+	ids, err := t.cfg.Repository().ListInFlight(ctx, t.group, t.cfg.Clock().Now())
+	if err != nil {
+		t.cfg.logger.Warn("rescan: couldn't list in-flight instances",
+			observability.AttrError, err.Error())
+
+		return
+	}
+
+	for _, id := range ids {
+		if ctx.Err() != nil || t.draining.Load() {
+			return
+		}
+
+		if _, err := t.instanceByID(id); err == nil {
+			continue
+		}
+
+		if err := t.recoverOne(ctx, id); err != nil {
+			if !failed[id] {
+				failed[id] = true
+				t.reportRecoveryFailure(id, err)
+			}
+
+			continue
+		}
+
+		delete(failed, id)
+	}
+}
+
+// Drain hands every instance the engine runs to the rest of its engine group,
+// then shuts the engine down (Shutdown). From the first moment the engine
+// takes nothing on — starts, wakes, recoveries and timer takeovers are
+// refused. A resident instance stops where it stands, with no terminal state
+// written; every instance's lease is surrendered, so another engine's rescan
+// (WithRecoveryRescan), timer takeover or restart recovers it from its last
+// checkpoint. A finished instance is left as it is.
+//
+// Drain needs an explicitly configured Repository (WithRepository) — without
+// one there is no checkpoint to hand over — and a Started or Paused engine. A
+// ctx that ends mid-drain leaves the rest to Shutdown, which terminates them.
+func (t *Thresher) Drain(ctx context.Context) error {
+	if !t.cfg.repoSet {
+		return errs.New(
+			errs.M("Drain needs an explicitly configured Repository (WithRepository)"),
+			errs.C(errorClass, errs.InvalidState))
+	}
+
+	if st := t.State(); st != Started && st != Paused {
+		return errs.New(
+			errs.M("couldn't drain thresher from state %q", st),
+			errs.C(errorClass, errs.InvalidState))
+	}
+
+	t.draining.Store(true)
+	t.stopUpkeep()
+
+	// Re-snapshot until a pass hands nothing new off: a wake that was in
+	// flight when draining began tracks a fresh instance, and it is handed
+	// off on the next pass.
+	handed := map[*instance.Instance]bool{}
+
+	for fresh := true; fresh && ctx.Err() == nil; {
+		fresh = false
+
+		for _, r := range t.pendingInstancesLocked(nil) {
+			if handed[r.inst] || ctx.Err() != nil {
+				continue
+			}
+
+			handed[r.inst] = true
+			fresh = true
+
+			t.handOff(ctx, r.inst)
+		}
+	}
+
+	t.cfg.logger.Info("engine drained to its group",
+		"engine_group", t.group, "instances", len(handed))
+
+	return t.Shutdown(ctx)
+}
+
+// handOff gives one instance up to the group: its loop, when it has one,
+// stops unsettled, whatever the engine holds for it is let go, and its lease
+// is surrendered.
+func (t *Thresher) handOff(ctx context.Context, inst *instance.Instance) {
+	if instanceTerminal(inst.State()) {
+		return
+	}
+
+	resident, err := inst.HandOff(ctx)
+	if err != nil {
+		return
+	}
+
+	// A resident instance released its waits' holds and its task
+	// announcements on the way out of its loop; a released one has no loop,
+	// so the engine drops its holds itself — its tasks once the record is
+	// read. Its correlation reservations are the engine's either way.
+	t.releaseHoldsOf(inst.ID())
+	t.dropKeysLocked(inst.ID())
+
+	rec, ok := t.surrenderLease(ctx, inst.ID())
+	if !ok {
+		return
+	}
+
+	if !resident {
+		t.withdrawReleasedTasks(ctx, inst, rec.Payload)
+	}
+
+	inst.Report(observability.Fact{
+		Kind:  observability.KindInstanceState,
+		Phase: observability.PhaseHandedOff,
+		Details: map[string]string{
+			observability.AttrProcessID: inst.ProcessID(),
+			"engine_group":              t.group,
+		},
+	})
+}
+
+// releaseHoldsOf withdraws every deadline and subscription the engine holds
+// for an instance it hands off. The group's due-timer index keeps the
+// instance's entries (ReleaseWaits): they are what the group takes over.
+func (t *Thresher) releaseHoldsOf(id string) {
+	if t.timerSvc != nil {
+		t.timerSvc.releaseInstance(id)
+	}
+
+	t.withdrawSubscriptions(id, "")
+}
+
+// withdrawReleasedTasks withdraws from the engine's distributor the tasks a
+// handed-off released instance had announced, read from its checkpoint: the
+// engine that recovers the instance announces them again.
+func (t *Thresher) withdrawReleasedTasks(
+	ctx context.Context, inst *instance.Instance, payload []byte,
+) {
+	id := inst.ID()
+
+	doc, err := t.openCheckpoint(ctx, id, payload)
+	if err != nil {
+		t.cfg.logger.Warn("drain: the checkpoint doesn't decode; its tasks stay announced",
+			observability.AttrInstanceID, id, observability.AttrError, err.Error())
+
+		return
+	}
+
+	for _, tr := range doc.Tracks {
+		if tr.TaskID == "" {
+			continue
+		}
+
+		if err := t.taskDist.Withdraw(ctx, tr.TaskID); err != nil {
+			t.cfg.logger.Warn("drain: a handed-off task doesn't withdraw",
+				observability.AttrInstanceID, id, observability.AttrTaskID, tr.TaskID,
+				observability.AttrError, err.Error())
+		}
+
+		inst.Report(observability.Fact{
+			Kind:    observability.KindTaskState,
+			Phase:   observability.PhaseWithdrawn,
+			Details: map[string]string{observability.AttrTaskID: tr.TaskID},
+		})
+	}
+}
+
+// surrenderLease lets the lease on an instance's record lapse now, so the
+// group may claim it at once. It returns the record as saved, and whether the
+// instance was this engine's to give up.
+func (t *Thresher) surrenderLease(
+	ctx context.Context, id string,
+) (repository.InstanceRecord, bool) {
+	if err := t.awaitClaim(id, "Drain"); err != nil {
+		return repository.InstanceRecord{}, false
+	}
+	defer t.releaseWake(id)
+
+	repo := t.cfg.Repository()
+
+	rec, ok, err := repo.Load(ctx, id)
+	if err != nil {
+		t.cfg.logger.Warn("couldn't load the instance to hand it off",
+			observability.AttrInstanceID, id, observability.AttrError, err.Error())
+
+		return repository.InstanceRecord{}, false
+	}
+
+	if !ok || rec.Status.IsTerminal() || rec.Lease.Owner != t.id {
+		return repository.InstanceRecord{}, false
+	}
+
+	rec.Lease.Expiry = t.cfg.Clock().Now()
+
+	if err := repo.Save(ctx, rec); err != nil {
+		if !lostClaim(err) {
+			t.cfg.logger.Warn("instance lease not surrendered; the group recovers"+
+				" it once the lease lapses",
+				observability.AttrInstanceID, id, observability.AttrError, err.Error())
+		}
+
+		return repository.InstanceRecord{}, false
+	}
+
+	return rec, true
+}
+
+// errEngineDraining is the classified refusal for work that would take an
+// instance on while the engine hands its own to the group (Drain).
+func (t *Thresher) errEngineDraining(op string) error {
+	return errs.New(
+		errs.M("%s: the thresher is draining to its engine group", op),
+		errs.C(errorClass, errs.InvalidState))
+}
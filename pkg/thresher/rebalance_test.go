@@ -0,0 +1,414 @@
+package thresher_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gobpm/pkg/clock/clocktest"
+	"github.com/dr-dobermann/gobpm/pkg/messaging"
+	"github.com/dr-dobermann/gobpm/pkg/messaging/membroker"
+	"github.com/dr-dobermann/gobpm/pkg/model/activities"
+	"github.com/dr-dobermann/gobpm/pkg/model/data"
+	"github.com/dr-dobermann/gobpm/pkg/model/events"
+	"github.com/dr-dobermann/gobpm/pkg/model/flow"
+	"github.com/dr-dobermann/gobpm/pkg/model/foundation"
+	"github.com/dr-dobermann/gobpm/pkg/model/process"
+	"github.com/dr-dobermann/gobpm/pkg/model/service"
+	"github.com/dr-dobermann/gobpm/pkg/model/service/gooper"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+	"github.com/dr-dobermann/gobpm/pkg/repository"
+	"github.com/dr-dobermann/gobpm/pkg/repository/memrepo"
+	"github.com/dr-dobermann/gobpm/pkg/thresher"
+)
+
+// Ownership across the engine group: a running engine keeps its leases alive,
+// a peer that stops renewing them is taken over by the next rescan, and a
+// draining engine hands its instances over before it stops.
+
+// upkeepEngine runs an armed engine of recoveryGroup on clk: a one-minute
+// lease renewed every 20s, rescans every 10s, and no timer takeover, so a
+// claim can only come from a rescan.
+func upkeepEngine(
+	t *testing.T, name string, repo repository.Repository,
+	clk *clocktest.Clock, p *process.Process,
+) (*thresher.Thresher, *factWatch) {
+	t.Helper()
+
+	th, err := thresher.New(name,
+		thresher.WithoutBanner(),
+		thresher.WithoutStartupConfig(),
+		thresher.WithRepository(repo),
+		thresher.WithClock(clk),
+		thresher.WithEngineGroup(recoveryGroup),
+		thresher.WithLeaseTTL(time.Minute),
+		thresher.WithLeaseHeartbeat(20*time.Second),
+		thresher.WithRecoveryRescan(10*time.Second),
+		thresher.WithTimerTakeover(24*time.Hour, 24*time.Hour))
+	require.NoError(t, err)
+
+	fw := &factWatch{}
+	sub := th.Observe(fw)
+	t.Cleanup(sub.Cancel)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	_, err = th.RegisterProcess(p)
+	require.NoError(t, err)
+	require.NoError(t, th.Run(ctx))
+
+	return th, fw
+}
+
+// leaseOf reads the lease on the instance's record.
+func leaseOf(t *testing.T, repo repository.Repository, id string) repository.Lease {
+	t.Helper()
+
+	rec, ok, err := repo.Load(context.Background(), id)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	return rec.Lease
+}
+
+// TestLeaseHeartbeatAndCrashTakeover: engine-1's instance, released to a long
+// timer, keeps its lease for as long as engine-1 runs, however long it waits,
+// and engine-2's rescans leave it alone. engine-1 then dies — its clock stops,
+// and its heartbeat and timers with it — and engine-2's rescan claims the
+// instance once the lease lapses, and fires its timer.
+func TestLeaseHeartbeatAndCrashTakeover(t *testing.T) {
+	repo := memrepo.New()
+	deadline := dehydrationEpoch.Add(2 * time.Hour)
+	clk1 := clocktest.New(dehydrationEpoch)
+	clk2 := clocktest.New(dehydrationEpoch)
+
+	var hit1, hit2 atomic.Bool
+
+	th1, fw1 := upkeepEngine(t, "engine-1", repo, clk1,
+		longTimerProc(t, "lease-keep", deadline, &hit1))
+	_, fw2 := upkeepEngine(t, "engine-2", repo, clk2,
+		longTimerProc(t, "lease-keep", deadline, &hit2))
+
+	h, err := th1.StartLatest("lease-keep")
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return fw1.saw(observability.KindInstanceState,
+			observability.PhaseDehydrated)
+	}, 3*time.Second, 5*time.Millisecond,
+		"engine-1's instance must dehydrate")
+
+	// five lease windows pass on both engines; the heartbeat keeps the lease
+	// ahead of the clock.
+	for range 30 {
+		clk1.Advance(10 * time.Second)
+		clk2.Advance(10 * time.Second)
+
+		require.Eventually(t, func() bool {
+			return leaseOf(t, repo, h.ID()).Expiry.After(clk2.Now().Add(30 * time.Second))
+		}, 3*time.Second, 5*time.Millisecond,
+			"engine-1 must keep renewing its lease")
+	}
+
+	require.False(t, fw2.saw(observability.KindInstanceState,
+		observability.PhaseRecovered), "a renewed lease keeps the instance on its engine")
+	require.Equal(t, "engine-1", leaseOf(t, repo, h.ID()).Owner)
+
+	// engine-1 dies: from here on only engine-2's clock moves.
+	require.Eventually(t, func() bool {
+		clk2.Advance(10 * time.Second)
+
+		return fw2.saw(observability.KindInstanceState,
+			observability.PhaseRecovered)
+	}, 3*time.Second, 10*time.Millisecond,
+		"engine-2's rescan must claim the dead engine's instance")
+
+	// up to a minute short of the deadline in one step; engine-2 renews the
+	// lease it took over.
+	clk2.Set(deadline.Add(-time.Minute))
+
+	require.Eventually(t, func() bool {
+		lease := leaseOf(t, repo, h.ID())
+
+		return lease.Owner == "engine-2" &&
+			lease.Expiry.After(clk2.Now().Add(30*time.Second))
+	}, 3*time.Second, 5*time.Millisecond,
+		"engine-2 must renew the lease it took over")
+
+	require.Eventually(t, func() bool {
+		clk2.Advance(10 * time.Second)
+
+		return hit2.Load()
+	}, 3*time.Second, 10*time.Millisecond,
+		"engine-2 must fire the taken-over timer")
+	require.False(t, hit1.Load())
+
+	require.Eventually(t, func() bool {
+		rec, ok, _ := repo.Load(context.Background(), h.ID())
+
+		return ok && rec.Status == repository.StatusCompleted &&
+			rec.Lease.Owner == "engine-2"
+	}, 3*time.Second, 10*time.Millisecond,
+		"the instance completes on engine-2")
+}
+
+// busyProc builds start → work → end with pinned ids; work runs op.
+func busyProc(
+	t *testing.T, key string,
+	op func(context.Context, service.DataReader, *data.ItemDefinition) (*data.ItemDefinition, error),
+) *process.Process {
+	t.Helper()
+
+	require.NoError(t, data.CreateDefaultStates())
+
+	p, err := process.New(key, foundation.WithID(key))
+	require.NoError(t, err)
+
+	start, err := events.NewStartEvent("start", foundation.WithID(key+"-start"))
+	require.NoError(t, err)
+
+	gop, err := gooper.New(key+"-work", op)
+	require.NoError(t, err)
+
+	work, err := activities.NewServiceTask("work", gop,
+		activities.WithoutParams(), foundation.WithID(key+"-work"))
+	require.NoError(t, err)
+
+	end, err := events.NewEndEvent("end", foundation.WithID(key+"-end"))
+	require.NoError(t, err)
+
+	for _, e := range []flow.Element{start, work, end} {
+		require.NoError(t, p.Add(e))
+	}
+
+	link(t, start, work)
+	link(t, work, end)
+
+	return p
+}
+
+// TestDrainHandsOffToGroup: engine-1 drains while its instance is mid-task.
+// Nothing terminal is written, the lease is surrendered, engine-1 stops, and
+// engine-2 recovers the instance and runs the task to completion.
+func TestDrainHandsOffToGroup(t *testing.T) {
+	repo := memrepo.New()
+	clk := clocktest.New(dehydrationEpoch)
+
+	entered := make(chan struct{}, 1)
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+
+	var hit2 atomic.Bool
+
+	th1, fw1 := upkeepEngine(t, "engine-1", repo, clk, busyProc(t, "drain-busy",
+		func(ctx context.Context, _ service.DataReader,
+			_ *data.ItemDefinition) (*data.ItemDefinition, error) {
+			entered <- struct{}{}
+
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+
+			case <-release:
+				return nil, nil
+			}
+		}))
+	_, fw2 := upkeepEngine(t, "engine-2", repo, clk, busyProc(t, "drain-busy",
+		func(context.Context, service.DataReader,
+			*data.ItemDefinition) (*data.ItemDefinition, error) {
+			hit2.Store(true)
+
+			return nil, nil
+		}))
+
+	h, err := th1.StartLatest("drain-busy")
+	require.NoError(t, err)
+
+	select {
+	case <-entered:
+	case <-time.After(3 * time.Second):
+		t.Fatal("engine-1 must enter the task")
+	}
+
+	require.Eventually(t, func() bool {
+		rec, ok, _ := repo.Load(context.Background(), h.ID())
+
+		return ok && rec.Status == repository.StatusActive
+	}, 3*time.Second, 5*time.Millisecond)
+
+	require.NoError(t, th1.Drain(context.Background()))
+	require.Equal(t, thresher.Stopped, th1.State())
+	require.Eventually(t, func() bool {
+		return fw1.saw(observability.KindInstanceState,
+			observability.PhaseHandedOff)
+	}, 3*time.Second, 5*time.Millisecond)
+
+	rec, ok, err := repo.Load(context.Background(), h.ID())
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, repository.StatusActive, rec.Status,
+		"a handed-off instance is not settled")
+	require.Equal(t, "engine-1", rec.Lease.Owner)
+	require.True(t, rec.Lease.Expired(clk.Now()), "the lease is surrendered")
+
+	require.Eventually(t, func() bool {
+		clk.Advance(10 * time.Second)
+
+		return fw2.saw(observability.KindInstanceState,
+			observability.PhaseRecovered)
+	}, 3*time.Second, 10*time.Millisecond,
+		"engine-2's rescan must recover the handed-off instance")
+
+	require.Eventually(t, func() bool {
+		rec, ok, _ := repo.Load(context.Background(), h.ID())
+
+		return ok && rec.Status == repository.StatusCompleted &&
+			rec.Lease.Owner == "engine-2"
+	}, 3*time.Second, 10*time.Millisecond,
+		"the instance completes on engine-2")
+	require.True(t, hit2.Load(), "the interrupted task re-runs on engine-2")
+}
+
+// withdrawLog is an annCollector that also records withdrawals.
+type withdrawLog struct {
+	annCollector
+	withdrawn []string
+}
+
+func (wl *withdrawLog) Withdraw(_ context.Context, taskID string) error {
+	wl.mu.Lock()
+	defer wl.mu.Unlock()
+
+	wl.withdrawn = append(wl.withdrawn, taskID)
+
+	return nil
+}
+
+func (wl *withdrawLog) withdrawals() []string {
+	wl.mu.Lock()
+	defer wl.mu.Unlock()
+
+	return append([]string{}, wl.withdrawn...)
+}
+
+// TestDrainReleasesHolds: instances released to a timer, a message catch and a
+// human task hand off with nothing left behind on the draining engine — no
+// held deadline, no hub subscription, no correlation reservation — and the
+// announced task is withdrawn from its distributor.
+func TestDrainReleasesHolds(t *testing.T) {
+	repo := memrepo.New()
+	broker := membroker.New()
+	dist := &withdrawLog{}
+
+	var hit atomic.Bool
+
+	th, err := thresher.New("engine-H",
+		thresher.WithoutBanner(),
+		thresher.WithoutStartupConfig(),
+		thresher.WithRepository(repo),
+		thresher.WithMessageBroker(broker),
+		thresher.WithTaskDistributor(dist),
+		thresher.WithClock(clocktest.New(dehydrationEpoch)),
+		thresher.WithLeaseTTL(time.Minute))
+	require.NoError(t, err)
+
+	fw := &factWatch{}
+	sub := th.Observe(fw)
+	t.Cleanup(sub.Cancel)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	for _, p := range []*process.Process{
+		longTimerProc(t, "drain-timer", dehydrationEpoch.Add(time.Hour), &hit),
+		msgWaitProcess(t, "drain-msg", make(chan string, 1)),
+		utProc(t, "drain-ut"),
+	} {
+		_, err := th.RegisterProcess(p)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, th.Run(ctx))
+
+	_, err = th.StartLatest("drain-timer")
+	require.NoError(t, err)
+
+	_, err = th.StartLatest("drain-ut")
+	require.NoError(t, err)
+
+	require.NoError(t, broker.Publish(ctx, messaging.Envelope{
+		Name: "order placed", Payload: "ORD-1", CorrelationKey: "ORD-1"}))
+
+	require.Eventually(t, func() bool {
+		return fw.count(observability.KindInstanceState,
+			observability.PhaseDehydrated) == 3
+	}, 3*time.Second, 10*time.Millisecond,
+		"all three instances must dehydrate")
+	require.Equal(t, 1, dist.count(), "the parked task must be announced")
+
+	ids := th.Instances(thresher.InstancesRunning)
+	require.Len(t, ids, 3)
+
+	held := 0
+	for _, id := range ids {
+		held += thresher.Holds(th, id)
+	}
+
+	require.GreaterOrEqual(t, held, 3,
+		"a deadline, a subscription and a reservation are held")
+
+	require.NoError(t, th.Drain(context.Background()))
+	require.Equal(t, 3, fw.count(observability.KindInstanceState,
+		observability.PhaseHandedOff))
+
+	for _, id := range ids {
+		require.Zero(t, thresher.Holds(th, id),
+			"a handed-off instance leaves no holds behind")
+	}
+
+	require.Equal(t, dist.taskIDs(), dist.withdrawals(),
+		"the announced task is withdrawn")
+}
+
+func TestDrainNeedsRepositoryAndRunningEngine(t *testing.T) {
+	th, err := thresher.New("engine-D",
+		thresher.WithoutBanner(), thresher.WithoutStartupConfig())
+	require.NoError(t, err)
+	require.ErrorContains(t, th.Drain(context.Background()), "WithRepository")
+
+	th, err = thresher.New("engine-D",
+		thresher.WithoutBanner(), thresher.WithoutStartupConfig(),
+		thresher.WithRepository(memrepo.New()))
+	require.NoError(t, err)
+	require.ErrorContains(t, th.Drain(context.Background()), "couldn't drain")
+}
+
+func TestLeaseUpkeepOptionsValidate(t *testing.T) {
+	for _, opt := range []thresher.Option{
+		thresher.WithLeaseHeartbeat(0),
+		thresher.WithRecoveryRescan(-time.Second),
+	} {
+		_, err := thresher.New("engine-V", opt)
+		require.Error(t, err)
+	}
+
+	_, err := thresher.New("engine-V",
+		thresher.WithLeaseTTL(time.Minute), thresher.WithLeaseHeartbeat(time.Minute))
+	require.ErrorContains(t, err, "shorter than the lease window")
+
+	_, err = thresher.New("engine-V",
+		thresher.WithLeaseTTL(time.Minute), thresher.WithLeaseHeartbeat(59*time.Second))
+	require.NoError(t, err)
+
+	_, err = thresher.New("engine-V", thresher.WithRecoveryRescan(time.Second))
+	require.ErrorContains(t, err, "needs WithLeaseHeartbeat",
+		"a rescan without renewals steals a live engine's idle instances")
+
+	_, err = thresher.New("engine-V",
+		thresher.WithLeaseHeartbeat(time.Second), thresher.WithRecoveryRescan(time.Second))
+	require.NoError(t, err)
+}
@@ -9,8 +9,10 @@ import (
 
 	"github.com/dr-dobermann/gobpm/internal/instance"
 	"github.com/dr-dobermann/gobpm/internal/instance/checkpoint"
+	"github.com/dr-dobermann/gobpm/internal/instance/snapshot"
 	"github.com/dr-dobermann/gobpm/internal/scope"
 	"github.com/dr-dobermann/gobpm/pkg/observability"
+	"github.com/dr-dobermann/gobpm/pkg/renv"
 	"github.com/dr-dobermann/gobpm/pkg/repository"
 )
 
@@ -39,6 +41,11 @@ func (t *Thresher) recoverInstances(ctx context.Context) {
 
 // recoverOne claims and rehydrates a single instance.
 func (t *Thresher) recoverOne(ctx context.Context, id string) error {
+	// a draining engine claims nothing: it would not run what it claimed.
+	if t.draining.Load() {
+		return nil
+	}
+
 	repo := t.cfg.Repository()
 	now := t.cfg.Clock().Now()
 
@@ -86,7 +93,7 @@ func (t *Thresher) recoverOne(ctx context.Context, id string) error {
 
 	rec.RecVersion++ // Save advanced the stored version; continue from it
 
-	doc, err := checkpoint.Unmarshal(rec.Payload)
+	doc, err := t.openCheckpoint(ctx, rec.ID, rec.Payload)
 	if err != nil {
 		return recoveryErr("the checkpoint doesn't decode", err)
 	}
@@ -114,6 +121,35 @@ func (t *Thresher) recoverOne(ctx context.Context, id string) error {
 			") — register it before Run", nil)
 	}
 
+	h, err := t.runClaimed(rec, doc, s, "recovery", recoveryErr)
+	if err != nil {
+		return err
+	}
+
+	h.current().Report(observability.Fact{
+		Kind:  observability.KindInstanceState,
+		Phase: observability.PhaseRecovered,
+		Details: map[string]string{
+			observability.AttrProcessID: doc.ProcessID,
+			observability.AttrVersion:   strconv.Itoa(doc.Version),
+			"live_tracks":               strconv.Itoa(len(doc.Tracks)),
+		},
+	})
+
+	return nil
+}
+
+// runClaimed restores the instance of a record this engine has just claimed
+// from its decoded checkpoint over the pinned version s, runs it, tracks it and
+// re-takes its conversation keys — the shared tail of recovery and import. It
+// returns the instance's handle; failures are built by fail, the caller's
+// classified error.
+func (t *Thresher) runClaimed(
+	rec repository.InstanceRecord, doc *checkpoint.Document,
+	s *snapshot.Snapshot, op string, fail func(string, error) error,
+) (*InstanceHandle, error) {
+	id := rec.ID
+
 	// cold restart: no pending trigger — the recorded waits re-ARM (a timer
 	// re-arms at its recorded deadline). Wake-on-trigger passes a PendingTrigger.
 	inst, err := instance.Restore(doc, s, scope.EmptyDataPath, &t.cfg, t,
@@ -124,19 +160,26 @@ func (t *Thresher) recoverOne(ctx context.Context, id string) error {
 		instance.WithCallReattacher(t.reattachChild),
 		instance.WithWaitHolders(t),
 		instance.WithCheckpointing(t.id, t.group, t.cfg.leaseTTL),
+		instance.WithCheckpointCodec(t.cfg.cpCodec),
+		instance.WithMessageOutbox(t.cfg.outbox),
 		instance.WithCheckpointCursor(rec.RecVersion, rec.Lease.Incarnation))
 	if err != nil {
-		return recoveryErr("the instance doesn't restore", err)
+		return nil, fail("the instance doesn't restore", err)
 	}
 
-	runCtx, cancel, err := t.instanceContext("recovery")
+	// The restored waits re-arm on this engine; the group's index forgets the
+	// previous owner's holds BEFORE the run, so a hold the run itself takes
+	// is indexed anew rather than dropped.
+	t.unindexTimers(id, "", "")
+
+	runCtx, cancel, err := t.instanceContext(op)
 	if err != nil {
-		return recoveryErr("the engine context is gone", err)
+		return nil, fail("the engine context is gone", err)
 	}
 	if err := inst.Run(runCtx); err != nil {
 		cancel()
 
-		return recoveryErr("the restored instance doesn't run", err)
+		return nil, fail("the restored instance doesn't run", err)
 	}
 
 	h, displaced := t.trackInstanceLocked(inst, cancel, t.settledFor(id))
@@ -146,20 +189,12 @@ func (t *Thresher) recoverOne(ctx context.Context, id string) error {
 	// A recovered conversation re-takes its correlation reservation (FIX-036
 	// §1.2): the reservation map does not survive the process, so without this
 	// the next message carrying this instance's key would start a duplicate
-	// beside the one just recovered.
+	// beside the one just recovered. Its accepted message ids re-seed the
+	// starters' memory for the same reason.
 	t.rebindKeysLocked(doc.ProcessID, id, doc.ConvKeys)
+	t.rebindMessagesLocked(doc.ProcessID, doc.AcceptedMessages)
 
-	inst.Report(observability.Fact{
-		Kind:  observability.KindInstanceState,
-		Phase: observability.PhaseRecovered,
-		Details: map[string]string{
-			observability.AttrProcessID: doc.ProcessID,
-			observability.AttrVersion:   strconv.Itoa(doc.Version),
-			"live_tracks":               strconv.Itoa(len(doc.Tracks)),
-		},
-	})
-
-	return nil
+	return h, nil
 }
 
 // ensureGroup establishes — or, under WithExistingEngineGroup, asserts
@@ -197,6 +232,32 @@ func (t *Thresher) ensureGroup(ctx context.Context) error {
 	return nil
 }
 
+// migrateDataStores runs Migrate on every registered Data Store that
+// implements renv.Migrator, in ref order; the first failure aborts the
+// start.
+func (t *Thresher) migrateDataStores(ctx context.Context) error {
+	for _, ref := range t.cfg.dataStores.Refs() {
+		store, err := t.cfg.dataStores.Store(ref)
+		if err != nil {
+			return err
+		}
+
+		m, ok := store.(renv.Migrator)
+		if !ok {
+			continue
+		}
+
+		if err := m.Migrate(ctx); err != nil {
+			return gerrs.New(
+				gerrs.M("the migration of DataStore %q failed", ref),
+				gerrs.C(errorClass, gerrs.OperationFailed),
+				gerrs.E(err))
+		}
+	}
+
+	return nil
+}
+
 // lostClaim reports whether err is the compare-and-set conflict that means
 // another engine claimed this instance first — the one Save failure that is a
 // normal outcome rather than a fault.
@@ -206,6 +267,33 @@ func lostClaim(err error) bool {
 	return errors.As(err, &ae) && ae.HasClass(gerrs.ConcurrentUpdate)
 }
 
+// openCheckpoint decodes record id's payload: the WithCheckpointCodec chain
+// undoes the codecs the payload names (a plain, pre-codec payload passes
+// through), then the document unmarshals.
+func (t *Thresher) openCheckpoint(
+	ctx context.Context, id string, payload []byte,
+) (*checkpoint.Document, error) {
+	plain, err := t.cfg.cpCodec.Decode(ctx, id, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return checkpoint.Unmarshal(plain)
+}
+
+// sealCheckpoint is openCheckpoint's inverse: it marshals doc and encodes it
+// with the WithCheckpointCodec chain for record id.
+func (t *Thresher) sealCheckpoint(
+	ctx context.Context, id string, doc *checkpoint.Document,
+) ([]byte, error) {
+	raw, err := doc.Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	return t.cfg.cpCodec.Encode(ctx, id, raw)
+}
+
 // recoveryErr builds one classified recovery error.
 func recoveryErr(msg string, cause error) error {
 	if cause == nil {
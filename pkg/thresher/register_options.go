@@ -1,5 +1,12 @@
 package thresher
 
+import (
+	"strings"
+
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/listener"
+)
+
 // registerConfig holds the per-process registration choices applied by
 // RegisterOption values at RegisterProcess (SRD-015). Its zero value is the
 // default: auto-instantiation (each instantiating start trigger registers a
@@ -9,6 +16,15 @@ type registerConfig struct {
 	// instantiation: no instance-starter is registered and the process is
 	// instantiated only via StartProcess (SRD-015 FR-9, ADR-015 §2.2).
 	manualStart bool
+	// uniqueBusinessKey, when set, refuses a second live instance of the
+	// process carrying the same business key (WithUniqueBusinessKey).
+	uniqueBusinessKey bool
+	// listeners are the process's and its elements' execution listeners
+	// (WithProcessListener, WithNodeListener), in the order given.
+	listeners []listener.Binding
+	// taskListeners are the process's UserTask lifecycle listeners
+	// (WithUserTaskListener), in the order given.
+	taskListeners []listener.TaskBinding
 }
 
 // RegisterOption tunes how a single process is registered with RegisterProcess.
@@ -30,3 +46,151 @@ func WithManualStart() RegisterOption {
 		return nil
 	}
 }
+
+// WithUniqueBusinessKey makes the business key unique per process: while an
+// instance carrying a key is live, starting another instance of the process
+// key with the same business key — explicitly (WithBusinessKey) or from a
+// message whose correlation key derives it — is refused with DuplicateObject.
+// A finished instance releases its key. The guarantee follows the LATEST
+// registered version of the key and spans every version's instances.
+//
+// The guarantee is per engine. With a Repository that implements
+// repository.BusinessKeyFinder the engine also refuses a key it finds held by
+// a live instance of another engine of the group, but that is a best-effort
+// check, not part of the guarantee: it is a lookup, not a reservation, and
+// two engines starting the same key at once both pass it. Route the starts of
+// one key to one engine (keyed messages through a partitioned broker, say)
+// where a duplicate must never happen.
+func WithUniqueBusinessKey() RegisterOption {
+	return func(c *registerConfig) error {
+		c.uniqueBusinessKey = true
+
+		return nil
+	}
+}
+
+// WithProcessListener binds an execution listener to every node of the
+// registered process: l runs on the executing token's track for each of
+// events (every event when none is given), after the engine-wide listeners and
+// before the nodes' own. A listener error fails the activity into the incident
+// machinery.
+func WithProcessListener(
+	l listener.Listener, events ...listener.Event,
+) RegisterOption {
+	return func(c *registerConfig) error {
+		b, err := bindListener("WithProcessListener", "", l, events)
+		if err != nil {
+			return err
+		}
+
+		c.listeners = append(c.listeners, b)
+
+		return nil
+	}
+}
+
+// WithNodeListener binds an execution listener to one element of the
+// registered process, by id: a node's id runs l at the node's start and end
+// and for the takes of its outgoing flows, a sequence flow's id for that
+// flow's take — each filtered by events (every event when none is given). The
+// element may be nested in a sub-process; RegisterProcess refuses an id naming
+// no element of the process with ObjectNotFound. A listener error fails the
+// activity into the incident machinery.
+func WithNodeListener(
+	elementID string, l listener.Listener, events ...listener.Event,
+) RegisterOption {
+	return func(c *registerConfig) error {
+		elementID = strings.TrimSpace(elementID)
+		if elementID == "" {
+			return errs.New(
+				errs.M("WithNodeListener: an empty element id isn't allowed"),
+				errs.C(errorClass, errs.EmptyNotAllowed))
+		}
+
+		b, err := bindListener("WithNodeListener", elementID, l, events)
+		if err != nil {
+			return err
+		}
+
+		c.listeners = append(c.listeners, b)
+
+		return nil
+	}
+}
+
+// WithUserTaskListener binds a task listener to one UserTask of the registered
+// process, by node id: l runs for each of events (every event when none is
+// given), after the engine-wide task listeners. The task may be nested in a
+// sub-process; an id naming no UserTask never matches.
+func WithUserTaskListener(
+	nodeID string, l listener.TaskListener, events ...listener.TaskEvent,
+) RegisterOption {
+	return func(c *registerConfig) error {
+		nodeID = strings.TrimSpace(nodeID)
+		if nodeID == "" {
+			return errs.New(
+				errs.M("WithUserTaskListener: an empty node id isn't allowed"),
+				errs.C(errorClass, errs.EmptyNotAllowed))
+		}
+
+		b, err := bindTaskListener("WithUserTaskListener", nodeID, l, events)
+		if err != nil {
+			return err
+		}
+
+		c.taskListeners = append(c.taskListeners, b)
+
+		return nil
+	}
+}
+
+// bindListener validates a listener option's arguments into a Binding: a nil
+// listener and an unknown event are refused, naming the option.
+func bindListener(
+	op, elementID string, l listener.Listener, events []listener.Event,
+) (listener.Binding, error) {
+	if l == nil {
+		return listener.Binding{}, errs.New(
+			errs.M("%s: a nil listener isn't allowed", op),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	for _, ev := range events {
+		if !ev.Valid() {
+			return listener.Binding{}, errs.New(
+				errs.M("%s: unknown listener event %q", op, ev),
+				errs.C(errorClass, errs.InvalidParameter))
+		}
+	}
+
+	return listener.Binding{
+		Listener:  l,
+		Events:    append([]listener.Event(nil), events...),
+		ElementID: elementID,
+	}, nil
+}
+
+// bindTaskListener is bindListener's twin for a task listener option.
+func bindTaskListener(
+	op, nodeID string, l listener.TaskListener, events []listener.TaskEvent,
+) (listener.TaskBinding, error) {
+	if l == nil {
+		return listener.TaskBinding{}, errs.New(
+			errs.M("%s: a nil listener isn't allowed", op),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	for _, ev := range events {
+		if !ev.Valid() {
+			return listener.TaskBinding{}, errs.New(
+				errs.M("%s: unknown task listener event %q", op, ev),
+				errs.C(errorClass, errs.InvalidParameter))
+		}
+	}
+
+	return listener.TaskBinding{
+		Listener: l,
+		Events:   append([]listener.TaskEvent(nil), events...),
+		NodeID:   nodeID,
+	}, nil
+}
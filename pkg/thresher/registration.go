@@ -14,6 +14,9 @@ type ProcessRegistration struct {
 	starters []*instanceStarter // auto-start starters of this version (nil in manual mode)
 	version  int                // 1-based, increments per key in registration order
 	manual   bool               // registered WithManualStart
+	// uniqueBusinessKey records WithUniqueBusinessKey; the key's latest
+	// registration decides (reserveBusinessKeyLocked).
+	uniqueBusinessKey bool
 	// wired records whether this version's starters are currently on the hub.
 	// Guarded by Thresher.m. Two paths wire starters — RegisterProcess and
 	// Run's one-time sweep — and they are not mutually exclusive, so the flag
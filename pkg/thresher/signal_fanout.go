@@ -0,0 +1,218 @@
+package thresher
+
+import (
+	"context"
+	"sync"
+
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/messaging"
+	"github.com/dr-dobermann/gobpm/pkg/model/data"
+	"github.com/dr-dobermann/gobpm/pkg/model/data/values"
+	"github.com/dr-dobermann/gobpm/pkg/model/events"
+	"github.com/dr-dobermann/gobpm/pkg/model/flow"
+	"github.com/dr-dobermann/gobpm/pkg/model/foundation"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+	"github.com/dr-dobermann/gobpm/pkg/signaling"
+	"github.com/dr-dobermann/gobpm/pkg/signaling/brokerfanout"
+)
+
+// maxReceivedSignals bounds the signal ids an engine remembers to drop a
+// repeated delivery; past it the oldest is forgotten.
+const maxReceivedSignals = 4096
+
+// The group signal fan-out. A signal is a broadcast (BPMN §10.5.1): the
+// event hub reaches every catch of its name on THIS engine, and the fan-out
+// extends that to the engine group. Every signal thrown locally is handed to
+// the signaling.Fanout stamped with the engine's id; every signal the
+// Fanout delivers from another engine is propagated through the local hub,
+// stamped with its origin. The hub's waiters include the holds the engine
+// keeps for dehydrated instances, so a remote signal wakes them like a
+// local one. A remote signal never starts an instance: its thrower's
+// engine did (instanceStarter.ProcessEvent).
+
+// resolveSignalFanout settles the engine's fan-out: an explicit
+// WithSignalFanout, or — for an engine of an explicit group — the
+// broadcast of its MessageBroker. A solo engine has no one to tell; a
+// group whose broker can't broadcast keeps its signals local, which is
+// said once at New.
+func (t *Thresher) resolveSignalFanout() error {
+	if t.cfg.signalFanout != nil || t.cfg.engineGroup == "" {
+		return nil
+	}
+
+	bc, ok := t.cfg.MessageBroker().(messaging.Broadcaster)
+	if !ok {
+		t.cfg.logger.Warn(
+			"the message broker can't broadcast; signals stay on the engine"+
+				" that throws them (WithSignalFanout carries them across the group)",
+			"engine_group", t.group)
+
+		return nil
+	}
+
+	f, err := brokerfanout.New(bc, brokerfanout.WithLogger(t.cfg.Logger()))
+	if err != nil {
+		return errs.New(
+			errs.M("couldn't build the broker signal fan-out"),
+			errs.C(errorClass, errs.BulidingFailed),
+			errs.E(err))
+	}
+
+	t.cfg.signalFanout = f
+
+	return nil
+}
+
+// listenSignals subscribes the engine to its group's signals for the
+// engine's lifetime. Without a fan-out it does nothing.
+func (t *Thresher) listenSignals(ctx context.Context) error {
+	if t.cfg.signalFanout == nil {
+		return nil
+	}
+
+	sub, err := t.cfg.signalFanout.Listen(ctx)
+	if err != nil {
+		return errs.New(
+			errs.M("couldn't listen to the engine group's signals"),
+			errs.C(errorClass, errs.OperationFailed),
+			errs.E(err))
+	}
+
+	go func() {
+		defer func() { _ = sub.Unsubscribe() }()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case sig, ok := <-sub.C():
+				if !ok {
+					return
+				}
+
+				t.receiveSignal(ctx, sig)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// receiveSignal propagates a signal another engine of the group threw. The
+// engine's own broadcasts and repeated deliveries are dropped.
+func (t *Thresher) receiveSignal(ctx context.Context, sig signaling.Signal) {
+	if sig.Origin == t.id {
+		return
+	}
+
+	if !t.receivedSignals.add(sig.ID) {
+		t.cfg.logger.Debug("repeated group signal dropped",
+			observability.AttrSignal, sig.Name, observability.AttrSignalID, sig.ID)
+
+		return
+	}
+
+	eDef, err := remoteSignalDefinition(sig)
+	if err != nil {
+		t.cfg.logger.Warn("undecodable group signal dropped",
+			observability.AttrSignal, sig.Name, observability.AttrError, err.Error())
+
+		return
+	}
+
+	if err := t.eventHub.PropagateEvent(ctx, eDef); err != nil {
+		t.cfg.logger.Warn("group signal propagation failed",
+			observability.AttrSignal, sig.Name, observability.AttrError, err.Error())
+	}
+}
+
+// broadcastSignal hands a locally thrown signal to the group. A failed
+// broadcast costs the other engines the signal, never the local throw, so
+// it is logged rather than returned.
+func (t *Thresher) broadcastSignal(ctx context.Context, eDef flow.EventDefinition) {
+	sed, ok := eDef.(*events.SignalEventDefinition)
+	if !ok || t.cfg.signalFanout == nil || sed.Origin() != "" || sed.Signal() == nil {
+		return
+	}
+
+	sig := signaling.Signal{
+		ID:     foundation.GenerateID(),
+		Name:   sed.Signal().Name(),
+		Origin: t.id,
+	}
+
+	if item := sed.Signal().Item(); item != nil {
+		sig.ItemID = item.ID()
+
+		if v := item.Structure(); v != nil {
+			sig.Payload = v.Get(ctx)
+		}
+	}
+
+	if err := t.cfg.signalFanout.Broadcast(ctx, sig); err != nil {
+		t.cfg.logger.Warn("signal not broadcast to the engine group",
+			observability.AttrSignal, sig.Name, observability.AttrError, err.Error())
+	}
+}
+
+// remoteSignalDefinition rebuilds the definition a remote signal fires: the
+// signal's name and, under its original item id, its payload — the id a
+// catch binds it by.
+func remoteSignalDefinition(sig signaling.Signal) (*events.SignalEventDefinition, error) {
+	var item *data.ItemDefinition
+
+	if sig.ItemID != "" {
+		var err error
+
+		item, err = data.NewItemDefinition(values.NewVariable(sig.Payload),
+			foundation.WithID(sig.ItemID))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	s, err := events.NewSignal(sig.Name, item)
+	if err != nil {
+		return nil, err
+	}
+
+	sed, err := events.NewSignalEventDefinition(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return sed.WithOrigin(sig.Origin), nil
+}
+
+// signalIDs is the bounded set of the signal ids an engine already took,
+// oldest first in order.
+type signalIDs struct {
+	seen  map[string]struct{}
+	order []string
+	mu    sync.Mutex
+}
+
+// add records id and reports whether it is new.
+func (s *signalIDs) add(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.seen[id]; ok {
+		return false
+	}
+
+	if s.seen == nil {
+		s.seen = map[string]struct{}{}
+	}
+
+	s.seen[id] = struct{}{}
+	s.order = append(s.order, id)
+
+	if len(s.order) > maxReceivedSignals {
+		delete(s.seen, s.order[0])
+		s.order = s.order[1:]
+	}
+
+	return true
+}
@@ -0,0 +1,262 @@
+package thresher_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gobpm/pkg/messaging"
+	"github.com/dr-dobermann/gobpm/pkg/messaging/membroker"
+	"github.com/dr-dobermann/gobpm/pkg/model/data"
+	"github.com/dr-dobermann/gobpm/pkg/model/events"
+	"github.com/dr-dobermann/gobpm/pkg/model/flow"
+	"github.com/dr-dobermann/gobpm/pkg/model/foundation"
+	"github.com/dr-dobermann/gobpm/pkg/model/process"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+	"github.com/dr-dobermann/gobpm/pkg/repository"
+	"github.com/dr-dobermann/gobpm/pkg/repository/memrepo"
+	"github.com/dr-dobermann/gobpm/pkg/signaling"
+	"github.com/dr-dobermann/gobpm/pkg/thresher"
+)
+
+// The group signal fan-out: a signal thrown on one engine of a group
+// reaches the catches on every other — dehydrated ones included — once,
+// and a signal start instantiates once per group.
+
+// groupEngine runs an engine of the group "signals" over the shared store
+// and broker, with procs registered BEFORE Run (deployment parity).
+func groupEngine(
+	t *testing.T, name string, repo repository.Repository,
+	broker messaging.MessageBroker, procs ...*process.Process,
+) (*thresher.Thresher, *factWatch) {
+	t.Helper()
+
+	th, err := thresher.New(name,
+		thresher.WithoutBanner(), thresher.WithoutStartupConfig(),
+		thresher.WithRepository(repo),
+		thresher.WithMessageBroker(broker),
+		thresher.WithEngineGroup("signals"),
+		thresher.WithLeaseTTL(time.Minute))
+	require.NoError(t, err)
+
+	fw := &factWatch{}
+	sub := th.Observe(fw)
+	t.Cleanup(sub.Cancel)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	for _, p := range procs {
+		_, err = th.RegisterProcess(p)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, th.Run(ctx))
+
+	return th, fw
+}
+
+// TestGroupSignalWakesDehydrated: a signal thrown by an instance on engine
+// A wakes the instance dehydrated on a catch of engine B.
+func TestGroupSignalWakesDehydrated(t *testing.T) {
+	repo := memrepo.New()
+	broker := membroker.New()
+
+	var hit atomic.Bool
+
+	thrower := signalThrowProcess(t, "grp-sig-throw", "go-live")
+
+	thA, _ := groupEngine(t, "engine-A", repo, broker, thrower)
+	thB, fwB := groupEngine(t, "engine-B", repo, broker,
+		signalWaitProcess(t, "grp-sig-wait", &hit))
+
+	h, err := thB.StartLatest("grp-sig-wait")
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return fwB.saw(observability.KindInstanceState,
+			observability.PhaseDehydrated)
+	}, 3*time.Second, 10*time.Millisecond,
+		"the instance parked on the signal catch must dehydrate")
+
+	_, err = thA.StartLatest(thrower.ID())
+	require.NoError(t, err)
+
+	require.Eventually(t, hit.Load, 3*time.Second, 10*time.Millisecond,
+		"the signal thrown on A must wake the instance dehydrated on B")
+
+	require.Eventually(t, func() bool {
+		rec, ok, _ := repo.Load(context.Background(), h.ID())
+
+		return ok && rec.Status == repository.StatusCompleted
+	}, 3*time.Second, 10*time.Millisecond,
+		"the woken instance must run to completion")
+}
+
+// TestGroupSignalStartsOnce: both engines deploy a signal-start process;
+// a signal thrown on A instantiates it on A only, not once per engine.
+func TestGroupSignalStartsOnce(t *testing.T) {
+	repo := memrepo.New()
+	broker := membroker.New()
+	done := make(chan string, 4)
+
+	thA, _ := groupEngine(t, "engine-A", repo, broker,
+		signalStartProcess(t, "grp-sig-start", "GO", "A", done))
+	groupEngine(t, "engine-B", repo, broker,
+		signalStartProcess(t, "grp-sig-start", "GO", "B", done))
+
+	require.NoError(t, thA.PropagateEvent(context.Background(), sigDef(t, "GO")))
+
+	select {
+	case got := <-done:
+		require.Equal(t, "A", got, "the thrower's engine starts the instance")
+	case <-time.After(3 * time.Second):
+		t.Fatal("the signal did not instantiate the signal-start process")
+	}
+
+	select {
+	case got := <-done:
+		t.Fatalf("the group started a second instance on %q", got)
+	case <-time.After(300 * time.Millisecond):
+	}
+}
+
+// chanFanout is a signaling.Fanout the test drives: Listen hands out in,
+// Broadcast records what the engine threw.
+type chanFanout struct {
+	in   chan signaling.Signal
+	out  []signaling.Signal
+	mu   sync.Mutex
+	once sync.Once
+}
+
+func (f *chanFanout) Broadcast(_ context.Context, sig signaling.Signal) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.out = append(f.out, sig)
+
+	return nil
+}
+
+func (f *chanFanout) Listen(context.Context) (signaling.Subscription, error) {
+	return f, nil
+}
+
+func (f *chanFanout) C() <-chan signaling.Signal { return f.in }
+
+func (f *chanFanout) Unsubscribe() error { return nil }
+
+func (f *chanFanout) thrown() []signaling.Signal {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]signaling.Signal(nil), f.out...)
+}
+
+// twoCatchProcess builds start → catch(name) → lane → catch(name) → end:
+// the lane marks the first catch fired.
+func twoCatchProcess(
+	t *testing.T, key, name string, hit *atomic.Bool,
+) *process.Process {
+	t.Helper()
+
+	require.NoError(t, data.CreateDefaultStates())
+
+	proc, err := process.New(key, foundation.WithID(key))
+	require.NoError(t, err)
+
+	start, err := events.NewStartEvent("start")
+	require.NoError(t, err)
+
+	first, err := events.NewIntermediateCatchEvent("first", sigDef(t, name))
+	require.NoError(t, err)
+
+	lane := pinnedLane(t, key+"-lane", hit)
+
+	second, err := events.NewIntermediateCatchEvent("second", sigDef(t, name))
+	require.NoError(t, err)
+
+	end, err := events.NewEndEvent("end")
+	require.NoError(t, err)
+
+	for _, e := range []flow.Element{start, first, lane, second, end} {
+		require.NoError(t, proc.Add(e))
+	}
+
+	link(t, start, first)
+	link(t, first, lane)
+	link(t, lane, second)
+	link(t, second, end)
+
+	return proc
+}
+
+// TestGroupSignalDedup: a signal delivered twice fires once, the engine's
+// own broadcast is ignored, and a local throw is broadcast stamped with the
+// engine's id.
+func TestGroupSignalDedup(t *testing.T) {
+	fan := &chanFanout{in: make(chan signaling.Signal, 4)}
+
+	var hit atomic.Bool
+
+	proc := twoCatchProcess(t, "grp-sig-dedup", "tick", &hit)
+
+	th, err := thresher.New("engine-D",
+		thresher.WithoutBanner(), thresher.WithoutStartupConfig(),
+		thresher.WithSignalFanout(fan))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	require.NoError(t, th.Run(ctx))
+	_, err = th.RegisterProcess(proc)
+	require.NoError(t, err)
+
+	h, err := th.StartLatest(proc.ID())
+	require.NoError(t, err)
+
+	waitSignalCatchers(t, th, "tick", 1)
+
+	fan.in <- signaling.Signal{ID: "s-1", Name: "tick", Origin: "engine-E"}
+
+	require.Eventually(t, hit.Load, 3*time.Second, 10*time.Millisecond,
+		"the remote signal must fire the first catch")
+	waitSignalCatchers(t, th, "tick", 1)
+
+	fan.in <- signaling.Signal{ID: "s-1", Name: "tick", Origin: "engine-E"}
+	fan.in <- signaling.Signal{ID: "s-2", Name: "tick", Origin: "engine-D"}
+
+	wctx, wcc := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer wcc()
+
+	_, err = h.WaitCompletion(wctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded,
+		"a repeated delivery and the engine's own broadcast must fire nothing")
+
+	require.NoError(t, th.PropagateEvent(ctx, sigDef(t, "tick")))
+
+	wctx2, wcc2 := context.WithTimeout(context.Background(), 3*time.Second)
+	defer wcc2()
+
+	st, err := h.WaitCompletion(wctx2)
+	require.NoError(t, err)
+	require.Equal(t, thresher.StateCompleted, st)
+
+	thrown := fan.thrown()
+	require.Len(t, thrown, 1, "only the local throw is broadcast")
+	require.Equal(t, "tick", thrown[0].Name)
+	require.Equal(t, "engine-D", thrown[0].Origin)
+	require.NotEmpty(t, thrown[0].ID)
+}
+
+func TestWithSignalFanoutRejectsNil(t *testing.T) {
+	_, err := thresher.New("engine-N", thresher.WithSignalFanout(nil))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "WithSignalFanout")
+}
@@ -0,0 +1,251 @@
+package thresher
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/dr-dobermann/gobpm/internal/instance/snapshot"
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/model/data"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+)
+
+// startConfig holds the per-instance choices applied by StartOption values at
+// StartProcess / StartLatest / StartVersion. Its zero value starts an instance
+// with nothing but its process's own properties.
+type startConfig struct {
+	// vars are the host-passed start variables, in the order given; validated
+	// against the started version's declarations before launch.
+	vars []data.Data
+	// businessKey is the host's identifier for the instance (WithBusinessKey);
+	// empty starts a keyless instance.
+	businessKey string
+}
+
+// StartOption tunes a single instance start. The default (no option) starts the
+// instance with its process's properties only.
+type StartOption func(*startConfig) error
+
+// WithStartVariables passes initial variables to the started instance. They are
+// validated against the process's declarations — its input set
+// (process.WithInputs) and its properties — and committed into the instance's
+// root scope before the first token leaves the start event, so they are in its
+// first checkpoint. A variable named like a property overrides the property's
+// initial value and must match its type. A nil datum is refused; the option may
+// be given more than once.
+func WithStartVariables(dd ...data.Data) StartOption {
+	return func(c *startConfig) error {
+		for _, d := range dd {
+			if d == nil {
+				return errs.New(
+					errs.M("a nil start variable isn't allowed"),
+					errs.C(errorClass, errs.EmptyNotAllowed))
+			}
+
+			c.vars = append(c.vars, d)
+		}
+
+		return nil
+	}
+}
+
+// WithStartValues is the typed-map form of WithStartVariables: each entry
+// becomes a Ready variable named by its key. Entries are added in key order,
+// so a failure is reported deterministically. A nil value is refused.
+func WithStartValues(vv map[string]data.Value) StartOption {
+	return func(c *startConfig) error {
+		for _, name := range slices.Sorted(maps.Keys(vv)) {
+			v := vv[name]
+			if v == nil {
+				return errs.New(
+					errs.M("start variable %q has a nil value", name),
+					errs.C(errorClass, errs.EmptyNotAllowed),
+					errs.D(observability.AttrDataName, name))
+			}
+
+			p, err := data.ReadyValueParameter(name, v)
+			if err != nil {
+				return err
+			}
+
+			c.vars = append(c.vars, p)
+		}
+
+		return nil
+	}
+}
+
+// WithBusinessKey gives the started instance its business key — the host's
+// own identifier for it (an order number, a case id). The key survives
+// dehydration and restart, finds the instance again through
+// Thresher.InstanceByBusinessKey, and is unique among the live instances of a
+// process registered WithUniqueBusinessKey. Surrounding blanks are trimmed; an
+// empty key is refused.
+func WithBusinessKey(key string) StartOption {
+	return func(c *startConfig) error {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			return errs.New(
+				errs.M("an empty business key isn't allowed"),
+				errs.C(errorClass, errs.EmptyNotAllowed))
+		}
+
+		c.businessKey = key
+
+		return nil
+	}
+}
+
+// applyStartOptions folds opts into a startConfig. A failing option is wrapped
+// as an invalid parameter, the RegisterProcess shape.
+func applyStartOptions(opts []StartOption) (startConfig, error) {
+	var sc startConfig
+
+	for _, o := range opts {
+		if o == nil {
+			return sc, errs.New(
+				errs.M("a nil start option isn't allowed"),
+				errs.C(errorClass, errs.EmptyNotAllowed))
+		}
+
+		if err := o(&sc); err != nil {
+			return sc, errs.New(
+				errs.M("invalid start option"),
+				errs.C(errorClass, errs.InvalidParameter),
+				errs.E(err))
+		}
+	}
+
+	return sc, nil
+}
+
+// startData validates the host-passed start variables against the started
+// version s and returns the engine's own Ready copies of them, ready to seed
+// the root scope. The copies isolate the instance from the caller: a host that
+// mutates a passed value after StartProcess returns touches nothing inside.
+//
+// The rules, checked in order:
+//   - a name appears at most once (DuplicateObject);
+//   - a name may not shadow a Data Object — it is a container, not a variable
+//     (InvalidParameter);
+//   - when the process declares an input set, every name is an input or a
+//     property (InvalidParameter), and every required input is passed
+//     (EmptyNotAllowed); without an input set, undeclared names are free
+//     variables;
+//   - a declared name's value type matches its ItemDefinition
+//     (TypeCastingError) — an input's declaration wins over a property's.
+func startData(s *snapshot.Snapshot, vars []data.Data) ([]data.Data, error) {
+	if len(vars) == 0 {
+		return nil, checkRequiredInputs(s, nil)
+	}
+
+	declared := make(map[string]*data.ItemDefinition,
+		len(s.Properties)+len(s.Inputs))
+	for _, p := range s.Properties {
+		declared[p.Name()] = p.ItemDefinition()
+	}
+
+	inputs := make(map[string]struct{}, len(s.Inputs))
+	for _, in := range s.Inputs {
+		inputs[in.Name()] = struct{}{}
+		declared[in.Name()] = in.ItemDefinition()
+	}
+
+	containers := make(map[string]struct{}, len(s.DataObjects))
+	for _, do := range s.DataObjects {
+		containers[do.Name()] = struct{}{}
+	}
+
+	passed := make(map[string]struct{}, len(vars))
+	out := make([]data.Data, 0, len(vars))
+
+	for _, d := range vars {
+		name := d.Name()
+
+		if _, dup := passed[name]; dup {
+			return nil, startVarErr(s, name, errs.DuplicateObject,
+				"start variable %q is passed more than once")
+		}
+
+		passed[name] = struct{}{}
+
+		if _, ok := containers[name]; ok {
+			return nil, startVarErr(s, name, errs.InvalidParameter,
+				"start variable %q collides with a data object")
+		}
+
+		item, ok := declared[name]
+		if !ok && len(inputs) != 0 {
+			return nil, startVarErr(s, name, errs.InvalidParameter,
+				"start variable %q is neither a process input nor a property")
+		}
+
+		cp, err := startCopy(s, d, item)
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, cp)
+	}
+
+	if err := checkRequiredInputs(s, passed); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// checkRequiredInputs fails when a required (non-optional) input of s is
+// missing from passed.
+func checkRequiredInputs(s *snapshot.Snapshot, passed map[string]struct{}) error {
+	for _, in := range s.Inputs {
+		if in.IsOptional() {
+			continue
+		}
+
+		if _, ok := passed[in.Name()]; !ok {
+			return startVarErr(s, in.Name(), errs.EmptyNotAllowed,
+				"required process input %q isn't passed")
+		}
+	}
+
+	return nil
+}
+
+// startCopy type-checks d against its declaration item (nil for a free
+// variable) and returns an isolated Ready copy of it. A value-less datum is
+// refused: a start variable exists to carry a value.
+func startCopy(
+	s *snapshot.Snapshot, d data.Data, item *data.ItemDefinition,
+) (data.Data, error) {
+	v := d.Value()
+	if v == nil {
+		return nil, startVarErr(s, d.Name(), errs.EmptyNotAllowed,
+			"start variable %q has no value")
+	}
+
+	if item != nil && item.Structure() != nil {
+		if want := item.Structure().Type(); v.Type() != want {
+			return nil, errs.New(
+				errs.M("start variable %q type mismatch: want %q, got %q",
+					d.Name(), want, v.Type()),
+				errs.C(errorClass, errs.TypeCastingError),
+				errs.D(observability.AttrProcessID, s.ProcessID),
+				errs.D(observability.AttrDataName, d.Name()))
+		}
+	}
+
+	return data.ReadyValueParameter(d.Name(), v.Clone())
+}
+
+// startVarErr builds a classified start-variable error naming the variable.
+func startVarErr(
+	s *snapshot.Snapshot, name, class, format string,
+) error {
+	return errs.New(
+		errs.M(format, name),
+		errs.C(errorClass, class),
+		errs.D(observability.AttrProcessID, s.ProcessID),
+		errs.D(observability.AttrDataName, name))
+}
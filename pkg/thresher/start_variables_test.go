@@ -0,0 +1,286 @@
+package thresher_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gobpm/internal/instance/checkpoint"
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/model/activities"
+	"github.com/dr-dobermann/gobpm/pkg/model/data"
+	"github.com/dr-dobermann/gobpm/pkg/model/data/values"
+	dataobjects "github.com/dr-dobermann/gobpm/pkg/model/data_objects"
+	"github.com/dr-dobermann/gobpm/pkg/model/events"
+	"github.com/dr-dobermann/gobpm/pkg/model/flow"
+	"github.com/dr-dobermann/gobpm/pkg/model/options"
+	"github.com/dr-dobermann/gobpm/pkg/model/process"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+	"github.com/dr-dobermann/gobpm/pkg/repository/memrepo"
+	"github.com/dr-dobermann/gobpm/pkg/thresher"
+)
+
+// startVarsProcess builds start -> work(service, sleeping) -> end with the
+// given process options, so the instance is still running while a test reads
+// its data.
+func startVarsProcess(
+	t *testing.T, id string, opts ...options.Option,
+) *process.Process {
+	t.Helper()
+
+	require.NoError(t, data.CreateDefaultStates())
+
+	proc, err := process.New(id, opts...)
+	require.NoError(t, err)
+
+	start, err := events.NewStartEvent("start")
+	require.NoError(t, err)
+
+	work, err := activities.NewServiceTask("work",
+		nopOp(t, "work-op", 300*time.Millisecond), activities.WithoutParams())
+	require.NoError(t, err)
+
+	end, err := events.NewEndEvent("end")
+	require.NoError(t, err)
+
+	for _, e := range []flow.Element{start, work, end} {
+		require.NoError(t, proc.Add(e))
+	}
+
+	link(t, start, work)
+	link(t, work, end)
+
+	return proc
+}
+
+func readyParam(t *testing.T, name string, v data.Value) *data.Parameter {
+	t.Helper()
+
+	p, err := data.ReadyValueParameter(name, v)
+	require.NoError(t, err)
+
+	return p
+}
+
+func readVar(t *testing.T, h *thresher.InstanceHandle, name string) any {
+	t.Helper()
+
+	d, err := h.Data().GetData(name)
+	require.NoError(t, err)
+
+	return d.Value().Get(context.Background())
+}
+
+// TestStartVariablesSeedRootScope verifies both option forms land in the root
+// scope, a start variable overrides a property's initial value, and the
+// instance holds its own copy of a passed value.
+func TestStartVariablesSeedRootScope(t *testing.T) {
+	require.NoError(t, data.CreateDefaultStates())
+
+	amount := data.MustProperty("amount",
+		data.MustItemDefinition(values.NewVariable(0)), data.ReadyDataState)
+
+	proc := startVarsProcess(t, "sv-seed", data.WithProperties(amount))
+	th, err := thresher.New("test-" + proc.ID())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	require.NoError(t, th.Run(ctx))
+
+	reg, err := th.RegisterProcess(proc)
+	require.NoError(t, err)
+
+	order := values.NewVariable("ord-1")
+
+	h, err := th.StartProcess(reg,
+		thresher.WithStartVariables(readyParam(t, "order_id", order)),
+		thresher.WithStartValues(map[string]data.Value{
+			"amount": values.NewVariable(42),
+		}))
+	require.NoError(t, err)
+
+	// the caller's value is not shared with the instance
+	require.NoError(t, order.Update(context.Background(), "changed"))
+
+	require.Equal(t, "ord-1", readVar(t, h, "order_id"))
+	require.Equal(t, 42, readVar(t, h, "amount"))
+}
+
+// TestStartVariablesValidation verifies the declaration checks — input set,
+// required inputs, property and input types, duplicates, data-object
+// collisions and nil data — each with its own class.
+func TestStartVariablesValidation(t *testing.T) {
+	require.NoError(t, data.CreateDefaultStates())
+
+	amount := data.MustProperty("amount",
+		data.MustItemDefinition(values.NewVariable(0)), data.ReadyDataState)
+
+	orderIn := readyParam(t, "order_id", values.NewVariable(""))
+
+	noteIn, err := data.NewParameter("note",
+		data.MustItemAwareElement(
+			data.MustItemDefinition(values.NewVariable("")),
+			data.ReadyDataState),
+		data.Optional())
+	require.NoError(t, err)
+
+	proc := startVarsProcess(t, "sv-validate",
+		data.WithProperties(amount),
+		process.WithInputs(orderIn, noteIn))
+	th, cancel := runEngine(t, proc)
+	defer cancel()
+
+	order := readyParam(t, "order_id", values.NewVariable("ord-1"))
+
+	for _, tc := range []struct {
+		name  string
+		opts  []thresher.StartOption
+		class string
+	}{
+		{
+			name:  "required input missing",
+			class: errs.EmptyNotAllowed,
+		},
+		{
+			name: "unknown variable with an input set",
+			opts: []thresher.StartOption{thresher.WithStartVariables(
+				order, readyParam(t, "stray", values.NewVariable(1)))},
+			class: errs.InvalidParameter,
+		},
+		{
+			name: "input type mismatch",
+			opts: []thresher.StartOption{thresher.WithStartValues(
+				map[string]data.Value{"order_id": values.NewVariable(7)})},
+			class: errs.TypeCastingError,
+		},
+		{
+			name: "property type mismatch",
+			opts: []thresher.StartOption{thresher.WithStartVariables(
+				order, readyParam(t, "amount", values.NewVariable("lots")))},
+			class: errs.TypeCastingError,
+		},
+		{
+			name: "duplicate name",
+			opts: []thresher.StartOption{
+				thresher.WithStartVariables(order),
+				thresher.WithStartValues(map[string]data.Value{
+					"order_id": values.NewVariable("ord-2"),
+				}),
+			},
+			class: errs.DuplicateObject,
+		},
+		{
+			name:  "nil datum",
+			opts:  []thresher.StartOption{thresher.WithStartVariables(nil)},
+			class: errs.InvalidParameter,
+		},
+		{
+			name: "nil value",
+			opts: []thresher.StartOption{thresher.WithStartValues(
+				map[string]data.Value{"order_id": nil})},
+			class: errs.InvalidParameter,
+		},
+		{
+			name:  "nil option",
+			opts:  []thresher.StartOption{nil},
+			class: errs.EmptyNotAllowed,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h, err := th.StartLatest(proc.ID(), tc.opts...)
+			require.Error(t, err)
+			require.Nil(t, h)
+			requireClass(t, err, tc.class)
+		})
+	}
+
+	t.Run("optional input may be omitted", func(t *testing.T) {
+		h, err := th.StartLatest(proc.ID(), thresher.WithStartVariables(order))
+		require.NoError(t, err)
+		require.Equal(t, "ord-1", readVar(t, h, "order_id"))
+	})
+}
+
+// TestStartVariablesDataObjectCollision verifies a start variable can't
+// replace a Data Object — a container, not a variable.
+func TestStartVariablesDataObjectCollision(t *testing.T) {
+	proc := startVarsProcess(t, "sv-dobj")
+
+	do, err := dataobjects.New("basket",
+		data.MustItemDefinition(values.NewVariable(0)), data.ReadyDataState)
+	require.NoError(t, err)
+	require.NoError(t, proc.Add(do))
+
+	th, cancel := runEngine(t, proc)
+	defer cancel()
+
+	_, err = th.StartLatest(proc.ID(),
+		thresher.WithStartValues(map[string]data.Value{
+			"basket": values.NewVariable(1),
+		}))
+	require.Error(t, err)
+	requireClass(t, err, errs.InvalidParameter)
+}
+
+// TestStartVariablesFactAndCheckpoint verifies the passed names ride the
+// Active transition fact (names only — the masking rule) and the values are in
+// the instance's first checkpoint.
+func TestStartVariablesFactAndCheckpoint(t *testing.T) {
+	repo := memrepo.New()
+	proc := startVarsProcess(t, "sv-durable")
+
+	th, fw, cancel := bootEngine(t, "sv-engine", repo, time.Minute, proc)
+	defer cancel()
+
+	h, err := th.StartLatest(proc.ID(),
+		thresher.WithStartValues(map[string]data.Value{
+			"order_id": values.NewVariable("ord-secret"),
+			"amount":   values.NewVariable(42),
+		}))
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		fw.mu.Lock()
+		defer fw.mu.Unlock()
+
+		for _, f := range fw.facts {
+			if f.Kind == observability.KindInstanceState &&
+				f.Phase == observability.Phase("Active") &&
+				f.Details[observability.AttrStartVariables] == "amount,order_id" {
+				for _, v := range f.Details {
+					require.NotContains(t, v, "ord-secret")
+				}
+
+				return true
+			}
+		}
+
+		return false
+	}, 2*time.Second, 5*time.Millisecond)
+
+	require.Eventually(t, func() bool {
+		rec, ok, _ := repo.Load(context.Background(), h.ID())
+		if !ok {
+			return false
+		}
+
+		d, err := checkpoint.Unmarshal(rec.Payload)
+		if err != nil {
+			return false
+		}
+
+		for _, sr := range d.Scopes {
+			if strings.Contains(string(sr.Data), "ord-secret") {
+				return true
+			}
+		}
+
+		return false
+	}, 2*time.Second, 5*time.Millisecond,
+		"the first checkpoint must carry the start variables")
+}
@@ -151,14 +151,26 @@ func (t *Thresher) dropHold(key subKey, h *subHolder) {
 func (t *Thresher) ReleaseWaits(instanceID, trackID string) {
 	if t.timerSvc != nil {
 		t.timerSvc.release(instanceID, trackID)
+
+		// a draining engine hands the instance off: its index entries are
+		// what the group takes over.
+		if !t.draining.Load() {
+			t.unindexTimers(instanceID, trackID, "")
+		}
 	}
 
+	t.withdrawSubscriptions(instanceID, trackID)
+}
+
+// withdrawSubscriptions takes the instance's held subscriptions off the hub:
+// the track's, or every track's when trackID is empty.
+func (t *Thresher) withdrawSubscriptions(instanceID, trackID string) {
 	t.subMu.Lock()
 
 	var dropped []*subHolder
 
 	for k, h := range t.subs {
-		if k.instanceID == instanceID && k.trackID == trackID {
+		if k.instanceID == instanceID && (trackID == "" || k.trackID == trackID) {
 			dropped = append(dropped, h)
 
 			delete(t.subs, k)
@@ -201,9 +213,10 @@ func (t *Thresher) wakeFromSubscription(
 }
 
 // reportDropOrFailure classifies a wake's outcome: nothing to say on success, a
-// Debug line when the trigger simply belonged to ANOTHER conversation (a benign
-// drop — the instance stays dehydrated, exactly as a resident one would drop
-// the message and stay parked), and the loud operator fact for everything else.
+// Debug line when the trigger simply belonged to ANOTHER conversation or was a
+// redelivery (a benign drop — the instance stays dehydrated, exactly as a
+// resident one would drop the message and stay parked), and the loud operator
+// fact for everything else.
 func (t *Thresher) reportDropOrFailure(
 	h *subHolder, eDef flow.EventDefinition, err error,
 ) {
@@ -220,5 +233,13 @@ func (t *Thresher) reportDropOrFailure(
 		return
 	}
 
+	if errors.As(err, &ae) && ae.HasClass(duplicateDropClass) {
+		t.cfg.logger.Debug("a wake trigger is a redelivered message",
+			observability.AttrInstanceID, h.instanceID, observability.AttrTrackID, h.trackID,
+			observability.AttrEventDefinitionID, eDef.ID())
+
+		return
+	}
+
 	t.reportWakeFailure(h.instanceID, err)
 }
@@ -0,0 +1,548 @@
+package thresher_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gobpm/pkg/clock/clocktest"
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/messaging"
+	"github.com/dr-dobermann/gobpm/pkg/messaging/membroker"
+	"github.com/dr-dobermann/gobpm/pkg/model/activities"
+	"github.com/dr-dobermann/gobpm/pkg/model/bpmncommon"
+	"github.com/dr-dobermann/gobpm/pkg/model/data"
+	"github.com/dr-dobermann/gobpm/pkg/model/data/values"
+	"github.com/dr-dobermann/gobpm/pkg/model/events"
+	"github.com/dr-dobermann/gobpm/pkg/model/flow"
+	"github.com/dr-dobermann/gobpm/pkg/model/foundation"
+	"github.com/dr-dobermann/gobpm/pkg/model/gateways"
+	"github.com/dr-dobermann/gobpm/pkg/model/process"
+	"github.com/dr-dobermann/gobpm/pkg/model/service"
+	"github.com/dr-dobermann/gobpm/pkg/model/service/gooper"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+	"github.com/dr-dobermann/gobpm/pkg/repository"
+	"github.com/dr-dobermann/gobpm/pkg/repository/memrepo"
+	"github.com/dr-dobermann/gobpm/pkg/thresher"
+)
+
+// gatedProcess builds start → work → after → end, where work signals entered
+// and then blocks until release is closed, and after records that it ran.
+func gatedProcess(
+	t *testing.T, id string, entered, release chan struct{}, ran *atomic.Bool,
+) *process.Process {
+	t.Helper()
+
+	op, err := gooper.New("gated-op",
+		func(_ context.Context, _ service.DataReader,
+			_ *data.ItemDefinition) (*data.ItemDefinition, error) {
+			close(entered)
+			<-release
+
+			return nil, nil
+		})
+	require.NoError(t, err)
+
+	proc, err := process.New(id)
+	require.NoError(t, err)
+
+	start, err := events.NewStartEvent("start")
+	require.NoError(t, err)
+
+	work, err := activities.NewServiceTask("work", op, activities.WithoutParams())
+	require.NoError(t, err)
+
+	after := pinnedLane(t, id+"-after", ran)
+
+	end, err := events.NewEndEvent("end")
+	require.NoError(t, err)
+
+	for _, e := range []flow.Element{start, work, after, end} {
+		require.NoError(t, proc.Add(e))
+	}
+
+	link(t, start, work)
+	link(t, work, after)
+	link(t, after, end)
+
+	return proc
+}
+
+// observedEngine runs a volatile engine with a fact watch and registers proc.
+func observedEngine(
+	t *testing.T, proc *process.Process,
+) (*thresher.Thresher, *factWatch) {
+	t.Helper()
+
+	th, err := thresher.New("suspend-"+proc.ID(),
+		thresher.WithoutBanner(), thresher.WithoutStartupConfig())
+	require.NoError(t, err)
+
+	fw := &factWatch{}
+	sub := th.Observe(fw)
+	t.Cleanup(sub.Cancel)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	require.NoError(t, th.Run(ctx))
+
+	_, err = th.RegisterProcess(proc)
+	require.NoError(t, err)
+
+	return th, fw
+}
+
+// TestSuspendHoldsTheToken verifies a suspension lets the executing step run
+// to its transition but keeps the token from the next node until Resume, and
+// that both acts are observable.
+func TestSuspendHoldsTheToken(t *testing.T) {
+	entered, release := make(chan struct{}), make(chan struct{})
+
+	var ran atomic.Bool
+
+	proc := gatedProcess(t, "susp-resident", entered, release, &ran)
+	th, fw := observedEngine(t, proc)
+
+	h, err := th.StartLatest(proc.ID())
+	require.NoError(t, err)
+
+	<-entered
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	require.NoError(t, h.Suspend(ctx))
+	require.Equal(t, thresher.StateSuspended, h.State())
+	require.NoError(t, h.Suspend(ctx), "a second Suspend is a no-op")
+
+	close(release)
+
+	require.Never(t, ran.Load, 200*time.Millisecond, 10*time.Millisecond,
+		"no token moves past a suspension")
+	require.Equal(t, thresher.StateSuspended, h.State())
+
+	require.NoError(t, h.Resume(ctx))
+
+	st, err := h.WaitCompletion(ctx)
+	require.NoError(t, err)
+	require.Equal(t, thresher.StateCompleted, st)
+	require.True(t, ran.Load())
+
+	require.Equal(t, 1, fw.count(observability.KindInstanceState,
+		observability.PhasePaused))
+	require.Equal(t, 1, fw.count(observability.KindInstanceState,
+		observability.PhaseResumed))
+}
+
+// TestSuspendRefusals verifies Resume of a running instance is a no-op and a
+// finished instance refuses both operations with InvalidState.
+func TestSuspendRefusals(t *testing.T) {
+	proc := linearProcess(t, "susp-refusals", 0)
+	th, cancel := runEngine(t, proc)
+	defer cancel()
+
+	h, err := th.StartLatest(proc.ID())
+	require.NoError(t, err)
+
+	ctx, wcancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer wcancel()
+
+	st, err := h.WaitCompletion(ctx)
+	require.NoError(t, err)
+	require.Equal(t, thresher.StateCompleted, st)
+
+	err = h.Suspend(ctx)
+	require.Error(t, err)
+	requireClass(t, err, errs.InvalidState)
+
+	err = h.Resume(ctx)
+	require.Error(t, err)
+	requireClass(t, err, errs.InvalidState)
+
+	_, err = th.ResumeInstance(ctx, "no-such-instance")
+	require.Error(t, err)
+	requireClass(t, err, errs.ObjectNotFound)
+}
+
+// TestSuspendedInstanceCancels verifies Cancel still terminates a suspended
+// instance.
+func TestSuspendedInstanceCancels(t *testing.T) {
+	proc := blockingProcess(t, "susp-cancel")
+	th, cancel := runEngine(t, proc)
+	defer cancel()
+
+	h, err := th.StartLatest(proc.ID())
+	require.NoError(t, err)
+
+	ctx, wcancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer wcancel()
+
+	require.NoError(t, h.Suspend(ctx))
+	require.NoError(t, h.Resume(ctx))
+	require.NoError(t, h.Resume(ctx), "resuming an active instance is a no-op")
+	require.NoError(t, h.Suspend(ctx))
+
+	st, err := h.Cancel(ctx)
+	require.NoError(t, err)
+	require.Equal(t, thresher.StateTerminated, st)
+}
+
+// TestSuspendReleasedInstance verifies a suspension over a dehydrated instance
+// is persisted and keeps recovery and its timer away from it: the record reads
+// Suspended, the recovery listing skips it, and a deadline passing meanwhile
+// fires nothing. Resume fires the overdue timer once and the instance
+// completes.
+func TestSuspendReleasedInstance(t *testing.T) {
+	repo := memrepo.New()
+	deadline := dehydrationEpoch.Add(2 * time.Hour)
+
+	var hit atomic.Bool
+
+	p := longTimerProc(t, "susp-dehy", deadline, &hit)
+
+	th, fw, clk, cancel := bootDehydrationEngine(t, "engine-SD", repo, p)
+	defer cancel()
+
+	h, err := th.StartLatest(p.ID())
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return fw.saw(observability.KindInstanceState,
+			observability.PhaseDehydrated)
+	}, 3*time.Second, 10*time.Millisecond)
+
+	ctx, wcancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer wcancel()
+
+	require.NoError(t, h.Suspend(ctx))
+	require.Equal(t, thresher.StateSuspended, h.State())
+
+	require.Eventually(t, func() bool {
+		rec, ok, _ := repo.Load(context.Background(), h.ID())
+
+		return ok && rec.Status == repository.StatusSuspended &&
+			countFacts(fw, observability.KindInstanceState,
+				observability.PhaseDehydrated) == 2
+	}, 3*time.Second, 10*time.Millisecond,
+		"the suspended instance releases its goroutines again")
+
+	ids, err := repo.ListInFlight(context.Background(), recoveryGroup,
+		clk.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.NotContains(t, ids, h.ID(), "recovery leaves a suspension alone")
+
+	require.NoError(t, h.Suspend(ctx), "a second Suspend is a no-op")
+
+	clk.Advance(3 * time.Hour)
+
+	require.Never(t, hit.Load, 200*time.Millisecond, 10*time.Millisecond,
+		"a suspended instance's timer doesn't fire")
+	require.Equal(t, thresher.StateSuspended, h.State())
+
+	require.NoError(t, h.Resume(ctx))
+
+	// the overdue deadline re-arms a moment out on the controlled clock.
+	require.Eventually(t, func() bool {
+		clk.Advance(time.Millisecond)
+
+		return hit.Load()
+	}, 3*time.Second, 10*time.Millisecond, "the overdue timer fires on Resume")
+
+	st, err := h.WaitCompletion(ctx)
+	require.NoError(t, err)
+	require.Equal(t, thresher.StateCompleted, st)
+}
+
+// TestSuspendRefusesTaskCompletion verifies a suspended instance keeps its
+// human task claimable but refuses its completion until Resume.
+func TestSuspendRefusesTaskCompletion(t *testing.T) {
+	repo := memrepo.New()
+	dist := &annCollector{}
+
+	p := utProc(t, "susp-task")
+
+	th, fw, cancel := bootTaskEngine(t, "engine-ST", repo, dist, p)
+	defer cancel()
+
+	h, err := th.StartLatest(p.ID())
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool { return dist.count() == 1 },
+		3*time.Second, 10*time.Millisecond)
+
+	taskID := dist.taskIDs()[0]
+
+	require.Eventually(t, func() bool {
+		return fw.saw(observability.KindInstanceState,
+			observability.PhaseDehydrated)
+	}, 3*time.Second, 10*time.Millisecond)
+
+	ctx, wcancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer wcancel()
+
+	require.NoError(t, h.Suspend(ctx))
+
+	actor := utActor{id: "operator"}
+	require.NoError(t, th.Claim(ctx, taskID, actor))
+
+	out := []data.Data{
+		data.MustParameter("result",
+			data.MustItemAwareElement(
+				data.MustItemDefinition(values.NewVariable("approved")),
+				data.ReadyDataState)),
+	}
+
+	err = th.Complete(ctx, taskID, actor, out)
+	require.Error(t, err)
+	requireClass(t, err, errs.InvalidState)
+
+	// the resumed instance re-announces the task, so it is claimed afresh.
+	require.NoError(t, h.Resume(ctx))
+	require.Eventually(t, func() bool {
+		return th.Claim(ctx, taskID, actor) == nil
+	}, 3*time.Second, 10*time.Millisecond)
+	require.NoError(t, th.Complete(ctx, taskID, actor, out))
+
+	st, err := h.WaitCompletion(ctx)
+	require.NoError(t, err)
+	require.Equal(t, thresher.StateCompleted, st)
+}
+
+// TestResumeInstanceAfterRestart verifies a suspension outlives its engine:
+// the engine that takes over does not recover the suspended instance, and
+// ResumeInstance claims, rebuilds and resumes it there.
+func TestResumeInstanceAfterRestart(t *testing.T) {
+	repo := memrepo.New()
+	deadline := dehydrationEpoch.Add(2 * time.Hour)
+
+	var hit1, hit2 atomic.Bool
+
+	clk := clocktest.New(dehydrationEpoch)
+
+	p1 := longTimerProc(t, "susp-restart", deadline, &hit1)
+
+	th1, fw1, err := bootShortLeaseEngine(t, "engine-SR1", repo, clk, p1)
+	require.NoError(t, err)
+
+	h1, err := th1.StartLatest(p1.ID())
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return fw1.saw(observability.KindInstanceState,
+			observability.PhaseDehydrated)
+	}, 3*time.Second, 10*time.Millisecond)
+
+	ctx, wcancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer wcancel()
+
+	require.NoError(t, h1.Suspend(ctx))
+
+	require.Eventually(t, func() bool {
+		rec, ok, _ := repo.Load(context.Background(), h1.ID())
+
+		return ok && rec.Status == repository.StatusSuspended
+	}, 3*time.Second, 10*time.Millisecond)
+
+	clk.Advance(3 * time.Hour) // past engine-1's lease and the deadline
+
+	p2 := longTimerProc(t, "susp-restart", deadline, &hit2)
+
+	th2, _, _, cancel2 := bootDehydrationEngineWithClock(t, "engine-SR2",
+		repo, clk, p2)
+	defer cancel2()
+
+	_, tracked := th2.Instance(h1.ID())
+	require.False(t, tracked, "recovery leaves a suspension alone")
+
+	h2, err := th2.ResumeInstance(ctx, h1.ID())
+	require.NoError(t, err)
+	require.Equal(t, h1.ID(), h2.ID())
+
+	require.Eventually(t, func() bool {
+		clk.Advance(time.Millisecond)
+
+		return hit2.Load()
+	}, 3*time.Second, 10*time.Millisecond, "the overdue timer fires on Resume")
+
+	st, err := h2.WaitCompletion(ctx)
+	require.NoError(t, err)
+	require.Equal(t, thresher.StateCompleted, st)
+	require.False(t, hit1.Load(), "engine-1 never ran the suspended instance")
+
+	_, err = th2.ResumeInstance(ctx, h1.ID())
+	require.Error(t, err)
+	requireClass(t, err, errs.InvalidState)
+}
+
+// heldMessageProcess builds a conversation handler that waits on a message
+// while a gated step keeps it resident:
+//
+//	start("order placed", keyed) -> split =(catch("payment received"),
+//	  work)=> join -> report(pay_in) -> end
+//
+// work signals entered and blocks until release is closed; report publishes
+// the bound payload on got.
+func heldMessageProcess(
+	t *testing.T, key string, entered, release chan struct{},
+	got chan<- string,
+) *process.Process {
+	t.Helper()
+
+	require.NoError(t, data.CreateDefaultStates())
+
+	proc, err := process.New(key, foundation.WithID(key))
+	require.NoError(t, err)
+
+	start, err := events.NewStartEvent("start",
+		events.WithMessageTrigger(events.MustMessageEventDefinition(
+			bpmncommon.MustMessage("order placed", data.MustItemDefinition(
+				values.NewVariable(""), foundation.WithID("order_in"))), nil)),
+		events.WithCorrelationKey(orderKeyFor(t, "order placed")),
+		foundation.WithID(key+"-start"))
+	require.NoError(t, err)
+
+	split, err := gateways.NewParallelGateway(
+		gateways.WithDirection(gateways.Diverging),
+		foundation.WithID(key+"-split"))
+	require.NoError(t, err)
+
+	catch, err := events.NewIntermediateCatchEvent("await-payment",
+		events.MustMessageEventDefinition(
+			bpmncommon.MustMessage("payment received", data.MustItemDefinition(
+				values.NewVariable(""), foundation.WithID("pay_in"))), nil),
+		foundation.WithID(key+"-catch"))
+	require.NoError(t, err)
+
+	workOp, err := gooper.New(key+"-work",
+		func(_ context.Context, _ service.DataReader,
+			_ *data.ItemDefinition) (*data.ItemDefinition, error) {
+			if entered != nil {
+				close(entered)
+			}
+
+			<-release
+
+			return nil, nil
+		})
+	require.NoError(t, err)
+
+	work, err := activities.NewServiceTask(key+"-work", workOp,
+		activities.WithoutParams(), foundation.WithID(key+"-work"))
+	require.NoError(t, err)
+
+	join, err := gateways.NewParallelGateway(
+		gateways.WithDirection(gateways.Converging),
+		foundation.WithID(key+"-join"))
+	require.NoError(t, err)
+
+	reportOp, err := gooper.New(key+"-report",
+		func(ctx context.Context, r service.DataReader,
+			_ *data.ItemDefinition) (*data.ItemDefinition, error) {
+			pay, err := r.GetDataByID("pay_in")
+			if err != nil {
+				return nil, fmt.Errorf("read pay_in: %w", err)
+			}
+
+			got <- fmt.Sprint(pay.Value().Get(ctx))
+
+			return nil, nil
+		})
+	require.NoError(t, err)
+
+	report, err := activities.NewServiceTask(key+"-report", reportOp,
+		activities.WithoutParams(), foundation.WithID(key+"-report"))
+	require.NoError(t, err)
+
+	end, err := events.NewEndEvent("end", foundation.WithID(key+"-end"))
+	require.NoError(t, err)
+
+	for _, e := range []flow.Element{
+		start, split, catch, work, join, report, end,
+	} {
+		require.NoError(t, proc.Add(e))
+	}
+
+	for _, l := range [][2]flow.Element{
+		{start, split}, {split, catch}, {split, work}, {catch, join},
+		{work, join}, {join, report}, {report, end},
+	} {
+		link(t, l[0], l[1])
+	}
+
+	return proc
+}
+
+// TestSuspendHeldMessageSurvivesDrain verifies a message that reaches a
+// suspended instance is held in its checkpoint rather than in memory: the
+// engine drains, another engine resumes the instance, and the held payload
+// reaches the flow.
+func TestSuspendHeldMessageSurvivesDrain(t *testing.T) {
+	repo := memrepo.New()
+	broker := membroker.New()
+	entered, release := make(chan struct{}), make(chan struct{})
+	got := make(chan string, 1)
+
+	p1 := heldMessageProcess(t, "susp-held", entered, release, got)
+
+	th1, fw1, cancel1 := msgEngine(t, "engine-H1", repo, broker, p1,
+		thresher.WithEngineGroup(recoveryGroup))
+	defer cancel1()
+
+	ctx, wcancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer wcancel()
+
+	require.NoError(t, broker.Publish(ctx, messaging.Envelope{
+		Name: "order placed", Payload: "ORD-1", CorrelationKey: "ORD-1"}))
+
+	<-entered
+
+	require.Eventually(t, func() bool {
+		return fw1.saw(observability.KindEventFlow,
+			observability.PhaseRegistered)
+	}, 3*time.Second, 10*time.Millisecond, "the catch parks")
+
+	ids := th1.Instances(thresher.InstancesRunning)
+	require.Len(t, ids, 1)
+
+	h1, ok := th1.Instance(ids[0])
+	require.True(t, ok)
+	require.NoError(t, h1.Suspend(ctx))
+
+	require.NoError(t, broker.Publish(ctx, messaging.Envelope{
+		Name: "payment received", Payload: "ORD-1", CorrelationKey: "ORD-1"}))
+
+	require.Eventually(t, func() bool {
+		rec, ok, _ := repo.Load(context.Background(), h1.ID())
+
+		return ok && bytes.Contains(rec.Payload, []byte(`"held"`))
+	}, 3*time.Second, 10*time.Millisecond, "the held message is checkpointed")
+
+	close(release)
+	require.NoError(t, th1.Drain(ctx))
+
+	p2 := heldMessageProcess(t, "susp-held", nil, release, got)
+
+	th2, _, cancel2 := msgEngine(t, "engine-H2", repo, broker, p2,
+		thresher.WithEngineGroup(recoveryGroup))
+	defer cancel2()
+
+	h2, err := th2.ResumeInstance(ctx, h1.ID())
+	require.NoError(t, err)
+
+	select {
+	case payload := <-got:
+		require.Equal(t, "ORD-1", payload, "Resume applies the held message")
+	case <-ctx.Done():
+		t.Fatal("the held message was lost across the drain")
+	}
+
+	st, err := h2.WaitCompletion(ctx)
+	require.NoError(t, err)
+	require.Equal(t, thresher.StateCompleted, st)
+}
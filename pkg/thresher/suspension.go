@@ -0,0 +1,159 @@
+package thresher
+
+import (
+	"context"
+
+	"github.com/dr-dobermann/gobpm/internal/instance"
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/model/flow"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+	"github.com/dr-dobermann/gobpm/pkg/repository"
+)
+
+// suspendedClass marks a trigger refused because its instance is suspended
+// and has released its goroutines — a timer whose deadline re-arms on Resume.
+const suspendedClass = "INSTANCE_SUSPENDED"
+
+// suspensionOp names a Suspend/Resume in messages.
+func suspensionOp(resume bool) string {
+	if resume {
+		return "resume"
+	}
+
+	return "suspend"
+}
+
+// wakeForSuspension applies a Suspend/Resume to an instance whose loop has
+// exited (ADR-033 §2.6). A released instance already in the requested state
+// needs nothing — suspending a suspended one, resuming a dehydrated one — so
+// it is not rebuilt just to be told so. Otherwise the request rides a rebuild
+// exactly as an incident operation does; the messages and signals the
+// suspended instance held ride its checkpoint, and the Resume applies them.
+func (t *Thresher) wakeForSuspension(
+	ctx context.Context, h *InstanceHandle, resume bool,
+) error {
+	released := h.current()
+
+	if released.Released() && (released.State() == instance.Suspended) != resume {
+		return nil
+	}
+
+	resp := make(chan error, 1)
+
+	return t.rebuildForOp(ctx, h, suspensionOp(resume), resp,
+		instance.WithPendingSuspension(resume, resp))
+}
+
+// holdForResume is the rebuild option holding a trigger that reached a
+// released suspended instance: a message or signal rides a rebuild that keeps
+// the instance suspended and persists the trigger for Resume, and a timer is
+// refused with suspendedClass — its deadline re-arms from the checkpoint.
+func holdForResume(
+	inst *instance.Instance, pending *instance.PendingTrigger,
+) (instance.Option, error) {
+	if pending.EDef == nil || pending.EDef.Type() == flow.TriggerTimer {
+		return nil, errs.New(
+			errs.M("instance %q is suspended", inst.ID()),
+			errs.C(errorClass, errs.InvalidState, suspendedClass),
+			errs.D(observability.AttrInstanceID, inst.ID()))
+	}
+
+	return instance.WithHeldTrigger(inst, *pending), nil
+}
+
+// ResumeInstance resumes a suspended instance by its id. An instance this
+// engine tracks is resumed through its handle (InstanceHandle.Resume). One it
+// does not track — a suspension persisted before the engine restarted, which
+// recovery deliberately leaves alone — is claimed from the Repository,
+// rebuilt, resumed and tracked, and its handle returned. An unknown id is
+// ObjectNotFound; a record that is not suspended, or whose lease another live
+// engine holds, is InvalidState.
+func (t *Thresher) ResumeInstance(
+	ctx context.Context, instanceID string,
+) (*InstanceHandle, error) {
+	if h, ok := t.Instance(instanceID); ok {
+		return h, h.Resume(ctx)
+	}
+
+	if !t.cfg.repoSet {
+		return nil, errs.New(
+			errs.M("instance %q isn't tracked", instanceID),
+			errs.C(errorClass, errs.ObjectNotFound),
+			errs.D(observability.AttrInstanceID, instanceID))
+	}
+
+	if err := t.checkResumable(ctx, instanceID); err != nil {
+		return nil, err
+	}
+
+	if err := t.awaitClaim(instanceID, "resume"); err != nil {
+		return nil, err
+	}
+
+	resp := make(chan error, 1)
+
+	err := t.rebuildAndContinue(instanceID, nil,
+		instance.WithPendingSuspension(true, resp))
+
+	t.releaseWake(instanceID)
+
+	if err != nil {
+		return nil, errs.New(
+			errs.M("resume: the suspended instance %q doesn't wake", instanceID),
+			errs.C(errorClass, errs.OperationFailed),
+			errs.D(observability.AttrInstanceID, instanceID),
+			errs.E(err))
+	}
+
+	if err := awaitOpVerdict(ctx, resp); err != nil {
+		return nil, err
+	}
+
+	h, _ := t.Instance(instanceID)
+
+	return h, nil
+}
+
+// checkResumable refuses a ResumeInstance whose record is missing, not
+// suspended, or leased by another live engine — that engine tracks the
+// instance, and the Resume belongs there.
+func (t *Thresher) checkResumable(ctx context.Context, instanceID string) error {
+	if engCtx, running := t.engineContext(); !running || engCtx.Err() != nil {
+		return t.errEngineNotRunning("resume")
+	}
+
+	rec, ok, err := t.cfg.Repository().Load(ctx, instanceID)
+	if err != nil {
+		return errs.New(
+			errs.M("couldn't load instance %q", instanceID),
+			errs.C(errorClass, errs.OperationFailed),
+			errs.D(observability.AttrInstanceID, instanceID),
+			errs.E(err))
+	}
+
+	if !ok || rec.Group != t.group {
+		return errs.New(
+			errs.M("instance %q isn't found in engine group %q",
+				instanceID, t.group),
+			errs.C(errorClass, errs.ObjectNotFound),
+			errs.D(observability.AttrInstanceID, instanceID))
+	}
+
+	if rec.Status != repository.StatusSuspended {
+		return errs.New(
+			errs.M("instance %q isn't suspended", instanceID),
+			errs.C(errorClass, errs.InvalidState),
+			errs.D(observability.AttrInstanceID, instanceID))
+	}
+
+	if rec.Lease.Owner != t.id &&
+		!rec.Lease.Expired(t.cfg.Clock().Now()) {
+		return errs.New(
+			errs.M("instance %q is owned by engine %q",
+				instanceID, rec.Lease.Owner),
+			errs.C(errorClass, errs.InvalidState),
+			errs.D(observability.AttrInstanceID, instanceID))
+	}
+
+	return nil
+}
@@ -0,0 +1,302 @@
+package thresher_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gobpm/pkg/listener"
+	"github.com/dr-dobermann/gobpm/pkg/model/data"
+	"github.com/dr-dobermann/gobpm/pkg/model/data/values"
+	"github.com/dr-dobermann/gobpm/pkg/model/process"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+	"github.com/dr-dobermann/gobpm/pkg/repository/memrepo"
+	"github.com/dr-dobermann/gobpm/pkg/thresher"
+)
+
+// taskLog records task listener calls as "<event>:<node name>[:<detail>]".
+type taskLog struct {
+	m     sync.Mutex
+	calls []string
+}
+
+func (l *taskLog) listener() listener.TaskListener {
+	return listener.TaskFunc(func(_ context.Context, task *listener.Task) error {
+		entry := fmt.Sprintf("%s:%s", task.Event, task.NodeName)
+
+		switch task.Event {
+		case listener.TaskAssign:
+			entry += fmt.Sprintf(":%s>%s", task.PreviousOwner, task.Owner)
+
+		case listener.TaskComplete:
+			entry += ":" + task.UserID
+		}
+
+		l.m.Lock()
+		l.calls = append(l.calls, entry)
+		l.m.Unlock()
+
+		return nil
+	})
+}
+
+func (l *taskLog) snapshot() []string {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	return append([]string(nil), l.calls...)
+}
+
+// resultOutput is the "result" output the test UserTasks require.
+func resultOutput(v string) []data.Data {
+	return []data.Data{
+		data.MustParameter("result",
+			data.MustItemAwareElement(
+				data.MustItemDefinition(values.NewVariable(v)),
+				data.ReadyDataState)),
+	}
+}
+
+// approveID returns the id of proc's "approve" UserTask.
+func approveID(t *testing.T, proc *process.Process) string {
+	t.Helper()
+
+	for _, n := range proc.Nodes() {
+		if n.Name() == "approve" {
+			return n.ID()
+		}
+	}
+
+	require.FailNow(t, "no approve node")
+
+	return ""
+}
+
+// startTaskListening runs an engine with the task listener engineWide,
+// registers proc with opts and starts it, returning the handle and the
+// announced task id.
+func startTaskListening(
+	t *testing.T,
+	proc *process.Process,
+	engineWide listener.TaskListener,
+	opts ...thresher.RegisterOption,
+) (*thresher.Thresher, *thresher.InstanceHandle, string) {
+	t.Helper()
+
+	dist := &captureDist{}
+
+	th, err := thresher.New("test-"+proc.ID(), thresher.WithoutBanner(),
+		thresher.WithTaskDistributor(dist),
+		thresher.WithTaskListener(engineWide))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	require.NoError(t, th.Run(ctx))
+
+	_, err = th.RegisterProcess(proc, opts...)
+	require.NoError(t, err)
+
+	h, err := th.StartLatest(proc.ID())
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool { return dist.taskID() != "" },
+		2*time.Second, 10*time.Millisecond)
+
+	return th, h, dist.taskID()
+}
+
+// TestTaskListenersFollowTheLifecycle verifies the create, assign and complete
+// listeners run in order — a completed task runs no delete — and a create
+// listener's assignment is the one the task is distributed and authorized with.
+func TestTaskListenersFollowTheLifecycle(t *testing.T) {
+	var log taskLog
+
+	require.NoError(t, data.CreateDefaultStates())
+
+	proc := userTaskProcess(t, "tl-lifecycle")
+
+	th, h, taskID := startTaskListening(t, proc, log.listener(),
+		thresher.WithUserTaskListener(approveID(t, proc), listener.TaskFunc(
+			func(_ context.Context, task *listener.Task) error {
+				task.SetCandidateUsers("alice", "bob")
+
+				return nil
+			}), listener.TaskCreate))
+
+	ctx := context.Background()
+	bob := utActor{id: "bob"}
+
+	// bob is a candidate only through the create listener.
+	require.NoError(t, th.Claim(ctx, taskID, bob))
+	require.NoError(t, th.Reassign(ctx, taskID, "alice"))
+	require.NoError(t, th.Complete(ctx, taskID, utActor{id: "alice"},
+		resultOutput("ok")))
+
+	wctx, wcancel := context.WithTimeout(ctx, 3*time.Second)
+	defer wcancel()
+
+	st, err := h.WaitCompletion(wctx)
+	require.NoError(t, err)
+	require.Equal(t, thresher.StateCompleted, st)
+
+	require.Equal(t, []string{
+		"create:approve",
+		"assign:approve:>bob",
+		"assign:approve:bob>alice",
+		"complete:approve:alice",
+	}, log.snapshot())
+}
+
+// TestTaskListenerVetoes verifies an assign listener's error refuses the
+// ownership change and a complete listener's error rejects the completion —
+// both returned to the caller, the task staying parked.
+func TestTaskListenerVetoes(t *testing.T) {
+	var log taskLog
+
+	require.NoError(t, data.CreateDefaultStates())
+
+	proc := userTaskProcess(t, "tl-veto")
+
+	th, h, taskID := startTaskListening(t, proc, log.listener(),
+		thresher.WithUserTaskListener(approveID(t, proc), listener.TaskFunc(
+			func(ctx context.Context, task *listener.Task) error {
+				switch task.Event {
+				case listener.TaskAssign:
+					if task.Owner == "" {
+						return errors.New("a claimed approval can't be released")
+					}
+
+				case listener.TaskComplete:
+					if task.Outputs[0].Value().Get(ctx) == "maybe" {
+						return errors.New("an approval needs a decision")
+					}
+				}
+
+				return nil
+			})))
+
+	ctx := context.Background()
+	alice := utActor{id: "alice"}
+
+	require.NoError(t, th.Claim(ctx, taskID, alice))
+	require.ErrorContains(t, th.Unclaim(ctx, taskID, alice),
+		"a claimed approval can't be released")
+
+	// the veto left alice the owner, so her completion gets to the listener
+	require.ErrorContains(t,
+		th.Complete(ctx, taskID, alice, resultOutput("maybe")),
+		"an approval needs a decision")
+	require.NoError(t, th.Complete(ctx, taskID, alice, resultOutput("yes")))
+
+	wctx, wcancel := context.WithTimeout(ctx, 3*time.Second)
+	defer wcancel()
+
+	st, err := h.WaitCompletion(wctx)
+	require.NoError(t, err)
+	require.Equal(t, thresher.StateCompleted, st)
+}
+
+// TestTaskListenerDeleteOnCancel verifies a task withdrawn by its instance's
+// cancellation runs the delete listeners.
+func TestTaskListenerDeleteOnCancel(t *testing.T) {
+	var log taskLog
+
+	require.NoError(t, data.CreateDefaultStates())
+
+	_, h, _ := startTaskListening(t, userTaskProcess(t, "tl-delete"),
+		log.listener())
+
+	cctx, ccancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer ccancel()
+
+	st, err := h.Cancel(cctx)
+	require.NoError(t, err)
+	require.Equal(t, thresher.StateTerminated, st)
+
+	require.Equal(t, []string{"create:approve", "delete:approve"},
+		log.snapshot())
+}
+
+// TestTaskListenerAssignmentSurvivesDehydration verifies a create listener's
+// assignment is recorded with the parked task: the rehydrated instance
+// re-announces it unchanged, without creating the task again.
+func TestTaskListenerAssignmentSurvivesDehydration(t *testing.T) {
+	var log taskLog
+
+	repo := memrepo.New()
+	dist := &annCollector{}
+	p := utProc(t, "tl-dehy")
+
+	th, fw, cancel := bootTaskEngine(t, "engine-TL", repo, dist, p,
+		thresher.WithUserTaskListener(p.ID()+"-approve", log.listener()),
+		thresher.WithUserTaskListener(p.ID()+"-approve", listener.TaskFunc(
+			func(_ context.Context, task *listener.Task) error {
+				task.SetAssignee("dora")
+
+				return nil
+			}), listener.TaskCreate))
+	defer cancel()
+
+	h, err := th.StartLatest(p.ID())
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return fw.saw(observability.KindInstanceState,
+			observability.PhaseDehydrated)
+	}, 3*time.Second, 10*time.Millisecond)
+
+	taskID := dist.taskIDs()[0]
+	dora := utActor{id: "dora"}
+
+	// Take hydrates the instance, which re-announces the task: dora, whom the
+	// model never names, must still be its assignee and born owner.
+	_, err = th.Take(context.Background(), taskID, dora)
+	require.NoError(t, err)
+	require.Len(t, dist.taskIDs(), 2, "the rehydrated task is re-announced")
+
+	require.NoError(t, th.Complete(context.Background(), taskID, dora,
+		resultOutput("approved")))
+
+	wctx, wcancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer wcancel()
+
+	st, err := h.WaitCompletion(wctx)
+	require.NoError(t, err)
+	require.Equal(t, thresher.StateCompleted, st)
+
+	require.Equal(t, []string{"create:approve", "complete:approve:dora"},
+		log.snapshot())
+}
+
+// TestTaskListenerOptionValidation verifies the task listener options refuse a
+// nil listener, an unknown event and an empty node id.
+func TestTaskListenerOptionValidation(t *testing.T) {
+	nop := listener.TaskFunc(func(context.Context, *listener.Task) error {
+		return nil
+	})
+
+	_, err := thresher.New("test-tl-nil", thresher.WithTaskListener(nil))
+	require.ErrorContains(t, err, "a nil listener isn't allowed")
+
+	require.NoError(t, data.CreateDefaultStates())
+
+	proc := userTaskProcess(t, "tl-validate")
+	th, cancel := runEngine(t, proc)
+	defer cancel()
+
+	for _, opt := range []thresher.RegisterOption{
+		thresher.WithUserTaskListener("approve", nop,
+			listener.TaskEvent("escalate")),
+		thresher.WithUserTaskListener(" ", nop),
+		thresher.WithUserTaskListener("approve", nil),
+	} {
+		_, err := th.RegisterProcess(proc, opt)
+		require.Error(t, err)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/dr-dobermann/gobpm/internal/instance"
 	"github.com/dr-dobermann/gobpm/pkg/errs"
 	"github.com/dr-dobermann/gobpm/pkg/interactor"
+	"github.com/dr-dobermann/gobpm/pkg/listener"
 	"github.com/dr-dobermann/gobpm/pkg/model/data"
 	hi "github.com/dr-dobermann/gobpm/pkg/model/hinteraction"
 	"github.com/dr-dobermann/gobpm/pkg/observability"
@@ -51,6 +52,9 @@ func (r *routingDistributor) Withdraw(
 // only mutable field, and every mutation happens under Thresher.m (SRD-073 NFR-1).
 type taskRecord struct {
 	instanceID string
+	// nodeID names the UserTask node, so the ownership operations find the
+	// task's listeners.
+	nodeID string
 	// owner is the BPMN actualOwner (§10.3.4.1 Table 10.14) — a user-id literal,
 	// empty while the task is unowned.
 	owner    string
@@ -66,6 +70,7 @@ func (t *Thresher) registerTask(task interactor.TaskInfo) {
 
 	t.tasks[task.TaskID] = &taskRecord{
 		instanceID: task.InstanceID,
+		nodeID:     task.NodeID,
 		eligible:   task.Eligible,
 		owner:      bornOwner(task.Eligible),
 	}
@@ -280,7 +285,7 @@ func (t *Thresher) completeVerdict(
 // The task stays parked and the instance is never hydrated: claiming is a registry
 // mutation, not an execution step (ADR-020 v.2 §2.1.1).
 func (t *Thresher) Claim(
-	_ context.Context,
+	ctx context.Context,
 	taskID string,
 	actor hi.Actor,
 ) error {
@@ -288,7 +293,9 @@ func (t *Thresher) Claim(
 		return err
 	}
 
-	if err := t.setOwner(taskID, actor.UserID(), actor, claimGuard(actor)); err != nil {
+	err := t.setOwner(ctx, taskID, actor.UserID(), actor, actor.UserID(),
+		claimGuard(actor))
+	if err != nil {
 		return err
 	}
 
@@ -303,7 +310,7 @@ func (t *Thresher) Claim(
 // eligible actor may claim it again. Only the current owner may unclaim (ADR-020
 // v.2 §2.5.2).
 func (t *Thresher) Unclaim(
-	_ context.Context,
+	ctx context.Context,
 	taskID string,
 	actor hi.Actor,
 ) error {
@@ -311,7 +318,9 @@ func (t *Thresher) Unclaim(
 		return err
 	}
 
-	if err := t.setOwner(taskID, "", actor, ownerOnlyGuard(actor)); err != nil {
+	err := t.setOwner(ctx, taskID, "", actor, actor.UserID(),
+		ownerOnlyGuard(actor))
+	if err != nil {
 		return err
 	}
 
@@ -337,7 +346,7 @@ func (t *Thresher) Unclaim(
 // nominated, since group membership is authenticated by the embedder for a present
 // actor and cannot be asserted for an absent one (SRD-073 §4.4).
 func (t *Thresher) Reassign(
-	_ context.Context,
+	ctx context.Context,
 	taskID, nomineeUserID string,
 ) error {
 	if err := checkTaskArgs("Reassign", taskID, nomineeUserID); err != nil {
@@ -348,7 +357,8 @@ func (t *Thresher) Reassign(
 
 	// The NOMINEE is the actor authorized here, not the caller: a reassignment
 	// may only move a task to someone already eligible for it.
-	err := t.setOwner(taskID, nomineeUserID, userIDActor(nomineeUserID),
+	err := t.setOwner(ctx, taskID, nomineeUserID,
+		userIDActor(nomineeUserID), "",
 		func(_ string, rec *taskRecord) error {
 			from = rec.owner
 
@@ -369,15 +379,19 @@ func (t *Thresher) Reassign(
 
 // setOwner applies guard to the task's record and, if it passes, writes owner. The
 // registry lookup, the guard and the write happen in ONE critical section, so
-// concurrent claims on the same task cannot both succeed (SRD-073 NFR-3).
+// concurrent claims on the same task cannot both succeed (SRD-073 NFR-3). userID
+// is the acting user the task's assign listeners are told of.
 func (t *Thresher) setOwner(
+	ctx context.Context,
 	taskID, owner string,
 	actor hi.Actor,
+	userID string,
 	admit func(string, *taskRecord) error,
 ) error {
 	// PHASE 1 — read the eligibility policy under the lock. It is written once
 	// at registration and read-only afterwards (see taskRecord), so one read is
-	// enough and it cannot go stale.
+	// enough and it cannot go stale. The guard is checked here too, so the
+	// assign listeners below never hear of a change it would refuse.
 	t.m.Lock()
 
 	rec, ok := t.tasks[taskID]
@@ -387,10 +401,18 @@ func (t *Thresher) setOwner(
 		return errUnknownTask(taskID)
 	}
 
-	eligible := rec.eligible
+	if err := admit(taskID, rec); err != nil {
+		t.m.Unlock()
+
+		return err
+	}
+
+	pending := *rec
 
 	t.m.Unlock()
 
+	eligible := pending.eligible
+
 	// PHASE 2 — HOST policy, outside the lock. Authorize is embedder code and a
 	// directory or database lookup is the normal implementation; running it
 	// under t.m stalled every registration, launch and discovery call in the
@@ -401,6 +423,12 @@ func (t *Thresher) setOwner(
 		return err
 	}
 
+	// The assign listeners are host code as well, and run here for the same
+	// reason; an error vetoes the change.
+	if err := t.notifyAssign(ctx, taskID, &pending, owner, userID); err != nil {
+		return err
+	}
+
 	// PHASE 3 — the ownership decision and the mutation, under the lock and on
 	// a FRESHLY read record: the answer must not be stale by the time it is
 	// applied, and phase 2 released the lock.
@@ -421,6 +449,38 @@ func (t *Thresher) setOwner(
 	return nil
 }
 
+// notifyAssign runs the assign listeners of the task rec describes for its change
+// to owner. The listeners live on the owning instance's registered snapshot,
+// which a released instance keeps, so nothing is hydrated.
+func (t *Thresher) notifyAssign(
+	ctx context.Context,
+	taskID string,
+	rec *taskRecord,
+	owner, userID string,
+) error {
+	t.m.Lock()
+	reg, ok := t.instances[rec.instanceID]
+	t.m.Unlock()
+
+	if !ok || reg.inst == nil {
+		return nil
+	}
+
+	return reg.inst.NotifyTask(ctx, &listener.Task{
+		TaskRef: interactor.TaskRef{
+			TaskID:     taskID,
+			InstanceID: rec.instanceID,
+			NodeID:     rec.nodeID,
+			ProcessID:  reg.inst.ProcessID(),
+		},
+		Event:         listener.TaskAssign,
+		Eligible:      rec.eligible,
+		Owner:         owner,
+		PreviousOwner: rec.owner,
+		UserID:        userID,
+	})
+}
+
 // claimGuard admits an eligible actor to a task that is unowned, or that the actor
 // already holds.
 //
@@ -473,7 +533,8 @@ func (u userIDActor) Groups() []string { return nil }
 
 // reportTaskOwnership emits an ownership transition on the TaskState stream. The
 // activity itself stays Active — ownership is an attribute of a parked task, not a
-// node phase (ADR-020 v.2 §2.1.1, §2.8).
+// node phase (ADR-020 v.2 §2.1.1, §2.8). The fact leaves the engine's producer,
+// not the instance's, so it is stamped with the task's instance and node here.
 func (t *Thresher) reportTaskOwnership(
 	taskID string,
 	phase observability.Phase,
@@ -481,6 +542,13 @@ func (t *Thresher) reportTaskOwnership(
 ) {
 	details[observability.AttrTaskID] = taskID
 
+	t.m.Lock()
+	if rec, ok := t.tasks[taskID]; ok {
+		details[observability.AttrInstanceID] = rec.instanceID
+		details[observability.AttrNodeID] = rec.nodeID
+	}
+	t.m.Unlock()
+
 	t.producer.Report(observability.Fact{
 		Kind:    observability.KindTaskState,
 		Phase:   phase,
@@ -617,7 +685,7 @@ func (t *Thresher) residentForTask(taskID string) (*instance.Instance, error) {
 		return nil, err
 	}
 
-	if inst.State() != instance.Dehydrated {
+	if !inst.Released() {
 		inst.PinResident()
 
 		return inst, nil
@@ -665,7 +733,7 @@ func (t *Thresher) hydrateForTask(instanceID string) error {
 	// instead of rebuilding it again — the pin must exist on EVERY path out of
 	// here, because the caller unpins unconditionally.
 	if inst, err := t.instanceByID(instanceID); err == nil &&
-		inst.State() != instance.Dehydrated {
+		!inst.Released() {
 		inst.PinResident()
 
 		return nil
@@ -154,7 +154,7 @@ func TestHandleObserverSurvivesARebuild(t *testing.T) {
 	snap := th.latestSnapshotLocked(proc.ID())
 	require.NotNil(t, snap)
 
-	other, err := th.launchInstance(snap)
+	other, err := th.launchInstance(snap, nil, "")
 	require.NoError(t, err)
 
 	rebuilt, err := th.instanceByID(other.ID())
@@ -262,7 +262,8 @@ func TestTaskVanishesBetweenThePhases(t *testing.T) {
 		var ownErr error
 
 		vanish(t, th, act, func() {
-			ownErr = th.setOwner("task-1", "u-2", act, claimGuard(act))
+			ownErr = th.setOwner(context.Background(), "task-1", "u-2",
+				act, "u-2", claimGuard(act))
 		})
 
 		require.Error(t, ownErr,
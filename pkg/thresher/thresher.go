@@ -37,6 +37,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -44,11 +45,15 @@ import (
 
 	"github.com/dr-dobermann/gobpm/internal/eventproc"
 	"github.com/dr-dobermann/gobpm/internal/eventproc/eventhub"
+	"github.com/dr-dobermann/gobpm/internal/eventproc/eventhub/waiters"
 	"github.com/dr-dobermann/gobpm/internal/instance"
 	"github.com/dr-dobermann/gobpm/internal/instance/snapshot"
 	"github.com/dr-dobermann/gobpm/internal/scope"
 	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/history"
 	"github.com/dr-dobermann/gobpm/pkg/interactor"
+	"github.com/dr-dobermann/gobpm/pkg/messaging"
+	"github.com/dr-dobermann/gobpm/pkg/model/data"
 	"github.com/dr-dobermann/gobpm/pkg/model/expression"
 	"github.com/dr-dobermann/gobpm/pkg/model/expression/goexpr"
 	"github.com/dr-dobermann/gobpm/pkg/model/expression/lite"
@@ -56,6 +61,7 @@ import (
 	"github.com/dr-dobermann/gobpm/pkg/model/process"
 	"github.com/dr-dobermann/gobpm/pkg/observability"
 	"github.com/dr-dobermann/gobpm/pkg/renv"
+	"github.com/dr-dobermann/gobpm/pkg/repository"
 	"github.com/dr-dobermann/gobpm/pkg/rules"
 	"github.com/dr-dobermann/gobpm/pkg/script"
 	"github.com/dr-dobermann/gobpm/pkg/tasks"
@@ -169,6 +175,21 @@ type Thresher struct {
 	// conversation once the old one finished, instead of joining a ghost
 	// (FIX-036 §1.2). Guarded by m; reaped by Forget.
 	seenKeys map[string]string
+	// bizKeysInFlight holds the namespaced business keys of unique-key starts
+	// still launching (reserveBusinessKeyLocked): a launched instance is found
+	// among instances, but until it is tracked only this entry stops a
+	// concurrent same-key start. Guarded by m.
+	bizKeysInFlight map[string]struct{}
+	// startMsgs holds the broker MessageIDs the instance-starters already
+	// took (reserveStartMessageLocked), oldest first in startMsgOrder, so a
+	// redelivered start message never launches a second instance. Bounded
+	// by maxStartMessages; re-seeded from every recovered or woken
+	// checkpoint. Guarded by m.
+	startMsgs     map[string]struct{}
+	startMsgOrder []string
+	// receivedSignals holds the ids of the group signals the engine already
+	// propagated (signal_fanout.go), so a repeated delivery fires nothing.
+	receivedSignals signalIDs
 	// tasks maps a parked UserTask id → its engine-level record: where it lives,
 	// who may act on it, and who currently holds it (SRD-034, SRD-073 FR-2).
 	// Guarded by m. Populated/cleared by taskDist as tasks are announced and
@@ -189,6 +210,11 @@ type Thresher struct {
 	// name, or — the solo default — the engine id. Stamped on every
 	// record; recovery and claims are scoped to it.
 	group string
+	// member is the engine's record in its group's membership registry,
+	// built once by Run; every refresh re-stamps its heartbeat. Zero
+	// before Run and once the engine has left. Guarded by memberMu, which
+	// also keeps a refresh from landing after the engine leaves.
+	member repository.GroupMember
 	// timerSvc is the engine-level durable timer holder (SRD-071 FR-6): a
 	// dehydratable timer registers its deadline here at arm and the service
 	// wakes the released instance on fire. nil until Run when a Repository is
@@ -211,12 +237,22 @@ type Thresher struct {
 	// settled holds the per-instance-ID TERMINAL signal (SRD-071): closed only
 	// when the instance genuinely finishes, and shared with every rebuild, so a
 	// WaitCompletion survives dehydration cycles. Guarded by m.
-	settled map[string]chan struct{}
-	cfg     thresherConfig
-	m       sync.Mutex
-	wakeMu  sync.Mutex
-	subMu   sync.Mutex
-	state   atomic.Uint32 // a State; lock-free, NEVER accessed under m
+	settled  map[string]chan struct{}
+	cfg      thresherConfig
+	m        sync.Mutex
+	wakeMu   sync.Mutex
+	subMu    sync.Mutex
+	memberMu sync.Mutex
+	state    atomic.Uint32 // a State; lock-free, NEVER accessed under m
+	// draining is set by Drain: from then on the engine takes no instance on
+	// — no start, wake, recovery or takeover — while it hands its own to the
+	// group.
+	draining atomic.Bool
+	// upkeepStop, closed once by stopUpkeep, ends the lease heartbeat and
+	// the rescans ahead of the engine context: a draining engine gives its
+	// leases up rather than renewing them.
+	upkeepStop chan struct{}
+	upkeepOnce sync.Once
 }
 
 // resolveIdentity settles the engine's id and group (SRD-078 FR-2): a
@@ -268,6 +304,15 @@ func New(id string, opts ...Option) (*Thresher, error) {
 		return nil, err
 	}
 
+	// the outbox stages sends into the checkpoint — without a Repository
+	// there is no checkpoint to stage into.
+	if cfg.outbox && !cfg.repoSet {
+		return nil, errs.New(
+			errs.M("WithMessageOutbox needs an explicitly configured"+
+				" Repository (WithRepository)"),
+			errs.C(errorClass, errs.InvalidParameter))
+	}
+
 	reg, err := script.NewRegistry(cfg.scriptEngines...)
 	if err != nil {
 		return nil,
@@ -277,6 +322,24 @@ func New(id string, opts ...Option) (*Thresher, error) {
 				errs.E(err))
 	}
 
+	// a heartbeat no shorter than the lease lets every lease lapse between
+	// renewals.
+	if cfg.heartbeat >= cfg.leaseTTL {
+		return nil, errs.New(
+			errs.M("WithLeaseHeartbeat: the heartbeat (%s) must be shorter than"+
+				" the lease window (%s)", cfg.heartbeat, cfg.leaseTTL),
+			errs.C(errorClass, errs.InvalidParameter))
+	}
+
+	// a rescan without renewals claims a live peer's idle instances out
+	// from under it once their leases lapse.
+	if cfg.rescan > 0 && cfg.heartbeat == 0 {
+		return nil, errs.New(
+			errs.M("WithRecoveryRescan needs WithLeaseHeartbeat: without lease"+
+				" renewals a rescan claims a live engine's idle instances"),
+			errs.C(errorClass, errs.InvalidParameter))
+	}
+
 	cfg.scriptRegistry = reg
 
 	// the expression registry (ADR-032 §2.1): the batteries prepend unless
@@ -300,18 +363,21 @@ func New(id string, opts ...Option) (*Thresher, error) {
 	cfg.exprRegistry = exprReg
 
 	t := &Thresher{
-		id:            id,
-		group:         group,
-		cfg:           cfg,
-		registrations: map[string][]*ProcessRegistration{},
-		nextVersion:   map[string]int{},
-		instances:     map[string]instanceReg{},
-		seenKeys:      map[string]string{},
-		tasks:         map[string]*taskRecord{},
-		keyLocks:      newKeyLockManager(),
-		waking:        map[string]chan struct{}{},
-		subs:          map[subKey]*subHolder{},
-		settled:       map[string]chan struct{}{},
+		id:              id,
+		group:           group,
+		cfg:             cfg,
+		registrations:   map[string][]*ProcessRegistration{},
+		nextVersion:     map[string]int{},
+		instances:       map[string]instanceReg{},
+		seenKeys:        map[string]string{},
+		bizKeysInFlight: map[string]struct{}{},
+		startMsgs:       map[string]struct{}{},
+		tasks:           map[string]*taskRecord{},
+		keyLocks:        newKeyLockManager(),
+		waking:          map[string]chan struct{}{},
+		subs:            map[subKey]*subHolder{},
+		settled:         map[string]chan struct{}{},
+		upkeepStop:      make(chan struct{}),
 	}
 	t.state.Store(uint32(NotStarted))
 
@@ -328,6 +394,15 @@ func New(id string, opts ...Option) (*Thresher, error) {
 	t.producer = newProducer(cfg.Logger(), cfg.AuthorizationProvider())
 	t.cfg.reporter = t.producer
 
+	// The history Recorder rides the engine stream for the engine's
+	// lifetime: it drains on its own goroutine, so a slow store loses
+	// history, never throughput.
+	if cfg.history != nil {
+		t.producer.subscribe(history.NewRecorder(cfg.history,
+			append([]history.RecorderOption{history.WithLogger(cfg.Logger())},
+				cfg.historyOpts...)...))
+	}
+
 	// Bind the producer to the dispatcher (when it accepts one) so the
 	// dispatcher's job-lifecycle events land on the same seam (SRD-041 §3.2). A
 	// dispatcher without the binder simply does not emit.
@@ -366,6 +441,18 @@ func New(id string, opts ...Option) (*Thresher, error) {
 		rb.BindReporter(t.producer)
 	}
 
+	// Same for the message broker: a broker that keeps a dead-letter queue
+	// reports what it dead-letters, replays and purges on the engine seam.
+	if rb, ok := cfg.MessageBroker().(messaging.ReporterBinder); ok {
+		rb.BindReporter(t.producer)
+	}
+
+	// The group's signal fan-out: an explicit WithSignalFanout, else the
+	// broker's broadcast for an engine of an explicit group.
+	if err := t.resolveSignalFanout(); err != nil {
+		return nil, err
+	}
+
 	// The EventHub receives the engine's resolved runtime (&t.cfg implements
 	// renv.EngineRuntime) so the waiters it builds reach Clock / ExpressionEngine
 	// (ADR-002 §4.3, Solution B). Built after t so it shares t's cfg pointer.
@@ -417,11 +504,22 @@ func (t *Thresher) logStartupConfig() {
 
 		log.Info("configuration:")
 		module("repository", t.cfg.repository)
+		module("history", t.cfg.history)
+		if ids := t.cfg.cpCodec.IDs(); len(ids) > 0 {
+			log.Info(fmt.Sprintf("  %-22s %s", "checkpointCodec:",
+				strings.Join(ids, ", ")))
+		}
+		if t.cfg.outbox {
+			log.Info(fmt.Sprintf("  %-22s %s", "messageOutbox:", "on"))
+		}
 		module("logger", t.cfg.logger)
 		module("tracer", t.cfg.tracer)
 		module("metricsRecorder", t.cfg.metrics)
 		module("clock", t.cfg.clock)
 		module("messageBroker", t.cfg.msgBroker)
+		if t.cfg.signalFanout != nil {
+			module("signalFanout", t.cfg.signalFanout)
+		}
 		module("expressionEngine", t.cfg.exprRegistry)
 		log.Info(fmt.Sprintf("  %-22s %s", "expressionEngines:",
 			t.cfg.exprRegistry.Type()))
@@ -495,6 +593,10 @@ func (t *Thresher) instanceContext(
 		return nil, nil, t.errEngineNotRunning(op)
 	}
 
+	if t.draining.Load() {
+		return nil, nil, t.errEngineDraining(op)
+	}
+
 	ctx, cancel := context.WithCancel(engCtx)
 
 	return ctx, cancel, nil
@@ -733,6 +835,17 @@ func (t *Thresher) Run(ctx context.Context) error {
 		}
 	}
 
+	// A durable Data Store migrates the same way: one that implements
+	// renv.Migrator creates its own tables before recovery can resume an
+	// instance that reads it. Independent of the Repository — a volatile
+	// engine may still keep its Data Stores in a database.
+	if err := t.migrateDataStores(runCtx); err != nil {
+		ec.cancel()
+		t.state.Store(uint32(NotStarted))
+
+		return err
+	}
+
 	// The engine group (SRD-078 FR-2, ADR-033 v.3 §2.8): establish it in
 	// the repository's registry — or, under WithExistingEngineGroup,
 	// assert it is already established, so a misspelled group refuses
@@ -745,6 +858,10 @@ func (t *Thresher) Run(ctx context.Context) error {
 
 			return err
 		}
+
+		// Over a membership registry the engine makes itself known to
+		// the group and keeps its record fresh while it runs.
+		t.joinGroup(runCtx)
 	}
 
 	// The engine timer service (SRD-071 FR-6, closes #84): the durable holder
@@ -755,7 +872,24 @@ func (t *Thresher) Run(ctx context.Context) error {
 	if t.cfg.repoSet {
 		t.timerSvc = newTimerService(
 			t.cfg.Clock(), t.cfg.wakeBackoff, t.hydrateFromTimer)
+		t.timerSvc.lag = t.cfg.metrics.Histogram(waiters.TimerLagMetric)
+		t.timerSvc.lagAttrs = []observability.Attr{{Key: "holder", Value: "engine"}}
 		go t.timerSvc.run(runCtx)
+
+		// Over a due-timer index, the group's overdue timers whose engine
+		// is gone are taken over by whichever engine polls first.
+		t.takeOverTimers(runCtx)
+	}
+
+	// The group signal fan-out: listen BEFORE recovery, so a signal thrown
+	// elsewhere while this engine re-arms its recovered waits is not lost
+	// to the window.
+	if err := t.listenSignals(runCtx); err != nil {
+		ec.cancel()
+		t.leaveGroup(ctx)
+		t.state.Store(uint32(NotStarted))
+
+		return err
 	}
 
 	// Restart recovery (SRD-070 FR-7): with an explicitly configured
@@ -764,6 +898,16 @@ func (t *Thresher) Run(ctx context.Context) error {
 	// start (an empty/fresh store recovers nothing).
 	if t.cfg.repoSet {
 		t.recoverInstances(runCtx)
+
+		// Opted in, the engine keeps the leases it owns alive and recovers
+		// what the rest of the group leaves claimable while it runs.
+		if t.cfg.heartbeat > 0 {
+			t.keepLeases(runCtx)
+		}
+
+		if t.cfg.rescan > 0 {
+			t.rescanInFlight(runCtx)
+		}
 	}
 
 	return nil
@@ -837,6 +981,11 @@ func (t *Thresher) Shutdown(ctx context.Context) error {
 		return err
 	}
 
+	// The engine leaves its group's registry on a clean stop, once its
+	// instances are drained; a crashed one leaves its record behind, with a
+	// heartbeat that stops moving.
+	t.leaveGroup(ctx)
+
 	// Drain the event machinery: stop waiters and wait for their goroutines.
 	return t.eventHub.Shutdown(ctx)
 }
@@ -968,6 +1117,9 @@ func (t *Thresher) PropagateEvent(
 			errs.E(err))
 	}
 
+	// a signal thrown here reaches the rest of the engine group too.
+	t.broadcastSignal(ctx, eDef)
+
 	return nil
 }
 
@@ -1021,6 +1173,24 @@ func (t *Thresher) RegisterProcess(
 			errs.E(err))
 	}
 
+	// An element listener bound to no element of the process would never
+	// run: refuse it now rather than let a misspelled id go silent.
+	for _, b := range rc.listeners {
+		if b.ElementID != "" && !s.HasElement(b.ElementID) {
+			return nil, errs.New(
+				errs.M("WithNodeListener: process %q has no node or sequence"+
+					" flow %q", s.ProcessID, b.ElementID),
+				errs.C(errorClass, errs.ObjectNotFound),
+				errs.D(observability.AttrProcessID, s.ProcessID))
+		}
+	}
+
+	// Every instance of the version runs the same listeners, task listeners
+	// too — the engine's, then the process's — rebuilt instances included, since they clone this
+	// snapshot too.
+	s.Listeners = slices.Concat(t.cfg.listeners, rc.listeners)
+	s.TaskListeners = slices.Concat(t.cfg.taskListeners, rc.taskListeners)
+
 	// Serialize this whole key operation against a concurrent unregister of the
 	// same key: the per-key lock spans the registry mutation AND the hub work
 	// below, so an UnregisterVersion/UnregisterProcess cannot drop the new
@@ -1036,7 +1206,7 @@ func (t *Thresher) RegisterProcess(
 		starters = scanInstantiatingStarts(s, t)
 	}
 
-	reg, prevLatest := t.appendVersionLocked(s, starters, rc.manualStart)
+	reg, prevLatest := t.appendVersionLocked(s, starters, rc)
 
 	// The registry now holds a new latest version; if it displaced one, that
 	// prior latest is superseded (its auto-start stops — ADR-019 §2.5). A
@@ -1369,6 +1539,17 @@ func (t *Thresher) resolveAndLaunch(
 		return nil // an instance already exists for this key: join, no duplicate
 	}
 
+	// The correlation value is the event-born instance's business key, so a
+	// unique-key process refuses a message whose key a live instance started
+	// some other way (WithBusinessKey) already holds.
+	release, err := t.claimBusinessKey(ctx, s.ProcessID, key)
+	if err != nil {
+		t.releaseKeyLocked(nsKey)
+
+		return err
+	}
+	defer release()
+
 	if err := t.launchInstanceFromEvent(
 		ctx, s, startNode, eDef, keyName, key); err != nil {
 		// the launch failed — drop the reservation so a later message can retry.
@@ -1397,12 +1578,14 @@ func (t *Thresher) launchInstanceFromEvent(
 ) error {
 	// The conversation key (keyName/keyVal) is seeded inside NewFromEvent BEFORE
 	// createTracks parks any receiver, so an in-instance receiver reached
-	// directly off the born start subscribes keyed to it (SRD-017 §4.5).
+	// directly off the born start subscribes keyed to it (SRD-017 §4.5). The
+	// key's value doubles as the instance's business key.
 	settled := make(chan struct{})
 
 	inst, err := instance.NewFromEvent(
 		s, scope.EmptyDataPath, &t.cfg, t, t.taskDist, startNode.ID(), eDef,
-		keyName, keyVal, t.instanceOptions(settled)...)
+		keyName, keyVal, append(t.instanceOptions(settled),
+			instance.WithBusinessKey(keyVal))...)
 	if err != nil {
 		return errs.New(
 			errs.M("couldn't create an event-born Instance for process %q",
@@ -1459,8 +1642,12 @@ func nsKeyFor(processID, key string) string {
 // StartProcess launches a new instance of the exact registered version named by
 // reg — the receipt RegisterProcess returned — and returns its read-only
 // observation handle. A nil reg is rejected. To start by key instead, use
-// StartLatest (the newest version) or StartVersion (a specific one).
-func (t *Thresher) StartProcess(reg *ProcessRegistration) (*InstanceHandle, error) {
+// StartLatest (the newest version) or StartVersion (a specific one). opts pass
+// start variables (WithStartVariables, WithStartValues) and the business key
+// (WithBusinessKey).
+func (t *Thresher) StartProcess(
+	reg *ProcessRegistration, opts ...StartOption,
+) (*InstanceHandle, error) {
 	if reg == nil {
 		return nil, errs.New(
 			errs.M("StartProcess: a nil ProcessRegistration isn't allowed"),
@@ -1473,14 +1660,17 @@ func (t *Thresher) StartProcess(reg *ProcessRegistration) (*InstanceHandle, erro
 
 	// launchInstance re-acquires t.m, so reg.snapshot is read lock-free here: a
 	// registration handle is immutable, and its snapshot is frozen (ADR-019).
-	return t.launchInstance(reg.snapshot)
+	return t.startInstance(reg.snapshot, opts)
 }
 
 // StartLatest launches a new instance of the LATEST registered version of the
 // process key, returning its observation handle. It errors if the key is empty
 // or no version is registered for it. This is the "just run the current one"
 // path; hold a ProcessRegistration and use StartProcess to pin an exact version.
-func (t *Thresher) StartLatest(key string) (*InstanceHandle, error) {
+// opts are as for StartProcess.
+func (t *Thresher) StartLatest(
+	key string, opts ...StartOption,
+) (*InstanceHandle, error) {
 	key = strings.TrimSpace(key)
 	if key == "" {
 		return nil, errs.New(
@@ -1502,14 +1692,17 @@ func (t *Thresher) StartLatest(key string) (*InstanceHandle, error) {
 			errs.C(errorClass, errs.ObjectNotFound))
 	}
 
-	return t.launchInstance(s)
+	return t.startInstance(s, opts)
 }
 
 // StartVersion launches a new instance of a SPECIFIC registered version (1-based)
 // of the process key, returning its observation handle. It errors if the key is
 // empty, the version is below 1, or no such key/version is registered. Use it to
 // re-run an older version by its (key, version) without holding its handle.
-func (t *Thresher) StartVersion(key string, version int) (*InstanceHandle, error) {
+// opts are as for StartProcess.
+func (t *Thresher) StartVersion(
+	key string, version int, opts ...StartOption,
+) (*InstanceHandle, error) {
 	key = strings.TrimSpace(key)
 	if key == "" {
 		return nil, errs.New(
@@ -1537,7 +1730,34 @@ func (t *Thresher) StartVersion(key string, version int) (*InstanceHandle, error
 			errs.C(errorClass, errs.ObjectNotFound))
 	}
 
-	return t.launchInstance(s)
+	return t.startInstance(s, opts)
+}
+
+// startInstance applies the start options of a Start* call, validates its
+// start variables against the resolved version s, claims its business key
+// (claimBusinessKey) and launches the instance. A validation failure is
+// returned as is — its class names what was wrong.
+func (t *Thresher) startInstance(
+	s *snapshot.Snapshot, opts []StartOption,
+) (*InstanceHandle, error) {
+	sc, err := applyStartOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	vars, err := startData(s, sc.vars)
+	if err != nil {
+		return nil, err
+	}
+
+	release, err := t.claimBusinessKey(
+		context.Background(), s.ProcessID, sc.businessKey)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return t.launchInstance(s, vars, sc.businessKey)
 }
 
 // ensureStarted returns an InvalidState error unless the engine is Started — the
@@ -1588,19 +1808,27 @@ func (t *Thresher) instanceOptions(settled chan struct{}) []instance.Option {
 	if t.cfg.repoSet {
 		opts = append(opts,
 			instance.WithCheckpointing(t.id, t.group, t.cfg.leaseTTL),
+			instance.WithCheckpointCodec(t.cfg.cpCodec),
+			instance.WithMessageOutbox(t.cfg.outbox),
 			instance.WithWaitHolders(t))
 	}
 
 	return opts
 }
 
-// launchInstance creates a new Instance from the Snapshot s, runs it, appends it
-// to the running instances of the Thresher, and returns its read-only handle.
-func (t *Thresher) launchInstance(s *snapshot.Snapshot) (*InstanceHandle, error) {
+// launchInstance creates a new Instance from the Snapshot s, seeds its root
+// scope with the validated start variables vars (startData), gives it the
+// business key (empty for none), runs it, appends it to the running instances
+// of the Thresher, and returns its read-only handle.
+func (t *Thresher) launchInstance(
+	s *snapshot.Snapshot, vars []data.Data, businessKey string,
+) (*InstanceHandle, error) {
 	settled := make(chan struct{})
 
 	inst, err := instance.New(s, scope.EmptyDataPath, &t.cfg, t, t.taskDist,
-		t.instanceOptions(settled)...)
+		append(t.instanceOptions(settled),
+			instance.WithStartData(vars),
+			instance.WithBusinessKey(businessKey))...)
 	if err != nil {
 		return nil, errs.New(
 			errs.M("couldn't create an Instance for process %q",
@@ -0,0 +1,202 @@
+package thresher
+
+import (
+	"context"
+	"time"
+
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+	"github.com/dr-dobermann/gobpm/pkg/repository"
+)
+
+// timerTakeoversMetric counts the instances an engine claimed to fire a timer
+// another engine of the group left overdue (ADR-002 §8.1 naming). The lag of
+// every fire is waiters.TimerLagMetric.
+const timerTakeoversMetric = "gobpm_timer_takeovers_total"
+
+// maxTakeoverBatch bounds the overdue timers one takeover poll reads; the
+// rest wait for the next. The index leaves out what no takeover fires —
+// suspended and live-leased instances — so those never fill a batch.
+const maxTakeoverBatch = 256
+
+// The group's due-timer index. A held timer lives in the memory of the engine
+// that armed it, so an engine that dies takes its deadlines with it. Over a
+// Repository that implements repository.TimerIndex every hold is also
+// indexed for the group, and every engine polls the index for deadlines
+// overdue by more than the takeover grace. The instance of such a deadline
+// is claimed only when its lease has lapsed — the owner is gone — through
+// the same claim restart recovery makes: a higher incarnation fences the
+// old owner's writes. The restored instance re-arms its timers at their
+// recorded, already-passed deadlines, and the fire that follows is the
+// ordinary one on the claiming engine.
+//
+// An entry leaves the index when its wait ends (ReleaseWaits) or when an
+// engine claims its instance back to residence (runClaimed). An engine
+// going down leaves its entries in place: they are what the rest of the
+// group takes over.
+
+// timerIndex returns the Repository's due-timer index, or nil when the store
+// has none or the engine runs without a Repository.
+func (t *Thresher) timerIndex() repository.TimerIndex {
+	if !t.cfg.repoSet {
+		return nil
+	}
+
+	idx, _ := t.cfg.Repository().(repository.TimerIndex)
+
+	return idx
+}
+
+// indexTimer records a held deadline in the group's index. A failure costs
+// the group its takeover of this timer, never the local fire, so it is
+// logged rather than returned.
+func (t *Thresher) indexTimer(
+	instanceID, trackID, eventID string, deadline time.Time,
+) {
+	idx := t.timerIndex()
+	if idx == nil {
+		return
+	}
+
+	ctx, running := t.engineContext()
+	if !running {
+		return
+	}
+
+	if err := idx.PutTimer(ctx, repository.DueTimer{
+		Deadline:   deadline,
+		Group:      t.group,
+		InstanceID: instanceID,
+		TrackID:    trackID,
+		EventID:    eventID,
+	}); err != nil {
+		t.cfg.logger.Warn("timer not indexed for the engine group",
+			observability.AttrInstanceID, instanceID, observability.AttrTrackID, trackID,
+			observability.AttrError, err.Error())
+	}
+}
+
+// unindexTimers drops a track's deadlines — one of them when eventID is set —
+// from the group's index. A stopping engine keeps them: its instances' waits
+// are not over, and the group takes them over.
+func (t *Thresher) unindexTimers(instanceID, trackID, eventID string) {
+	idx := t.timerIndex()
+	if idx == nil {
+		return
+	}
+
+	ctx, running := t.engineContext()
+	if !running || ctx.Err() != nil {
+		return
+	}
+
+	if err := idx.DeleteTimers(ctx, instanceID, trackID, eventID); err != nil {
+		t.cfg.logger.Warn("timer not dropped from the engine group's index",
+			observability.AttrInstanceID, instanceID, observability.AttrTrackID, trackID,
+			observability.AttrError, err.Error())
+	}
+}
+
+// takeOverTimers polls the group's index for the engine's lifetime. Without
+// an index it does nothing.
+func (t *Thresher) takeOverTimers(ctx context.Context) {
+	idx := t.timerIndex()
+	if idx == nil {
+		return
+	}
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case <-t.cfg.Clock().After(t.cfg.timerPoll):
+				t.takeOverDue(ctx, idx)
+			}
+		}
+	}()
+}
+
+// takeOverDue claims the instances of the group's timers overdue by more
+// than the grace whose owner's lease has lapsed. An instance this engine
+// tracks is its own timer service's to fire; an entry whose instance is gone
+// or finished is dropped.
+func (t *Thresher) takeOverDue(ctx context.Context, idx repository.TimerIndex) {
+	if t.draining.Load() {
+		return
+	}
+
+	now := t.cfg.Clock().Now()
+
+	due, err := idx.DueTimers(ctx, t.group, now.Add(-t.cfg.timerGrace), now,
+		maxTakeoverBatch)
+	if err != nil {
+		t.cfg.logger.Warn("couldn't list the engine group's overdue timers",
+			observability.AttrError, err.Error())
+
+		return
+	}
+
+	seen := map[string]bool{}
+
+	for _, d := range due {
+		if ctx.Err() != nil {
+			return
+		}
+
+		if seen[d.InstanceID] {
+			continue
+		}
+
+		seen[d.InstanceID] = true
+
+		if _, err := t.instanceByID(d.InstanceID); err == nil {
+			continue
+		}
+
+		t.takeOver(ctx, d, now)
+	}
+}
+
+// takeOver claims and rehydrates the instance of one overdue timer.
+func (t *Thresher) takeOver(
+	ctx context.Context, d repository.DueTimer, now time.Time,
+) {
+	rec, ok, err := t.cfg.Repository().Load(ctx, d.InstanceID)
+	if err != nil {
+		t.cfg.logger.Warn("couldn't load the instance of an overdue timer",
+			observability.AttrInstanceID, d.InstanceID, observability.AttrError, err.Error())
+
+		return
+	}
+
+	if !ok || rec.Status.IsTerminal() {
+		t.unindexTimers(d.InstanceID, "", "")
+
+		return
+	}
+
+	// A live lease means a live owner; a suspended instance refuses its
+	// timers until Resume re-arms them.
+	if !rec.Lease.Expired(now) || rec.Status == repository.StatusSuspended {
+		return
+	}
+
+	if err := t.recoverOne(ctx, d.InstanceID); err != nil {
+		t.reportRecoveryFailure(d.InstanceID, err)
+
+		return
+	}
+
+	// recoverOne is silent when another engine won the claim.
+	if _, err := t.instanceByID(d.InstanceID); err != nil {
+		return
+	}
+
+	t.cfg.metrics.Counter(timerTakeoversMetric).Add(ctx, 1,
+		observability.Attr{Key: "engine_id", Value: t.id})
+
+	t.cfg.logger.Info("overdue timer taken over from the engine group",
+		observability.AttrInstanceID, d.InstanceID, observability.AttrTrackID, d.TrackID,
+		"overdue", now.Sub(d.Deadline).String())
+}
@@ -1,7 +1,9 @@
 package thresher
 
 import (
+	"cmp"
 	"context"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -10,6 +12,7 @@ import (
 	"github.com/dr-dobermann/gobpm/pkg/clock"
 	"github.com/dr-dobermann/gobpm/pkg/exec"
 	"github.com/dr-dobermann/gobpm/pkg/model/flow"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
 )
 
 // timerHold is one dehydratable timer's durable firing plan (SRD-071 FR-6): the
@@ -77,6 +80,10 @@ type timerService struct {
 	// still due the instant the wake returns, so the loop would re-fire it
 	// immediately and spin — retrying as fast as it turns (FIX-027 §3.2.1).
 	backoff time.Duration
+	// lag, when set, records how late each timer fired — the time from its
+	// deadline to its successful wake — under lagAttrs.
+	lag      observability.Histogram
+	lagAttrs []observability.Attr
 }
 
 // newTimerService builds the service over clk, waking instances through wake
@@ -174,8 +181,18 @@ func (ts *timerService) releaseOne(instanceID, trackID, eDefID string) {
 // release withdraws EVERY deadline a track holds (the instance terminated, the
 // wait was canceled, or a sibling won an EBG race). Idempotent.
 func (ts *timerService) release(instanceID, trackID string) {
-	prefix := trackPrefix(instanceID, trackID)
+	ts.releasePrefix(trackPrefix(instanceID, trackID))
+}
+
+// releaseInstance withdraws every deadline the instance holds, on all its
+// tracks — an instance handed off to the engine group. Idempotent.
+func (ts *timerService) releaseInstance(instanceID string) {
+	ts.releasePrefix(instanceID + "|")
+}
 
+// releasePrefix withdraws the holds, and cancels the arms in flight, whose
+// key starts with prefix.
+func (ts *timerService) releasePrefix(prefix string) {
 	ts.mu.Lock()
 
 	for k := range ts.holds {
@@ -184,10 +201,10 @@ func (ts *timerService) release(instanceID, trackID string) {
 		}
 	}
 
-	// Cancel any arm still in flight for this track, so a hold that began
-	// before this release cannot land after it (FIX-037 §1.5).
+	// Cancel any arm still in flight for the released tracks, so a hold that
+	// began before this release cannot land after it (FIX-037 §1.5).
 	for token, p := range ts.arming {
-		if p == prefix {
+		if strings.HasPrefix(p, prefix) {
 			delete(ts.arming, token)
 		}
 	}
@@ -264,6 +281,10 @@ func (ts *timerService) run(ctx context.Context) {
 // marked firing for the duration — later scans skip it, so a slow or failing
 // wake is never re-entered — then released on success or deferred on failure.
 //
+// Due holds fire in deadline order, ties broken by hold key, so a batch of
+// overdue deadlines — the ones an instance re-arms when it is resumed after
+// they passed (ADR-033 §2.6) — fires the same way on every run.
+//
 // The wake runs inline on the service goroutine: it rebuilds and continues the
 // instance, so a slow wake serializes the next fire — acceptable for the
 // single-engine scope (SRD-071 §4.2).
@@ -285,6 +306,16 @@ func (ts *timerService) fireDue(ctx context.Context) {
 
 	ts.mu.Unlock()
 
+	slices.SortFunc(due, func(a, b timerHold) int {
+		if c := a.deadline.Compare(b.deadline); c != 0 {
+			return c
+		}
+
+		return cmp.Compare(
+			holdKey(a.instanceID, a.trackID, eDefIDOf(a.eDef)),
+			holdKey(b.instanceID, b.trackID, eDefIDOf(b.eDef)))
+	})
+
 	for _, h := range due {
 		select {
 		case <-ctx.Done():
@@ -308,6 +339,10 @@ func (ts *timerService) fireDue(ctx context.Context) {
 		}
 
 		if ts.wake(h.instanceID, pending) {
+			if ts.lag != nil {
+				ts.lag.Record(ctx, ts.clk.Now().Sub(h.deadline).Seconds(), ts.lagAttrs...)
+			}
+
 			ts.releaseOne(h.instanceID, h.trackID, eDefIDOf(h.eDef))
 
 			continue
@@ -0,0 +1,259 @@
+package thresher_test
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gobpm/pkg/clock/clocktest"
+	"github.com/dr-dobermann/gobpm/pkg/model/process"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+	"github.com/dr-dobermann/gobpm/pkg/observability/memmetrics"
+	"github.com/dr-dobermann/gobpm/pkg/repository"
+	"github.com/dr-dobermann/gobpm/pkg/repository/memrepo"
+	"github.com/dr-dobermann/gobpm/pkg/thresher"
+)
+
+// The group's due-timer index: a timer whose engine died fires on another
+// engine of the group, through a claim of its instance; a timer whose owner
+// still holds the lease is left to it.
+
+// takeoverEngine runs an armed engine of recoveryGroup on clk with the
+// given metrics registry.
+func takeoverEngine(
+	t *testing.T, name string, repo repository.Repository,
+	clk *clocktest.Clock, ttl time.Duration, p *process.Process,
+	metrics *memmetrics.Registry,
+) (*thresher.Thresher, *factWatch) {
+	t.Helper()
+
+	th, err := thresher.New(name,
+		thresher.WithoutBanner(),
+		thresher.WithoutStartupConfig(),
+		thresher.WithRepository(repo),
+		thresher.WithClock(clk),
+		thresher.WithEngineGroup(recoveryGroup),
+		thresher.WithLeaseTTL(ttl),
+		thresher.WithMetricsRecorder(metrics),
+		thresher.WithTimerTakeover(time.Minute, 5*time.Minute))
+	require.NoError(t, err)
+
+	fw := &factWatch{}
+	sub := th.Observe(fw)
+	t.Cleanup(sub.Cancel)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	_, err = th.RegisterProcess(p)
+	require.NoError(t, err)
+	require.NoError(t, th.Run(ctx))
+
+	return th, fw
+}
+
+// dueTimers lists the group's claimable indexed deadlines up to at.
+func dueTimers(t *testing.T, repo *memrepo.Repo, at time.Time) []repository.DueTimer {
+	t.Helper()
+
+	due, err := repo.DueTimers(context.Background(), recoveryGroup, at, at, 0)
+	require.NoError(t, err)
+
+	return due
+}
+
+// TestTimerTakeover: engine-1 arms a timer and dies with its instance
+// dehydrated. engine-2, already running in the group, finds the timer
+// overdue in the index, claims the instance and fires it; the index is
+// empty once the instance completes, and engine-2 counts the takeover and
+// the fire's lag.
+func TestTimerTakeover(t *testing.T) {
+	repo := memrepo.New()
+	deadline := dehydrationEpoch.Add(2 * time.Hour)
+
+	var hit1, hit2 atomic.Bool
+
+	// both engines run from the start; engine-1's clock never moves again —
+	// the engine is dead to the group, its timer service with it.
+	clk1 := clocktest.New(dehydrationEpoch)
+	clk2 := clocktest.New(dehydrationEpoch)
+
+	th1, fw1 := takeoverEngine(t, "engine-1", repo, clk1, time.Minute,
+		longTimerProc(t, "timer-takeover", deadline, &hit1), memmetrics.New())
+
+	metrics := memmetrics.New()
+	_, fw2 := takeoverEngine(t, "engine-2", repo, clk2, time.Minute,
+		longTimerProc(t, "timer-takeover", deadline, &hit2), metrics)
+
+	h, err := th1.StartLatest("timer-takeover")
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return fw1.saw(observability.KindInstanceState,
+			observability.PhaseDehydrated)
+	}, 3*time.Second, 5*time.Millisecond,
+		"engine-1's instance must dehydrate")
+
+	due := dueTimers(t, repo, deadline)
+	require.Len(t, due, 1, "the held timer is indexed for the group")
+	require.Equal(t, h.ID(), due[0].InstanceID)
+	require.True(t, due[0].Deadline.Equal(deadline))
+
+	// the deadline passes, but not the grace: the owner keeps its turn.
+	clk2.Advance(2*time.Hour + time.Minute)
+	require.Never(t, hit2.Load, 200*time.Millisecond, 20*time.Millisecond,
+		"a timer inside the grace stays with its engine")
+
+	clk2.Advance(10 * time.Minute)
+
+	require.Eventually(t, func() bool {
+		return fw2.saw(observability.KindInstanceState,
+			observability.PhaseRecovered)
+	}, 3*time.Second, 5*time.Millisecond,
+		"engine-2 must claim the instance of the overdue timer")
+
+	// the restored timer is overdue and fires at the next tick of
+	// engine-2's clock.
+	require.Eventually(t, func() bool {
+		clk2.Advance(time.Second)
+
+		return hit2.Load()
+	}, 3*time.Second, 10*time.Millisecond,
+		"engine-2 must fire the timer engine-1 left overdue")
+	require.False(t, hit1.Load())
+
+	require.Eventually(t, func() bool {
+		rec, ok, _ := repo.Load(context.Background(), h.ID())
+
+		return ok && rec.Status == repository.StatusCompleted &&
+			rec.Lease.Owner == "engine-2"
+	}, 3*time.Second, 10*time.Millisecond,
+		"the instance completes on engine-2")
+
+	require.Empty(t, dueTimers(t, repo, deadline.Add(time.Hour)),
+		"the fired timer leaves the index")
+
+	snap := metrics.Snapshot()
+	require.Equal(t, 1.0,
+		snap.Counters["gobpm_timer_takeovers_total"]["engine_id=string:engine-2"])
+
+	lag := snap.Histograms["gobpm_timer_lag_seconds"]["holder=string:hub"]
+	require.Equal(t, uint64(1), lag.Count)
+	require.GreaterOrEqual(t, lag.Sum, (5 * time.Minute).Seconds(),
+		"the lag covers the grace the timer waited out")
+}
+
+// TestTimerTakeoverPastSuspended: more suspended instances than a
+// takeover batch hold earlier overdue deadlines; they never reach the
+// listing, so the claimable timer behind them still fires on engine-2.
+func TestTimerTakeoverPastSuspended(t *testing.T) {
+	ctx := context.Background()
+	repo := memrepo.New()
+	deadline := dehydrationEpoch.Add(2 * time.Hour)
+
+	require.NoError(t, repo.RegisterGroup(ctx, recoveryGroup))
+
+	// one batch (maxTakeoverBatch) and then some.
+	for i := range 300 {
+		id := fmt.Sprintf("suspended-%03d", i)
+
+		require.NoError(t, repo.Save(ctx, repository.InstanceRecord{
+			ID:      id,
+			Payload: []byte(`{"schema":1}`),
+			Group:   recoveryGroup,
+			Status:  repository.StatusSuspended,
+		}))
+		require.NoError(t, repo.PutTimer(ctx, repository.DueTimer{
+			Deadline:   deadline.Add(-time.Hour),
+			Group:      recoveryGroup,
+			InstanceID: id,
+			TrackID:    "track",
+			EventID:    "timer",
+		}))
+	}
+
+	var hit1, hit2 atomic.Bool
+
+	clk1 := clocktest.New(dehydrationEpoch)
+	clk2 := clocktest.New(dehydrationEpoch)
+
+	th1, fw1 := takeoverEngine(t, "engine-1", repo, clk1, time.Minute,
+		longTimerProc(t, "timer-starved", deadline, &hit1), memmetrics.New())
+	_, fw2 := takeoverEngine(t, "engine-2", repo, clk2, time.Minute,
+		longTimerProc(t, "timer-starved", deadline, &hit2), memmetrics.New())
+
+	_, err := th1.StartLatest("timer-starved")
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return fw1.saw(observability.KindInstanceState,
+			observability.PhaseDehydrated)
+	}, 3*time.Second, 5*time.Millisecond,
+		"engine-1's instance must dehydrate")
+
+	clk2.Advance(2*time.Hour + 11*time.Minute)
+
+	require.Eventually(t, func() bool {
+		return fw2.saw(observability.KindInstanceState,
+			observability.PhaseRecovered)
+	}, 3*time.Second, 5*time.Millisecond,
+		"the suspended deadlines must not starve the claimable one")
+
+	require.Eventually(t, func() bool {
+		clk2.Advance(time.Second)
+
+		return hit2.Load()
+	}, 3*time.Second, 10*time.Millisecond,
+		"engine-2 must fire the timer behind the suspended ones")
+	require.False(t, hit1.Load())
+}
+
+// TestTimerTakeoverLeavesLiveLease: an overdue timer whose instance is
+// still leased to its engine is not claimed.
+func TestTimerTakeoverLeavesLiveLease(t *testing.T) {
+	repo := memrepo.New()
+	deadline := dehydrationEpoch.Add(2 * time.Hour)
+
+	var hit1, hit2 atomic.Bool
+
+	clk1 := clocktest.New(dehydrationEpoch)
+	clk2 := clocktest.New(dehydrationEpoch)
+
+	th1, fw1 := takeoverEngine(t, "engine-1", repo, clk1, 24*time.Hour,
+		longTimerProc(t, "timer-leased", deadline, &hit1), memmetrics.New())
+	_, fw2 := takeoverEngine(t, "engine-2", repo, clk2, 24*time.Hour,
+		longTimerProc(t, "timer-leased", deadline, &hit2), memmetrics.New())
+
+	_, err := th1.StartLatest("timer-leased")
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return fw1.saw(observability.KindInstanceState,
+			observability.PhaseDehydrated)
+	}, 3*time.Second, 5*time.Millisecond,
+		"engine-1's instance must dehydrate")
+
+	clk2.Advance(3 * time.Hour)
+
+	require.Never(t, hit2.Load, 300*time.Millisecond, 20*time.Millisecond,
+		"a live lease keeps the timer with its engine")
+	require.False(t, fw2.saw(observability.KindInstanceState,
+		observability.PhaseRecovered))
+
+	clk1.Advance(3 * time.Hour)
+
+	require.Eventually(t, hit1.Load, 3*time.Second, 5*time.Millisecond,
+		"the owner fires its own timer")
+}
+
+func TestWithTimerTakeoverRejectsNonPositive(t *testing.T) {
+	for _, d := range [][2]time.Duration{{0, time.Second}, {time.Second, -1}} {
+		_, err := thresher.New("engine-T", thresher.WithTimerTakeover(d[0], d[1]))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "WithTimerTakeover")
+	}
+}
@@ -0,0 +1,138 @@
+package thresher
+
+import (
+	"context"
+
+	"github.com/dr-dobermann/gobpm/internal/instance"
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/model/data"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+)
+
+// SetVariables sets the variables dd in the instance's container scope at
+// scopePath — an operator's fix for a bad value on a stuck instance. An empty
+// scopePath is the process scope; a sub-process scope is addressed by its
+// data path, absolute ("/<process>/sp-<node id>") or relative to the process
+// scope ("sp-<node id>"), and must be open. A name the scope doesn't hold yet
+// is created; one it already resolves keeps its type — a value of another type
+// is refused with TypeCastingError, as a value-less datum is with
+// EmptyNotAllowed. The instance takes isolated copies of dd, so the caller's
+// data may be changed once the call returns.
+//
+// The change is applied on the instance's loop and commits exactly as a
+// node's outputs do: each changed path is reported as a KindDataChange fact
+// with no node, the armed conditional events are re-evaluated against it, and
+// the checkpoint is written. A released instance — dehydrated, suspended or
+// parked on an incident — is rebuilt to apply it. A finished or terminating
+// instance refuses with InvalidState.
+func (h *InstanceHandle) SetVariables(
+	ctx context.Context, scopePath string, dd ...data.Data,
+) error {
+	if len(dd) == 0 {
+		return errs.New(
+			errs.M("SetVariables: no variables to set"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	cp, err := variableCopies(h.ID(), dd)
+	if err != nil {
+		return err
+	}
+
+	return h.submitVariables(ctx, "set variables", scopePath, cp, nil)
+}
+
+// variableCopies returns isolated Ready copies of an operator's data, as
+// startCopy does for start variables. A value-less datum is refused.
+func variableCopies(id string, dd []data.Data) ([]data.Data, error) {
+	out := make([]data.Data, 0, len(dd))
+
+	for _, d := range dd {
+		v := d.Value()
+		if v == nil {
+			return nil, errs.New(
+				errs.M("SetVariables: variable %q has no value", d.Name()),
+				errs.C(errorClass, errs.EmptyNotAllowed),
+				errs.D(observability.AttrInstanceID, id),
+				errs.D(observability.AttrDataName, d.Name()))
+		}
+
+		cp, err := data.ReadyValueParameter(d.Name(), v.Clone())
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, cp)
+	}
+
+	return out, nil
+}
+
+// DeleteVariables removes the variables names from the instance's container
+// scope at scopePath, addressed as for SetVariables. Each name must be held by
+// that scope itself — a variable of an enclosing scope is deleted there — or
+// nothing is removed and the call fails. A deletion is
+// reported as a ValueDeleted KindDataChange fact and re-evaluates the armed
+// conditional events, as SetVariables does.
+func (h *InstanceHandle) DeleteVariables(
+	ctx context.Context, scopePath string, names ...string,
+) error {
+	if len(names) == 0 {
+		return errs.New(
+			errs.M("DeleteVariables: no variables to delete"),
+			errs.C(errorClass, errs.EmptyNotAllowed))
+	}
+
+	return h.submitVariables(ctx, "delete variables", scopePath, nil, names)
+}
+
+// submitVariables delivers a variable change like a Suspend/Resume: a
+// resident instance takes it on its loop, and one whose loop has exited is
+// rebuilt from its checkpoint through the engine first.
+func (h *InstanceHandle) submitVariables(
+	ctx context.Context,
+	op, scopePath string,
+	dd []data.Data,
+	deleted []string,
+) error {
+	inst := h.current()
+
+	switch inst.State() {
+	case instance.Completed, instance.Terminating, instance.Terminated:
+		return errs.New(
+			errs.M("can't %s of instance %q: it is %s",
+				op, inst.ID(), inst.State()),
+			errs.C(errorClass, errs.InvalidState),
+			errs.D(observability.AttrInstanceID, inst.ID()))
+	}
+
+	delivered, err := inst.SubmitVariables(ctx, scopePath, dd, deleted)
+	if err != nil || delivered {
+		return err
+	}
+
+	if h.th == nil {
+		return errs.New(
+			errs.M("%s on a parked instance needs its engine", op),
+			errs.C(errorClass, errs.InvalidState))
+	}
+
+	return h.th.wakeForVariables(ctx, h, op, scopePath, dd, deleted)
+}
+
+// wakeForVariables applies a variable change to an instance whose loop has
+// exited: the change rides a rebuild exactly as an incident operation does. A
+// suspended instance comes back suspended, still holding the messages and
+// signals its checkpoint records for Resume.
+func (t *Thresher) wakeForVariables(
+	ctx context.Context,
+	h *InstanceHandle,
+	op, scopePath string,
+	dd []data.Data,
+	deleted []string,
+) error {
+	resp := make(chan error, 1)
+
+	return t.rebuildForOp(ctx, h, op, resp,
+		instance.WithPendingVariables(scopePath, dd, deleted, resp))
+}
@@ -0,0 +1,297 @@
+package thresher_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gobpm/pkg/errs"
+	"github.com/dr-dobermann/gobpm/pkg/model/activities"
+	"github.com/dr-dobermann/gobpm/pkg/model/data"
+	"github.com/dr-dobermann/gobpm/pkg/model/data/values"
+	"github.com/dr-dobermann/gobpm/pkg/model/events"
+	"github.com/dr-dobermann/gobpm/pkg/model/flow"
+	"github.com/dr-dobermann/gobpm/pkg/model/foundation"
+	"github.com/dr-dobermann/gobpm/pkg/model/process"
+	"github.com/dr-dobermann/gobpm/pkg/observability"
+	"github.com/dr-dobermann/gobpm/pkg/repository/memrepo"
+	"github.com/dr-dobermann/gobpm/pkg/thresher"
+)
+
+// variable builds the datum name=v an operator sets.
+func variable(name string, v any) data.Data {
+	return data.MustParameter(name,
+		data.MustItemAwareElement(
+			data.MustItemDefinition(values.NewVariable(v),
+				foundation.WithID(name)),
+			data.ReadyDataState))
+}
+
+// dataChanges lists the DataChange facts fw saw as "<phase>:<path>:<node id>".
+func (fw *factWatch) dataChanges() []string {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	var cc []string
+
+	for _, f := range fw.facts {
+		if f.Kind == observability.KindDataChange {
+			cc = append(cc, string(f.Phase)+":"+
+				f.Details[observability.AttrDataPath]+":"+f.NodeID)
+		}
+	}
+
+	return cc
+}
+
+// condWaitProcess builds start → catch[total>100] → notify → end over the
+// property total=10: only an operator's change releases the catch.
+func condWaitProcess(t *testing.T, notify *atomic.Bool) *process.Process {
+	t.Helper()
+
+	require.NoError(t, data.CreateDefaultStates())
+
+	proc, err := process.New("vars-cond",
+		data.WithProperties(
+			data.MustProperty("total",
+				data.MustItemDefinition(values.NewVariable(10),
+					foundation.WithID("total")),
+				data.ReadyDataState)))
+	require.NoError(t, err)
+
+	start, err := events.NewStartEvent("start")
+	require.NoError(t, err)
+
+	catch, err := events.NewIntermediateCatchEvent("watch-total",
+		events.MustConditionalEventDefinition(watchGt(t, "total", 100)))
+	require.NoError(t, err)
+
+	nTask := laneTask(t, "notify", notify)
+
+	end, err := events.NewEndEvent("end")
+	require.NoError(t, err)
+
+	for _, e := range []flow.Element{start, catch, nTask, end} {
+		require.NoError(t, proc.Add(e))
+	}
+
+	link(t, start, catch)
+	link(t, catch, nTask)
+	link(t, nTask, end)
+
+	return proc
+}
+
+// TestSetVariablesReleasesConditional verifies an operator's change commits
+// as a node-less DataChange fact and re-evaluates the armed conditional catch,
+// which the change releases.
+func TestSetVariablesReleasesConditional(t *testing.T) {
+	var notify atomic.Bool
+
+	proc := condWaitProcess(t, &notify)
+
+	th, err := thresher.New("test-vars-cond", thresher.WithoutBanner())
+	require.NoError(t, err)
+
+	fw := &factWatch{}
+	sub := th.Observe(fw)
+	t.Cleanup(sub.Cancel)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	require.NoError(t, th.Run(ctx))
+
+	_, err = th.RegisterProcess(proc)
+	require.NoError(t, err)
+
+	h, err := th.StartLatest(proc.ID())
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		tt := h.Tokens()
+
+		return len(tt) == 1 && tt[0].NodeName == "watch-total" &&
+			tt[0].State == thresher.TokenWaitForEvent
+	}, 2*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, h.SetVariables(ctx, "", variable("total", 50)))
+	require.False(t, notify.Load(), "50 doesn't satisfy the condition")
+
+	require.NoError(t, h.SetVariables(ctx, "", variable("total", 150)))
+
+	wctx, wcancel := context.WithTimeout(ctx, 3*time.Second)
+	defer wcancel()
+
+	st, err := h.WaitCompletion(wctx)
+	require.NoError(t, err)
+	require.Equal(t, thresher.StateCompleted, st)
+	require.True(t, notify.Load())
+
+	require.Equal(t, []string{"Value_Updated:total:", "Value_Updated:total:"},
+		fw.dataChanges())
+}
+
+// TestSetAndDeleteVariables verifies a set creates and a delete removes a
+// variable of a resident instance, and the refusals: nothing to change, an
+// unknown scope or name, and a finished instance.
+func TestSetAndDeleteVariables(t *testing.T) {
+	require.NoError(t, data.CreateDefaultStates())
+
+	proc := userTaskProcess(t, "vars-set")
+	th, cancel := runEngine(t, proc)
+	defer cancel()
+
+	h, err := th.StartLatest(proc.ID())
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	require.NoError(t, h.SetVariables(ctx, "", variable("note", "fixed")))
+
+	d, err := h.Data().GetData("note")
+	require.NoError(t, err)
+	require.Equal(t, "fixed", d.Value().Get(ctx))
+
+	require.NoError(t, h.DeleteVariables(ctx, "", "note"))
+
+	_, err = h.Data().GetData("note")
+	require.Error(t, err)
+
+	// nothing of the failed calls below is applied
+	require.ErrorContains(t, h.DeleteVariables(ctx, "", "note"),
+		`holds no data "note"`)
+	require.Error(t, h.SetVariables(ctx, "sp-ghost", variable("note", "x")))
+	requireClass(t, h.SetVariables(ctx, ""), errs.EmptyNotAllowed)
+	requireClass(t, h.DeleteVariables(ctx, ""), errs.EmptyNotAllowed)
+
+	_, err = h.Data().GetData("note")
+	require.Error(t, err)
+
+	cctx, ccancel := context.WithTimeout(ctx, 3*time.Second)
+	defer ccancel()
+
+	_, err = h.Cancel(cctx)
+	require.NoError(t, err)
+
+	requireClass(t, h.SetVariables(ctx, "", variable("note", "x")),
+		errs.InvalidState)
+}
+
+// TestSetVariablesIsolatesAndTypeChecks verifies the instance keeps its own
+// copy of an operator's datum — the caller's later change doesn't reach it —
+// and refuses a value whose type differs from the variable's.
+func TestSetVariablesIsolatesAndTypeChecks(t *testing.T) {
+	require.NoError(t, data.CreateDefaultStates())
+
+	proc := userTaskProcess(t, "vars-isolated")
+	th, cancel := runEngine(t, proc)
+	defer cancel()
+
+	h, err := th.StartLatest(proc.ID())
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	note := variable("note", "fixed")
+	require.NoError(t, h.SetVariables(ctx, "", note))
+	require.NoError(t, note.Value().Update(ctx, "tampered"))
+
+	d, err := h.Data().GetData("note")
+	require.NoError(t, err)
+	require.Equal(t, "fixed", d.Value().Get(ctx),
+		"the caller's change after the call doesn't reach the instance")
+
+	requireClass(t, h.SetVariables(ctx, "", variable("note", 42)),
+		errs.TypeCastingError)
+
+	d, err = h.Data().GetData("note")
+	require.NoError(t, err)
+	require.Equal(t, "fixed", d.Value().Get(ctx), "a refused set changes nothing")
+}
+
+// varsProc is utProc with the process property priority=1.
+func varsProc(t *testing.T, key string) *process.Process {
+	t.Helper()
+
+	require.NoError(t, data.CreateDefaultStates())
+
+	p, err := process.New(key, foundation.WithID(key),
+		data.WithProperties(
+			data.MustProperty("priority",
+				data.MustItemDefinition(values.NewVariable(1),
+					foundation.WithID("priority")),
+				data.ReadyDataState)))
+	require.NoError(t, err)
+
+	start, err := events.NewStartEvent("start")
+	require.NoError(t, err)
+
+	ut, err := activities.NewUserTask("approve",
+		activities.WithCandidateUsers("operator"),
+		activities.WithOutput("result", "string", true),
+		activities.WithoutParams())
+	require.NoError(t, err)
+
+	end, err := events.NewEndEvent("end")
+	require.NoError(t, err)
+
+	for _, e := range []flow.Element{start, ut, end} {
+		require.NoError(t, p.Add(e))
+	}
+
+	link(t, start, ut)
+	link(t, ut, end)
+
+	return p
+}
+
+// TestVariablesOnDehydratedInstance verifies a change to a dehydrated instance
+// hydrates it, applies and persists the change and lets it release again — a
+// deleted process property stays deleted across the next rebuild.
+func TestVariablesOnDehydratedInstance(t *testing.T) {
+	repo := memrepo.New()
+	dist := &annCollector{}
+	p := varsProc(t, "vars-dehy")
+
+	th, fw, cancel := bootTaskEngine(t, "engine-V", repo, dist, p)
+	defer cancel()
+
+	h, err := th.StartLatest(p.ID())
+	require.NoError(t, err)
+
+	dehydrated := func(n int) func() bool {
+		return func() bool {
+			return fw.count(observability.KindInstanceState,
+				observability.PhaseDehydrated) >= n
+		}
+	}
+
+	require.Eventually(t, dehydrated(1), 3*time.Second, 10*time.Millisecond)
+
+	ctx := context.Background()
+
+	require.NoError(t, h.SetVariables(ctx, "", variable("note", "fixed")))
+	require.Eventually(t, dehydrated(2), 3*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, h.DeleteVariables(ctx, "", "priority"))
+	require.Eventually(t, dehydrated(3), 3*time.Second, 10*time.Millisecond)
+
+	// Take hydrates the instance from the checkpoints the changes wrote.
+	taskID := dist.taskIDs()[0]
+	_, err = th.Take(ctx, taskID, utActor{id: "operator"})
+	require.NoError(t, err)
+
+	d, err := h.Data().GetData("note")
+	require.NoError(t, err)
+	require.Equal(t, "fixed", d.Value().Get(ctx))
+
+	_, err = h.Data().GetData("priority")
+	require.Error(t, err, "the deleted property isn't re-seeded")
+
+	require.Equal(t, []string{"Value_Added:note:", "Value_Deleted:priority:"},
+		fw.dataChanges())
+}
@@ -2,6 +2,7 @@ package thresher
 
 import (
 	"context"
+	"errors"
 	"strconv"
 	"time"
 
@@ -12,7 +13,6 @@ import (
 	"github.com/dr-dobermann/gobpm/pkg/repository"
 
 	"github.com/dr-dobermann/gobpm/internal/instance"
-	"github.com/dr-dobermann/gobpm/internal/instance/checkpoint"
 	"github.com/dr-dobermann/gobpm/internal/scope"
 )
 
@@ -40,6 +40,11 @@ func (t *Thresher) HoldTimer(
 	// deadline for the wait it had just canceled.
 	token := t.timerSvc.beginArm(instanceID, trackID)
 
+	// Index the deadline for the group BEFORE holding it, and take the entry
+	// back when the hold is refused, so an entry never outlives a release
+	// the way a hold landing after one would.
+	t.indexTimer(instanceID, trackID, eDefIDOf(eDef), deadline)
+
 	if !t.timerSvc.hold(timerHold{
 		instanceID: instanceID,
 		trackID:    trackID,
@@ -48,6 +53,7 @@ func (t *Thresher) HoldTimer(
 		cycles:     cycles,
 		kind:       kind,
 	}, token) {
+		t.unindexTimers(instanceID, trackID, eDefIDOf(eDef))
 		t.reportRefusedArm(instanceID, trackID)
 	}
 
@@ -72,13 +78,31 @@ func (t *Thresher) hydrateFromTimer(
 	instanceID string, pending *instance.PendingTrigger,
 ) bool {
 	err := t.wakeInstance(instanceID, pending)
-	if err != nil {
-		t.reportWakeFailure(instanceID, err)
+	if err == nil {
+		return true
+	}
 
-		return false
+	// A suspended instance re-arms the deadline when it resumes, so the
+	// service lets go of it instead of retrying against the suspension.
+	var ae *gerrs.ApplicationError
+	if errors.As(err, &ae) && ae.HasClass(suspendedClass) {
+		t.cfg.logger.Debug("timer refused — the instance is suspended",
+			observability.AttrInstanceID, instanceID)
+
+		return true
+	}
+
+	// An instance another engine took over fires its timers there.
+	if errors.As(err, &ae) && ae.HasClass(ownedElsewhereClass) {
+		t.cfg.logger.Debug("timer dropped — another engine owns the instance",
+			observability.AttrInstanceID, instanceID)
+
+		return true
 	}
 
-	return true
+	t.reportWakeFailure(instanceID, err)
+
+	return false
 }
 
 // wakeInstance forks on residency (ADR-007 v.2 §2.4): a RESIDENT instance still
@@ -104,15 +128,31 @@ func (t *Thresher) wakeInstanceAttempt(
 	// reports whether the loop took it; if it did not, fall through and wake the
 	// instance from its checkpoint — the trigger is never lost to that race
 	// (SRD-071 NFR-1).
-	if inst, err := t.instanceByID(instanceID); err == nil &&
-		inst.State() != instance.Dehydrated {
-		delivered, err := inst.WakeParkedTrack(pending.TrackID, pending.EDef)
-		if err != nil {
-			return err
+	var hold instance.Option
+
+	if inst, err := t.instanceByID(instanceID); err == nil {
+		if !inst.Released() {
+			delivered, err := inst.WakeParkedTrack(pending.TrackID, pending.EDef)
+			if err != nil {
+				return err
+			}
+
+			if delivered {
+				return nil
+			}
 		}
 
-		if delivered {
-			return nil
+		// A suspended instance that let its goroutines go is not woken by a
+		// trigger (ADR-033 §2.6): it refuses a timer, whose deadline re-arms
+		// from its checkpoint, and a message or signal rebuilds it still
+		// suspended, holding the trigger in its checkpoint for Resume.
+		if inst.State() == instance.Suspended {
+			opt, err := holdForResume(inst, pending)
+			if err != nil {
+				return err
+			}
+
+			hold = opt
 		}
 	}
 
@@ -120,6 +160,10 @@ func (t *Thresher) wakeInstanceAttempt(
 	if claimed {
 		defer t.releaseWake(instanceID)
 
+		if hold != nil {
+			return t.rebuildAndContinue(instanceID, nil, hold)
+		}
+
 		return t.rebuildAndContinue(instanceID, pending)
 	}
 
@@ -163,12 +207,16 @@ func (t *Thresher) rebuildAndContinue(
 		return t.errEngineNotRunning("rebuildAndContinue")
 	}
 
+	if t.draining.Load() {
+		return t.errEngineDraining("rebuildAndContinue")
+	}
+
 	rec, err := t.claimForWake(ctx, instanceID)
 	if err != nil {
 		return err
 	}
 
-	doc, err := checkpoint.Unmarshal(rec.Payload)
+	doc, err := t.openCheckpoint(ctx, rec.ID, rec.Payload)
 	if err != nil {
 		return wakeErr("the checkpoint doesn't decode", err)
 	}
@@ -185,12 +233,20 @@ func (t *Thresher) rebuildAndContinue(
 		instance.WithCallReattacher(t.reattachChild),
 		instance.WithWaitHolders(t),
 		instance.WithCheckpointing(t.id, t.group, t.cfg.leaseTTL),
+		instance.WithCheckpointCodec(t.cfg.cpCodec),
+		instance.WithMessageOutbox(t.cfg.outbox),
 		instance.WithCheckpointCursor(rec.RecVersion, rec.Lease.Incarnation),
 	}, extra...)
 
 	inst, err := instance.Restore(doc, s, scope.EmptyDataPath, &t.cfg, t,
 		t.taskDist, pending, opts...)
 	if err != nil {
+		// a benign refusal keeps its class on top, where the caller
+		// classifies it.
+		if benignDrop(err) {
+			return err
+		}
+
 		return wakeErr("the instance doesn't rebuild", err)
 	}
 
@@ -229,6 +285,7 @@ func (t *Thresher) rebuildAndContinue(
 	// A hydrated conversation re-takes its correlation reservation, for the
 	// same reason a recovered one does (FIX-036 §1.2).
 	t.rebindKeysLocked(doc.ProcessID, instanceID, doc.ConvKeys)
+	t.rebindMessagesLocked(doc.ProcessID, doc.AcceptedMessages)
 
 	inst.Report(observability.Fact{
 		Kind:  observability.KindInstanceState,
@@ -289,6 +346,10 @@ func wakeTriggerLabel(pending *instance.PendingTrigger) string {
 	return string(pending.EDef.Type())
 }
 
+// ownedElsewhereClass marks a wake refused because another engine of the
+// group holds the instance's live lease.
+const ownedElsewhereClass = "INSTANCE_OWNED_ELSEWHERE"
+
 // wakeClaimAttempts bounds the CAS retry below — a couple of rounds absorb the
 // dehydration write; more would mean something else is fighting for the record.
 const wakeClaimAttempts = 3
@@ -335,10 +396,21 @@ func (t *Thresher) claimForWake(
 					strconv.Quote(t.group), nil)
 		}
 
+		// Another engine holding a LIVE lease took the instance over — the
+		// group fired an overdue timer of it. Re-claiming would fence that
+		// engine mid-run; the instance is no longer this engine's to wake.
+		now := t.cfg.Clock().Now()
+		if rec.Lease.Owner != t.id && !rec.Lease.Expired(now) {
+			return repository.InstanceRecord{}, gerrs.New(
+				gerrs.M("the instance is owned by engine %q", rec.Lease.Owner),
+				gerrs.C(errorClass, gerrs.InvalidState, ownedElsewhereClass),
+				gerrs.D(observability.AttrInstanceID, instanceID))
+		}
+
 		rec.Lease = repository.Lease{
 			Owner:       t.id,
 			Incarnation: rec.Lease.Incarnation + 1,
-			Expiry:      t.cfg.Clock().Now().Add(t.cfg.leaseTTL),
+			Expiry:      now.Add(t.cfg.leaseTTL),
 		}
 
 		if err := repo.Save(ctx, rec); err != nil {
@@ -160,6 +160,45 @@ func TestTimerServiceReleaseAndIdle(t *testing.T) {
 	require.Equal(t, []string{"i-1"}, woke, "a fired hold is one-shot")
 }
 
+// TestTimerServiceFiresOverdueInOrder: a batch of overdue holds — what a
+// resumed instance re-arms after its deadlines passed — fires by deadline, ties
+// broken by hold key, whatever the map order.
+func TestTimerServiceFiresOverdueInOrder(t *testing.T) {
+	clk := clocktest.New(wakeEpoch)
+
+	var woke []string
+
+	ts := newTimerService(clk, DefaultWakeRetryBackoff,
+		func(id string, p *instance.PendingTrigger) bool {
+			woke = append(woke, id+"/"+p.TrackID)
+
+			return true
+		})
+
+	holds := []struct {
+		inst, track string
+		after       time.Duration
+	}{
+		{"i-3", "t-1", 20 * time.Minute},
+		{"i-1", "t-2", 10 * time.Minute},
+		{"i-2", "t-1", 30 * time.Minute},
+		{"i-1", "t-1", 10 * time.Minute},
+	}
+
+	for _, h := range holds {
+		ts.hold(timerHold{
+			instanceID: h.inst, trackID: h.track,
+			deadline: wakeEpoch.Add(h.after),
+		}, ts.beginArm(h.inst, h.track))
+	}
+
+	clk.Set(wakeEpoch.Add(time.Hour))
+	ts.fireDue(context.Background())
+
+	require.Equal(t,
+		[]string{"i-1/t-1", "i-1/t-2", "i-3/t-1", "i-2/t-1"}, woke)
+}
+
 // TestTimerServiceRunStops: run returns when its context is canceled, and a
 // canceled context stops fireDue mid-batch.
 func TestTimerServiceRunStops(t *testing.T) {